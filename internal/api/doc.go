@@ -0,0 +1,3 @@
+// Package api serves the read-only Store API as JSON over HTTP for local
+// dashboards and scripts.
+package api
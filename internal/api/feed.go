@@ -0,0 +1,88 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+	"github.com/KrauseFx/beeper-cli/internal/export"
+)
+
+// defaultFeedLimit is the number of newest messages in a feed unless
+// ?limit= says otherwise.
+const defaultFeedLimit = 50
+
+func (h *handler) threadFeed(w http.ResponseWriter, r *http.Request) {
+	threadID, ok := h.resolveThread(w, r)
+	if !ok {
+		return
+	}
+	q := query{values: r.URL.Query()}
+	opts := beeper.MessageListOptions{ThreadID: threadID, Limit: q.int("limit"), Format: beeper.FormatPlain}
+	if q.get("format") != "" {
+		opts.Format = q.format()
+	}
+	if opts.Limit == 0 {
+		opts.Limit = defaultFeedLimit
+	}
+	if q.err != nil {
+		writeError(w, http.StatusBadRequest, q.err)
+		return
+	}
+	thread, err := h.store.GetThread(r.Context(), threadID, false)
+	if err != nil {
+		writeResult(w, nil, err)
+		return
+	}
+	messages, err := h.store.ListMessages(r.Context(), opts)
+	if err != nil {
+		writeResult(w, nil, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	_ = export.WriteAtom(w, export.Feed{Thread: thread, Messages: messages, SelfURL: requestURL(r, r.URL.EscapedPath())})
+}
+
+// feedList lists the feeds of the threads /threads would return for the
+// same filters.
+func (h *handler) feedList(w http.ResponseWriter, r *http.Request) {
+	q := query{values: r.URL.Query()}
+	opts := q.threadListOptions()
+	if q.err != nil {
+		writeError(w, http.StatusBadRequest, q.err)
+		return
+	}
+	threads, err := h.store.ListThreads(r.Context(), opts)
+	if err != nil {
+		writeResult(w, nil, err)
+		return
+	}
+	links := make([]export.FeedLink, 0, len(threads))
+	for _, thread := range threads {
+		links = append(links, export.FeedLink{
+			Title: thread.DisplayName,
+			URL:   requestURL(r, "/threads/"+url.PathEscape(thread.ID)+"/feed"),
+		})
+	}
+	w.Header().Set("Content-Type", "text/x-opml; charset=utf-8")
+	_ = export.WriteOPML(w, "Beeper threads", links)
+}
+
+// requestURL returns the absolute URL of an escaped path on the server r
+// was sent to, keeping a ?token= the feed reader authenticated with.
+func requestURL(r *http.Request, escapedPath string) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	u := scheme + "://" + r.Host + escapedPath
+	if token := r.URL.Query().Get("token"); token != "" {
+		u += "?" + url.Values{"token": {token}}.Encode()
+	}
+	return u
+}
+
+func isFeedPath(path string) bool {
+	return path == "/feeds.opml" || strings.HasSuffix(path, "/feed")
+}
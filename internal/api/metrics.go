@@ -0,0 +1,53 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+)
+
+// metricsContentType is the Prometheus text exposition format.
+const metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func (h *handler) metrics(w http.ResponseWriter, r *http.Request) {
+	gauges, err := h.store.Gauges(r.Context(), time.Now())
+	if err != nil {
+		writeResult(w, nil, err)
+		return
+	}
+	w.Header().Set("Content-Type", metricsContentType)
+	_ = writeMetrics(w, gauges)
+}
+
+// writeMetrics renders g in the Prometheus text format.
+func writeMetrics(w io.Writer, g beeper.Gauges) error {
+	var b strings.Builder
+	gauge := func(name string, help string, value any) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n%s %v\n", name, help, name, name, value)
+	}
+	gauge("beeper_threads", "Threads in index.db.", g.Threads)
+	gauge("beeper_unread_threads", "Unread threads, not counting low-priority ones.", g.UnreadThreads)
+	gauge("beeper_unread_messages", "Unread messages in unread threads.", g.UnreadMessages)
+	gauge("beeper_unread_mentions", "Unread mentions in unread threads.", g.UnreadMentions)
+
+	b.WriteString("# HELP beeper_messages_last_hour Messages in the last hour per account.\n# TYPE beeper_messages_last_hour gauge\n")
+	for _, c := range g.LastHour {
+		account := labelEscaper.Replace(c.AccountID)
+		fmt.Fprintf(&b, "beeper_messages_last_hour{account=\"%s\",direction=\"received\"} %d\n", account, c.Received)
+		fmt.Fprintf(&b, "beeper_messages_last_hour{account=\"%s\",direction=\"sent\"} %d\n", account, c.Sent)
+	}
+
+	if !g.LastMessage.IsZero() {
+		gauge("beeper_last_message_timestamp_seconds", "Time of the newest message, in seconds since the epoch.", g.LastMessage.Unix())
+	}
+	gauge("beeper_db_size_bytes", "Size of index.db and its WAL.", g.DBSize)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,421 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+)
+
+// Store is the part of *beeper.Store served over HTTP.
+type Store interface {
+	ListThreads(ctx context.Context, opts beeper.ThreadListOptions) ([]beeper.Thread, error)
+	GetThread(ctx context.Context, threadID string, withStats bool) (beeper.Thread, error)
+	ResolveThread(ctx context.Context, query string) (beeper.Thread, error)
+	ListMessages(ctx context.Context, opts beeper.MessageListOptions) ([]beeper.Message, error)
+	SearchMessages(ctx context.Context, opts beeper.SearchOptions) ([]beeper.SearchResult, error)
+	ChangesSince(ctx context.Context, opts beeper.ChangeOptions) (beeper.ChangeSet, error)
+	Gauges(ctx context.Context, now time.Time) (beeper.Gauges, error)
+}
+
+// Options configures the HTTP handler.
+type Options struct {
+	// Token, when set, must be sent as "Authorization: Bearer <token>".
+	// Without it only requests addressed to a loopback host are served.
+	Token string
+	// AllowOrigin, when set, is returned as Access-Control-Allow-Origin so
+	// a dashboard served elsewhere can call the API from the browser. It
+	// is ignored without a Token, and "*" is never allowed: otherwise any
+	// page open in the browser could read the API.
+	AllowOrigin string
+}
+
+// NewHandler returns the API routes:
+//
+//	GET /threads
+//	GET /threads/{id}
+//	GET /threads/{id}/messages
+//	GET /threads/{id}/changes?cursor=...
+//	GET /search?q=...
+//	GET /threads/{id}/feed
+//	GET /feeds.opml
+//	GET /metrics
+//
+// {id} is a thread reference as Store.ResolveThread takes it: a room ID
+// (URL-escaped), a room alias, or a name matching exactly one thread.
+// Without a token, requests whose Host is not a loopback name or address
+// get 403, so web pages cannot read the API through DNS rebinding. Errors
+// are returned as {"error": "..."}. The feed routes serve Atom and OPML for
+// feed readers, which also accept the token as ?token= since most cannot
+// send headers. /metrics serves gauges in the Prometheus
+// text format. /changes returns what changed in a thread since the cursor
+// of the previous call, for sync tools that poll.
+func NewHandler(store Store, opts Options) http.Handler {
+	h := &handler{store: store}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /threads", h.listThreads)
+	mux.HandleFunc("GET /threads/{id}", h.getThread)
+	mux.HandleFunc("GET /threads/{id}/messages", h.listMessages)
+	mux.HandleFunc("GET /threads/{id}/changes", h.listChanges)
+	mux.HandleFunc("GET /search", h.search)
+	mux.HandleFunc("GET /threads/{id}/feed", h.threadFeed)
+	mux.HandleFunc("GET /feeds.opml", h.feedList)
+	mux.HandleFunc("GET /metrics", h.metrics)
+	mux.HandleFunc("/", func(w http.ResponseWriter, _ *http.Request) {
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+	})
+	origin := opts.AllowOrigin
+	if opts.Token == "" || origin == "*" {
+		origin = ""
+	}
+	return withCORS(origin, withAuth(opts.Token, mux))
+}
+
+// IsLoopback reports whether host, without a port, is localhost or a
+// loopback address.
+func IsLoopback(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(strings.TrimSuffix(strings.TrimPrefix(host, "["), "]"))
+	return ip != nil && ip.IsLoopback()
+}
+
+type handler struct {
+	store Store
+}
+
+func (h *handler) listThreads(w http.ResponseWriter, r *http.Request) {
+	q := query{values: r.URL.Query()}
+	opts := q.threadListOptions()
+	opts.WithParticipants = q.bool("participants")
+	if q.err != nil {
+		writeError(w, http.StatusBadRequest, q.err)
+		return
+	}
+	threads, err := h.store.ListThreads(r.Context(), opts)
+	writeResult(w, threads, err)
+}
+
+func (h *handler) getThread(w http.ResponseWriter, r *http.Request) {
+	threadID, ok := h.resolveThread(w, r)
+	if !ok {
+		return
+	}
+	thread, err := h.store.GetThread(r.Context(), threadID, true)
+	writeResult(w, thread, err)
+}
+
+func (h *handler) listMessages(w http.ResponseWriter, r *http.Request) {
+	threadID, ok := h.resolveThread(w, r)
+	if !ok {
+		return
+	}
+	q := query{values: r.URL.Query()}
+	opts := beeper.MessageListOptions{
+		ThreadID:       threadID,
+		Limit:          q.int("limit"),
+		All:            q.bool("all"),
+		After:          q.time("after", false),
+		Before:         q.time("before", true),
+		Format:         q.format(),
+		WithProvenance: q.bool("provenance"),
+	}
+	if q.err != nil {
+		writeError(w, http.StatusBadRequest, q.err)
+		return
+	}
+	messages, err := h.store.ListMessages(r.Context(), opts)
+	writeResult(w, messages, err)
+}
+
+func (h *handler) listChanges(w http.ResponseWriter, r *http.Request) {
+	threadID, ok := h.resolveThread(w, r)
+	if !ok {
+		return
+	}
+	q := query{values: r.URL.Query()}
+	opts := beeper.ChangeOptions{
+		ThreadID: threadID,
+		Cursor:   q.values.Get("cursor"),
+		Limit:    q.int("limit"),
+		Format:   q.format(),
+	}
+	if q.err != nil {
+		writeError(w, http.StatusBadRequest, q.err)
+		return
+	}
+	changes, err := h.store.ChangesSince(r.Context(), opts)
+	writeResult(w, changes, err)
+}
+
+func (h *handler) search(w http.ResponseWriter, r *http.Request) {
+	q := query{values: r.URL.Query()}
+	text := strings.TrimSpace(q.values.Get("q"))
+	if text == "" {
+		writeError(w, http.StatusBadRequest, errors.New("query parameter q is required"))
+		return
+	}
+	opts := beeper.SearchOptions{
+		Query:          text,
+		Days:           q.int("days"),
+		Limit:          q.int("limit"),
+		AccountID:      q.values.Get("account"),
+		Context:        q.int("context"),
+		Window:         q.duration("window"),
+		Format:         q.format(),
+		WithProvenance: q.bool("provenance"),
+		Type:           q.values.Get("type"),
+	}
+	if q.err != nil {
+		writeError(w, http.StatusBadRequest, q.err)
+		return
+	}
+	if ref := q.values.Get("thread"); ref != "" {
+		threadID, err := h.findThread(r.Context(), ref)
+		if err != nil {
+			writeThreadError(w, err)
+			return
+		}
+		opts.ThreadID = threadID
+	}
+	results, err := h.store.SearchMessages(r.Context(), opts)
+	writeResult(w, results, err)
+}
+
+// resolveThread reads {id} and writes a 404 if it names no single thread.
+func (h *handler) resolveThread(w http.ResponseWriter, r *http.Request) (string, bool) {
+	threadID, err := h.findThread(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeThreadError(w, err)
+		return "", false
+	}
+	return threadID, true
+}
+
+// findThread resolves ref like the CLI does. Unlike the CLI, which keeps
+// rooms missing from the threads table reachable by ID, it only returns
+// threads that exist.
+func (h *handler) findThread(ctx context.Context, ref string) (string, error) {
+	thread, err := h.store.ResolveThread(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	if _, err := h.store.GetThread(ctx, thread.ID, false); err != nil {
+		return "", err
+	}
+	return thread.ID, nil
+}
+
+// writeThreadError answers a failed thread lookup: 404 when the reference
+// names no single thread, 500 otherwise.
+func writeThreadError(w http.ResponseWriter, err error) {
+	var ambiguous *beeper.AmbiguousThreadError
+	if errors.Is(err, beeper.ErrThreadNotFound) || errors.Is(err, sql.ErrNoRows) || errors.As(err, &ambiguous) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	writeError(w, http.StatusInternalServerError, err)
+}
+
+// query parses URL parameters, remembering the first error.
+type query struct {
+	values url.Values
+	err    error
+}
+
+func (q *query) get(name string) string {
+	if v := q.values[name]; len(v) > 0 {
+		return strings.TrimSpace(v[0])
+	}
+	return ""
+}
+
+func (q *query) fail(err error) {
+	if q.err == nil {
+		q.err = err
+	}
+}
+
+func (q *query) int(name string) int {
+	value := q.get(name)
+	if value == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		q.fail(fmt.Errorf("invalid %s %q: expected a non-negative integer", name, value))
+	}
+	return n
+}
+
+func (q *query) bool(name string) bool {
+	value := q.get(name)
+	if value == "" {
+		return false
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		q.fail(fmt.Errorf("invalid %s %q: expected true or false", name, value))
+	}
+	return b
+}
+
+func (q *query) duration(name string) time.Duration {
+	value := q.get(name)
+	if value == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		q.fail(fmt.Errorf("invalid %s %q: expected a duration like 60m", name, value))
+	}
+	return d
+}
+
+// time accepts YYYY-MM-DD or RFC 3339; with endOfDay a plain date means
+// the end of that day.
+func (q *query) time(name string, endOfDay bool) *time.Time {
+	value := q.get(name)
+	if value == "" {
+		return nil
+	}
+	if parsed, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		if endOfDay {
+			parsed = parsed.AddDate(0, 0, 1).Add(-time.Millisecond)
+		}
+		return &parsed
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		q.fail(fmt.Errorf("invalid %s %q: use YYYY-MM-DD or RFC3339", name, value))
+		return nil
+	}
+	return &parsed
+}
+
+// threadListOptions reads the thread list filters shared by /threads and
+// /feeds.opml.
+func (q *query) threadListOptions() beeper.ThreadListOptions {
+	label := beeper.ThreadLabel(strings.ToLower(q.get("label")))
+	switch label {
+	case "":
+		label = beeper.LabelAll
+	case beeper.LabelAll, beeper.LabelInbox, beeper.LabelArchive, beeper.LabelFavourite, beeper.LabelUnread:
+	default:
+		q.fail(fmt.Errorf("invalid label %q: use inbox, archive, favourite, unread, or all", label))
+	}
+	return beeper.ThreadListOptions{
+		Days:               q.int("days"),
+		Limit:              q.int("limit"),
+		All:                q.bool("all"),
+		AccountID:          q.values.Get("account"),
+		Label:              label,
+		IncludeLowPriority: q.bool("includeLowPriority"),
+	}
+}
+
+func (q *query) format() beeper.MessageFormat {
+	switch value := strings.ToLower(q.get("format")); value {
+	case "", string(beeper.FormatRich):
+		return beeper.FormatRich
+	case string(beeper.FormatPlain):
+		return beeper.FormatPlain
+	default:
+		q.fail(fmt.Errorf("invalid format %q: use plain or rich", value))
+		return beeper.FormatRich
+	}
+}
+
+func withAuth(token string, next http.Handler) http.Handler {
+	if token == "" {
+		return withLoopbackHost(next)
+	}
+	want := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if auth == "" && isFeedPath(r.URL.Path) && r.URL.Query().Has("token") {
+			auth = "Bearer " + r.URL.Query().Get("token")
+		}
+		if r.Method != http.MethodOptions && subtle.ConstantTimeCompare([]byte(auth), want) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="beeper-cli"`)
+			writeError(w, http.StatusUnauthorized, errors.New("missing or invalid bearer token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// withLoopbackHost rejects requests whose Host header is not a loopback
+// name or address. A page that rebinds its own domain to 127.0.0.1 still
+// sends that domain as Host, so it is turned away.
+func withLoopbackHost(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		if !IsLoopback(host) {
+			writeError(w, http.StatusForbidden, fmt.Errorf("host %q is not allowed without a token", r.Host))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func withCORS(origin string, next http.Handler) http.Handler {
+	if origin == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Allow-Headers", "Authorization")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeResult(w http.ResponseWriter, v any, err error) {
+	switch {
+	case errors.Is(err, context.Canceled):
+		// The client went away.
+		return
+	case errors.Is(err, sql.ErrNoRows):
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+		return
+	case errors.Is(err, beeper.ErrThreadNotFound), errors.Is(err, beeper.ErrMessageNotFound):
+		writeError(w, http.StatusNotFound, err)
+		return
+	case errors.Is(err, beeper.ErrInvalidCursor):
+		writeError(w, http.StatusBadRequest, err)
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
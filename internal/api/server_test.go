@@ -0,0 +1,252 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+)
+
+type fakeStore struct {
+	messages beeper.MessageListOptions
+	search   beeper.SearchOptions
+	changes  beeper.ChangeOptions
+}
+
+func (f *fakeStore) ListThreads(_ context.Context, _ beeper.ThreadListOptions) ([]beeper.Thread, error) {
+	return []beeper.Thread{{ID: "!team:beeper.local", DisplayName: "Team Chat"}}, nil
+}
+
+func (f *fakeStore) GetThread(_ context.Context, threadID string, _ bool) (beeper.Thread, error) {
+	if threadID != "!team:beeper.local" {
+		return beeper.Thread{}, fmt.Errorf("%w: %s", beeper.ErrThreadNotFound, threadID)
+	}
+	return beeper.Thread{ID: threadID, DisplayName: "Team Chat"}, nil
+}
+
+// ResolveThread mimics Store.ResolveThread: unknown room IDs come back as
+// is, and names and aliases must match.
+func (f *fakeStore) ResolveThread(_ context.Context, query string) (beeper.Thread, error) {
+	switch {
+	case query == "Team Chat" || query == "#team:beeper.local":
+		return beeper.Thread{ID: "!team:beeper.local"}, nil
+	case query == "Team":
+		return beeper.Thread{}, &beeper.AmbiguousThreadError{Query: query, Candidates: []beeper.Thread{{ID: "!team:beeper.local"}, {ID: "!team2:beeper.local"}}}
+	case strings.HasPrefix(query, "!"):
+		return beeper.Thread{ID: query}, nil
+	}
+	return beeper.Thread{}, fmt.Errorf("%w: no thread matches %q", beeper.ErrThreadNotFound, query)
+}
+
+func (f *fakeStore) ListMessages(_ context.Context, opts beeper.MessageListOptions) ([]beeper.Message, error) {
+	f.messages = opts
+	return []beeper.Message{{ThreadID: opts.ThreadID, Text: "hello"}}, nil
+}
+
+func (f *fakeStore) SearchMessages(_ context.Context, opts beeper.SearchOptions) ([]beeper.SearchResult, error) {
+	f.search = opts
+	return []beeper.SearchResult{}, nil
+}
+
+func (f *fakeStore) ChangesSince(_ context.Context, opts beeper.ChangeOptions) (beeper.ChangeSet, error) {
+	f.changes = opts
+	if opts.Cursor == "bad" {
+		return beeper.ChangeSet{}, beeper.ErrInvalidCursor
+	}
+	return beeper.ChangeSet{Changes: []beeper.Change{{Kind: beeper.ChangeDeleted, EventID: "$gone"}}, Cursor: "next"}, nil
+}
+
+func (f *fakeStore) Gauges(_ context.Context, _ time.Time) (beeper.Gauges, error) {
+	return beeper.Gauges{
+		Threads:        4,
+		UnreadThreads:  2,
+		UnreadMessages: 7,
+		UnreadMentions: 1,
+		LastHour:       []beeper.AccountCount{{AccountID: `what"sapp`, Messages: 3, Sent: 1, Received: 2}},
+		DBSize:         4096,
+	}, nil
+}
+
+func get(t *testing.T, h http.Handler, path string, token string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Host = "127.0.0.1:8787"
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandlerRoutes(t *testing.T) {
+	store := &fakeStore{}
+	h := NewHandler(store, Options{})
+
+	rec := get(t, h, "/threads?limit=5", "")
+	var threads []beeper.Thread
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &threads) != nil || len(threads) != 1 {
+		t.Fatalf("threads: %d %s", rec.Code, rec.Body)
+	}
+
+	rec = get(t, h, "/threads/%21team:beeper.local/messages?limit=10&after=2025-01-01&format=plain", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("messages: %d %s", rec.Code, rec.Body)
+	}
+	if store.messages.ThreadID != "!team:beeper.local" || store.messages.Limit != 10 || store.messages.After == nil || store.messages.Format != beeper.FormatPlain {
+		t.Fatalf("unexpected message options: %+v", store.messages)
+	}
+
+	rec = get(t, h, "/threads/Team%20Chat/changes?cursor=abc&limit=20", "")
+	var changes beeper.ChangeSet
+	if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &changes) != nil || changes.Cursor != "next" || len(changes.Changes) != 1 {
+		t.Fatalf("changes: %d %s", rec.Code, rec.Body)
+	}
+	if store.changes.ThreadID != "!team:beeper.local" || store.changes.Cursor != "abc" || store.changes.Limit != 20 {
+		t.Fatalf("unexpected change options: %+v", store.changes)
+	}
+
+	rec = get(t, h, "/threads/%23team:beeper.local", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("thread by alias: %d %s", rec.Code, rec.Body)
+	}
+
+	rec = get(t, h, "/search?q=lunch&thread=Team%20Chat&context=2", "")
+	if rec.Code != http.StatusOK || store.search.ThreadID != "!team:beeper.local" || store.search.Context != 2 {
+		t.Fatalf("search: %d %s %+v", rec.Code, rec.Body, store.search)
+	}
+
+	for path, status := range map[string]int{
+		"/search": http.StatusBadRequest,
+		"/threads/Team%20Chat/changes?cursor=bad": http.StatusBadRequest,
+		"/threads?limit=x":                        http.StatusBadRequest,
+		"/threads?label=spam":                     http.StatusBadRequest,
+		"/threads/Nobody/messages":                http.StatusNotFound,
+		"/threads/%21gone:beeper.x":               http.StatusNotFound,
+		"/threads/%21gone:beeper.x/messages":      http.StatusNotFound,
+		"/threads/Team/messages":                  http.StatusNotFound,
+		"/search?q=lunch&thread=%21gone:beeper.x": http.StatusNotFound,
+		"/nope": http.StatusNotFound,
+	} {
+		if rec := get(t, h, path, ""); rec.Code != status {
+			t.Fatalf("%s: expected %d, got %d %s", path, status, rec.Code, rec.Body)
+		}
+	}
+}
+
+func TestHandlerHost(t *testing.T) {
+	for host, status := range map[string]int{
+		"127.0.0.1:8787":    http.StatusOK,
+		"localhost:8787":    http.StatusOK,
+		"[::1]:8787":        http.StatusOK,
+		"localhost":         http.StatusOK,
+		"evil.example:8787": http.StatusForbidden,
+		"192.168.1.2:8787":  http.StatusForbidden,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/threads", nil)
+		req.Host = host
+		rec := httptest.NewRecorder()
+		NewHandler(&fakeStore{}, Options{}).ServeHTTP(rec, req)
+		if rec.Code != status {
+			t.Fatalf("%s: expected %d, got %d %s", host, status, rec.Code, rec.Body)
+		}
+	}
+
+	// With a token any host is served.
+	req := httptest.NewRequest(http.MethodGet, "/threads", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	rec := httptest.NewRecorder()
+	NewHandler(&fakeStore{}, Options{Token: "s3cret"}).ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 with a token on %s, got %d", req.Host, rec.Code)
+	}
+}
+
+func TestHandlerCORS(t *testing.T) {
+	for _, tt := range []struct {
+		opts Options
+		want string
+	}{
+		{Options{Token: "s3cret", AllowOrigin: "http://localhost:5173"}, "http://localhost:5173"},
+		{Options{AllowOrigin: "http://localhost:5173"}, ""},
+		{Options{Token: "s3cret", AllowOrigin: "*"}, ""},
+	} {
+		rec := get(t, NewHandler(&fakeStore{}, tt.opts), "/threads", tt.opts.Token)
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.want {
+			t.Fatalf("%+v: expected allowed origin %q, got %q", tt.opts, tt.want, got)
+		}
+	}
+}
+
+func TestHandlerToken(t *testing.T) {
+	h := NewHandler(&fakeStore{}, Options{Token: "s3cret"})
+	if rec := get(t, h, "/threads", ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without token, got %d", rec.Code)
+	}
+	if rec := get(t, h, "/threads", "wrong"); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 with wrong token, got %d", rec.Code)
+	}
+	if rec := get(t, h, "/threads", "s3cret"); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 with token, got %d", rec.Code)
+	}
+}
+
+func TestHandlerFeeds(t *testing.T) {
+	store := &fakeStore{}
+	h := NewHandler(store, Options{Token: "secret"})
+
+	rec := get(t, h, "/threads/Team%20Chat/feed?token=secret", "")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/atom+xml; charset=utf-8" {
+		t.Fatalf("feed: %d %s", rec.Code, rec.Body)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{"<title>Team Chat</title>", "<content type=\"text\">hello</content>", `href="http://127.0.0.1:8787/threads/Team%20Chat/feed?token=secret"`} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("missing %q in:\n%s", want, body)
+		}
+	}
+	if store.messages.Limit != defaultFeedLimit || store.messages.Format != beeper.FormatPlain {
+		t.Fatalf("unexpected message options: %+v", store.messages)
+	}
+
+	rec = get(t, h, "/feeds.opml?label=favourite&token=secret", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `xmlUrl="http://127.0.0.1:8787/threads/%21team:beeper.local/feed?token=secret"`) {
+		t.Fatalf("opml: %d %s", rec.Code, rec.Body)
+	}
+
+	// The query token is only accepted on feed routes.
+	if rec := get(t, h, "/threads?token=secret", ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for JSON routes, got %d", rec.Code)
+	}
+	if rec := get(t, h, "/feeds.opml?token=wrong", ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for a wrong token, got %d", rec.Code)
+	}
+}
+
+func TestHandlerMetrics(t *testing.T) {
+	rec := get(t, NewHandler(&fakeStore{}, Options{}), "/metrics", "")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != metricsContentType {
+		t.Fatalf("metrics: %d %s", rec.Code, rec.Body)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE beeper_unread_threads gauge\nbeeper_unread_threads 2\n",
+		"beeper_unread_mentions 1\n",
+		`beeper_messages_last_hour{account="what\"sapp",direction="received"} 2` + "\n",
+		`beeper_messages_last_hour{account="what\"sapp",direction="sent"} 1` + "\n",
+		"beeper_db_size_bytes 4096\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("missing %q in:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "beeper_last_message_timestamp_seconds") {
+		t.Fatalf("expected no last message gauge without messages:\n%s", body)
+	}
+}
@@ -0,0 +1,157 @@
+package backup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// File names are index-<UTC time>.db, which sort by time.
+const (
+	filePrefix = "index-"
+	fileSuffix = ".db"
+	timeLayout = "20060102T150405Z"
+)
+
+// Backup is one copy in a backup directory.
+type Backup struct {
+	Path string    `json:"path"`
+	Time time.Time `json:"time"`
+}
+
+// FileName returns the file name of a copy taken at t.
+func FileName(t time.Time) string {
+	return filePrefix + t.UTC().Format(timeLayout) + fileSuffix
+}
+
+// List returns the copies in dir, newest first. Files not named by
+// FileName are ignored.
+func List(dir string) ([]Backup, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	backups := []Backup{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, fileSuffix) {
+			continue
+		}
+		t, err := time.Parse(timeLayout, strings.TrimSuffix(strings.TrimPrefix(name, filePrefix), fileSuffix))
+		if err != nil {
+			continue
+		}
+		backups = append(backups, Backup{Path: filepath.Join(dir, name), Time: t})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Time.After(backups[j].Time) })
+	return backups, nil
+}
+
+// Rule keeps the newest copy in each of the Count most recent periods
+// (h, d, w, m, or y) that have a copy.
+type Rule struct {
+	Period byte
+	Count  int
+}
+
+// Policy is a set of rules; a copy is kept when any rule keeps it.
+type Policy []Rule
+
+// ParsePolicy parses a retention policy such as "keep=7d,4w,12m" (the
+// keep= prefix is optional): 7 daily, 4 weekly, and 12 monthly copies.
+func ParsePolicy(value string) (Policy, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "keep=")
+	if value == "" {
+		return nil, fmt.Errorf("empty retention policy")
+	}
+	policy := Policy{}
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if len(part) < 2 || !strings.ContainsRune("hdwmy", rune(part[len(part)-1])) {
+			return nil, fmt.Errorf("invalid retention rule %q: want a count and h, d, w, m, or y (e.g. 7d)", part)
+		}
+		count, err := strconv.Atoi(part[:len(part)-1])
+		if err != nil || count <= 0 {
+			return nil, fmt.Errorf("invalid retention rule %q: count must be a positive number", part)
+		}
+		policy = append(policy, Rule{Period: part[len(part)-1], Count: count})
+	}
+	return policy, nil
+}
+
+// String formats the policy like ParsePolicy accepts it.
+func (p Policy) String() string {
+	parts := make([]string, len(p))
+	for i, rule := range p {
+		parts[i] = strconv.Itoa(rule.Count) + string(rule.Period)
+	}
+	return "keep=" + strings.Join(parts, ",")
+}
+
+// Apply splits backups (newest first, as List returns them) into the
+// copies the policy keeps and the ones it drops. Periods follow local
+// time; weeks are ISO weeks.
+func (p Policy) Apply(backups []Backup) (keep, drop []Backup) {
+	kept := make([]bool, len(backups))
+	for _, rule := range p {
+		remaining := rule.Count
+		last := ""
+		for i, b := range backups {
+			if remaining == 0 {
+				break
+			}
+			key := periodKey(b.Time.Local(), rule.Period)
+			if key == last {
+				continue
+			}
+			last = key
+			kept[i] = true
+			remaining--
+		}
+	}
+	for i, b := range backups {
+		if kept[i] {
+			keep = append(keep, b)
+		} else {
+			drop = append(drop, b)
+		}
+	}
+	return keep, drop
+}
+
+func periodKey(t time.Time, period byte) string {
+	switch period {
+	case 'h':
+		return t.Format("2006-01-02T15")
+	case 'd':
+		return t.Format("2006-01-02")
+	case 'w':
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case 'm':
+		return t.Format("2006-01")
+	default:
+		return t.Format("2006")
+	}
+}
+
+// Prune deletes the copies in dir the policy drops and returns them.
+func Prune(dir string, policy Policy) ([]Backup, error) {
+	backups, err := List(dir)
+	if err != nil {
+		return nil, err
+	}
+	_, drop := policy.Apply(backups)
+	removed := []Backup{}
+	for _, b := range drop {
+		if err := os.Remove(b.Path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, b)
+	}
+	return removed, nil
+}
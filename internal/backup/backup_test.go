@@ -0,0 +1,71 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParsePolicy(t *testing.T) {
+	policy, err := ParsePolicy("keep=7d,4w,12m")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if got := policy.String(); got != "keep=7d,4w,12m" {
+		t.Fatalf("unexpected policy %s", got)
+	}
+	for _, bad := range []string{"", "keep=", "7", "0d", "3x", "d"} {
+		if _, err := ParsePolicy(bad); err == nil {
+			t.Fatalf("expected %q to fail", bad)
+		}
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+	create := func(at time.Time) {
+		if err := os.WriteFile(filepath.Join(dir, FileName(at)), nil, 0o600); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	// A copy at noon every day of 2025's first quarter, plus an earlier one
+	// on the last day; March 31 is a Monday.
+	for day := time.Date(2025, 1, 1, 12, 0, 0, 0, time.Local); day.Month() < 4; day = day.AddDate(0, 0, 1) {
+		create(day)
+	}
+	create(time.Date(2025, 3, 31, 6, 0, 0, 0, time.Local))
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	policy, err := ParsePolicy("7d,4w,3m")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	removed, err := Prune(dir, policy)
+	if err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	if len(removed) != 80 {
+		t.Fatalf("expected 80 copies removed, got %d", len(removed))
+	}
+
+	kept, err := List(dir)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	// Daily Mar 25-31, weekly adds Mar 23 and 16, monthly Feb 28 and Jan 31.
+	want := []string{"03-31", "03-30", "03-29", "03-28", "03-27", "03-26", "03-25", "03-23", "03-16", "02-28", "01-31"}
+	if len(kept) != len(want) {
+		t.Fatalf("expected %d copies kept, got %+v", len(want), kept)
+	}
+	for i, b := range kept {
+		if got := b.Time.Local().Format("01-02"); got != want[i] || b.Time.Local().Hour() != 12 {
+			t.Fatalf("copy %d: expected %s at noon, got %s", i, want[i], b.Time.Local())
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notes.txt")); err != nil {
+		t.Fatalf("expected other files to stay: %v", err)
+	}
+}
@@ -0,0 +1,5 @@
+// Package backup manages a directory of timestamped database copies
+// written by `db backup`, and prunes it with grandfather-father-son
+// retention: keep the newest copy of each of the last N hours, days,
+// weeks, months, or years that have one.
+package backup
@@ -0,0 +1,168 @@
+package beeper
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultStaleAfter is how long an account may go without incoming
+// messages or bridge writes before AccountStatuses calls it stalled.
+const DefaultStaleAfter = 3 * 24 * time.Hour
+
+// Account health, from the most to the least reassuring.
+const (
+	// AccountActive accounts received a message within StaleAfter.
+	AccountActive = "active"
+	// AccountQuiet accounts received nothing, but their bridge database is
+	// still being written, so the bridge is alive and people are just quiet.
+	AccountQuiet = "quiet"
+	// AccountStalled accounts received nothing and their bridge database
+	// has not changed either: the bridge has probably stopped syncing.
+	AccountStalled = "stalled"
+	// AccountUnknown accounts received nothing and have no local bridge
+	// database to tell quiet from stalled (e.g. cloud bridges).
+	AccountUnknown = "unknown"
+)
+
+// AccountStatusOptions controls AccountStatuses.
+type AccountStatusOptions struct {
+	// StaleAfter defaults to DefaultStaleAfter.
+	StaleAfter time.Duration
+	// Now defaults to the current time.
+	Now time.Time
+}
+
+// AccountStatus is the health of one account.
+type AccountStatus struct {
+	AccountID    string    `json:"accountId"`
+	Threads      int       `json:"threads"`
+	LastReceived time.Time `json:"lastReceived,omitempty"`
+	LastSent     time.Time `json:"lastSent,omitempty"`
+	BridgeDB     string    `json:"bridgeDb,omitempty"`
+	// BridgeUpdated is the last write to the bridge database (file or WAL).
+	BridgeUpdated time.Time `json:"bridgeUpdated,omitempty"`
+	// BridgeLastMessage is the newest row in the bridge's message table,
+	// when it has one.
+	BridgeLastMessage time.Time `json:"bridgeLastMessage,omitempty"`
+	Status            string    `json:"status"`
+}
+
+// AccountStatuses reports, per account, when it last received and sent a
+// message and how fresh its bridge database is, and classifies it as
+// active, quiet, stalled, or unknown. Accounts are ordered by ID.
+func (s *Store) AccountStatuses(ctx context.Context, opts AccountStatusOptions) ([]AccountStatus, error) {
+	if opts.StaleAfter <= 0 {
+		opts.StaleAfter = DefaultStaleAfter
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT t.accountID,
+		COUNT(DISTINCT t.threadID),
+		(SELECT MAX(m.timestamp) FROM mx_room_messages m JOIN threads r ON r.threadID = m.roomID
+			WHERE r.accountID = t.accountID AND m.isSentByMe = 0 AND m.isDeleted = 0 AND m.type NOT IN ('HIDDEN','REACTION')),
+		(SELECT MAX(m.timestamp) FROM mx_room_messages m JOIN threads r ON r.threadID = m.roomID
+			WHERE r.accountID = t.accountID AND m.isSentByMe = 1 AND m.isDeleted = 0 AND m.type NOT IN ('HIDDEN','REACTION'))
+		FROM threads t
+		WHERE COALESCE(t.accountID, '') != ''
+		GROUP BY t.accountID
+		ORDER BY t.accountID`)
+	if err != nil {
+		return nil, err
+	}
+	statuses := []AccountStatus{}
+	for rows.Next() {
+		var status AccountStatus
+		var received, sent sql.NullInt64
+		if err := rows.Scan(&status.AccountID, &status.Threads, &received, &sent); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		status.LastReceived = unixMillisOrZero(received)
+		status.LastSent = unixMillisOrZero(sent)
+		statuses = append(statuses, status)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+
+	cutoff := opts.Now.Add(-opts.StaleAfter)
+	for i := range statuses {
+		status := &statuses[i]
+		if path, ok := s.bridgeLookup().PathFor(status.AccountID); ok {
+			status.BridgeDB = path
+			status.BridgeUpdated = fileUpdated(path)
+			last, err := bridgeLastMessage(ctx, path)
+			if err != nil {
+				return nil, err
+			}
+			status.BridgeLastMessage = last
+		}
+		status.Status = accountHealth(*status, cutoff)
+	}
+	return statuses, nil
+}
+
+func accountHealth(status AccountStatus, cutoff time.Time) string {
+	switch {
+	case status.LastReceived.After(cutoff):
+		return AccountActive
+	case status.BridgeDB == "":
+		return AccountUnknown
+	case status.BridgeUpdated.After(cutoff) || status.BridgeLastMessage.After(cutoff):
+		return AccountQuiet
+	default:
+		return AccountStalled
+	}
+}
+
+// fileUpdated returns the later modification time of a database and its
+// write-ahead log, or zero when neither exists.
+func fileUpdated(path string) time.Time {
+	var updated time.Time
+	for _, p := range []string{path, path + "-wal"} {
+		if info, err := os.Stat(p); err == nil && info.ModTime().After(updated) {
+			updated = info.ModTime()
+		}
+	}
+	return updated
+}
+
+// bridgeLastMessage returns the newest timestamp in a bridge's message
+// table. Bridges without one return zero. Megabridge stores nanoseconds;
+// older bridges store milliseconds or seconds.
+func bridgeLastMessage(ctx context.Context, dbPath string) (time.Time, error) {
+	dsn := fmt.Sprintf("file:%s?mode=ro&_busy_timeout=5000", dbPath)
+	conn, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	conn.SetMaxOpenConns(1)
+
+	var last sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT MAX(timestamp) FROM message").Scan(&last); err != nil {
+		if strings.Contains(err.Error(), "no such table") || strings.Contains(err.Error(), "no such column") {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+	if !last.Valid || last.Int64 <= 0 {
+		return time.Time{}, nil
+	}
+	switch {
+	case last.Int64 > 1e17:
+		return time.Unix(0, last.Int64), nil
+	case last.Int64 > 1e11:
+		return time.UnixMilli(last.Int64), nil
+	default:
+		return time.Unix(last.Int64, 0), nil
+	}
+}
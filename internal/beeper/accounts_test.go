@@ -0,0 +1,63 @@
+package beeper
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAccountStatuses(t *testing.T) {
+	path := createTestDB(t, false)
+	bridgeRoot := createBridgeDB(t)
+
+	store, err := OpenWithOptions(path, StoreOptions{BridgeLookup: true, BridgeRoot: bridgeRoot})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	ctx := context.Background()
+	health := func(opts AccountStatusOptions) map[string]AccountStatus {
+		t.Helper()
+		statuses, err := store.AccountStatuses(ctx, opts)
+		if err != nil {
+			t.Fatalf("account statuses: %v", err)
+		}
+		byID := map[string]AccountStatus{}
+		for _, s := range statuses {
+			byID[s.AccountID] = s
+		}
+		return byID
+	}
+
+	// The fixture's last incoming messages: telegram at +400ms, signal at
+	// +500ms, and whatsapp at +700ms after 1700000000000.
+	got := health(AccountStatusOptions{StaleAfter: 550 * time.Millisecond, Now: time.UnixMilli(1700000001000)})
+	for id, want := range map[string]string{"whatsapp": AccountActive, "signal": AccountActive, "telegram": AccountUnknown} {
+		if got[id].Status != want {
+			t.Fatalf("%s: expected %s, got %+v", id, want, got[id])
+		}
+	}
+
+	now := time.UnixMilli(1700000000000).Add(48 * time.Hour)
+	opts := AccountStatusOptions{StaleAfter: 24 * time.Hour, Now: now}
+	got = health(opts)
+	for id, want := range map[string]string{"whatsapp": AccountQuiet, "telegram": AccountUnknown, "signal": AccountUnknown} {
+		if got[id].Status != want {
+			t.Fatalf("%s: expected %s, got %+v", id, want, got[id])
+		}
+	}
+	if got["whatsapp"].BridgeDB == "" || got["whatsapp"].Threads != 2 {
+		t.Fatalf("unexpected whatsapp status: %+v", got["whatsapp"])
+	}
+
+	old := now.Add(-48 * time.Hour)
+	if err := os.Chtimes(filepath.Join(bridgeRoot, "local-whatsapp", "megabridge.db"), old, old); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	if got := health(opts); got["whatsapp"].Status != AccountStalled {
+		t.Fatalf("expected stalled whatsapp bridge, got %+v", got["whatsapp"])
+	}
+}
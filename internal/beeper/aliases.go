@@ -0,0 +1,119 @@
+package beeper
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+)
+
+// canonicalAliasCondition keeps m.room.canonical_alias events, stored
+// either whole or as their content.
+const canonicalAliasCondition = ` AND json_valid(m.message) AND (json_extract(m.message, '$.type') = 'm.room.canonical_alias'
+	OR json_type(m.message, '$.alt_aliases') = 'array' OR json_type(m.message, '$.content.alt_aliases') = 'array'
+	OR json_extract(m.message, '$.alias') LIKE '#%:%' OR json_extract(m.message, '$.content.alias') LIKE '#%:%')`
+
+// roomAliases returns a room's canonical alias and its other published
+// aliases. The newest m.room.canonical_alias event wins; rooms without one
+// fall back to the alias Beeper keeps in the thread JSON, if any.
+func (s *Store) roomAliases(ctx context.Context, roomID string) (string, []string, error) {
+	var raw string
+	err := s.db.QueryRowContext(ctx, `SELECT m.message FROM mx_room_messages m
+		WHERE m.roomID = ? AND m.isDeleted = 0`+canonicalAliasCondition+`
+		ORDER BY m.timestamp DESC, m.id DESC LIMIT 1`, roomID).Scan(&raw)
+	if err == nil {
+		canonical, aliases := parseCanonicalAlias(raw)
+		return canonical, aliases, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return "", nil, err
+	}
+
+	var canonical, alt sql.NullString
+	err = s.db.QueryRowContext(ctx, `SELECT COALESCE(json_extract(thread, '$.canonicalAlias'), json_extract(thread, '$.extra.canonicalAlias')),
+		json_extract(thread, '$.extra.altAliases')
+		FROM threads WHERE threadID = ?`, roomID).Scan(&canonical, &alt)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", nil, err
+	}
+	var aliases []string
+	if alt.Valid {
+		_ = jsonUnmarshalStrings(alt.String, &aliases)
+	}
+	return strings.TrimSpace(canonical.String), cleanAliases(canonical.String, aliases), nil
+}
+
+// parseCanonicalAlias reads the alias and alt_aliases of an
+// m.room.canonical_alias event or its content.
+func parseCanonicalAlias(raw string) (string, []string) {
+	var event struct {
+		Alias      string   `json:"alias"`
+		AltAliases []string `json:"alt_aliases"`
+		Content    *struct {
+			Alias      string   `json:"alias"`
+			AltAliases []string `json:"alt_aliases"`
+		} `json:"content"`
+	}
+	if err := json.Unmarshal([]byte(raw), &event); err != nil {
+		return "", nil
+	}
+	if event.Content != nil {
+		event.Alias, event.AltAliases = event.Content.Alias, event.Content.AltAliases
+	}
+	return strings.TrimSpace(event.Alias), cleanAliases(event.Alias, event.AltAliases)
+}
+
+// cleanAliases drops blanks, duplicates, and the canonical alias from alt.
+func cleanAliases(canonical string, alt []string) []string {
+	aliases := []string{}
+	for _, alias := range alt {
+		alias = strings.TrimSpace(alias)
+		if strings.HasPrefix(alias, "#") && alias != strings.TrimSpace(canonical) && !slices.Contains(aliases, alias) {
+			aliases = append(aliases, alias)
+		}
+	}
+	if len(aliases) == 0 {
+		return nil
+	}
+	return aliases
+}
+
+// roomsByAlias returns the rooms whose current canonical or alternative
+// alias is alias, compared case-insensitively.
+func (s *Store) roomsByAlias(ctx context.Context, alias string) ([]string, error) {
+	pattern := "%" + likeEscaper.Replace(alias) + "%"
+	rows, err := s.db.QueryContext(ctx, `SELECT threadID FROM threads WHERE thread LIKE ? ESCAPE '\'
+		UNION
+		SELECT m.roomID FROM mx_room_messages m WHERE m.message LIKE ? ESCAPE '\'`+canonicalAliasCondition, pattern, pattern)
+	if err != nil {
+		return nil, err
+	}
+	candidates := []string{}
+	for rows.Next() {
+		var roomID string
+		if err := rows.Scan(&roomID); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		candidates = append(candidates, roomID)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// A room matches on its current aliases only, not ones it gave up.
+	rooms := []string{}
+	for _, roomID := range candidates {
+		canonical, aliases, err := s.roomAliases(ctx, roomID)
+		if err != nil {
+			return nil, err
+		}
+		if strings.EqualFold(canonical, alias) || slices.ContainsFunc(aliases, func(a string) bool { return strings.EqualFold(a, alias) }) {
+			rooms = append(rooms, roomID)
+		}
+	}
+	return rooms, nil
+}
@@ -0,0 +1,59 @@
+package beeper
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestRoomAliases(t *testing.T) {
+	path := createTestDB(t, false)
+	insertMessages(t, path,
+		testMessage{id: 100, roomID: "!room1:beeper.local", eventID: "$alias1", sender: "@alice:beeper.local", ts: 1600000000000, typ: "HIDDEN", hsOrder: 100,
+			message: `{"type":"m.room.canonical_alias","content":{"alias":"#old:beeper.local"}}`},
+		testMessage{id: 101, roomID: "!room1:beeper.local", eventID: "$alias2", sender: "@alice:beeper.local", ts: 1600000001000, typ: "HIDDEN", hsOrder: 101,
+			message: `{"alias":"#team:beeper.local","alt_aliases":["#crew:beeper.local","#team:beeper.local"]}`},
+	)
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if _, err := conn.Exec(`UPDATE threads SET thread = json_set(thread, '$.canonicalAlias', '#archive:beeper.local') WHERE threadID = '!room2:beeper.local'`); err != nil {
+		t.Fatalf("set alias: %v", err)
+	}
+	_ = conn.Close()
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	ctx := context.Background()
+
+	thread, err := store.GetThread(ctx, "!room1:beeper.local", false)
+	if err != nil {
+		t.Fatalf("get thread: %v", err)
+	}
+	if thread.CanonicalAlias != "#team:beeper.local" || fmt.Sprint(thread.Aliases) != "[#crew:beeper.local]" {
+		t.Fatalf("unexpected aliases: %q %v", thread.CanonicalAlias, thread.Aliases)
+	}
+
+	for alias, want := range map[string]string{
+		"#team:beeper.local":    "!room1:beeper.local",
+		"#CREW:beeper.local":    "!room1:beeper.local",
+		"#archive:beeper.local": "!room2:beeper.local",
+	} {
+		got, err := store.ResolveThread(ctx, alias)
+		if err != nil || got.ID != want {
+			t.Fatalf("resolve %s: got %q, %v", alias, got.ID, err)
+		}
+	}
+	if _, err := store.ResolveThread(ctx, "#old:beeper.local"); !errors.Is(err, ErrThreadNotFound) {
+		t.Fatalf("expected a replaced alias not to resolve, got %v", err)
+	}
+	if matches, err := store.FindThreadsByName(ctx, "#team:beeper.local"); err != nil || len(matches) != 1 || matches[0].ID != "!room1:beeper.local" {
+		t.Fatalf("find by alias: %+v, %v", matches, err)
+	}
+}
@@ -3,16 +3,38 @@ package beeper
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 )
 
-// BridgeLookup resolves DM names via platform bridge databases.
+// errBridgeClosed is returned by lookups that need a bridge database after
+// Close.
+var errBridgeClosed = errors.New("bridge lookup is closed")
+
+// BridgeLookup resolves DM names via platform bridge databases. It keeps
+// one read-only connection per bridge open until Close and is safe for
+// concurrent use.
 type BridgeLookup struct {
 	platformDBs map[string]string
-	cache       map[string]string
+
+	// mu guards the connection and result caches; queries run unlocked.
+	mu     sync.Mutex
+	conns  map[string]*sql.DB
+	cache  map[string]*BridgeContact
+	closed bool
+}
+
+// RoomRef names a DM room to resolve and the account it belongs to, whose
+// bridge is asked first.
+type RoomRef struct {
+	RoomID    string
+	AccountID string
 }
 
 // NewBridgeLookup discovers megabridge.db files under the Beeper support directory.
@@ -44,85 +66,227 @@ func NewBridgeLookup(indexDBPath string, overrideRoot string) (*BridgeLookup, er
 
 	return &BridgeLookup{
 		platformDBs: platformDBs,
-		cache:       map[string]string{},
+		conns:       map[string]*sql.DB{},
+		cache:       map[string]*BridgeContact{},
 	}, nil
 }
 
+// Close closes the bridge database connections.
+func (b *BridgeLookup) Close() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	var firstErr error
+	for platform, conn := range b.conns {
+		if err := conn.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(b.conns, platform)
+	}
+	return firstErr
+}
+
+// conn returns the open connection to a platform's bridge database. After
+// Close it fails instead of reopening the database.
+func (b *BridgeLookup) conn(platform string) (*sql.DB, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if conn, ok := b.conns[platform]; ok {
+		return conn, nil
+	}
+	if b.closed {
+		return nil, errBridgeClosed
+	}
+	dsn := fmt.Sprintf("file:%s?mode=ro&_busy_timeout=5000", b.platformDBs[platform])
+	conn, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetMaxOpenConns(1)
+	b.conns[platform] = conn
+	return conn, nil
+}
+
+// platforms returns the bridge platforms in name order.
+func (b *BridgeLookup) platforms() []string {
+	platforms := make([]string, 0, len(b.platformDBs))
+	for platform := range b.platformDBs {
+		platforms = append(platforms, platform)
+	}
+	sort.Strings(platforms)
+	return platforms
+}
+
 // LookupDMName attempts to resolve a DM name for the given room ID.
 func (b *BridgeLookup) LookupDMName(ctx context.Context, roomID string, accountID string) (string, bool, error) {
+	contact, ok, err := b.LookupDMContact(ctx, roomID, accountID)
+	if err != nil || !ok || contact.Name == "" {
+		return "", false, err
+	}
+	return contact.Name, true, nil
+}
+
+// LookupDMContact returns the remote user behind the DM with the given room
+// ID. The contact's Name is empty when the bridge has no name for them.
+func (b *BridgeLookup) LookupDMContact(ctx context.Context, roomID string, accountID string) (BridgeContact, bool, error) {
 	if b == nil || len(b.platformDBs) == 0 {
-		return "", false, nil
+		return BridgeContact{}, false, nil
+	}
+	contacts, err := b.LookupDMContacts(ctx, []RoomRef{{RoomID: roomID, AccountID: accountID}})
+	if err != nil {
+		return BridgeContact{}, false, err
+	}
+	contact, ok := contacts[roomID]
+	return contact, ok, nil
+}
+
+// LookupDMNames resolves the names of many DM rooms at once, keyed by room
+// ID; rooms without a bridge name are left out.
+func (b *BridgeLookup) LookupDMNames(ctx context.Context, refs []RoomRef) (map[string]string, error) {
+	contacts, err := b.LookupDMContacts(ctx, refs)
+	if err != nil {
+		return nil, err
 	}
-	if cached, ok := b.cache[roomID]; ok {
-		if cached == "" {
-			return "", false, nil
+	names := map[string]string{}
+	for roomID, contact := range contacts {
+		if contact.Name != "" {
+			names[roomID] = contact.Name
 		}
-		return cached, true, nil
 	}
+	return names, nil
+}
 
-	candidate := ""
-	if accountID != "" {
-		candidate = b.platformDBs[normalizePlatform(accountID)]
+// LookupDMContacts resolves the remote users behind many DM rooms, keyed
+// by room ID, with one query per bridge database. Rooms no bridge knows
+// are left out. Results, including misses, are cached.
+func (b *BridgeLookup) LookupDMContacts(ctx context.Context, refs []RoomRef) (map[string]BridgeContact, error) {
+	contacts := map[string]BridgeContact{}
+	if b == nil || len(b.platformDBs) == 0 {
+		return contacts, nil
 	}
 
-	if candidate != "" {
-		name, ok, err := queryBridgeName(ctx, candidate, roomID)
-		if err != nil {
-			return "", false, err
+	// own maps each room to look up to the bridge of its account, or ""
+	// when the account has none.
+	own := map[string]string{}
+	b.mu.Lock()
+	for _, ref := range refs {
+		if _, ok := own[ref.RoomID]; ok {
+			continue
+		}
+		if _, ok := contacts[ref.RoomID]; ok {
+			continue
+		}
+		if cached, ok := b.cache[ref.RoomID]; ok {
+			if cached != nil {
+				contacts[ref.RoomID] = *cached
+			}
+			continue
 		}
-		b.cache[roomID] = name
-		return name, ok, nil
+		platform := normalizePlatform(ref.AccountID)
+		if _, ok := b.platformDBs[platform]; !ok {
+			platform = ""
+		}
+		own[ref.RoomID] = platform
+	}
+	b.mu.Unlock()
+	if len(own) == 0 {
+		return contacts, nil
 	}
 
-	for _, path := range b.platformDBs {
-		name, ok, err := queryBridgeName(ctx, path, roomID)
+	// Each room is asked its own account's bridge first, then every other
+	// bridge in name order until one has a name for it. A nameless match
+	// is kept unless a later bridge names the user.
+	lookup := func(platform string, rooms []string) error {
+		if len(rooms) == 0 {
+			return nil
+		}
+		conn, err := b.conn(platform)
+		if err != nil {
+			return err
+		}
+		found, err := queryBridgeContacts(ctx, conn, rooms)
 		if err != nil {
-			return "", false, err
+			return err
 		}
-		if ok {
-			b.cache[roomID] = name
-			return name, true, nil
+		for roomID, contact := range found {
+			if _, ok := contacts[roomID]; ok && contact.Name == "" {
+				continue
+			}
+			contact.Platform = platform
+			contacts[roomID] = contact
+		}
+		return nil
+	}
+	byPlatform := map[string][]string{}
+	for roomID, platform := range own {
+		if platform != "" {
+			byPlatform[platform] = append(byPlatform[platform], roomID)
+		}
+	}
+	platforms := b.platforms()
+	for _, platform := range platforms {
+		if err := lookup(platform, byPlatform[platform]); err != nil {
+			return nil, err
+		}
+	}
+	for _, platform := range platforms {
+		rooms := []string{}
+		for roomID, ownPlatform := range own {
+			if ownPlatform == platform || contacts[roomID].Name != "" {
+				continue
+			}
+			rooms = append(rooms, roomID)
+		}
+		if err := lookup(platform, rooms); err != nil {
+			return nil, err
 		}
 	}
 
-	b.cache[roomID] = ""
-	return "", false, nil
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for roomID := range own {
+		if contact, ok := contacts[roomID]; ok {
+			b.cache[roomID] = &contact
+		} else {
+			b.cache[roomID] = nil
+		}
+	}
+	return contacts, nil
 }
 
-func queryBridgeName(ctx context.Context, dbPath string, roomID string) (string, bool, error) {
-	dsn := fmt.Sprintf("file:%s?mode=ro&_busy_timeout=5000", dbPath)
-	conn, err := sql.Open("sqlite3", dsn)
+// queryBridgeContacts looks up the remote users behind rooms in one bridge
+// database. A ghost without a name still yields a contact.
+func queryBridgeContacts(ctx context.Context, conn *sql.DB, rooms []string) (map[string]BridgeContact, error) {
+	list, err := json.Marshal(rooms)
 	if err != nil {
-		return "", false, err
+		return nil, err
 	}
-	defer func() {
-		_ = conn.Close()
-	}()
-	conn.SetMaxOpenConns(1)
-
-	var otherUser string
-	row := conn.QueryRowContext(ctx, "SELECT other_user_id FROM portal WHERE mxid = ? AND other_user_id IS NOT NULL LIMIT 1", roomID)
-	if err := row.Scan(&otherUser); err != nil {
-		if err == sql.ErrNoRows {
-			return "", false, nil
-		}
-		return "", false, err
+	rows, err := conn.QueryContext(ctx, `SELECT p.mxid, p.other_user_id,
+		COALESCE((SELECT g.name FROM ghost g WHERE g.id = p.other_user_id AND g.name != '' LIMIT 1), '')
+		FROM portal p
+		WHERE p.mxid IN (SELECT value FROM json_each(?)) AND p.other_user_id IS NOT NULL`, string(list))
+	if err != nil {
+		return nil, err
 	}
+	defer func() { _ = rows.Close() }()
 
-	var name string
-	row = conn.QueryRowContext(ctx, "SELECT name FROM ghost WHERE id = ? AND name != '' LIMIT 1", otherUser)
-	if err := row.Scan(&name); err != nil {
-		if err == sql.ErrNoRows {
-			return "", false, nil
+	contacts := map[string]BridgeContact{}
+	for rows.Next() {
+		var roomID, name string
+		var contact BridgeContact
+		if err := rows.Scan(&roomID, &contact.RemoteID, &name); err != nil {
+			return nil, err
 		}
-		return "", false, err
-	}
-
-	name = strings.TrimSpace(name)
-	if name == "" {
-		return "", false, nil
+		if _, ok := contacts[roomID]; ok {
+			continue
+		}
+		contact.Name = strings.TrimSpace(name)
+		contacts[roomID] = contact
 	}
-	return name, true, nil
+	return contacts, rows.Err()
 }
 
 func normalizePlatform(platform string) string {
@@ -131,6 +295,66 @@ func normalizePlatform(platform string) string {
 	return platform
 }
 
+// BridgeGhost is a remote user known to a platform bridge.
+type BridgeGhost struct {
+	Platform string
+	ID       string
+	Name     string
+}
+
+// Ghosts returns every named ghost from all bridge databases. Bridges
+// without a ghost table are skipped.
+func (b *BridgeLookup) Ghosts(ctx context.Context) ([]BridgeGhost, error) {
+	if b == nil {
+		return nil, nil
+	}
+	ghosts := []BridgeGhost{}
+	for _, platform := range b.platforms() {
+		conn, err := b.conn(platform)
+		if err != nil {
+			return nil, err
+		}
+		list, err := queryBridgeGhosts(ctx, conn, platform)
+		if err != nil {
+			return nil, err
+		}
+		ghosts = append(ghosts, list...)
+	}
+	return ghosts, nil
+}
+
+func queryBridgeGhosts(ctx context.Context, conn *sql.DB, platform string) ([]BridgeGhost, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT id, name FROM ghost WHERE TRIM(COALESCE(name, '')) != ''")
+	if err != nil {
+		if strings.Contains(err.Error(), "no such table") {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	ghosts := []BridgeGhost{}
+	for rows.Next() {
+		var ghost BridgeGhost
+		if err := rows.Scan(&ghost.ID, &ghost.Name); err != nil {
+			return nil, err
+		}
+		ghost.Platform = platform
+		ghost.Name = strings.TrimSpace(ghost.Name)
+		ghosts = append(ghosts, ghost)
+	}
+	return ghosts, rows.Err()
+}
+
+// PathFor returns the bridge database of an account or platform ID.
+func (b *BridgeLookup) PathFor(accountID string) (string, bool) {
+	if b == nil {
+		return "", false
+	}
+	path, ok := b.platformDBs[normalizePlatform(accountID)]
+	return path, ok
+}
+
 // Paths returns bridge database paths discovered for the current user.
 func (b *BridgeLookup) Paths() []string {
 	if b == nil {
@@ -0,0 +1,243 @@
+package beeper
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// callTypes are the message types Beeper gives call events.
+var callTypes = []string{"CALL", "VOICE_CALL", "VIDEO_CALL"}
+
+// callTextPattern matches the short notices bridges write for calls, such
+// as "Missed voice call", "Video call (12:34)", "Incoming call from Alice",
+// or "Call ended"; questions are left out ("Video call tomorrow?").
+var callTextPattern = regexp.MustCompile(`(?i)^\s*(?:` +
+	`(?:missed|incoming|outgoing|declined|rejected|cancell?ed|unanswered)\s+(?:(?:voice|video|audio|group)\s+)?call` +
+	`|(?:voice|video|audio|group)\s+call` +
+	`|call\s+(?:ended|started|missed|declined|rejected))\b[^?]{0,40}$`)
+
+// callDurationPattern finds a call length written as m:ss or h:mm:ss.
+var callDurationPattern = regexp.MustCompile(`\b(?:(\d+):)?(\d{1,2}):(\d{2})\b`)
+
+// callPayloadKeys hold call details in message payloads.
+var callPayloadKeys = []string{"com.beeper.call", "call"}
+
+// ListCalls returns the calls recorded in threads, newest first. Calls are
+// messages of a call type, Matrix m.call.* events, and the call notices
+// bridges write as text; each call is one message, so a call that spans
+// invite and hangup events counts once per event the bridge kept.
+func (s *Store) ListCalls(ctx context.Context, opts CallOptions) ([]Call, error) {
+	query := strings.Builder{}
+	query.WriteString(`SELECT m.id, m.eventID, m.roomID, m.senderContactID, m.timestamp, m.isSentByMe, m.type,
+		COALESCE(m.text_content, ''), COALESCE(m.message, '')
+		FROM mx_room_messages m
+		WHERE m.isDeleted = 0
+		AND (m.type IN (` + placeholders(len(callTypes)) + `) OR m.message LIKE '%call%' OR ` + messageText + ` LIKE '%call%')`)
+	args := stringSliceToAny(callTypes)
+	if opts.ThreadID != "" {
+		query.WriteString(" AND m.roomID = ?")
+		args = append(args, opts.ThreadID)
+	}
+	if opts.AccountID != "" {
+		query.WriteString(" AND m.roomID IN (SELECT threadID FROM threads WHERE accountID = ?)")
+		args = append(args, opts.AccountID)
+	}
+	if opts.After != nil {
+		query.WriteString(" AND m.timestamp >= ?")
+		args = append(args, opts.After.UnixMilli())
+	}
+	if opts.Before != nil {
+		query.WriteString(" AND m.timestamp <= ?")
+		args = append(args, opts.Before.UnixMilli())
+	}
+	query.WriteString(" ORDER BY m.timestamp DESC, m.id DESC")
+
+	rows, err := s.db.QueryContext(ctx, query.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	calls := []Call{}
+	for rows.Next() && (opts.Limit <= 0 || len(calls) < opts.Limit) {
+		var msg Message
+		var ts int64
+		var isSentByMe int
+		var msgType sql.NullString
+		var textContent, rawMessage string
+		if err := rows.Scan(&msg.ID, &msg.EventID, &msg.ThreadID, &msg.SenderID, &ts, &isSentByMe, &msgType, &textContent, &rawMessage); err != nil {
+			return nil, err
+		}
+		msg.Type = strings.TrimSpace(msgType.String)
+		msg.Text = ResolveMessageText(rawMessage, msg.Type, textContent, FormatPlain)
+		call, ok := parseCall(rawMessage, msg.Type, msg.Text)
+		if !ok {
+			continue
+		}
+		msg.Timestamp = unixMillis(ts)
+		msg.IsSentByMe = isSentByMe != 0
+		call.Message = msg
+		call.Outgoing = msg.IsSentByMe
+		calls = append(calls, call)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	_ = rows.Close()
+
+	messages := make([]Message, len(calls))
+	for i, call := range calls {
+		messages[i] = call.Message
+	}
+	if err := s.enrichMessages(ctx, messages); err != nil {
+		return nil, err
+	}
+	for i := range calls {
+		calls[i].Message = messages[i]
+	}
+	return calls, nil
+}
+
+// parseCall reports whether a message is a call event and reads whether
+// it was a video call, whether it was missed, and its duration.
+func parseCall(rawMessage string, msgType string, text string) (Call, bool) {
+	upperType := strings.ToUpper(msgType)
+	var payload map[string]any
+	_ = json.Unmarshal([]byte(rawMessage), &payload)
+	details := callDetails(payload)
+
+	if !slices.Contains(callTypes, upperType) && details == nil && !callTextPattern.MatchString(text) {
+		return Call{}, false
+	}
+
+	lower := strings.ToLower(text)
+	call := Call{
+		Video: upperType == "VIDEO_CALL" || strings.Contains(lower, "video"),
+		Missed: strings.Contains(lower, "missed") || strings.Contains(lower, "declined") ||
+			strings.Contains(lower, "rejected") || strings.Contains(lower, "unanswered") || strings.Contains(lower, "no answer"),
+	}
+	if details != nil {
+		call.Video = call.Video || firstBool(details, "isVideo", "is_video", "video")
+		call.Missed = call.Missed || firstBool(details, "isMissed", "is_missed", "missed")
+		switch reason := strings.ToLower(firstString(details, "reason", "status")); reason {
+		case "missed", "declined", "rejected", "no_answer", "invite_timeout":
+			call.Missed = true
+		}
+		if ms := firstInt(details, "durationMs", "duration_ms"); ms > 0 {
+			call.Duration = time.Duration(ms) * time.Millisecond
+		} else if sec := firstInt(details, "duration", "durationSeconds", "duration_seconds"); sec > 0 {
+			call.Duration = time.Duration(sec) * time.Second
+		}
+	}
+	if call.Duration == 0 && !call.Missed {
+		call.Duration = textCallDuration(text)
+	}
+	call.Seconds = int64(call.Duration / time.Second)
+	return call, true
+}
+
+// callDetails returns the call part of a payload: an m.call.* event's
+// content, or an object under a call key (top level or nested under
+// content/extra); nil when the payload describes no call.
+func callDetails(payload map[string]any) map[string]any {
+	if payload == nil {
+		return nil
+	}
+	if strings.HasPrefix(firstString(payload, "type", "eventType", "event_type"), "m.call.") {
+		if content, ok := payload["content"].(map[string]any); ok {
+			return content
+		}
+		return payload
+	}
+	for _, m := range append([]map[string]any{payload}, nestedMetadata(payload)...) {
+		for _, key := range callPayloadKeys {
+			if details, ok := m[key].(map[string]any); ok {
+				return details
+			}
+		}
+	}
+	return nil
+}
+
+func nestedMetadata(payload map[string]any) []map[string]any {
+	nested := []map[string]any{}
+	for _, key := range metadataKeys {
+		if m, ok := payload[key].(map[string]any); ok {
+			nested = append(nested, m)
+		}
+	}
+	return nested
+}
+
+// textCallDuration reads a call length like "12:34" or "1:02:03" from a
+// call notice.
+func textCallDuration(text string) time.Duration {
+	match := callDurationPattern.FindStringSubmatch(text)
+	if match == nil {
+		return 0
+	}
+	hours, _ := strconv.Atoi(match[1])
+	minutes, _ := strconv.Atoi(match[2])
+	seconds, _ := strconv.Atoi(match[3])
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second
+}
+
+func firstBool(payload map[string]any, keys ...string) bool {
+	for _, key := range keys {
+		if value, ok := payload[key].(bool); ok {
+			return value
+		}
+	}
+	return false
+}
+
+// SummarizeCalls totals calls per thread, the longest total duration
+// first, then the most calls.
+func SummarizeCalls(calls []Call) []CallSummary {
+	byThread := map[string]*CallSummary{}
+	for _, call := range calls {
+		msg := call.Message
+		summary := byThread[msg.ThreadID]
+		if summary == nil {
+			summary = &CallSummary{ThreadID: msg.ThreadID, ThreadName: msg.ThreadName, AccountID: msg.AccountID}
+			byThread[msg.ThreadID] = summary
+		}
+		summary.Calls++
+		if call.Missed {
+			summary.Missed++
+		}
+		if call.Video {
+			summary.Video++
+		}
+		if call.Outgoing {
+			summary.Outgoing++
+		}
+		summary.Duration += call.Duration
+		if msg.Timestamp.After(summary.Last) {
+			summary.Last = msg.Timestamp
+		}
+	}
+	summaries := make([]CallSummary, 0, len(byThread))
+	for _, summary := range byThread {
+		summary.Seconds = int64(summary.Duration / time.Second)
+		summaries = append(summaries, *summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		a, b := summaries[i], summaries[j]
+		if a.Duration != b.Duration {
+			return a.Duration > b.Duration
+		}
+		if a.Calls != b.Calls {
+			return a.Calls > b.Calls
+		}
+		return a.ThreadID < b.ThreadID
+	})
+	return summaries
+}
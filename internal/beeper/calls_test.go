@@ -0,0 +1,57 @@
+package beeper
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestListCalls(t *testing.T) {
+	path := createTestDB(t, false)
+	insertMessages(t, path,
+		testMessage{id: 20, roomID: "!room4:beeper.local", eventID: "$calla", sender: "@me:beeper.local", ts: 1700000001000, typ: "VIDEO_CALL", hsOrder: 20, isMe: true, message: `{"com.beeper.call":{"duration":125}}`},
+		testMessage{id: 21, roomID: "!room1:beeper.local", eventID: "$callb", sender: "@alice:beeper.local", ts: 1700000002000, hsOrder: 21, message: `{"text":"Missed voice call"}`},
+		testMessage{id: 22, roomID: "!room1:beeper.local", eventID: "$callc", sender: "@alice:beeper.local", ts: 1700000003000, hsOrder: 22, message: `{"text":"Call me later?"}`},
+		testMessage{id: 23, roomID: "!room1:beeper.local", eventID: "$calld", sender: "@alice:beeper.local", ts: 1700000004000, typ: "HIDDEN", hsOrder: 23, message: `{"type":"m.call.hangup","content":{"reason":"invite_timeout"}}`},
+		testMessage{id: 24, roomID: "!room1:beeper.local", eventID: "$calle", sender: "@alice:beeper.local", ts: 1700000005000, hsOrder: 24, message: `{"text":"Video call · 1:02:03"}`},
+	)
+
+	store, err := OpenWithOptions(path, StoreOptions{})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	calls, err := store.ListCalls(context.Background(), CallOptions{})
+	if err != nil {
+		t.Fatalf("list calls: %v", err)
+	}
+	if len(calls) != 4 {
+		t.Fatalf("expected 4 calls, got %+v", calls)
+	}
+	video, hangup, missed, outgoing := calls[0], calls[1], calls[2], calls[3]
+	if !video.Video || video.Missed || video.Duration != time.Hour+2*time.Minute+3*time.Second || video.Message.SenderName != "Alice" {
+		t.Fatalf("unexpected video call: %+v", video)
+	}
+	if !hangup.Missed || hangup.Duration != 0 {
+		t.Fatalf("expected a missed hangup, got %+v", hangup)
+	}
+	if !missed.Missed || missed.Video {
+		t.Fatalf("expected a missed voice call, got %+v", missed)
+	}
+	if !outgoing.Outgoing || !outgoing.Video || outgoing.Seconds != 125 {
+		t.Fatalf("unexpected outgoing call: %+v", outgoing)
+	}
+
+	summaries := SummarizeCalls(calls)
+	if len(summaries) != 2 || summaries[0].ThreadID != "!room1:beeper.local" || summaries[0].Calls != 3 || summaries[0].Missed != 2 || summaries[0].Seconds != 3723 {
+		t.Fatalf("unexpected summaries: %+v", summaries)
+	}
+	if summaries[1].Outgoing != 1 || summaries[1].Video != 1 {
+		t.Fatalf("unexpected DM summary: %+v", summaries[1])
+	}
+
+	if limited, err := store.ListCalls(context.Background(), CallOptions{ThreadID: "!room4:beeper.local", Limit: 1}); err != nil || len(limited) != 1 {
+		t.Fatalf("expected the DM call, got %d, %v", len(limited), err)
+	}
+}
@@ -0,0 +1,282 @@
+package beeper
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// changeRow is a row added to a thread: a message, or an m.replace edit of
+// the message editTarget names.
+type changeRow struct {
+	msg        Message
+	editTarget string
+	editText   string
+}
+
+// maxCursorDeleted bounds how many deleted row IDs a changes cursor lists;
+// older deleted rows are only summarized by a count and checksum.
+const maxCursorDeleted = 64
+
+// ChangesSince returns the messages added, edited, or deleted in a thread
+// since opts.Cursor, oldest first, so sync tools can poll a thread instead
+// of listing it again. Beeper keeps no change log, so the cursor records
+// the last row seen and the rows deleted by then: later rows are new
+// messages or edits (reported as the edited message with its latest text),
+// and rows deleted since are deletions. The cursor lists only the newest
+// maxCursorDeleted deleted rows and a checksum of the others; when that
+// checksum no longer matches, every older deleted row is reported again.
+// An empty cursor starts from the thread's first message.
+func (s *Store) ChangesSince(ctx context.Context, opts ChangeOptions) (ChangeSet, error) {
+	cursor, err := decodeCursor(opts.Cursor, cursorChanges)
+	if err != nil {
+		return ChangeSet{}, err
+	}
+	var known int
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM threads WHERE threadID = ?", opts.ThreadID).Scan(&known); err != nil {
+		return ChangeSet{}, err
+	}
+	if known == 0 {
+		return ChangeSet{}, fmt.Errorf("%w: %s", ErrThreadNotFound, opts.ThreadID)
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	lastID := int64(0)
+	wasDeleted := []int64{}
+	var below deletedSum
+	if cursor != nil {
+		lastID = cursor.ID
+		if wasDeleted, err = decodeIDs(cursor.Deleted); err != nil {
+			return ChangeSet{}, err
+		}
+		below = deletedSum{cursor.DeletedCount, cursor.DeletedSum}
+	}
+	// Deleted rows below floor are only known by their checksum.
+	floor := int64(0)
+	if below.count > 0 && len(wasDeleted) > 0 {
+		floor = wasDeleted[0]
+	}
+
+	rows, err := s.changeRows(ctx, opts.ThreadID, lastID, limit+1, opts.Format)
+	if err != nil {
+		return ChangeSet{}, err
+	}
+	set := ChangeSet{Changes: []Change{}, HasMore: len(rows) > limit}
+	rows = rows[:min(len(rows), limit)]
+	nextID := lastID
+	if len(rows) > 0 {
+		nextID = rows[len(rows)-1].msg.ID
+	}
+
+	deleted, err := s.deletedRows(ctx, opts.ThreadID, nextID)
+	if err != nil {
+		return ChangeSet{}, err
+	}
+	var nowBelow deletedSum
+	for _, msg := range deleted {
+		if msg.ID < floor {
+			nowBelow.add(msg.ID)
+		}
+	}
+	for _, msg := range deleted {
+		// Rows past the old cursor were never reported, so their deletion
+		// is not news.
+		if msg.ID > lastID {
+			continue
+		}
+		if msg.ID < floor {
+			if nowBelow != below {
+				set.Changes = append(set.Changes, Change{Kind: ChangeDeleted, EventID: msg.EventID})
+			}
+			continue
+		}
+		if _, seen := slices.BinarySearch(wasDeleted, msg.ID); !seen {
+			set.Changes = append(set.Changes, Change{Kind: ChangeDeleted, EventID: msg.EventID})
+		}
+	}
+
+	targets, err := s.editTargets(ctx, opts.ThreadID, rows, opts.Format)
+	if err != nil {
+		return ChangeSet{}, err
+	}
+	messages := []Message{}
+	kinds := []string{}
+	for _, row := range rows {
+		if row.editTarget == "" {
+			messages = append(messages, row.msg)
+			kinds = append(kinds, ChangeNew)
+			continue
+		}
+		// As in Matrix, only edits by the original sender count.
+		target, ok := targets[row.editTarget]
+		if !ok || target.SenderID != row.msg.SenderID {
+			continue
+		}
+		target.Text = row.editText
+		target.Edited = true
+		messages = append(messages, target)
+		kinds = append(kinds, ChangeEdited)
+	}
+	if err := s.enrichMessages(ctx, messages); err != nil {
+		return ChangeSet{}, err
+	}
+	for i := range messages {
+		set.Changes = append(set.Changes, Change{Kind: kinds[i], EventID: messages[i].EventID, Message: &messages[i]})
+	}
+
+	next := pageCursor{Kind: cursorChanges, ID: nextID}
+	var older deletedSum
+	deletedIDs := make([]int64, 0, min(len(deleted), maxCursorDeleted))
+	for i, msg := range deleted {
+		if i < len(deleted)-maxCursorDeleted {
+			older.add(msg.ID)
+			continue
+		}
+		deletedIDs = append(deletedIDs, msg.ID)
+	}
+	next.Deleted = encodeIDs(deletedIDs)
+	next.DeletedCount, next.DeletedSum = older.count, older.sum
+	set.Cursor = next.encode()
+	return set, nil
+}
+
+// deletedSum summarizes a set of deleted row IDs by their count and the sum
+// of their mixed bits, which changes when any ID is added or removed.
+type deletedSum struct {
+	count int64
+	sum   uint64
+}
+
+func (d *deletedSum) add(id int64) {
+	// splitmix64's finalizer, so nearby IDs do not cancel out.
+	z := uint64(id) + 0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	d.count++
+	d.sum += z ^ (z >> 31)
+}
+
+// changeRows returns up to limit visible rows of a thread after afterID in
+// ID order, with edits marked.
+func (s *Store) changeRows(ctx context.Context, threadID string, afterID int64, limit int, format MessageFormat) ([]changeRow, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT m.id, m.eventID, m.roomID, m.senderContactID, m.timestamp, m.isSentByMe, m.type,
+		COALESCE(m.text_content, ''), COALESCE(m.message, ''),
+		COALESCE(CASE WHEN json_valid(m.message) AND json_extract(m.message, '$."m.relates_to".rel_type') = 'm.replace'
+			THEN json_extract(m.message, '$."m.relates_to".event_id') END, '')
+		FROM mx_room_messages m
+		WHERE m.roomID = ? AND m.id > ? AND m.isDeleted = 0 AND m.type NOT IN ('HIDDEN','REACTION')
+		ORDER BY m.id ASC LIMIT ?`, threadID, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	changes := []changeRow{}
+	for rows.Next() {
+		var row changeRow
+		var ts int64
+		var isSentByMe int
+		var textContent, raw string
+		if err := rows.Scan(&row.msg.ID, &row.msg.EventID, &row.msg.ThreadID, &row.msg.SenderID, &ts, &isSentByMe,
+			&row.msg.Type, &textContent, &raw, &row.editTarget); err != nil {
+			return nil, err
+		}
+		row.msg.Timestamp = unixMillis(ts)
+		row.msg.IsSentByMe = isSentByMe != 0
+		row.msg.Type = strings.TrimSpace(row.msg.Type)
+		if row.editTarget != "" {
+			row.editText = editText(raw, row.msg.Type, textContent, format)
+		} else {
+			row.msg.Text = ResolveMessageText(raw, row.msg.Type, textContent, format)
+			row.msg.Undecrypted = isUndecrypted(raw, row.msg.Type, textContent)
+			row.msg.ThreadRootEventID, row.msg.ReplyToEventID = parseRelation(raw)
+		}
+		changes = append(changes, row)
+	}
+	return changes, rows.Err()
+}
+
+// editTargets returns the visible messages the edits among rows edit, by
+// event ID.
+func (s *Store) editTargets(ctx context.Context, threadID string, rows []changeRow, format MessageFormat) (map[string]Message, error) {
+	eventIDs := []string{}
+	for _, row := range rows {
+		if row.editTarget != "" {
+			eventIDs = append(eventIDs, row.editTarget)
+		}
+	}
+	targets := map[string]Message{}
+	if len(eventIDs) == 0 {
+		return targets, nil
+	}
+	eventIDs = uniqueStrings(eventIDs)
+	args := append([]any{threadID}, stringSliceToAny(eventIDs)...)
+	messages, err := s.scanMessages(ctx, `SELECT m.id, m.eventID, m.roomID, m.senderContactID, m.timestamp, m.isSentByMe, m.type,
+		COALESCE(m.text_content, ''), COALESCE(m.message, '')
+		FROM mx_room_messages m
+		WHERE m.roomID = ? AND m.isDeleted = 0 AND m.eventID IN (`+placeholders(len(eventIDs))+`)`+notEditCondition, args, format)
+	if err != nil {
+		return nil, err
+	}
+	for _, msg := range messages {
+		targets[msg.EventID] = msg
+	}
+	return targets, nil
+}
+
+// deletedRows returns the deleted messages of a thread up to row upToID,
+// in ID order, with only ID and EventID set.
+func (s *Store) deletedRows(ctx context.Context, threadID string, upToID int64) ([]Message, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT m.id, m.eventID
+		FROM mx_room_messages m
+		WHERE m.roomID = ? AND m.id <= ? AND m.isDeleted = 1 AND m.type NOT IN ('HIDDEN','REACTION')`+notEditCondition+`
+		ORDER BY m.id ASC`, threadID, upToID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	deleted := []Message{}
+	for rows.Next() {
+		var msg Message
+		var eventID sql.NullString
+		if err := rows.Scan(&msg.ID, &eventID); err != nil {
+			return nil, err
+		}
+		msg.EventID = eventID.String
+		deleted = append(deleted, msg)
+	}
+	return deleted, rows.Err()
+}
+
+// encodeIDs packs ascending IDs as uvarint deltas, so a thread's deleted
+// rows take a few bytes each.
+func encodeIDs(ids []int64) []byte {
+	data := []byte{}
+	prev := int64(0)
+	for _, id := range ids {
+		data = binary.AppendUvarint(data, uint64(id-prev))
+		prev = id
+	}
+	return data
+}
+
+func decodeIDs(data []byte) ([]int64, error) {
+	ids := []int64{}
+	prev := int64(0)
+	for len(data) > 0 {
+		delta, n := binary.Uvarint(data)
+		if n <= 0 {
+			return nil, ErrInvalidCursor
+		}
+		prev += int64(delta)
+		ids = append(ids, prev)
+		data = data[n:]
+	}
+	return ids, nil
+}
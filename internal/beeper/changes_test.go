@@ -0,0 +1,174 @@
+package beeper
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestChangesSince(t *testing.T) {
+	path := createTestDB(t, false)
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	ctx := context.Background()
+
+	summary := func(set ChangeSet) string {
+		out := ""
+		for _, c := range set.Changes {
+			out += c.Kind + ":" + c.EventID
+			if c.Message != nil {
+				out += "=" + c.Message.Text
+			}
+			out += " "
+		}
+		return fmt.Sprintf("%s(more=%t)", out, set.HasMore)
+	}
+	poll := func(cursor string, limit int) ChangeSet {
+		t.Helper()
+		set, err := store.ChangesSince(ctx, ChangeOptions{ThreadID: "!room1:beeper.local", Cursor: cursor, Limit: limit, Format: FormatPlain})
+		if err != nil {
+			t.Fatalf("changes: %v", err)
+		}
+		return set
+	}
+
+	first := poll("", 2)
+	if got := summary(first); got != "new:$evt1=hello new:$evt2=christmas party (more=true)" {
+		t.Fatalf("first page: %s", got)
+	}
+	if first.Changes[0].Message.ThreadName != "Team Chat" || first.Changes[0].Message.SenderName != "Alice" {
+		t.Fatalf("expected enriched messages, got %+v", first.Changes[0].Message)
+	}
+	second := poll(first.Cursor, 2)
+	if got := summary(second); got != "new:$evt3=see you new:$evt7=invoice due (more=false)" {
+		t.Fatalf("second page: %s", got)
+	}
+	if got := summary(poll(second.Cursor, 2)); got != "(more=false)" {
+		t.Fatalf("expected no changes, got %s", got)
+	}
+
+	insertMessages(t, path,
+		testMessage{id: 8, roomID: "!room1:beeper.local", eventID: "$evt8", sender: "@me:beeper.local", ts: 1700000000800, hsOrder: 10, isMe: true, text: "new here"},
+		testMessage{id: 9, roomID: "!room1:beeper.local", eventID: "$edit1", sender: "@alice:beeper.local", ts: 1700000000900, hsOrder: 11, text: "* christmas party!",
+			message: `{"text":"* christmas party!","m.new_content":{"body":"christmas party!"},"m.relates_to":{"rel_type":"m.replace","event_id":"$evt2"}}`},
+		testMessage{id: 10, roomID: "!room1:beeper.local", eventID: "$edit2", sender: "@bob:beeper.local", ts: 1700000001000, hsOrder: 12, text: "hijacked",
+			message: `{"m.new_content":{"body":"hijacked"},"m.relates_to":{"rel_type":"m.replace","event_id":"$evt2"}}`},
+		testMessage{id: 11, roomID: "!room1:beeper.local", eventID: "$evt11", sender: "@alice:beeper.local", ts: 1700000001100, hsOrder: 13, isDeleted: true, text: "oops"},
+	)
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if _, err := conn.Exec(`UPDATE mx_room_messages SET isDeleted = 1 WHERE id = 3`); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	_ = conn.Close()
+
+	third := poll(second.Cursor, 10)
+	if got := summary(third); got != "deleted:$evt3 new:$evt8=new here edited:$evt2=christmas party! (more=false)" {
+		t.Fatalf("after changes: %s", got)
+	}
+	if !third.Changes[2].Message.Edited {
+		t.Fatalf("expected edited message, got %+v", third.Changes[2].Message)
+	}
+	if got := summary(poll(third.Cursor, 10)); got != "(more=false)" {
+		t.Fatalf("expected no repeated changes, got %s", got)
+	}
+
+	if _, err := store.ChangesSince(ctx, ChangeOptions{ThreadID: "!room1:beeper.local", Cursor: pageCursor{Kind: cursorMessages}.encode()}); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("expected ErrInvalidCursor, got %v", err)
+	}
+	if _, err := store.ChangesSince(ctx, ChangeOptions{ThreadID: "!missing:beeper.local"}); !errors.Is(err, ErrThreadNotFound) {
+		t.Fatalf("expected ErrThreadNotFound, got %v", err)
+	}
+}
+
+func TestChangesSinceBoundsDeletedCursor(t *testing.T) {
+	path := createTestDB(t, false)
+	// Rows 100-299; the even ones below 280 are deleted.
+	messages := []testMessage{}
+	for id := int64(100); id < 300; id++ {
+		messages = append(messages, testMessage{id: id, roomID: "!room1:beeper.local", eventID: fmt.Sprintf("$x%d", id), sender: "@alice:beeper.local",
+			ts: 1700000100000 + id, hsOrder: int(id), isDeleted: id%2 == 0 && id < 280, text: "row"})
+	}
+	insertMessages(t, path, messages...)
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	ctx := context.Background()
+
+	poll := func(cursor string) ChangeSet {
+		t.Helper()
+		set, err := store.ChangesSince(ctx, ChangeOptions{ThreadID: "!room1:beeper.local", Cursor: cursor, Limit: 1000})
+		if err != nil {
+			t.Fatalf("changes: %v", err)
+		}
+		return set
+	}
+	deletions := func(set ChangeSet) []string {
+		ids := []string{}
+		for _, c := range set.Changes {
+			if c.Kind == ChangeDeleted {
+				ids = append(ids, c.EventID)
+			}
+		}
+		return ids
+	}
+	deleteRow := func(id int) {
+		t.Helper()
+		conn, err := sql.Open("sqlite3", path)
+		if err != nil {
+			t.Fatalf("open sqlite: %v", err)
+		}
+		defer func() { _ = conn.Close() }()
+		if _, err := conn.Exec(`UPDATE mx_room_messages SET isDeleted = 1 WHERE id = ?`, id); err != nil {
+			t.Fatalf("delete: %v", err)
+		}
+	}
+
+	first := poll("")
+	c, err := decodeCursor(first.Cursor, cursorChanges)
+	if err != nil {
+		t.Fatalf("decode cursor: %v", err)
+	}
+	listed, _ := decodeIDs(c.Deleted)
+	if len(listed) != maxCursorDeleted || c.DeletedCount != 90-maxCursorDeleted {
+		t.Fatalf("expected %d listed and %d summarized deleted rows, got %d and %d", maxCursorDeleted, 90-maxCursorDeleted, len(listed), c.DeletedCount)
+	}
+
+	// A deletion among the listed rows is reported alone.
+	deleteRow(281)
+	second := poll(first.Cursor)
+	if got := deletions(second); fmt.Sprint(got) != "[$x281]" {
+		t.Fatalf("expected [$x281], got %v", got)
+	}
+
+	// A deletion below them reports every older deleted row again.
+	deleteRow(101)
+	third := poll(second.Cursor)
+	got := deletions(third)
+	if len(got) != 28 || got[0] != "$x100" || got[1] != "$x101" {
+		t.Fatalf("expected the 28 older deleted rows starting $x100 $x101, got %v", got)
+	}
+	if got := deletions(poll(third.Cursor)); len(got) != 0 {
+		t.Fatalf("expected no repeated deletions, got %v", got)
+	}
+}
+
+func TestEncodeIDsRoundTrip(t *testing.T) {
+	ids := []int64{3, 4, 200, 70000, 70001}
+	got, err := decodeIDs(encodeIDs(ids))
+	if err != nil || fmt.Sprint(got) != fmt.Sprint(ids) {
+		t.Fatalf("round trip: %v %v", got, err)
+	}
+	if _, err := decodeIDs([]byte{0x80}); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("expected ErrInvalidCursor for a truncated varint, got %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package beeper
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// TestStoreConcurrentUse shares one Store between goroutines the way serve
+// does; run with -race to check the bridge cache and lazy discovery.
+func TestStoreConcurrentUse(t *testing.T) {
+	path := createTestDB(t, true)
+	bridgeRoot := createBridgeDB(t)
+	store, err := OpenWithOptions(path, StoreOptions{BridgeLookup: true, BridgeRoot: bridgeRoot})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	ctx := context.Background()
+
+	calls := []func() error{
+		func() error {
+			thread, err := store.GetThread(ctx, "!room4:beeper.local", false)
+			if err == nil && thread.DisplayName != "Bridge Name" {
+				err = errors.New("unexpected DM name " + thread.DisplayName)
+			}
+			return err
+		},
+		func() error {
+			_, err := store.ListThreads(ctx, ThreadListOptions{Limit: 10})
+			return err
+		},
+		func() error {
+			_, err := store.SearchMessages(ctx, SearchOptions{Query: "party", Context: 1})
+			return err
+		},
+		func() error {
+			_, err := store.ListMessagesPage(ctx, MessageListOptions{ThreadID: "!room1:beeper.local", WithEngagement: true})
+			return err
+		},
+		func() error {
+			_ = store.BridgeDBs()
+			return nil
+		},
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8*len(calls))
+	for i := 0; i < 8; i++ {
+		for _, call := range calls {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- call()
+			}()
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBridgeLookupAfterClose(t *testing.T) {
+	lookup, err := NewBridgeLookup("", createBridgeDB(t))
+	if err != nil {
+		t.Fatalf("new bridge lookup: %v", err)
+	}
+	if err := lookup.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if _, _, err := lookup.LookupDMName(context.Background(), "!room4:beeper.local", "whatsapp"); !errors.Is(err, errBridgeClosed) {
+		t.Fatalf("expected errBridgeClosed, got %v", err)
+	}
+	if len(lookup.conns) != 0 {
+		t.Fatalf("lookup reopened %d connections after Close", len(lookup.conns))
+	}
+}
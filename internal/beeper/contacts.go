@@ -0,0 +1,315 @@
+package beeper
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ListContacts returns everyone except the user found in the participants
+// table and in bridge ghost tables, merged by display name, with the
+// platforms and threads they appear in. Contacts are ordered by the number
+// of messages they sent, most first.
+func (s *Store) ListContacts(ctx context.Context, opts ContactOptions) ([]Contact, error) {
+	contacts, err := s.loadContacts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := strings.ToLower(strings.TrimSpace(opts.Query))
+	platform := normalizePlatform(opts.Platform)
+	filtered := []Contact{}
+	for _, c := range contacts {
+		if query != "" && !contactMatches(c, query) {
+			continue
+		}
+		if platform != "" && !slices.Contains(c.Platforms, platform) {
+			continue
+		}
+		c.Threads = nil
+		filtered = append(filtered, c)
+	}
+	if opts.Limit > 0 && len(filtered) > opts.Limit {
+		filtered = filtered[:opts.Limit]
+	}
+	return filtered, nil
+}
+
+// GetContact returns one contact, with its threads, by participant ID or
+// name. An exact ID or name wins; otherwise ref must match exactly one
+// contact as a substring.
+func (s *Store) GetContact(ctx context.Context, ref string) (Contact, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return Contact{}, fmt.Errorf("contact ID or name is required")
+	}
+	contacts, err := s.loadContacts(ctx)
+	if err != nil {
+		return Contact{}, err
+	}
+
+	var found *Contact
+	for i := range contacts {
+		if slices.Contains(contacts[i].IDs, ref) || strings.EqualFold(contacts[i].Name, ref) {
+			found = &contacts[i]
+			break
+		}
+	}
+	if found == nil {
+		matches := []int{}
+		for i := range contacts {
+			if contactMatches(contacts[i], strings.ToLower(ref)) {
+				matches = append(matches, i)
+			}
+		}
+		switch len(matches) {
+		case 0:
+			return Contact{}, fmt.Errorf("no contact matches %q", ref)
+		case 1:
+			found = &contacts[matches[0]]
+		default:
+			names := make([]string, 0, len(matches))
+			for _, i := range matches {
+				names = append(names, contacts[i].Name)
+			}
+			return Contact{}, fmt.Errorf("%q matches %d contacts: %s", ref, len(matches), strings.Join(names, ", "))
+		}
+	}
+
+	threadIDs := make([]string, 0, len(found.Threads))
+	for _, t := range found.Threads {
+		threadIDs = append(threadIDs, t.ThreadID)
+	}
+	names, err := s.threadNames(ctx, threadIDs)
+	if err != nil {
+		return Contact{}, err
+	}
+	for i := range found.Threads {
+		found.Threads[i].ThreadName = names[found.Threads[i].ThreadID].DisplayName
+	}
+	return *found, nil
+}
+
+// PersonTimeline merges what was exchanged with a contact on every
+// platform into one stream, oldest first: all messages in their DM threads,
+// yours included, and the messages they sent in group threads. With a
+// limit, the most recent messages are kept.
+func (s *Store) PersonTimeline(ctx context.Context, contact Contact, opts TimelineOptions) ([]Message, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	if opts.All {
+		// SQLite treats a negative LIMIT as unbounded.
+		limit = -1
+	}
+	rooms := make([]string, 0, len(contact.Threads))
+	for _, t := range contact.Threads {
+		rooms = append(rooms, t.ThreadID)
+	}
+	if len(rooms) == 0 && (opts.DMsOnly || len(contact.IDs) == 0) {
+		return []Message{}, nil
+	}
+
+	query := strings.Builder{}
+	args := []any{}
+	query.WriteString(`SELECT m.id, m.eventID, m.roomID, m.senderContactID, m.timestamp, m.isSentByMe, m.type,
+		COALESCE(m.text_content, '') AS text_content,
+		COALESCE(m.message, '') AS message
+		FROM mx_room_messages m
+		LEFT JOIN threads t ON t.threadID = m.roomID
+		WHERE m.isDeleted = 0 AND m.type NOT IN ('HIDDEN','REACTION')
+		AND ((m.roomID IN (` + placeholders(len(rooms)) + `) AND json_extract(t.thread,'$.type') IN ('single','dm'))`)
+	args = append(args, stringSliceToAny(rooms)...)
+	if !opts.DMsOnly && len(contact.IDs) > 0 {
+		query.WriteString(" OR m.senderContactID IN (" + placeholders(len(contact.IDs)) + ")")
+		args = append(args, stringSliceToAny(contact.IDs)...)
+	}
+	query.WriteString(")")
+	if opts.After != nil {
+		query.WriteString(" AND m.timestamp >= ?")
+		args = append(args, opts.After.UnixMilli())
+	}
+	if opts.Before != nil {
+		query.WriteString(" AND m.timestamp <= ?")
+		args = append(args, opts.Before.UnixMilli())
+	}
+	query.WriteString(" ORDER BY m.timestamp DESC, m.id DESC LIMIT ?")
+	args = append(args, limit)
+
+	messages, err := s.scanMessages(ctx, query.String(), args, opts.Format)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.enrichMessages(ctx, messages); err != nil {
+		return nil, err
+	}
+	slices.Reverse(messages)
+	return messages, nil
+}
+
+// contactBuilder accumulates one contact while scanning.
+type contactBuilder struct {
+	contact Contact
+	ids     map[string]struct{}
+	threads map[string]*ContactThread
+}
+
+func (s *Store) loadContacts(ctx context.Context) ([]Contact, error) {
+	builders := []*contactBuilder{}
+	byKey := map[string]*contactBuilder{}
+	byID := map[string]*contactBuilder{}
+
+	add := func(id string, name string, platform string) *contactBuilder {
+		if b, ok := byID[id]; ok {
+			if platform != "" && !slices.Contains(b.contact.Platforms, platform) {
+				b.contact.Platforms = append(b.contact.Platforms, platform)
+			}
+			return b
+		}
+		if name == "" {
+			name = id
+		}
+		key := strings.ToLower(name)
+		b, ok := byKey[key]
+		if !ok {
+			b = &contactBuilder{contact: Contact{Name: name}, ids: map[string]struct{}{}, threads: map[string]*ContactThread{}}
+			byKey[key] = b
+			builders = append(builders, b)
+		}
+		if _, ok := b.ids[id]; !ok {
+			b.ids[id] = struct{}{}
+			b.contact.IDs = append(b.contact.IDs, id)
+		}
+		byID[id] = b
+		if platform != "" && !slices.Contains(b.contact.Platforms, platform) {
+			b.contact.Platforms = append(b.contact.Platforms, platform)
+		}
+		return b
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT p.id, p.room_id, COALESCE(p.account_id, ''), COALESCE(t.accountID, ''),
+		COALESCE(NULLIF(TRIM(p.full_name), ''), NULLIF(TRIM(p.nickname), ''), '')
+		FROM participants p
+		LEFT JOIN threads t ON t.threadID = p.room_id
+		WHERE COALESCE(p.is_self, 0) = 0
+		ORDER BY p.rowid`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var id, roomID, participantAccount, threadAccount, name string
+		if err := rows.Scan(&id, &roomID, &participantAccount, &threadAccount, &name); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		account := threadAccount
+		if account == "" {
+			account = participantAccount
+		}
+		b := add(id, name, normalizePlatform(account))
+		if _, ok := b.threads[roomID]; !ok {
+			b.threads[roomID] = &ContactThread{ThreadID: roomID, AccountID: account}
+		}
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+
+	if bridge := s.bridgeLookup(); bridge != nil {
+		ghosts, err := bridge.Ghosts(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, ghost := range ghosts {
+			// Ghosts only add platforms (or new people); their IDs are
+			// remote IDs, so merging happens by name.
+			add(ghost.Platform+":"+ghost.ID, ghost.Name, ghost.Platform)
+		}
+	}
+
+	if err := s.countContactMessages(ctx, byID); err != nil {
+		return nil, err
+	}
+
+	contacts := make([]Contact, 0, len(builders))
+	for _, b := range builders {
+		c := b.contact
+		sort.Strings(c.Platforms)
+		for _, t := range b.threads {
+			c.Messages += t.Messages
+			if t.LastMessage.After(c.LastMessage) {
+				c.LastMessage = t.LastMessage
+			}
+			c.Threads = append(c.Threads, *t)
+		}
+		sort.Slice(c.Threads, func(i, j int) bool {
+			if !c.Threads[i].LastMessage.Equal(c.Threads[j].LastMessage) {
+				return c.Threads[i].LastMessage.After(c.Threads[j].LastMessage)
+			}
+			return c.Threads[i].ThreadID < c.Threads[j].ThreadID
+		})
+		c.ThreadCount = len(c.Threads)
+		contacts = append(contacts, c)
+	}
+	sort.SliceStable(contacts, func(i, j int) bool {
+		if contacts[i].Messages != contacts[j].Messages {
+			return contacts[i].Messages > contacts[j].Messages
+		}
+		return strings.ToLower(contacts[i].Name) < strings.ToLower(contacts[j].Name)
+	})
+	return contacts, nil
+}
+
+// countContactMessages adds per-thread message counts and last message
+// times for every known participant.
+func (s *Store) countContactMessages(ctx context.Context, byID map[string]*contactBuilder) error {
+	rows, err := s.db.QueryContext(ctx, `SELECT senderContactID, roomID, COUNT(*), MAX(timestamp)
+		FROM mx_room_messages
+		WHERE isDeleted = 0 AND isSentByMe = 0 AND type NOT IN ('HIDDEN','REACTION')
+		GROUP BY senderContactID, roomID`)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var sender, roomID string
+		var count int
+		var last sql.NullInt64
+		if err := rows.Scan(&sender, &roomID, &count, &last); err != nil {
+			return err
+		}
+		b, ok := byID[sender]
+		if !ok {
+			continue
+		}
+		t, ok := b.threads[roomID]
+		if !ok {
+			t = &ContactThread{ThreadID: roomID}
+			b.threads[roomID] = t
+		}
+		t.Messages += count
+		if last.Valid {
+			if ts := unixMillis(last.Int64); ts.After(t.LastMessage) {
+				t.LastMessage = ts
+			}
+		}
+	}
+	return rows.Err()
+}
+
+func contactMatches(c Contact, query string) bool {
+	if strings.Contains(strings.ToLower(c.Name), query) {
+		return true
+	}
+	for _, id := range c.IDs {
+		if strings.Contains(strings.ToLower(id), query) {
+			return true
+		}
+	}
+	return false
+}
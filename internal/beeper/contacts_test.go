@@ -0,0 +1,137 @@
+package beeper
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+func TestContacts(t *testing.T) {
+	path := createTestDB(t, false)
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	participants := [][]any{
+		{"telegram", "!room2:beeper.local", "@alice_tg:beeper.local", "alice", "", 0},
+		{"telegram", "!room2:beeper.local", "@bob:beeper.local", "", "Bobby", 0},
+		{"telegram", "!room2:beeper.local", "@me:beeper.local", "Me", "", 1},
+	}
+	for _, p := range participants {
+		if _, err := conn.Exec("INSERT INTO participants (account_id, room_id, id, full_name, nickname, is_self) VALUES (?, ?, ?, ?, ?, ?)", p...); err != nil {
+			t.Fatalf("insert participant: %v", err)
+		}
+	}
+	_ = conn.Close()
+
+	store, err := OpenWithOptions(path, StoreOptions{BridgeLookup: true, BridgeRoot: createBridgeDB(t)})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	ctx := context.Background()
+
+	contacts, err := store.ListContacts(ctx, ContactOptions{})
+	if err != nil {
+		t.Fatalf("list contacts: %v", err)
+	}
+	names := []string{}
+	for _, c := range contacts {
+		names = append(names, c.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"Alice", "Bobby", "Bridge Name"}) {
+		t.Fatalf("unexpected contacts: %v", names)
+	}
+	alice := contacts[0]
+	if !reflect.DeepEqual(alice.IDs, []string{"@alice:beeper.local", "@alice_tg:beeper.local"}) ||
+		!reflect.DeepEqual(alice.Platforms, []string{"telegram", "whatsapp"}) ||
+		alice.ThreadCount != 2 || alice.Messages != 4 || alice.Threads != nil {
+		t.Fatalf("unexpected alice: %+v", alice)
+	}
+	if bobby := contacts[1]; bobby.Messages != 1 || bobby.LastMessage.UnixMilli() != 1700000000400 {
+		t.Fatalf("unexpected bobby: %+v", bobby)
+	}
+
+	filtered, err := store.ListContacts(ctx, ContactOptions{Platform: "whatsapp"})
+	if err != nil {
+		t.Fatalf("filter contacts: %v", err)
+	}
+	if len(filtered) != 2 || filtered[1].Name != "Bridge Name" {
+		t.Fatalf("unexpected whatsapp contacts: %+v", filtered)
+	}
+
+	contact, err := store.GetContact(ctx, "@bob:beeper.local")
+	if err != nil {
+		t.Fatalf("get contact: %v", err)
+	}
+	if contact.Name != "Bobby" || len(contact.Threads) != 1 || contact.Threads[0].ThreadName != "Archived" {
+		t.Fatalf("unexpected contact: %+v", contact)
+	}
+	contact, err = store.GetContact(ctx, "alice")
+	if err != nil || contact.Threads[0].ThreadID != "!room1:beeper.local" {
+		t.Fatalf("get contact by name: %+v, %v", contact, err)
+	}
+	if _, err := store.GetContact(ctx, "b"); err == nil {
+		t.Fatalf("expected ambiguous match error")
+	}
+}
+
+func TestPersonTimeline(t *testing.T) {
+	path := createTestDB(t, false)
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	// Alice also has a Telegram DM, under another ID.
+	for _, stmt := range []string{
+		"INSERT INTO threads (threadID, accountID, thread, timestamp) VALUES ('!tg:beeper.local', 'telegram', '{\"type\":\"single\"}', 1700000001000)",
+		"INSERT INTO participants (account_id, room_id, id, full_name, nickname, is_self) VALUES ('telegram', '!tg:beeper.local', '@alice_tg:beeper.local', 'alice', '', 0)",
+		"INSERT INTO participants (account_id, room_id, id, full_name, nickname, is_self) VALUES ('telegram', '!tg:beeper.local', '@me:beeper.local', 'Me', '', 1)",
+	} {
+		if _, err := conn.Exec(stmt); err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+	}
+	_ = conn.Close()
+	insertMessages(t, path,
+		testMessage{roomID: "!tg:beeper.local", eventID: "$tg1", sender: "@alice_tg:beeper.local", ts: 1700000001000, hsOrder: 1, message: `{"text":"moved to telegram"}`},
+		testMessage{roomID: "!tg:beeper.local", eventID: "$tg2", sender: "@me:beeper.local", ts: 1700000002000, hsOrder: 1, isMe: true, message: `{"text":"welcome"}`},
+	)
+
+	store, err := OpenWithOptions(path, StoreOptions{BridgeLookup: false})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	ctx := context.Background()
+
+	alice, err := store.GetContact(ctx, "Alice")
+	if err != nil {
+		t.Fatalf("get contact: %v", err)
+	}
+	before := unixMillis(1700000001500)
+	tests := []struct {
+		name string
+		opts TimelineOptions
+		want []string
+	}{
+		{"all", TimelineOptions{All: true}, []string{"$evt1", "$evt2", "$evt3", "$evt7", "$tg1", "$tg2"}},
+		{"most recent", TimelineOptions{Limit: 3}, []string{"$evt7", "$tg1", "$tg2"}},
+		{"dms only", TimelineOptions{DMsOnly: true}, []string{"$tg1", "$tg2"}},
+		{"before", TimelineOptions{Before: &before, Limit: 2}, []string{"$evt7", "$tg1"}},
+	}
+	for _, tt := range tests {
+		messages, err := store.PersonTimeline(ctx, alice, tt.opts)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		got := []string{}
+		for _, msg := range messages {
+			got = append(got, msg.EventID)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package beeper
+
+import "sort"
+
+// MergeSearchContext groups search results whose context overlaps into
+// blocks, so hits close together in one conversation share a single run of
+// messages instead of repeating it per hit. Two results overlap when they
+// are in the same thread and their time spans (match plus context) meet.
+// Blocks are ordered by their best-ranked hit; Matches lists hit IDs in
+// rank order.
+func MergeSearchContext(results []SearchResult) []SearchBlock {
+	type span struct {
+		ranks []int
+		start int64
+		end   int64
+	}
+
+	byThread := map[string][]*span{}
+	threads := []string{}
+	for i, result := range results {
+		sp := &span{ranks: []int{i}, start: result.Match.Timestamp.UnixMilli(), end: result.Match.Timestamp.UnixMilli()}
+		for _, msg := range result.Context {
+			sp.start = min(sp.start, msg.Timestamp.UnixMilli())
+			sp.end = max(sp.end, msg.Timestamp.UnixMilli())
+		}
+		threadID := result.Match.ThreadID
+		if _, ok := byThread[threadID]; !ok {
+			threads = append(threads, threadID)
+		}
+		byThread[threadID] = append(byThread[threadID], sp)
+	}
+
+	merged := []*span{}
+	for _, threadID := range threads {
+		spans := byThread[threadID]
+		sort.SliceStable(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+		cur := spans[0]
+		for _, sp := range spans[1:] {
+			if sp.start > cur.end {
+				merged = append(merged, cur)
+				cur = sp
+				continue
+			}
+			cur.ranks = append(cur.ranks, sp.ranks...)
+			cur.end = max(cur.end, sp.end)
+		}
+		merged = append(merged, cur)
+	}
+	for _, sp := range merged {
+		sort.Ints(sp.ranks)
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].ranks[0] < merged[j].ranks[0] })
+
+	blocks := make([]SearchBlock, 0, len(merged))
+	for _, sp := range merged {
+		best := results[sp.ranks[0]].Match
+		block := SearchBlock{ThreadID: best.ThreadID, ThreadName: best.ThreadName, AccountID: best.AccountID}
+		// Matches go first so they win over their copies in another
+		// hit's context.
+		seen := map[int64]struct{}{}
+		for _, r := range sp.ranks {
+			match := results[r].Match
+			block.Matches = append(block.Matches, match.ID)
+			if _, ok := seen[match.ID]; !ok {
+				seen[match.ID] = struct{}{}
+				block.Messages = append(block.Messages, match)
+			}
+		}
+		for _, r := range sp.ranks {
+			for _, msg := range results[r].Context {
+				if _, ok := seen[msg.ID]; ok {
+					continue
+				}
+				seen[msg.ID] = struct{}{}
+				block.Messages = append(block.Messages, msg)
+			}
+		}
+		sort.SliceStable(block.Messages, func(i, j int) bool {
+			a, b := block.Messages[i], block.Messages[j]
+			if !a.Timestamp.Equal(b.Timestamp) {
+				return a.Timestamp.Before(b.Timestamp)
+			}
+			return a.ID < b.ID
+		})
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
@@ -0,0 +1,71 @@
+package beeper
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMergeSearchContext(t *testing.T) {
+	base := time.UnixMilli(1700000000000)
+	msg := func(id int64, thread string, minutes int) Message {
+		return Message{ID: id, ThreadID: thread, Timestamp: base.Add(time.Duration(minutes) * time.Minute)}
+	}
+	results := []SearchResult{
+		// Best hit: message 3 in room A, context 2..4.
+		{Match: msg(3, "!a", 3), Context: []Message{msg(2, "!a", 2), msg(4, "!a", 4)}},
+		// Unrelated room.
+		{Match: msg(20, "!b", 3), Context: []Message{msg(21, "!b", 4)}},
+		// Overlaps the first block via message 4.
+		{Match: msg(5, "!a", 5), Context: []Message{msg(4, "!a", 4), msg(6, "!a", 6)}},
+		// Same room, much later: separate block.
+		{Match: msg(9, "!a", 90), Context: []Message{msg(8, "!a", 89)}},
+		// Touches the first block in time; context copy of 3 is deduplicated.
+		{Match: msg(2, "!a", 2), Context: []Message{msg(1, "!a", 1), msg(3, "!a", 3)}},
+	}
+
+	blocks := MergeSearchContext(results)
+	if len(blocks) != 3 {
+		t.Fatalf("expected 3 blocks, got %d", len(blocks))
+	}
+	first := blocks[0]
+	if first.ThreadID != "!a" || len(first.Matches) != 3 || first.Matches[0] != 3 || first.Matches[1] != 5 || first.Matches[2] != 2 {
+		t.Fatalf("unexpected first block matches: %+v", first.Matches)
+	}
+	ids := []int64{}
+	for _, m := range first.Messages {
+		ids = append(ids, m.ID)
+	}
+	if len(ids) != 6 || ids[0] != 1 || ids[5] != 6 {
+		t.Fatalf("expected messages 1..6 in order, got %v", ids)
+	}
+	if blocks[1].ThreadID != "!b" || blocks[2].Matches[0] != 9 {
+		t.Fatalf("unexpected block order: %+v / %+v", blocks[1].Matches, blocks[2].Matches)
+	}
+}
+
+func TestGetMessageByEventID(t *testing.T) {
+	path := createTestDB(t, false)
+	store, err := OpenWithOptions(path, StoreOptions{BridgeLookup: false})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	ctx := context.Background()
+
+	result, err := store.GetMessageByEventID(ctx, "$evt3", SearchOptions{Context: 1})
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if result.Match.EventID != "$evt3" || result.Match.Text != "see you" {
+		t.Fatalf("unexpected match: %+v", result.Match)
+	}
+	if len(result.Context) != 2 || result.Context[0].EventID != "$evt2" || result.Context[1].EventID != "$evt7" {
+		t.Fatalf("unexpected context: %+v", result.Context)
+	}
+
+	if _, err := store.GetMessageByEventID(ctx, "$missing", SearchOptions{}); !errors.Is(err, ErrMessageNotFound) {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+}
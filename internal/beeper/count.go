@@ -0,0 +1,97 @@
+package beeper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// CountMessages counts the messages ListMessages would list for opts with
+// one COUNT(*) query, loading no rows. Unlike ListMessages it counts every
+// thread when opts.ThreadID is empty; Limit, All, Cursor, Format, and the
+// enrichment options do not apply, and TopEngaged is not supported.
+func (s *Store) CountMessages(ctx context.Context, opts MessageCountOptions) (int, error) {
+	if opts.TopEngaged {
+		return 0, errors.New("counting top-engaged messages is not supported")
+	}
+	if opts.UnreadOnly && opts.ThreadID == "" {
+		return 0, errors.New("counting unread messages requires a thread")
+	}
+	query := strings.Builder{}
+	args := []any{}
+	query.WriteString(`SELECT COUNT(*) FROM mx_room_messages m
+		WHERE m.type NOT IN ('HIDDEN','REACTION')`)
+	if opts.ThreadID != "" {
+		query.WriteString(" AND m.roomID = ?")
+		args = append(args, opts.ThreadID)
+	}
+	if opts.AccountID != "" {
+		query.WriteString(" AND m.roomID IN (SELECT threadID FROM threads WHERE accountID = ?)")
+		args = append(args, opts.AccountID)
+	}
+	switch {
+	case opts.SentByMe:
+		query.WriteString(" AND m.isSentByMe = 1")
+	case opts.Received:
+		query.WriteString(" AND m.isSentByMe = 0")
+	}
+	conditions, conditionArgs, err := s.messageConditions(ctx, opts.MessageListOptions)
+	if err != nil {
+		return 0, err
+	}
+	query.WriteString(conditions)
+	args = append(args, conditionArgs...)
+
+	var count int
+	if err := s.db.QueryRowContext(ctx, query.String(), args...).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// CountThreads counts the threads ListThreads would list for opts, ignoring
+// Limit and Sort. Labels depend on each thread's archive state, so the
+// threads are read, but their participants and names are not resolved.
+func (s *Store) CountThreads(ctx context.Context, opts ThreadListOptions) (int, error) {
+	opts.Sort = SortActivity
+	threads, err := s.queryThreads(ctx, opts, -1)
+	if err != nil {
+		return 0, err
+	}
+	return len(threads), nil
+}
+
+// CountSearchMatches counts the messages SearchMessages would match for
+// opts with one COUNT(*) query, loading no rows; Limit, Cursor, Context,
+// and Window do not apply. Like SearchMessages it falls back to substring
+// matching without FTS unless opts.RequireFTS is set.
+func (s *Store) CountSearchMatches(ctx context.Context, opts SearchOptions) (int, error) {
+	if strings.TrimSpace(opts.Query) == "" && opts.MessageIDs == nil && len(opts.Emoji) == 0 && strings.TrimSpace(opts.Type) == "" {
+		return 0, errors.New("search query is required")
+	}
+	useFTS, err := s.HasFTS(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if strings.TrimSpace(opts.Query) == "" {
+		useFTS = false
+	} else if opts.RequireFTS && !useFTS {
+		return 0, fmt.Errorf("%w: %s has no mx_room_messages_fts table", ErrFTSUnavailable, s.path)
+	}
+
+	var count int
+	query, args := searchQuery(opts, useFTS, nil, 0, true)
+	err = s.db.QueryRowContext(ctx, query, args...).Scan(&count)
+	if err != nil && useFTS && isFTSError(err) {
+		if opts.RequireFTS {
+			return 0, fmt.Errorf("%w: %v", ErrFTSUnavailable, err)
+		}
+		query, args = searchQuery(opts, false, nil, 0, true)
+		err = s.db.QueryRowContext(ctx, query, args...).Scan(&count)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
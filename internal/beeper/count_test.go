@@ -0,0 +1,138 @@
+package beeper
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCountsMatchListings(t *testing.T) {
+	path := createTestDB(t, false)
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	ctx := context.Background()
+
+	listOpts := MessageListOptions{ThreadID: "!room1:beeper.local", All: true, Include: []string{"e"}}
+	messages, err := store.ListMessages(ctx, listOpts)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	count, err := store.CountMessages(ctx, MessageCountOptions{MessageListOptions: listOpts})
+	if err != nil || count != len(messages) || count == 0 {
+		t.Fatalf("expected %d messages, got %d (%v)", len(messages), count, err)
+	}
+
+	all, err := store.CountMessages(ctx, MessageCountOptions{})
+	if err != nil {
+		t.Fatalf("count all: %v", err)
+	}
+	mine, err := store.CountMessages(ctx, MessageCountOptions{SentByMe: true})
+	if err != nil {
+		t.Fatalf("count mine: %v", err)
+	}
+	received, err := store.CountMessages(ctx, MessageCountOptions{Received: true})
+	if err != nil {
+		t.Fatalf("count received: %v", err)
+	}
+	if all <= count || mine+received != all {
+		t.Fatalf("unexpected totals: all=%d mine=%d received=%d", all, mine, received)
+	}
+	if _, err := store.CountMessages(ctx, MessageCountOptions{MessageListOptions: MessageListOptions{UnreadOnly: true}}); err == nil {
+		t.Fatalf("expected unread counting without a thread to fail")
+	}
+
+	threadOpts := ThreadListOptions{All: true, Label: LabelInbox}
+	threads, err := store.ListThreads(ctx, threadOpts)
+	if err != nil {
+		t.Fatalf("list threads: %v", err)
+	}
+	threadOpts.Limit = 1
+	threadOpts.All = false
+	if n, err := store.CountThreads(ctx, threadOpts); err != nil || n != len(threads) || n < 2 {
+		t.Fatalf("expected %d threads, got %d (%v)", len(threads), n, err)
+	}
+
+	searchOpts := SearchOptions{Query: "christmas", Limit: 1000}
+	results, err := store.SearchMessages(ctx, searchOpts)
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	searchOpts.Limit = 0
+	if n, err := store.CountSearchMatches(ctx, searchOpts); err != nil || n != len(results) || n == 0 {
+		t.Fatalf("expected %d matches, got %d (%v)", len(results), n, err)
+	}
+}
+
+func TestSearchAfter(t *testing.T) {
+	path := createTestDB(t, false)
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	ctx := context.Background()
+
+	all, err := store.SearchMessages(ctx, SearchOptions{Query: "e", Limit: 100})
+	if err != nil || len(all) < 2 {
+		t.Fatalf("search: %d results, %v", len(all), err)
+	}
+	after := all[0].Match.Timestamp
+	for _, r := range all {
+		if r.Match.Timestamp.After(after) {
+			after = r.Match.Timestamp
+		}
+	}
+	recent, err := store.SearchMessages(ctx, SearchOptions{Query: "e", Limit: 100, After: &after})
+	if err != nil {
+		t.Fatalf("search after: %v", err)
+	}
+	if len(recent) == 0 || len(recent) >= len(all) {
+		t.Fatalf("expected After to keep only the newest of %d results, got %d", len(all), len(recent))
+	}
+	for _, r := range recent {
+		if r.Match.Timestamp.Before(after) {
+			t.Fatalf("result before --since: %+v", r.Match)
+		}
+	}
+	if n, err := store.CountSearchMatches(ctx, SearchOptions{Query: "e", After: &after}); err != nil || n != len(recent) {
+		t.Fatalf("count after: %d, %v; want %d", n, err, len(recent))
+	}
+}
+
+func TestSearchBefore(t *testing.T) {
+	path := createTestDB(t, false)
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	ctx := context.Background()
+
+	all, err := store.SearchMessages(ctx, SearchOptions{Query: "e", Limit: 100})
+	if err != nil || len(all) < 2 {
+		t.Fatalf("search: %d results, %v", len(all), err)
+	}
+	before := all[0].Match.Timestamp
+	for _, r := range all {
+		if r.Match.Timestamp.Before(before) {
+			before = r.Match.Timestamp
+		}
+	}
+	old, err := store.SearchMessages(ctx, SearchOptions{Query: "e", Limit: 100, Before: &before})
+	if err != nil {
+		t.Fatalf("search before: %v", err)
+	}
+	if len(old) == 0 || len(old) >= len(all) {
+		t.Fatalf("expected Before to keep only the oldest of %d results, got %d", len(all), len(old))
+	}
+	for _, r := range old {
+		if r.Match.Timestamp.After(before) {
+			t.Fatalf("result after --before: %+v", r.Match)
+		}
+	}
+	if n, err := store.CountSearchMatches(ctx, SearchOptions{Query: "e", Before: &before}); err != nil || n != len(old) {
+		t.Fatalf("count before: %d, %v; want %d", n, err, len(old))
+	}
+}
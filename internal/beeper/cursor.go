@@ -0,0 +1,63 @@
+package beeper
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// ErrInvalidCursor is returned for a cursor that is malformed or was issued
+// for a different kind of listing.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+const (
+	cursorMessages   = "messages"
+	cursorTopEngaged = "engaged"
+	cursorSearch     = "search"
+	cursorSearchLike = "search-like"
+	cursorChanges    = "changes"
+)
+
+// pageCursor is the sort key of the last row of a page. Pages continue
+// strictly after it (keyset pagination), so no rows are skipped with
+// OFFSET and rows inserted meanwhile do not shift later pages.
+type pageCursor struct {
+	Kind string `json:"k"`
+	// Rank is the FTS rank or the engagement count, depending on Kind.
+	Rank      float64 `json:"r,omitempty"`
+	Timestamp int64   `json:"t"`
+	ID        int64   `json:"i"`
+	// Deleted holds the IDs of the newest rows already deleted at a changes
+	// cursor, as uvarint deltas; DeletedCount and DeletedSum summarize the
+	// older ones (see deletedSum).
+	Deleted      []byte `json:"d,omitempty"`
+	DeletedCount int64  `json:"dn,omitempty"`
+	DeletedSum   uint64 `json:"ds,omitempty"`
+}
+
+func (c pageCursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses an opaque cursor of one of the given kinds. An empty
+// cursor decodes to nil (the first page).
+func decodeCursor(value string, kinds ...string) (*pageCursor, error) {
+	if value == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c pageCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	for _, kind := range kinds {
+		if c.Kind == kind {
+			return &c, nil
+		}
+	}
+	return nil, ErrInvalidCursor
+}
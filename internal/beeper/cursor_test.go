@@ -0,0 +1,86 @@
+package beeper
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestListMessagesPage(t *testing.T) {
+	path := createTestDB(t, false)
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	ctx := context.Background()
+
+	all, err := store.ListMessages(ctx, MessageListOptions{ThreadID: "!room1:beeper.local", All: true})
+	if err != nil {
+		t.Fatalf("list messages: %v", err)
+	}
+
+	paged := []Message{}
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > len(all) {
+			t.Fatal("paging did not terminate")
+		}
+		page, err := store.ListMessagesPage(ctx, MessageListOptions{ThreadID: "!room1:beeper.local", Limit: 3, Cursor: cursor})
+		if err != nil {
+			t.Fatalf("list page: %v", err)
+		}
+		paged = append(paged, page.Messages...)
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	if !slices.EqualFunc(all, paged, func(a, b Message) bool { return a.ID == b.ID }) {
+		t.Fatalf("expected pages to cover %d messages in order, got %d", len(all), len(paged))
+	}
+
+	if _, err := store.ListMessagesPage(ctx, MessageListOptions{ThreadID: "!room1:beeper.local", Cursor: "not-a-cursor"}); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("expected ErrInvalidCursor, got %v", err)
+	}
+	engaged := pageCursor{Kind: cursorTopEngaged}.encode()
+	if _, err := store.ListMessagesPage(ctx, MessageListOptions{ThreadID: "!room1:beeper.local", Cursor: engaged}); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("expected a top-engaged cursor to be rejected, got %v", err)
+	}
+}
+
+func TestSearchMessagesPage(t *testing.T) {
+	path := createTestDB(t, false)
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	ctx := context.Background()
+
+	ids := []int64{}
+	cursor := ""
+	for {
+		page, err := store.SearchMessagesPage(ctx, SearchOptions{Query: "e", Limit: 2, Cursor: cursor})
+		if err != nil {
+			t.Fatalf("search page: %v", err)
+		}
+		for _, r := range page.Results {
+			ids = append(ids, r.Match.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+	// "invoice due", "archived", "see you", and "hello", newest first.
+	if want := []int64{7, 4, 3, 1}; !slices.Equal(ids, want) {
+		t.Fatalf("expected %v, got %v", want, ids)
+	}
+
+	messages := pageCursor{Kind: cursorMessages}.encode()
+	if _, err := store.SearchMessagesPage(ctx, SearchOptions{Query: "e", Cursor: messages}); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("expected a message cursor to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package beeper
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+)
+
+// UndecryptedText stands in for the text of a message Beeper has stored
+// but not decrypted yet.
+const UndecryptedText = "[Not yet decrypted]"
+
+// undecryptedCondition matches messages Beeper has not decrypted yet: rows
+// typed ENCRYPTED, and rows without text whose payload is still an
+// m.room.encrypted event (one carrying ciphertext). It expects the
+// messages table to be aliased m.
+const undecryptedCondition = `(m.type = 'ENCRYPTED' OR (COALESCE(m.text_content, '') = ''
+		AND json_valid(m.message)
+		AND (json_extract(m.message, '$.type') = 'm.room.encrypted' OR json_extract(m.message, '$.ciphertext') IS NOT NULL)))`
+
+// skipUndecrypted returns the condition leaving out undecrypted messages
+// when skip is set, else nothing.
+func skipUndecrypted(skip bool) string {
+	if !skip {
+		return ""
+	}
+	return " AND NOT " + undecryptedCondition
+}
+
+// isUndecrypted reports whether a message row matches undecryptedCondition.
+func isUndecrypted(rawMessage string, msgType string, textContent string) bool {
+	if strings.EqualFold(strings.TrimSpace(msgType), "ENCRYPTED") {
+		return true
+	}
+	if strings.TrimSpace(textContent) != "" || strings.TrimSpace(rawMessage) == "" {
+		return false
+	}
+	var payload map[string]any
+	if err := json.Unmarshal([]byte(rawMessage), &payload); err != nil {
+		return false
+	}
+	if eventType, _ := payload["type"].(string); eventType == "m.room.encrypted" {
+		return true
+	}
+	_, ok := payload["ciphertext"]
+	return ok
+}
+
+// UndecryptedCount is how many messages in one thread are not decrypted
+// yet.
+type UndecryptedCount struct {
+	ThreadID  string `json:"threadId"`
+	Name      string `json:"name"`
+	AccountID string `json:"accountId,omitempty"`
+	Messages  int    `json:"messages"`
+}
+
+// UndecryptedCounts returns, per thread, how many visible messages Beeper
+// has not decrypted yet, most first. Threads without any are left out.
+func (s *Store) UndecryptedCounts(ctx context.Context) ([]UndecryptedCount, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT m.roomID, COUNT(*) AS n
+		FROM mx_room_messages m
+		WHERE m.isDeleted = 0 AND m.type NOT IN ('HIDDEN','REACTION') AND `+undecryptedCondition+`
+		GROUP BY m.roomID
+		ORDER BY n DESC, m.roomID`)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	counts := []UndecryptedCount{}
+	for rows.Next() {
+		var count UndecryptedCount
+		if err := rows.Scan(&count.ThreadID, &count.Messages); err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(counts))
+	for _, count := range counts {
+		ids = append(ids, count.ThreadID)
+	}
+	threads, err := s.threadNames(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range counts {
+		counts[i].Name = threads[counts[i].ThreadID].DisplayName
+		counts[i].AccountID = threads[counts[i].ThreadID].AccountID
+	}
+	return counts, nil
+}
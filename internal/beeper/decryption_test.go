@@ -0,0 +1,100 @@
+package beeper
+
+import (
+	"context"
+	"testing"
+)
+
+func TestUndecryptedMessages(t *testing.T) {
+	path := createTestDB(t, false)
+	rows := []testMessage{
+		{roomID: "!room1:beeper.local", eventID: "$enc1", sender: "@alice:beeper.local", ts: 1700000002000, typ: "ENCRYPTED", hsOrder: 20, message: `{}`},
+		{roomID: "!room1:beeper.local", eventID: "$enc2", sender: "@alice:beeper.local", ts: 1700000002001, hsOrder: 20,
+			message: `{"type":"m.room.encrypted","algorithm":"m.megolm.v1.aes-sha2","ciphertext":"AwgAEn..."}`},
+		{roomID: "!room1:beeper.local", eventID: "$enc3", sender: "@alice:beeper.local", ts: 1700000002002, hsOrder: 20,
+			message: `{"algorithm":"m.megolm.v1.aes-sha2","ciphertext":"AwgBEn..."}`},
+	}
+	insertMessages(t, path, rows...)
+
+	store, err := OpenWithOptions(path, StoreOptions{BridgeLookup: false})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	ctx := context.Background()
+
+	for _, format := range []MessageFormat{FormatRich, FormatPlain} {
+		messages, err := store.ListMessages(ctx, MessageListOptions{ThreadID: "!room1:beeper.local", All: true, Format: format})
+		if err != nil {
+			t.Fatalf("list: %v", err)
+		}
+		undecrypted := 0
+		for _, msg := range messages {
+			if msg.Undecrypted {
+				undecrypted++
+				if msg.Text != UndecryptedText {
+					t.Fatalf("expected placeholder for %s, got %q", msg.EventID, msg.Text)
+				}
+			} else if msg.Text == UndecryptedText {
+				t.Fatalf("unexpected placeholder for %s", msg.EventID)
+			}
+		}
+		if undecrypted != len(rows) {
+			t.Fatalf("expected %d undecrypted messages with %s, got %d", len(rows), format, undecrypted)
+		}
+	}
+
+	all, err := store.ListMessages(ctx, MessageListOptions{ThreadID: "!room1:beeper.local", All: true})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	skipped, err := store.ListMessages(ctx, MessageListOptions{ThreadID: "!room1:beeper.local", All: true, SkipUndecrypted: true})
+	if err != nil {
+		t.Fatalf("list skipped: %v", err)
+	}
+	if len(all)-len(skipped) != len(rows) {
+		t.Fatalf("expected --skip-undecrypted to drop %d messages, dropped %d", len(rows), len(all)-len(skipped))
+	}
+	for _, msg := range skipped {
+		if msg.Undecrypted {
+			t.Fatalf("skipped list still has %s", msg.EventID)
+		}
+	}
+
+	thread, err := store.GetThread(ctx, "!room1:beeper.local", false)
+	if err != nil {
+		t.Fatalf("get thread: %v", err)
+	}
+	if thread.Undecrypted != len(rows) {
+		t.Fatalf("expected %d undecrypted in thread, got %d", len(rows), thread.Undecrypted)
+	}
+
+	counts, err := store.UndecryptedCounts(ctx)
+	if err != nil {
+		t.Fatalf("undecrypted counts: %v", err)
+	}
+	if len(counts) != 1 || counts[0].ThreadID != "!room1:beeper.local" || counts[0].Messages != len(rows) || counts[0].Name == "" {
+		t.Fatalf("unexpected counts: %+v", counts)
+	}
+
+	ids := []int64{}
+	for _, msg := range all {
+		if msg.Undecrypted {
+			ids = append(ids, msg.ID)
+		}
+	}
+	results, err := store.SearchMessages(ctx, SearchOptions{MessageIDs: ids, Limit: 10})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(results) != len(rows) {
+		t.Fatalf("expected %d undecrypted search results, got %d", len(rows), len(results))
+	}
+	results, err = store.SearchMessages(ctx, SearchOptions{MessageIDs: ids, Limit: 10, SkipUndecrypted: true})
+	if err != nil {
+		t.Fatalf("search skipped: %v", err)
+	}
+	if len(results) != 0 {
+		t.Fatalf("expected search to skip undecrypted messages, got %d", len(results))
+	}
+}
@@ -0,0 +1,85 @@
+package beeper
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// contentHash identifies a message by sender, timestamp, and body. It is the
+// fallback identity for copies of the same message that carry different event
+// IDs, e.g. an FTS hit and a sidecar media hit for the same message.
+func (m Message) contentHash() string {
+	h := sha256.New()
+	h.Write([]byte(m.SenderID))
+	h.Write([]byte{0})
+	h.Write([]byte(strconv.FormatInt(m.Timestamp.UnixMilli(), 10)))
+	h.Write([]byte{0})
+	h.Write([]byte(strings.Join(strings.Fields(m.Text), " ")))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// dedupeMessages removes repeated messages, keeping the first occurrence.
+// Messages are matched by event ID first and by contentHash otherwise.
+func dedupeMessages(messages []Message) []Message {
+	seenEvents := map[string]struct{}{}
+	seenHashes := map[string]struct{}{}
+	unique := make([]Message, 0, len(messages))
+	for _, msg := range messages {
+		if msg.EventID != "" {
+			if _, ok := seenEvents[msg.EventID]; ok {
+				continue
+			}
+		}
+		hash := msg.contentHash()
+		if _, ok := seenHashes[hash]; ok {
+			continue
+		}
+		if msg.EventID != "" {
+			seenEvents[msg.EventID] = struct{}{}
+		}
+		seenHashes[hash] = struct{}{}
+		unique = append(unique, msg)
+	}
+	return unique
+}
+
+// MergeSearchResults unions several result lists, such as FTS hits and
+// sidecar media hits, dropping repeated matches and keeping the best-ranked
+// copy. Context messages are deduplicated too.
+func MergeSearchResults(lists ...[]SearchResult) []SearchResult {
+	merged := []SearchResult{}
+	for _, list := range lists {
+		merged = append(merged, list...)
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		if merged[i].Match.Score != merged[j].Match.Score {
+			return merged[i].Match.Score < merged[j].Match.Score
+		}
+		return merged[i].Match.Timestamp.After(merged[j].Match.Timestamp)
+	})
+
+	seenEvents := map[string]struct{}{}
+	seenHashes := map[string]struct{}{}
+	unique := make([]SearchResult, 0, len(merged))
+	for _, result := range merged {
+		if result.Match.EventID != "" {
+			if _, ok := seenEvents[result.Match.EventID]; ok {
+				continue
+			}
+		}
+		hash := result.Match.contentHash()
+		if _, ok := seenHashes[hash]; ok {
+			continue
+		}
+		if result.Match.EventID != "" {
+			seenEvents[result.Match.EventID] = struct{}{}
+		}
+		seenHashes[hash] = struct{}{}
+		result.Context = dedupeMessages(result.Context)
+		unique = append(unique, result)
+	}
+	return unique
+}
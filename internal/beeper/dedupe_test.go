@@ -0,0 +1,44 @@
+package beeper
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDedupeMessages(t *testing.T) {
+	base := time.UnixMilli(1700000000000)
+	a := []Message{
+		{EventID: "$1", SenderID: "@alice", Timestamp: base, Text: "hello"},
+		{EventID: "$2", SenderID: "@bob", Timestamp: base.Add(time.Second), Text: "hi there"},
+	}
+	b := []Message{
+		{EventID: "$1", SenderID: "@alice", Timestamp: base, Text: "hello"},
+		{EventID: "$2-remote", SenderID: "@bob", Timestamp: base.Add(time.Second), Text: "hi  there "},
+		{EventID: "$3", SenderID: "@alice", Timestamp: base.Add(2 * time.Second), Text: "bye"},
+	}
+
+	unique := dedupeMessages(append(a, b...))
+	if len(unique) != 3 {
+		t.Fatalf("expected 3 messages, got %d", len(unique))
+	}
+	if unique[0].EventID != "$1" || unique[1].EventID != "$2" || unique[2].EventID != "$3" {
+		t.Fatalf("unexpected messages: %s %s %s", unique[0].EventID, unique[1].EventID, unique[2].EventID)
+	}
+}
+
+func TestMergeSearchResultsKeepsBestRank(t *testing.T) {
+	base := time.UnixMilli(1700000000000)
+	match := Message{EventID: "$1", SenderID: "@alice", Timestamp: base, Text: "invoice"}
+	worse := match
+	worse.Score = -1
+	better := match
+	better.Score = -5
+
+	merged := MergeSearchResults([]SearchResult{{Match: worse}}, []SearchResult{{Match: better}})
+	if len(merged) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(merged))
+	}
+	if merged[0].Match.Score != -5 {
+		t.Fatalf("expected best score, got %.1f", merged[0].Match.Score)
+	}
+}
@@ -0,0 +1,98 @@
+package beeper
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+)
+
+// notEditCondition excludes m.replace edit events, which ShowEdits folds
+// into the message they edit.
+const notEditCondition = ` AND COALESCE(CASE WHEN json_valid(m.message)
+		THEN json_extract(m.message, '$."m.relates_to".rel_type') END, '') != 'm.replace'`
+
+// edit is one m.replace event.
+type edit struct {
+	target string
+	sender string
+	text   string
+}
+
+// attachEdits applies the m.replace edits in a thread to messages: an
+// edited message gets the text of its latest edit, Edited, and its earlier
+// texts, oldest first, in PreviousVersions. As in Matrix, only edits by the
+// original sender count.
+func (s *Store) attachEdits(ctx context.Context, threadID string, messages []Message, format MessageFormat) error {
+	edits, err := s.threadEdits(ctx, threadID, format)
+	if err != nil {
+		return err
+	}
+	byTarget := map[string][]edit{}
+	for _, e := range edits {
+		byTarget[e.target] = append(byTarget[e.target], e)
+	}
+	for i := range messages {
+		msg := &messages[i]
+		versions := []string{msg.Text}
+		for _, e := range byTarget[msg.EventID] {
+			if e.sender == msg.SenderID {
+				versions = append(versions, e.text)
+			}
+		}
+		if len(versions) == 1 {
+			continue
+		}
+		msg.Edited = true
+		msg.Text = versions[len(versions)-1]
+		msg.PreviousVersions = versions[:len(versions)-1]
+	}
+	return nil
+}
+
+// threadEdits returns a thread's edit events in the order they were sent.
+func (s *Store) threadEdits(ctx context.Context, threadID string, format MessageFormat) ([]edit, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT json_extract(message, '$."m.relates_to".event_id'), senderContactID, type,
+		COALESCE(text_content, ''), message
+		FROM mx_room_messages
+		WHERE roomID = ?
+		AND isDeleted = 0
+		AND json_valid(message)
+		AND json_extract(message, '$."m.relates_to".rel_type') = 'm.replace'
+		ORDER BY timestamp ASC, id ASC`, threadID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	edits := []edit{}
+	for rows.Next() {
+		var target sql.NullString
+		var sender, msgType, textContent, raw string
+		if err := rows.Scan(&target, &sender, &msgType, &textContent, &raw); err != nil {
+			return nil, err
+		}
+		if target.String == "" {
+			continue
+		}
+		edits = append(edits, edit{target: target.String, sender: sender, text: editText(raw, msgType, textContent, format)})
+	}
+	return edits, rows.Err()
+}
+
+// editText renders the replacement content (m.new_content) of an edit. The
+// top-level body is a "* "-prefixed fallback for clients without edits.
+func editText(raw string, msgType string, textContent string, format MessageFormat) string {
+	var payload struct {
+		NewContent json.RawMessage `json:"m.new_content"`
+	}
+	if err := json.Unmarshal([]byte(raw), &payload); err == nil && len(payload.NewContent) > 0 {
+		var content struct {
+			Body string `json:"body"`
+		}
+		if err := json.Unmarshal(payload.NewContent, &content); err == nil {
+			return ResolveMessageText(string(payload.NewContent), msgType, content.Body, format)
+		}
+	}
+	return strings.TrimPrefix(ResolveMessageText(raw, msgType, textContent, format), "* ")
+}
@@ -0,0 +1,64 @@
+package beeper
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestListMessagesDeletedAndEdits(t *testing.T) {
+	path := createTestDB(t, false)
+	insertMessages(t, path,
+		testMessage{roomID: "!room1:beeper.local", eventID: "$gone", sender: "@alice:beeper.local", ts: 1700000001000, hsOrder: 20, isDeleted: true, text: "oops"},
+		testMessage{roomID: "!room1:beeper.local", eventID: "$edit1", sender: "@alice:beeper.local", ts: 1700000001100, hsOrder: 20, text: "* hello there",
+			message: `{"body":"* hello there","m.new_content":{"body":"hello there"},"m.relates_to":{"rel_type":"m.replace","event_id":"$evt1"}}`},
+		testMessage{roomID: "!room1:beeper.local", eventID: "$edit2", sender: "@alice:beeper.local", ts: 1700000001200, hsOrder: 20, text: "* hello again",
+			message: `{"body":"* hello again","m.new_content":{"body":"hello again"},"m.relates_to":{"rel_type":"m.replace","event_id":"$evt1"}}`},
+		testMessage{roomID: "!room1:beeper.local", eventID: "$forged", sender: "@bob:beeper.local", ts: 1700000001300, hsOrder: 20, text: "* forged",
+			message: `{"body":"* forged","m.new_content":{"body":"forged"},"m.relates_to":{"rel_type":"m.replace","event_id":"$evt1"}}`},
+	)
+
+	store, err := OpenWithOptions(path, StoreOptions{BridgeLookup: false})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	ctx := context.Background()
+
+	list := func(opts MessageListOptions) map[string]Message {
+		t.Helper()
+		opts.ThreadID, opts.All, opts.Format = "!room1:beeper.local", true, FormatPlain
+		messages, err := store.ListMessages(ctx, opts)
+		if err != nil {
+			t.Fatalf("list: %v", err)
+		}
+		byEvent := map[string]Message{}
+		for _, msg := range messages {
+			byEvent[msg.EventID] = msg
+		}
+		return byEvent
+	}
+
+	plain := list(MessageListOptions{})
+	if _, ok := plain["$gone"]; ok {
+		t.Fatalf("deleted message listed by default")
+	}
+	if _, ok := plain["$edit1"]; !ok || plain["$evt1"].Edited {
+		t.Fatalf("expected edits listed as messages without --show-edits")
+	}
+
+	full := list(MessageListOptions{IncludeDeleted: true, ShowEdits: true})
+	if !full["$gone"].IsDeleted || full["$evt1"].IsDeleted {
+		t.Fatalf("expected only $gone deleted, got %+v", full["$gone"])
+	}
+	if _, ok := full["$edit1"]; ok {
+		t.Fatalf("edit events should be folded into their target")
+	}
+	edited := full["$evt1"]
+	if !edited.Edited || edited.Text != "hello again" || !slices.Equal(edited.PreviousVersions, []string{"hello", "hello there"}) {
+		t.Fatalf("unexpected edited message: %+v", edited)
+	}
+	if full["$evt2"].Edited {
+		t.Fatalf("unedited message marked edited")
+	}
+}
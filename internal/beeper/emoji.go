@@ -0,0 +1,108 @@
+package beeper
+
+import "strings"
+
+const (
+	zeroWidthJoiner   = 0x200D
+	variationSelector = 0xFE0F
+	combiningKeycap   = 0x20E3
+)
+
+// ExtractEmoji returns the emoji in text in order of appearance. Modifier and
+// ZWJ sequences (skin tones, families, flags) are kept together as one entry
+// and variation selectors are dropped so equivalent spellings compare equal.
+func ExtractEmoji(text string) []string {
+	runes := []rune(text)
+	found := []string{}
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if isRegionalIndicator(r) && i+1 < len(runes) && isRegionalIndicator(runes[i+1]) {
+			found = append(found, string(runes[i:i+2]))
+			i++
+			continue
+		}
+		if !isEmojiRune(r) {
+			continue
+		}
+		var cluster strings.Builder
+		cluster.WriteRune(r)
+	modifiers:
+		for i+1 < len(runes) {
+			next := runes[i+1]
+			switch {
+			case next == variationSelector:
+				i++
+			case isSkinTone(next), next == combiningKeycap:
+				cluster.WriteRune(next)
+				i++
+			case next == zeroWidthJoiner && i+2 < len(runes) && isEmojiRune(runes[i+2]):
+				cluster.WriteRune(next)
+				cluster.WriteRune(runes[i+2])
+				i += 2
+			default:
+				break modifiers
+			}
+		}
+		found = append(found, cluster.String())
+	}
+	return found
+}
+
+// ContainsEmoji reports whether text contains the given emoji (see
+// MatchesEmoji).
+func ContainsEmoji(text string, emoji string) bool {
+	for _, e := range ExtractEmoji(text) {
+		if MatchesEmoji(e, emoji) {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeEmoji drops variation selectors, so "❤️" and "❤" compare equal.
+func NormalizeEmoji(emoji string) string {
+	return strings.ReplaceAll(emoji, string(rune(variationSelector)), "")
+}
+
+// MatchesEmoji reports whether candidate is the emoji filter, ignoring
+// variation selectors. A filter without a skin tone also matches its
+// skin-tone variants, so "👍" finds "👍🏽" but "👍🏽" only itself.
+func MatchesEmoji(candidate string, filter string) bool {
+	candidate, filter = NormalizeEmoji(candidate), NormalizeEmoji(filter)
+	if candidate == filter {
+		return true
+	}
+	if strings.IndexFunc(filter, isSkinTone) >= 0 {
+		return false
+	}
+	return strings.Map(func(r rune) rune {
+		if isSkinTone(r) {
+			return -1
+		}
+		return r
+	}, candidate) == filter
+}
+
+func isEmojiRune(r rune) bool {
+	switch {
+	case r >= 0x1F300 && r <= 0x1F3FA, r >= 0x1F400 && r <= 0x1FAFF:
+		return true
+	case r >= 0x2600 && r <= 0x27BF:
+		return true
+	case r >= 0x2300 && r <= 0x23FF:
+		return true
+	case r >= 0x2B05 && r <= 0x2B55:
+		return true
+	case r == 0x3030, r == 0x303D, r == 0x3297, r == 0x3299:
+		return true
+	}
+	return false
+}
+
+func isSkinTone(r rune) bool {
+	return r >= 0x1F3FB && r <= 0x1F3FF
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
@@ -0,0 +1,40 @@
+package beeper
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtractEmoji(t *testing.T) {
+	text := "party 🎉🎉 thumbs 👍🏽 family 👨‍👩‍👧 flag 🇩🇪 heart ❤️ done"
+	got := strings.Join(ExtractEmoji(text), " ")
+	expected := "🎉 🎉 👍🏽 👨‍👩‍👧 🇩🇪 ❤"
+	if got != expected {
+		t.Fatalf("expected %q, got %q", expected, got)
+	}
+	if !ContainsEmoji(text, "❤️") {
+		t.Fatalf("expected heart with variation selector to match")
+	}
+	if ContainsEmoji("plain text", "🎉") {
+		t.Fatalf("unexpected match")
+	}
+}
+
+func TestMatchesEmoji(t *testing.T) {
+	for _, tt := range []struct {
+		candidate, filter string
+		want              bool
+	}{
+		{"❤️", "❤", true},
+		{"❤", "❤️", true},
+		{"👍🏽", "👍", true},
+		{"👍", "👍🏽", false},
+		{"👍🏻", "👍🏽", false},
+		{"👨‍👩‍👧", "👨", false},
+		{"🎉", "👍", false},
+	} {
+		if got := MatchesEmoji(tt.candidate, tt.filter); got != tt.want {
+			t.Fatalf("MatchesEmoji(%q, %q) = %v, want %v", tt.candidate, tt.filter, got, tt.want)
+		}
+	}
+}
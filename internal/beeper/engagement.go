@@ -0,0 +1,42 @@
+package beeper
+
+// Reactions and replies point at their target event through the Matrix
+// relation in the payload (m.relates_to.event_id for reactions,
+// m.relates_to.m.in_reply_to.event_id for replies) or through Beeper's
+// linkedMessageID. Edits and threads use m.relates_to too, so only REACTION
+// rows count their plain relation. Callers must guard with json_valid.
+const (
+	reactionTargetExpr = `COALESCE(
+		json_extract(message, '$."m.relates_to".event_id'),
+		json_extract(message, '$.linkedMessageID'))`
+	replyTargetExpr = `COALESCE(
+		json_extract(message, '$."m.relates_to"."m.in_reply_to".event_id'),
+		json_extract(message, '$.linkedMessageID'))`
+	// reactionKeyExpr is the emoji (or text) of a reaction.
+	reactionKeyExpr = `COALESCE(
+		json_extract(message, '$."m.relates_to".key'),
+		json_extract(message, '$.reactionKey'),
+		NULLIF(TRIM(text_content), ''),
+		'')`
+)
+
+// engagementCTE counts reactions to and replies to messages per target
+// event ID in one room (bound as its only parameter).
+const engagementCTE = `WITH relations AS (
+		SELECT type = 'REACTION' AS isReaction,
+			CASE WHEN type = 'REACTION' THEN ` + reactionTargetExpr + `
+			ELSE ` + replyTargetExpr + `
+			END AS target
+		FROM mx_room_messages
+		WHERE roomID = ?
+		AND isDeleted = 0
+		AND type != 'HIDDEN'
+		AND json_valid(message)
+	),
+	engagement AS (
+		SELECT target, SUM(isReaction) AS reactions, SUM(NOT isReaction) AS replies
+		FROM relations
+		WHERE target IS NOT NULL
+		GROUP BY target
+	)
+	`
@@ -0,0 +1,51 @@
+package beeper
+
+import (
+	"context"
+	"testing"
+)
+
+func TestListMessagesEngagement(t *testing.T) {
+	path := createTestDB(t, false)
+	for _, row := range []testMessage{
+		{eventID: "$r1", typ: "REACTION", message: `{"m.relates_to":{"rel_type":"m.annotation","event_id":"$evt2","key":"🎉"}}`},
+		{eventID: "$r2", typ: "REACTION", message: `{"m.relates_to":{"rel_type":"m.annotation","event_id":"$evt2","key":"👍"}}`},
+		{eventID: "$r3", typ: "REACTION", message: `{"linkedMessageID":"$evt1"}`},
+		{eventID: "$a1", message: `{"text":"yes!","m.relates_to":{"m.in_reply_to":{"event_id":"$evt2"}}}`},
+		{eventID: "$a2", message: `{"text":"fixed","m.relates_to":{"rel_type":"m.replace","event_id":"$evt3"}}`},
+		{eventID: "$a3", message: `not json`},
+	} {
+		row.roomID, row.sender, row.ts, row.hsOrder = "!room1:beeper.local", "@bob:beeper.local", 1700000000800, 10
+		insertMessages(t, path, row)
+	}
+
+	store, err := OpenWithOptions(path, StoreOptions{BridgeLookup: false})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	top, err := store.ListMessages(context.Background(), MessageListOptions{ThreadID: "!room1:beeper.local", TopEngaged: true, Limit: 10})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(top) != 2 {
+		t.Fatalf("expected 2 engaged messages, got %d", len(top))
+	}
+	if top[0].EventID != "$evt2" || top[0].ReactionCount != 2 || top[0].ReplyCount != 1 {
+		t.Fatalf("unexpected top message: %+v", top[0])
+	}
+	if top[1].EventID != "$evt1" || top[1].ReactionCount != 1 || top[1].ReplyCount != 0 {
+		t.Fatalf("unexpected second message: %+v", top[1])
+	}
+
+	all, err := store.ListMessages(context.Background(), MessageListOptions{ThreadID: "!room1:beeper.local", WithEngagement: true})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	for _, msg := range all {
+		if msg.EventID == "$evt3" && (msg.ReactionCount != 0 || msg.ReplyCount != 0) {
+			t.Fatalf("edit must not count as engagement: %+v", msg)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package beeper
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+func TestSkipEnrichment(t *testing.T) {
+	path := createTestDB(t, false)
+	writer, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("open writer: %v", err)
+	}
+	if _, err := writer.Exec("INSERT INTO participants (account_id, room_id, id, full_name, nickname, is_self) VALUES ('whatsapp', '!room4:beeper.local', '@carol:beeper.local', 'Carol', '', 0)"); err != nil {
+		t.Fatalf("insert participant: %v", err)
+	}
+	_ = writer.Close()
+	ctx := context.Background()
+
+	names := func(opts StoreOptions) (string, string, int) {
+		t.Helper()
+		store, err := OpenWithOptions(path, opts)
+		if err != nil {
+			t.Fatalf("open store: %v", err)
+		}
+		defer func() { _ = store.Close() }()
+		dm, err := store.GetThread(ctx, "!room4:beeper.local", true)
+		if err != nil {
+			t.Fatalf("get thread: %v", err)
+		}
+		messages, err := store.ListMessages(ctx, MessageListOptions{ThreadID: "!room1:beeper.local", Limit: 1})
+		if err != nil || len(messages) != 1 {
+			t.Fatalf("list messages: %v", err)
+		}
+		return dm.DisplayName, messages[0].SenderName, len(dm.Participants)
+	}
+
+	if dm, sender, participants := names(StoreOptions{}); dm != "Carol" || sender != "Alice" || participants != 1 {
+		t.Fatalf("expected names by default, got %q, %q, %d", dm, sender, participants)
+	}
+	for _, opts := range []StoreOptions{{NoParticipants: true}, {NoNames: true, BridgeLookup: true}} {
+		if dm, sender, participants := names(opts); dm != "!room4:beeper.local" || sender != "" || participants != 0 {
+			t.Fatalf("%+v: expected IDs only, got %q, %q, %d", opts, dm, sender, participants)
+		}
+	}
+}
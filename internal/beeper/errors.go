@@ -0,0 +1,28 @@
+package beeper
+
+import "errors"
+
+// Errors returned by Store methods, wrapped with details; test for them
+// with errors.Is.
+var (
+	// ErrDBNotFound means there is no database at the path given to Open.
+	ErrDBNotFound = errors.New("database not found")
+	// ErrSchemaUnsupported means the file is not a SQLite database or
+	// lacks the tables this package reads, e.g. it is not a Beeper
+	// index.db.
+	ErrSchemaUnsupported = errors.New("unsupported database schema")
+	// ErrThreadNotFound means no thread matches an ID or name.
+	ErrThreadNotFound = errors.New("thread not found")
+	// ErrMessageNotFound means no visible message has the given event ID.
+	ErrMessageNotFound = errors.New("message not found")
+	// ErrNoReadMarker means an unread thread has no read state to tell
+	// its read messages from its unread ones.
+	ErrNoReadMarker = errors.New("no read marker for thread")
+	// ErrFTSUnavailable means full-text search was required but the
+	// database has no usable FTS index.
+	ErrFTSUnavailable = errors.New("full-text search unavailable")
+)
+
+// requiredTables are the tables a database needs to be opened at all;
+// commands that read others fail on their own when those are missing.
+var requiredTables = []string{"threads", "mx_room_messages"}
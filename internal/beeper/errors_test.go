@@ -0,0 +1,63 @@
+package beeper
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := OpenWithOptions(filepath.Join(dir, "missing.db"), StoreOptions{}); !errors.Is(err, ErrDBNotFound) {
+		t.Fatalf("expected ErrDBNotFound, got %v", err)
+	}
+
+	notDB := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(notDB, []byte("not a database, just some text that is long enough to be read as a header"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, err := OpenWithOptions(notDB, StoreOptions{}); !errors.Is(err, ErrSchemaUnsupported) {
+		t.Fatalf("expected ErrSchemaUnsupported for a text file, got %v", err)
+	}
+
+	other := filepath.Join(dir, "other.db")
+	conn, err := sql.Open("sqlite3", other)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if _, err := conn.Exec("CREATE TABLE threads (threadID TEXT)"); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	_ = conn.Close()
+	if _, err := OpenWithOptions(other, StoreOptions{}); !errors.Is(err, ErrSchemaUnsupported) {
+		t.Fatalf("expected ErrSchemaUnsupported without mx_room_messages, got %v", err)
+	}
+}
+
+func TestStoreErrors(t *testing.T) {
+	store, err := OpenWithOptions(createTestDB(t, false), StoreOptions{BridgeLookup: false})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	ctx := context.Background()
+
+	if _, err := store.GetThread(ctx, "!missing:beeper.local", false); !errors.Is(err, ErrThreadNotFound) {
+		t.Fatalf("expected ErrThreadNotFound, got %v", err)
+	}
+	if _, err := store.ListReplies(ctx, "$missing", FormatPlain); !errors.Is(err, ErrMessageNotFound) {
+		t.Fatalf("expected ErrMessageNotFound, got %v", err)
+	}
+
+	if _, err := store.SearchMessages(ctx, SearchOptions{Query: "hello", RequireFTS: true}); !errors.Is(err, ErrFTSUnavailable) {
+		t.Fatalf("expected ErrFTSUnavailable, got %v", err)
+	}
+	results, err := store.SearchMessages(ctx, SearchOptions{Query: "hello"})
+	if err != nil || len(results) != 1 {
+		t.Fatalf("expected the substring fallback to find hello, got %v, %v", results, err)
+	}
+}
@@ -0,0 +1,103 @@
+package beeper
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ExpiredText stands in for the text of a disappearing message that has
+// expired and been removed.
+const ExpiredText = "[Expired message]"
+
+// disappearingCondition matches rows of disappearing messages: payloads
+// with an expiry time, a disappearing timer, or an expired marker. It
+// expects the messages table to be aliased m.
+const disappearingCondition = `(json_valid(m.message) AND (json_extract(m.message, '$.expired') = 1
+		OR json_extract(m.message, '$.expiresAt') IS NOT NULL
+		OR json_extract(m.message, '$.extra.expiresAt') IS NOT NULL
+		OR json_extract(m.message, '$."com.beeper.disappearing_timer"') IS NOT NULL))`
+
+// deletedFilter keeps deleted rows out of a message listing, except, with
+// includeExpired, those of disappearing messages, which become tombstones.
+func deletedFilter(includeDeleted bool, includeExpired bool) string {
+	switch {
+	case includeDeleted:
+		return ""
+	case includeExpired:
+		return " AND (m.isDeleted = 0 OR " + disappearingCondition + ")"
+	default:
+		return " AND m.isDeleted = 0"
+	}
+}
+
+// applyExpiry sets the disappearing-message fields of msg from its
+// payload. A deleted disappearing message counts as expired, since bridges
+// remove them once they expire, and its text becomes ExpiredText. Messages
+// still in the database past their expiry are marked expired but keep
+// their text.
+func applyExpiry(msg *Message, rawMessage string, now time.Time) {
+	expiresAt, disappearing, expired := parseExpiry(rawMessage, msg.Timestamp)
+	if !disappearing {
+		return
+	}
+	msg.Disappearing = true
+	if !expiresAt.IsZero() {
+		msg.ExpiresAt = &expiresAt
+		expired = expired || !expiresAt.After(now)
+	}
+	msg.Expired = expired || msg.IsDeleted
+	if msg.Expired && msg.IsDeleted {
+		msg.Text = ExpiredText
+	}
+}
+
+// parseExpiry reads how bridges mark disappearing messages: an expiresAt
+// time (epoch milliseconds or RFC3339, at the top level or under extra), a
+// com.beeper.disappearing_timer whose after_send timer (milliseconds)
+// counts from sent, and an expired flag. Timers that start when the message
+// is read have no known expiry.
+func parseExpiry(rawMessage string, sent time.Time) (expiresAt time.Time, disappearing bool, expired bool) {
+	var payload struct {
+		ExpiresAt json.RawMessage `json:"expiresAt"`
+		Expired   bool            `json:"expired"`
+		Extra     struct {
+			ExpiresAt json.RawMessage `json:"expiresAt"`
+		} `json:"extra"`
+		Timer *struct {
+			Type  string `json:"type"`
+			Timer int64  `json:"timer"`
+		} `json:"com.beeper.disappearing_timer"`
+	}
+	if rawMessage == "" || json.Unmarshal([]byte(rawMessage), &payload) != nil {
+		return time.Time{}, false, false
+	}
+	for _, raw := range []json.RawMessage{payload.ExpiresAt, payload.Extra.ExpiresAt} {
+		if t, ok := parseExpiryTime(raw); ok {
+			return t, true, payload.Expired
+		}
+	}
+	if payload.Timer != nil {
+		if payload.Timer.Type == "after_send" && payload.Timer.Timer > 0 {
+			expiresAt = sent.Add(time.Duration(payload.Timer.Timer) * time.Millisecond)
+		}
+		return expiresAt, true, payload.Expired
+	}
+	return time.Time{}, payload.Expired, payload.Expired
+}
+
+func parseExpiryTime(raw json.RawMessage) (time.Time, bool) {
+	if len(raw) == 0 {
+		return time.Time{}, false
+	}
+	var ms int64
+	if json.Unmarshal(raw, &ms) == nil && ms > 0 {
+		return unixMillis(ms), true
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
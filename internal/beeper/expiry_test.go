@@ -0,0 +1,74 @@
+package beeper
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestListMessagesExpiry(t *testing.T) {
+	path := createTestDB(t, false)
+	insertMessages(t, path,
+		testMessage{roomID: "!room1:beeper.local", eventID: "$expired", sender: "@alice:beeper.local", ts: 1700000003000, hsOrder: 20, isDeleted: true,
+			message: `{"text":"secret","expiresAt":1700000010000}`},
+		testMessage{roomID: "!room1:beeper.local", eventID: "$timer", sender: "@alice:beeper.local", ts: 1700000003001, hsOrder: 20,
+			message: `{"text":"soon gone","com.beeper.disappearing_timer":{"type":"after_send","timer":86400000}}`},
+		testMessage{roomID: "!room1:beeper.local", eventID: "$future", sender: "@alice:beeper.local", ts: 1700000003002, hsOrder: 20,
+			message: `{"text":"later","extra":{"expiresAt":"2999-01-01T00:00:00Z"}}`},
+		testMessage{roomID: "!room1:beeper.local", eventID: "$on-read", sender: "@alice:beeper.local", ts: 1700000003003, hsOrder: 20,
+			message: `{"text":"read me","com.beeper.disappearing_timer":{"type":"after_read","timer":3600000}}`},
+		testMessage{roomID: "!room1:beeper.local", eventID: "$deleted", sender: "@alice:beeper.local", ts: 1700000003004, hsOrder: 20, isDeleted: true, message: `{"text":"oops"}`},
+	)
+
+	store, err := OpenWithOptions(path, StoreOptions{BridgeLookup: false})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	ctx := context.Background()
+
+	list := func(opts MessageListOptions) map[string]Message {
+		t.Helper()
+		opts.ThreadID, opts.All = "!room1:beeper.local", true
+		messages, err := store.ListMessages(ctx, opts)
+		if err != nil {
+			t.Fatalf("list: %v", err)
+		}
+		byEvent := map[string]Message{}
+		for _, msg := range messages {
+			byEvent[msg.EventID] = msg
+		}
+		return byEvent
+	}
+
+	plain := list(MessageListOptions{})
+	if _, ok := plain["$expired"]; ok {
+		t.Fatalf("expired tombstone listed without IncludeExpired")
+	}
+	timer := plain["$timer"]
+	if !timer.Disappearing || !timer.Expired || timer.Text != "soon gone" || timer.ExpiresAt == nil ||
+		!timer.ExpiresAt.Equal(time.UnixMilli(1700000003001+86400000)) {
+		t.Fatalf("unexpected after_send message: %+v", timer)
+	}
+	if future := plain["$future"]; !future.Disappearing || future.Expired || future.ExpiresAt == nil || future.ExpiresAt.Year() != 2999 {
+		t.Fatalf("unexpected future message: %+v", future)
+	}
+	if onRead := plain["$on-read"]; !onRead.Disappearing || onRead.Expired || onRead.ExpiresAt != nil {
+		t.Fatalf("unexpected after_read message: %+v", onRead)
+	}
+
+	withExpired := list(MessageListOptions{IncludeExpired: true})
+	tombstone, ok := withExpired["$expired"]
+	if !ok {
+		t.Fatalf("expected the expired tombstone")
+	}
+	if !tombstone.IsDeleted || !tombstone.Expired || tombstone.Text != ExpiredText {
+		t.Fatalf("unexpected tombstone: %+v", tombstone)
+	}
+	if _, ok := withExpired["$deleted"]; ok {
+		t.Fatalf("a deleted message that never expired was listed")
+	}
+	if len(withExpired) != len(plain)+1 {
+		t.Fatalf("expected one extra message with IncludeExpired, got %d vs %d", len(withExpired), len(plain))
+	}
+}
@@ -0,0 +1,85 @@
+package beeper
+
+import (
+	"database/sql"
+	"strings"
+	"unicode"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// driverName is the sqlite3 driver with the store's SQL functions
+// registered; see NewSQLiteDriver.
+const driverName = "sqlite3_beeper"
+
+// foldFunction is the SQL name of foldText.
+const foldFunction = "beeper_fold"
+
+func init() {
+	sql.Register(driverName, NewSQLiteDriver())
+}
+
+// NewSQLiteDriver returns the sqlite3 driver with the SQL functions the
+// store's queries use registered on every connection. Connectors passed as
+// StoreOptions.Connector should open their connections with it.
+func NewSQLiteDriver() *sqlite3.SQLiteDriver {
+	return &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			return conn.RegisterFunc(foldFunction, foldText, true)
+		},
+	}
+}
+
+// foldedLetters maps accented Latin letters (already lower-cased) to their
+// base letters.
+var foldedLetters = func() map[rune]string {
+	groups := map[string]string{
+		"a": "àáâãäåāăą", "c": "çćĉċč", "d": "ďđð", "e": "èéêëēĕėęě",
+		"g": "ĝğġģ", "h": "ĥħ", "i": "ìíîïĩīĭįı", "j": "ĵ", "k": "ķ",
+		"l": "ĺļľŀł", "n": "ñńņňŉ", "o": "òóôõöøōŏő", "r": "ŕŗř",
+		"s": "śŝşšș", "t": "ţťŧț", "u": "ùúûüũūŭůűų", "w": "ŵ", "y": "ýÿŷ",
+		"z": "źżž", "ss": "ß", "ae": "æ", "oe": "œ", "th": "þ",
+	}
+	folded := map[rune]string{}
+	for base, letters := range groups {
+		for _, r := range letters {
+			folded[r] = base
+		}
+	}
+	return folded
+}()
+
+// foldText lower-cases text and strips diacritics from Latin letters
+// (precomposed or followed by combining marks), so "Café" and "CAFE" both
+// fold to "cafe".
+func foldText(text string) string {
+	var b strings.Builder
+	b.Grow(len(text))
+	for _, r := range strings.ToLower(text) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if base, ok := foldedLetters[r]; ok {
+			b.WriteString(base)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// searchTerms splits a search query into terms at whitespace, keeping
+// "double-quoted phrases" together.
+func searchTerms(query string) []string {
+	terms := []string{}
+	for i, part := range strings.Split(query, `"`) {
+		if i%2 == 1 {
+			if part = strings.TrimSpace(part); part != "" {
+				terms = append(terms, part)
+			}
+			continue
+		}
+		terms = append(terms, strings.Fields(part)...)
+	}
+	return terms
+}
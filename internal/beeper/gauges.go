@@ -0,0 +1,50 @@
+package beeper
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"time"
+)
+
+// Gauges returns thread and unread totals, the messages per account in the
+// hour before now, the newest message time, and the database size.
+func (s *Store) Gauges(ctx context.Context, now time.Time) (Gauges, error) {
+	var g Gauges
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*),
+		COALESCE(SUM(unread), 0),
+		COALESCE(SUM(CASE WHEN unread THEN unreadCount END), 0),
+		COALESCE(SUM(CASE WHEN unread THEN unreadMentions END), 0)
+		FROM (
+			SELECT (COALESCE(json_extract(thread,'$.isUnread'), 0) != 0 OR COALESCE(json_extract(thread,'$.isMarkedUnread'), 0) != 0)
+				AND COALESCE(json_extract(thread,'$.isLowPriority'), 0) = 0 AS unread,
+				COALESCE(json_extract(thread,'$.unreadCount'), 0) AS unreadCount,
+				COALESCE(json_extract(thread,'$.unreadMentionsCount'), 0) AS unreadMentions
+			FROM threads
+		)`).Scan(&g.Threads, &g.UnreadThreads, &g.UnreadMessages, &g.UnreadMentions)
+	if err != nil {
+		return Gauges{}, err
+	}
+
+	hourAgo := now.Add(-time.Hour)
+	if g.LastHour, err = s.MessagesPerAccount(ctx, StatsOptions{After: &hourAgo, Before: &now}); err != nil {
+		return Gauges{}, err
+	}
+
+	var newest sql.NullInt64
+	if err := s.db.QueryRowContext(ctx, `SELECT MAX(timestamp) FROM mx_room_messages
+		WHERE isDeleted = 0 AND type NOT IN ('HIDDEN','REACTION')`).Scan(&newest); err != nil {
+		return Gauges{}, err
+	}
+	g.LastMessage = unixMillisOrZero(newest)
+
+	// Stores opened through a connector (replay) have no file to measure.
+	if s.path != "" {
+		for _, path := range []string{s.path, s.path + "-wal"} {
+			if info, err := os.Stat(path); err == nil {
+				g.DBSize += info.Size()
+			}
+		}
+	}
+	return g, nil
+}
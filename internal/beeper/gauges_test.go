@@ -0,0 +1,33 @@
+package beeper
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestGauges(t *testing.T) {
+	path := createTestDB(t, false)
+	now := time.Now()
+	insertMessages(t, path, testMessage{id: 8, roomID: "!room1:beeper.local", eventID: "$evt8", sender: "@alice:beeper.local", ts: now.Add(-time.Minute).UnixMilli(), hsOrder: 10, text: "now"})
+
+	store, err := OpenWithOptions(path, StoreOptions{})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	g, err := store.Gauges(context.Background(), now)
+	if err != nil {
+		t.Fatalf("gauges: %v", err)
+	}
+	if g.Threads != 4 || g.UnreadThreads != 1 || g.UnreadMessages != 2 || g.UnreadMentions != 1 {
+		t.Fatalf("unexpected thread gauges: %+v", g)
+	}
+	if len(g.LastHour) != 1 || g.LastHour[0].AccountID != "whatsapp" || g.LastHour[0].Received != 1 {
+		t.Fatalf("expected one received WhatsApp message in the last hour, got %+v", g.LastHour)
+	}
+	if g.LastMessage.UnixMilli() != now.Add(-time.Minute).UnixMilli() || g.DBSize <= 0 {
+		t.Fatalf("unexpected last message or size: %v, %d", g.LastMessage, g.DBSize)
+	}
+}
@@ -0,0 +1,51 @@
+package beeper
+
+// MessageDay is the messages of one local calendar day.
+type MessageDay struct {
+	Day      string    `json:"day"`
+	Messages []Message `json:"messages"`
+}
+
+// GroupMessagesByDay splits messages into runs that share a local calendar
+// day (YYYY-MM-DD), keeping their order, so newest-first listings yield the
+// newest day first.
+func GroupMessagesByDay(messages []Message) []MessageDay {
+	days := []MessageDay{}
+	for _, msg := range messages {
+		day := msg.Timestamp.Local().Format(dayLayout)
+		if n := len(days); n == 0 || days[n-1].Day != day {
+			days = append(days, MessageDay{Day: day})
+		}
+		days[len(days)-1].Messages = append(days[len(days)-1].Messages, msg)
+	}
+	return days
+}
+
+// SearchThread is the search results in one thread.
+type SearchThread struct {
+	ThreadID   string `json:"threadId"`
+	ThreadName string `json:"threadName,omitempty"`
+	AccountID  string `json:"accountId,omitempty"`
+	Hits       int    `json:"hits"`
+	// Results keeps the thread's results in rank order.
+	Results []SearchResult `json:"results"`
+}
+
+// GroupSearchResultsByThread clusters search results by thread, ordering
+// threads by their best-ranked hit and keeping rank order within each.
+func GroupSearchResultsByThread(results []SearchResult) []SearchThread {
+	threads := []SearchThread{}
+	index := map[string]int{}
+	for _, result := range results {
+		match := result.Match
+		i, ok := index[match.ThreadID]
+		if !ok {
+			i = len(threads)
+			index[match.ThreadID] = i
+			threads = append(threads, SearchThread{ThreadID: match.ThreadID, ThreadName: match.ThreadName, AccountID: match.AccountID})
+		}
+		threads[i].Hits++
+		threads[i].Results = append(threads[i].Results, result)
+	}
+	return threads
+}
@@ -0,0 +1,44 @@
+package beeper
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGroupMessagesByDay(t *testing.T) {
+	at := func(day, hour int) Message {
+		return Message{ID: int64(day*100 + hour), Timestamp: time.Date(2025, 12, day, hour, 0, 0, 0, time.Local)}
+	}
+	days := GroupMessagesByDay([]Message{at(20, 9), at(19, 23), at(19, 8), at(17, 12)})
+	if len(days) != 3 {
+		t.Fatalf("expected 3 days, got %+v", days)
+	}
+	if days[0].Day != "2025-12-20" || days[1].Day != "2025-12-19" || days[2].Day != "2025-12-17" {
+		t.Fatalf("unexpected days: %s, %s, %s", days[0].Day, days[1].Day, days[2].Day)
+	}
+	if len(days[1].Messages) != 2 || days[1].Messages[0].ID != 1923 || days[1].Messages[1].ID != 1908 {
+		t.Fatalf("expected day order kept, got %+v", days[1].Messages)
+	}
+	if got := GroupMessagesByDay(nil); len(got) != 0 {
+		t.Fatalf("expected no days, got %+v", got)
+	}
+}
+
+func TestGroupSearchResultsByThread(t *testing.T) {
+	hit := func(id int64, thread string) SearchResult {
+		return SearchResult{Match: Message{ID: id, ThreadID: thread, ThreadName: "name " + thread}}
+	}
+	threads := GroupSearchResultsByThread([]SearchResult{hit(1, "!b"), hit(2, "!a"), hit(3, "!b"), hit(4, "!c"), hit(5, "!a"), hit(6, "!b")})
+	if len(threads) != 3 || threads[0].ThreadID != "!b" || threads[1].ThreadID != "!a" || threads[2].ThreadID != "!c" {
+		t.Fatalf("expected threads ordered by best hit, got %+v", threads)
+	}
+	if threads[0].Hits != 3 || threads[0].ThreadName != "name !b" || threads[0].Results[1].Match.ID != 3 || threads[0].Results[2].Match.ID != 6 {
+		t.Fatalf("unexpected first thread: %+v", threads[0])
+	}
+	if threads[2].Hits != 1 {
+		t.Fatalf("expected one hit in the last thread, got %d", threads[2].Hits)
+	}
+	if got := GroupSearchResultsByThread(nil); len(got) != 0 {
+		t.Fatalf("expected no threads, got %+v", got)
+	}
+}
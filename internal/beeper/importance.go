@@ -0,0 +1,304 @@
+package beeper
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	defaultHalfLife = 30 * 24 * time.Hour
+	// Messages older than this many half-lives weigh under 0.4% and are
+	// not scanned.
+	importanceHalfLives = 8
+
+	weightRecency     = 0.30
+	weightFrequency   = 0.35
+	weightReciprocity = 0.20
+	weightMentions    = 0.15
+)
+
+// RankContacts scores contacts by how important they are to the user,
+// highest first. The score combines recency (time since the last message
+// exchanged), frequency (messages exchanged, decaying with age),
+// reciprocity (how balanced sent and received messages are), and mentions
+// (of the user by the contact and of the contact by the user).
+func (s *Store) RankContacts(ctx context.Context, opts ContactRankOptions) ([]RankedContact, error) {
+	ranked, err := s.rankContacts(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	platform := normalizePlatform(opts.Platform)
+	filtered := []RankedContact{}
+	for _, c := range ranked {
+		if platform != "" && !slices.Contains(c.Platforms, platform) {
+			continue
+		}
+		c.Threads = nil
+		filtered = append(filtered, c)
+	}
+	if opts.Limit > 0 && len(filtered) > opts.Limit {
+		filtered = filtered[:opts.Limit]
+	}
+	return filtered, nil
+}
+
+// contactTally accumulates the raw signals of one contact.
+type contactTally struct {
+	received, sent, mentions float64
+	receivedCount            int
+	sentCount                float64
+	mentionCount             int
+	last                     time.Time
+}
+
+// rankContacts scores every contact, keeping their threads.
+func (s *Store) rankContacts(ctx context.Context, opts ContactRankOptions) ([]RankedContact, error) {
+	now := opts.Now
+	if now.IsZero() {
+		var err error
+		if now, err = s.newestMessageTime(ctx); err != nil {
+			return nil, err
+		}
+	}
+	halfLife := opts.HalfLife
+	if halfLife <= 0 {
+		halfLife = defaultHalfLife
+	}
+	cutoff := now.Add(-importanceHalfLives * halfLife).UnixMilli()
+	decay := func(ts int64) float64 {
+		age := now.Sub(unixMillis(ts))
+		if age < 0 {
+			age = 0
+		}
+		return math.Exp2(-float64(age) / float64(halfLife))
+	}
+
+	contacts, err := s.loadContacts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byID := map[string]int{}
+	members := map[string][]int{}
+	for i, c := range contacts {
+		for _, id := range c.IDs {
+			byID[id] = i
+		}
+		for _, t := range c.Threads {
+			members[t.ThreadID] = append(members[t.ThreadID], i)
+		}
+	}
+
+	tallies := make([]contactTally, len(contacts))
+	if err := s.tallyMessages(ctx, cutoff, decay, byID, members, tallies); err != nil {
+		return nil, err
+	}
+	if err := s.tallyMentions(ctx, cutoff, decay, contacts, byID, members, tallies); err != nil {
+		return nil, err
+	}
+
+	maxFrequency, maxMentions := 0.0, 0.0
+	for _, t := range tallies {
+		maxFrequency = max(maxFrequency, t.received+t.sent)
+		maxMentions = max(maxMentions, t.mentions)
+	}
+
+	ranked := make([]RankedContact, len(contacts))
+	for i, c := range contacts {
+		t := tallies[i]
+		signals := ContactSignals{
+			Frequency:    logScale(t.received+t.sent, maxFrequency),
+			Mentions:     logScale(t.mentions, maxMentions),
+			Received:     t.receivedCount,
+			Sent:         math.Round(t.sentCount*10) / 10,
+			MentionCount: t.mentionCount,
+			LastContact:  t.last,
+		}
+		if !t.last.IsZero() {
+			signals.Recency = decay(t.last.UnixMilli())
+		}
+		if hi := max(t.received, t.sent); hi > 0 {
+			signals.Reciprocity = min(t.received, t.sent) / hi
+		}
+		signals.Recency = round3(signals.Recency)
+		signals.Frequency = round3(signals.Frequency)
+		signals.Reciprocity = round3(signals.Reciprocity)
+		signals.Mentions = round3(signals.Mentions)
+		score := weightRecency*signals.Recency + weightFrequency*signals.Frequency +
+			weightReciprocity*signals.Reciprocity + weightMentions*signals.Mentions
+		ranked[i] = RankedContact{Contact: c, Score: math.Round(score*1000) / 10, Signals: signals}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return strings.ToLower(ranked[i].Name) < strings.ToLower(ranked[j].Name)
+	})
+	return ranked, nil
+}
+
+// newestMessageTime returns the time of the newest message, or now for an
+// empty database. Ranking relative to it keeps scores of an old copy of
+// the database meaningful.
+func (s *Store) newestMessageTime(ctx context.Context) (time.Time, error) {
+	var newest sql.NullInt64
+	if err := s.db.QueryRowContext(ctx, `SELECT MAX(timestamp) FROM mx_room_messages
+		WHERE isDeleted = 0 AND type NOT IN ('HIDDEN','REACTION')`).Scan(&newest); err != nil {
+		return time.Time{}, err
+	}
+	if !newest.Valid {
+		return time.Now(), nil
+	}
+	return unixMillis(newest.Int64), nil
+}
+
+// tallyMessages adds decayed message counts per contact. Messages from a
+// contact count as received; the user's messages count as sent to every
+// contact in the thread, split evenly between them.
+func (s *Store) tallyMessages(
+	ctx context.Context,
+	cutoff int64,
+	decay func(int64) float64,
+	byID map[string]int,
+	members map[string][]int,
+	tallies []contactTally,
+) error {
+	rows, err := s.db.QueryContext(ctx, `SELECT senderContactID, roomID, isSentByMe, COUNT(*), MAX(timestamp)
+		FROM mx_room_messages
+		WHERE isDeleted = 0 AND type NOT IN ('HIDDEN','REACTION') AND timestamp >= ?
+		GROUP BY senderContactID, roomID, isSentByMe, timestamp / 86400000`, cutoff)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var sender, roomID string
+		var sentByMe, count int
+		var last int64
+		if err := rows.Scan(&sender, &roomID, &sentByMe, &count, &last); err != nil {
+			return err
+		}
+		weight := float64(count) * decay(last)
+		if sentByMe != 0 {
+			room := members[roomID]
+			for _, i := range room {
+				tallies[i].sent += weight / float64(len(room))
+				tallies[i].sentCount += float64(count) / float64(len(room))
+				// Only direct messages count as contact with one person.
+				if len(room) == 1 {
+					tallies[i].last = maxTime(tallies[i].last, unixMillis(last))
+				}
+			}
+			continue
+		}
+		i, ok := byID[sender]
+		if !ok {
+			continue
+		}
+		tallies[i].received += weight
+		tallies[i].receivedCount += count
+		tallies[i].last = maxTime(tallies[i].last, unixMillis(last))
+	}
+	return rows.Err()
+}
+
+// tallyMentions adds decayed mention counts: messages from a contact that
+// mention the user, and messages from the user that mention a contact in
+// the same thread by ID or full name.
+func (s *Store) tallyMentions(
+	ctx context.Context,
+	cutoff int64,
+	decay func(int64) float64,
+	contacts []Contact,
+	byID map[string]int,
+	members map[string][]int,
+	tallies []contactTally,
+) error {
+	selfByRoom, err := s.selfIdentities(ctx, "")
+	if err != nil {
+		return err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT roomID, senderContactID, isSentByMe, timestamp, type,
+		COALESCE(text_content, ''), COALESCE(message, '')
+		FROM mx_room_messages
+		WHERE isDeleted = 0 AND type NOT IN ('HIDDEN','REACTION') AND timestamp >= ?`, cutoff)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var roomID, sender, msgType, textContent, rawMessage string
+		var sentByMe int
+		var ts int64
+		if err := rows.Scan(&roomID, &sender, &sentByMe, &ts, &msgType, &textContent, &rawMessage); err != nil {
+			return err
+		}
+		plain := ResolveMessageText(rawMessage, strings.TrimSpace(msgType), textContent, FormatPlain)
+		if sentByMe == 0 {
+			i, ok := byID[sender]
+			self, hasSelf := selfByRoom[roomID]
+			if ok && hasSelf && isMention(rawMessage, plain, self) {
+				tallies[i].mentions += decay(ts)
+				tallies[i].mentionCount++
+			}
+			continue
+		}
+		for _, i := range members[roomID] {
+			if mentionsContact(rawMessage, plain, contacts[i]) {
+				tallies[i].mentions += decay(ts)
+				tallies[i].mentionCount++
+			}
+		}
+	}
+	return rows.Err()
+}
+
+// mentionsContact reports whether a message lists one of the contact's IDs
+// as mentioned, contains one, or contains their full name as whole words.
+func mentionsContact(rawMessage string, text string, c Contact) bool {
+	identity := selfIdentity{ids: c.IDs}
+	if strings.Contains(strings.TrimSpace(c.Name), " ") {
+		identity.names = []string{c.Name}
+	}
+	return isMention(rawMessage, text, identity)
+}
+
+// logScale maps value to 0..1 relative to the largest value on a log
+// scale, so a few very busy contacts do not flatten everyone else.
+func logScale(value float64, largest float64) float64 {
+	if value <= 0 || largest <= 0 {
+		return 0
+	}
+	return math.Log1p(value) / math.Log1p(largest)
+}
+
+func round3(value float64) float64 {
+	return math.Round(value*1000) / 1000
+}
+
+// threadImportance scores threads by their most important contact,
+// divided by the square root of the number of contacts in the thread so a
+// DM outranks a large group with the same person.
+func threadImportance(ranked []RankedContact) map[string]float64 {
+	best := map[string]float64{}
+	counts := map[string]int{}
+	for _, c := range ranked {
+		for _, t := range c.Threads {
+			best[t.ThreadID] = max(best[t.ThreadID], c.Score)
+			counts[t.ThreadID]++
+		}
+	}
+	scores := make(map[string]float64, len(best))
+	for id, score := range best {
+		scores[id] = math.Round(score/math.Sqrt(float64(counts[id]))*10) / 10
+	}
+	return scores
+}
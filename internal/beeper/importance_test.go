@@ -0,0 +1,99 @@
+package beeper
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestRankContacts(t *testing.T) {
+	path := createTestDB(t, false)
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	participants := [][]any{
+		{"whatsapp", "!room1:beeper.local", "@me:beeper.local", "Me", "", 1},
+		{"whatsapp", "!room4:beeper.local", "@me:beeper.local", "Me", "", 1},
+		{"whatsapp", "!room4:beeper.local", "@bridge:beeper.local", "Dana Scott", "", 0},
+		{"telegram", "!room2:beeper.local", "@bob:beeper.local", "Bob", "", 0},
+	}
+	for _, p := range participants {
+		if _, err := conn.Exec("INSERT INTO participants (account_id, room_id, id, full_name, nickname, is_self) VALUES (?, ?, ?, ?, ?, ?)", p...); err != nil {
+			t.Fatalf("insert participant: %v", err)
+		}
+	}
+	_ = conn.Close()
+	insertMessages(t, path,
+		testMessage{roomID: "!room4:beeper.local", eventID: "$evt8", sender: "@me:beeper.local", ts: 1700000000800, hsOrder: 2, isMe: true, text: "hi Dana"},
+		testMessage{roomID: "!room1:beeper.local", eventID: "$evt9", sender: "@me:beeper.local", ts: 1700000000900, hsOrder: 10, isMe: true, text: "thanks",
+			message: `{"text":"thanks","m.mentions":{"user_ids":["@alice:beeper.local"]}}`},
+	)
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	ctx := context.Background()
+
+	ranked, err := store.RankContacts(ctx, ContactRankOptions{})
+	if err != nil {
+		t.Fatalf("rank contacts: %v", err)
+	}
+	if len(ranked) != 3 {
+		t.Fatalf("expected 3 contacts, got %d", len(ranked))
+	}
+	byName := map[string]RankedContact{}
+	for _, c := range ranked {
+		byName[c.Name] = c
+		if c.Threads != nil {
+			t.Fatalf("expected threads to be omitted for %s", c.Name)
+		}
+	}
+	if ranked[0].Name != "Alice" {
+		t.Fatalf("expected Alice first, got %s", ranked[0].Name)
+	}
+	alice := byName["Alice"].Signals
+	if alice.Received != 4 || alice.Sent != 1 || alice.MentionCount != 1 || alice.Frequency != 1 || alice.Mentions != 1 {
+		t.Fatalf("unexpected signals for Alice: %+v", alice)
+	}
+	dana := byName["Dana Scott"].Signals
+	if dana.Reciprocity != 1 || !dana.LastContact.Equal(time.UnixMilli(1700000000800)) {
+		t.Fatalf("unexpected signals for Dana: %+v", dana)
+	}
+	if byName["Bob"].Signals.Reciprocity != 0 {
+		t.Fatalf("expected no reciprocity for Bob, got %+v", byName["Bob"].Signals)
+	}
+
+	limited, err := store.RankContacts(ctx, ContactRankOptions{Platform: "telegram", Limit: 1})
+	if err != nil {
+		t.Fatalf("rank contacts: %v", err)
+	}
+	if len(limited) != 1 || limited[0].Name != "Bob" {
+		t.Fatalf("unexpected filtered ranking: %+v", limited)
+	}
+
+	// A year later every message has left the scoring window.
+	later, err := store.RankContacts(ctx, ContactRankOptions{Now: time.UnixMilli(1700000000800).AddDate(1, 0, 0)})
+	if err != nil {
+		t.Fatalf("rank contacts: %v", err)
+	}
+	for _, c := range later {
+		if c.Score != 0 || c.Signals.Recency != 0 {
+			t.Fatalf("expected zero scores outside the window, got %+v", c)
+		}
+	}
+
+	threads, err := store.ListThreads(ctx, ThreadListOptions{Sort: SortImportance, IncludeLowPriority: true, Limit: 2})
+	if err != nil {
+		t.Fatalf("list threads: %v", err)
+	}
+	if len(threads) != 2 || threads[0].ID != "!room1:beeper.local" || threads[1].ID != "!room4:beeper.local" {
+		t.Fatalf("unexpected importance order: %+v", threads)
+	}
+	if threads[0].Importance != byName["Alice"].Score || threads[0].DisplayName != "Team Chat" {
+		t.Fatalf("unexpected thread importance: %+v", threads[0])
+	}
+}
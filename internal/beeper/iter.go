@@ -0,0 +1,86 @@
+package beeper
+
+import (
+	"context"
+	"errors"
+	"iter"
+)
+
+// iterBatchSize is how many messages IterMessages loads per query.
+const iterBatchSize = 1000
+
+// IterMessages streams the messages of opts.ThreadID oldest first, loading
+// them in batches with keyset pagination so a history of millions of
+// messages is never held in memory at once. It honors the filters and
+// enrichment of MessageListOptions; Limit, All, Cursor, and TopEngaged do
+// not apply. Iteration stops after the first error, which is yielded with
+// a zero Message.
+func (s *Store) IterMessages(ctx context.Context, opts MessageListOptions) iter.Seq2[Message, error] {
+	opts.Cursor = ""
+	return s.iterMessages(ctx, opts, true)
+}
+
+// IterMessagesNewestFirst streams what ListMessagesPage returns with All,
+// newest first and starting after opts.Cursor, in batches like
+// IterMessages. Limit, All, and TopEngaged do not apply.
+func (s *Store) IterMessagesNewestFirst(ctx context.Context, opts MessageListOptions) iter.Seq2[Message, error] {
+	return s.iterMessages(ctx, opts, false)
+}
+
+func (s *Store) iterMessages(ctx context.Context, opts MessageListOptions, ascending bool) iter.Seq2[Message, error] {
+	return func(yield func(Message, error) bool) {
+		if opts.ThreadID == "" {
+			yield(Message{}, errors.New("thread ID is required"))
+			return
+		}
+		opts.TopEngaged = false
+		cursor, err := decodeCursor(opts.Cursor, cursorMessages)
+		if err != nil {
+			yield(Message{}, err)
+			return
+		}
+		for {
+			batch, err := s.queryMessages(ctx, opts, cursor, iterBatchSize, ascending)
+			if err != nil {
+				yield(Message{}, err)
+				return
+			}
+			for _, msg := range batch {
+				if !yield(msg, nil) {
+					return
+				}
+			}
+			if len(batch) < iterBatchSize {
+				return
+			}
+			last := batch[len(batch)-1]
+			cursor = &pageCursor{Timestamp: last.Timestamp.UnixMilli(), ID: last.ID}
+		}
+	}
+}
+
+// iterNewMessages streams the visible messages with IDs above opts.AfterID
+// in ID order, in batches of opts.Limit, with thread and sender names.
+func (s *Store) iterNewMessages(ctx context.Context, opts ScanOptions) iter.Seq2[Message, error] {
+	return func(yield func(Message, error) bool) {
+		for {
+			batch, err := s.ScanMessages(ctx, opts)
+			if err == nil {
+				err = s.enrichMessages(ctx, batch)
+			}
+			if err != nil {
+				yield(Message{}, err)
+				return
+			}
+			for _, msg := range batch {
+				if !yield(msg, nil) {
+					return
+				}
+			}
+			if len(batch) < opts.Limit {
+				return
+			}
+			opts.AfterID = batch[len(batch)-1].ID
+		}
+	}
+}
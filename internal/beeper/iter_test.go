@@ -0,0 +1,84 @@
+package beeper
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestIterMessages(t *testing.T) {
+	path := createTestDB(t, false)
+	// More than two batches, many sharing a timestamp so batches split
+	// inside a run of equal times.
+	const extra = 2*iterBatchSize + 10
+	bulk := make([]testMessage, 0, extra)
+	for i := 0; i < extra; i++ {
+		bulk = append(bulk, testMessage{roomID: "!room1:beeper.local", eventID: fmt.Sprintf("$bulk%d", i), sender: "@alice:beeper.local", ts: 1800000000000 + int64(i/7), hsOrder: 100, text: "bulk"})
+	}
+	insertMessages(t, path, bulk...)
+
+	store, err := OpenWithOptions(path, StoreOptions{BridgeLookup: false})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	ctx := context.Background()
+
+	all, err := store.ListMessages(ctx, MessageListOptions{ThreadID: "!room1:beeper.local", All: true})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	n := 0
+	for msg, err := range store.IterMessages(ctx, MessageListOptions{ThreadID: "!room1:beeper.local", Limit: 5}) {
+		if err != nil {
+			t.Fatalf("iterate: %v", err)
+		}
+		// ListMessages is newest first; the iterator oldest first.
+		if want := all[len(all)-1-n]; msg.ID != want.ID || msg.SenderName != want.SenderName {
+			t.Fatalf("message %d: expected %d, got %d", n, want.ID, msg.ID)
+		}
+		n++
+	}
+	if n != len(all) || n < extra {
+		t.Fatalf("expected %d messages, iterated %d", len(all), n)
+	}
+
+	// Newest first matches ListMessages, also after a page's cursor.
+	page, err := store.ListMessagesPage(ctx, MessageListOptions{ThreadID: "!room1:beeper.local", Limit: 5})
+	if err != nil {
+		t.Fatalf("page: %v", err)
+	}
+	for i, opts := range []MessageListOptions{
+		{ThreadID: "!room1:beeper.local"},
+		{ThreadID: "!room1:beeper.local", Cursor: page.NextCursor},
+	} {
+		want := all[5*i:]
+		n = 0
+		for msg, err := range store.IterMessagesNewestFirst(ctx, opts) {
+			if err != nil {
+				t.Fatalf("iterate newest first: %v", err)
+			}
+			if msg.ID != want[n].ID {
+				t.Fatalf("newest first %d: expected %d, got %d", n, want[n].ID, msg.ID)
+			}
+			n++
+		}
+		if n != len(want) {
+			t.Fatalf("expected %d messages newest first, iterated %d", len(want), n)
+		}
+	}
+
+	// Stopping early ends the iteration without an error.
+	n = 0
+	for range store.IterMessages(ctx, MessageListOptions{ThreadID: "!room1:beeper.local"}) {
+		n++
+		if n == 3 {
+			break
+		}
+	}
+	for _, err := range store.IterMessages(ctx, MessageListOptions{}) {
+		if err == nil {
+			t.Fatalf("expected an error without a thread")
+		}
+	}
+}
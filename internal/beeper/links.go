@@ -0,0 +1,21 @@
+package beeper
+
+import (
+	"net/url"
+	"strings"
+)
+
+// MatrixURI returns the matrix: URI (MSC2312) of a room, e.g.
+// matrix:roomid/abc:beeper.local. Desktop clients that register the
+// scheme, Beeper included, open the room directly.
+func MatrixURI(roomID string) string {
+	return "matrix:roomid/" + url.PathEscape(strings.TrimPrefix(roomID, "!"))
+}
+
+// MatrixToURL returns the https://matrix.to link of a room, which works in
+// any browser and offers to open the room in an installed client. As in
+// the spec's examples, the sigil stays and the colon is encoded.
+func MatrixToURL(roomID string) string {
+	id := url.PathEscape(strings.TrimPrefix(roomID, "!"))
+	return "https://matrix.to/#/!" + strings.ReplaceAll(id, ":", "%3A")
+}
@@ -0,0 +1,15 @@
+package beeper
+
+import "testing"
+
+func TestMatrixLinks(t *testing.T) {
+	if got, want := MatrixURI("!abc123:beeper.local"), "matrix:roomid/abc123:beeper.local"; got != want {
+		t.Fatalf("MatrixURI = %q, want %q", got, want)
+	}
+	if got, want := MatrixURI("!a/b c:beeper.local"), "matrix:roomid/a%2Fb%20c:beeper.local"; got != want {
+		t.Fatalf("MatrixURI = %q, want %q", got, want)
+	}
+	if got, want := MatrixToURL("!abc123:beeper.local"), "https://matrix.to/#/!abc123%3Abeeper.local"; got != want {
+		t.Fatalf("MatrixToURL = %q, want %q", got, want)
+	}
+}
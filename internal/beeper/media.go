@@ -0,0 +1,286 @@
+package beeper
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Attachment describes a media payload referenced by a message.
+type Attachment struct {
+	MessageID  int64     `json:"messageId"`
+	EventID    string    `json:"eventId"`
+	ThreadID   string    `json:"threadId"`
+	ThreadName string    `json:"threadName,omitempty"`
+	AccountID  string    `json:"accountId,omitempty"`
+	SenderID   string    `json:"senderId"`
+	SenderName string    `json:"senderName,omitempty"`
+	Timestamp  time.Time `json:"timestamp"`
+	Type       string    `json:"type"`
+	URL        string    `json:"url,omitempty"`
+	FileName   string    `json:"fileName,omitempty"`
+	Caption    string    `json:"caption,omitempty"`
+	AltText    string    `json:"altText,omitempty"`
+	MimeType   string    `json:"mimeType,omitempty"`
+	Size       int64     `json:"size,omitempty"`
+	LocalPath  string    `json:"localPath,omitempty"`
+}
+
+const attachmentBatchSize = 1000
+
+var mediaTypes = map[string]bool{
+	"IMAGE":   true,
+	"VIDEO":   true,
+	"AUDIO":   true,
+	"FILE":    true,
+	"STICKER": true,
+}
+
+// IsMediaType reports whether messages of msgType carry an attachment.
+func IsMediaType(msgType string) bool {
+	return mediaTypes[strings.ToUpper(strings.TrimSpace(msgType))]
+}
+
+// ParseAttachment extracts the attachment fields from a raw message payload.
+func ParseAttachment(rawMessage string, msgType string) (Attachment, bool) {
+	att := Attachment{Type: strings.ToUpper(strings.TrimSpace(msgType))}
+	if !IsMediaType(att.Type) || strings.TrimSpace(rawMessage) == "" {
+		return att, false
+	}
+	var payload map[string]any
+	if err := json.Unmarshal([]byte(rawMessage), &payload); err != nil {
+		return att, false
+	}
+
+	fields := payload
+	if list, ok := payload["attachments"].([]any); ok && len(list) > 0 {
+		if first, ok := list[0].(map[string]any); ok {
+			fields = first
+		}
+	}
+
+	att.URL = firstString(fields, "srcURL", "url", "src")
+	att.FileName = firstString(fields, "fileName", "filename", "name")
+	att.MimeType = firstString(fields, "mimeType", "mimetype")
+	att.Size = firstInt(fields, "fileSize", "size")
+	if info, ok := fields["info"].(map[string]any); ok {
+		if att.MimeType == "" {
+			att.MimeType = firstString(info, "mimetype", "mimeType")
+		}
+		if att.Size == 0 {
+			att.Size = firstInt(info, "size")
+		}
+	}
+	if body := firstString(fields, "body"); att.FileName == "" && body != "" && (att.Type == "FILE" || looksLikeFileName(body)) {
+		att.FileName = body
+	}
+	att.AltText = firstString(fields, "altText", "alt_text", "alt")
+	// A body that differs from the file name is a caption (Matrix sends the
+	// file name as body when there is none).
+	att.Caption = firstString(fields, "caption")
+	if att.Caption == "" {
+		for _, candidate := range []string{firstString(fields, "body"), firstString(payload, "text", "body")} {
+			if candidate != "" && candidate != att.FileName && candidate != att.AltText {
+				att.Caption = candidate
+				break
+			}
+		}
+	}
+	if att.URL == "" && att.FileName == "" {
+		return att, false
+	}
+	return att, true
+}
+
+// ScanAttachments returns the attachments of up to opts.Limit visible media
+// messages with IDs greater than opts.AfterID, in ascending ID order, plus
+// the last message ID examined. Callers page by passing that ID as the next
+// AfterID until it stops advancing; media messages whose payload has no
+// recognizable attachment are skipped.
+func (s *Store) ScanAttachments(ctx context.Context, opts ScanOptions) ([]Attachment, int64, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+
+	query := strings.Builder{}
+	query.WriteString(`SELECT id, eventID, roomID, senderContactID, timestamp, type, COALESCE(message, '')
+		FROM mx_room_messages
+		WHERE isDeleted = 0 AND type IN ('IMAGE','VIDEO','AUDIO','FILE','STICKER') AND id > ?`)
+	args := []any{opts.AfterID}
+	if opts.ThreadID != "" {
+		query.WriteString(" AND roomID = ?")
+		args = append(args, opts.ThreadID)
+	}
+	if opts.AccountID != "" {
+		query.WriteString(" AND roomID IN (SELECT threadID FROM threads WHERE accountID = ?)")
+		args = append(args, opts.AccountID)
+	}
+	if opts.After != nil {
+		query.WriteString(" AND timestamp >= ?")
+		args = append(args, opts.After.UnixMilli())
+	}
+	if opts.Before != nil {
+		query.WriteString(" AND timestamp <= ?")
+		args = append(args, opts.Before.UnixMilli())
+	}
+	query.WriteString(" ORDER BY id ASC LIMIT ?")
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(ctx, query.String(), args...)
+	if err != nil {
+		return nil, opts.AfterID, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	lastID := opts.AfterID
+	attachments := []Attachment{}
+	for rows.Next() {
+		var id, ts int64
+		var eventID, roomID, senderID, msgType, raw string
+		if err := rows.Scan(&id, &eventID, &roomID, &senderID, &ts, &msgType, &raw); err != nil {
+			return nil, lastID, err
+		}
+		lastID = id
+		att, ok := ParseAttachment(raw, msgType)
+		if !ok {
+			continue
+		}
+		att.MessageID = id
+		att.EventID = eventID
+		att.ThreadID = roomID
+		att.SenderID = senderID
+		att.Timestamp = unixMillis(ts)
+		attachments = append(attachments, att)
+	}
+	return attachments, lastID, rows.Err()
+}
+
+// ListAttachments returns the attachments of media messages matching opts,
+// newest first, with thread and sender names and, when the file has been
+// downloaded, its path in the local media cache.
+func (s *Store) ListAttachments(ctx context.Context, opts AttachmentOptions) ([]Attachment, error) {
+	msgType := strings.ToUpper(strings.TrimSpace(opts.Type))
+	scan := ScanOptions{
+		ThreadID:  opts.ThreadID,
+		AccountID: opts.AccountID,
+		After:     opts.After,
+		Before:    opts.Before,
+		Limit:     attachmentBatchSize,
+	}
+	attachments := []Attachment{}
+	for {
+		batch, lastID, err := s.ScanAttachments(ctx, scan)
+		if err != nil {
+			return nil, err
+		}
+		if lastID == scan.AfterID {
+			break
+		}
+		for _, att := range batch {
+			if msgType == "" || att.Type == msgType {
+				attachments = append(attachments, att)
+			}
+		}
+		if opts.Limit > 0 && len(attachments) > opts.Limit {
+			attachments = attachments[len(attachments)-opts.Limit:]
+		}
+		scan.AfterID = lastID
+	}
+	slices.Reverse(attachments)
+
+	threadIDs := make([]string, 0, len(attachments))
+	senderIDs := make([]string, 0, len(attachments))
+	for _, att := range attachments {
+		threadIDs = append(threadIDs, att.ThreadID)
+		senderIDs = append(senderIDs, att.SenderID)
+	}
+	threads, err := s.threadNames(ctx, threadIDs)
+	if err != nil {
+		return nil, err
+	}
+	senders, err := s.participantNames(ctx, senderIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range attachments {
+		att := &attachments[i]
+		att.ThreadName = threads[att.ThreadID].DisplayName
+		att.AccountID = threads[att.ThreadID].AccountID
+		att.SenderName = senders[att.SenderID]
+		if path, ok := s.ResolveMediaPath(att.URL); ok {
+			att.LocalPath = path
+		}
+	}
+	return attachments, nil
+}
+
+// ResolveMediaPath maps an attachment URL to a file in the local Beeper
+// media cache next to index.db, if it has been downloaded.
+func (s *Store) ResolveMediaPath(mediaURL string) (string, bool) {
+	if s == nil || s.path == "" {
+		return "", false
+	}
+	return resolveMediaPath(filepath.Dir(s.path), mediaURL)
+}
+
+func resolveMediaPath(root string, mediaURL string) (string, bool) {
+	mediaURL = strings.TrimSpace(mediaURL)
+	if mediaURL == "" {
+		return "", false
+	}
+	parsed, err := url.Parse(mediaURL)
+	if err != nil {
+		return "", false
+	}
+
+	candidates := []string{}
+	switch parsed.Scheme {
+	case "file":
+		candidates = append(candidates, parsed.Path)
+	case "mxc":
+		mediaID := strings.TrimPrefix(parsed.Path, "/")
+		if parsed.Host == "" || mediaID == "" || strings.Contains(mediaID, "..") {
+			return "", false
+		}
+		candidates = append(candidates,
+			filepath.Join(root, "media", parsed.Host, mediaID),
+			filepath.Join(root, "media", mediaID),
+		)
+	default:
+		return "", false
+	}
+
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// looksLikeFileName reports whether s is a bare file name such as
+// "IMG_0001.jpg", as Matrix clients send in the body of uncaptioned media.
+func looksLikeFileName(s string) bool {
+	ext := filepath.Ext(s)
+	return len(ext) > 1 && len(ext) <= 5 && !strings.ContainsAny(s, " \t\n/")
+}
+
+func firstInt(payload map[string]any, keys ...string) int64 {
+	for _, key := range keys {
+		switch value := payload[key].(type) {
+		case float64:
+			return int64(value)
+		case json.Number:
+			if n, err := value.Int64(); err == nil {
+				return n
+			}
+		}
+	}
+	return 0
+}
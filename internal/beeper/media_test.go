@@ -0,0 +1,84 @@
+package beeper
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseAttachmentCaption(t *testing.T) {
+	tests := []struct {
+		raw, msgType           string
+		fileName, caption, alt string
+	}{
+		{`{"body":"slides.pdf","filename":"Q3 Roadmap.pdf","url":"mxc://x/a"}`, "FILE", "Q3 Roadmap.pdf", "slides.pdf", ""},
+		{`{"body":"IMG_0001.jpg","url":"mxc://x/b"}`, "IMAGE", "IMG_0001.jpg", "", ""},
+		{`{"body":"rent receipt for march","url":"mxc://x/c"}`, "IMAGE", "", "rent receipt for march", ""},
+		{`{"text":"the signed contract","attachments":[{"fileName":"contract.pdf","srcURL":"file:///tmp/c.pdf","altText":"PDF scan"}]}`, "FILE", "contract.pdf", "the signed contract", "PDF scan"},
+	}
+	for _, tt := range tests {
+		att, ok := ParseAttachment(tt.raw, tt.msgType)
+		if !ok {
+			t.Fatalf("%s: no attachment", tt.raw)
+		}
+		if att.FileName != tt.fileName || att.Caption != tt.caption || att.AltText != tt.alt {
+			t.Fatalf("%s: got file=%q caption=%q alt=%q", tt.raw, att.FileName, att.Caption, att.AltText)
+		}
+	}
+}
+
+func TestListAttachments(t *testing.T) {
+	path := createTestDB(t, false)
+	insertMessages(t, path,
+		testMessage{roomID: "!room1:beeper.local", eventID: "$img", sender: "@alice:beeper.local", ts: 1700000001000, typ: "IMAGE", hsOrder: 10,
+			message: `{"body":"IMG_0001.jpg","url":"mxc://beeper.local/img"}`},
+		testMessage{roomID: "!room1:beeper.local", eventID: "$pdf", sender: "@alice:beeper.local", ts: 1700000002000, typ: "FILE", hsOrder: 10,
+			message: `{"body":"plan.pdf","info":{"mimetype":"application/pdf","size":10},"url":"mxc://beeper.local/pdf"}`},
+		testMessage{roomID: "!room1:beeper.local", eventID: "$bad", sender: "@alice:beeper.local", ts: 1700000003000, typ: "FILE", hsOrder: 10, message: `{}`},
+	)
+	cached := filepath.Join(filepath.Dir(path), "media", "beeper.local", "pdf")
+	if err := os.MkdirAll(filepath.Dir(cached), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(cached, []byte("0123456789"), 0o644); err != nil {
+		t.Fatalf("write media: %v", err)
+	}
+
+	store, err := OpenWithOptions(path, StoreOptions{BridgeLookup: false})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	ctx := context.Background()
+
+	all, err := store.ListAttachments(ctx, AttachmentOptions{ThreadID: "!room1:beeper.local"})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(all) != 2 || all[0].EventID != "$pdf" || all[1].EventID != "$img" {
+		t.Fatalf("expected newest first, got %+v", all)
+	}
+	pdf := all[0]
+	if pdf.LocalPath != cached || pdf.SenderName != "Alice" || pdf.ThreadName != "Team Chat" || pdf.AccountID != "whatsapp" || pdf.Size != 10 {
+		t.Fatalf("unexpected attachment: %+v", pdf)
+	}
+	if all[1].LocalPath != "" {
+		t.Fatalf("expected uncached image, got %q", all[1].LocalPath)
+	}
+
+	images, err := store.ListAttachments(ctx, AttachmentOptions{Type: "image"})
+	if err != nil {
+		t.Fatalf("list images: %v", err)
+	}
+	if len(images) != 1 || images[0].EventID != "$img" {
+		t.Fatalf("unexpected images: %+v", images)
+	}
+	latest, err := store.ListAttachments(ctx, AttachmentOptions{Limit: 1})
+	if err != nil {
+		t.Fatalf("list latest: %v", err)
+	}
+	if len(latest) != 1 || latest[0].EventID != "$pdf" {
+		t.Fatalf("unexpected latest: %+v", latest)
+	}
+}
@@ -0,0 +1,135 @@
+package beeper
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"strings"
+)
+
+// Membership actions, as ListMembership reports them.
+const (
+	MembershipJoined   = "joined"
+	MembershipLeft     = "left"
+	MembershipInvited  = "invited"
+	MembershipDeclined = "declined"
+	MembershipRemoved  = "removed"
+	MembershipBanned   = "banned"
+	MembershipUnbanned = "unbanned"
+	MembershipKnocked  = "knocked"
+)
+
+// ListMembership returns who joined, left, was invited to, or was removed
+// from a thread, oldest first. Beeper's index keeps no room state table, so
+// the history comes from the m.room.member events stored as message rows;
+// it only reaches back as far as Beeper synced the room, and profile
+// changes (a join following a join) are left out.
+func (s *Store) ListMembership(ctx context.Context, threadID string) ([]MembershipChange, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT m.eventID, m.senderContactID, m.timestamp, m.message
+		FROM mx_room_messages m
+		WHERE m.roomID = ? AND m.isDeleted = 0 AND json_valid(m.message)
+		AND COALESCE(json_extract(m.message, '$.membership'), json_extract(m.message, '$.content.membership')) IS NOT NULL
+		ORDER BY m.timestamp ASC, m.id ASC`, threadID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	changes := []MembershipChange{}
+	for rows.Next() {
+		var eventID, senderID, raw string
+		var ts int64
+		if err := rows.Scan(&eventID, &senderID, &ts, &raw); err != nil {
+			return nil, err
+		}
+		change, ok := parseMembership(raw, senderID)
+		if !ok {
+			continue
+		}
+		change.EventID = eventID
+		change.Timestamp = unixMillis(ts)
+		changes = append(changes, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	participants, err := s.participantsByRoom(ctx, []string{threadID})
+	if err != nil {
+		return nil, err
+	}
+	index := indexParticipants(participants[threadID])
+	for i := range changes {
+		if p, ok := index[changes[i].UserID]; ok && p.Name != "" {
+			changes[i].UserName = p.Name
+		}
+		if p, ok := index[changes[i].ActorID]; ok {
+			changes[i].ActorName = p.Name
+		}
+	}
+	return changes, nil
+}
+
+// parseMembership reads an m.room.member event, stored either whole or as
+// its content, into a change. The affected user is the state key, or the
+// sender when the row has none; the previous membership tells a leave from
+// a declined invite, a kick, or an unban.
+func parseMembership(raw string, senderID string) (MembershipChange, bool) {
+	var event map[string]any
+	if err := json.Unmarshal([]byte(raw), &event); err != nil {
+		return MembershipChange{}, false
+	}
+	content := event
+	if nested, ok := event["content"].(map[string]any); ok {
+		content = nested
+	}
+	prev, _ := event["prev_content"].(map[string]any)
+	if unsigned, ok := event["unsigned"].(map[string]any); ok && prev == nil {
+		prev, _ = unsigned["prev_content"].(map[string]any)
+	}
+	previous := ""
+	if prev != nil {
+		previous = firstString(prev, "membership")
+	}
+
+	change := MembershipChange{
+		UserID:   cmp.Or(firstString(event, "state_key", "stateKey"), senderID),
+		UserName: firstString(content, "displayname"),
+		Reason:   firstString(content, "reason"),
+	}
+	if sender := firstString(event, "sender"); sender != "" {
+		senderID = sender
+	}
+	byOther := senderID != "" && senderID != change.UserID
+	if byOther {
+		change.ActorID = senderID
+	}
+
+	switch strings.ToLower(firstString(content, "membership")) {
+	case "join":
+		if previous == "join" {
+			return MembershipChange{}, false
+		}
+		change.Action = MembershipJoined
+	case "invite":
+		change.Action = MembershipInvited
+	case "knock":
+		change.Action = MembershipKnocked
+	case "ban":
+		change.Action = MembershipBanned
+	case "leave":
+		switch {
+		case previous == "ban":
+			change.Action = MembershipUnbanned
+		case byOther:
+			change.Action = MembershipRemoved
+		case previous == "invite":
+			change.Action = MembershipDeclined
+		default:
+			change.Action = MembershipLeft
+		}
+	default:
+		return MembershipChange{}, false
+	}
+	return change, true
+}
@@ -0,0 +1,53 @@
+package beeper
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestListMembership(t *testing.T) {
+	path := createTestDB(t, false)
+	insertMessages(t, path,
+		testMessage{id: 100, roomID: "!room1:beeper.local", eventID: "$member0", sender: "@alice:beeper.local", ts: 1600000000000, typ: "HIDDEN", hsOrder: 100,
+			message: `{"type":"m.room.member","sender":"@me:beeper.local","state_key":"@alice:beeper.local","content":{"membership":"invite"}}`},
+		testMessage{id: 101, roomID: "!room1:beeper.local", eventID: "$member1", sender: "@alice:beeper.local", ts: 1600000001000, typ: "HIDDEN", hsOrder: 101,
+			message: `{"type":"m.room.member","sender":"@alice:beeper.local","state_key":"@alice:beeper.local","content":{"membership":"join","displayname":"Alice"},"unsigned":{"prev_content":{"membership":"invite"}}}`},
+		testMessage{id: 102, roomID: "!room1:beeper.local", eventID: "$member2", sender: "@alice:beeper.local", ts: 1600000002000, typ: "HIDDEN", hsOrder: 102,
+			message: `{"type":"m.room.member","sender":"@alice:beeper.local","state_key":"@alice:beeper.local","content":{"membership":"join","displayname":"Alice B."},"unsigned":{"prev_content":{"membership":"join"}}}`},
+		testMessage{id: 103, roomID: "!room1:beeper.local", eventID: "$member3", sender: "@carol:beeper.local", ts: 1600000003000, typ: "HIDDEN", hsOrder: 103,
+			message: `{"membership":"join","displayname":"Carol"}`},
+		testMessage{id: 104, roomID: "!room1:beeper.local", eventID: "$member4", sender: "@alice:beeper.local", ts: 1600000004000, typ: "HIDDEN", hsOrder: 104,
+			message: `{"type":"m.room.member","sender":"@alice:beeper.local","state_key":"@carol:beeper.local","content":{"membership":"leave","reason":"spam"},"prev_content":{"membership":"join"}}`},
+		testMessage{id: 105, roomID: "!room1:beeper.local", eventID: "$member5", sender: "@alice:beeper.local", ts: 1600000005000, typ: "HIDDEN", hsOrder: 105,
+			message: `{"type":"m.room.member","sender":"@dave:beeper.local","state_key":"@dave:beeper.local","content":{"membership":"leave"},"unsigned":{"prev_content":{"membership":"invite"}}}`},
+	)
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	changes, err := store.ListMembership(context.Background(), "!room1:beeper.local")
+	if err != nil {
+		t.Fatalf("membership: %v", err)
+	}
+	lines := []string{}
+	for _, c := range changes {
+		lines = append(lines, strings.Join([]string{c.Action, c.UserID, c.UserName, c.ActorName, c.Reason}, ","))
+	}
+	want := []string{
+		"invited,@alice:beeper.local,Alice,,",
+		"joined,@alice:beeper.local,Alice,,",
+		"joined,@carol:beeper.local,Carol,,",
+		"removed,@carol:beeper.local,,Alice,spam",
+		"declined,@dave:beeper.local,,,",
+	}
+	if got := strings.Join(lines, "\n"); got != strings.Join(want, "\n") {
+		t.Fatalf("unexpected membership:\n%s", got)
+	}
+	if changes[0].EventID != "$member0" || changes[0].ActorID != "@me:beeper.local" {
+		t.Fatalf("unexpected first change: %+v", changes[0])
+	}
+}
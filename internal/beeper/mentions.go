@@ -0,0 +1,347 @@
+package beeper
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"maps"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// selfIdentity lists the IDs and names the user is known by in a room.
+type selfIdentity struct {
+	ids   []string
+	names []string
+}
+
+// ListMentions returns messages from others that mention the user, newest
+// first. A message counts as a mention when its payload lists one of the
+// user's IDs in m.mentions/mentions, when the text contains a matrix.to link
+// or the raw ID, or when it contains one of the user's display names as a
+// whole word. With UnreadOnly, only mentions after the thread's read marker
+// in threads with unread mentions are returned.
+func (s *Store) ListMentions(ctx context.Context, opts MentionOptions) ([]Message, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+
+	selfByRoom, err := s.selfIdentities(ctx, opts.ThreadID)
+	if err != nil {
+		return nil, err
+	}
+	if len(selfByRoom) == 0 {
+		return []Message{}, nil
+	}
+
+	query := strings.Builder{}
+	query.WriteString(`SELECT id, eventID, roomID, senderContactID, timestamp, isSentByMe, type,
+		COALESCE(text_content, '') AS text_content,
+		COALESCE(message, '') AS message
+		FROM mx_room_messages
+		WHERE isDeleted = 0
+		AND isSentByMe = 0
+		AND type NOT IN ('HIDDEN','REACTION')`)
+	args := []any{}
+	if opts.ThreadID != "" {
+		query.WriteString(" AND roomID = ?")
+		args = append(args, opts.ThreadID)
+	}
+	if opts.AccountID != "" {
+		query.WriteString(" AND roomID IN (SELECT threadID FROM threads WHERE accountID = ?)")
+		args = append(args, opts.AccountID)
+	}
+	if opts.After != nil {
+		query.WriteString(" AND timestamp >= ?")
+		args = append(args, opts.After.UnixMilli())
+	}
+	// Read markers are loaded before the mentions query, whose open rows
+	// would otherwise hold a connection while ReadMarker waits for another.
+	var markers map[string]*ReadMarker
+	if opts.UnreadOnly {
+		markers, err = s.unreadMentionMarkers(ctx, selfByRoom)
+		if err != nil {
+			return nil, err
+		}
+		rooms, err := json.Marshal(slices.Sorted(maps.Keys(markers)))
+		if err != nil {
+			return nil, err
+		}
+		query.WriteString(" AND roomID IN (SELECT value FROM json_each(?))")
+		args = append(args, string(rooms))
+	}
+	query.WriteString(" ORDER BY timestamp DESC")
+
+	rows, err := s.db.QueryContext(ctx, query.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	mentions := []Message{}
+	for rows.Next() && len(mentions) < limit {
+		var msg Message
+		var ts int64
+		var isSentByMe int
+		var msgType sql.NullString
+		var textContent sql.NullString
+		var rawMessage sql.NullString
+		if err := rows.Scan(
+			&msg.ID,
+			&msg.EventID,
+			&msg.ThreadID,
+			&msg.SenderID,
+			&ts,
+			&isSentByMe,
+			&msgType,
+			&textContent,
+			&rawMessage,
+		); err != nil {
+			return nil, err
+		}
+		self, ok := selfByRoom[msg.ThreadID]
+		if !ok {
+			continue
+		}
+		if opts.UnreadOnly {
+			if marker := markers[msg.ThreadID]; marker != nil && (ts < marker.Timestamp.UnixMilli() || ts == marker.Timestamp.UnixMilli() && msg.ID <= marker.MessageID) {
+				continue
+			}
+		}
+		msg.Type = strings.TrimSpace(msgType.String)
+		plain := ResolveMessageText(rawMessage.String, msg.Type, textContent.String, FormatPlain)
+		if !isMention(rawMessage.String, plain, self) {
+			continue
+		}
+		msg.Timestamp = unixMillis(ts)
+		msg.IsSentByMe = isSentByMe != 0
+		msg.Text = ResolveMessageText(rawMessage.String, msg.Type, textContent.String, opts.Format)
+		msg.Undecrypted = isUndecrypted(rawMessage.String, msg.Type, textContent.String)
+		msg.ThreadRootEventID, msg.ReplyToEventID = parseRelation(rawMessage.String)
+		mentions = append(mentions, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := s.enrichMessages(ctx, mentions); err != nil {
+		return nil, err
+	}
+	return mentions, nil
+}
+
+// unreadMentionMarkers returns the read markers of the rooms in selfByRoom
+// whose thread has unread mentions, keyed by room ID. A room without a
+// marker maps to nil.
+func (s *Store) unreadMentionMarkers(ctx context.Context, selfByRoom map[string]selfIdentity) (map[string]*ReadMarker, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT threadID FROM threads WHERE COALESCE(json_extract(thread,'$.unreadMentionsCount'), 0) > 0`)
+	if err != nil {
+		return nil, err
+	}
+	rooms := []string{}
+	for rows.Next() {
+		var room string
+		if err := rows.Scan(&room); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+		if _, ok := selfByRoom[room]; ok {
+			rooms = append(rooms, room)
+		}
+	}
+	err = rows.Err()
+	_ = rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	markers := map[string]*ReadMarker{}
+	for _, room := range rooms {
+		marker, err := s.ReadMarker(ctx, room)
+		if err != nil {
+			return nil, err
+		}
+		markers[room] = marker
+	}
+	return markers, nil
+}
+
+// selfIdentities loads the user's participant rows, optionally for one room.
+func (s *Store) selfIdentities(ctx context.Context, roomID string) (map[string]selfIdentity, error) {
+	query := `SELECT room_id, id, full_name, nickname FROM participants WHERE is_self = 1`
+	args := []any{}
+	if roomID != "" {
+		query += " AND room_id = ?"
+		args = append(args, roomID)
+	}
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	identities := map[string]selfIdentity{}
+	for rows.Next() {
+		var room, id string
+		var fullName, nickname sql.NullString
+		if err := rows.Scan(&room, &id, &fullName, &nickname); err != nil {
+			return nil, err
+		}
+		identity := identities[room]
+		identity.ids = append(identity.ids, id)
+		for _, name := range []string{fullName.String, nickname.String} {
+			name = strings.TrimSpace(name)
+			if utf8.RuneCountInString(name) >= 2 {
+				identity.names = append(identity.names, name)
+			}
+		}
+		identities[room] = identity
+	}
+	return identities, rows.Err()
+}
+
+func isMention(rawMessage string, text string, self selfIdentity) bool {
+	var payload map[string]any
+	if strings.TrimSpace(rawMessage) != "" {
+		_ = json.Unmarshal([]byte(rawMessage), &payload)
+	}
+
+	for _, mentioned := range payloadMentions(payload) {
+		for _, id := range self.ids {
+			if mentioned == id {
+				return true
+			}
+		}
+	}
+
+	haystack := text
+	if payload != nil {
+		haystack += "\n" + firstString(payload, "formatted_body", "formattedBody", "html")
+	}
+	for _, id := range self.ids {
+		if strings.Contains(haystack, id) || strings.Contains(haystack, "matrix.to/#/"+id) {
+			return true
+		}
+	}
+	for _, name := range self.names {
+		if containsWord(text, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// payloadMentions collects user IDs from m.mentions.user_ids and mentions
+// arrays, at the top level or nested under content/extra.
+func payloadMentions(payload map[string]any) []string {
+	if payload == nil {
+		return nil
+	}
+	ids := []string{}
+	collect := func(m map[string]any) {
+		if mm, ok := m["m.mentions"].(map[string]any); ok {
+			ids = append(ids, stringList(mm["user_ids"])...)
+		}
+		ids = append(ids, stringList(m["mentions"])...)
+	}
+	collect(payload)
+	for _, key := range metadataKeys {
+		if nested, ok := payload[key].(map[string]any); ok {
+			collect(nested)
+		}
+	}
+	return ids
+}
+
+func stringList(value any) []string {
+	list, ok := value.([]any)
+	if !ok {
+		return nil
+	}
+	out := []string{}
+	for _, item := range list {
+		switch v := item.(type) {
+		case string:
+			out = append(out, v)
+		case map[string]any:
+			if id := firstString(v, "id", "userID", "userId", "user_id"); id != "" {
+				out = append(out, id)
+			}
+		}
+	}
+	return out
+}
+
+// containsWord reports whether needle occurs in text case-insensitively and
+// is not surrounded by letters or digits.
+func containsWord(text string, needle string) bool {
+	lowerText := strings.ToLower(text)
+	lowerNeedle := strings.ToLower(needle)
+	if lowerNeedle == "" {
+		return false
+	}
+	for start := 0; start < len(lowerText); {
+		idx := strings.Index(lowerText[start:], lowerNeedle)
+		if idx < 0 {
+			return false
+		}
+		idx += start
+		end := idx + len(lowerNeedle)
+		before, _ := utf8.DecodeLastRuneInString(lowerText[:idx])
+		after, _ := utf8.DecodeRuneInString(lowerText[end:])
+		if !isWordRune(before) && !isWordRune(after) {
+			return true
+		}
+		start = idx + 1
+	}
+	return false
+}
+
+func isWordRune(r rune) bool {
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// enrichMessages fills AccountID, ThreadName, and SenderName for messages
+// that may span several threads.
+func (s *Store) enrichMessages(ctx context.Context, messages []Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	roomIDs := make([]string, 0, len(messages))
+	for _, msg := range messages {
+		roomIDs = append(roomIDs, msg.ThreadID)
+	}
+	roomIDs = uniqueStrings(roomIDs)
+
+	info, err := s.threadInfoByID(ctx, roomIDs)
+	if err != nil {
+		return err
+	}
+	participantsByRoom, err := s.participantsByRoom(ctx, roomIDs)
+	if err != nil {
+		return err
+	}
+	s.prefetchBridgeNames(ctx, threadsFromInfo(info))
+	names := map[string]string{}
+	indexByRoom := map[string]map[string]Participant{}
+	for _, roomID := range roomIDs {
+		i := info[roomID]
+		thread := Thread{ID: roomID, AccountID: i.AccountID, Title: i.Title, Name: i.Name, Type: i.Type}
+		names[roomID] = s.displayName(ctx, thread, participantsByRoom[roomID])
+		indexByRoom[roomID] = indexParticipants(participantsByRoom[roomID])
+	}
+
+	for i := range messages {
+		roomID := messages[i].ThreadID
+		messages[i].AccountID = info[roomID].AccountID
+		messages[i].ThreadName = names[roomID]
+		if p, ok := indexByRoom[roomID][messages[i].SenderID]; ok {
+			messages[i].SenderName = p.Name
+		}
+	}
+	s.setPreviews(messages)
+	return nil
+}
@@ -0,0 +1,82 @@
+package beeper
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestIsMention(t *testing.T) {
+	self := selfIdentity{ids: []string{"@me:beeper.local"}, names: []string{"Felix"}}
+
+	cases := []struct {
+		name string
+		raw  string
+		text string
+		want bool
+	}{
+		{"m.mentions", `{"text":"look","m.mentions":{"user_ids":["@me:beeper.local"]}}`, "look", true},
+		{"matrix.to link", `{"formatted_body":"<a href=\"https://matrix.to/#/@me:beeper.local\">F</a> hi"}`, "F hi", true},
+		{"display name", `{}`, "thanks felix!", true},
+		{"name inside word", `{}`, "felixstowe trip", false},
+		{"other user", `{"m.mentions":{"user_ids":["@bob:beeper.local"]}}`, "hey bob", false},
+	}
+	for _, tc := range cases {
+		if got := isMention(tc.raw, tc.text, self); got != tc.want {
+			t.Fatalf("%s: expected %t, got %t", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestListMentionsUnreadOnly(t *testing.T) {
+	path := createTestDB(t, false)
+	writer, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("open writer: %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+	for _, room := range []string{"!room1:beeper.local", "!room3:beeper.local"} {
+		if _, err := writer.Exec("INSERT INTO participants (account_id, room_id, id, full_name, nickname, is_self) VALUES ('whatsapp', ?, '@me:beeper.local', 'Felix', '', 1)", room); err != nil {
+			t.Fatalf("insert self: %v", err)
+		}
+	}
+	// Room 1 was last opened at ...500 and has one unread mention; room 3 has none.
+	insertMessages(t, path,
+		testMessage{roomID: "!room1:beeper.local", eventID: "$read", sender: "@alice:beeper.local", ts: 1700000000450, hsOrder: 20, text: "ping felix"},
+		testMessage{roomID: "!room1:beeper.local", eventID: "$unread", sender: "@alice:beeper.local", ts: 1700000000900, hsOrder: 20, text: "ping felix"},
+		testMessage{roomID: "!room3:beeper.local", eventID: "$other", sender: "@alice:beeper.local", ts: 1700000000950, hsOrder: 20, text: "ping felix"},
+	)
+
+	store, err := OpenWithOptions(path, StoreOptions{BridgeLookup: false})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	ctx := context.Background()
+
+	all, err := store.ListMentions(ctx, MentionOptions{})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(all) != 3 || all[0].EventID != "$other" || all[2].EventID != "$read" {
+		t.Fatalf("unexpected mentions: %+v", all)
+	}
+
+	unread, err := store.ListMentions(ctx, MentionOptions{UnreadOnly: true})
+	if err != nil {
+		t.Fatalf("list unread: %v", err)
+	}
+	if len(unread) != 1 || unread[0].EventID != "$unread" || unread[0].ThreadName != "Team Chat" {
+		t.Fatalf("unexpected unread mentions: %+v", unread)
+	}
+
+	// Read markers must not be queried while the mentions rows hold the
+	// only free connection.
+	store.db.SetMaxOpenConns(1)
+	timeout, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if unread, err := store.ListMentions(timeout, MentionOptions{UnreadOnly: true}); err != nil || len(unread) != 1 {
+		t.Fatalf("list unread on one connection: %v, %+v", err, unread)
+	}
+}
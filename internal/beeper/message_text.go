@@ -7,7 +7,12 @@ import (
 )
 
 // ResolveMessageText produces a display string based on the chosen format.
+// Messages Beeper has not decrypted yet render as UndecryptedText in
+// either format.
 func ResolveMessageText(rawMessage string, msgType string, textContent string, format MessageFormat) string {
+	if isUndecrypted(rawMessage, msgType, textContent) {
+		return UndecryptedText
+	}
 	if format == FormatPlain {
 		if strings.TrimSpace(textContent) != "" {
 			return textContent
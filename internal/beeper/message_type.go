@@ -0,0 +1,47 @@
+package beeper
+
+import "strings"
+
+// Message kinds accepted by the Type filters besides raw message types.
+const (
+	MessageKindImage    = "image"
+	MessageKindVideo    = "video"
+	MessageKindAudio    = "audio"
+	MessageKindFile     = "file"
+	MessageKindLocation = "location"
+	MessageKindLink     = "link"
+)
+
+// messageKindTypes maps kinds to the message types they match. Voice notes
+// count as audio.
+var messageKindTypes = map[string][]string{
+	MessageKindImage:    {"IMAGE"},
+	MessageKindVideo:    {"VIDEO"},
+	MessageKindAudio:    {"AUDIO", "VOICE"},
+	MessageKindFile:     {"FILE"},
+	MessageKindLocation: {"LOCATION"},
+}
+
+// linkCondition matches messages whose text contains a URL. LIKE is
+// case-insensitive for ASCII, so HTTPS:// and WWW. match too.
+const linkCondition = " AND (" + messageText + " LIKE '%http://%' OR " +
+	messageText + " LIKE '%https://%' OR " + messageText + " LIKE '%www.%')"
+
+// messageTypeFilter returns the condition (with a leading AND) restricting
+// m to a message kind (image, video, audio, file, location, link) or, for
+// any other value, to that exact message type (TEXT, STICKER, ...).
+func messageTypeFilter(value string) (string, []any) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+	kind := strings.ToLower(value)
+	if kind == MessageKindLink {
+		return linkCondition, nil
+	}
+	types, ok := messageKindTypes[kind]
+	if !ok {
+		types = []string{strings.ToUpper(value)}
+	}
+	return " AND m.type IN (" + placeholders(len(types)) + ")", stringSliceToAny(types)
+}
@@ -0,0 +1,53 @@
+package beeper
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFilterByMessageKind(t *testing.T) {
+	path := createTestDB(t, false)
+	insertMessages(t, path,
+		testMessage{roomID: "!room1:beeper.local", eventID: "$img", sender: "@alice:beeper.local", ts: 1700000001000, typ: "IMAGE", hsOrder: 10, message: `{"body":"IMG_0001.jpg"}`},
+		testMessage{roomID: "!room1:beeper.local", eventID: "$voice", sender: "@alice:beeper.local", ts: 1700000002000, typ: "VOICE", hsOrder: 10, message: `{"body":"voice.ogg"}`},
+		testMessage{roomID: "!room1:beeper.local", eventID: "$pdf", sender: "@alice:beeper.local", ts: 1700000003000, typ: "FILE", hsOrder: 10, message: `{"body":"plan.pdf"}`},
+		testMessage{roomID: "!room1:beeper.local", eventID: "$link", sender: "@alice:beeper.local", ts: 1700000004000, hsOrder: 10, text: "see HTTPS://example.com/plan"},
+	)
+
+	store, err := OpenWithOptions(path, StoreOptions{BridgeLookup: false})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	ctx := context.Background()
+
+	tests := map[string][]string{
+		"image": {"$img"},
+		"audio": {"$voice"},
+		"File":  {"$pdf"},
+		"link":  {"$link"},
+		"VOICE": {"$voice"},
+		"video": {},
+	}
+	for kind, want := range tests {
+		page, err := store.ListMessagesPage(ctx, MessageListOptions{ThreadID: "!room1:beeper.local", Limit: 10, Type: kind})
+		if err != nil {
+			t.Fatalf("list %s: %v", kind, err)
+		}
+		got := []string{}
+		for _, msg := range page.Messages {
+			got = append(got, msg.EventID)
+		}
+		if len(got) != len(want) || (len(want) > 0 && got[0] != want[0]) {
+			t.Fatalf("list %s: got %v, want %v", kind, got, want)
+		}
+
+		results, err := store.SearchMessages(ctx, SearchOptions{Limit: 10, Type: kind})
+		if err != nil {
+			t.Fatalf("search %s: %v", kind, err)
+		}
+		if len(results) != len(want) || (len(want) > 0 && results[0].Match.EventID != want[0]) {
+			t.Fatalf("search %s: got %d results, want %v", kind, len(results), want)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package beeper
 
-import "time"
+import (
+	"database/sql/driver"
+	"time"
+)
 
 const (
 	defaultLimit         = 50
@@ -33,10 +36,60 @@ const (
 	LabelUnread ThreadLabel = "unread"
 )
 
+// ThreadSort orders thread lists.
+type ThreadSort string
+
+const (
+	// SortActivity orders threads by last activity, newest first.
+	SortActivity ThreadSort = "activity"
+	// SortImportance orders threads by the importance of their contacts
+	// (see RankContacts).
+	SortImportance ThreadSort = "importance"
+	// SortUnread orders threads by unread count, most first.
+	SortUnread ThreadSort = "unread"
+	// SortName orders threads by display name, A to Z.
+	SortName ThreadSort = "name"
+	// SortMessages orders threads by message count, most first.
+	SortMessages ThreadSort = "messages"
+)
+
+// ThreadType filters thread lists by kind of chat.
+type ThreadType string
+
+const (
+	// ThreadTypeDM matches one-to-one chats (type "single" or "dm").
+	ThreadTypeDM ThreadType = "dm"
+	// ThreadTypeGroup matches every other chat.
+	ThreadTypeGroup ThreadType = "group"
+)
+
 // StoreOptions configures Store behavior.
 type StoreOptions struct {
 	BridgeLookup bool
 	BridgeRoot   string
+	// Connector, when set, opens the database instead of the sqlite3
+	// driver, e.g. to record or replay the queries a command issues. It
+	// receives the read-only sqlite3 DSN.
+	Connector func(dsn string) driver.Connector
+	// Immutable opens the database with immutable=1: SQLite takes no locks
+	// and ignores the write-ahead log, so changes Beeper has not yet
+	// checkpointed are not visible and the running app is never blocked.
+	Immutable bool
+	// NoParticipants skips loading participants for enrichment: messages
+	// get no sender names, threads no participant lists, and untitled
+	// group threads their room ID as display name.
+	NoParticipants bool
+	// NoNames skips every name lookup: participants as with NoParticipants
+	// and bridge databases as without BridgeLookup, leaving only titles and
+	// IDs.
+	NoNames bool
+	// Snapshot copies the database, write-ahead log included, to a temp
+	// file with VACUUM INTO and queries the copy, which Close removes.
+	// Results are consistent as of the copy however long the command runs.
+	Snapshot bool
+	// PreviewLength is the length of Message.Preview in runes; zero means
+	// DefaultPreviewLength.
+	PreviewLength int
 }
 
 // Thread describes a conversation.
@@ -59,6 +112,46 @@ type Thread struct {
 	TotalMessages  int           `json:"totalMessages,omitempty"`
 	Tags           []string      `json:"tags,omitempty"`
 	Participants   []Participant `json:"participants,omitempty"`
+	// TotalMessages is also set when sorting by SortMessages.
+	// Importance is set when sorting by SortImportance.
+	Importance float64 `json:"importance,omitempty"`
+	// Undecrypted counts the messages Beeper has not decrypted yet; set by
+	// GetThread.
+	Undecrypted int `json:"undecrypted,omitempty"`
+	// NameSource and Bridge are set by GetThread.
+	NameSource string         `json:"nameSource,omitempty"`
+	Bridge     *BridgeContact `json:"bridge,omitempty"`
+	// CanonicalAlias and Aliases are the room's published Matrix aliases
+	// (#name:server); set by GetThread.
+	CanonicalAlias string   `json:"canonicalAlias,omitempty"`
+	Aliases        []string `json:"aliases,omitempty"`
+	// Reading is set by `threads show --with-stats`.
+	Reading *ReadingStats `json:"reading,omitempty"`
+	// ReadMarker is set by `threads show`; nil when nothing is read yet.
+	ReadMarker *ReadMarker `json:"readMarker,omitempty"`
+	// Membership is set by `threads show --membership`.
+	Membership []MembershipChange `json:"membership,omitempty"`
+}
+
+// Where a thread's DisplayName came from.
+const (
+	NameSourceTitle        = "title"
+	NameSourceName         = "name"
+	NameSourceBridge       = "bridge"
+	NameSourceParticipants = "participants"
+	NameSourceUnknown      = "unknown"
+	// NameSourceID means names were skipped (StoreOptions.NoNames or
+	// NoParticipants) and the room ID stands in.
+	NameSourceID = "id"
+)
+
+// BridgeContact is the remote user behind a DM, as recorded by the
+// platform's bridge database. RemoteID is the user's ID on the native
+// network (e.g. a phone number JID on WhatsApp).
+type BridgeContact struct {
+	Platform string `json:"platform"`
+	RemoteID string `json:"remoteId"`
+	Name     string `json:"name,omitempty"`
 }
 
 // Participant represents a user in a thread.
@@ -81,7 +174,38 @@ type Message struct {
 	IsSentByMe bool      `json:"isSentByMe"`
 	Type       string    `json:"type"`
 	Text       string    `json:"text"`
-	Score      float64   `json:"score,omitempty"`
+	// Preview is Text on one line, shortened for lists (see Preview). It
+	// is set when messages are enriched with names.
+	Preview    string      `json:"preview,omitempty"`
+	Score      float64     `json:"score,omitempty"`
+	Provenance *Provenance `json:"provenance,omitempty"`
+	SessionID  string      `json:"sessionId,omitempty"`
+	// ThreadRootEventID is the root of the Matrix thread (m.thread) the
+	// message belongs to; ReplyToEventID is the event it replies to. Both
+	// are set by ListMessages.
+	ThreadRootEventID string `json:"threadRootEventId,omitempty"`
+	ReplyToEventID    string `json:"replyToEventId,omitempty"`
+	// ReactionCount and ReplyCount count the events that react to or reply
+	// to this message; set when engagement is requested.
+	ReactionCount int `json:"reactionCount,omitempty"`
+	ReplyCount    int `json:"replyCount,omitempty"`
+	// Reactions lists who reacted with what; set with WithReactions.
+	Reactions []Reaction `json:"reactions,omitempty"`
+	// IsDeleted marks a removed message; only listed with IncludeDeleted.
+	IsDeleted bool `json:"isDeleted,omitempty"`
+	// Edited and PreviousVersions (earlier texts, oldest first) are set
+	// with ShowEdits; Text is then the latest version.
+	Edited           bool     `json:"edited,omitempty"`
+	PreviousVersions []string `json:"previousVersions,omitempty"`
+	// Undecrypted marks a message Beeper has not decrypted yet; its Text is
+	// UndecryptedText.
+	Undecrypted bool `json:"undecrypted,omitempty"`
+	// Disappearing marks a message the bridge set to disappear; ExpiresAt
+	// is when, if known. Expired is set once it has, and a deleted expired
+	// message is a tombstone whose Text is ExpiredText.
+	Disappearing bool       `json:"disappearing,omitempty"`
+	ExpiresAt    *time.Time `json:"expiresAt,omitempty"`
+	Expired      bool       `json:"expired,omitempty"`
 }
 
 // SearchResult is a match plus optional surrounding context.
@@ -90,34 +214,497 @@ type SearchResult struct {
 	Context []Message `json:"context,omitempty"`
 }
 
+// Reaction is one person's reaction to a message.
+type Reaction struct {
+	EventID    string    `json:"eventId"`
+	Key        string    `json:"key"`
+	SenderID   string    `json:"senderId"`
+	SenderName string    `json:"senderName,omitempty"`
+	IsSentByMe bool      `json:"isSentByMe"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// ReactionTally counts the reactions with one key.
+type ReactionTally struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+}
+
+// MessageReactions is a message with the reactions it received.
+type MessageReactions struct {
+	Message   Message         `json:"message"`
+	Reactions []Reaction      `json:"reactions"`
+	Tallies   []ReactionTally `json:"tallies"`
+}
+
+// ReactionOptions controls reaction listing.
+type ReactionOptions struct {
+	ThreadID string
+	// Key keeps only reactions with this emoji (see MatchesEmoji).
+	Key    string
+	After  *time.Time
+	Before *time.Time
+	Limit  int
+	Format MessageFormat
+}
+
+// SearchBlock is one stretch of a conversation around one or more search
+// hits, with overlapping context merged.
+type SearchBlock struct {
+	ThreadID   string  `json:"threadId"`
+	ThreadName string  `json:"threadName,omitempty"`
+	AccountID  string  `json:"accountId,omitempty"`
+	Matches    []int64 `json:"matches"`
+	// Messages holds the hits and their context in chronological order.
+	Messages []Message `json:"messages"`
+}
+
 // ThreadListOptions controls thread list filtering.
 type ThreadListOptions struct {
 	Days               int
 	Limit              int
+	All                bool
 	AccountID          string
 	Label              ThreadLabel
 	IncludeLowPriority bool
 	WithParticipants   bool
 	WithStats          bool
+	// Sort defaults to SortActivity. Ties keep activity order.
+	Sort ThreadSort
+	// Type keeps only DMs or only groups; empty means both.
+	Type ThreadType
+	// Participant keeps threads with a participant whose ID equals it or
+	// whose name or nickname contains it, case-insensitively.
+	Participant string
+	// Cache, when set, stands in for aggregating every message and for
+	// bridge lookups of the DMs it has names for.
+	Cache *ThreadCache
+}
+
+// Read marker sources, in the order Store.ReadMarker tries them.
+const (
+	// ReadSourceUnreadCount places the marker before the thread's last
+	// unreadCount incoming messages.
+	ReadSourceUnreadCount = "unreadCount"
+	// ReadSourceLastOpen places it at the last message before the thread
+	// was last opened.
+	ReadSourceLastOpen = "lastOpen"
+	// ReadSourceRead places it at the newest message of a thread that has
+	// neither but is not unread.
+	ReadSourceRead = "read"
+)
+
+// ReadMarker is the newest message the user has read in a thread.
+type ReadMarker struct {
+	MessageID int64     `json:"messageId"`
+	EventID   string    `json:"eventId"`
+	Timestamp time.Time `json:"timestamp"`
+	Source    string    `json:"source"`
+}
+
+// MembershipChange is one join, leave, invite, or removal in a thread.
+type MembershipChange struct {
+	EventID   string    `json:"eventId"`
+	Timestamp time.Time `json:"timestamp"`
+	// Action is one of the Membership* constants.
+	Action   string `json:"action"`
+	UserID   string `json:"userId"`
+	UserName string `json:"userName,omitempty"`
+	// ActorID is who invited, removed, or banned the user; empty when the
+	// user acted themselves.
+	ActorID   string `json:"actorId,omitempty"`
+	ActorName string `json:"actorName,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// Gauges is a point-in-time snapshot of the database for monitoring.
+type Gauges struct {
+	Threads int `json:"threads"`
+	// UnreadThreads, UnreadMessages, and UnreadMentions cover the threads
+	// `threads list --label unread` shows, so low-priority threads are left
+	// out.
+	UnreadThreads  int `json:"unreadThreads"`
+	UnreadMessages int `json:"unreadMessages"`
+	UnreadMentions int `json:"unreadMentions"`
+	// LastHour counts messages per account in the hour before the snapshot.
+	LastHour    []AccountCount `json:"lastHour"`
+	LastMessage time.Time      `json:"lastMessage,omitempty"`
+	// DBSize is the size of index.db and its WAL in bytes.
+	DBSize int64 `json:"dbSize"`
 }
 
 // MessageListOptions controls message list filtering.
 type MessageListOptions struct {
+	ThreadID       string
+	Limit          int
+	All            bool
+	After          *time.Time
+	Before         *time.Time
+	Format         MessageFormat
+	WithProvenance bool
+	// WithEngagement fills Message.ReactionCount and Message.ReplyCount.
+	WithEngagement bool
+	// WithReactions fills Message.Reactions.
+	WithReactions bool
+	// TopEngaged returns only messages with reactions or replies, most
+	// engaged first; it implies WithEngagement.
+	TopEngaged bool
+	// Cursor continues after a previous page (MessagePage.NextCursor).
+	Cursor string
+	// IncludeDeleted also lists deleted messages, with IsDeleted set.
+	IncludeDeleted bool
+	// UnreadOnly keeps messages after the thread's read marker (see
+	// Store.ReadMarker); all of them when nothing is read yet.
+	UnreadOnly bool
+	// ShowEdits folds m.replace edits into the message they edit instead
+	// of listing them as messages of their own.
+	ShowEdits bool
+	// SkipUndecrypted leaves out messages Beeper has not decrypted yet.
+	SkipUndecrypted bool
+	// IncludeExpired also lists disappearing messages the bridge deleted
+	// when they expired, as tombstones.
+	IncludeExpired bool
+	// Type restricts results to a message kind (image, video, audio, file,
+	// location, link) or one message type (TEXT, STICKER, ...).
+	Type string
+	// SenderIDs restricts results to messages from these participant IDs.
+	SenderIDs []string
+	// Include keeps only messages whose text contains one of these terms;
+	// Exclude leaves out messages containing any of its terms. Both match
+	// substrings, case-insensitively for ASCII.
+	Include []string
+	Exclude []string
+}
+
+// MessageCountOptions selects the messages CountMessages counts: those
+// MessageListOptions selects, in every thread when ThreadID is empty.
+type MessageCountOptions struct {
+	MessageListOptions
+	AccountID string
+	// SentByMe counts only messages you sent; Received only the others.
+	SentByMe bool
+	Received bool
+}
+
+// MessagePage is one page of ListMessagesPage.
+type MessagePage struct {
+	Messages []Message `json:"messages"`
+	// NextCursor fetches the following page; empty on the last page.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// Change kinds reported by ChangesSince.
+const (
+	ChangeNew     = "new"
+	ChangeEdited  = "edited"
+	ChangeDeleted = "deleted"
+)
+
+// ChangeOptions controls ChangesSince.
+type ChangeOptions struct {
 	ThreadID string
-	Limit    int
-	After    *time.Time
-	Before   *time.Time
-	Format   MessageFormat
+	// Cursor continues after a previous call (ChangeSet.Cursor); empty
+	// starts from the thread's first message.
+	Cursor string
+	Limit  int
+	Format MessageFormat
+}
+
+// Change is one message added, edited, or deleted in a thread.
+type Change struct {
+	Kind    string `json:"kind"`
+	EventID string `json:"eventId"`
+	// Message is the message as it is now, with the latest text for edits;
+	// nil for deletions.
+	Message *Message `json:"message,omitempty"`
+}
+
+// ChangeSet is one batch of ChangesSince.
+type ChangeSet struct {
+	Changes []Change `json:"changes"`
+	// Cursor fetches the changes after this batch. It is always set: poll
+	// with it later to pick up what changed meanwhile.
+	Cursor string `json:"cursor"`
+	// HasMore reports that more changes are waiting right away.
+	HasMore bool `json:"hasMore"`
 }
 
 // SearchOptions controls full-text search behavior.
 type SearchOptions struct {
-	Query     string
+	Query    string
+	ThreadID string
+	Days     int
+	// After keeps only messages sent at or after this time; with Days, the
+	// later of the two bounds applies.
+	After *time.Time
+	// Before keeps only messages sent at or before this time.
+	Before         *time.Time
+	Limit          int
+	AccountID      string
+	Context        int
+	Window         time.Duration
+	Format         MessageFormat
+	WithProvenance bool
+	// MessageIDs restricts results to these message IDs (e.g. from a sidecar
+	// lookup). With MessageIDs set, Query may be empty.
+	MessageIDs []int64
+	// Type restricts results to a message kind (image, video, audio, file,
+	// location, link) or one message type (TEXT, STICKER, ...).
+	Type string
+	// SenderIDs restricts results to messages from these participant IDs.
+	SenderIDs []string
+	// SentByMe restricts results to messages the user sent.
+	SentByMe bool
+	// Emoji restricts results to messages containing every one of these
+	// emoji. Variation selectors are ignored, and an emoji without a skin
+	// tone also matches its skin-tone variants. With Emoji set, Query may
+	// be empty.
+	Emoji []string
+	// SkipUndecrypted leaves out messages Beeper has not decrypted yet,
+	// both as results and as context.
+	SkipUndecrypted bool
+	// Cursor continues after a previous page (SearchPage.NextCursor).
+	Cursor string
+	// RequireFTS fails with ErrFTSUnavailable instead of falling back to
+	// substring matching when the database has no usable FTS index.
+	RequireFTS bool
+}
+
+// SearchPage is one page of SearchMessagesPage.
+type SearchPage struct {
+	Results []SearchResult `json:"results"`
+	// NextCursor fetches the following page; empty on the last page.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// MentionOptions controls mention lookups.
+type MentionOptions struct {
 	ThreadID  string
-	Days      int
+	AccountID string
+	After     *time.Time
 	Limit     int
+	Format    MessageFormat
+	// UnreadOnly keeps mentions after the thread's read marker (see
+	// Store.ReadMarker), in threads that still report unread mentions.
+	UnreadOnly bool
+}
+
+// NotificationOptions controls notification reconstruction.
+type NotificationOptions struct {
+	Since     time.Time
+	Until     *time.Time
+	AccountID string
+	Limit     int
+	// IncludeLowPriority also notifies for every message in low-priority
+	// threads, not just mentions.
+	IncludeLowPriority bool
+	// MentionsOnly drops plain message notifications.
+	MentionsOnly bool
+	Format       MessageFormat
+}
+
+// Notification is a message that would have notified the user.
+type Notification struct {
+	// Kind is NotificationMessage or NotificationMention.
+	Kind string `json:"kind"`
+	Message
+}
+
+// SampleOptions controls random message sampling.
+type SampleOptions struct {
+	N         int
+	Seed      int64
+	ThreadID  string
 	AccountID string
-	Context   int
-	Window    time.Duration
 	Format    MessageFormat
 }
+
+// StatsOptions bounds analytics queries.
+type StatsOptions struct {
+	After     *time.Time
+	Before    *time.Time
+	AccountID string
+	ThreadID  string
+}
+
+// ScanOptions controls batch iteration over messages in ID order.
+type ScanOptions struct {
+	ThreadID  string
+	AccountID string
+	After     *time.Time
+	Before    *time.Time
+	// AfterID resumes after this message ID.
+	AfterID int64
+	Limit   int
+	Format  MessageFormat
+}
+
+// AttachmentOptions filters ListAttachments.
+type AttachmentOptions struct {
+	ThreadID  string
+	AccountID string
+	After     *time.Time
+	Before    *time.Time
+	// Type restricts results to one message type (IMAGE, VIDEO, AUDIO,
+	// FILE, STICKER).
+	Type string
+	// Limit keeps the N most recent attachments; 0 means all.
+	Limit int
+}
+
+// SessionOptions controls conversation-session segmentation.
+type SessionOptions struct {
+	ThreadID  string
+	AccountID string
+	After     *time.Time
+	Before    *time.Time
+	Gap       time.Duration
+	Limit     int
+	Format    MessageFormat
+}
+
+// Contact is a person aggregated across the rooms and platforms they appear
+// in. Participants sharing a display name are merged into one contact.
+type Contact struct {
+	Name        string          `json:"name"`
+	IDs         []string        `json:"ids"`
+	Platforms   []string        `json:"platforms"`
+	ThreadCount int             `json:"threadCount"`
+	Messages    int             `json:"messages"`
+	LastMessage time.Time       `json:"lastMessage,omitempty"`
+	Threads     []ContactThread `json:"threads,omitempty"`
+}
+
+// ContactThread is a thread a contact participates in.
+type ContactThread struct {
+	ThreadID    string    `json:"threadId"`
+	ThreadName  string    `json:"threadName"`
+	AccountID   string    `json:"accountId,omitempty"`
+	Messages    int       `json:"messages"`
+	LastMessage time.Time `json:"lastMessage,omitempty"`
+}
+
+// RankedContact is a contact with its importance score.
+type RankedContact struct {
+	Contact
+	// Score is the weighted sum of the signals, from 0 to 100.
+	Score   float64        `json:"score"`
+	Signals ContactSignals `json:"signals"`
+}
+
+// ContactSignals are the inputs of a contact's importance score. The
+// normalized signals range from 0 to 1.
+type ContactSignals struct {
+	Recency     float64 `json:"recency"`
+	Frequency   float64 `json:"frequency"`
+	Reciprocity float64 `json:"reciprocity"`
+	Mentions    float64 `json:"mentions"`
+	// Received and Sent count messages within the scoring window; messages
+	// the user sent to a group are split between its members.
+	Received     int       `json:"received"`
+	Sent         float64   `json:"sent"`
+	MentionCount int       `json:"mentionCount"`
+	LastContact  time.Time `json:"lastContact,omitempty"`
+}
+
+// ContactRankOptions controls contact ranking.
+type ContactRankOptions struct {
+	Platform string
+	Limit    int
+	// HalfLife is how quickly old messages stop counting (default 30 days).
+	HalfLife time.Duration
+	// Now is the reference time for recency and decay (default: the time
+	// of the newest message).
+	Now time.Time
+}
+
+// ContactOptions controls contact listing.
+type ContactOptions struct {
+	// Query matches a substring of the name or any ID, case-insensitively.
+	Query    string
+	Platform string
+	Limit    int
+}
+
+// TimelineOptions controls Store.PersonTimeline.
+type TimelineOptions struct {
+	After  *time.Time
+	Before *time.Time
+	// Limit keeps the N most recent messages (default 50); All keeps every
+	// message.
+	Limit int
+	All   bool
+	// DMsOnly leaves out the contact's messages in group threads.
+	DMsOnly bool
+	Format  MessageFormat
+}
+
+// LinkOptions controls Store.ListLinks.
+type LinkOptions struct {
+	ThreadID  string
+	AccountID string
+	After     *time.Time
+	Before    *time.Time
+	// Limit keeps the N most recently shared links (default 50).
+	Limit int
+}
+
+// SharedLink is a URL shared in chats. Message is its most recent share;
+// Shares counts how often it was shared in the searched window.
+type SharedLink struct {
+	URL     string  `json:"url"`
+	Title   string  `json:"title,omitempty"`
+	Shares  int     `json:"shares"`
+	Message Message `json:"message"`
+}
+
+// CallOptions controls Store.ListCalls.
+type CallOptions struct {
+	ThreadID  string
+	AccountID string
+	After     *time.Time
+	Before    *time.Time
+	// Limit keeps the N most recent calls; 0 keeps all.
+	Limit int
+}
+
+// Call is a voice or video call recorded as a message. Message is the call
+// event, sent by the caller.
+type Call struct {
+	Message  Message `json:"message"`
+	Video    bool    `json:"video"`
+	Missed   bool    `json:"missed"`
+	Outgoing bool    `json:"outgoing"`
+	// Duration is zero when the bridge recorded none, e.g. for missed calls.
+	Duration time.Duration `json:"-"`
+	Seconds  int64         `json:"durationSeconds"`
+}
+
+// CallSummary totals the calls of one thread; for DMs, one contact.
+type CallSummary struct {
+	ThreadID   string        `json:"threadId"`
+	ThreadName string        `json:"threadName"`
+	AccountID  string        `json:"accountId,omitempty"`
+	Calls      int           `json:"calls"`
+	Missed     int           `json:"missed"`
+	Video      int           `json:"video"`
+	Outgoing   int           `json:"outgoing"`
+	Duration   time.Duration `json:"-"`
+	Seconds    int64         `json:"durationSeconds"`
+	Last       time.Time     `json:"lastCall"`
+}
+
+// WatchOptions controls live message streaming.
+type WatchOptions struct {
+	ThreadID  string
+	AccountID string
+	// Interval between change checks (DefaultWatchInterval when zero).
+	Interval time.Duration
+	// AfterID streams messages after this ID; zero starts at the newest
+	// message, so only messages arriving later are streamed.
+	AfterID int64
+	Format  MessageFormat
+	// Nice polls at least NiceWatchInterval apart and backs off while
+	// Beeper is writing.
+	Nice bool
+}
@@ -0,0 +1,172 @@
+package beeper
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Notification kinds.
+const (
+	NotificationMessage = "message"
+	NotificationMention = "mention"
+)
+
+// notifyState is what decides whether a thread notifies.
+type notifyState struct {
+	mutedForever bool
+	mutedUntil   time.Time
+	lowPriority  bool
+}
+
+func (n notifyState) mutedAt(t time.Time) bool {
+	return n.mutedForever || n.mutedUntil.After(t)
+}
+
+// ListNotifications reconstructs the notifications the user would have
+// received since opts.Since, newest first: every message from others in a
+// thread that was not muted at the time, and every mention of the user,
+// muted or not. Low-priority threads only notify for mentions unless
+// opts.IncludeLowPriority is set.
+func (s *Store) ListNotifications(ctx context.Context, opts NotificationOptions) ([]Notification, error) {
+	if opts.Since.IsZero() {
+		return nil, errors.New("notification start time is required")
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+
+	states, err := s.notifyStates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	selfByRoom, err := s.selfIdentities(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	query := strings.Builder{}
+	query.WriteString(`SELECT id, eventID, roomID, senderContactID, timestamp, type,
+		COALESCE(text_content, '') AS text_content,
+		COALESCE(message, '') AS message
+		FROM mx_room_messages
+		WHERE isDeleted = 0
+		AND isSentByMe = 0
+		AND type NOT IN ('HIDDEN','REACTION')
+		AND timestamp >= ?`)
+	args := []any{opts.Since.UnixMilli()}
+	if opts.Until != nil {
+		query.WriteString(" AND timestamp <= ?")
+		args = append(args, opts.Until.UnixMilli())
+	}
+	if opts.AccountID != "" {
+		query.WriteString(" AND roomID IN (SELECT threadID FROM threads WHERE accountID = ?)")
+		args = append(args, opts.AccountID)
+	}
+	query.WriteString(" ORDER BY timestamp DESC, id DESC")
+
+	notifications, err := s.scanNotifications(ctx, query.String(), args, opts, states, selfByRoom, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]Message, len(notifications))
+	for i, n := range notifications {
+		messages[i] = n.Message
+	}
+	if err := s.enrichMessages(ctx, messages); err != nil {
+		return nil, err
+	}
+	for i := range notifications {
+		notifications[i].Message = messages[i]
+	}
+	return notifications, nil
+}
+
+// scanNotifications walks the candidate messages and keeps those that
+// would have notified, closing its rows before the caller enriches them.
+func (s *Store) scanNotifications(ctx context.Context, query string, args []any, opts NotificationOptions, states map[string]notifyState, selfByRoom map[string]selfIdentity, limit int) ([]Notification, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	notifications := []Notification{}
+	for rows.Next() && len(notifications) < limit {
+		var msg Message
+		var ts int64
+		var msgType, textContent, rawMessage string
+		if err := rows.Scan(&msg.ID, &msg.EventID, &msg.ThreadID, &msg.SenderID, &ts, &msgType, &textContent, &rawMessage); err != nil {
+			return nil, err
+		}
+		msg.Timestamp = unixMillis(ts)
+		msg.Type = strings.TrimSpace(msgType)
+		plain := ResolveMessageText(rawMessage, msg.Type, textContent, FormatPlain)
+
+		kind := NotificationMessage
+		if self, ok := selfByRoom[msg.ThreadID]; ok && isMention(rawMessage, plain, self) {
+			kind = NotificationMention
+		} else {
+			state := states[msg.ThreadID]
+			if opts.MentionsOnly || state.mutedAt(msg.Timestamp) || (state.lowPriority && !opts.IncludeLowPriority) {
+				continue
+			}
+		}
+		msg.Text = ResolveMessageText(rawMessage, msg.Type, textContent, opts.Format)
+		msg.Undecrypted = isUndecrypted(rawMessage, msg.Type, textContent)
+		msg.ThreadRootEventID, msg.ReplyToEventID = parseRelation(rawMessage)
+		notifications = append(notifications, Notification{Kind: kind, Message: msg})
+	}
+	return notifications, rows.Err()
+}
+
+// notifyStates reads the mute and priority settings of every thread.
+func (s *Store) notifyStates(ctx context.Context) (map[string]notifyState, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT threadID,
+		COALESCE(json_extract(thread,'$.mutedUntil'), json_extract(thread,'$.extra.mutedUntil')),
+		json_extract(thread,'$.isLowPriority')
+		FROM threads`)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	states := map[string]notifyState{}
+	for rows.Next() {
+		var id string
+		var mutedUntil sql.NullString
+		var lowPriority sql.NullInt64
+		if err := rows.Scan(&id, &mutedUntil, &lowPriority); err != nil {
+			return nil, err
+		}
+		state := notifyState{lowPriority: lowPriority.Valid && lowPriority.Int64 != 0}
+		state.mutedForever, state.mutedUntil = parseMutedUntil(mutedUntil.String)
+		states[id] = state
+	}
+	return states, rows.Err()
+}
+
+// parseMutedUntil reads a thread's mutedUntil value: "forever", a date
+// string, or epoch milliseconds.
+func parseMutedUntil(value string) (bool, time.Time) {
+	value = strings.TrimSpace(value)
+	switch strings.ToLower(value) {
+	case "", "null", "0", "false":
+		return false, time.Time{}
+	case "forever", "true", "1":
+		return true, time.Time{}
+	}
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return false, unixMillis(ms)
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return false, t
+	}
+	// An unreadable value still means the thread was muted.
+	return true, time.Time{}
+}
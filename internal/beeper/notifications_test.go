@@ -0,0 +1,97 @@
+package beeper
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestListNotifications(t *testing.T) {
+	path := createTestDB(t, false)
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if _, err := conn.Exec("INSERT INTO participants (account_id, room_id, id, full_name, nickname, is_self) VALUES ('whatsapp', '!room1:beeper.local', '@me:beeper.local', 'Robin', '', 1)"); err != nil {
+		t.Fatalf("insert participant: %v", err)
+	}
+	if _, err := conn.Exec(`UPDATE threads SET thread = json_set(thread, '$.mutedUntil', 'forever') WHERE threadID = '!room1:beeper.local'`); err != nil {
+		t.Fatalf("mute thread: %v", err)
+	}
+	_ = conn.Close()
+	insertMessages(t, path, testMessage{id: 8, roomID: "!room1:beeper.local", eventID: "$evt8", sender: "@alice:beeper.local", ts: 1700000000800, hsOrder: 10, text: "ping",
+		message: `{"text":"ping","m.mentions":{"user_ids":["@me:beeper.local"]}}`})
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	ctx := context.Background()
+	since := time.UnixMilli(1700000000000)
+
+	ids := func(notifications []Notification) []int64 {
+		out := []int64{}
+		for _, n := range notifications {
+			out = append(out, n.ID)
+		}
+		return out
+	}
+
+	notifications, err := store.ListNotifications(ctx, NotificationOptions{Since: since})
+	if err != nil {
+		t.Fatalf("list notifications: %v", err)
+	}
+	// Muted room1 only notifies for the mention, low-priority room3 not at all.
+	if got := ids(notifications); !slices.Equal(got, []int64{8, 6, 4}) {
+		t.Fatalf("unexpected notifications: %v", got)
+	}
+	if notifications[0].Kind != NotificationMention || notifications[1].Kind != NotificationMessage {
+		t.Fatalf("unexpected kinds: %s, %s", notifications[0].Kind, notifications[1].Kind)
+	}
+	if notifications[0].ThreadName != "Team Chat" {
+		t.Fatalf("expected enriched thread name, got %q", notifications[0].ThreadName)
+	}
+
+	notifications, err = store.ListNotifications(ctx, NotificationOptions{Since: since, IncludeLowPriority: true})
+	if err != nil {
+		t.Fatalf("list notifications: %v", err)
+	}
+	if got := ids(notifications); !slices.Equal(got, []int64{8, 6, 5, 4}) {
+		t.Fatalf("unexpected notifications with low priority: %v", got)
+	}
+
+	notifications, err = store.ListNotifications(ctx, NotificationOptions{Since: since, MentionsOnly: true})
+	if err != nil {
+		t.Fatalf("list notifications: %v", err)
+	}
+	if got := ids(notifications); !slices.Equal(got, []int64{8}) {
+		t.Fatalf("unexpected mentions: %v", got)
+	}
+
+	if _, err := store.ListNotifications(ctx, NotificationOptions{}); err == nil {
+		t.Fatalf("expected error without a start time")
+	}
+}
+
+func TestParseMutedUntil(t *testing.T) {
+	cases := []struct {
+		value   string
+		forever bool
+		until   time.Time
+	}{
+		{"", false, time.Time{}},
+		{"forever", true, time.Time{}},
+		{"1700000000000", false, time.UnixMilli(1700000000000)},
+		{"2024-01-02T03:04:05Z", false, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{"garbage", true, time.Time{}},
+	}
+	for _, tc := range cases {
+		forever, until := parseMutedUntil(tc.value)
+		if forever != tc.forever || !until.Equal(tc.until) {
+			t.Fatalf("parseMutedUntil(%q) = %v, %v", tc.value, forever, until)
+		}
+	}
+}
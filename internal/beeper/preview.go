@@ -0,0 +1,107 @@
+package beeper
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultPreviewLength is the length of Message.Preview in runes unless
+// StoreOptions.PreviewLength says otherwise.
+const DefaultPreviewLength = 120
+
+// previewWordSlack is how far Preview backs up to end on a word boundary.
+const previewWordSlack = 15
+
+// previewURLPlaceholder matches attachment placeholders that carry an
+// mxc:// or web URL, which says nothing in a preview.
+var previewURLPlaceholder = regexp.MustCompile(`\[(Audio|Sticker|File): (?:([^\]]*?) - )?(?:mxc|https?)://[^\]\s]*\]`)
+
+// Preview renders text as a one-line preview of at most length runes:
+// whitespace collapsed, attachment URLs dropped from placeholders
+// ("[File: a.pdf - mxc://…]" becomes "[File: a.pdf]"), and, when it has to
+// be shortened, cut at a word boundary if one is near, never inside a
+// leading placeholder or an emoji sequence, and ended with "…". A length
+// of zero or less means DefaultPreviewLength.
+func Preview(text string, length int) string {
+	if length <= 0 {
+		length = DefaultPreviewLength
+	}
+	text = previewURLPlaceholder.ReplaceAllStringFunc(text, func(match string) string {
+		parts := previewURLPlaceholder.FindStringSubmatch(match)
+		switch {
+		case parts[2] != "":
+			return "[" + parts[1] + ": " + parts[2] + "]"
+		case parts[1] == "Audio":
+			return "[Audio message]"
+		default:
+			return "[" + parts[1] + "]"
+		}
+	})
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= length {
+		return text
+	}
+
+	runes := []rune(text)
+	cut := length - 1
+	floor := 0
+	if strings.HasPrefix(text, "[") {
+		if end := strings.IndexRune(text, ']'); end >= 0 {
+			// Keep a leading placeholder whole rather than break it.
+			floor = utf8.RuneCountInString(text[:end]) + 1
+			cut = max(cut, floor)
+		}
+	}
+	if cut >= len(runes) {
+		return text
+	}
+	for i := cut; i > floor && i > cut-previewWordSlack; i-- {
+		if runes[i] == ' ' {
+			cut = i
+			break
+		}
+	}
+	for cut > 0 && splitsCluster(runes, cut) {
+		cut--
+	}
+	return strings.TrimRightFunc(string(runes[:cut]), unicode.IsSpace) + "…"
+}
+
+// setPreviews fills Message.Preview from each message's final text.
+func (s *Store) setPreviews(messages []Message) {
+	for i := range messages {
+		messages[i].Preview = Preview(messages[i].Text, s.previewLength)
+	}
+}
+
+// splitsCluster reports whether cutting runes before index i would split
+// an emoji sequence or a letter from its combining marks.
+func splitsCluster(runes []rune, i int) bool {
+	if i >= len(runes) {
+		return false
+	}
+	if joinsPrevious(runes[i]) || runes[i-1] == '\u200d' {
+		return true
+	}
+	// Flags are pairs of regional indicators.
+	pairs := 0
+	for j := i - 1; j >= 0 && isRegionalIndicator(runes[j]); j-- {
+		pairs++
+	}
+	return pairs%2 == 1 && isRegionalIndicator(runes[i])
+}
+
+// joinsPrevious reports whether r belongs to the character before it:
+// zero-width joiners, variation selectors, skin tones, emoji tag
+// characters, and combining marks.
+func joinsPrevious(r rune) bool {
+	switch {
+	case r == '\u200d', r == '\ufe0e', r == '\ufe0f':
+		return true
+	case isSkinTone(r), r >= 0xE0020 && r <= 0xE007F:
+		return true
+	}
+	return unicode.In(r, unicode.Mn, unicode.Me)
+}
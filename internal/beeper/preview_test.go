@@ -0,0 +1,66 @@
+package beeper
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestPreview(t *testing.T) {
+	for _, tt := range []struct {
+		text   string
+		length int
+		want   string
+	}{
+		{"hello\n\n  world ", 20, "hello world"},
+		{"[File: a.pdf - mxc://beeper.local/abc]", 0, "[File: a.pdf]"},
+		{"[Audio: mxc://beeper.local/voice]", 0, "[Audio message]"},
+		{"[Sticker: https://example.com/s.png]", 0, "[Sticker]"},
+		{"the quick brown fox jumps", 12, "the quick…"},
+		{"ab👍🏽cdef", 4, "ab…"},
+		{"ab🇩🇪cd", 4, "ab…"},
+		{"a👨‍👩‍👧xyz", 4, "a…"},
+		{"[File: a very long file name.pdf] caption text", 10, "[File: a very long file name.pdf]…"},
+		{"[File: long name.pdf]", 5, "[File: long name.pdf]"},
+	} {
+		if got := Preview(tt.text, tt.length); got != tt.want {
+			t.Fatalf("Preview(%q, %d) = %q, want %q", tt.text, tt.length, got, tt.want)
+		}
+	}
+
+	long := Preview(strings.Repeat("a", 200), 0)
+	if utf8.RuneCountInString(long) != DefaultPreviewLength || !strings.HasSuffix(long, "…") {
+		t.Fatalf("expected %d runes ending in an ellipsis, got %q", DefaultPreviewLength, long)
+	}
+}
+
+func TestMessagesCarryPreviews(t *testing.T) {
+	path := createTestDB(t, false)
+	store, err := OpenWithOptions(path, StoreOptions{PreviewLength: 8})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	ctx := context.Background()
+
+	messages, err := store.ListMessages(ctx, MessageListOptions{ThreadID: "!room1:beeper.local", All: true})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	previews := []string{}
+	for _, msg := range messages {
+		previews = append(previews, msg.Preview)
+	}
+	if got := strings.Join(previews, "|"); got != "invoice…|see you|christm…|hello" {
+		t.Fatalf("unexpected previews: %s", got)
+	}
+
+	results, err := store.SearchMessages(ctx, SearchOptions{Query: "christmas", Context: 1})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(results) != 1 || results[0].Match.Preview != "christm…" || results[0].Context[0].Preview != "hello" {
+		t.Fatalf("unexpected search previews: %+v", results)
+	}
+}
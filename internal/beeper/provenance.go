@@ -0,0 +1,65 @@
+package beeper
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+const (
+	// SentViaBeeper marks own messages sent from a Beeper client.
+	SentViaBeeper = "beeper"
+	// SentViaNative marks own messages sent from the platform's native app and
+	// mirrored into Beeper by the bridge (double puppeting).
+	SentViaNative = "native"
+)
+
+// Provenance describes where a message originated.
+type Provenance struct {
+	Platform string `json:"platform,omitempty"`
+	RemoteID string `json:"remoteId,omitempty"`
+	SentVia  string `json:"sentVia,omitempty"`
+}
+
+var (
+	remoteIDKeys     = []string{"remoteID", "remoteId", "remote_id", "com.beeper.remote_id"}
+	doublePuppetKeys = []string{"fi.mau.double_puppet_source", "com.beeper.double_puppet_source"}
+	metadataKeys     = []string{"extra", "metadata", "content"}
+)
+
+// extractProvenance reads remote IDs and double-puppet markers from the raw
+// message payload. Platform is filled in by the caller once the account is known.
+func extractProvenance(rawMessage string, isSentByMe bool) *Provenance {
+	prov := &Provenance{}
+	var payload map[string]any
+	if strings.TrimSpace(rawMessage) != "" {
+		_ = json.Unmarshal([]byte(rawMessage), &payload)
+	}
+
+	prov.RemoteID = lookupPayloadString(payload, remoteIDKeys)
+	if isSentByMe {
+		prov.SentVia = SentViaBeeper
+		if lookupPayloadString(payload, doublePuppetKeys) != "" {
+			prov.SentVia = SentViaNative
+		}
+	}
+	return prov
+}
+
+func lookupPayloadString(payload map[string]any, keys []string) string {
+	if payload == nil {
+		return ""
+	}
+	if value := firstString(payload, keys...); value != "" {
+		return value
+	}
+	for _, nestedKey := range metadataKeys {
+		nested, ok := payload[nestedKey].(map[string]any)
+		if !ok {
+			continue
+		}
+		if value := firstString(nested, keys...); value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,24 @@
+package beeper
+
+import "testing"
+
+func TestExtractProvenance(t *testing.T) {
+	raw := `{"text":"hi","extra":{"remoteID":"wa-123","fi.mau.double_puppet_source":"mautrix-whatsapp"}}`
+	prov := extractProvenance(raw, true)
+	if prov.RemoteID != "wa-123" {
+		t.Fatalf("unexpected remote ID: %q", prov.RemoteID)
+	}
+	if prov.SentVia != SentViaNative {
+		t.Fatalf("expected native, got %q", prov.SentVia)
+	}
+
+	prov = extractProvenance(`{"text":"hi"}`, true)
+	if prov.SentVia != SentViaBeeper || prov.RemoteID != "" {
+		t.Fatalf("unexpected provenance: %+v", prov)
+	}
+
+	prov = extractProvenance(`{"text":"hi","remote_id":"tg-9"}`, false)
+	if prov.SentVia != "" || prov.RemoteID != "tg-9" {
+		t.Fatalf("unexpected provenance: %+v", prov)
+	}
+}
@@ -0,0 +1,171 @@
+package beeper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// QueryResult holds the rows of an ad-hoc SELECT in column order.
+type QueryResult struct {
+	Columns []string `json:"columns"`
+	Rows    [][]any  `json:"rows"`
+	// Truncated is true when more rows matched than the limit allowed.
+	Truncated bool `json:"truncated"`
+}
+
+// ErrNotSelect is returned by ValidateSelect for statements other than a
+// single SELECT.
+var ErrNotSelect = errors.New("only a single SELECT statement is allowed")
+
+// ValidateSelect checks that query is exactly one SELECT statement,
+// optionally preceded by WITH common table expressions. Comments and quoted
+// strings and identifiers are ignored; a trailing semicolon is allowed.
+func ValidateSelect(query string) error {
+	words, statements, err := topLevelWords(query)
+	if err != nil {
+		return err
+	}
+	if statements != 1 || len(words) == 0 {
+		return ErrNotSelect
+	}
+	switch words[0] {
+	case "SELECT":
+		return nil
+	case "WITH":
+		// The main statement is the first depth-0 verb after the CTEs;
+		// everything else at depth 0 is a CTE name, AS, or a modifier.
+		for _, w := range words[1:] {
+			switch w {
+			case "SELECT":
+				return nil
+			case "INSERT", "UPDATE", "DELETE", "REPLACE", "VALUES":
+				return ErrNotSelect
+			}
+		}
+	}
+	return ErrNotSelect
+}
+
+// topLevelWords returns the upper-cased bare words outside parentheses,
+// comments, and quotes, plus the number of non-empty statements.
+func topLevelWords(query string) ([]string, int, error) {
+	words := []string{}
+	statements := 0
+	pending := false
+	depth := 0
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += end + 1
+			}
+			continue
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				return nil, 0, fmt.Errorf("unterminated comment")
+			}
+			i += end + 4
+			continue
+		case c == '\'' || c == '"' || c == '`' || c == '[':
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			end := strings.IndexByte(query[i+1:], closing)
+			if end < 0 {
+				return nil, 0, fmt.Errorf("unterminated quote %c", c)
+			}
+			i += end + 2
+			pending = true
+			continue
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ';':
+			if pending {
+				statements++
+			}
+			pending = false
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(query[i:])
+		if unicode.IsSpace(r) {
+			i += size
+			continue
+		}
+		pending = true
+		if unicode.IsLetter(r) || r == '_' {
+			start := i
+			for i < len(query) {
+				r, size := utf8.DecodeRuneInString(query[i:])
+				if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+					break
+				}
+				i += size
+			}
+			if depth == 0 {
+				words = append(words, strings.ToUpper(query[start:i]))
+			}
+			continue
+		}
+		i += size
+	}
+	if pending {
+		statements++
+	}
+	return words, statements, nil
+}
+
+// Query runs a single SELECT (see ValidateSelect) on the read-only
+// connection and returns at most limit rows; limit <= 0 returns all rows.
+// Text and valid UTF-8 blobs are returned as strings.
+func (s *Store) Query(ctx context.Context, query string, limit int) (QueryResult, error) {
+	result := QueryResult{Rows: [][]any{}}
+	if err := ValidateSelect(query); err != nil {
+		return result, err
+	}
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return result, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	result.Columns, err = rows.Columns()
+	if err != nil {
+		return result, err
+	}
+	for rows.Next() {
+		if limit > 0 && len(result.Rows) == limit {
+			result.Truncated = true
+			break
+		}
+		values := make([]any, len(result.Columns))
+		ptrs := make([]any, len(values))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return result, err
+		}
+		for i, v := range values {
+			if b, ok := v.([]byte); ok && utf8.Valid(b) {
+				values[i] = string(b)
+			}
+		}
+		result.Rows = append(result.Rows, values)
+	}
+	return result, rows.Err()
+}
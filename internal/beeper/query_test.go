@@ -0,0 +1,78 @@
+package beeper
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestValidateSelect(t *testing.T) {
+	allowed := []string{
+		"SELECT 1",
+		"select * from threads;",
+		"  -- comment\n select replace(text, 'a', 'b') from t  ; ",
+		"WITH RECURSIVE x(n) AS (SELECT 1 UNION ALL SELECT n+1 FROM x WHERE n < 3) SELECT n FROM x",
+		"SELECT 'it''s; DELETE FROM threads' AS s",
+		`SELECT "delete" FROM t /* ; drop table t */`,
+	}
+	for _, q := range allowed {
+		if err := ValidateSelect(q); err != nil {
+			t.Errorf("ValidateSelect(%q) = %v", q, err)
+		}
+	}
+	rejected := []string{
+		"",
+		" ; ",
+		"DELETE FROM threads",
+		"SELECT 1; SELECT 2",
+		"SELECT 1; DROP TABLE threads",
+		"WITH x AS (SELECT 1) DELETE FROM threads",
+		"PRAGMA table_info(threads)",
+		"ATTACH DATABASE 'x.db' AS x",
+		"VALUES (1)",
+	}
+	for _, q := range rejected {
+		if err := ValidateSelect(q); !errors.Is(err, ErrNotSelect) {
+			t.Errorf("ValidateSelect(%q) = %v, want ErrNotSelect", q, err)
+		}
+	}
+	if err := ValidateSelect("SELECT 'open"); err == nil {
+		t.Error("expected error for unterminated quote")
+	}
+}
+
+func TestQuery(t *testing.T) {
+	path := createTestDB(t, false)
+	store, err := OpenWithOptions(path, StoreOptions{BridgeLookup: false})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	ctx := context.Background()
+
+	result, err := store.Query(ctx, "SELECT eventID, text_content, NULL AS n FROM mx_room_messages ORDER BY id", 2)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if len(result.Columns) != 3 || result.Columns[2] != "n" {
+		t.Fatalf("unexpected columns: %v", result.Columns)
+	}
+	if len(result.Rows) != 2 || !result.Truncated {
+		t.Fatalf("expected 2 truncated rows, got %d truncated=%t", len(result.Rows), result.Truncated)
+	}
+	if _, ok := result.Rows[0][0].(string); !ok || result.Rows[0][2] != nil {
+		t.Fatalf("unexpected row values: %#v", result.Rows[0])
+	}
+
+	all, err := store.Query(ctx, "SELECT id FROM mx_room_messages", 0)
+	if err != nil {
+		t.Fatalf("query all: %v", err)
+	}
+	if all.Truncated || len(all.Rows) != 7 {
+		t.Fatalf("expected 7 rows, got %d", len(all.Rows))
+	}
+
+	if _, err := store.Query(ctx, "DELETE FROM mx_room_messages", 0); !errors.Is(err, ErrNotSelect) {
+		t.Fatalf("expected ErrNotSelect, got %v", err)
+	}
+}
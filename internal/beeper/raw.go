@@ -0,0 +1,98 @@
+package beeper
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// RawEvent is an unmodified message row including its JSON payload.
+type RawEvent struct {
+	ID          int64           `json:"id"`
+	EventID     string          `json:"eventId"`
+	ThreadID    string          `json:"threadId"`
+	SenderID    string          `json:"senderId"`
+	Timestamp   time.Time       `json:"timestamp"`
+	Type        string          `json:"type"`
+	HSOrder     int64           `json:"hsOrder"`
+	IsSentByMe  bool            `json:"isSentByMe"`
+	IsDeleted   bool            `json:"isDeleted"`
+	TextContent string          `json:"textContent,omitempty"`
+	Payload     json.RawMessage `json:"payload,omitempty"`
+}
+
+// ListRawEvents returns every row of a thread, including hidden, reaction,
+// and deleted rows, oldest first. Limit and Format are ignored.
+func (s *Store) ListRawEvents(ctx context.Context, opts MessageListOptions) ([]RawEvent, error) {
+	if opts.ThreadID == "" {
+		return nil, errors.New("thread ID is required")
+	}
+
+	query := strings.Builder{}
+	query.WriteString(`SELECT id, eventID, roomID, senderContactID, timestamp, type, hsOrder, isSentByMe, isDeleted,
+		COALESCE(text_content, '') AS text_content,
+		COALESCE(message, '') AS message
+		FROM mx_room_messages
+		WHERE roomID = ?`)
+	args := []any{opts.ThreadID}
+	if opts.After != nil {
+		query.WriteString(" AND timestamp >= ?")
+		args = append(args, opts.After.UnixMilli())
+	}
+	if opts.Before != nil {
+		query.WriteString(" AND timestamp <= ?")
+		args = append(args, opts.Before.UnixMilli())
+	}
+	query.WriteString(" ORDER BY timestamp ASC, id ASC")
+
+	rows, err := s.db.QueryContext(ctx, query.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	events := []RawEvent{}
+	for rows.Next() {
+		var ev RawEvent
+		var ts int64
+		var isSentByMe int
+		var isDeleted int
+		var msgType sql.NullString
+		var rawMessage string
+		if err := rows.Scan(
+			&ev.ID,
+			&ev.EventID,
+			&ev.ThreadID,
+			&ev.SenderID,
+			&ts,
+			&msgType,
+			&ev.HSOrder,
+			&isSentByMe,
+			&isDeleted,
+			&ev.TextContent,
+			&rawMessage,
+		); err != nil {
+			return nil, err
+		}
+		ev.Timestamp = unixMillis(ts)
+		ev.Type = strings.TrimSpace(msgType.String)
+		ev.IsSentByMe = isSentByMe != 0
+		ev.IsDeleted = isDeleted != 0
+		if rawMessage != "" {
+			if json.Valid([]byte(rawMessage)) {
+				ev.Payload = json.RawMessage(rawMessage)
+			} else {
+				encoded, err := json.Marshal(rawMessage)
+				if err != nil {
+					return nil, err
+				}
+				ev.Payload = encoded
+			}
+		}
+		events = append(events, ev)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,193 @@
+package beeper
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+)
+
+// ListReactions returns the messages in a thread that received reactions,
+// newest first, each with its reactions and per-emoji tallies.
+func (s *Store) ListReactions(ctx context.Context, opts ReactionOptions) ([]MessageReactions, error) {
+	if strings.TrimSpace(opts.ThreadID) == "" {
+		return nil, errors.New("thread ID is required")
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+
+	byTarget, err := s.reactionsByTarget(ctx, opts.ThreadID, nil)
+	if err != nil {
+		return nil, err
+	}
+	targets := []string{}
+	for eventID, reactions := range byTarget {
+		if opts.Key != "" {
+			reactions = filterReactions(reactions, opts.Key)
+			byTarget[eventID] = reactions
+		}
+		if len(reactions) > 0 {
+			targets = append(targets, eventID)
+		}
+	}
+	if len(targets) == 0 {
+		return []MessageReactions{}, nil
+	}
+
+	ids, _ := json.Marshal(targets)
+	query := strings.Builder{}
+	query.WriteString(`SELECT id, eventID, roomID, senderContactID, timestamp, isSentByMe, type,
+		COALESCE(text_content, '') AS text_content,
+		COALESCE(message, '') AS message
+		FROM mx_room_messages
+		WHERE roomID = ?
+		AND eventID IN (SELECT value FROM json_each(?))
+		AND isDeleted = 0
+		AND type NOT IN ('HIDDEN','REACTION')`)
+	args := []any{opts.ThreadID, string(ids)}
+	if opts.After != nil {
+		query.WriteString(" AND timestamp >= ?")
+		args = append(args, opts.After.UnixMilli())
+	}
+	if opts.Before != nil {
+		query.WriteString(" AND timestamp <= ?")
+		args = append(args, opts.Before.UnixMilli())
+	}
+	query.WriteString(" ORDER BY timestamp DESC LIMIT ?")
+	args = append(args, limit)
+
+	messages, err := s.scanMessages(ctx, query.String(), args, opts.Format)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.enrichMessages(ctx, messages); err != nil {
+		return nil, err
+	}
+
+	result := make([]MessageReactions, 0, len(messages))
+	for _, msg := range messages {
+		reactions := byTarget[msg.EventID]
+		result = append(result, MessageReactions{
+			Message:   msg,
+			Reactions: reactions,
+			Tallies:   TallyReactions(reactions),
+		})
+	}
+	return result, nil
+}
+
+// TallyReactions counts reactions per key, most used first; ties keep the
+// order in which keys were first used.
+func TallyReactions(reactions []Reaction) []ReactionTally {
+	tallies := []ReactionTally{}
+	index := map[string]int{}
+	for _, r := range reactions {
+		i, ok := index[r.Key]
+		if !ok {
+			i = len(tallies)
+			index[r.Key] = i
+			tallies = append(tallies, ReactionTally{Key: r.Key})
+		}
+		tallies[i].Count++
+	}
+	sort.SliceStable(tallies, func(i, j int) bool { return tallies[i].Count > tallies[j].Count })
+	return tallies
+}
+
+// attachReactions fills Message.Reactions for messages in one room.
+func (s *Store) attachReactions(ctx context.Context, roomID string, messages []Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	eventIDs := make([]string, 0, len(messages))
+	for _, msg := range messages {
+		eventIDs = append(eventIDs, msg.EventID)
+	}
+	byTarget, err := s.reactionsByTarget(ctx, roomID, eventIDs)
+	if err != nil {
+		return err
+	}
+	for i := range messages {
+		messages[i].Reactions = byTarget[messages[i].EventID]
+	}
+	return nil
+}
+
+// reactionsByTarget loads the reactions in a room keyed by the event they
+// react to, oldest first. With eventIDs set, only those targets are loaded.
+func (s *Store) reactionsByTarget(ctx context.Context, roomID string, eventIDs []string) (map[string][]Reaction, error) {
+	query := strings.Builder{}
+	query.WriteString(`SELECT eventID, senderContactID, timestamp, isSentByMe, target, key FROM (
+		SELECT eventID, senderContactID, timestamp, isSentByMe,
+			` + reactionTargetExpr + ` AS target,
+			` + reactionKeyExpr + ` AS key
+		FROM mx_room_messages
+		WHERE roomID = ?
+		AND type = 'REACTION'
+		AND isDeleted = 0
+		AND json_valid(message)
+	) WHERE target IS NOT NULL`)
+	args := []any{roomID}
+	if eventIDs != nil {
+		ids, _ := json.Marshal(eventIDs)
+		query.WriteString(" AND target IN (SELECT value FROM json_each(?))")
+		args = append(args, string(ids))
+	}
+	query.WriteString(" ORDER BY timestamp ASC")
+
+	rows, err := s.db.QueryContext(ctx, query.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	byTarget := map[string][]Reaction{}
+	for rows.Next() {
+		var r Reaction
+		var ts int64
+		var isSentByMe int
+		var target string
+		var key sql.NullString
+		if err := rows.Scan(&r.EventID, &r.SenderID, &ts, &isSentByMe, &target, &key); err != nil {
+			return nil, err
+		}
+		r.Timestamp = unixMillis(ts)
+		r.IsSentByMe = isSentByMe != 0
+		r.Key = key.String
+		byTarget[target] = append(byTarget[target], r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(byTarget) == 0 {
+		return byTarget, nil
+	}
+
+	participantsByRoom, err := s.participantsByRoom(ctx, []string{roomID})
+	if err != nil {
+		return nil, err
+	}
+	participantIndex := indexParticipants(participantsByRoom[roomID])
+	for _, reactions := range byTarget {
+		for i := range reactions {
+			if p, ok := participantIndex[reactions[i].SenderID]; ok {
+				reactions[i].SenderName = p.Name
+			}
+		}
+	}
+	return byTarget, nil
+}
+
+func filterReactions(reactions []Reaction, key string) []Reaction {
+	kept := []Reaction{}
+	for _, r := range reactions {
+		if MatchesEmoji(r.Key, key) {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,74 @@
+package beeper
+
+import (
+	"context"
+	"testing"
+)
+
+func TestListReactions(t *testing.T) {
+	path := createTestDB(t, false)
+	insertMessages(t, path,
+		testMessage{roomID: "!room1:beeper.local", eventID: "$r1", sender: "@alice:beeper.local", ts: 1700000000800, typ: "REACTION", hsOrder: 10,
+			message: `{"m.relates_to":{"rel_type":"m.annotation","event_id":"$evt2","key":"👍"}}`},
+		testMessage{roomID: "!room1:beeper.local", eventID: "$r2", sender: "@bob:beeper.local", ts: 1700000000900, typ: "REACTION", hsOrder: 10,
+			message: `{"m.relates_to":{"rel_type":"m.annotation","event_id":"$evt2","key":"🎉"}}`},
+		testMessage{roomID: "!room1:beeper.local", eventID: "$r3", sender: "@carol:beeper.local", ts: 1700000001000, typ: "REACTION", hsOrder: 10,
+			message: `{"m.relates_to":{"rel_type":"m.annotation","event_id":"$evt2","key":"👍"}}`},
+		testMessage{roomID: "!room1:beeper.local", eventID: "$r4", sender: "@bob:beeper.local", ts: 1700000001100, typ: "REACTION", hsOrder: 10,
+			message: `{"linkedMessageID":"$evt7"}`, text: "❤️"},
+	)
+
+	store, err := OpenWithOptions(path, StoreOptions{BridgeLookup: false})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	ctx := context.Background()
+
+	list, err := store.ListReactions(ctx, ReactionOptions{ThreadID: "!room1:beeper.local", Format: FormatPlain})
+	if err != nil {
+		t.Fatalf("list reactions: %v", err)
+	}
+	if len(list) != 2 || list[0].Message.EventID != "$evt7" || list[1].Message.EventID != "$evt2" {
+		t.Fatalf("unexpected targets: %+v", list)
+	}
+	if list[0].Reactions[0].Key != "❤️" {
+		t.Fatalf("expected key from text_content, got %q", list[0].Reactions[0].Key)
+	}
+	party := list[1]
+	if len(party.Reactions) != 3 || party.Reactions[0].SenderName != "Alice" {
+		t.Fatalf("unexpected reactions: %+v", party.Reactions)
+	}
+	if len(party.Tallies) != 2 || party.Tallies[0] != (ReactionTally{Key: "👍", Count: 2}) {
+		t.Fatalf("unexpected tallies: %+v", party.Tallies)
+	}
+
+	filtered, err := store.ListReactions(ctx, ReactionOptions{ThreadID: "!room1:beeper.local", Key: "🎉"})
+	if err != nil {
+		t.Fatalf("list reactions: %v", err)
+	}
+	if len(filtered) != 1 || len(filtered[0].Reactions) != 1 {
+		t.Fatalf("unexpected filtered reactions: %+v", filtered)
+	}
+
+	hearts, err := store.ListReactions(ctx, ReactionOptions{ThreadID: "!room1:beeper.local", Key: "❤"})
+	if err != nil {
+		t.Fatalf("list reactions: %v", err)
+	}
+	if len(hearts) != 1 || hearts[0].Message.EventID != "$evt7" {
+		t.Fatalf("expected the heart without variation selector to match, got %+v", hearts)
+	}
+
+	messages, err := store.ListMessages(ctx, MessageListOptions{ThreadID: "!room1:beeper.local", WithReactions: true})
+	if err != nil {
+		t.Fatalf("list messages: %v", err)
+	}
+	for _, msg := range messages {
+		if msg.EventID == "$evt2" && len(msg.Reactions) != 3 {
+			t.Fatalf("expected 3 reactions on $evt2, got %+v", msg.Reactions)
+		}
+		if msg.EventID == "$evt1" && msg.Reactions != nil {
+			t.Fatalf("expected no reactions on $evt1, got %+v", msg.Reactions)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package beeper
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ReadMarker returns the newest message the user has read in a thread, or
+// nil when nothing is read yet. An unread thread without read state
+// returns ErrNoReadMarker.
+//
+// index.db keeps no receipt table of its own; Beeper applies the read
+// receipt to the thread's unreadCount instead. The marker is therefore the
+// newest message that still leaves unreadCount incoming messages after it
+// (a message sent by the user marks everything before it as read). Threads
+// without an unreadCount fall back to the last message before
+// breadcrumbs.lastOpenTime, and threads with neither that are not unread
+// count as read up to their newest message.
+func (s *Store) ReadMarker(ctx context.Context, threadID string) (*ReadMarker, error) {
+	var unreadCount sql.NullInt64
+	var lastOpen sql.NullInt64
+	var unread bool
+	err := s.db.QueryRowContext(ctx, `SELECT json_extract(t.thread,'$.unreadCount'), b.lastOpenTime,
+		(COALESCE(json_extract(t.thread,'$.isUnread'), 0) != 0 OR COALESCE(json_extract(t.thread,'$.isMarkedUnread'), 0) != 0)
+		FROM threads t
+		LEFT JOIN breadcrumbs b ON b.id = t.threadID
+		WHERE t.threadID = ?`, threadID).Scan(&unreadCount, &lastOpen, &unread)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case unreadCount.Valid:
+		return s.readMarkerByCount(ctx, threadID, int(unreadCount.Int64), ReadSourceUnreadCount)
+	case lastOpen.Valid && lastOpen.Int64 > 0:
+		marker := &ReadMarker{Source: ReadSourceLastOpen}
+		var ts int64
+		err := s.db.QueryRowContext(ctx, `SELECT m.id, m.eventID, m.timestamp
+			FROM mx_room_messages m
+			WHERE m.roomID = ? AND m.type NOT IN ('HIDDEN','REACTION')`+deletedFilter(false, false)+`
+			AND m.timestamp <= ?
+			ORDER BY m.timestamp DESC, m.id DESC LIMIT 1`, threadID, lastOpen.Int64).Scan(&marker.MessageID, &marker.EventID, &ts)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		marker.Timestamp = unixMillis(ts)
+		return marker, nil
+	case !unread:
+		return s.readMarkerByCount(ctx, threadID, 0, ReadSourceRead)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrNoReadMarker, threadID)
+}
+
+// readMarkerByCount walks the thread newest first, skipping unread incoming
+// messages, and returns the first message that is read, marked with source.
+func (s *Store) readMarkerByCount(ctx context.Context, threadID string, unread int, source string) (*ReadMarker, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT m.id, m.eventID, m.timestamp, m.isSentByMe
+		FROM mx_room_messages m
+		WHERE m.roomID = ? AND m.type NOT IN ('HIDDEN','REACTION')`+deletedFilter(false, false)+`
+		ORDER BY m.timestamp DESC, m.id DESC`, threadID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	skipped := 0
+	for rows.Next() {
+		marker := ReadMarker{Source: source}
+		var ts int64
+		var isSentByMe int
+		if err := rows.Scan(&marker.MessageID, &marker.EventID, &ts, &isSentByMe); err != nil {
+			return nil, err
+		}
+		if isSentByMe == 0 && skipped < unread {
+			skipped++
+			continue
+		}
+		marker.Timestamp = unixMillis(ts)
+		return &marker, nil
+	}
+	return nil, rows.Err()
+}
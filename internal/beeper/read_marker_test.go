@@ -0,0 +1,80 @@
+package beeper
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestReadMarker(t *testing.T) {
+	path := createTestDB(t, false)
+	writer, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("open writer: %v", err)
+	}
+	if _, err := writer.Exec("INSERT INTO breadcrumbs (id, lastOpenTime) VALUES ('!room2:beeper.local', 1700000000450)"); err != nil {
+		t.Fatalf("insert breadcrumb: %v", err)
+	}
+	if _, err := writer.Exec(`INSERT INTO threads (threadID, accountID, thread) VALUES ('!room5:beeper.local', 'signal', '{"type":"single","isUnread":1}')`); err != nil {
+		t.Fatalf("insert thread: %v", err)
+	}
+	_ = writer.Close()
+
+	store, err := OpenWithOptions(path, StoreOptions{})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	ctx := context.Background()
+
+	// Team Chat has two unread messages, so $evt3 and $evt7 are unread.
+	marker, err := store.ReadMarker(ctx, "!room1:beeper.local")
+	if err != nil {
+		t.Fatalf("read marker: %v", err)
+	}
+	if marker == nil || marker.EventID != "$evt2" || marker.Source != ReadSourceUnreadCount {
+		t.Fatalf("expected $evt2 from unreadCount, got %+v", marker)
+	}
+	unread, err := store.ListMessages(ctx, MessageListOptions{ThreadID: "!room1:beeper.local", UnreadOnly: true})
+	if err != nil {
+		t.Fatalf("list unread: %v", err)
+	}
+	events := []string{}
+	for _, msg := range unread {
+		events = append(events, msg.EventID)
+	}
+	if !slices.Equal(events, []string{"$evt7", "$evt3"}) {
+		t.Fatalf("expected the two unread messages, got %v", events)
+	}
+
+	marker, err = store.ReadMarker(ctx, "!room2:beeper.local")
+	if err != nil {
+		t.Fatalf("read marker: %v", err)
+	}
+	if marker == nil || marker.EventID != "$evt4" || marker.Source != ReadSourceLastOpen {
+		t.Fatalf("expected $evt4 from lastOpen, got %+v", marker)
+	}
+
+	// The DM has no unreadCount and no breadcrumb but is not unread, so
+	// everything in it is read.
+	marker, err = store.ReadMarker(ctx, "!room4:beeper.local")
+	if err != nil {
+		t.Fatalf("read marker: %v", err)
+	}
+	if marker == nil || marker.EventID != "$evt6" || marker.Source != ReadSourceRead {
+		t.Fatalf("expected $evt6 for a read thread, got %+v", marker)
+	}
+	if unread, err := store.ListMessages(ctx, MessageListOptions{ThreadID: "!room4:beeper.local", UnreadOnly: true}); err != nil || len(unread) != 0 {
+		t.Fatalf("expected no unread messages, got %d, %v", len(unread), err)
+	}
+
+	// An unread thread without read state cannot be filtered.
+	if _, err := store.ReadMarker(ctx, "!room5:beeper.local"); !errors.Is(err, ErrNoReadMarker) {
+		t.Fatalf("expected ErrNoReadMarker, got %v", err)
+	}
+	if _, err := store.ListMessages(ctx, MessageListOptions{ThreadID: "!room5:beeper.local", UnreadOnly: true}); !errors.Is(err, ErrNoReadMarker) {
+		t.Fatalf("expected ErrNoReadMarker from an unread-only listing, got %v", err)
+	}
+}
@@ -0,0 +1,147 @@
+package beeper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// maxThreadSuggestions caps the candidates listed in an AmbiguousThreadError.
+const maxThreadSuggestions = 5
+
+// AmbiguousThreadError is returned by ResolveThread when the best matching
+// tier has more than one thread. Candidates are ordered by last activity.
+type AmbiguousThreadError struct {
+	Query      string
+	Candidates []Thread
+}
+
+func (e *AmbiguousThreadError) Error() string {
+	names := make([]string, 0, min(len(e.Candidates), maxThreadSuggestions))
+	for _, thread := range e.Candidates[:min(len(e.Candidates), maxThreadSuggestions)] {
+		names = append(names, fmt.Sprintf("%q (%s, %s)", thread.DisplayName, thread.AccountID, thread.ID))
+	}
+	more := ""
+	if extra := len(e.Candidates) - len(names); extra > 0 {
+		more = fmt.Sprintf(", and %d more", extra)
+	}
+	return fmt.Sprintf("%q matches %d threads: %s%s", e.Query, len(e.Candidates), strings.Join(names, ", "), more)
+}
+
+// ResolveThread finds the thread a human reference points at. query may be
+// a room ID or a name; names are tried from the strictest match to the
+// loosest, and the first tier with any match decides:
+//
+//  1. room ID, or room alias (#name:server) for queries starting with "#"
+//  2. display name, title, or name (case-insensitive)
+//  3. a name starting with query
+//  4. a name containing query
+//  5. a participant (other than you) whose name equals, then contains, query
+//
+// A room ID that is not in the threads table is returned as is, so
+// messages of rooms Beeper never listed stay reachable.
+func (s *Store) ResolveThread(ctx context.Context, query string) (Thread, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return Thread{}, errors.New("thread is required")
+	}
+	threads, err := s.ListThreads(ctx, ThreadListOptions{All: true, Label: LabelAll, IncludeLowPriority: true, WithParticipants: true})
+	if err != nil {
+		return Thread{}, err
+	}
+	if strings.HasPrefix(query, "#") {
+		// Names such as Slack's "#general" fall through to the name tiers
+		// when no room has the alias.
+		rooms, err := s.roomsByAlias(ctx, query)
+		if err != nil {
+			return Thread{}, err
+		}
+		if len(rooms) > 0 {
+			return resolveAliasRooms(threads, query, rooms)
+		}
+	}
+	return resolveThread(threads, query)
+}
+
+// resolveAliasRooms picks the thread of the rooms with an alias. Like room
+// IDs, a room missing from the threads table is returned as is.
+func resolveAliasRooms(threads []Thread, alias string, rooms []string) (Thread, error) {
+	found := []Thread{}
+	for _, roomID := range rooms {
+		thread := Thread{ID: roomID}
+		if i := slices.IndexFunc(threads, func(t Thread) bool { return t.ID == roomID }); i >= 0 {
+			thread = threads[i]
+		}
+		found = append(found, thread)
+	}
+	if len(found) > 1 {
+		return Thread{}, &AmbiguousThreadError{Query: alias, Candidates: found}
+	}
+	return found[0], nil
+}
+
+func resolveThread(threads []Thread, query string) (Thread, error) {
+	needle := normalizeThreadName(query)
+	tiers := []func(Thread) bool{
+		func(t Thread) bool { return t.ID == query },
+		func(t Thread) bool { return anyThreadName(t, func(name string) bool { return name == needle }) },
+		func(t Thread) bool {
+			return anyThreadName(t, func(name string) bool { return strings.HasPrefix(name, needle) })
+		},
+		func(t Thread) bool {
+			return anyThreadName(t, func(name string) bool { return strings.Contains(name, needle) })
+		},
+		func(t Thread) bool { return anyParticipant(t, func(name string) bool { return name == needle }) },
+		func(t Thread) bool {
+			return anyParticipant(t, func(name string) bool { return strings.Contains(name, needle) })
+		},
+	}
+	for _, matches := range tiers {
+		found := []Thread{}
+		for _, thread := range threads {
+			if matches(thread) {
+				found = append(found, thread)
+			}
+		}
+		switch len(found) {
+		case 0:
+			continue
+		case 1:
+			return found[0], nil
+		default:
+			return Thread{}, &AmbiguousThreadError{Query: query, Candidates: found}
+		}
+	}
+	if strings.HasPrefix(query, "!") {
+		return Thread{ID: query}, nil
+	}
+	return Thread{}, fmt.Errorf("%w: no thread matches %q", ErrThreadNotFound, query)
+}
+
+func anyThreadName(thread Thread, match func(string) bool) bool {
+	for _, name := range []string{thread.DisplayName, thread.Title, thread.Name} {
+		if name = normalizeThreadName(name); name != "" && match(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func anyParticipant(thread Thread, match func(string) bool) bool {
+	for _, p := range thread.Participants {
+		if p.IsSelf {
+			continue
+		}
+		if name := normalizeThreadName(p.Name); name != "" && match(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeThreadName lowercases a name and collapses its whitespace.
+func normalizeThreadName(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
@@ -0,0 +1,74 @@
+package beeper
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestResolveThread(t *testing.T) {
+	threads := []Thread{
+		{ID: "!mom:beeper.local", AccountID: "whatsapp", DisplayName: "Mom", Participants: []Participant{{ID: "@mom", Name: "Maria Vale"}, {ID: "@me", Name: "Robin Vale", IsSelf: true}}},
+		{ID: "!team:beeper.local", AccountID: "slack", DisplayName: "Team Chat"},
+		{ID: "!team2:beeper.local", AccountID: "signal", DisplayName: "Team  Offsite"},
+		{ID: "!momgroup:beeper.local", AccountID: "whatsapp", DisplayName: "Moms & Dads"},
+	}
+
+	cases := []struct {
+		query string
+		want  string
+	}{
+		{"!team:beeper.local", "!team:beeper.local"},
+		{"mom", "!mom:beeper.local"},
+		{"TEAM CHAT", "!team:beeper.local"},
+		{"team offsite", "!team2:beeper.local"},
+		{"dads", "!momgroup:beeper.local"},
+		{"maria", "!mom:beeper.local"},
+		{"!unlisted:beeper.local", "!unlisted:beeper.local"},
+	}
+	for _, tc := range cases {
+		thread, err := resolveThread(threads, tc.query)
+		if err != nil {
+			t.Fatalf("resolve %q: %v", tc.query, err)
+		}
+		if thread.ID != tc.want {
+			t.Fatalf("resolve %q: expected %s, got %s", tc.query, tc.want, thread.ID)
+		}
+	}
+
+	_, err := resolveThread(threads, "team")
+	var ambiguous *AmbiguousThreadError
+	if !errors.As(err, &ambiguous) || len(ambiguous.Candidates) != 2 {
+		t.Fatalf("expected ambiguity between two threads, got %v", err)
+	}
+
+	// Your own name is in every thread and must not match.
+	if _, err := resolveThread(threads, "robin"); !errors.Is(err, ErrThreadNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func TestStoreResolveThread(t *testing.T) {
+	store, err := Open(createTestDB(t, false))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	thread, err := store.ResolveThread(context.Background(), "team")
+	if err != nil {
+		t.Fatalf("resolve thread: %v", err)
+	}
+	if thread.ID != "!room1:beeper.local" || thread.DisplayName != "Team Chat" {
+		t.Fatalf("unexpected thread: %+v", thread)
+	}
+
+	// No thread is named after Alice; she is a participant of Team Chat.
+	thread, err = store.ResolveThread(context.Background(), "alice")
+	if err != nil {
+		t.Fatalf("resolve by participant: %v", err)
+	}
+	if thread.ID != "!room1:beeper.local" {
+		t.Fatalf("expected Team Chat by participant, got %+v", thread)
+	}
+}
@@ -0,0 +1,204 @@
+package beeper
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"strings"
+)
+
+// SampleMessages returns a reproducible uniform sample of messages. It
+// counts the eligible rows first, which reads every one of them. When they
+// fill most of their ID range, it draws random row IDs and keeps the ones
+// that are eligible; otherwise, or when too few draws hit, it reads the
+// eligible IDs and picks among them. Either way it returns N messages when
+// there are at least N. The same seed against the same database yields the
+// same sample.
+func (s *Store) SampleMessages(ctx context.Context, opts SampleOptions) ([]Message, error) {
+	if opts.N <= 0 {
+		return nil, errors.New("sample size must be positive")
+	}
+
+	where := strings.Builder{}
+	where.WriteString(" WHERE isDeleted = 0 AND type NOT IN ('HIDDEN','REACTION')")
+	args := []any{}
+	if opts.ThreadID != "" {
+		where.WriteString(" AND roomID = ?")
+		args = append(args, opts.ThreadID)
+	}
+	if opts.AccountID != "" {
+		where.WriteString(" AND roomID IN (SELECT threadID FROM threads WHERE accountID = ?)")
+		args = append(args, opts.AccountID)
+	}
+
+	var minID, maxID sql.NullInt64
+	var total int
+	row := s.db.QueryRowContext(ctx, "SELECT MIN(id), MAX(id), COUNT(*) FROM mx_room_messages"+where.String(), args...)
+	if err := row.Scan(&minID, &maxID, &total); err != nil {
+		return nil, err
+	}
+	if total == 0 {
+		return []Message{}, nil
+	}
+
+	selectCols := `SELECT id, eventID, roomID, senderContactID, timestamp, isSentByMe, type,
+		COALESCE(text_content, '') AS text_content,
+		COALESCE(message, '') AS message
+		FROM mx_room_messages`
+
+	var messages []Message
+	var err error
+	if total <= opts.N {
+		messages, err = s.scanMessages(ctx, selectCols+where.String()+" ORDER BY id ASC", args, opts.Format)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		rng := rand.New(rand.NewSource(opts.Seed))
+		span := maxID.Int64 - minID.Int64 + 1
+		var ids []int64
+		// Drawing IDs only pays off when most of them hit an eligible row
+		// and the sample is a small part of those rows.
+		if span <= 2*int64(total) && opts.N*2 <= total {
+			ids, err = s.drawSampleIDs(ctx, rng, where.String(), args, minID.Int64, span, opts.N)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if len(ids) < opts.N {
+			ids, err = s.pickSampleIDs(ctx, rng, where.String(), args, opts.N)
+			if err != nil {
+				return nil, err
+			}
+		}
+		list, err := json.Marshal(ids)
+		if err != nil {
+			return nil, err
+		}
+		messages, err = s.scanMessages(ctx, selectCols+" WHERE id IN (SELECT value FROM json_each(?)) ORDER BY id ASC", []any{string(list)}, opts.Format)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.enrichMessages(ctx, messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// drawSampleIDs draws distinct random IDs in [minID, minID+span) and keeps
+// those of eligible rows until it has n, checking each batch of draws in
+// one query. Every eligible row is equally likely to be kept. It gives up
+// with fewer than n IDs after n*20 draws.
+func (s *Store) drawSampleIDs(ctx context.Context, rng *rand.Rand, where string, args []any, minID, span int64, n int) ([]int64, error) {
+	query := "SELECT id FROM mx_room_messages" + where + " AND id IN (SELECT value FROM json_each(?))"
+	drawn := map[int64]struct{}{}
+	ids := []int64{}
+	for attempts := 0; len(ids) < n && attempts < n*20; {
+		batch := []int64{}
+		for want := (n - len(ids)) * 2; len(batch) < want && attempts < n*20; attempts++ {
+			target := minID + rng.Int63n(span)
+			if _, ok := drawn[target]; ok {
+				continue
+			}
+			drawn[target] = struct{}{}
+			batch = append(batch, target)
+		}
+		list, err := json.Marshal(batch)
+		if err != nil {
+			return nil, err
+		}
+		eligible, err := s.queryIDs(ctx, query, append(append([]any{}, args...), string(list)))
+		if err != nil {
+			return nil, err
+		}
+		hit := map[int64]struct{}{}
+		for _, id := range eligible {
+			hit[id] = struct{}{}
+		}
+		// Keep hits in draw order so the sample depends only on the seed.
+		for _, id := range batch {
+			if _, ok := hit[id]; ok && len(ids) < n {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// pickSampleIDs reads the IDs of every eligible row and picks n of them at
+// random.
+func (s *Store) pickSampleIDs(ctx context.Context, rng *rand.Rand, where string, args []any, n int) ([]int64, error) {
+	ids, err := s.queryIDs(ctx, "SELECT id FROM mx_room_messages"+where+" ORDER BY id ASC", args)
+	if err != nil {
+		return nil, err
+	}
+	n = min(n, len(ids))
+	for i := 0; i < n; i++ {
+		j := i + rng.Intn(len(ids)-i)
+		ids[i], ids[j] = ids[j], ids[i]
+	}
+	return ids[:n], nil
+}
+
+// queryIDs runs a query selecting one integer ID column.
+func (s *Store) queryIDs(ctx context.Context, query string, args []any) ([]int64, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// scanMessages runs a query selecting the standard message columns.
+func (s *Store) scanMessages(ctx context.Context, query string, args []any, format MessageFormat) ([]Message, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	messages := []Message{}
+	for rows.Next() {
+		var msg Message
+		var ts int64
+		var isSentByMe int
+		var msgType sql.NullString
+		var textContent sql.NullString
+		var rawMessage sql.NullString
+		if err := rows.Scan(
+			&msg.ID,
+			&msg.EventID,
+			&msg.ThreadID,
+			&msg.SenderID,
+			&ts,
+			&isSentByMe,
+			&msgType,
+			&textContent,
+			&rawMessage,
+		); err != nil {
+			return nil, err
+		}
+		msg.Timestamp = unixMillis(ts)
+		msg.IsSentByMe = isSentByMe != 0
+		msg.Type = strings.TrimSpace(msgType.String)
+		msg.Text = ResolveMessageText(rawMessage.String, msg.Type, textContent.String, format)
+		msg.Undecrypted = isUndecrypted(rawMessage.String, msg.Type, textContent.String)
+		msg.ThreadRootEventID, msg.ReplyToEventID = parseRelation(rawMessage.String)
+		messages = append(messages, msg)
+	}
+	return messages, rows.Err()
+}
@@ -0,0 +1,82 @@
+package beeper
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestSampleMessagesReproducible(t *testing.T) {
+	path := createTestDB(t, false)
+	store, err := OpenWithOptions(path, StoreOptions{BridgeLookup: false})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	ctx := context.Background()
+	first, err := store.SampleMessages(ctx, SampleOptions{N: 3, Seed: 42})
+	if err != nil {
+		t.Fatalf("sample: %v", err)
+	}
+	second, err := store.SampleMessages(ctx, SampleOptions{N: 3, Seed: 42})
+	if err != nil {
+		t.Fatalf("sample: %v", err)
+	}
+	if len(first) != 3 || len(second) != 3 {
+		t.Fatalf("expected 3 messages, got %d and %d", len(first), len(second))
+	}
+	for i := range first {
+		if first[i].ID != second[i].ID {
+			t.Fatalf("samples differ at %d: %d vs %d", i, first[i].ID, second[i].ID)
+		}
+	}
+
+	all, err := store.SampleMessages(ctx, SampleOptions{N: 10, ThreadID: "!room1:beeper.local"})
+	if err != nil {
+		t.Fatalf("sample thread: %v", err)
+	}
+	if len(all) != 4 || all[0].ThreadName != "Team Chat" {
+		t.Fatalf("expected all 4 thread messages, got %d", len(all))
+	}
+}
+
+func TestSampleMessagesFillsSparseThread(t *testing.T) {
+	path := createTestDB(t, false)
+	noise := []testMessage{}
+	for i := 0; i < 200; i++ {
+		noise = append(noise, testMessage{roomID: "!room2:beeper.local", eventID: fmt.Sprintf("$n%d", i), sender: "@bob:beeper.local", ts: int64(1000 + i), text: "noise"})
+	}
+	insertMessages(t, path, noise...)
+	store, err := OpenWithOptions(path, StoreOptions{BridgeLookup: false})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	ctx := context.Background()
+	thread, err := store.SampleMessages(ctx, SampleOptions{N: 3, Seed: 7, ThreadID: "!room1:beeper.local"})
+	if err != nil {
+		t.Fatalf("sample thread: %v", err)
+	}
+	if len(thread) != 3 {
+		t.Fatalf("expected 3 messages, got %d", len(thread))
+	}
+	for _, msg := range thread {
+		if msg.ThreadID != "!room1:beeper.local" {
+			t.Fatalf("sampled message from %s", msg.ThreadID)
+		}
+	}
+
+	all, err := store.SampleMessages(ctx, SampleOptions{N: 50, Seed: 7})
+	if err != nil {
+		t.Fatalf("sample: %v", err)
+	}
+	seen := map[int64]bool{}
+	for _, msg := range all {
+		seen[msg.ID] = true
+	}
+	if len(all) != 50 || len(seen) != 50 {
+		t.Fatalf("expected 50 distinct messages, got %d (%d distinct)", len(all), len(seen))
+	}
+}
@@ -0,0 +1,75 @@
+package beeper
+
+import (
+	"context"
+	"strings"
+)
+
+// ScanMessages returns up to opts.Limit visible messages with IDs greater
+// than opts.AfterID, in ascending ID order. Callers page through the whole
+// corpus by passing the last returned ID as the next AfterID. Messages are
+// not enriched with thread or sender names.
+func (s *Store) ScanMessages(ctx context.Context, opts ScanOptions) ([]Message, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+
+	where := strings.Builder{}
+	where.WriteString(" WHERE isDeleted = 0 AND type NOT IN ('HIDDEN','REACTION') AND id > ?")
+	args := []any{opts.AfterID}
+	if opts.ThreadID != "" {
+		where.WriteString(" AND roomID = ?")
+		args = append(args, opts.ThreadID)
+	}
+	if opts.AccountID != "" {
+		where.WriteString(" AND roomID IN (SELECT threadID FROM threads WHERE accountID = ?)")
+		args = append(args, opts.AccountID)
+	}
+	if opts.After != nil {
+		where.WriteString(" AND timestamp >= ?")
+		args = append(args, opts.After.UnixMilli())
+	}
+	if opts.Before != nil {
+		where.WriteString(" AND timestamp <= ?")
+		args = append(args, opts.Before.UnixMilli())
+	}
+	args = append(args, limit)
+
+	query := `SELECT id, eventID, roomID, senderContactID, timestamp, isSentByMe, type,
+		COALESCE(text_content, '') AS text_content,
+		COALESCE(message, '') AS message
+		FROM mx_room_messages` + where.String() + " ORDER BY id ASC LIMIT ?"
+	return s.scanMessages(ctx, query, args, opts.Format)
+}
+
+// ParticipantNames returns the distinct display names of everyone except
+// the user across all threads.
+func (s *Store) ParticipantNames(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT DISTINCT COALESCE(NULLIF(TRIM(full_name), ''), NULLIF(TRIM(nickname), ''))
+		FROM participants
+		WHERE COALESCE(is_self, 0) = 0
+		AND COALESCE(NULLIF(TRIM(full_name), ''), NULLIF(TRIM(nickname), '')) IS NOT NULL`)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// CountMessagesAfter returns how many visible messages have IDs greater
+// than afterID, i.e. how much a scan resuming there would still process.
+func (s *Store) CountMessagesAfter(ctx context.Context, afterID int64) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM mx_room_messages WHERE isDeleted = 0 AND type NOT IN ('HIDDEN','REACTION') AND id > ?", afterID).Scan(&n)
+	return n, err
+}
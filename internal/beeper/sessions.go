@@ -0,0 +1,273 @@
+package beeper
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultSessionGap is the silence that ends a conversation session.
+const DefaultSessionGap = 30 * time.Minute
+
+const sessionSummaryRunes = 80
+
+// sessionLookupBatch keeps IN (...) lists well below SQLite's variable limit.
+const sessionLookupBatch = 500
+
+// Session is a run of messages in one thread with no gap longer than the
+// segmentation threshold between consecutive messages.
+type Session struct {
+	ID          string    `json:"id"`
+	ThreadID    string    `json:"threadId"`
+	ThreadName  string    `json:"threadName,omitempty"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	Messages    int       `json:"messages"`
+	SentByMe    int       `json:"sentByMe"`
+	StartedByMe bool      `json:"startedByMe"`
+	// Participants lists the other senders, in order of first appearance.
+	Participants []string `json:"participants,omitempty"`
+	FirstEventID string   `json:"firstEventId"`
+	Summary      string   `json:"summary,omitempty"`
+}
+
+// Duration is the time between the first and last message of the session.
+func (s Session) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// SessionStats aggregates a set of sessions.
+type SessionStats struct {
+	Sessions       int     `json:"sessions"`
+	Messages       int     `json:"messages"`
+	AvgMessages    float64 `json:"avgMessages"`
+	MedianMinutes  float64 `json:"medianMinutes"`
+	LongestMinutes float64 `json:"longestMinutes"`
+	StartedByMe    int     `json:"startedByMe"`
+}
+
+// SessionID derives a stable session ID from the thread and the event that
+// opened the session, so IDs survive new messages arriving later.
+func SessionID(threadID, firstEventID string) string {
+	sum := sha256.Sum256([]byte(threadID + "\x00" + firstEventID))
+	return "s-" + hex.EncodeToString(sum[:6])
+}
+
+// SegmentSessions groups chronologically ordered messages into sessions,
+// starting a new one whenever the thread changes or the gap between
+// consecutive messages exceeds gap. It sets SessionID on each message.
+func SegmentSessions(messages []Message, gap time.Duration) []Session {
+	if gap <= 0 {
+		gap = DefaultSessionGap
+	}
+	b := newSessionBuilder(gap)
+	for i := range messages {
+		msg := &messages[i]
+		name := msg.SenderName
+		if name == "" {
+			name = msg.SenderID
+		}
+		session := b.add(msg.ThreadID, msg.EventID, name, msg.Timestamp, msg.IsSentByMe)
+		if session.Messages == 1 {
+			session.Summary = summarizeText(msg.Text)
+			session.ThreadName = msg.ThreadName
+		}
+		msg.SessionID = session.ID
+	}
+	return b.finish()
+}
+
+// ListSessions segments every visible message matching opts into sessions.
+// Stats cover all sessions; the returned sessions are the newest opts.Limit
+// (all when zero). Only returned sessions get summaries and participant
+// names, so large histories stay cheap to scan.
+func (s *Store) ListSessions(ctx context.Context, opts SessionOptions) (SessionStats, []Session, error) {
+	gap := opts.Gap
+	if gap <= 0 {
+		gap = DefaultSessionGap
+	}
+	where, args := statsWhere(StatsOptions{
+		After:     opts.After,
+		Before:    opts.Before,
+		AccountID: opts.AccountID,
+		ThreadID:  opts.ThreadID,
+	})
+	query := `SELECT m.roomID, m.eventID, m.senderContactID, m.timestamp, m.isSentByMe
+		FROM mx_room_messages m` + where + ` ORDER BY m.roomID, m.timestamp, m.id`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return SessionStats{}, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	b := newSessionBuilder(gap)
+	for rows.Next() {
+		var roomID, eventID, senderID string
+		var ts int64
+		var isSentByMe int
+		if err := rows.Scan(&roomID, &eventID, &senderID, &ts, &isSentByMe); err != nil {
+			return SessionStats{}, nil, err
+		}
+		b.add(roomID, eventID, senderID, unixMillis(ts), isSentByMe != 0)
+	}
+	if err := rows.Err(); err != nil {
+		return SessionStats{}, nil, err
+	}
+	_ = rows.Close()
+
+	sessions := b.finish()
+	stats := SummarizeSessions(sessions)
+	sort.SliceStable(sessions, func(i, j int) bool { return sessions[i].Start.After(sessions[j].Start) })
+	if opts.Limit > 0 && len(sessions) > opts.Limit {
+		sessions = sessions[:opts.Limit]
+	}
+	if err := s.describeSessions(ctx, sessions, opts.Format); err != nil {
+		return SessionStats{}, nil, err
+	}
+	return stats, sessions, nil
+}
+
+// SummarizeSessions computes aggregate statistics over sessions.
+func SummarizeSessions(sessions []Session) SessionStats {
+	stats := SessionStats{Sessions: len(sessions)}
+	if len(sessions) == 0 {
+		return stats
+	}
+	durations := make([]time.Duration, 0, len(sessions))
+	for _, session := range sessions {
+		stats.Messages += session.Messages
+		if session.StartedByMe {
+			stats.StartedByMe++
+		}
+		durations = append(durations, session.Duration())
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	mid := len(durations) / 2
+	median := durations[mid]
+	if len(durations)%2 == 0 {
+		median = (durations[mid-1] + durations[mid]) / 2
+	}
+	stats.AvgMessages = float64(stats.Messages) / float64(len(sessions))
+	stats.MedianMinutes = median.Minutes()
+	stats.LongestMinutes = durations[len(durations)-1].Minutes()
+	return stats
+}
+
+// describeSessions fills thread names, participant names, and the summary
+// (the session's opening message) for the given sessions.
+func (s *Store) describeSessions(ctx context.Context, sessions []Session, format MessageFormat) error {
+	if len(sessions) == 0 {
+		return nil
+	}
+	threadIDs := make([]string, 0, len(sessions))
+	eventIDs := make([]string, 0, len(sessions))
+	for _, session := range sessions {
+		threadIDs = append(threadIDs, session.ThreadID)
+		eventIDs = append(eventIDs, session.FirstEventID)
+	}
+	threads, err := s.threadNames(ctx, threadIDs)
+	if err != nil {
+		return err
+	}
+	participants, err := s.participantsByRoom(ctx, threadIDs)
+	if err != nil {
+		return err
+	}
+
+	texts := map[string]string{}
+	eventIDs = uniqueStrings(eventIDs)
+	for start := 0; start < len(eventIDs); start += sessionLookupBatch {
+		end := min(start+sessionLookupBatch, len(eventIDs))
+		batch := eventIDs[start:end]
+		query := `SELECT id, eventID, roomID, senderContactID, timestamp, isSentByMe, type,
+			COALESCE(text_content, '') AS text_content,
+			COALESCE(message, '') AS message
+			FROM mx_room_messages WHERE eventID IN (` + placeholders(len(batch)) + `)`
+		messages, err := s.scanMessages(ctx, query, stringSliceToAny(batch), format)
+		if err != nil {
+			return err
+		}
+		for _, msg := range messages {
+			texts[msg.ThreadID+"\x00"+msg.EventID] = msg.Text
+		}
+	}
+
+	for i := range sessions {
+		session := &sessions[i]
+		if thread, ok := threads[session.ThreadID]; ok {
+			session.ThreadName = thread.DisplayName
+		}
+		index := indexParticipants(participants[session.ThreadID])
+		for j, id := range session.Participants {
+			if p, ok := index[id]; ok {
+				session.Participants[j] = p.Name
+			}
+		}
+		session.Summary = summarizeText(texts[session.ThreadID+"\x00"+session.FirstEventID])
+	}
+	return nil
+}
+
+// sessionBuilder segments a stream of messages ordered by thread and time.
+type sessionBuilder struct {
+	gap      time.Duration
+	sessions []Session
+	senders  map[string]struct{}
+}
+
+func newSessionBuilder(gap time.Duration) *sessionBuilder {
+	return &sessionBuilder{gap: gap}
+}
+
+func (b *sessionBuilder) add(threadID, eventID, sender string, ts time.Time, isSentByMe bool) *Session {
+	var current *Session
+	if n := len(b.sessions); n > 0 {
+		current = &b.sessions[n-1]
+	}
+	if current == nil || current.ThreadID != threadID || ts.Sub(current.End) > b.gap {
+		b.sessions = append(b.sessions, Session{
+			ID:           SessionID(threadID, eventID),
+			ThreadID:     threadID,
+			Start:        ts,
+			End:          ts,
+			StartedByMe:  isSentByMe,
+			FirstEventID: eventID,
+		})
+		current = &b.sessions[len(b.sessions)-1]
+		b.senders = map[string]struct{}{}
+	}
+	current.End = ts
+	current.Messages++
+	if isSentByMe {
+		current.SentByMe++
+	} else if _, ok := b.senders[sender]; !ok && sender != "" {
+		b.senders[sender] = struct{}{}
+		current.Participants = append(current.Participants, sender)
+	}
+	return current
+}
+
+func (b *sessionBuilder) finish() []Session {
+	if b.sessions == nil {
+		return []Session{}
+	}
+	return b.sessions
+}
+
+// summarizeText returns the first line of text, shortened for display.
+func summarizeText(text string) string {
+	text = strings.TrimSpace(text)
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		text = strings.TrimSpace(text[:i])
+	}
+	if utf8.RuneCountInString(text) <= sessionSummaryRunes {
+		return text
+	}
+	runes := []rune(text)
+	return strings.TrimSpace(string(runes[:sessionSummaryRunes-1])) + "…"
+}
@@ -0,0 +1,73 @@
+package beeper
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSegmentSessions(t *testing.T) {
+	base := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	messages := []Message{
+		{ThreadID: "!a", EventID: "$1", SenderID: "@bob", SenderName: "Bob", Timestamp: base, Text: "lunch?\nor later"},
+		{ThreadID: "!a", EventID: "$2", IsSentByMe: true, Timestamp: base.Add(10 * time.Minute)},
+		{ThreadID: "!a", EventID: "$3", SenderID: "@bob", SenderName: "Bob", Timestamp: base.Add(39 * time.Minute)},
+		{ThreadID: "!a", EventID: "$4", IsSentByMe: true, Timestamp: base.Add(2 * time.Hour), Text: "home now"},
+		{ThreadID: "!b", EventID: "$5", SenderID: "@eve", Timestamp: base.Add(2*time.Hour + time.Minute)},
+	}
+
+	sessions := SegmentSessions(messages, 30*time.Minute)
+	if len(sessions) != 3 {
+		t.Fatalf("expected 3 sessions, got %d: %+v", len(sessions), sessions)
+	}
+	first := sessions[0]
+	if first.Messages != 3 || first.SentByMe != 1 || first.StartedByMe || first.Duration() != 39*time.Minute {
+		t.Fatalf("unexpected first session: %+v", first)
+	}
+	if first.Summary != "lunch?" || len(first.Participants) != 1 || first.Participants[0] != "Bob" {
+		t.Fatalf("unexpected summary/participants: %q %v", first.Summary, first.Participants)
+	}
+	if !sessions[1].StartedByMe || sessions[2].ThreadID != "!b" {
+		t.Fatalf("unexpected split: %+v", sessions[1:])
+	}
+	if messages[2].SessionID != first.ID || messages[3].SessionID == first.ID {
+		t.Fatalf("messages not tagged with session IDs")
+	}
+	if first.ID != SessionID("!a", "$1") {
+		t.Fatalf("session ID not derived from first event")
+	}
+
+	stats := SummarizeSessions(sessions)
+	if stats.Sessions != 3 || stats.Messages != 5 || stats.StartedByMe != 1 || stats.LongestMinutes != 39 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestListSessions(t *testing.T) {
+	path := createTestDB(t, false)
+	store, err := OpenWithOptions(path, StoreOptions{BridgeLookup: false})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	stats, sessions, err := store.ListSessions(context.Background(), SessionOptions{Limit: 2})
+	if err != nil {
+		t.Fatalf("list sessions: %v", err)
+	}
+	if stats.Sessions != 4 || stats.Messages != 7 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("expected 2 sessions after limit, got %d", len(sessions))
+	}
+
+	_, sessions, err = store.ListSessions(context.Background(), SessionOptions{ThreadID: "!room1:beeper.local"})
+	if err != nil {
+		t.Fatalf("list thread sessions: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].Messages != 4 || sessions[0].ThreadName != "Team Chat" ||
+		sessions[0].Summary != "hello" || sessions[0].Participants[0] != "Alice" {
+		t.Fatalf("unexpected thread session: %+v", sessions)
+	}
+}
@@ -0,0 +1,224 @@
+package beeper
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// urlPattern finds http(s) URLs and bare www. hosts in message text.
+var urlPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)[^\s<>"'` + "`" + `]+`)
+
+// linkPreviewKeys are the payload keys bridges store link previews under,
+// either a list of previews or a single one.
+var linkPreviewKeys = []string{"com.beeper.linkpreviews", "m.url_previews", "linkPreviews", "link_previews", "linkPreview", "previews"}
+
+// ListLinks returns the URLs shared in messages, newest share first, with
+// duplicates merged. URLs come from the message text and from link preview
+// payloads, which also provide titles.
+func (s *Store) ListLinks(ctx context.Context, opts LinkOptions) ([]SharedLink, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+
+	query := strings.Builder{}
+	query.WriteString(`SELECT m.id, m.eventID, m.roomID, m.senderContactID, m.timestamp, m.isSentByMe, m.type,
+		COALESCE(m.text_content, ''), COALESCE(m.message, '')
+		FROM mx_room_messages m
+		WHERE m.isDeleted = 0
+		AND m.type NOT IN ('HIDDEN','REACTION')
+		AND (` + messageText + ` LIKE '%http%' OR ` + messageText + ` LIKE '%www.%' OR m.message LIKE '%preview%')`)
+	args := []any{}
+	if opts.ThreadID != "" {
+		query.WriteString(" AND m.roomID = ?")
+		args = append(args, opts.ThreadID)
+	}
+	if opts.AccountID != "" {
+		query.WriteString(" AND m.roomID IN (SELECT threadID FROM threads WHERE accountID = ?)")
+		args = append(args, opts.AccountID)
+	}
+	if opts.After != nil {
+		query.WriteString(" AND m.timestamp >= ?")
+		args = append(args, opts.After.UnixMilli())
+	}
+	if opts.Before != nil {
+		query.WriteString(" AND m.timestamp < ?")
+		args = append(args, opts.Before.UnixMilli())
+	}
+	query.WriteString(" ORDER BY m.timestamp DESC, m.id DESC")
+
+	rows, err := s.db.QueryContext(ctx, query.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	links := []SharedLink{}
+	byKey := map[string]int{}
+	for rows.Next() {
+		var msg Message
+		var ts int64
+		var isSentByMe int
+		var msgType sql.NullString
+		var textContent, rawMessage string
+		if err := rows.Scan(&msg.ID, &msg.EventID, &msg.ThreadID, &msg.SenderID, &ts, &isSentByMe, &msgType, &textContent, &rawMessage); err != nil {
+			return nil, err
+		}
+		msg.Type = strings.TrimSpace(msgType.String)
+		if isUndecrypted(rawMessage, msg.Type, textContent) {
+			continue
+		}
+		msg.Timestamp = unixMillis(ts)
+		msg.IsSentByMe = isSentByMe != 0
+		msg.Text = ResolveMessageText(rawMessage, msg.Type, textContent, FormatPlain)
+
+		for _, found := range extractLinks(rawMessage, msg.Text) {
+			key := linkKey(found.URL)
+			if i, ok := byKey[key]; ok {
+				links[i].Shares++
+				if links[i].Title == "" {
+					links[i].Title = found.Title
+				}
+				continue
+			}
+			byKey[key] = len(links)
+			links = append(links, SharedLink{URL: found.URL, Title: found.Title, Shares: 1, Message: msg})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	_ = rows.Close()
+
+	if len(links) > limit {
+		links = links[:limit]
+	}
+	messages := make([]Message, len(links))
+	for i, link := range links {
+		messages[i] = link.Message
+	}
+	if err := s.enrichMessages(ctx, messages); err != nil {
+		return nil, err
+	}
+	for i := range links {
+		links[i].Message = messages[i]
+	}
+	return links, nil
+}
+
+// extractLinks returns the URLs in a message, each once, in the order they
+// appear in text followed by preview-only URLs. Titles come from previews.
+func extractLinks(rawMessage string, text string) []SharedLink {
+	previews := payloadLinkPreviews(rawMessage)
+	titles := map[string]string{}
+	for _, preview := range previews {
+		if key := linkKey(preview.URL); titles[key] == "" {
+			titles[key] = preview.Title
+		}
+	}
+
+	links := []SharedLink{}
+	seen := map[string]bool{}
+	add := func(raw string) {
+		link := normalizeLink(raw)
+		if link == "" {
+			return
+		}
+		key := linkKey(link)
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		links = append(links, SharedLink{URL: link, Title: titles[key]})
+	}
+	for _, match := range urlPattern.FindAllString(text, -1) {
+		add(match)
+	}
+	for _, preview := range previews {
+		add(preview.URL)
+	}
+	return links
+}
+
+// payloadLinkPreviews reads link previews (URL and title) from the raw
+// message payload, at the top level or nested under content/extra.
+func payloadLinkPreviews(rawMessage string) []SharedLink {
+	var payload map[string]any
+	if err := json.Unmarshal([]byte(rawMessage), &payload); err != nil {
+		return nil
+	}
+
+	previews := []SharedLink{}
+	collect := func(m map[string]any) {
+		for _, key := range linkPreviewKeys {
+			var items []any
+			switch v := m[key].(type) {
+			case []any:
+				items = v
+			case map[string]any:
+				items = []any{v}
+			}
+			for _, item := range items {
+				preview, ok := item.(map[string]any)
+				if !ok {
+					continue
+				}
+				link := firstString(preview, "matched_url", "matchedUrl", "og:url", "url", "canonical_url", "canonicalUrl")
+				if link == "" {
+					continue
+				}
+				previews = append(previews, SharedLink{URL: link, Title: firstString(preview, "og:title", "title")})
+			}
+		}
+	}
+	collect(payload)
+	for _, key := range metadataKeys {
+		if nested, ok := payload[key].(map[string]any); ok {
+			collect(nested)
+		}
+	}
+	return previews
+}
+
+// normalizeLink trims punctuation that usually ends the sentence rather
+// than the URL, keeping a closing parenthesis the URL opened, and adds
+// https:// to bare www. hosts. It returns "" for anything not http(s).
+func normalizeLink(raw string) string {
+	link := strings.TrimSpace(raw)
+	for link != "" {
+		last := link[len(link)-1]
+		if strings.IndexByte(".,;:!?'\"]}>*_", last) >= 0 {
+			link = link[:len(link)-1]
+			continue
+		}
+		if last == ')' && strings.Count(link, "(") < strings.Count(link, ")") {
+			link = link[:len(link)-1]
+			continue
+		}
+		break
+	}
+	if len(link) >= 4 && strings.EqualFold(link[:4], "www.") {
+		link = "https://" + link
+	}
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ""
+	}
+	return link
+}
+
+// linkKey identifies a URL for deduplication: scheme and host are
+// case-insensitive, http and https count as the same, and a trailing slash
+// or fragment makes no difference.
+func linkKey(link string) string {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || u.Host == "" {
+		return link
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	return host + strings.TrimSuffix(u.EscapedPath(), "/") + "?" + u.RawQuery
+}
@@ -0,0 +1,50 @@
+package beeper
+
+import (
+	"context"
+	"testing"
+)
+
+func TestExtractLinks(t *testing.T) {
+	raw := `{"text":"see https://Example.com/a/ and (https://en.wikipedia.org/wiki/Go_(language)).","com.beeper.linkpreviews":[{"matched_url":"https://example.com/a","og:title":"Page A"},{"og:url":"https://only.example/preview","title":"Preview only"}]}`
+	links := extractLinks(raw, "see https://Example.com/a/ and (https://en.wikipedia.org/wiki/Go_(language)), or www.golang.org.")
+	want := []SharedLink{
+		{URL: "https://Example.com/a/", Title: "Page A"},
+		{URL: "https://en.wikipedia.org/wiki/Go_(language)"},
+		{URL: "https://www.golang.org"},
+		{URL: "https://only.example/preview", Title: "Preview only"},
+	}
+	if len(links) != len(want) {
+		t.Fatalf("expected %d links, got %+v", len(want), links)
+	}
+	for i := range want {
+		if links[i].URL != want[i].URL || links[i].Title != want[i].Title {
+			t.Fatalf("link %d: expected %+v, got %+v", i, want[i], links[i])
+		}
+	}
+}
+
+func TestListLinks(t *testing.T) {
+	path := createTestDB(t, false)
+	insertMessages(t, path,
+		testMessage{roomID: "!room1:beeper.local", eventID: "$old", sender: "@alice:beeper.local", ts: 1700000000700, hsOrder: 20, text: "read http://example.com/post"},
+		testMessage{roomID: "!room1:beeper.local", eventID: "$new", sender: "@alice:beeper.local", ts: 1700000000800, hsOrder: 20, text: "again: https://www.example.com/post/ and https://go.dev"},
+	)
+
+	store, err := OpenWithOptions(path, StoreOptions{BridgeLookup: false})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	links, err := store.ListLinks(context.Background(), LinkOptions{ThreadID: "!room1:beeper.local"})
+	if err != nil {
+		t.Fatalf("list links: %v", err)
+	}
+	if len(links) != 2 || links[0].URL != "https://www.example.com/post/" || links[0].Shares != 2 || links[1].URL != "https://go.dev" {
+		t.Fatalf("unexpected links: %+v", links)
+	}
+	if links[0].Message.EventID != "$new" || links[0].Message.SenderName != "Alice" || links[0].Message.ThreadName != "Team Chat" {
+		t.Fatalf("expected the newest share with names, got %+v", links[0].Message)
+	}
+}
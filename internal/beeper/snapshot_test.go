@@ -0,0 +1,67 @@
+package beeper
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io/fs"
+	"os"
+	"testing"
+)
+
+func TestOpenSnapshot(t *testing.T) {
+	path := createTestDB(t, false)
+	writer, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("open writer: %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+	writer.SetMaxOpenConns(1)
+	for _, stmt := range []string{"PRAGMA journal_mode=WAL", "PRAGMA wal_autocheckpoint=0"} {
+		if _, err := writer.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	insert := func(eventID string) {
+		t.Helper()
+		if _, err := writer.Exec(`INSERT INTO mx_room_messages (roomID, eventID, senderContactID, timestamp, isDeleted, type, hsOrder, isSentByMe, message, text_content)
+			VALUES ('!room1:beeper.local', ?, '@alice:beeper.local', 1700000000900, 0, 'TEXT', 30, 0, '{"text":"late"}', 'late')`, eventID); err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+	}
+	// Only in the write-ahead log until a checkpoint.
+	insert("$wal")
+
+	store, err := OpenWithOptions(path, StoreOptions{Snapshot: true})
+	if err != nil {
+		t.Fatalf("open snapshot: %v", err)
+	}
+	ctx := context.Background()
+	if _, err := store.GetMessageByEventID(ctx, "$wal", SearchOptions{}); err != nil {
+		t.Fatalf("snapshot should include the WAL: %v", err)
+	}
+	insert("$later")
+	if _, err := store.GetMessageByEventID(ctx, "$later", SearchOptions{}); !errors.Is(err, ErrMessageNotFound) {
+		t.Fatalf("snapshot should not see later writes, got %v", err)
+	}
+	copyPath := store.snapshotPath
+	if err := store.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if _, err := os.Stat(copyPath); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected Close to remove the snapshot copy, stat: %v", err)
+	}
+
+	immutable, err := OpenWithOptions(path, StoreOptions{Immutable: true})
+	if err != nil {
+		t.Fatalf("open immutable: %v", err)
+	}
+	defer func() { _ = immutable.Close() }()
+	if _, err := immutable.GetMessageByEventID(ctx, "$evt1", SearchOptions{}); err != nil {
+		t.Fatalf("immutable read: %v", err)
+	}
+
+	if _, err := OpenWithOptions(path+".missing", StoreOptions{Snapshot: true}); !errors.Is(err, ErrDBNotFound) {
+		t.Fatalf("expected ErrDBNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,563 @@
+package beeper
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const dayLayout = "2006-01-02"
+
+// SenderCount is the number of messages written by one sender.
+type SenderCount struct {
+	SenderID string `json:"senderId"`
+	Name     string `json:"name"`
+	Messages int    `json:"messages"`
+	Threads  int    `json:"threads"`
+}
+
+// ThreadCount is the number of messages in one thread.
+type ThreadCount struct {
+	ThreadID  string `json:"threadId"`
+	Name      string `json:"name"`
+	AccountID string `json:"accountId,omitempty"`
+	Messages  int    `json:"messages"`
+	Sent      int    `json:"sent"`
+	Received  int    `json:"received"`
+}
+
+// AccountCount is the message volume of one account/platform.
+type AccountCount struct {
+	AccountID string `json:"accountId"`
+	Threads   int    `json:"threads"`
+	Messages  int    `json:"messages"`
+	Sent      int    `json:"sent"`
+	Received  int    `json:"received"`
+}
+
+// WeekCount is the number of messages in one ISO week ("2025-W01").
+type WeekCount struct {
+	Week     string `json:"week"`
+	Messages int    `json:"messages"`
+}
+
+// Totals summarizes message volume.
+type Totals struct {
+	Messages int       `json:"messages"`
+	Sent     int       `json:"sent"`
+	Received int       `json:"received"`
+	Threads  int       `json:"threads"`
+	First    time.Time `json:"first,omitempty"`
+	Last     time.Time `json:"last,omitempty"`
+}
+
+// DayCount is the number of messages on one local calendar day.
+type DayCount struct {
+	Day      string `json:"day"`
+	Messages int    `json:"messages"`
+}
+
+// Streak is a run of consecutive local calendar days with messages.
+type Streak struct {
+	ThreadID   string `json:"threadId,omitempty"`
+	ThreadName string `json:"threadName,omitempty"`
+	Days       int    `json:"days"`
+	Start      string `json:"start,omitempty"`
+	End        string `json:"end,omitempty"`
+}
+
+// EmojiCount is how often an emoji was used.
+type EmojiCount struct {
+	Emoji string `json:"emoji"`
+	Count int    `json:"count"`
+}
+
+// TextStats summarizes message volume and text content.
+type TextStats struct {
+	Messages  int          `json:"messages"`
+	Sent      int          `json:"sent"`
+	Received  int          `json:"received"`
+	Words     int          `json:"words"`
+	SentWords int          `json:"sentWords"`
+	TopEmoji  []EmojiCount `json:"topEmoji,omitempty"`
+}
+
+// DefaultReadingWPM is the reading speed, in words per minute, used for
+// reading-time estimates.
+const DefaultReadingWPM = 200
+
+// ReadingStats estimates how long it takes to read a set of messages.
+type ReadingStats struct {
+	Messages       int     `json:"messages"`
+	Words          int     `json:"words"`
+	Characters     int     `json:"characters"`
+	AverageWords   float64 `json:"averageWords"`
+	AverageChars   float64 `json:"averageCharacters"`
+	WordsPerMinute int     `json:"wordsPerMinute"`
+	ReadingMinutes float64 `json:"readingMinutes"`
+}
+
+// statsWhere returns the shared predicates for analytics queries over
+// mx_room_messages aliased as m.
+func statsWhere(opts StatsOptions) (string, []any) {
+	query := strings.Builder{}
+	query.WriteString(" WHERE m.isDeleted = 0 AND m.type NOT IN ('HIDDEN','REACTION')")
+	args := []any{}
+	if opts.ThreadID != "" {
+		query.WriteString(" AND m.roomID = ?")
+		args = append(args, opts.ThreadID)
+	}
+	if opts.AccountID != "" {
+		query.WriteString(" AND m.roomID IN (SELECT threadID FROM threads WHERE accountID = ?)")
+		args = append(args, opts.AccountID)
+	}
+	if opts.After != nil {
+		query.WriteString(" AND m.timestamp >= ?")
+		args = append(args, opts.After.UnixMilli())
+	}
+	if opts.Before != nil {
+		query.WriteString(" AND m.timestamp <= ?")
+		args = append(args, opts.Before.UnixMilli())
+	}
+	return query.String(), args
+}
+
+// TopSenders returns the senders other than the user with the most messages.
+func (s *Store) TopSenders(ctx context.Context, opts StatsOptions, limit int) ([]SenderCount, error) {
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	where, args := statsWhere(opts)
+	query := `SELECT m.senderContactID, COUNT(*) AS c, COUNT(DISTINCT m.roomID) FROM mx_room_messages m` + where +
+		` AND m.isSentByMe = 0 GROUP BY m.senderContactID ORDER BY c DESC, m.senderContactID ASC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	counts := []SenderCount{}
+	ids := []string{}
+	for rows.Next() {
+		var c SenderCount
+		if err := rows.Scan(&c.SenderID, &c.Messages, &c.Threads); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+		ids = append(ids, c.SenderID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	names, err := s.participantNames(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range counts {
+		counts[i].Name = names[counts[i].SenderID]
+		if counts[i].Name == "" {
+			counts[i].Name = counts[i].SenderID
+		}
+	}
+	return counts, nil
+}
+
+// TopThreads returns the threads with the most messages.
+func (s *Store) TopThreads(ctx context.Context, opts StatsOptions, limit int) ([]ThreadCount, error) {
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	where, args := statsWhere(opts)
+	query := `SELECT m.roomID, COUNT(*) AS c, COALESCE(SUM(m.isSentByMe), 0) FROM mx_room_messages m` + where +
+		` GROUP BY m.roomID ORDER BY c DESC, m.roomID ASC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	counts := []ThreadCount{}
+	ids := []string{}
+	for rows.Next() {
+		var c ThreadCount
+		if err := rows.Scan(&c.ThreadID, &c.Messages, &c.Sent); err != nil {
+			return nil, err
+		}
+		c.Received = c.Messages - c.Sent
+		counts = append(counts, c)
+		ids = append(ids, c.ThreadID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	names, err := s.threadNames(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range counts {
+		counts[i].Name = names[counts[i].ThreadID].DisplayName
+		counts[i].AccountID = names[counts[i].ThreadID].AccountID
+	}
+	return counts, nil
+}
+
+// MessageTotals counts messages, sent and received, and threads with
+// messages, plus the first and last message times.
+func (s *Store) MessageTotals(ctx context.Context, opts StatsOptions) (Totals, error) {
+	where, args := statsWhere(opts)
+	var totals Totals
+	var first, last sql.NullInt64
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*), COALESCE(SUM(m.isSentByMe), 0), COUNT(DISTINCT m.roomID),
+		MIN(m.timestamp), MAX(m.timestamp) FROM mx_room_messages m`+where, args...).
+		Scan(&totals.Messages, &totals.Sent, &totals.Threads, &first, &last)
+	if err != nil {
+		return Totals{}, err
+	}
+	totals.Received = totals.Messages - totals.Sent
+	totals.First = unixMillisOrZero(first)
+	totals.Last = unixMillisOrZero(last)
+	return totals, nil
+}
+
+// MessagesPerAccount returns message volume per account, most messages
+// first.
+func (s *Store) MessagesPerAccount(ctx context.Context, opts StatsOptions) ([]AccountCount, error) {
+	where, args := statsWhere(opts)
+	rows, err := s.db.QueryContext(ctx, `SELECT COALESCE(t.accountID, ''), COUNT(DISTINCT m.roomID), COUNT(*), COALESCE(SUM(m.isSentByMe), 0)
+		FROM mx_room_messages m
+		LEFT JOIN threads t ON t.threadID = m.roomID`+where+`
+		GROUP BY COALESCE(t.accountID, '')
+		ORDER BY COUNT(*) DESC, 1 ASC`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	counts := []AccountCount{}
+	for rows.Next() {
+		var c AccountCount
+		if err := rows.Scan(&c.AccountID, &c.Threads, &c.Messages, &c.Sent); err != nil {
+			return nil, err
+		}
+		c.Received = c.Messages - c.Sent
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
+// MessagesPerWeek returns message counts per ISO week of local time, oldest
+// first.
+func (s *Store) MessagesPerWeek(ctx context.Context, opts StatsOptions) ([]WeekCount, error) {
+	days, err := s.MessagesPerDay(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return WeeklyCounts(days), nil
+}
+
+// WeeklyCounts folds day counts (oldest first) into ISO week counts.
+func WeeklyCounts(days []DayCount) []WeekCount {
+	weeks := []WeekCount{}
+	for _, d := range days {
+		t, err := parseDay(d.Day)
+		if err != nil {
+			continue
+		}
+		year, week := t.ISOWeek()
+		label := fmt.Sprintf("%04d-W%02d", year, week)
+		if n := len(weeks); n > 0 && weeks[n-1].Week == label {
+			weeks[n-1].Messages += d.Messages
+			continue
+		}
+		weeks = append(weeks, WeekCount{Week: label, Messages: d.Messages})
+	}
+	return weeks
+}
+
+// MessagesPerDay returns message counts per local calendar day, oldest first.
+func (s *Store) MessagesPerDay(ctx context.Context, opts StatsOptions) ([]DayCount, error) {
+	daysByRoom, err := s.activeDays(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	totals := map[string]int{}
+	for _, days := range daysByRoom {
+		for day, n := range days {
+			totals[day] += n
+		}
+	}
+	counts := make([]DayCount, 0, len(totals))
+	for day, n := range totals {
+		counts = append(counts, DayCount{Day: day, Messages: n})
+	}
+	sort.Slice(counts, func(i, j int) bool { return counts[i].Day < counts[j].Day })
+	return counts, nil
+}
+
+// ThreadStreaks returns the longest consecutive-day streak per thread,
+// longest first.
+func (s *Store) ThreadStreaks(ctx context.Context, opts StatsOptions, limit int) ([]Streak, error) {
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	daysByRoom, err := s.activeDays(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	streaks := make([]Streak, 0, len(daysByRoom))
+	for roomID, days := range daysByRoom {
+		list := make([]string, 0, len(days))
+		for day := range days {
+			list = append(list, day)
+		}
+		streak := LongestStreak(list)
+		streak.ThreadID = roomID
+		streaks = append(streaks, streak)
+	}
+	sort.Slice(streaks, func(i, j int) bool {
+		if streaks[i].Days != streaks[j].Days {
+			return streaks[i].Days > streaks[j].Days
+		}
+		return streaks[i].ThreadID < streaks[j].ThreadID
+	})
+	if len(streaks) > limit {
+		streaks = streaks[:limit]
+	}
+
+	ids := make([]string, 0, len(streaks))
+	for _, streak := range streaks {
+		ids = append(ids, streak.ThreadID)
+	}
+	names, err := s.threadNames(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range streaks {
+		streaks[i].ThreadName = names[streaks[i].ThreadID].DisplayName
+	}
+	return streaks, nil
+}
+
+// TextStats counts messages and words, and ranks the emoji the user sent.
+func (s *Store) TextStats(ctx context.Context, opts StatsOptions, emojiLimit int) (TextStats, error) {
+	where, args := statsWhere(opts)
+	query := `SELECT m.isSentByMe, COALESCE(NULLIF(m.text_content, ''), json_extract(m.message, '$.text'), '')
+		FROM mx_room_messages m` + where
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return TextStats{}, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	stats := TextStats{}
+	emoji := map[string]int{}
+	for rows.Next() {
+		var isSentByMe int
+		var text sql.NullString
+		if err := rows.Scan(&isSentByMe, &text); err != nil {
+			return TextStats{}, err
+		}
+		words := len(strings.Fields(text.String))
+		stats.Messages++
+		stats.Words += words
+		if isSentByMe != 0 {
+			stats.Sent++
+			stats.SentWords += words
+			for _, e := range ExtractEmoji(text.String) {
+				emoji[e]++
+			}
+		} else {
+			stats.Received++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return TextStats{}, err
+	}
+
+	stats.TopEmoji = rankEmoji(emoji, emojiLimit)
+	return stats, nil
+}
+
+// ReadingStats counts the words and characters of the matching messages and
+// estimates their reading time at wpm words per minute (DefaultReadingWPM
+// when wpm is not positive).
+func (s *Store) ReadingStats(ctx context.Context, opts StatsOptions, wpm int) (ReadingStats, error) {
+	if wpm <= 0 {
+		wpm = DefaultReadingWPM
+	}
+	where, args := statsWhere(opts)
+	query := `SELECT COALESCE(NULLIF(m.text_content, ''), json_extract(m.message, '$.text'), '')
+		FROM mx_room_messages m` + where
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return ReadingStats{}, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	stats := ReadingStats{WordsPerMinute: wpm}
+	for rows.Next() {
+		var text sql.NullString
+		if err := rows.Scan(&text); err != nil {
+			return ReadingStats{}, err
+		}
+		stats.Messages++
+		stats.Words += len(strings.Fields(text.String))
+		stats.Characters += utf8.RuneCountInString(strings.TrimSpace(text.String))
+	}
+	if err := rows.Err(); err != nil {
+		return ReadingStats{}, err
+	}
+
+	if stats.Messages > 0 {
+		stats.AverageWords = float64(stats.Words) / float64(stats.Messages)
+		stats.AverageChars = float64(stats.Characters) / float64(stats.Messages)
+	}
+	stats.ReadingMinutes = float64(stats.Words) / float64(wpm)
+	return stats, nil
+}
+
+// LongestStreak finds the longest run of consecutive days in a list of
+// YYYY-MM-DD strings.
+func LongestStreak(days []string) Streak {
+	if len(days) == 0 {
+		return Streak{}
+	}
+	sorted := append([]string(nil), days...)
+	sort.Strings(sorted)
+
+	best := Streak{Days: 1, Start: sorted[0], End: sorted[0]}
+	current := best
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i] == sorted[i-1] {
+			continue
+		}
+		if nextDay(sorted[i-1]) == sorted[i] {
+			current.Days++
+			current.End = sorted[i]
+		} else {
+			current = Streak{Days: 1, Start: sorted[i], End: sorted[i]}
+		}
+		if current.Days > best.Days {
+			best = current
+		}
+	}
+	return best
+}
+
+// activeDays returns per-room message counts keyed by local day.
+func (s *Store) activeDays(ctx context.Context, opts StatsOptions) (map[string]map[string]int, error) {
+	where, args := statsWhere(opts)
+	rows, err := s.db.QueryContext(ctx, `SELECT m.roomID, m.timestamp FROM mx_room_messages m`+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	days := map[string]map[string]int{}
+	for rows.Next() {
+		var roomID string
+		var ts int64
+		if err := rows.Scan(&roomID, &ts); err != nil {
+			return nil, err
+		}
+		day := unixMillis(ts).Local().Format(dayLayout)
+		if days[roomID] == nil {
+			days[roomID] = map[string]int{}
+		}
+		days[roomID][day]++
+	}
+	return days, rows.Err()
+}
+
+// threadNames resolves display names for the given thread IDs.
+func (s *Store) threadNames(ctx context.Context, ids []string) (map[string]Thread, error) {
+	info, err := s.threadInfoByID(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	participantsByRoom, err := s.participantsByRoom(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	s.prefetchBridgeNames(ctx, threadsFromInfo(info))
+	threads := map[string]Thread{}
+	for _, id := range ids {
+		i := info[id]
+		thread := Thread{ID: id, AccountID: i.AccountID, Title: i.Title, Name: i.Name, Type: i.Type}
+		thread.DisplayName = s.displayName(ctx, thread, participantsByRoom[id])
+		threads[id] = thread
+	}
+	return threads, nil
+}
+
+// participantNames resolves participant IDs to their best known name.
+func (s *Store) participantNames(ctx context.Context, ids []string) (map[string]string, error) {
+	names := map[string]string{}
+	ids = uniqueStrings(ids)
+	if len(ids) == 0 {
+		return names, nil
+	}
+	query := fmt.Sprintf(`SELECT id, full_name, nickname FROM participants WHERE id IN (%s)`, placeholders(len(ids)))
+	rows, err := s.db.QueryContext(ctx, query, stringSliceToAny(ids)...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var id string
+		var fullName, nickname sql.NullString
+		if err := rows.Scan(&id, &fullName, &nickname); err != nil {
+			return nil, err
+		}
+		name := strings.TrimSpace(fullName.String)
+		if name == "" {
+			name = strings.TrimSpace(nickname.String)
+		}
+		if name != "" && names[id] == "" {
+			names[id] = name
+		}
+	}
+	return names, rows.Err()
+}
+
+func rankEmoji(counts map[string]int, limit int) []EmojiCount {
+	ranked := make([]EmojiCount, 0, len(counts))
+	for e, n := range counts {
+		ranked = append(ranked, EmojiCount{Emoji: e, Count: n})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Count != ranked[j].Count {
+			return ranked[i].Count > ranked[j].Count
+		}
+		return ranked[i].Emoji < ranked[j].Emoji
+	})
+	if limit > 0 && len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	return ranked
+}
+
+func nextDay(day string) string {
+	t, err := parseDay(day)
+	if err != nil {
+		return ""
+	}
+	return t.AddDate(0, 0, 1).Format(dayLayout)
+}
+
+func parseDay(day string) (time.Time, error) {
+	return time.ParseInLocation(dayLayout, day, time.UTC)
+}
@@ -0,0 +1,133 @@
+package beeper
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLongestStreak(t *testing.T) {
+	streak := LongestStreak([]string{"2024-01-05", "2024-01-01", "2024-01-02", "2024-01-03", "2024-01-03", "2024-02-28", "2024-02-29", "2024-03-01"})
+	if streak.Days != 3 || streak.Start != "2024-01-01" || streak.End != "2024-01-03" {
+		t.Fatalf("unexpected streak: %+v", streak)
+	}
+	if empty := LongestStreak(nil); empty.Days != 0 {
+		t.Fatalf("expected empty streak, got %+v", empty)
+	}
+}
+
+func TestTopThreadsAndTextStats(t *testing.T) {
+	path := createTestDB(t, false)
+	store, err := OpenWithOptions(path, StoreOptions{BridgeLookup: false})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	ctx := context.Background()
+	threads, err := store.TopThreads(ctx, StatsOptions{}, 1)
+	if err != nil {
+		t.Fatalf("top threads: %v", err)
+	}
+	if len(threads) != 1 || threads[0].ThreadID != "!room1:beeper.local" || threads[0].Messages != 4 || threads[0].Name != "Team Chat" {
+		t.Fatalf("unexpected top threads: %+v", threads)
+	}
+
+	senders, err := store.TopSenders(ctx, StatsOptions{AccountID: "whatsapp"}, 5)
+	if err != nil {
+		t.Fatalf("top senders: %v", err)
+	}
+	if len(senders) != 2 || senders[0].Name != "Alice" || senders[0].Messages != 4 {
+		t.Fatalf("unexpected top senders: %+v", senders)
+	}
+
+	stats, err := store.TextStats(ctx, StatsOptions{}, 5)
+	if err != nil {
+		t.Fatalf("text stats: %v", err)
+	}
+	if stats.Messages != 7 || stats.Words != 10 {
+		t.Fatalf("unexpected text stats: %+v", stats)
+	}
+}
+
+func TestReadingStats(t *testing.T) {
+	path := createTestDB(t, false)
+	store, err := OpenWithOptions(path, StoreOptions{BridgeLookup: false})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	ctx := context.Background()
+
+	// hello / christmas party / see you / invoice due
+	stats, err := store.ReadingStats(ctx, StatsOptions{ThreadID: "!room1:beeper.local"}, 7)
+	if err != nil {
+		t.Fatalf("reading stats: %v", err)
+	}
+	if stats.Messages != 4 || stats.Words != 7 || stats.Characters != 38 || stats.AverageWords != 1.75 || stats.AverageChars != 9.5 || stats.ReadingMinutes != 1 {
+		t.Fatalf("unexpected reading stats: %+v", stats)
+	}
+
+	after := time.UnixMilli(1700000000250)
+	windowed, err := store.ReadingStats(ctx, StatsOptions{ThreadID: "!room1:beeper.local", After: &after}, 0)
+	if err != nil {
+		t.Fatalf("reading stats: %v", err)
+	}
+	if windowed.Messages != 2 || windowed.Words != 4 || windowed.WordsPerMinute != DefaultReadingWPM {
+		t.Fatalf("unexpected windowed stats: %+v", windowed)
+	}
+}
+
+func TestMessageTotalsAndPerAccount(t *testing.T) {
+	path := createTestDB(t, false)
+	insertMessages(t, path, testMessage{roomID: "!room1:beeper.local", eventID: "$me1", sender: "@me:beeper.local", ts: 1700000000800, hsOrder: 10, isMe: true, text: "on my way"})
+
+	store, err := OpenWithOptions(path, StoreOptions{BridgeLookup: false})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	ctx := context.Background()
+	totals, err := store.MessageTotals(ctx, StatsOptions{})
+	if err != nil {
+		t.Fatalf("totals: %v", err)
+	}
+	if totals.Messages != 8 || totals.Sent != 1 || totals.Received != 7 || totals.Threads != 4 || totals.Last.UnixMilli() != 1700000000800 {
+		t.Fatalf("unexpected totals: %+v", totals)
+	}
+
+	accounts, err := store.MessagesPerAccount(ctx, StatsOptions{})
+	if err != nil {
+		t.Fatalf("per account: %v", err)
+	}
+	if len(accounts) != 3 || accounts[0].AccountID != "whatsapp" || accounts[0].Messages != 6 || accounts[0].Sent != 1 || accounts[0].Threads != 2 {
+		t.Fatalf("unexpected accounts: %+v", accounts)
+	}
+
+	threads, err := store.TopThreads(ctx, StatsOptions{}, 1)
+	if err != nil {
+		t.Fatalf("top threads: %v", err)
+	}
+	if len(threads) != 1 || threads[0].Sent != 1 || threads[0].Received != 4 {
+		t.Fatalf("unexpected top threads: %+v", threads)
+	}
+}
+
+func TestWeeklyCounts(t *testing.T) {
+	weeks := WeeklyCounts([]DayCount{
+		{Day: "2024-12-29", Messages: 1},
+		{Day: "2024-12-30", Messages: 2},
+		{Day: "2025-01-05", Messages: 3},
+		{Day: "2025-01-06", Messages: 4},
+	})
+	want := []WeekCount{{Week: "2024-W52", Messages: 1}, {Week: "2025-W01", Messages: 5}, {Week: "2025-W02", Messages: 4}}
+	if len(weeks) != len(want) {
+		t.Fatalf("unexpected weeks: %+v", weeks)
+	}
+	for i := range want {
+		if weeks[i] != want[i] {
+			t.Fatalf("unexpected weeks: %+v", weeks)
+		}
+	}
+}
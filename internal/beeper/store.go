@@ -3,62 +3,235 @@ package beeper
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
-	_ "github.com/mattn/go-sqlite3" // sqlite3 driver
+	"github.com/mattn/go-sqlite3"
 )
 
 // Store provides read-only access to Beeper's SQLite database.
+//
+// A Store is safe for concurrent use: one instance can serve many
+// goroutines (serve, mcp, watch). Queries share a pool of up to
+// maxReadConns read-only connections, and the lazily discovered bridge
+// lookup guards its connections and cache with a mutex. Close must not be
+// called while other calls are in flight; calls after Close fail.
 type Store struct {
-	db     *sql.DB
-	bridge *BridgeLookup
+	db   *sql.DB
+	path string
+	// snapshotPath is the temp copy queried with StoreOptions.Snapshot.
+	snapshotPath string
+	// noParticipants skips participant enrichment (StoreOptions.NoNames or
+	// NoParticipants).
+	noParticipants bool
+	// previewLength is StoreOptions.PreviewLength.
+	previewLength int
+
+	// Bridge databases are discovered on first use, so commands that never
+	// resolve a DM name do not scan the Beeper directory.
+	bridgeEnabled bool
+	bridgeRoot    string
+	bridgeOnce    sync.Once
+	bridge        *BridgeLookup
 }
 
+// maxReadConns caps the store's connection pool. SQLite serves readers in
+// parallel, so concurrent requests do not queue behind one connection.
+const maxReadConns = 4
+
 // Open opens a read-only store with bridge lookups enabled.
 func Open(path string) (*Store, error) {
 	return OpenWithOptions(path, StoreOptions{BridgeLookup: true})
 }
 
-// OpenWithOptions opens a read-only store with the provided options.
+// OpenWithOptions opens a read-only store with the provided options. A
+// missing file fails with ErrDBNotFound, and one without Beeper's tables
+// with ErrSchemaUnsupported; databases opened through opts.Connector are
+// not checked.
 func OpenWithOptions(path string, opts StoreOptions) (*Store, error) {
+	if opts.Connector == nil || opts.Snapshot {
+		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%w at %s", ErrDBNotFound, path)
+		}
+	}
+	store := &Store{
+		path:           path,
+		bridgeEnabled:  opts.BridgeLookup && !opts.NoNames,
+		bridgeRoot:     opts.BridgeRoot,
+		noParticipants: opts.NoParticipants || opts.NoNames,
+		previewLength:  opts.PreviewLength,
+	}
 	dsn := fmt.Sprintf("file:%s?mode=ro&_busy_timeout=5000", path)
-	db, err := sql.Open("sqlite3", dsn)
-	if err != nil {
-		return nil, err
+	if opts.Snapshot {
+		copyPath, err := snapshotDB(dsn)
+		if err != nil {
+			return nil, fmt.Errorf("%s: snapshot: %w", path, notADatabase(err))
+		}
+		store.snapshotPath = copyPath
+		dsn = fmt.Sprintf("file:%s?mode=ro&immutable=1", copyPath)
+	} else if opts.Immutable {
+		dsn = fmt.Sprintf("file:%s?mode=ro&immutable=1", path)
+	}
+
+	var db *sql.DB
+	if opts.Connector != nil {
+		db = sql.OpenDB(opts.Connector(dsn))
+	} else {
+		var err error
+		db, err = sql.Open(driverName, dsn)
+		if err != nil {
+			store.removeSnapshot()
+			return nil, err
+		}
 	}
-	db.SetMaxOpenConns(1)
+	store.db = db
+	db.SetMaxOpenConns(maxReadConns)
+	db.SetMaxIdleConns(maxReadConns)
 	if err := db.Ping(); err != nil {
-		return nil, err
+		_ = store.Close()
+		return nil, fmt.Errorf("%s: %w", path, notADatabase(err))
+	}
+	if opts.Connector == nil {
+		if err := checkSchema(db); err != nil {
+			_ = store.Close()
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
 	}
 
-	var bridge *BridgeLookup
-	if opts.BridgeLookup {
-		if b, err := NewBridgeLookup(path, opts.BridgeRoot); err == nil {
-			bridge = b
+	return store, nil
+}
+
+// snapshotDB copies the database behind dsn to a new temp file with VACUUM
+// INTO and returns the copy's path. The copy is one consistent read of the
+// source, so Beeper is only held back while it is written.
+func snapshotDB(dsn string) (string, error) {
+	f, err := os.CreateTemp("", "beeper-snapshot-*.db")
+	if err != nil {
+		return "", err
+	}
+	copyPath := f.Name()
+	// VACUUM INTO accepts an existing file only when it is empty.
+	if err := f.Close(); err != nil {
+		_ = os.Remove(copyPath)
+		return "", err
+	}
+
+	src, err := sql.Open("sqlite3", dsn)
+	if err == nil {
+		_, err = src.Exec("VACUUM INTO ?", copyPath)
+		_ = src.Close()
+	}
+	if err != nil {
+		_ = os.Remove(copyPath)
+		return "", err
+	}
+	return copyPath, nil
+}
+
+// Backup writes a consistent copy of the database, write-ahead log
+// included, to path with VACUUM INTO. path must not exist or be empty.
+func (s *Store) Backup(ctx context.Context, path string) error {
+	_, err := s.db.ExecContext(ctx, "VACUUM INTO ?", path)
+	return err
+}
+
+// removeSnapshot deletes the snapshot copy, if any.
+func (s *Store) removeSnapshot() {
+	if s.snapshotPath != "" {
+		_ = os.Remove(s.snapshotPath)
+		s.snapshotPath = ""
+	}
+}
+
+// checkSchema fails with ErrSchemaUnsupported unless db is a SQLite
+// database with every table in requiredTables.
+func checkSchema(db *sql.DB) error {
+	rows, err := db.Query("SELECT name FROM sqlite_master WHERE type IN ('table','view') AND name IN ("+placeholders(len(requiredTables))+")", stringSliceToAny(requiredTables)...)
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.Code == sqlite3.ErrNotADB {
+		return fmt.Errorf("%w: not a SQLite database", ErrSchemaUnsupported)
+	}
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+	found := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		found[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	missing := []string{}
+	for _, table := range requiredTables {
+		if !found[table] {
+			missing = append(missing, table)
 		}
 	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: missing tables %s", ErrSchemaUnsupported, strings.Join(missing, ", "))
+	}
+	return nil
+}
 
-	return &Store{db: db, bridge: bridge}, nil
+// notADatabase reports SQLite's "file is not a database" as
+// ErrSchemaUnsupported and returns other errors unchanged.
+func notADatabase(err error) error {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.Code == sqlite3.ErrNotADB {
+		return fmt.Errorf("%w: not a SQLite database", ErrSchemaUnsupported)
+	}
+	return err
 }
 
-// Close closes the underlying database connection.
+// Close closes the underlying database connection and removes the
+// snapshot copy, if any.
 func (s *Store) Close() error {
 	if s == nil || s.db == nil {
 		return nil
 	}
-	return s.db.Close()
+	// Marks discovery done so a lookup racing Close cannot start one.
+	s.bridgeOnce.Do(func() {})
+	_ = s.bridge.Close()
+	err := s.db.Close()
+	s.removeSnapshot()
+	return err
+}
+
+// bridgeLookup discovers the bridge databases next to the index on first
+// call. It returns nil when bridge lookups are disabled or none can be
+// read.
+func (s *Store) bridgeLookup() *BridgeLookup {
+	s.bridgeOnce.Do(func() {
+		if !s.bridgeEnabled {
+			return
+		}
+		if b, err := NewBridgeLookup(s.path, s.bridgeRoot); err == nil {
+			s.bridge = b
+		}
+	})
+	return s.bridge
 }
 
 // BridgeDBs returns discovered platform bridge database paths.
 func (s *Store) BridgeDBs() []string {
-	if s == nil || s.bridge == nil {
+	if s == nil {
 		return nil
 	}
-	return s.bridge.Paths()
+	return s.bridgeLookup().Paths()
 }
 
 // HasFTS reports whether the FTS table exists.
@@ -80,13 +253,106 @@ func (s *Store) ListThreads(ctx context.Context, opts ThreadListOptions) ([]Thre
 	if limit <= 0 {
 		limit = defaultLimit
 	}
+	if opts.All {
+		limit = -1
+	}
+	threads, err := s.queryThreads(ctx, opts, limit)
+	if err != nil {
+		return nil, err
+	}
+	threadIDs := make([]string, 0, len(threads))
+	for _, thread := range threads {
+		threadIDs = append(threadIDs, thread.ID)
+	}
+
+	if opts.Sort == SortImportance {
+		ranked, err := s.rankContacts(ctx, ContactRankOptions{})
+		if err != nil {
+			return nil, err
+		}
+		scores := threadImportance(ranked)
+		for i := range threads {
+			threads[i].Importance = scores[threads[i].ID]
+		}
+		sort.SliceStable(threads, func(i, j int) bool {
+			return threads[i].Importance > threads[j].Importance
+		})
+		if limit >= 0 && len(threads) > limit {
+			threads = threads[:limit]
+		}
+		threadIDs = threadIDs[:0]
+		for _, t := range threads {
+			threadIDs = append(threadIDs, t.ID)
+		}
+	}
+
+	participantsByRoom, err := s.participantsByRoom(ctx, threadIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	bridgeNames := map[string]string{}
+	if s.bridgeEnabled {
+		bridgeNames = opts.Cache.bridgeNames()
+	}
+	uncached := slices.DeleteFunc(slices.Clone(threads), func(t Thread) bool { return bridgeNames[t.ID] != "" })
+	s.prefetchBridgeNames(ctx, uncached)
+	for i := range threads {
+		threadParticipants := participantsByRoom[threads[i].ID]
+		if name := bridgeNames[threads[i].ID]; name != "" && threads[i].Title == "" && threads[i].Name == "" {
+			threads[i].DisplayName = name
+		} else {
+			threads[i].DisplayName = s.displayName(ctx, threads[i], threadParticipants)
+		}
+		if opts.WithParticipants {
+			threads[i].Participants = threadParticipants
+		}
+	}
+
+	if opts.Sort == SortName {
+		sort.SliceStable(threads, func(i, j int) bool {
+			return strings.ToLower(threads[i].DisplayName) < strings.ToLower(threads[j].DisplayName)
+		})
+		if limit >= 0 && len(threads) > limit {
+			threads = threads[:limit]
+		}
+	}
+
+	return threads, nil
+}
+
+// queryThreads loads the threads opts selects, in the order it asks for,
+// with their label filter applied but without participants or names.
+func (s *Store) queryThreads(ctx context.Context, opts ThreadListOptions, limit int) ([]Thread, error) {
 	label := opts.Label
 	if label == "" {
 		label = LabelAll
 	}
 
+	// Per-thread message stats come from one grouped pass over
+	// mx_room_messages instead of three correlated subqueries per thread,
+	// or from the cache plus the rows added since it was built.
 	query := strings.Builder{}
-	query.WriteString(`SELECT t.threadID, t.accountID, t.timestamp,
+	args := []any{}
+	if opts.Cache != nil {
+		cacheArgs, err := opts.Cache.roomStatsArgs()
+		if err != nil {
+			return nil, err
+		}
+		query.WriteString(cachedRoomStats)
+		args = append(args, cacheArgs...)
+	} else {
+		query.WriteString(`WITH room_stats AS (
+			SELECT roomID,
+				MAX(CASE WHEN type NOT IN ('HIDDEN','REACTION') THEN timestamp END) AS lastMessageTime,
+				MAX(CASE WHEN type != 'HIDDEN' THEN hsOrder END) AS latestHsOrder,
+				SUM(type NOT IN ('HIDDEN','REACTION')) AS totalMessages
+			FROM mx_room_messages
+			GROUP BY roomID
+		)`)
+	}
+	query.WriteString(`
+		SELECT t.threadID, t.accountID, t.timestamp,
 		json_extract(t.thread,'$.title') AS title,
 		json_extract(t.thread,'$.name') AS name,
 		json_extract(t.thread,'$.type') AS type,
@@ -99,14 +365,23 @@ func (s *Store) ListThreads(ctx context.Context, opts ThreadListOptions) ([]Thre
 		json_extract(t.thread,'$.extra.isArchivedUpToOrder') AS isArchivedUpToOrder,
 		json_extract(t.thread,'$.extra.tags') AS tags,
 		b.lastOpenTime AS lastOpenTime,
-		(SELECT MAX(timestamp) FROM mx_room_messages WHERE roomID = t.threadID AND type NOT IN ('HIDDEN','REACTION')) AS lastMessageTime,
-		(SELECT MAX(hsOrder) FROM mx_room_messages WHERE roomID = t.threadID AND type != 'HIDDEN') AS latestHsOrder,
-		(SELECT COUNT(*) FROM mx_room_messages WHERE roomID = t.threadID AND type NOT IN ('HIDDEN','REACTION')) AS totalMessages
+		rs.lastMessageTime AS lastMessageTime,
+		rs.latestHsOrder AS latestHsOrder,
+		COALESCE(rs.totalMessages, 0) AS totalMessages
 		FROM threads t
-		LEFT JOIN breadcrumbs b ON t.threadID = b.id`)
+		LEFT JOIN breadcrumbs b ON t.threadID = b.id
+		LEFT JOIN room_stats rs ON rs.roomID = t.threadID`)
 
 	conds := []string{}
-	args := []any{}
+
+	if participant := strings.TrimSpace(opts.Participant); participant != "" {
+		query.WriteString(`
+		JOIN (SELECT DISTINCT room_id FROM participants
+			WHERE id = ? OR full_name LIKE ? ESCAPE '\' OR nickname LIKE ? ESCAPE '\'
+		) p ON p.room_id = t.threadID`)
+		pattern := "%" + likeEscaper.Replace(participant) + "%"
+		args = append(args, participant, pattern, pattern)
+	}
 
 	if opts.AccountID != "" {
 		conds = append(conds, "t.accountID = ?")
@@ -119,13 +394,35 @@ func (s *Store) ListThreads(ctx context.Context, opts ThreadListOptions) ([]Thre
 		args = append(args, cutoff)
 	}
 
+	switch opts.Type {
+	case ThreadTypeDM:
+		conds = append(conds, "type IN ('single','dm')")
+	case ThreadTypeGroup:
+		conds = append(conds, "COALESCE(type, '') NOT IN ('single','dm')")
+	}
+
 	if len(conds) > 0 {
 		query.WriteString(" WHERE ")
 		query.WriteString(strings.Join(conds, " AND "))
 	}
 
-	query.WriteString(" ORDER BY COALESCE(lastMessageTime, lastOpenTime, t.timestamp) DESC LIMIT ?")
-	args = append(args, limit)
+	activity := "COALESCE(lastMessageTime, lastOpenTime, t.timestamp) DESC"
+	switch opts.Sort {
+	case SortUnread:
+		query.WriteString(" ORDER BY COALESCE(unreadCount, 0) DESC, " + activity)
+	case SortMessages:
+		query.WriteString(" ORDER BY totalMessages DESC, " + activity)
+	default:
+		query.WriteString(" ORDER BY " + activity)
+	}
+	query.WriteString(" LIMIT ?")
+	if opts.Sort == SortImportance || opts.Sort == SortName {
+		// Every matching thread is a candidate; the limit applies after
+		// ranking or naming.
+		args = append(args, -1)
+	} else {
+		args = append(args, limit)
+	}
 
 	rows, err := s.db.QueryContext(ctx, query.String(), args...)
 	if err != nil {
@@ -134,7 +431,6 @@ func (s *Store) ListThreads(ctx context.Context, opts ThreadListOptions) ([]Thre
 	defer func() { _ = rows.Close() }()
 
 	threads := []Thread{}
-	threadIDs := []string{}
 
 	for rows.Next() {
 		var thread Thread
@@ -205,11 +501,12 @@ func (s *Store) ListThreads(ctx context.Context, opts ThreadListOptions) ([]Thre
 			lastMessage,
 		)
 		thread.IsArchived = archived
-		if opts.WithStats {
+		if opts.WithStats || opts.Sort == SortMessages {
 			if totalMessages.Valid {
 				thread.TotalMessages = int(totalMessages.Int64)
 			}
-		} else {
+		}
+		if !opts.WithStats {
 			thread.LastMessage = time.Time{}
 			thread.LastOpen = time.Time{}
 		}
@@ -219,26 +516,11 @@ func (s *Store) ListThreads(ctx context.Context, opts ThreadListOptions) ([]Thre
 		}
 
 		threads = append(threads, thread)
-		threadIDs = append(threadIDs, thread.ID)
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
-
-	participantsByRoom, err := s.participantsByRoom(ctx, threadIDs)
-	if err != nil {
-		return nil, err
-	}
-
-	for i := range threads {
-		threadParticipants := participantsByRoom[threads[i].ID]
-		threads[i].DisplayName = s.displayName(ctx, threads[i], threadParticipants)
-		if opts.WithParticipants {
-			threads[i].Participants = threadParticipants
-		}
-	}
-
 	return threads, nil
 }
 
@@ -259,7 +541,9 @@ func (s *Store) GetThread(ctx context.Context, threadID string, withStats bool)
 		b.lastOpenTime AS lastOpenTime,
 		(SELECT MAX(timestamp) FROM mx_room_messages WHERE roomID = t.threadID AND type NOT IN ('HIDDEN','REACTION')) AS lastMessageTime,
 		(SELECT MAX(hsOrder) FROM mx_room_messages WHERE roomID = t.threadID AND type != 'HIDDEN') AS latestHsOrder,
-		(SELECT COUNT(*) FROM mx_room_messages WHERE roomID = t.threadID AND type NOT IN ('HIDDEN','REACTION')) AS totalMessages
+		(SELECT COUNT(*) FROM mx_room_messages WHERE roomID = t.threadID AND type NOT IN ('HIDDEN','REACTION')) AS totalMessages,
+		(SELECT COUNT(*) FROM mx_room_messages m WHERE m.roomID = t.threadID AND m.isDeleted = 0
+			AND m.type NOT IN ('HIDDEN','REACTION') AND ` + undecryptedCondition + `) AS undecrypted
 		FROM threads t
 		LEFT JOIN breadcrumbs b ON t.threadID = b.id
 		WHERE t.threadID = ? LIMIT 1`
@@ -281,6 +565,7 @@ func (s *Store) GetThread(ctx context.Context, threadID string, withStats bool)
 	var lastMessage sql.NullInt64
 	var latestHsOrder sql.NullInt64
 	var totalMessages sql.NullInt64
+	var undecrypted int
 	var ts int64
 
 	row := s.db.QueryRowContext(ctx, query, threadID)
@@ -303,7 +588,11 @@ func (s *Store) GetThread(ctx context.Context, threadID string, withStats bool)
 		&lastMessage,
 		&latestHsOrder,
 		&totalMessages,
+		&undecrypted,
 	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Thread{}, fmt.Errorf("%w: %s", ErrThreadNotFound, threadID)
+		}
 		return Thread{}, err
 	}
 
@@ -321,6 +610,7 @@ func (s *Store) GetThread(ctx context.Context, threadID string, withStats bool)
 		thread.UnreadMentions = int(unreadMentions.Int64)
 	}
 	thread.Tags = parseTags(tagsRaw.String)
+	thread.Undecrypted = undecrypted
 	thread.LastOpen = unixMillisOrZero(lastOpen)
 	thread.LastMessage = unixMillisOrZero(lastMessage)
 	thread.LastActivity = maxTime(thread.LastMessage, thread.LastOpen, unixMillis(ts))
@@ -339,7 +629,15 @@ func (s *Store) GetThread(ctx context.Context, threadID string, withStats bool)
 		return Thread{}, err
 	}
 	thread.Participants = participantsByRoom[threadID]
-	thread.DisplayName = s.displayName(ctx, thread, thread.Participants)
+	thread.DisplayName, thread.NameSource = s.resolveDisplayName(ctx, thread, thread.Participants)
+	if thread.CanonicalAlias, thread.Aliases, err = s.roomAliases(ctx, threadID); err != nil {
+		return Thread{}, err
+	}
+	if bridge := s.bridgeLookup(); bridge != nil && (thread.Type == "single" || thread.Type == "dm") {
+		if contact, ok, err := bridge.LookupDMContact(ctx, thread.ID, thread.AccountID); err == nil && ok {
+			thread.Bridge = &contact
+		}
+	}
 
 	if !withStats {
 		thread.LastMessage = time.Time{}
@@ -350,38 +648,182 @@ func (s *Store) GetThread(ctx context.Context, threadID string, withStats bool)
 	return thread, nil
 }
 
+// FindThreadsByName returns threads whose display name, title, or name
+// matches name case-insensitively, or, for names starting with "#", whose
+// room alias is name.
+func (s *Store) FindThreadsByName(ctx context.Context, name string) ([]Thread, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("thread name is required")
+	}
+	threads, err := s.ListThreads(ctx, ThreadListOptions{All: true, Label: LabelAll, IncludeLowPriority: true})
+	if err != nil {
+		return nil, err
+	}
+	var aliased []string
+	if strings.HasPrefix(name, "#") {
+		if aliased, err = s.roomsByAlias(ctx, name); err != nil {
+			return nil, err
+		}
+	}
+	matches := []Thread{}
+	for _, thread := range threads {
+		if slices.Contains(aliased, thread.ID) || strings.EqualFold(thread.DisplayName, name) || strings.EqualFold(thread.Title, name) || strings.EqualFold(thread.Name, name) {
+			matches = append(matches, thread)
+		}
+	}
+	return matches, nil
+}
+
 // ListMessages returns messages for a thread.
 func (s *Store) ListMessages(ctx context.Context, opts MessageListOptions) ([]Message, error) {
+	page, err := s.ListMessagesPage(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return page.Messages, nil
+}
+
+// ListMessagesPage returns one page of messages for a thread, newest first
+// (or most engaged first), with a cursor for the next page.
+func (s *Store) ListMessagesPage(ctx context.Context, opts MessageListOptions) (MessagePage, error) {
 	if opts.ThreadID == "" {
-		return nil, errors.New("thread ID is required")
+		return MessagePage{}, errors.New("thread ID is required")
+	}
+	cursorKind := cursorMessages
+	if opts.TopEngaged {
+		cursorKind = cursorTopEngaged
+	}
+	cursor, err := decodeCursor(opts.Cursor, cursorKind)
+	if err != nil {
+		return MessagePage{}, err
 	}
 
 	limit := opts.Limit
 	if limit <= 0 {
 		limit = defaultLimit
 	}
+	if opts.All {
+		// SQLite treats a negative LIMIT as unbounded.
+		limit = -1
+	}
 
-	query := strings.Builder{}
-	query.WriteString(`SELECT id, eventID, roomID, senderContactID, timestamp, isSentByMe, type,
-		COALESCE(text_content, '') AS text_content,
-		COALESCE(message, '') AS message
-		FROM mx_room_messages
-		WHERE roomID = ?
-		AND isDeleted = 0
-		AND type NOT IN ('HIDDEN','REACTION')`)
+	messages, err := s.queryMessages(ctx, opts, cursor, limit, false)
+	if err != nil {
+		return MessagePage{}, err
+	}
+
+	page := MessagePage{Messages: messages}
+	if limit > 0 && len(messages) == limit {
+		last := messages[len(messages)-1]
+		next := pageCursor{Kind: cursorKind, Timestamp: last.Timestamp.UnixMilli(), ID: last.ID}
+		if opts.TopEngaged {
+			next.Rank = float64(last.ReactionCount + last.ReplyCount)
+		}
+		page.NextCursor = next.encode()
+	}
+	return page, nil
+}
 
-	args := []any{opts.ThreadID}
+// messageConditions returns the conditions, each with a leading AND, that
+// select the messages opts lists beyond the thread: deletion, edits,
+// decryption, type, sender, text, time, and read state filters.
+func (s *Store) messageConditions(ctx context.Context, opts MessageListOptions) (string, []any, error) {
+	query := strings.Builder{}
+	args := []any{}
+	query.WriteString(deletedFilter(opts.IncludeDeleted, opts.IncludeExpired))
+	if opts.ShowEdits {
+		query.WriteString(notEditCondition)
+	}
+	query.WriteString(skipUndecrypted(opts.SkipUndecrypted))
+	typeCondition, typeArgs := messageTypeFilter(opts.Type)
+	query.WriteString(typeCondition)
+	args = append(args, typeArgs...)
+	if len(opts.SenderIDs) > 0 {
+		query.WriteString(" AND m.senderContactID IN (" + placeholders(len(opts.SenderIDs)) + ")")
+		args = append(args, stringSliceToAny(opts.SenderIDs)...)
+	}
+	termsCondition, termsArgs := textTermsFilter(opts.Include, opts.Exclude)
+	query.WriteString(termsCondition)
+	args = append(args, termsArgs...)
 
 	if opts.After != nil {
-		query.WriteString(" AND timestamp >= ?")
+		query.WriteString(" AND m.timestamp >= ?")
 		args = append(args, opts.After.UnixMilli())
 	}
 	if opts.Before != nil {
-		query.WriteString(" AND timestamp <= ?")
+		query.WriteString(" AND m.timestamp <= ?")
 		args = append(args, opts.Before.UnixMilli())
 	}
+	if opts.UnreadOnly {
+		marker, err := s.ReadMarker(ctx, opts.ThreadID)
+		if err != nil {
+			return "", nil, err
+		}
+		if marker != nil {
+			ts := marker.Timestamp.UnixMilli()
+			query.WriteString(" AND (m.timestamp > ? OR (m.timestamp = ? AND m.id > ?))")
+			args = append(args, ts, ts, marker.MessageID)
+		}
+	}
+	return query.String(), args, nil
+}
 
-	query.WriteString(" ORDER BY timestamp DESC LIMIT ?")
+// queryMessages loads up to limit messages of opts.ThreadID after cursor,
+// newest first or, with ascending, oldest first (top engaged ordering
+// ignores ascending), with names, reactions, and edits attached.
+func (s *Store) queryMessages(ctx context.Context, opts MessageListOptions, cursor *pageCursor, limit int, ascending bool) ([]Message, error) {
+	engaged := opts.WithEngagement || opts.TopEngaged
+	query := strings.Builder{}
+	args := []any{}
+	if engaged {
+		query.WriteString(engagementCTE)
+		args = append(args, opts.ThreadID)
+		query.WriteString(`SELECT m.id, m.eventID, m.roomID, m.senderContactID, m.timestamp, m.isSentByMe, m.type,
+		COALESCE(m.text_content, '') AS text_content,
+		COALESCE(m.message, '') AS message,
+		COALESCE(e.reactions, 0), COALESCE(e.replies, 0), COALESCE(m.isDeleted, 0)
+		FROM mx_room_messages m
+		LEFT JOIN engagement e ON e.target = m.eventID`)
+	} else {
+		query.WriteString(`SELECT m.id, m.eventID, m.roomID, m.senderContactID, m.timestamp, m.isSentByMe, m.type,
+		COALESCE(m.text_content, '') AS text_content,
+		COALESCE(m.message, '') AS message,
+		0, 0, COALESCE(m.isDeleted, 0)
+		FROM mx_room_messages m`)
+	}
+	query.WriteString(`
+		WHERE m.roomID = ?
+		AND m.type NOT IN ('HIDDEN','REACTION')`)
+	args = append(args, opts.ThreadID)
+	conditions, conditionArgs, err := s.messageConditions(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	query.WriteString(conditions)
+	args = append(args, conditionArgs...)
+	if cursor != nil {
+		if opts.TopEngaged {
+			query.WriteString(` AND (COALESCE(e.reactions + e.replies, 0) < ? OR (COALESCE(e.reactions + e.replies, 0) = ?
+				AND (m.timestamp < ? OR (m.timestamp = ? AND m.id < ?))))`)
+			args = append(args, int64(cursor.Rank), int64(cursor.Rank), cursor.Timestamp, cursor.Timestamp, cursor.ID)
+		} else if ascending {
+			query.WriteString(" AND (m.timestamp > ? OR (m.timestamp = ? AND m.id > ?))")
+			args = append(args, cursor.Timestamp, cursor.Timestamp, cursor.ID)
+		} else {
+			query.WriteString(" AND (m.timestamp < ? OR (m.timestamp = ? AND m.id < ?))")
+			args = append(args, cursor.Timestamp, cursor.Timestamp, cursor.ID)
+		}
+	}
+
+	switch {
+	case opts.TopEngaged:
+		query.WriteString(" AND e.target IS NOT NULL ORDER BY e.reactions + e.replies DESC, m.timestamp DESC, m.id DESC LIMIT ?")
+	case ascending:
+		query.WriteString(" ORDER BY m.timestamp ASC, m.id ASC LIMIT ?")
+	default:
+		query.WriteString(" ORDER BY m.timestamp DESC, m.id DESC LIMIT ?")
+	}
 	args = append(args, limit)
 
 	rows, err := s.db.QueryContext(ctx, query.String(), args...)
@@ -391,6 +833,7 @@ func (s *Store) ListMessages(ctx context.Context, opts MessageListOptions) ([]Me
 	defer func() { _ = rows.Close() }()
 
 	messages := []Message{}
+	now := time.Now()
 	for rows.Next() {
 		var msg Message
 		var ts int64
@@ -398,6 +841,7 @@ func (s *Store) ListMessages(ctx context.Context, opts MessageListOptions) ([]Me
 		var msgType sql.NullString
 		var textContent sql.NullString
 		var rawMessage sql.NullString
+		var isDeleted int
 		if err := rows.Scan(
 			&msg.ID,
 			&msg.EventID,
@@ -408,13 +852,23 @@ func (s *Store) ListMessages(ctx context.Context, opts MessageListOptions) ([]Me
 			&msgType,
 			&textContent,
 			&rawMessage,
+			&msg.ReactionCount,
+			&msg.ReplyCount,
+			&isDeleted,
 		); err != nil {
 			return nil, err
 		}
 		msg.Timestamp = unixMillis(ts)
 		msg.IsSentByMe = isSentByMe != 0
+		msg.IsDeleted = isDeleted != 0
 		msg.Type = strings.TrimSpace(msgType.String)
 		msg.Text = ResolveMessageText(rawMessage.String, msg.Type, textContent.String, opts.Format)
+		msg.Undecrypted = isUndecrypted(rawMessage.String, msg.Type, textContent.String)
+		applyExpiry(&msg, rawMessage.String, now)
+		msg.ThreadRootEventID, msg.ReplyToEventID = parseRelation(rawMessage.String)
+		if opts.WithProvenance {
+			msg.Provenance = extractProvenance(rawMessage.String, msg.IsSentByMe)
+		}
 		messages = append(messages, msg)
 	}
 	if err := rows.Err(); err != nil {
@@ -428,19 +882,53 @@ func (s *Store) ListMessages(ctx context.Context, opts MessageListOptions) ([]Me
 	participants := participantsByRoom[opts.ThreadID]
 	participantIndex := indexParticipants(participants)
 
+	platform := ""
+	if opts.WithProvenance {
+		info, err := s.threadInfoByID(ctx, []string{opts.ThreadID})
+		if err != nil {
+			return nil, err
+		}
+		platform = normalizePlatform(info[opts.ThreadID].AccountID)
+	}
+
 	for i := range messages {
 		if p, ok := participantIndex[messages[i].SenderID]; ok {
 			messages[i].SenderName = p.Name
 		}
+		if messages[i].Provenance != nil {
+			messages[i].Provenance.Platform = platform
+		}
 	}
 
+	if opts.WithReactions {
+		if err := s.attachReactions(ctx, opts.ThreadID, messages); err != nil {
+			return nil, err
+		}
+	}
+	if opts.ShowEdits {
+		if err := s.attachEdits(ctx, opts.ThreadID, messages, opts.Format); err != nil {
+			return nil, err
+		}
+	}
+	s.setPreviews(messages)
+
 	return messages, nil
 }
 
 // SearchMessages searches messages using FTS (or LIKE fallback).
 func (s *Store) SearchMessages(ctx context.Context, opts SearchOptions) ([]SearchResult, error) {
-	if strings.TrimSpace(opts.Query) == "" {
-		return nil, errors.New("search query is required")
+	page, err := s.SearchMessagesPage(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return page.Results, nil
+}
+
+// SearchMessagesPage returns one page of search results, best ranked
+// first, with a cursor for the next page.
+func (s *Store) SearchMessagesPage(ctx context.Context, opts SearchOptions) (SearchPage, error) {
+	if strings.TrimSpace(opts.Query) == "" && opts.MessageIDs == nil && len(opts.Emoji) == 0 && strings.TrimSpace(opts.Type) == "" {
+		return SearchPage{}, errors.New("search query is required")
 	}
 
 	limit := opts.Limit
@@ -448,73 +936,47 @@ func (s *Store) SearchMessages(ctx context.Context, opts SearchOptions) ([]Searc
 		limit = defaultLimit
 	}
 
-	useFTS, err := s.HasFTS(ctx)
+	cursor, err := decodeCursor(opts.Cursor, cursorSearch, cursorSearchLike)
 	if err != nil {
-		return nil, err
+		return SearchPage{}, err
 	}
 
-	buildQuery := func(useFTS bool) (string, []any) {
-		query := strings.Builder{}
-		args := []any{}
-
-		if useFTS {
-			query.WriteString(`SELECT m.id, m.eventID, m.roomID, m.senderContactID, m.timestamp, m.isSentByMe, m.type,
-				COALESCE(m.text_content, '') AS text_content,
-				COALESCE(m.message, '') AS message,
-				bm25(f) AS rank
-				FROM mx_room_messages_fts f
-				JOIN mx_room_messages m ON m.id = f.rowid
-				WHERE f.text_content MATCH ?
-				AND m.isDeleted = 0
-				AND m.type NOT IN ('HIDDEN','REACTION')`)
-			args = append(args, opts.Query)
-		} else {
-			query.WriteString(`SELECT m.id, m.eventID, m.roomID, m.senderContactID, m.timestamp, m.isSentByMe, m.type,
-				COALESCE(m.text_content, '') AS text_content,
-				COALESCE(m.message, '') AS message,
-				0 as rank
-				FROM mx_room_messages m
-				WHERE json_extract(m.message,'$.text') LIKE ?
-				AND m.isDeleted = 0
-				AND m.type NOT IN ('HIDDEN','REACTION')`)
-			args = append(args, "%"+opts.Query+"%")
-		}
-
-		if opts.ThreadID != "" {
-			query.WriteString(" AND m.roomID = ?")
-			args = append(args, opts.ThreadID)
-		}
-
-		if opts.AccountID != "" {
-			query.WriteString(" AND m.roomID IN (SELECT threadID FROM threads WHERE accountID = ?)")
-			args = append(args, opts.AccountID)
-		}
-
-		if opts.Days > 0 {
-			cutoff := time.Now().AddDate(0, 0, -opts.Days).UnixMilli()
-			query.WriteString(" AND m.timestamp >= ?")
-			args = append(args, cutoff)
+	useFTS, err := s.HasFTS(ctx)
+	if err != nil {
+		return SearchPage{}, err
+	}
+	if strings.TrimSpace(opts.Query) == "" {
+		useFTS = false
+	} else if opts.RequireFTS && !useFTS {
+		return SearchPage{}, fmt.Errorf("%w: %s has no mx_room_messages_fts table", ErrFTSUnavailable, s.path)
+	}
+	if cursor != nil {
+		// Keep paging the way the first page was ranked.
+		if cursor.Kind == cursorSearch && !useFTS {
+			return SearchPage{}, ErrInvalidCursor
 		}
-
-		query.WriteString(" ORDER BY rank ASC, m.timestamp DESC LIMIT ?")
-		args = append(args, limit)
-		return query.String(), args
+		useFTS = cursor.Kind == cursorSearch
 	}
 
-	queryStr, args := buildQuery(useFTS)
+	queryStr, args := searchQuery(opts, useFTS, cursor, limit, false)
 	rows, err := s.db.QueryContext(ctx, queryStr, args...)
 	if err != nil && useFTS && isFTSError(err) {
-		queryStr, args = buildQuery(false)
+		if opts.RequireFTS {
+			return SearchPage{}, fmt.Errorf("%w: %v", ErrFTSUnavailable, err)
+		}
+		useFTS = false
+		queryStr, args = searchQuery(opts, false, cursor, limit, false)
 		rows, err = s.db.QueryContext(ctx, queryStr, args...)
 	}
 	if err != nil {
-		return nil, err
+		return SearchPage{}, err
 	}
 	defer func() { _ = rows.Close() }()
 
 	matches := []Message{}
 	roomIDs := []string{}
 
+	now := time.Now()
 	for rows.Next() {
 		var msg Message
 		var ts int64
@@ -534,37 +996,47 @@ func (s *Store) SearchMessages(ctx context.Context, opts SearchOptions) ([]Searc
 			&rawMessage,
 			&msg.Score,
 		); err != nil {
-			return nil, err
+			return SearchPage{}, err
 		}
 		msg.Timestamp = unixMillis(ts)
 		msg.IsSentByMe = isSentByMe != 0
 		msg.Type = strings.TrimSpace(msgType.String)
 		msg.Text = ResolveMessageText(rawMessage.String, msg.Type, textContent.String, opts.Format)
+		msg.Undecrypted = isUndecrypted(rawMessage.String, msg.Type, textContent.String)
+		applyExpiry(&msg, rawMessage.String, now)
+		msg.ThreadRootEventID, msg.ReplyToEventID = parseRelation(rawMessage.String)
+		if opts.WithProvenance {
+			msg.Provenance = extractProvenance(rawMessage.String, msg.IsSentByMe)
+		}
 		matches = append(matches, msg)
 		roomIDs = append(roomIDs, msg.ThreadID)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, err
+		return SearchPage{}, err
 	}
 
 	threadInfo, err := s.threadInfoByID(ctx, uniqueStrings(roomIDs))
 	if err != nil {
-		return nil, err
+		return SearchPage{}, err
 	}
 
 	participantsByRoom, err := s.participantsByRoom(ctx, uniqueStrings(roomIDs))
 	if err != nil {
-		return nil, err
+		return SearchPage{}, err
 	}
 	participantIndexByRoom := map[string]map[string]Participant{}
 	for roomID, participants := range participantsByRoom {
 		participantIndexByRoom[roomID] = indexParticipants(participants)
 	}
 
+	s.prefetchBridgeNames(ctx, threadsFromInfo(threadInfo))
 	for i := range matches {
 		info := threadInfo[matches[i].ThreadID]
 		matches[i].AccountID = info.AccountID
+		if matches[i].Provenance != nil {
+			matches[i].Provenance.Platform = normalizePlatform(info.AccountID)
+		}
 		matches[i].ThreadName = s.displayName(ctx, Thread{ID: matches[i].ThreadID, Title: info.Title, Name: info.Name, Type: info.Type, AccountID: info.AccountID}, participantsByRoom[matches[i].ThreadID])
 		if participantIndex, ok := participantIndexByRoom[matches[i].ThreadID]; ok {
 			if p, ok := participantIndex[matches[i].SenderID]; ok {
@@ -572,6 +1044,7 @@ func (s *Store) SearchMessages(ctx context.Context, opts SearchOptions) ([]Searc
 			}
 		}
 	}
+	s.setPreviews(matches)
 
 	results := make([]SearchResult, 0, len(matches))
 	for _, match := range matches {
@@ -579,14 +1052,173 @@ func (s *Store) SearchMessages(ctx context.Context, opts SearchOptions) ([]Searc
 		if opts.Context > 0 || opts.Window > 0 {
 			contextMessages, err := s.fetchContextMessages(ctx, match, opts, participantsByRoom, threadInfo)
 			if err != nil {
-				return nil, err
+				return SearchPage{}, err
 			}
 			result.Context = contextMessages
 		}
 		results = append(results, result)
 	}
 
-	return results, nil
+	page := SearchPage{Results: results}
+	if len(matches) == limit {
+		last := matches[len(matches)-1]
+		next := pageCursor{Kind: cursorSearchLike, Timestamp: last.Timestamp.UnixMilli(), ID: last.ID}
+		if useFTS {
+			next.Kind = cursorSearch
+			next.Rank = last.Score
+		}
+		page.NextCursor = next.encode()
+	}
+	return page, nil
+}
+
+// searchQuery builds the query for one page of search results after
+// cursor, or, with count, the query counting every match.
+func searchQuery(opts SearchOptions, useFTS bool, cursor *pageCursor, limit int, count bool) (string, []any) {
+	query := strings.Builder{}
+	args := []any{}
+
+	columns := func(rank string) string {
+		if count {
+			return "COUNT(*)"
+		}
+		return `m.id, m.eventID, m.roomID, m.senderContactID, m.timestamp, m.isSentByMe, m.type,
+			COALESCE(m.text_content, '') AS text_content,
+			COALESCE(m.message, '') AS message,
+			` + rank + ` AS rank`
+	}
+	if useFTS {
+		query.WriteString(`SELECT ` + columns("bm25(f)") + `
+			FROM mx_room_messages_fts f
+			JOIN mx_room_messages m ON m.id = f.rowid
+			WHERE f.text_content MATCH ?
+			AND m.isDeleted = 0
+			AND m.type NOT IN ('HIDDEN','REACTION')`)
+		args = append(args, opts.Query)
+	} else {
+		query.WriteString(`SELECT ` + columns("0") + `
+			FROM mx_room_messages m
+			WHERE m.isDeleted = 0
+			AND m.type NOT IN ('HIDDEN','REACTION')`)
+		// Every term must match text_content, ignoring case and
+		// diacritics.
+		for _, term := range searchTerms(opts.Query) {
+			query.WriteString(" AND " + foldFunction + `(COALESCE(m.text_content, '')) LIKE ? ESCAPE '\'`)
+			args = append(args, "%"+likeEscaper.Replace(foldText(term))+"%")
+		}
+	}
+
+	if opts.MessageIDs != nil {
+		ids, _ := json.Marshal(opts.MessageIDs)
+		query.WriteString(" AND m.id IN (SELECT value FROM json_each(?))")
+		args = append(args, string(ids))
+	}
+
+	if opts.ThreadID != "" {
+		query.WriteString(" AND m.roomID = ?")
+		args = append(args, opts.ThreadID)
+	}
+
+	if opts.AccountID != "" {
+		query.WriteString(" AND m.roomID IN (SELECT threadID FROM threads WHERE accountID = ?)")
+		args = append(args, opts.AccountID)
+	}
+
+	typeCondition, typeArgs := messageTypeFilter(opts.Type)
+	query.WriteString(typeCondition)
+	args = append(args, typeArgs...)
+
+	if len(opts.SenderIDs) > 0 {
+		query.WriteString(" AND m.senderContactID IN (" + placeholders(len(opts.SenderIDs)) + ")")
+		args = append(args, stringSliceToAny(opts.SenderIDs)...)
+	}
+
+	if opts.SentByMe {
+		query.WriteString(" AND m.isSentByMe = 1")
+	}
+
+	query.WriteString(skipUndecrypted(opts.SkipUndecrypted))
+
+	// FTS tokenizers drop emoji, so match them in the raw text.
+	for _, emoji := range opts.Emoji {
+		query.WriteString(" AND REPLACE(COALESCE(json_extract(m.message,'$.text'), m.text_content, ''), char(65039), '') LIKE ?")
+		args = append(args, "%"+NormalizeEmoji(emoji)+"%")
+	}
+
+	if opts.Days > 0 {
+		cutoff := time.Now().AddDate(0, 0, -opts.Days).UnixMilli()
+		query.WriteString(" AND m.timestamp >= ?")
+		args = append(args, cutoff)
+	}
+	if opts.After != nil {
+		query.WriteString(" AND m.timestamp >= ?")
+		args = append(args, opts.After.UnixMilli())
+	}
+	if opts.Before != nil {
+		query.WriteString(" AND m.timestamp <= ?")
+		args = append(args, opts.Before.UnixMilli())
+	}
+
+	if count {
+		return query.String(), args
+	}
+
+	if cursor != nil {
+		if useFTS {
+			query.WriteString(" AND (bm25(f) > ? OR (bm25(f) = ? AND (m.timestamp < ? OR (m.timestamp = ? AND m.id < ?))))")
+			args = append(args, cursor.Rank, cursor.Rank, cursor.Timestamp, cursor.Timestamp, cursor.ID)
+		} else {
+			query.WriteString(" AND (m.timestamp < ? OR (m.timestamp = ? AND m.id < ?))")
+			args = append(args, cursor.Timestamp, cursor.Timestamp, cursor.ID)
+		}
+	}
+
+	query.WriteString(" ORDER BY rank ASC, m.timestamp DESC, m.id DESC LIMIT ?")
+	args = append(args, limit)
+	return query.String(), args
+}
+
+// GetMessageByEventID returns the message with a Matrix event ID as a
+// search result, with the context opts asks for (Context, Window) as in
+// SearchMessages. Format and WithProvenance apply as well; other filters
+// are ignored.
+func (s *Store) GetMessageByEventID(ctx context.Context, eventID string, opts SearchOptions) (SearchResult, error) {
+	eventID = strings.TrimSpace(eventID)
+	if eventID == "" {
+		return SearchResult{}, errors.New("event ID is required")
+	}
+	var id int64
+	var msgType string
+	var isDeleted int
+	err := s.db.QueryRowContext(ctx, "SELECT id, type, COALESCE(isDeleted, 0) FROM mx_room_messages WHERE eventID = ? LIMIT 1", eventID).Scan(&id, &msgType, &isDeleted)
+	if errors.Is(err, sql.ErrNoRows) {
+		return SearchResult{}, fmt.Errorf("%w: %s", ErrMessageNotFound, eventID)
+	}
+	if err != nil {
+		return SearchResult{}, err
+	}
+	if isDeleted != 0 {
+		return SearchResult{}, fmt.Errorf("event %s was deleted: %w", eventID, ErrMessageNotFound)
+	}
+	if msgType == "HIDDEN" || msgType == "REACTION" {
+		return SearchResult{}, fmt.Errorf("event %s is not a visible message: %w", eventID, ErrMessageNotFound)
+	}
+
+	results, err := s.SearchMessages(ctx, SearchOptions{
+		MessageIDs:     []int64{id},
+		Limit:          1,
+		Context:        opts.Context,
+		Window:         opts.Window,
+		Format:         opts.Format,
+		WithProvenance: opts.WithProvenance,
+	})
+	if err != nil {
+		return SearchResult{}, err
+	}
+	if len(results) == 0 {
+		return SearchResult{}, fmt.Errorf("%w: %s", ErrMessageNotFound, eventID)
+	}
+	return results[0], nil
 }
 
 func (s *Store) fetchContextMessages(
@@ -596,6 +1228,49 @@ func (s *Store) fetchContextMessages(
 	participantsByRoom map[string][]Participant,
 	threadInfo map[string]threadInfo,
 ) ([]Message, error) {
+	var messages []Message
+	var err error
+	if opts.Context > 0 && opts.Window == 0 {
+		messages, err = s.neighborMessages(ctx, match, opts.Context, opts)
+	} else {
+		messages, err = s.windowMessages(ctx, match, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	participants := participantsByRoom[match.ThreadID]
+	participantIndex := indexParticipants(participants)
+	info := threadInfo[match.ThreadID]
+	threadName := s.displayName(ctx, Thread{ID: match.ThreadID, Title: info.Title, Name: info.Name, Type: info.Type, AccountID: info.AccountID}, participants)
+
+	for i := range messages {
+		messages[i].AccountID = info.AccountID
+		messages[i].ThreadName = threadName
+		if messages[i].Provenance != nil {
+			messages[i].Provenance.Platform = normalizePlatform(info.AccountID)
+		}
+		if p, ok := participantIndex[messages[i].SenderID]; ok {
+			messages[i].SenderName = p.Name
+		}
+	}
+	s.setPreviews(messages)
+
+	if opts.Context > 0 && opts.Window > 0 {
+		return trimContext(messages, match.ID, opts.Context), nil
+	}
+
+	return messages, nil
+}
+
+const contextColumns = `SELECT id, eventID, roomID, senderContactID, timestamp, isSentByMe, type,
+		COALESCE(text_content, '') AS text_content,
+		COALESCE(message, '') AS message
+		FROM mx_room_messages m`
+
+// windowMessages returns every visible message in the match's thread within
+// opts.Window (default one hour) of it, oldest first.
+func (s *Store) windowMessages(ctx context.Context, match Message, opts SearchOptions) ([]Message, error) {
 	window := opts.Window
 	if window == 0 {
 		window = defaultContextWindow
@@ -604,23 +1279,54 @@ func (s *Store) fetchContextMessages(
 	start := match.Timestamp.Add(-window).UnixMilli()
 	end := match.Timestamp.Add(window).UnixMilli()
 
-	query := `SELECT id, eventID, roomID, senderContactID, timestamp, isSentByMe, type,
-		COALESCE(text_content, '') AS text_content,
-		COALESCE(message, '') AS message
-		FROM mx_room_messages
+	query := contextColumns + `
 		WHERE roomID = ?
 		AND timestamp BETWEEN ? AND ?
 		AND isDeleted = 0
-		AND type NOT IN ('HIDDEN','REACTION')
+		AND type NOT IN ('HIDDEN','REACTION')` + skipUndecrypted(opts.SkipUndecrypted) + `
 		ORDER BY timestamp ASC`
+	return s.queryContextMessages(ctx, query, []any{match.ThreadID, start, end}, opts)
+}
 
-	rows, err := s.db.QueryContext(ctx, query, match.ThreadID, start, end)
+// neighborMessages returns up to n visible messages on each side of the
+// match in server order (hsOrder, then id), however far apart in time they
+// are. Bursty rooms get exactly n neighbors and high-volume rooms never
+// load a whole time window just to trim it.
+func (s *Store) neighborMessages(ctx context.Context, match Message, n int, opts SearchOptions) ([]Message, error) {
+	visible := ` AND isDeleted = 0 AND type NOT IN ('HIDDEN','REACTION')` + skipUndecrypted(opts.SkipUndecrypted)
+	const matchOrder = `(SELECT hsOrder FROM mx_room_messages WHERE id = ?)`
+
+	before, err := s.queryContextMessages(ctx, contextColumns+`
+		WHERE roomID = ? AND (hsOrder, id) < (`+matchOrder+`, ?)`+visible+`
+		ORDER BY hsOrder DESC, id DESC LIMIT ?`,
+		[]any{match.ThreadID, match.ID, match.ID, n}, opts)
+	if err != nil {
+		return nil, err
+	}
+	after, err := s.queryContextMessages(ctx, contextColumns+`
+		WHERE roomID = ? AND (hsOrder, id) > (`+matchOrder+`, ?)`+visible+`
+		ORDER BY hsOrder ASC, id ASC LIMIT ?`,
+		[]any{match.ThreadID, match.ID, match.ID, n}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]Message, 0, len(before)+len(after))
+	for i := len(before) - 1; i >= 0; i-- {
+		messages = append(messages, before[i])
+	}
+	return append(messages, after...), nil
+}
+
+func (s *Store) queryContextMessages(ctx context.Context, query string, args []any, opts SearchOptions) ([]Message, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer func() { _ = rows.Close() }()
 
 	messages := []Message{}
+	now := time.Now()
 	for rows.Next() {
 		var msg Message
 		var ts int64
@@ -645,29 +1351,17 @@ func (s *Store) fetchContextMessages(
 		msg.IsSentByMe = isSentByMe != 0
 		msg.Type = strings.TrimSpace(msgType.String)
 		msg.Text = ResolveMessageText(rawMessage.String, msg.Type, textContent.String, opts.Format)
+		msg.Undecrypted = isUndecrypted(rawMessage.String, msg.Type, textContent.String)
+		applyExpiry(&msg, rawMessage.String, now)
+		msg.ThreadRootEventID, msg.ReplyToEventID = parseRelation(rawMessage.String)
+		if opts.WithProvenance {
+			msg.Provenance = extractProvenance(rawMessage.String, msg.IsSentByMe)
+		}
 		messages = append(messages, msg)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
-
-	participants := participantsByRoom[match.ThreadID]
-	participantIndex := indexParticipants(participants)
-	info := threadInfo[match.ThreadID]
-	threadName := s.displayName(ctx, Thread{ID: match.ThreadID, Title: info.Title, Name: info.Name, Type: info.Type, AccountID: info.AccountID}, participants)
-
-	for i := range messages {
-		messages[i].AccountID = info.AccountID
-		messages[i].ThreadName = threadName
-		if p, ok := participantIndex[messages[i].SenderID]; ok {
-			messages[i].SenderName = p.Name
-		}
-	}
-
-	if opts.Context > 0 {
-		return trimContext(messages, match.ID, opts.Context), nil
-	}
-
 	return messages, nil
 }
 
@@ -678,6 +1372,15 @@ type threadInfo struct {
 	Type      string
 }
 
+// threadsFromInfo turns threadInfoByID results into (unsorted) threads.
+func threadsFromInfo(info map[string]threadInfo) []Thread {
+	threads := make([]Thread, 0, len(info))
+	for id, i := range info {
+		threads = append(threads, Thread{ID: id, AccountID: i.AccountID, Title: i.Title, Name: i.Name, Type: i.Type})
+	}
+	return threads
+}
+
 func (s *Store) threadInfoByID(ctx context.Context, ids []string) (map[string]threadInfo, error) {
 	info := map[string]threadInfo{}
 	if len(ids) == 0 {
@@ -715,10 +1418,12 @@ func (s *Store) threadInfoByID(ctx context.Context, ids []string) (map[string]th
 	return info, rows.Err()
 }
 
+// participantsByRoom loads the participants of roomIDs; it returns none
+// without querying when participant enrichment is off.
 func (s *Store) participantsByRoom(ctx context.Context, roomIDs []string) (map[string][]Participant, error) {
 	participantsByRoom := map[string][]Participant{}
 	roomIDs = uniqueStrings(roomIDs)
-	if len(roomIDs) == 0 {
+	if len(roomIDs) == 0 || s.noParticipants {
 		return participantsByRoom, nil
 	}
 
@@ -756,17 +1461,43 @@ func (s *Store) participantsByRoom(ctx context.Context, roomIDs []string) (map[s
 	return participantsByRoom, rows.Err()
 }
 
+// prefetchBridgeNames resolves the DMs among threads that need a bridge
+// name in one batch, so displayName finds them cached. Lookup errors are
+// ignored here as in displayName.
+func (s *Store) prefetchBridgeNames(ctx context.Context, threads []Thread) {
+	bridge := s.bridgeLookup()
+	if bridge == nil {
+		return
+	}
+	refs := []RoomRef{}
+	for _, thread := range threads {
+		if thread.Title == "" && thread.Name == "" && (thread.Type == "single" || thread.Type == "dm") {
+			refs = append(refs, RoomRef{RoomID: thread.ID, AccountID: thread.AccountID})
+		}
+	}
+	if len(refs) > 0 {
+		_, _ = bridge.LookupDMContacts(ctx, refs)
+	}
+}
+
 func (s *Store) displayName(ctx context.Context, thread Thread, participants []Participant) string {
+	name, _ := s.resolveDisplayName(ctx, thread, participants)
+	return name
+}
+
+// resolveDisplayName returns a thread's display name and which NameSource
+// it came from.
+func (s *Store) resolveDisplayName(ctx context.Context, thread Thread, participants []Participant) (string, string) {
 	if thread.Title != "" {
-		return thread.Title
+		return thread.Title, NameSourceTitle
 	}
 	if thread.Name != "" {
-		return thread.Name
+		return thread.Name, NameSourceName
 	}
 
-	if s.bridge != nil && (thread.Type == "single" || thread.Type == "dm") {
-		if name, ok, err := s.bridge.LookupDMName(ctx, thread.ID, thread.AccountID); err == nil && ok {
-			return name
+	if bridge := s.bridgeLookup(); bridge != nil && (thread.Type == "single" || thread.Type == "dm") {
+		if name, ok, err := bridge.LookupDMName(ctx, thread.ID, thread.AccountID); err == nil && ok {
+			return name, NameSourceBridge
 		}
 	}
 
@@ -779,18 +1510,21 @@ func (s *Store) displayName(ctx context.Context, thread Thread, participants []P
 	}
 
 	if len(nonSelf) == 0 {
-		return "(unknown)"
+		if s.noParticipants {
+			return thread.ID, NameSourceID
+		}
+		return "(unknown)", NameSourceUnknown
 	}
 
 	if thread.Type == "single" || thread.Type == "dm" {
-		return nonSelf[0]
+		return nonSelf[0], NameSourceParticipants
 	}
 
 	if len(nonSelf) <= 3 {
-		return strings.Join(nonSelf, ", ")
+		return strings.Join(nonSelf, ", "), NameSourceParticipants
 	}
 
-	return fmt.Sprintf("%s +%d", strings.Join(nonSelf[:3], ", "), len(nonSelf)-3)
+	return fmt.Sprintf("%s +%d", strings.Join(nonSelf[:3], ", "), len(nonSelf)-3), NameSourceParticipants
 }
 
 func indexParticipants(participants []Participant) map[string]Participant {
@@ -1,13 +1,19 @@
 package beeper
 
 import (
+	"cmp"
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"testing"
 	"time"
 
+	"github.com/KrauseFx/beeper-cli/internal/synth"
 	_ "github.com/mattn/go-sqlite3"
 )
 
@@ -54,6 +60,51 @@ func TestListThreadsLabels(t *testing.T) {
 	}
 }
 
+func TestListThreadsSortAndType(t *testing.T) {
+	path := createTestDB(t, false)
+	store, err := OpenWithOptions(path, StoreOptions{BridgeLookup: false})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	ctx := context.Background()
+	list := func(opts ThreadListOptions) []string {
+		t.Helper()
+		opts.Label = LabelAll
+		opts.IncludeLowPriority = true
+		threads, err := store.ListThreads(ctx, opts)
+		if err != nil {
+			t.Fatalf("list threads: %v", err)
+		}
+		return ids(threads)
+	}
+
+	if got := list(ThreadListOptions{Type: ThreadTypeDM}); !slices.Equal(got, []string{"!room4:beeper.local"}) {
+		t.Fatalf("expected the DM only, got %v", got)
+	}
+	if got := list(ThreadListOptions{Type: ThreadTypeGroup}); !slices.Equal(got, []string{"!room1:beeper.local", "!room3:beeper.local", "!room2:beeper.local"}) {
+		t.Fatalf("expected groups by activity, got %v", got)
+	}
+	if got := list(ThreadListOptions{Type: ThreadTypeGroup, Sort: SortUnread}); got[0] != "!room1:beeper.local" {
+		t.Fatalf("expected the unread thread first, got %v", got)
+	}
+	if got := list(ThreadListOptions{Sort: SortMessages, Limit: 1}); !slices.Equal(got, []string{"!room1:beeper.local"}) {
+		t.Fatalf("expected the busiest thread, got %v", got)
+	}
+	if got := list(ThreadListOptions{Type: ThreadTypeGroup, Sort: SortName, Limit: 2}); !slices.Equal(got, []string{"!room2:beeper.local", "!room3:beeper.local"}) {
+		t.Fatalf("expected Archived, Fav by name, got %v", got)
+	}
+	for _, participant := range []string{"@alice:beeper.local", "ALI", " alice "} {
+		if got := list(ThreadListOptions{Participant: participant}); !slices.Equal(got, []string{"!room1:beeper.local"}) {
+			t.Fatalf("participant %q: expected Team Chat only, got %v", participant, got)
+		}
+	}
+	if got := list(ThreadListOptions{Participant: "%"}); len(got) != 0 {
+		t.Fatalf("expected LIKE wildcards to match literally, got %v", got)
+	}
+}
+
 func TestSearchWithContext(t *testing.T) {
 	path := createTestDB(t, true)
 	store, err := OpenWithOptions(path, StoreOptions{BridgeLookup: false})
@@ -85,6 +136,44 @@ func TestSearchWithContext(t *testing.T) {
 	}
 }
 
+func TestSearchContextByCount(t *testing.T) {
+	path := createTestDB(t, false)
+	store, err := OpenWithOptions(path, StoreOptions{BridgeLookup: false})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	ctx := context.Background()
+	for _, tt := range []struct {
+		query   string
+		context int
+		want    []int64
+	}{
+		{"christmas", 1, []int64{1, 3}},
+		{"invoice", 2, []int64{2, 3}},
+		{"hello", 5, []int64{2, 3, 7}},
+	} {
+		results, err := store.SearchMessages(ctx, SearchOptions{Query: tt.query, Context: tt.context, Format: FormatPlain})
+		if err != nil {
+			t.Fatalf("search %q: %v", tt.query, err)
+		}
+		if len(results) != 1 {
+			t.Fatalf("search %q: expected 1 result, got %d", tt.query, len(results))
+		}
+		got := []int64{}
+		for _, msg := range results[0].Context {
+			got = append(got, msg.ID)
+			if msg.ThreadName != "Team Chat" || msg.SenderName != "Alice" {
+				t.Fatalf("context message not enriched: %+v", msg)
+			}
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Fatalf("search %q: expected context %v, got %v", tt.query, tt.want, got)
+		}
+	}
+}
+
 func TestSearchFallbackLike(t *testing.T) {
 	path := createTestDB(t, false)
 	store, err := OpenWithOptions(path, StoreOptions{BridgeLookup: false})
@@ -103,6 +192,131 @@ func TestSearchFallbackLike(t *testing.T) {
 	}
 }
 
+func TestSearchFallbackFoldsAndCombinesTerms(t *testing.T) {
+	path := createTestDB(t, false)
+	// text_content is what the fallback matches; $.text differs on purpose.
+	insertMessages(t, path, testMessage{id: 8, roomID: "!room1:beeper.local", eventID: "$evt8", sender: "@alice:beeper.local", ts: 1700000000800, hsOrder: 10,
+		message: `{"text":"unrelated"}`, text: "Rendez-vous au CAFÉ Zoë à 10h, 50% off_peak"})
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	ctx := context.Background()
+	for _, tt := range []struct {
+		query string
+		want  []int64
+	}{
+		{"cafe", []int64{8}},
+		{"Café zoe", []int64{8}},
+		{"zoe invoice", []int64{}},
+		{`"au cafe"`, []int64{8}},
+		{`"cafe au"`, []int64{}},
+		{"50%", []int64{8}},
+		{"0%_", []int64{}},
+		{"unrelated", []int64{}},
+		{"INVOICE due", []int64{7}},
+	} {
+		results, err := store.SearchMessages(ctx, SearchOptions{Query: tt.query})
+		if err != nil {
+			t.Fatalf("search %q: %v", tt.query, err)
+		}
+		got := []int64{}
+		for _, r := range results {
+			got = append(got, r.Match.ID)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Fatalf("search %q: expected %v, got %v", tt.query, tt.want, got)
+		}
+	}
+}
+
+func TestSearchBySender(t *testing.T) {
+	for _, withFTS := range []bool{false, true} {
+		t.Run(fmt.Sprintf("fts=%t", withFTS), func(t *testing.T) {
+			path := createTestDB(t, withFTS)
+			insertMessages(t, path, testMessage{id: 8, roomID: "!room1:beeper.local", eventID: "$evt8", sender: "@me:beeper.local", ts: 1700000000800, hsOrder: 10, isMe: true, text: "party at mine"})
+
+			store, err := Open(path)
+			if err != nil {
+				t.Fatalf("open store: %v", err)
+			}
+			defer func() { _ = store.Close() }()
+			ctx := context.Background()
+
+			ids := func(opts SearchOptions) []int64 {
+				t.Helper()
+				opts.Query = "party"
+				results, err := store.SearchMessages(ctx, opts)
+				if err != nil {
+					t.Fatalf("search: %v", err)
+				}
+				ids := []int64{}
+				for _, r := range results {
+					ids = append(ids, r.Match.ID)
+				}
+				slices.Sort(ids)
+				return ids
+			}
+			if got := ids(SearchOptions{}); !slices.Equal(got, []int64{2, 8}) {
+				t.Fatalf("expected both matches, got %v", got)
+			}
+			if got := ids(SearchOptions{SenderIDs: []string{"@alice:beeper.local"}}); !slices.Equal(got, []int64{2}) {
+				t.Fatalf("expected Alice's match, got %v", got)
+			}
+			if got := ids(SearchOptions{SentByMe: true}); !slices.Equal(got, []int64{8}) {
+				t.Fatalf("expected my match, got %v", got)
+			}
+			if got := ids(SearchOptions{SenderIDs: []string{"@bob:beeper.local"}}); len(got) != 0 {
+				t.Fatalf("expected no matches from Bob, got %v", got)
+			}
+		})
+	}
+}
+
+func TestSearchByEmoji(t *testing.T) {
+	path := createTestDB(t, false)
+	insertMessages(t, path,
+		testMessage{id: 8, roomID: "!room1:beeper.local", eventID: "$evt8", sender: "@me:beeper.local", ts: 1700000000800, hsOrder: 10, isMe: true, text: "party 🎉 tonight"},
+		testMessage{id: 9, roomID: "!room1:beeper.local", eventID: "$evt9", sender: "@me:beeper.local", ts: 1700000000800, hsOrder: 10, isMe: true, text: "sounds good 👍🏽"},
+		testMessage{id: 10, roomID: "!room1:beeper.local", eventID: "$evt10", sender: "@me:beeper.local", ts: 1700000000800, hsOrder: 10, isMe: true, text: "love it ❤️"},
+	)
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	ctx := context.Background()
+
+	for _, tt := range []struct {
+		query string
+		emoji []string
+		want  []int64
+	}{
+		{"", []string{"🎉"}, []int64{8}},
+		{"party", []string{"🎉"}, []int64{8}},
+		{"", []string{"👍"}, []int64{9}},
+		{"", []string{"👍🏻"}, nil},
+		{"", []string{"❤"}, []int64{10}},
+		{"", []string{"🎉", "👍"}, nil},
+	} {
+		results, err := store.SearchMessages(ctx, SearchOptions{Query: tt.query, Emoji: tt.emoji})
+		if err != nil {
+			t.Fatalf("search %v: %v", tt.emoji, err)
+		}
+		var got []int64
+		for _, r := range results {
+			got = append(got, r.Match.ID)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Fatalf("search %q %v: expected %v, got %v", tt.query, tt.emoji, tt.want, got)
+		}
+	}
+}
+
 func TestBridgeLookupDMName(t *testing.T) {
 	path := createTestDB(t, false)
 	bridgeRoot := createBridgeDB(t)
@@ -121,6 +335,86 @@ func TestBridgeLookupDMName(t *testing.T) {
 	if thread.DisplayName != "Bridge Name" {
 		t.Fatalf("expected bridge name, got %q", thread.DisplayName)
 	}
+	if thread.NameSource != NameSourceBridge {
+		t.Fatalf("expected name source %q, got %q", NameSourceBridge, thread.NameSource)
+	}
+	want := BridgeContact{Platform: "whatsapp", RemoteID: "user-1", Name: "Bridge Name"}
+	if thread.Bridge == nil || *thread.Bridge != want {
+		t.Fatalf("expected bridge contact %+v, got %+v", want, thread.Bridge)
+	}
+
+	group, err := store.GetThread(ctx, "!room1:beeper.local", false)
+	if err != nil {
+		t.Fatalf("get thread: %v", err)
+	}
+	if group.NameSource != NameSourceTitle || group.Bridge != nil {
+		t.Fatalf("expected titled thread without bridge contact, got %q %+v", group.NameSource, group.Bridge)
+	}
+
+	lookup, err := NewBridgeLookup(path, bridgeRoot)
+	if err != nil {
+		t.Fatalf("bridge lookup: %v", err)
+	}
+	defer func() { _ = lookup.Close() }()
+	names, err := lookup.LookupDMNames(ctx, []RoomRef{
+		{RoomID: "!room4:beeper.local"},
+		{RoomID: "!room2:beeper.local", AccountID: "whatsapp"},
+		{RoomID: "!room4:beeper.local", AccountID: "whatsapp"},
+	})
+	if err != nil {
+		t.Fatalf("lookup names: %v", err)
+	}
+	if !reflect.DeepEqual(names, map[string]string{"!room4:beeper.local": "Bridge Name"}) {
+		t.Fatalf("unexpected batch names: %v", names)
+	}
+	// Cached, including the miss.
+	if _, ok, err := lookup.LookupDMContact(ctx, "!room2:beeper.local", "whatsapp"); err != nil || ok {
+		t.Fatalf("expected cached miss, got %v %v", ok, err)
+	}
+}
+
+func TestBridgeLookupFallsThroughNamelessContacts(t *testing.T) {
+	bridgeRoot := createBridgeDB(t)
+	// The Signal bridge knows the room but not the user's name; WhatsApp does.
+	signalDir := filepath.Join(bridgeRoot, "local-signal")
+	if err := os.MkdirAll(signalDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	conn, err := sql.Open("sqlite3", filepath.Join(signalDir, "megabridge.db"))
+	if err != nil {
+		t.Fatalf("open bridge: %v", err)
+	}
+	for _, stmt := range []string{
+		"CREATE TABLE portal (mxid TEXT, other_user_id TEXT)",
+		"CREATE TABLE ghost (id TEXT, name TEXT)",
+		"INSERT INTO portal (mxid, other_user_id) VALUES ('!room4:beeper.local', 'sig-1'), ('!room9:beeper.local', 'sig-2')",
+		"INSERT INTO ghost (id, name) VALUES ('sig-1', '')",
+	} {
+		if _, err := conn.Exec(stmt); err != nil {
+			t.Fatalf("seed signal bridge: %v", err)
+		}
+	}
+	_ = conn.Close()
+
+	lookup, err := NewBridgeLookup("", bridgeRoot)
+	if err != nil {
+		t.Fatalf("bridge lookup: %v", err)
+	}
+	defer func() { _ = lookup.Close() }()
+	contacts, err := lookup.LookupDMContacts(context.Background(), []RoomRef{
+		{RoomID: "!room4:beeper.local", AccountID: "signal"},
+		{RoomID: "!room9:beeper.local", AccountID: "signal"},
+	})
+	if err != nil {
+		t.Fatalf("lookup contacts: %v", err)
+	}
+	want := map[string]BridgeContact{
+		"!room4:beeper.local": {Platform: "whatsapp", RemoteID: "user-1", Name: "Bridge Name"},
+		"!room9:beeper.local": {Platform: "signal", RemoteID: "sig-2"},
+	}
+	if !reflect.DeepEqual(contacts, want) {
+		t.Fatalf("expected %+v, got %+v", want, contacts)
+	}
 }
 
 func createTestDB(t *testing.T, withFTS bool) string {
@@ -191,43 +485,81 @@ func createTestDB(t *testing.T, withFTS bool) string {
 		t.Fatalf("insert participant: %v", err)
 	}
 
-	messages := []struct {
-		id      int
-		roomID  string
-		eventID string
-		sender  string
-		ts      int64
-		typeVal string
-		hsOrder int
-		isMe    int
-		message string
-		text    string
-	}{
-		{1, "!room1:beeper.local", "$evt1", "@alice:beeper.local", 1700000000100, "TEXT", 6, 0, `{"text":"hello"}`, "hello"},
-		{2, "!room1:beeper.local", "$evt2", "@alice:beeper.local", 1700000000200, "TEXT", 7, 0, `{"text":"christmas party"}`, "christmas party"},
-		{3, "!room1:beeper.local", "$evt3", "@alice:beeper.local", 1700000000300, "TEXT", 8, 0, `{"text":"see you"}`, "see you"},
-		{4, "!room2:beeper.local", "$evt4", "@bob:beeper.local", 1700000000400, "TEXT", 5, 0, `{"text":"archived"}`, "archived"},
-		{5, "!room3:beeper.local", "$evt5", "@eve:beeper.local", 1700000000500, "TEXT", 5, 0, `{"text":"fav"}`, "fav"},
-		{6, "!room4:beeper.local", "$evt6", "@bridge:beeper.local", 1700000000600, "TEXT", 1, 0, `{"text":"dm"}`, "dm"},
-		{7, "!room1:beeper.local", "$evt7", "@alice:beeper.local", 1700000000700, "TEXT", 9, 0, `{"text":"invoice due"}`, "invoice due"},
+	insertMessages(t, path,
+		testMessage{id: 1, roomID: "!room1:beeper.local", eventID: "$evt1", sender: "@alice:beeper.local", ts: 1700000000100, hsOrder: 6, text: "hello"},
+		testMessage{id: 2, roomID: "!room1:beeper.local", eventID: "$evt2", sender: "@alice:beeper.local", ts: 1700000000200, hsOrder: 7, text: "christmas party"},
+		testMessage{id: 3, roomID: "!room1:beeper.local", eventID: "$evt3", sender: "@alice:beeper.local", ts: 1700000000300, hsOrder: 8, text: "see you"},
+		testMessage{id: 4, roomID: "!room2:beeper.local", eventID: "$evt4", sender: "@bob:beeper.local", ts: 1700000000400, hsOrder: 5, text: "archived"},
+		testMessage{id: 5, roomID: "!room3:beeper.local", eventID: "$evt5", sender: "@eve:beeper.local", ts: 1700000000500, hsOrder: 5, text: "fav"},
+		testMessage{id: 6, roomID: "!room4:beeper.local", eventID: "$evt6", sender: "@bridge:beeper.local", ts: 1700000000600, hsOrder: 1, text: "dm"},
+		testMessage{id: 7, roomID: "!room1:beeper.local", eventID: "$evt7", sender: "@alice:beeper.local", ts: 1700000000700, hsOrder: 9, text: "invoice due"},
+	)
+
+	return path
+}
+
+// testMessage is a row of mx_room_messages for insertMessages. typ
+// defaults to TEXT and message to {"text": text}; an empty text is stored
+// as NULL, as Beeper does for events without a body.
+type testMessage struct {
+	id        int64 // 0 lets SQLite assign the next ID
+	roomID    string
+	eventID   string
+	sender    string
+	ts        int64
+	typ       string
+	hsOrder   int
+	isMe      bool
+	isDeleted bool
+	message   string
+	text      string
+}
+
+// insertMessages adds messages to the database at path, indexing their
+// text in mx_room_messages_fts when the database has it.
+func insertMessages(t *testing.T, path string, messages ...testMessage) {
+	t.Helper()
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
 	}
+	defer func() { _ = conn.Close() }()
 
+	var withFTS bool
+	if err := conn.QueryRow("SELECT COUNT(*) > 0 FROM sqlite_master WHERE name = 'mx_room_messages_fts'").Scan(&withFTS); err != nil {
+		t.Fatalf("check fts: %v", err)
+	}
+	tx, err := conn.Begin()
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
 	for _, msg := range messages {
-		_, err := conn.Exec(
-			"INSERT INTO mx_room_messages (id, roomID, eventID, senderContactID, timestamp, isDeleted, type, hsOrder, isSentByMe, message, text_content) VALUES (?, ?, ?, ?, ?, 0, ?, ?, ?, ?, ?)",
-			msg.id, msg.roomID, msg.eventID, msg.sender, msg.ts, msg.typeVal, msg.hsOrder, msg.isMe, msg.message, msg.text,
-		)
+		message := msg.message
+		if message == "" && msg.text != "" {
+			data, _ := json.Marshal(map[string]string{"text": msg.text})
+			message = string(data)
+		}
+		result, err := tx.Exec(`INSERT INTO mx_room_messages (id, roomID, eventID, senderContactID, timestamp, isDeleted, type, hsOrder, isSentByMe, message, text_content)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			sql.NullInt64{Int64: msg.id, Valid: msg.id != 0}, msg.roomID, msg.eventID, msg.sender, msg.ts, msg.isDeleted,
+			cmp.Or(msg.typ, "TEXT"), msg.hsOrder, msg.isMe,
+			sql.NullString{String: message, Valid: message != ""}, sql.NullString{String: msg.text, Valid: msg.text != ""})
 		if err != nil {
-			t.Fatalf("insert message: %v", err)
+			t.Fatalf("insert message %s: %v", msg.eventID, err)
 		}
-		if withFTS {
-			if _, err := conn.Exec("INSERT INTO mx_room_messages_fts (rowid, text_content) VALUES (?, ?)", msg.id, msg.text); err != nil {
+		if withFTS && msg.text != "" {
+			id, err := result.LastInsertId()
+			if err != nil {
+				t.Fatalf("message id: %v", err)
+			}
+			if _, err := tx.Exec("INSERT INTO mx_room_messages_fts (rowid, text_content) VALUES (?, ?)", id, msg.text); err != nil {
 				t.Fatalf("insert fts: %v", err)
 			}
 		}
 	}
-
-	return path
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
 }
 
 func createBridgeDB(t *testing.T) string {
@@ -269,3 +601,119 @@ func ids(threads []Thread) []string {
 	}
 	return list
 }
+
+func TestListMessagesBreaksTimestampTiesByID(t *testing.T) {
+	path := createTestDB(t, false)
+	for _, eventID := range []string{"$tie1", "$tie2", "$tie3"} {
+		insertMessages(t, path, testMessage{roomID: "!room4:beeper.local", eventID: eventID, sender: "@bob:beeper.local", ts: 1700000009000, hsOrder: 1, text: "same time"})
+	}
+
+	store, err := OpenWithOptions(path, StoreOptions{BridgeLookup: false})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	messages, err := store.ListMessages(context.Background(), MessageListOptions{ThreadID: "!room4:beeper.local", All: true, Format: FormatPlain})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	ids := []int64{}
+	for _, msg := range messages {
+		if msg.Timestamp.UnixMilli() == 1700000009000 {
+			ids = append(ids, msg.ID)
+		}
+	}
+	if len(ids) != 3 || ids[0] < ids[1] || ids[1] < ids[2] {
+		t.Fatalf("expected tied messages newest ID first, got %v", ids)
+	}
+}
+
+func BenchmarkListThreads(b *testing.B) {
+	dir := b.TempDir()
+	if _, err := synth.Generate(context.Background(), synth.Options{Dir: dir, Threads: 500, Messages: 50000, Seed: 1}); err != nil {
+		b.Fatalf("generate: %v", err)
+	}
+	store, err := OpenWithOptions(filepath.Join(dir, "index.db"), StoreOptions{})
+	if err != nil {
+		b.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	ctx := context.Background()
+
+	for _, sort := range []ThreadSort{SortActivity, SortMessages} {
+		b.Run(string(sort), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := store.ListThreads(ctx, ThreadListOptions{All: true, Sort: sort}); err != nil {
+					b.Fatalf("list threads: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestBridgeDiscoveryIsDeferred(t *testing.T) {
+	path := createTestDB(t, false)
+	bridgeRoot := createBridgeDB(t)
+	bridgeDir := filepath.Join(bridgeRoot, "local-whatsapp")
+	hidden := filepath.Join(t.TempDir(), "local-whatsapp")
+	if err := os.Rename(bridgeDir, hidden); err != nil {
+		t.Fatalf("hide bridge: %v", err)
+	}
+
+	store, err := OpenWithOptions(path, StoreOptions{BridgeLookup: true, BridgeRoot: bridgeRoot})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	// A bridge that appears after Open is still found: Open scanned nothing.
+	if err := os.Rename(hidden, bridgeDir); err != nil {
+		t.Fatalf("restore bridge: %v", err)
+	}
+	thread, err := store.GetThread(context.Background(), "!room4:beeper.local", false)
+	if err != nil {
+		t.Fatalf("get thread: %v", err)
+	}
+	if thread.DisplayName != "Bridge Name" || len(store.BridgeDBs()) != 1 {
+		t.Fatalf("expected the bridge to be discovered on first use, got %q and %v", thread.DisplayName, store.BridgeDBs())
+	}
+}
+
+// BenchmarkOpen compares opening a store, which no longer scans the bridge
+// directory, with opening it and discovering the bridges right away as
+// Open used to.
+func BenchmarkOpen(b *testing.B) {
+	dir := b.TempDir()
+	if _, err := synth.Generate(context.Background(), synth.Options{Dir: dir, Threads: 50, Messages: 1000, Seed: 1}); err != nil {
+		b.Fatalf("generate: %v", err)
+	}
+	for i := 0; i < 20; i++ {
+		bridgeDir := filepath.Join(dir, fmt.Sprintf("local-bridge%02d", i))
+		if err := os.MkdirAll(bridgeDir, 0o755); err != nil {
+			b.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(bridgeDir, "megabridge.db"), nil, 0o644); err != nil {
+			b.Fatalf("write bridge: %v", err)
+		}
+	}
+	path := filepath.Join(dir, "index.db")
+
+	for _, tt := range []struct {
+		name  string
+		eager bool
+	}{{"deferred", false}, {"eager", true}} {
+		b.Run(tt.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				store, err := OpenWithOptions(path, StoreOptions{BridgeLookup: true})
+				if err != nil {
+					b.Fatalf("open store: %v", err)
+				}
+				if tt.eager && len(store.BridgeDBs()) != 20 {
+					b.Fatalf("expected 20 bridges, got %d", len(store.BridgeDBs()))
+				}
+				_ = store.Close()
+			}
+		})
+	}
+}
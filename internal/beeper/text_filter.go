@@ -0,0 +1,35 @@
+package beeper
+
+import "strings"
+
+// messageText is the SQL expression for a message's text: $.text, else
+// text_content.
+const messageText = "COALESCE(json_extract(m.message,'$.text'), m.text_content, '')"
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// textTermsFilter returns the condition (with a leading AND) keeping
+// messages whose text contains at least one include term and no exclude
+// term. Terms match as substrings, case-insensitively for ASCII; empty
+// terms are ignored.
+func textTermsFilter(include []string, exclude []string) (string, []any) {
+	query := strings.Builder{}
+	args := []any{}
+	matches := []string{}
+	for _, term := range include {
+		if term = strings.TrimSpace(term); term != "" {
+			matches = append(matches, messageText+` LIKE ? ESCAPE '\'`)
+			args = append(args, "%"+likeEscaper.Replace(term)+"%")
+		}
+	}
+	if len(matches) > 0 {
+		query.WriteString(" AND (" + strings.Join(matches, " OR ") + ")")
+	}
+	for _, term := range exclude {
+		if term = strings.TrimSpace(term); term != "" {
+			query.WriteString(" AND " + messageText + ` NOT LIKE ? ESCAPE '\'`)
+			args = append(args, "%"+likeEscaper.Replace(term)+"%")
+		}
+	}
+	return query.String(), args
+}
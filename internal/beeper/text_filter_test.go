@@ -0,0 +1,48 @@
+package beeper
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestListMessagesTextAndSenderFilters(t *testing.T) {
+	path := createTestDB(t, false)
+	insertMessages(t, path,
+		testMessage{roomID: "!room1:beeper.local", eventID: "$sale", sender: "@bob:beeper.local", ts: 1700000001000, hsOrder: 10, text: "50% off"},
+		testMessage{roomID: "!room1:beeper.local", eventID: "$items", sender: "@alice:beeper.local", ts: 1700000002000, hsOrder: 10, text: "500 items"},
+	)
+
+	store, err := OpenWithOptions(path, StoreOptions{BridgeLookup: false})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	tests := []struct {
+		name string
+		opts MessageListOptions
+		want []string
+	}{
+		{"include any", MessageListOptions{Include: []string{"PARTY", "invoice"}}, []string{"$evt7", "$evt2"}},
+		{"exclude", MessageListOptions{Exclude: []string{"you", "50", " "}}, []string{"$evt7", "$evt2", "$evt1"}},
+		{"sender", MessageListOptions{SenderIDs: []string{"@bob:beeper.local"}}, []string{"$sale"}},
+		{"wildcards are literal", MessageListOptions{Include: []string{"50%"}}, []string{"$sale"}},
+		{"combined", MessageListOptions{SenderIDs: []string{"@alice:beeper.local"}, Include: []string{"e"}, Exclude: []string{"items"}}, []string{"$evt7", "$evt3", "$evt1"}},
+	}
+	for _, tt := range tests {
+		tt.opts.ThreadID = "!room1:beeper.local"
+		tt.opts.All = true
+		messages, err := store.ListMessages(context.Background(), tt.opts)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		got := []string{}
+		for _, msg := range messages {
+			got = append(got, msg.EventID)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Fatalf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
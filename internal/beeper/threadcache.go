@@ -0,0 +1,169 @@
+package beeper
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+)
+
+// ThreadSummary is what ListThreads computes per thread from
+// mx_room_messages, plus the DM name from the bridge databases, so it can
+// be cached (see ThreadCache). Times are Unix milliseconds.
+type ThreadSummary struct {
+	ThreadID        string
+	LastMessageTime sql.NullInt64
+	LatestHsOrder   sql.NullInt64
+	TotalMessages   int
+	// BridgeName is set for DMs without a title or name whose bridge
+	// database knows the other side.
+	BridgeName string
+}
+
+// ThreadCache is a snapshot of thread summaries covering every message row
+// up to LastMessageID. With ThreadListOptions.Cache set, ListThreads reads
+// the summaries instead of aggregating all of mx_room_messages and only
+// aggregates the rows added since; cached bridge names save the bridge
+// lookups. Rows that change type or disappear after the snapshot are not
+// noticed until it is rebuilt.
+type ThreadCache struct {
+	LastMessageID int64
+	Summaries     []ThreadSummary
+}
+
+// cachedRoomStats replaces the room_stats CTE of queryThreads: the cached
+// summaries, passed as JSON, merged with the rows after the snapshot.
+const cachedRoomStats = `WITH room_stats AS (
+			SELECT roomID, MAX(lastMessageTime) AS lastMessageTime, MAX(latestHsOrder) AS latestHsOrder,
+				SUM(totalMessages) AS totalMessages
+			FROM (
+				SELECT json_extract(value, '$.id') AS roomID, json_extract(value, '$.last') AS lastMessageTime,
+					json_extract(value, '$.hs') AS latestHsOrder, json_extract(value, '$.total') AS totalMessages
+				FROM json_each(?)
+				UNION ALL
+				SELECT roomID,
+					MAX(CASE WHEN type NOT IN ('HIDDEN','REACTION') THEN timestamp END),
+					MAX(CASE WHEN type != 'HIDDEN' THEN hsOrder END),
+					SUM(type NOT IN ('HIDDEN','REACTION'))
+				FROM mx_room_messages
+				WHERE id > ?
+				GROUP BY roomID
+			)
+			GROUP BY roomID
+		)`
+
+// cachedSummary is the JSON form of a ThreadSummary inside cachedRoomStats.
+type cachedSummary struct {
+	ID    string `json:"id"`
+	Last  *int64 `json:"last,omitempty"`
+	Hs    *int64 `json:"hs,omitempty"`
+	Total int    `json:"total"`
+}
+
+// roomStatsArgs returns the arguments of cachedRoomStats.
+func (c *ThreadCache) roomStatsArgs() ([]any, error) {
+	summaries := make([]cachedSummary, 0, len(c.Summaries))
+	for _, summary := range c.Summaries {
+		cached := cachedSummary{ID: summary.ThreadID, Total: summary.TotalMessages}
+		if summary.LastMessageTime.Valid {
+			cached.Last = &summary.LastMessageTime.Int64
+		}
+		if summary.LatestHsOrder.Valid {
+			cached.Hs = &summary.LatestHsOrder.Int64
+		}
+		summaries = append(summaries, cached)
+	}
+	data, err := json.Marshal(summaries)
+	if err != nil {
+		return nil, err
+	}
+	return []any{string(data), c.LastMessageID}, nil
+}
+
+// bridgeNames returns the cached bridge names by thread ID.
+func (c *ThreadCache) bridgeNames() map[string]string {
+	names := map[string]string{}
+	if c == nil {
+		return names
+	}
+	for _, summary := range c.Summaries {
+		if summary.BridgeName != "" {
+			names[summary.ThreadID] = summary.BridgeName
+		}
+	}
+	return names
+}
+
+// MaxMessageID returns the newest message row ID, or 0 without messages.
+// A ThreadCache whose LastMessageID is greater was built from another
+// database, or this one lost rows since.
+func (s *Store) MaxMessageID(ctx context.Context) (int64, error) {
+	var lastID sql.NullInt64
+	if err := s.db.QueryRowContext(ctx, "SELECT MAX(id) FROM mx_room_messages").Scan(&lastID); err != nil {
+		return 0, err
+	}
+	return lastID.Int64, nil
+}
+
+// SummarizeThreads aggregates the message rows with IDs greater than
+// afterID by thread, as ListThreads would, and looks up bridge names for
+// the DMs among them. lastID is the newest row included; pass it as the
+// next afterID to pick up only later rows. Summaries of a later call are
+// merged into earlier ones by taking the maximum times and adding counts.
+func (s *Store) SummarizeThreads(ctx context.Context, afterID int64) ([]ThreadSummary, int64, error) {
+	lastID, err := s.MaxMessageID(ctx)
+	if err != nil {
+		return nil, afterID, err
+	}
+	if lastID <= afterID {
+		return []ThreadSummary{}, afterID, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT m.roomID,
+			MAX(CASE WHEN m.type NOT IN ('HIDDEN','REACTION') THEN m.timestamp END),
+			MAX(CASE WHEN m.type != 'HIDDEN' THEN m.hsOrder END),
+			SUM(m.type NOT IN ('HIDDEN','REACTION')),
+			t.accountID,
+			COALESCE(json_extract(t.thread, '$.title'), ''),
+			COALESCE(json_extract(t.thread, '$.name'), ''),
+			COALESCE(json_extract(t.thread, '$.type'), '')
+		FROM mx_room_messages m
+		LEFT JOIN threads t ON t.threadID = m.roomID
+		WHERE m.id > ? AND m.id <= ?
+		GROUP BY m.roomID
+		ORDER BY m.roomID`, afterID, lastID)
+	if err != nil {
+		return nil, afterID, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	summaries := []ThreadSummary{}
+	dms := []RoomRef{}
+	for rows.Next() {
+		var summary ThreadSummary
+		var accountID sql.NullString
+		var title, name, threadType string
+		if err := rows.Scan(&summary.ThreadID, &summary.LastMessageTime, &summary.LatestHsOrder, &summary.TotalMessages,
+			&accountID, &title, &name, &threadType); err != nil {
+			return nil, afterID, err
+		}
+		if strings.TrimSpace(title) == "" && strings.TrimSpace(name) == "" && (threadType == "single" || threadType == "dm") {
+			dms = append(dms, RoomRef{RoomID: summary.ThreadID, AccountID: accountID.String})
+		}
+		summaries = append(summaries, summary)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, afterID, err
+	}
+
+	if bridge := s.bridgeLookup(); bridge != nil && len(dms) > 0 {
+		names, err := bridge.LookupDMNames(ctx, dms)
+		if err != nil {
+			return nil, afterID, err
+		}
+		for i := range summaries {
+			summaries[i].BridgeName = names[summaries[i].ThreadID]
+		}
+	}
+	return summaries, lastID, nil
+}
@@ -0,0 +1,59 @@
+package beeper
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestListThreadsFromCache(t *testing.T) {
+	path := createTestDB(t, false)
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	ctx := context.Background()
+
+	summaries, lastID, err := store.SummarizeThreads(ctx, 0)
+	if err != nil {
+		t.Fatalf("summarize: %v", err)
+	}
+	if len(summaries) == 0 || lastID == 0 {
+		t.Fatalf("expected summaries up to a message ID, got %d up to %d", len(summaries), lastID)
+	}
+	cache := &ThreadCache{LastMessageID: lastID, Summaries: summaries}
+
+	// Messages after the snapshot are aggregated live.
+	insertMessages(t, path, testMessage{roomID: "!room4:beeper.local", eventID: "$late", sender: "@bob:beeper.local", ts: 1800000000000, hsOrder: 99, text: "late"})
+
+	for _, opts := range []ThreadListOptions{
+		{All: true, WithStats: true},
+		{Label: LabelInbox, WithStats: true, Sort: SortMessages},
+		{Label: LabelArchive, Limit: 1},
+	} {
+		want, err := store.ListThreads(ctx, opts)
+		if err != nil {
+			t.Fatalf("list: %v", err)
+		}
+		opts.Cache = cache
+		got, err := store.ListThreads(ctx, opts)
+		if err != nil {
+			t.Fatalf("list from cache: %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("cached listing differs:\n got %+v\nwant %+v", got, want)
+		}
+	}
+
+	later, laterID, err := store.SummarizeThreads(ctx, lastID)
+	if err != nil {
+		t.Fatalf("summarize later rows: %v", err)
+	}
+	if laterID <= lastID || len(later) != 1 || later[0].ThreadID != "!room4:beeper.local" || later[0].TotalMessages != 1 {
+		t.Fatalf("unexpected later summaries up to %d: %+v", laterID, later)
+	}
+	if maxID, err := store.MaxMessageID(ctx); err != nil || maxID != laterID {
+		t.Fatalf("expected MaxMessageID %d, got %d %v", laterID, maxID, err)
+	}
+}
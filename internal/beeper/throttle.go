@@ -0,0 +1,85 @@
+package beeper
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+)
+
+// Nice-mode timing. Watch polls at most every NiceWatchInterval and, while
+// Beeper keeps writing, doubles its wait up to niceMaxBackoff.
+const (
+	NiceWatchInterval = 10 * time.Second
+	niceMaxBackoff    = time.Minute
+	// niceSettle is how often Acquire rechecks a busy database, and
+	// niceMaxWait how long it defers to Beeper before querying anyway.
+	niceSettle  = 250 * time.Millisecond
+	niceMaxWait = 5 * time.Second
+)
+
+// Throttle keeps long-running commands out of Beeper Desktop's way: it lets
+// one query run at a time and holds queries back while Beeper is writing.
+// Beeper's writes show up as changes to the database's write-ahead log
+// (index.db-wal), which can be checked without touching SQLite.
+type Throttle struct {
+	slot chan struct{}
+
+	mu      sync.Mutex
+	walPath string
+	size    int64
+	modTime time.Time
+	seen    bool
+}
+
+// NewThrottle returns a throttle watching the store's write-ahead log.
+func (s *Store) NewThrottle() *Throttle {
+	return newThrottle(s.path + "-wal")
+}
+
+func newThrottle(walPath string) *Throttle {
+	return &Throttle{slot: make(chan struct{}, 1), walPath: walPath}
+}
+
+// Busy reports whether the write-ahead log changed since the previous call.
+// The first call, and a database without a WAL, report idle.
+func (t *Throttle) Busy() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	info, err := os.Stat(t.walPath)
+	if err != nil {
+		t.seen = false
+		return false
+	}
+	changed := t.seen && (info.Size() != t.size || !info.ModTime().Equal(t.modTime))
+	t.size, t.modTime, t.seen = info.Size(), info.ModTime(), true
+	return changed
+}
+
+// Acquire waits for the query slot and then for Beeper to stop writing, for
+// at most niceMaxWait, so a steady stream of writes delays queries without
+// starving them. Call the returned function when the query is done.
+func (t *Throttle) Acquire(ctx context.Context) (func(), error) {
+	select {
+	case t.slot <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	release := func() { <-t.slot }
+
+	deadline := time.Now().Add(niceMaxWait)
+	t.Busy()
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		case <-time.After(niceSettle):
+		}
+		if !t.Busy() {
+			break
+		}
+	}
+	return release, nil
+}
@@ -0,0 +1,54 @@
+package beeper
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestThrottleBusy(t *testing.T) {
+	wal := filepath.Join(t.TempDir(), "index.db-wal")
+	throttle := newThrottle(wal)
+	if throttle.Busy() {
+		t.Fatalf("expected idle without a WAL")
+	}
+	if err := os.WriteFile(wal, []byte("a"), 0o600); err != nil {
+		t.Fatalf("write wal: %v", err)
+	}
+	if throttle.Busy() {
+		t.Fatalf("expected the first look at the WAL to be idle")
+	}
+	if throttle.Busy() {
+		t.Fatalf("expected an unchanged WAL to be idle")
+	}
+	if err := os.WriteFile(wal, []byte("ab"), 0o600); err != nil {
+		t.Fatalf("write wal: %v", err)
+	}
+	if !throttle.Busy() {
+		t.Fatalf("expected a growing WAL to be busy")
+	}
+}
+
+func TestThrottleAcquire(t *testing.T) {
+	throttle := newThrottle(filepath.Join(t.TempDir(), "index.db-wal"))
+	release, err := throttle.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	// A second query waits for the first.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := throttle.Acquire(ctx); err == nil {
+		t.Fatalf("expected the second acquire to wait for the slot")
+	}
+
+	release()
+	second, err := throttle.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire after release: %v", err)
+	}
+	second()
+}
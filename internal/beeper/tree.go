@@ -0,0 +1,145 @@
+package beeper
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// MessageNode is a message together with the messages replying to it.
+type MessageNode struct {
+	Message
+	Depth   int            `json:"depth"`
+	Replies []*MessageNode `json:"replies,omitempty"`
+}
+
+// parseRelation returns the Matrix thread root (m.thread relation) and the
+// replied-to event of a raw message payload. In a thread, clients add a
+// fallback m.in_reply_to pointing at the latest thread event for clients
+// without thread support; such fallbacks are not reported as replies.
+// Beeper's linkedMessageID counts as a reply, as in engagement counts.
+func parseRelation(raw string) (threadRoot string, replyTo string) {
+	if raw == "" {
+		return "", ""
+	}
+	var payload struct {
+		RelatesTo *struct {
+			RelType       string `json:"rel_type"`
+			EventID       string `json:"event_id"`
+			IsFallingBack bool   `json:"is_falling_back"`
+			InReplyTo     *struct {
+				EventID string `json:"event_id"`
+			} `json:"m.in_reply_to"`
+		} `json:"m.relates_to"`
+		LinkedMessageID string `json:"linkedMessageID"`
+	}
+	if err := json.Unmarshal([]byte(raw), &payload); err != nil {
+		return "", ""
+	}
+	if rel := payload.RelatesTo; rel != nil {
+		if rel.RelType == "m.thread" {
+			threadRoot = rel.EventID
+		}
+		if rel.InReplyTo != nil && !(threadRoot != "" && rel.IsFallingBack) {
+			replyTo = rel.InReplyTo.EventID
+		}
+	}
+	if replyTo == "" && threadRoot == "" {
+		replyTo = payload.LinkedMessageID
+	}
+	return threadRoot, replyTo
+}
+
+// BuildMessageTree arranges messages (oldest first) into reply trees: a
+// message hangs below the event it replies to, or else below its thread
+// root. Messages whose parent is not among messages (or comes later) are
+// roots. Roots and replies keep chronological order.
+func BuildMessageTree(messages []Message) []*MessageNode {
+	byEvent := map[string]*MessageNode{}
+	roots := []*MessageNode{}
+	for _, msg := range messages {
+		node := &MessageNode{Message: msg}
+		parentID := msg.ReplyToEventID
+		if parentID == "" {
+			parentID = msg.ThreadRootEventID
+		}
+		if parent, ok := byEvent[parentID]; ok && parentID != "" {
+			node.Depth = parent.Depth + 1
+			parent.Replies = append(parent.Replies, node)
+		} else {
+			roots = append(roots, node)
+		}
+		if msg.EventID != "" {
+			byEvent[msg.EventID] = node
+		}
+	}
+	return roots
+}
+
+// ListReplies returns the message with eventID and, nested below it, every
+// message that replies to it directly or through other replies, or that
+// belongs to the Matrix thread it starts. Replies are looked for in the
+// same chat from the message's time on.
+func (s *Store) ListReplies(ctx context.Context, eventID string, format MessageFormat) (*MessageNode, error) {
+	if eventID == "" {
+		return nil, errors.New("event ID is required")
+	}
+	var roomID string
+	var ts int64
+	err := s.db.QueryRowContext(ctx, "SELECT roomID, timestamp FROM mx_room_messages WHERE eventID = ?", eventID).Scan(&roomID, &ts)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrMessageNotFound, eventID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	after := unixMillis(ts)
+	messages, err := s.ListMessages(ctx, MessageListOptions{
+		ThreadID: roomID,
+		All:      true,
+		After:    &after,
+		Format:   format,
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.Reverse(messages)
+	node := MessageSubtree(BuildMessageTree(messages), eventID)
+	if node == nil {
+		return nil, fmt.Errorf("event %s is not a visible message: %w", eventID, ErrMessageNotFound)
+	}
+	return node, nil
+}
+
+// MessageSubtree returns the node for eventID within trees, re-rooted so
+// its Depth is 0, or nil if no message has that event ID.
+func MessageSubtree(trees []*MessageNode, eventID string) *MessageNode {
+	node := findMessageNode(trees, eventID)
+	if node != nil {
+		setDepth(node, 0)
+	}
+	return node
+}
+
+func findMessageNode(trees []*MessageNode, eventID string) *MessageNode {
+	for _, node := range trees {
+		if node.EventID == eventID {
+			return node
+		}
+		if found := findMessageNode(node.Replies, eventID); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func setDepth(node *MessageNode, depth int) {
+	node.Depth = depth
+	for _, reply := range node.Replies {
+		setDepth(reply, depth+1)
+	}
+}
@@ -0,0 +1,120 @@
+package beeper
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseRelation(t *testing.T) {
+	tests := []struct {
+		raw           string
+		root, replyTo string
+	}{
+		{`{"text":"hi"}`, "", ""},
+		{`{"m.relates_to":{"m.in_reply_to":{"event_id":"$a"}}}`, "", "$a"},
+		{`{"m.relates_to":{"rel_type":"m.thread","event_id":"$root","is_falling_back":true,"m.in_reply_to":{"event_id":"$latest"}}}`, "$root", ""},
+		{`{"m.relates_to":{"rel_type":"m.thread","event_id":"$root","m.in_reply_to":{"event_id":"$b"}}}`, "$root", "$b"},
+		{`{"m.relates_to":{"rel_type":"m.replace","event_id":"$edited"}}`, "", ""},
+		{`{"linkedMessageID":"$linked"}`, "", "$linked"},
+		{`not json`, "", ""},
+	}
+	for _, tt := range tests {
+		root, replyTo := parseRelation(tt.raw)
+		if root != tt.root || replyTo != tt.replyTo {
+			t.Fatalf("%s: got root=%q replyTo=%q", tt.raw, root, replyTo)
+		}
+	}
+}
+
+func TestBuildMessageTree(t *testing.T) {
+	messages := []Message{
+		{EventID: "$root"},
+		{EventID: "$other"},
+		{EventID: "$t1", ThreadRootEventID: "$root"},
+		{EventID: "$r1", ThreadRootEventID: "$root", ReplyToEventID: "$t1"},
+		{EventID: "$reply", ReplyToEventID: "$other"},
+		{EventID: "$orphan", ReplyToEventID: "$missing"},
+		{EventID: "$early", ReplyToEventID: "$late"},
+		{EventID: "$late"},
+	}
+	trees := BuildMessageTree(messages)
+
+	var flatten func(nodes []*MessageNode) []string
+	flatten = func(nodes []*MessageNode) []string {
+		out := []string{}
+		for _, n := range nodes {
+			out = append(out, fmt.Sprintf("%d%s", n.Depth, n.EventID))
+			out = append(out, flatten(n.Replies)...)
+		}
+		return out
+	}
+	got := strings.Join(flatten(trees), " ")
+	want := "0$root 1$t1 2$r1 0$other 1$reply 0$orphan 0$early 0$late"
+	if got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+
+	sub := MessageSubtree(trees, "$t1")
+	if sub == nil || sub.Depth != 0 || len(sub.Replies) != 1 || sub.Replies[0].Depth != 1 {
+		t.Fatalf("unexpected subtree: %+v", sub)
+	}
+	if MessageSubtree(trees, "$nope") != nil {
+		t.Fatal("expected nil for unknown event")
+	}
+}
+
+func TestListMessagesSetsRelations(t *testing.T) {
+	path := createTestDB(t, false)
+	insertMessages(t, path, testMessage{roomID: "!room1:beeper.local", eventID: "$thr", sender: "@bob:beeper.local", ts: 1700000000800, hsOrder: 10, text: "in thread",
+		message: `{"text":"in thread","m.relates_to":{"rel_type":"m.thread","event_id":"$evt1","is_falling_back":true,"m.in_reply_to":{"event_id":"$evt1"}}}`})
+
+	store, err := OpenWithOptions(path, StoreOptions{BridgeLookup: false})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	messages, err := store.ListMessages(context.Background(), MessageListOptions{ThreadID: "!room1:beeper.local", Limit: 1, Format: FormatPlain})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(messages) != 1 || messages[0].ThreadRootEventID != "$evt1" || messages[0].ReplyToEventID != "" {
+		t.Fatalf("unexpected relations: %+v", messages)
+	}
+}
+
+func TestListReplies(t *testing.T) {
+	path := createTestDB(t, false)
+	insertMessages(t, path,
+		testMessage{roomID: "!room1:beeper.local", eventID: "$r1", sender: "@bob:beeper.local", ts: 1700000000800, hsOrder: 20,
+			message: `{"text":"reply","m.relates_to":{"m.in_reply_to":{"event_id":"$evt2"}}}`},
+		testMessage{roomID: "!room1:beeper.local", eventID: "$r2", sender: "@bob:beeper.local", ts: 1700000000900, hsOrder: 21,
+			message: `{"text":"reply to reply","m.relates_to":{"m.in_reply_to":{"event_id":"$r1"}}}`},
+		testMessage{roomID: "!room1:beeper.local", eventID: "$r3", sender: "@bob:beeper.local", ts: 1700000001000, hsOrder: 22,
+			message: `{"text":"elsewhere","m.relates_to":{"m.in_reply_to":{"event_id":"$evt3"}}}`},
+	)
+
+	store, err := OpenWithOptions(path, StoreOptions{BridgeLookup: false})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+	ctx := context.Background()
+
+	node, err := store.ListReplies(ctx, "$evt2", FormatPlain)
+	if err != nil {
+		t.Fatalf("list replies: %v", err)
+	}
+	if node.Text != "christmas party" || len(node.Replies) != 1 || node.Replies[0].EventID != "$r1" {
+		t.Fatalf("unexpected replies: %+v", node)
+	}
+	if nested := node.Replies[0].Replies; len(nested) != 1 || nested[0].EventID != "$r2" || nested[0].Depth != 2 {
+		t.Fatalf("expected $r2 nested below $r1, got %+v", nested)
+	}
+
+	if _, err := store.ListReplies(ctx, "$missing", FormatPlain); err == nil {
+		t.Fatal("expected an error for an unknown event")
+	}
+}
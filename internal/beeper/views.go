@@ -0,0 +1,290 @@
+package beeper
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ViewDialect selects the SQL flavour emitted by GenerateViews.
+type ViewDialect string
+
+const (
+	// DialectDuckDB targets DuckDB with the database attached via the sqlite extension.
+	DialectDuckDB ViewDialect = "duckdb"
+	// DialectSQLite targets SQLite itself (for example a copy of index.db).
+	DialectSQLite ViewDialect = "sqlite"
+)
+
+// ViewOptions controls view generation.
+type ViewOptions struct {
+	Dialect ViewDialect
+	// Catalog prefixes source tables, e.g. "beeper" after
+	// `ATTACH 'index.db' AS beeper (TYPE sqlite)` in DuckDB.
+	Catalog string
+}
+
+// ViewNames lists the generated views in creation order.
+var ViewNames = []string{"beeper_participants", "beeper_thread_state", "beeper_threads", "beeper_messages"}
+
+// GenerateViews returns CREATE VIEW statements that flatten Beeper's JSON
+// columns into typed columns using the same rules as the store: display
+// names, archived/favourite/unread flags, sender names, and plain message
+// text. Bridge (megabridge) DM name lookups are not reproduced.
+func GenerateViews(opts ViewOptions) (string, error) {
+	d := viewDialect{kind: opts.Dialect}
+	switch opts.Dialect {
+	case DialectDuckDB, DialectSQLite:
+	default:
+		return "", fmt.Errorf("unknown dialect %q (expected duckdb or sqlite)", opts.Dialect)
+	}
+	table := func(name string) string {
+		if opts.Catalog == "" {
+			return name
+		}
+		return opts.Catalog + "." + name
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "-- Generated by beeper-cli (%s dialect).\n", opts.Dialect)
+	if opts.Dialect == DialectDuckDB {
+		b.WriteString("-- Requires the sqlite and json extensions, e.g.:\n")
+		fmt.Fprintf(&b, "--   ATTACH 'index.db' AS %s (TYPE sqlite, READ_ONLY);\n", catalogOrDefault(opts.Catalog))
+	}
+	b.WriteString("\n")
+
+	// Participant names fall back from full name to nickname to ID.
+	fmt.Fprintf(&b, `DROP VIEW IF EXISTS beeper_participants;
+CREATE VIEW beeper_participants AS
+SELECT
+  p.room_id AS thread_id,
+  p.account_id AS account_id,
+  p.id AS participant_id,
+  COALESCE(NULLIF(TRIM(p.full_name), ''), NULLIF(TRIM(p.nickname), ''), p.id) AS name,
+  COALESCE(p.is_self, 0) <> 0 AS is_self,
+  ROW_NUMBER() OVER (PARTITION BY p.room_id ORDER BY p.rowid) AS position
+FROM %s p;
+
+`, table("participants"))
+
+	// Raw thread state before archived/display-name rules are applied.
+	fmt.Fprintf(&b, `DROP VIEW IF EXISTS beeper_thread_state;
+CREATE VIEW beeper_thread_state AS
+SELECT
+  t.threadID AS thread_id,
+  t.accountID AS account_id,
+  t.timestamp AS thread_timestamp_ms,
+  NULLIF(TRIM(%s), '') AS title,
+  NULLIF(TRIM(%s), '') AS name,
+  NULLIF(TRIM(%s), '') AS type,
+  %s AS is_unread,
+  %s AS is_marked_unread,
+  %s AS is_low_priority,
+  COALESCE(%s, 0) AS unread_count,
+  COALESCE(%s, 0) AS unread_mentions,
+  %s AS archived_upto_raw,
+  %s AS archived_upto,
+  %s AS archived_upto_order,
+  %s AS tags,
+  b.lastOpenTime AS last_open_ms,
+  (SELECT MAX(m.timestamp) FROM %s m WHERE m.roomID = t.threadID AND m.type NOT IN ('HIDDEN','REACTION')) AS last_message_ms,
+  (SELECT MAX(m.hsOrder) FROM %s m WHERE m.roomID = t.threadID AND m.type <> 'HIDDEN') AS latest_hs_order,
+  (SELECT COUNT(*) FROM %s m WHERE m.roomID = t.threadID AND m.type NOT IN ('HIDDEN','REACTION')) AS total_messages
+FROM %s t
+LEFT JOIN %s b ON t.threadID = b.id;
+
+`,
+		d.jsonText("t.thread", "$.title"),
+		d.jsonText("t.thread", "$.name"),
+		d.jsonText("t.thread", "$.type"),
+		d.jsonBool("t.thread", "$.isUnread"),
+		d.jsonBool("t.thread", "$.isMarkedUnread"),
+		d.jsonBool("t.thread", "$.isLowPriority"),
+		d.jsonInt("t.thread", "$.unreadCount"),
+		d.jsonInt("t.thread", "$.unreadMentionsCount"),
+		d.jsonText("t.thread", "$.extra.isArchivedUpto"),
+		d.archivedValue(d.jsonText("t.thread", "$.extra.isArchivedUpto")),
+		d.archivedValue(d.jsonText("t.thread", "$.extra.isArchivedUpToOrder")),
+		d.jsonText("t.thread", "$.extra.tags"),
+		table("mx_room_messages"),
+		table("mx_room_messages"),
+		table("mx_room_messages"),
+		table("threads"),
+		table("breadcrumbs"),
+	)
+
+	fmt.Fprintf(&b, `DROP VIEW IF EXISTS beeper_threads;
+CREATE VIEW beeper_threads AS
+SELECT
+  thread_id,
+  account_id,
+  display_name,
+  title,
+  name,
+  type,
+  is_unread,
+  is_marked_unread,
+  is_unread OR is_marked_unread AS in_unread,
+  is_low_priority,
+  is_archived,
+  is_favourite,
+  is_favourite OR NOT is_archived AS in_inbox,
+  is_archived AND NOT is_favourite AS in_archive,
+  unread_count,
+  unread_mentions,
+  total_messages,
+  tags,
+  %s AS last_open_at,
+  %s AS last_message_at,
+  %s AS last_activity_at
+FROM (
+  SELECT
+    s.*,
+    COALESCE(
+      s.title,
+      s.name,
+      CASE
+        WHEN s.type IN ('single','dm') THEN (
+          SELECT p.name FROM beeper_participants p
+          WHERE p.thread_id = s.thread_id AND NOT p.is_self
+          ORDER BY p.position LIMIT 1)
+        ELSE (
+          SELECT CASE WHEN COUNT(*) > 3 THEN %s || ' +' || CAST(COUNT(*) - 3 AS VARCHAR) ELSE %s END
+          FROM beeper_participants p
+          WHERE p.thread_id = s.thread_id AND NOT p.is_self)
+      END,
+      '(unknown)'
+    ) AS display_name,
+    CASE
+      WHEN s.archived_upto_order IS NOT NULL AND s.latest_hs_order IS NOT NULL
+        THEN s.latest_hs_order <= s.archived_upto_order
+      WHEN s.archived_upto IS NOT NULL AND s.archived_upto > 1000000000000
+        THEN s.last_message_ms IS NULL OR s.last_message_ms <= s.archived_upto
+      WHEN s.archived_upto IS NOT NULL
+        THEN s.latest_hs_order IS NULL OR s.latest_hs_order <= s.archived_upto
+      ELSE COALESCE(TRIM(s.archived_upto_raw), '') <> ''
+    END AS is_archived,
+    %s AS is_favourite
+  FROM beeper_thread_state s
+) x;
+
+`,
+		d.timestamp("last_open_ms"),
+		d.timestamp("last_message_ms"),
+		d.timestamp(d.greatest("last_message_ms", "last_open_ms", "thread_timestamp_ms")),
+		d.firstNames("s.thread_id"),
+		d.firstNames("s.thread_id"),
+		d.hasFavouriteTag("s.tags"),
+	)
+
+	// Same filters and plain-text resolution as `messages list --format plain`.
+	fmt.Fprintf(&b, `DROP VIEW IF EXISTS beeper_messages;
+CREATE VIEW beeper_messages AS
+SELECT
+  m.id AS id,
+  m.eventID AS event_id,
+  m.roomID AS thread_id,
+  t.accountID AS account_id,
+  m.senderContactID AS sender_id,
+  p.name AS sender_name,
+  %s AS sent_at,
+  m.timestamp AS timestamp_ms,
+  m.isSentByMe <> 0 AS is_sent_by_me,
+  TRIM(m.type) AS type,
+  COALESCE(NULLIF(TRIM(m.text_content), ''), NULLIF(%s, ''), NULLIF(%s, ''), '') AS text,
+  m.hsOrder AS hs_order
+FROM %s m
+LEFT JOIN %s t ON t.threadID = m.roomID
+LEFT JOIN beeper_participants p ON p.thread_id = m.roomID AND p.participant_id = m.senderContactID
+WHERE m.isDeleted = 0
+  AND m.type NOT IN ('HIDDEN','REACTION');
+`,
+		d.timestamp("m.timestamp"),
+		d.jsonText("m.message", "$.body"),
+		d.jsonText("m.message", "$.text"),
+		table("mx_room_messages"),
+		table("threads"),
+	)
+
+	return b.String(), nil
+}
+
+func catalogOrDefault(catalog string) string {
+	if catalog == "" {
+		return "beeper"
+	}
+	return catalog
+}
+
+type viewDialect struct {
+	kind ViewDialect
+}
+
+// jsonText extracts a scalar as text, yielding NULL for invalid JSON.
+func (d viewDialect) jsonText(column, path string) string {
+	if d.kind == DialectDuckDB {
+		return fmt.Sprintf("CASE WHEN json_valid(%s) THEN json_extract_string(%s, '%s') END", column, column, path)
+	}
+	return fmt.Sprintf("CASE WHEN json_valid(%s) THEN CAST(json_extract(%s, '%s') AS TEXT) END", column, column, path)
+}
+
+func (d viewDialect) jsonInt(column, path string) string {
+	if d.kind == DialectDuckDB {
+		return fmt.Sprintf("TRY_CAST(%s AS BIGINT)", d.jsonText(column, path))
+	}
+	return fmt.Sprintf("CASE WHEN json_valid(%s) THEN CAST(json_extract(%s, '%s') AS INTEGER) END", column, column, path)
+}
+
+// jsonBool treats any non-zero / true value as true, like the store.
+func (d viewDialect) jsonBool(column, path string) string {
+	value := d.jsonText(column, path)
+	return fmt.Sprintf("COALESCE(LOWER(%s) IN ('1','true'), false)", value)
+}
+
+// archivedValue parses isArchivedUpto-style values ("ts123", "123", "1.2e3").
+func (d viewDialect) archivedValue(expr string) string {
+	stripped := fmt.Sprintf("CASE WHEN LOWER(TRIM(%s)) LIKE 'ts%%' THEN SUBSTR(TRIM(%s), 3) ELSE TRIM(%s) END", expr, expr, expr)
+	if d.kind == DialectDuckDB {
+		return fmt.Sprintf("TRY_CAST(TRY_CAST(%s AS DOUBLE) AS BIGINT)", stripped)
+	}
+	return fmt.Sprintf("(SELECT CASE WHEN v GLOB '[0-9]*' OR v GLOB '-[0-9]*' THEN CAST(CAST(v AS REAL) AS INTEGER) END FROM (SELECT %s AS v))", stripped)
+}
+
+func (d viewDialect) timestamp(msExpr string) string {
+	if d.kind == DialectDuckDB {
+		return fmt.Sprintf("epoch_ms(CAST(%s AS BIGINT))", msExpr)
+	}
+	return fmt.Sprintf("strftime('%%Y-%%m-%%dT%%H:%%M:%%fZ', (%s) / 1000.0, 'unixepoch')", msExpr)
+}
+
+func (d viewDialect) greatest(exprs ...string) string {
+	if d.kind == DialectDuckDB {
+		return "GREATEST(" + strings.Join(exprs, ", ") + ")"
+	}
+	wrapped := make([]string, len(exprs))
+	for i, expr := range exprs {
+		wrapped[i] = fmt.Sprintf("COALESCE(%s, 0)", expr)
+	}
+	return "MAX(" + strings.Join(wrapped, ", ") + ")"
+}
+
+// firstNames joins the first three non-self participant names in table order.
+func (d viewDialect) firstNames(threadExpr string) string {
+	if d.kind == DialectDuckDB {
+		return fmt.Sprintf(`(SELECT string_agg(q.name, ', ' ORDER BY q.position) FROM (
+            SELECT name, position FROM beeper_participants
+            WHERE thread_id = %s AND NOT is_self ORDER BY position LIMIT 3) q)`, threadExpr)
+	}
+	return fmt.Sprintf(`(SELECT group_concat(q.name, ', ') FROM (
+            SELECT name FROM beeper_participants
+            WHERE thread_id = %s AND NOT is_self ORDER BY position LIMIT 3) q)`, threadExpr)
+}
+
+// hasFavouriteTag mirrors parseTags + containsTag: a JSON array containing
+// "favourite", or any non-array value mentioning it.
+func (d viewDialect) hasFavouriteTag(expr string) string {
+	return fmt.Sprintf(`COALESCE(
+      CASE WHEN TRIM(%s) LIKE '[%%'
+        THEN LOWER(%s) LIKE '%%"favourite"%%'
+        ELSE LOWER(%s) LIKE '%%favourite%%'
+      END, false)`, expr, expr, expr)
+}
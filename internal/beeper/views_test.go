@@ -0,0 +1,78 @@
+package beeper
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+)
+
+func TestGenerateViewsMatchesStore(t *testing.T) {
+	path := createTestDB(t, false)
+	views, err := GenerateViews(ViewOptions{Dialect: DialectSQLite})
+	if err != nil {
+		t.Fatalf("generate views: %v", err)
+	}
+
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	if _, err := conn.Exec(views); err != nil {
+		t.Fatalf("create views: %v\n%s", err, views)
+	}
+
+	store, err := OpenWithOptions(path, StoreOptions{BridgeLookup: false})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	threads, err := store.ListThreads(context.Background(), ThreadListOptions{Label: LabelAll, IncludeLowPriority: true, WithStats: true})
+	if err != nil {
+		t.Fatalf("list threads: %v", err)
+	}
+	for _, thread := range threads {
+		var displayName string
+		var archived, unread, lowPriority bool
+		var total int
+		err := conn.QueryRow(`SELECT display_name, is_archived, in_unread, is_low_priority, total_messages
+			FROM beeper_threads WHERE thread_id = ?`, thread.ID).Scan(&displayName, &archived, &unread, &lowPriority, &total)
+		if err != nil {
+			t.Fatalf("query view for %s: %v", thread.ID, err)
+		}
+		if displayName != thread.DisplayName || archived != thread.IsArchived || unread != (thread.IsUnread || thread.IsMarkedUnread) ||
+			lowPriority != thread.IsLowPriority || total != thread.TotalMessages {
+			t.Fatalf("view mismatch for %s: got (%q, %t, %t, %t, %d), store %+v", thread.ID, displayName, archived, unread, lowPriority, total, thread)
+		}
+	}
+
+	labels := map[string]ThreadLabel{"in_inbox": LabelInbox, "in_archive": LabelArchive, "is_favourite": LabelFavourite}
+	for column, label := range labels {
+		want, err := store.ListThreads(context.Background(), ThreadListOptions{Label: label, IncludeLowPriority: true})
+		if err != nil {
+			t.Fatalf("list %s: %v", label, err)
+		}
+		var got int
+		if err := conn.QueryRow("SELECT COUNT(*) FROM beeper_threads WHERE " + column).Scan(&got); err != nil {
+			t.Fatalf("count %s: %v", column, err)
+		}
+		if got != len(want) {
+			t.Fatalf("%s: view has %d threads, store has %d", column, got, len(want))
+		}
+	}
+
+	var sender, text string
+	if err := conn.QueryRow(`SELECT sender_name, text FROM beeper_messages WHERE event_id = '$evt2'`).Scan(&sender, &text); err != nil {
+		t.Fatalf("query messages view: %v", err)
+	}
+	if sender != "Alice" || text != "christmas party" {
+		t.Fatalf("unexpected message row: %q %q", sender, text)
+	}
+}
+
+func TestGenerateViewsRejectsUnknownDialect(t *testing.T) {
+	if _, err := GenerateViews(ViewOptions{Dialect: "postgres"}); err == nil {
+		t.Fatalf("expected error for unknown dialect")
+	}
+}
@@ -0,0 +1,119 @@
+package beeper
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// DefaultWatchInterval is how often Watch checks for new messages.
+const DefaultWatchInterval = 2 * time.Second
+
+const watchBatchSize = 500
+
+// LatestMessageID returns the highest message row ID, or zero for an empty
+// database.
+func (s *Store) LatestMessageID(ctx context.Context) (int64, error) {
+	var id sql.NullInt64
+	if err := s.db.QueryRowContext(ctx, "SELECT MAX(id) FROM mx_room_messages").Scan(&id); err != nil {
+		return 0, err
+	}
+	return id.Int64, nil
+}
+
+// Watch calls fn for every visible message inserted after opts.AfterID, in
+// ID order, until ctx is cancelled or fn returns an error. Each interval it
+// polls PRAGMA data_version, which only changes when another connection
+// (Beeper) commits, so an idle database costs one pragma per interval. The
+// version is per connection, so Watch polls on a connection of its own to
+// keep versions comparable. Messages are
+// enriched with thread and sender names. With opts.Nice, a check that finds
+// Beeper writing is skipped and the wait doubles until it is quiet again.
+func (s *Store) Watch(ctx context.Context, opts WatchOptions, fn func(Message) error) error {
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	var throttle *Throttle
+	if opts.Nice {
+		interval = max(interval, NiceWatchInterval)
+		throttle = s.NewThrottle()
+		throttle.Busy()
+	}
+	lastID := opts.AfterID
+	if lastID == 0 {
+		latest, err := s.LatestMessageID(ctx)
+		if err != nil {
+			return err
+		}
+		lastID = latest
+	}
+
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return ctxErr(ctx, err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	lastVersion := int64(-1)
+	wait := interval
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-timer.C:
+		}
+
+		if throttle != nil && throttle.Busy() {
+			wait = min(wait*2, max(niceMaxBackoff, interval))
+			timer.Reset(wait)
+			continue
+		}
+		wait = interval
+
+		var version int64
+		if err := conn.QueryRowContext(ctx, "PRAGMA data_version").Scan(&version); err != nil {
+			return ctxErr(ctx, err)
+		}
+		if version != lastVersion {
+			lastVersion = version
+			next, err := s.streamNewMessages(ctx, opts, lastID, fn)
+			if err != nil {
+				return ctxErr(ctx, err)
+			}
+			lastID = next
+		}
+		timer.Reset(wait)
+	}
+}
+
+// streamNewMessages delivers the messages after lastID and returns the
+// last ID delivered.
+func (s *Store) streamNewMessages(ctx context.Context, opts WatchOptions, lastID int64, fn func(Message) error) (int64, error) {
+	for msg, err := range s.iterNewMessages(ctx, ScanOptions{
+		ThreadID:  opts.ThreadID,
+		AccountID: opts.AccountID,
+		AfterID:   lastID,
+		Limit:     watchBatchSize,
+		Format:    opts.Format,
+	}) {
+		if err != nil {
+			return lastID, err
+		}
+		if err := fn(msg); err != nil {
+			return lastID, err
+		}
+		lastID = msg.ID
+	}
+	return lastID, nil
+}
+
+// ctxErr reports cancellation as a clean stop.
+func ctxErr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,53 @@
+package beeper
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	path := createTestDB(t, false)
+	store, err := OpenWithOptions(path, StoreOptions{BridgeLookup: false})
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	received := make(chan Message, 4)
+	done := make(chan error, 1)
+	go func() {
+		done <- store.Watch(ctx, WatchOptions{ThreadID: "!room1:beeper.local", Interval: 10 * time.Millisecond, Format: FormatPlain}, func(msg Message) error {
+			received <- msg
+			return nil
+		})
+	}()
+
+	// Give Watch time to record the starting ID, then insert one message in
+	// another room (filtered out) and one in the watched room.
+	time.Sleep(50 * time.Millisecond)
+	insertMessages(t, path,
+		testMessage{roomID: "!room2:beeper.local", eventID: "$new1", sender: "@bob:beeper.local", ts: 1700000000800, hsOrder: 10, text: "elsewhere"},
+		testMessage{roomID: "!room1:beeper.local", eventID: "$new2", sender: "@alice:beeper.local", ts: 1700000000800, hsOrder: 10, text: "fresh"},
+	)
+
+	select {
+	case msg := <-received:
+		if msg.EventID != "$new2" || msg.Text != "fresh" || msg.ThreadName != "Team Chat" || msg.SenderName != "Alice" {
+			t.Fatalf("unexpected message: %+v", msg)
+		}
+	case <-ctx.Done():
+		t.Fatalf("no message received")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	if len(received) != 0 {
+		t.Fatalf("unexpected extra messages: %d", len(received))
+	}
+}
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+	"github.com/spf13/cobra"
+)
+
+func newAccountsCmd(app *App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "accounts",
+		Short: "Inspect connected accounts",
+	}
+
+	cmd.AddCommand(newAccountsStatusCmd(app))
+	return cmd
+}
+
+func newAccountsStatusCmd(app *App) *cobra.Command {
+	var staleAfter string
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show per-account activity and whether its bridge looks stalled",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			stale, err := parseAgeFlag(staleAfter)
+			if err != nil {
+				return err
+			}
+			if stale <= 0 {
+				return fmt.Errorf("--stale-after must be positive")
+			}
+
+			ctx := context.Background()
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			statuses, err := store.AccountStatuses(ctx, beeper.AccountStatusOptions{StaleAfter: stale})
+			if err != nil {
+				return err
+			}
+
+			if app.JSON {
+				return app.write(statuses)
+			}
+
+			w := newTabWriter()
+			if err := writeHeader(w, "ACCOUNT\tSTATUS\tTHREADS\tLAST RECEIVED\tLAST SENT\tBRIDGE UPDATED\tBRIDGE LAST MESSAGE"); err != nil {
+				return err
+			}
+			for _, s := range statuses {
+				if err := writef(w, "%s\t%s\t%d\t%s\t%s\t%s\t%s\n", s.AccountID, s.Status, s.Threads, formatTime(s.LastReceived), formatTime(s.LastSent), formatTime(s.BridgeUpdated), formatTime(s.BridgeLastMessage)); err != nil {
+					return err
+				}
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().StringVar(&staleAfter, "stale-after", "3d", "call an account stalled after this long without incoming messages or bridge writes (e.g. 72h, 7d)")
+
+	return cmd
+}
@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+	"github.com/KrauseFx/beeper-cli/internal/extract"
+	"github.com/spf13/cobra"
+)
+
+type topicsReport struct {
+	ThreadID   string          `json:"threadId"`
+	ThreadName string          `json:"threadName"`
+	Messages   int             `json:"messages"`
+	Topics     []extract.Topic `json:"topics"`
+}
+
+func newAnalyzeCmd(app *App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "analyze",
+		Short: "Analyze the content of conversations",
+	}
+
+	cmd.AddCommand(newAnalyzeTopicsCmd(app))
+	return cmd
+}
+
+func newAnalyzeTopicsCmd(app *App) *cobra.Command {
+	var threadRef string
+	var topics int
+	var excerpts int
+	var gapValue string
+	var from string
+	var to string
+
+	cmd := &cobra.Command{
+		Use:               "topics",
+		Short:             "Cluster a thread's history into topics with excerpts and date ranges",
+		ValidArgsFunction: app.completeThreadArgs,
+		RunE: func(_ *cobra.Command, args []string) error {
+			if threadRef == "" && len(args) > 0 {
+				threadRef = args[0]
+			}
+			if threadRef == "" {
+				return fmt.Errorf("thread ID is required")
+			}
+			gap, err := parseDuration(gapValue)
+			if err != nil {
+				return err
+			}
+			if gap <= 0 {
+				return fmt.Errorf("--gap must be positive")
+			}
+			after, err := parseDateFlag(from, false)
+			if err != nil {
+				return err
+			}
+			before, err := parseDateFlag(to, true)
+			if err != nil {
+				return err
+			}
+
+			ctx := context.Background()
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			threadID, err := resolveThreadRef(ctx, store, threadRef)
+			if err != nil {
+				return err
+			}
+			thread, err := store.GetThread(ctx, threadID, false)
+			if err != nil {
+				return err
+			}
+			messages, err := store.ListMessages(ctx, beeper.MessageListOptions{
+				ThreadID: threadID,
+				All:      true,
+				After:    after,
+				Before:   before,
+				Format:   beeper.FormatPlain,
+			})
+			if err != nil {
+				return err
+			}
+			slices.Reverse(messages)
+			beeper.SegmentSessions(messages, gap)
+
+			report := topicsReport{
+				ThreadID:   threadID,
+				ThreadName: thread.DisplayName,
+				Messages:   len(messages),
+				Topics:     extract.Topics(messages, extract.TopicOptions{Topics: topics, Excerpts: excerpts}),
+			}
+			if app.JSON {
+				return app.write(report)
+			}
+
+			fmt.Printf("%s: %d messages, %d topics\n", safe(report.ThreadName), report.Messages, len(report.Topics))
+			for i, topic := range report.Topics {
+				fmt.Printf("\n%d. %s\n", i+1, topic.Label)
+				fmt.Printf("   %s to %s · %d sessions · %d messages\n",
+					topic.Start.Local().Format("2006-01-02"), topic.End.Local().Format("2006-01-02"), topic.Sessions, topic.Messages)
+				for _, ex := range topic.Excerpts {
+					fmt.Printf("   > %s %s: %s\n", ex.Timestamp.Local().Format("2006-01-02"), ex.Sender, ex.Text)
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&threadRef, "thread", "", "thread to analyze (ID or name)")
+	cmd.Flags().IntVar(&topics, "topics", 0, "number of topics (default: based on history size, at most 12)")
+	cmd.Flags().IntVar(&excerpts, "excerpts", 3, "representative messages per topic")
+	cmd.Flags().StringVar(&gapValue, "gap", beeper.DefaultSessionGap.String(), "silence that separates the sessions being clustered")
+	cmd.Flags().StringVar(&from, "from", "", "start date (YYYY-MM-DD, RFC3339, yesterday, last monday, 2w, ...)")
+	cmd.Flags().StringVar(&to, "to", "", "end date, inclusive (same forms as --from)")
+
+	return cmd
+}
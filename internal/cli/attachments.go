@@ -0,0 +1,296 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+	"github.com/KrauseFx/beeper-cli/internal/export"
+	"github.com/spf13/cobra"
+)
+
+type exportedAttachment struct {
+	beeper.Attachment
+	// Path is where the file was (or would have been) written.
+	Path   string `json:"path,omitempty"`
+	Status string `json:"status"`
+}
+
+type attachmentsExportResult struct {
+	Out         string               `json:"out"`
+	Copied      int                  `json:"copied"`
+	Unchanged   int                  `json:"unchanged"`
+	Missing     int                  `json:"missing"`
+	Attachments []exportedAttachment `json:"attachments"`
+}
+
+// attachmentFilter holds the flags shared by the attachments subcommands.
+type attachmentFilter struct {
+	threadRef string
+	accountID string
+	msgType   string
+	from      string
+	to        string
+}
+
+func (f *attachmentFilter) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.threadRef, "thread", "", "only this thread (ID or name)")
+	cmd.Flags().StringVar(&f.accountID, "account", "", "filter by account/platform ID")
+	cmd.Flags().StringVar(&f.msgType, "type", "", "only IMAGE, VIDEO, AUDIO, FILE, or STICKER")
+	cmd.Flags().StringVar(&f.from, "from", "", "start date (YYYY-MM-DD, RFC3339, yesterday, last monday, 2w, ...)")
+	cmd.Flags().StringVar(&f.to, "to", "", "end date, inclusive (same forms as --from)")
+}
+
+func (f *attachmentFilter) options(ctx context.Context, store *beeper.Store, limit int) (beeper.AttachmentOptions, error) {
+	if f.msgType != "" && !beeper.IsMediaType(f.msgType) {
+		return beeper.AttachmentOptions{}, fmt.Errorf("invalid type %q (expected IMAGE, VIDEO, AUDIO, FILE, or STICKER)", f.msgType)
+	}
+	after, err := parseDateFlag(f.from, false)
+	if err != nil {
+		return beeper.AttachmentOptions{}, err
+	}
+	before, err := parseDateFlag(f.to, true)
+	if err != nil {
+		return beeper.AttachmentOptions{}, err
+	}
+	opts := beeper.AttachmentOptions{
+		AccountID: f.accountID,
+		After:     after,
+		Before:    before,
+		Type:      f.msgType,
+		Limit:     limit,
+	}
+	if f.threadRef != "" {
+		opts.ThreadID, err = resolveThreadRef(ctx, store, f.threadRef)
+		if err != nil {
+			return beeper.AttachmentOptions{}, err
+		}
+	}
+	return opts, nil
+}
+
+func newAttachmentsCmd(app *App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "attachments",
+		Short: "List images, videos, audio, and files sent in chats, and copy them out of the media cache",
+	}
+
+	cmd.AddCommand(newAttachmentsListCmd(app))
+	cmd.AddCommand(newAttachmentsExportCmd(app))
+	return cmd
+}
+
+func newAttachmentsListCmd(app *App) *cobra.Command {
+	var filter attachmentFilter
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List attachments, newest first, and whether they are cached locally",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			ctx := context.Background()
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			opts, err := filter.options(ctx, store, limit)
+			if err != nil {
+				return err
+			}
+			attachments, err := store.ListAttachments(ctx, opts)
+			if err != nil {
+				return err
+			}
+
+			if app.JSON {
+				return app.write(attachments)
+			}
+
+			w := newTabWriter()
+			if err := writeHeader(w, "TIME\tTHREAD\tSENDER\tTYPE\tFILE\tSIZE\tCACHED"); err != nil {
+				return err
+			}
+			for _, att := range attachments {
+				sender := att.SenderName
+				if sender == "" {
+					sender = att.SenderID
+				}
+				cached := "no"
+				if att.LocalPath != "" {
+					cached = "yes"
+				}
+				if err := writef(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+					formatTime(att.Timestamp),
+					safe(att.ThreadName),
+					sender,
+					att.Type,
+					safe(att.FileName),
+					formatSize(att.Size),
+					cached,
+				); err != nil {
+					return err
+				}
+			}
+			return w.Flush()
+		},
+	}
+
+	filter.register(cmd)
+	cmd.Flags().IntVar(&limit, "limit", 50, "number of most recent attachments (0 for all)")
+
+	return cmd
+}
+
+func newAttachmentsExportCmd(app *App) *cobra.Command {
+	var filter attachmentFilter
+	var outDir string
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Copy cached attachments into per-thread folders with readable file names",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if outDir == "" {
+				return fmt.Errorf("--out is required")
+			}
+
+			ctx := context.Background()
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			opts, err := filter.options(ctx, store, limit)
+			if err != nil {
+				return err
+			}
+			attachments, err := store.ListAttachments(ctx, opts)
+			if err != nil {
+				return err
+			}
+
+			result, err := exportAttachments(outDir, attachments)
+			if err != nil {
+				return err
+			}
+			if app.JSON {
+				return app.write(result)
+			}
+			fmt.Printf("Copied %d attachments to %s (%d already there, %d not cached locally)\n",
+				result.Copied, result.Out, result.Unchanged, result.Missing)
+			return nil
+		},
+	}
+
+	filter.register(cmd)
+	cmd.Flags().StringVar(&outDir, "out", "", "output directory (required)")
+	cmd.Flags().IntVar(&limit, "limit", 0, "number of most recent attachments (0 for all)")
+
+	return cmd
+}
+
+// exportAttachments copies every cached attachment to
+// <out>/<thread>/<time sender - file name>.
+func exportAttachments(outDir string, attachments []beeper.Attachment) (attachmentsExportResult, error) {
+	result := attachmentsExportResult{Out: outDir, Attachments: []exportedAttachment{}}
+	dirs := map[string]string{}
+	dirOwner := map[string]string{}
+	used := map[string]bool{}
+	for _, att := range attachments {
+		item := exportedAttachment{Attachment: att}
+		dir, ok := dirs[att.ThreadID]
+		if !ok {
+			dir = attachmentDirName(att)
+			if owner, taken := dirOwner[dir]; taken && owner != att.ThreadID {
+				dir = fmt.Sprintf("%s [%s]", dir, export.SafeName(strings.TrimPrefix(att.ThreadID, "!")))
+			}
+			dirs[att.ThreadID] = dir
+			dirOwner[dir] = att.ThreadID
+		}
+		item.Path = uniquePath(filepath.Join(outDir, dir, export.AttachmentFileName(att)), used)
+
+		switch {
+		case att.LocalPath == "":
+			item.Status = "missing"
+			result.Missing++
+		default:
+			copied, err := export.CopyAttachment(att.LocalPath, item.Path, att.Timestamp)
+			if err != nil {
+				return result, fmt.Errorf("copy %s: %w", att.EventID, err)
+			}
+			if copied {
+				item.Status = "copied"
+				result.Copied++
+			} else {
+				item.Status = "unchanged"
+				result.Unchanged++
+			}
+		}
+		result.Attachments = append(result.Attachments, item)
+	}
+	return result, nil
+}
+
+// uniquePath appends " (2)", " (3)", ... before the extension until path
+// has not been handed out yet in this run.
+func uniquePath(path string, used map[string]bool) string {
+	candidate := path
+	ext := filepath.Ext(path)
+	for i := 2; used[candidate]; i++ {
+		candidate = fmt.Sprintf("%s (%d)%s", strings.TrimSuffix(path, ext), i, ext)
+	}
+	used[candidate] = true
+	return candidate
+}
+
+// formatSize renders a byte count for tables, or "-" when unknown.
+func formatSize(size int64) string {
+	switch {
+	case size <= 0:
+		return "-"
+	case size < 1024:
+		return fmt.Sprintf("%d B", size)
+	case size < 1024*1024:
+		return fmt.Sprintf("%.1f KB", float64(size)/1024)
+	default:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1024*1024))
+	}
+}
+
+// attachmentDirName is the folder of a thread's attachments: its name and
+// platform, with path separators and control characters replaced.
+func attachmentDirName(att beeper.Attachment) string {
+	name := sanitizeDirName(att.ThreadName)
+	if name == "" {
+		name = sanitizeDirName(strings.TrimPrefix(att.ThreadID, "!"))
+	}
+	if att.AccountID != "" {
+		name += " (" + sanitizeDirName(att.AccountID) + ")"
+	}
+	return name
+}
+
+func sanitizeDirName(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case r == '/' || r == '\\' || r == ':' || r < 0x20 || r == 0x7f:
+			return '_'
+		default:
+			return r
+		}
+	}, strings.TrimSpace(s))
+	// "." and ".." would leave the output directory.
+	if strings.Trim(s, ".") == "" {
+		return ""
+	}
+	return s
+}
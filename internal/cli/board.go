@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+	"github.com/KrauseFx/beeper-cli/internal/report"
+	"github.com/spf13/cobra"
+)
+
+// clearScreen moves the cursor home and clears the terminal.
+const clearScreen = "\x1b[H\x1b[2J"
+
+func newBoardCmd(app *App) *cobra.Command {
+	var threadRefs []string
+	var limit int
+	var refresh string
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "board",
+		Short: "Show favourite threads, unread counts, and today's activity on one screen",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			interval, err := parseDuration(refresh)
+			if err != nil {
+				return err
+			}
+			if interval < 0 {
+				return fmt.Errorf("--watch must not be negative")
+			}
+			if interval > 0 && (app.Snapshot || app.Immutable) {
+				return fmt.Errorf("board --watch follows the live database and cannot use --snapshot or --immutable")
+			}
+			formatValue, err := parseMessageFormat(format)
+			if err != nil {
+				return err
+			}
+			if !cmd.Flags().Changed("thread") {
+				threadRefs = app.Config.Board.Threads
+			}
+			if !cmd.Flags().Changed("limit") && app.Config.Board.Limit > 0 {
+				limit = app.Config.Board.Limit
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			threadIDs := make([]string, 0, len(threadRefs))
+			for _, ref := range threadRefs {
+				id, err := resolveThreadRef(ctx, store, ref)
+				if err != nil {
+					return err
+				}
+				threadIDs = append(threadIDs, id)
+			}
+			opts := report.BoardOptions{ThreadIDs: threadIDs, Limit: limit, Format: formatValue}
+
+			redraw := interval > 0 && !app.JSON && isTerminal(os.Stdout)
+			for {
+				board, err := report.BuildBoard(ctx, store, opts)
+				if err != nil {
+					if ctx.Err() != nil {
+						return nil
+					}
+					return err
+				}
+				if redraw {
+					fmt.Print(clearScreen)
+				}
+				if err := app.writeBoard(board); err != nil {
+					return err
+				}
+				if interval == 0 {
+					return nil
+				}
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-time.After(interval):
+				}
+			}
+		},
+	}
+
+	cmd.Flags().StringArrayVar(&threadRefs, "thread", nil, "thread ID or name to show, in order (repeatable; default: config board.threads, else favourites)")
+	cmd.Flags().IntVar(&limit, "limit", 10, "max number of favourite threads (default from config board.limit)")
+	cmd.Flags().StringVar(&refresh, "watch", "", "redraw the board at this interval, e.g. 30s, until Ctrl-C")
+	cmd.Flags().StringVar(&format, "format", string(beeper.FormatPlain), "message format: plain|rich")
+
+	return cmd
+}
+
+// writeBoard prints the board headline and one row per thread, or the
+// board itself as JSON.
+func (a *App) writeBoard(board report.Board) error {
+	if a.JSON {
+		return a.write(board)
+	}
+	if _, err := fmt.Println(report.BoardSummary(board)); err != nil {
+		return err
+	}
+	if len(board.Threads) == 0 {
+		_, err := fmt.Println("No favourite threads; mark some in Beeper or set board.threads in the config.")
+		return err
+	}
+	w := newTextTable(4)
+	if err := writeHeader(w, "THREAD\tUNREAD\tTODAY\tACTIVITY\tLAST"); err != nil {
+		return err
+	}
+	for _, thread := range board.Threads {
+		unread := fmt.Sprint(thread.Unread)
+		if thread.UnreadMentions > 0 {
+			unread += fmt.Sprintf(" (@%d)", thread.UnreadMentions)
+		}
+		last := ""
+		if msg := thread.Last; msg != nil {
+			sender := cmp.Or(msg.SenderName, msg.SenderID)
+			if msg.IsSentByMe {
+				sender = outputLocale.T("Me")
+			}
+			last = fmt.Sprintf("%s %s: %s", formatTime(msg.Timestamp), sender, cmp.Or(msg.Preview, beeper.Preview(msg.Text, 0)))
+		}
+		if err := writef(w, "%s\t%s\t%d\t%s\t%s\n", safe(thread.Name), unread, thread.Today, report.Sparkline(thread.Hourly), tableText(safe(last))); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,206 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+	"github.com/KrauseFx/beeper-cli/internal/sidecar"
+	"github.com/spf13/cobra"
+)
+
+// bookmarkEntry is a bookmark with the message it points to; Message is
+// nil when the message was deleted or is missing from the database.
+type bookmarkEntry struct {
+	sidecar.Bookmark
+	Message *beeper.Message `json:"message,omitempty"`
+	// context holds the surrounding messages when they were requested.
+	context []beeper.Message
+}
+
+func newBookmarksCmd(app *App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "bookmarks",
+		Short: "Bookmark messages locally (kept in the sidecar DB, never synced)",
+	}
+
+	cmd.AddCommand(newBookmarksAddCmd(app))
+	cmd.AddCommand(newBookmarksListCmd(app))
+	cmd.AddCommand(newBookmarksRemoveCmd(app))
+
+	return cmd
+}
+
+func newBookmarksAddCmd(app *App) *cobra.Command {
+	var note string
+
+	cmd := &cobra.Command{
+		Use:   "add <event>",
+		Short: "Bookmark a message by event ID (again to change its note)",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			ctx := context.Background()
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			result, err := store.GetMessageByEventID(ctx, args[0], beeper.SearchOptions{Format: beeper.FormatPlain})
+			if err != nil {
+				return err
+			}
+			side, err := app.openSidecar()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = side.Close()
+			}()
+
+			bookmark := sidecar.Bookmark{EventID: result.Match.EventID, ThreadID: result.Match.ThreadID, Note: strings.TrimSpace(note)}
+			if err := side.AddBookmark(ctx, bookmark); err != nil {
+				return err
+			}
+			if app.JSON {
+				return app.write(bookmark)
+			}
+			fmt.Printf("Bookmarked %s in %s\n", bookmark.EventID, safe(result.Match.ThreadName))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&note, "note", "", "why the message matters; shown in listings and dossiers")
+
+	return cmd
+}
+
+func newBookmarksListCmd(app *App) *cobra.Command {
+	var threadRef string
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List bookmarks, oldest first, with their messages",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			formatValue, err := parseMessageFormat(format)
+			if err != nil {
+				return err
+			}
+
+			ctx := context.Background()
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			threadID := ""
+			if threadRef != "" {
+				threadID, err = resolveThreadRef(ctx, store, threadRef)
+				if err != nil {
+					return err
+				}
+			}
+			entries, err := app.loadBookmarks(ctx, store, threadID, beeper.SearchOptions{Format: formatValue})
+			if err != nil {
+				return err
+			}
+
+			if app.JSON {
+				return app.write(entries)
+			}
+
+			w := newTextTable(4)
+			if err := writeHeader(w, "TIME\tTHREAD\tEVENT\tNOTE\tTEXT"); err != nil {
+				return err
+			}
+			for _, entry := range entries {
+				if entry.Message == nil {
+					if err := writef(w, "-\t%s\t%s\t%s\t[missing]\n", entry.ThreadID, entry.EventID, safe(entry.Note)); err != nil {
+						return err
+					}
+					continue
+				}
+				msg := entry.Message
+				if err := writef(w, "%s\t%s\t%s\t%s\t%s\n", formatTime(msg.Timestamp), safe(msg.ThreadName), entry.EventID, safe(entry.Note), tableText(outputLocale.Text(msg.Text))); err != nil {
+					return err
+				}
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().StringVar(&threadRef, "thread", "", "only bookmarks in this thread (ID or name)")
+	cmd.Flags().StringVar(&format, "format", string(beeper.FormatRich), "message format: plain|rich")
+
+	return cmd
+}
+
+func newBookmarksRemoveCmd(app *App) *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove <event>",
+		Short: "Remove a bookmark",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			side, err := app.openExistingSidecar()
+			if err != nil {
+				return err
+			}
+			if side == nil {
+				return fmt.Errorf("no bookmark for %s", args[0])
+			}
+			defer func() {
+				_ = side.Close()
+			}()
+			ok, err := side.RemoveBookmark(context.Background(), strings.TrimSpace(args[0]))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return fmt.Errorf("no bookmark for %s", args[0])
+			}
+			if !app.JSON {
+				fmt.Printf("Removed bookmark %s\n", args[0])
+			}
+			return nil
+		},
+	}
+}
+
+// loadBookmarks returns the bookmarks (in threadID, or all) with their
+// messages looked up using opts; missing messages leave Message nil.
+func (a *App) loadBookmarks(ctx context.Context, store *beeper.Store, threadID string, opts beeper.SearchOptions) ([]bookmarkEntry, error) {
+	side, err := a.openExistingSidecar()
+	if err != nil || side == nil {
+		return []bookmarkEntry{}, err
+	}
+	defer func() {
+		_ = side.Close()
+	}()
+	bookmarks, err := side.Bookmarks(ctx, threadID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]bookmarkEntry, 0, len(bookmarks))
+	for _, b := range bookmarks {
+		entry := bookmarkEntry{Bookmark: b}
+		result, err := store.GetMessageByEventID(ctx, b.EventID, opts)
+		switch {
+		case err == nil:
+			entry.Message = &result.Match
+			entry.context = result.Context
+		case !errors.Is(err, beeper.ErrMessageNotFound):
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
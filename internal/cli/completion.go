@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+	"github.com/KrauseFx/beeper-cli/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// registerCompletions adds dynamic completion of real thread names and
+// account IDs to every --thread and --account flag under cmd. Commands
+// taking a thread as their first argument set completeThreadArgs
+// themselves.
+func (a *App) registerCompletions(cmd *cobra.Command) {
+	if cmd.Flags().Lookup("thread") != nil {
+		_ = cmd.RegisterFlagCompletionFunc("thread", a.completeThreads)
+	}
+	if cmd.Flags().Lookup("account") != nil {
+		_ = cmd.RegisterFlagCompletionFunc("account", a.completeAccounts)
+	}
+	for _, sub := range cmd.Commands() {
+		a.registerCompletions(sub)
+	}
+}
+
+// completeThreadArgs completes a command's first argument as a thread.
+func (a *App) completeThreadArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return a.completeThreads(cmd, args, toComplete)
+}
+
+// completeThreads offers the threads matching toComplete, most recently
+// active first: by display name when it names one thread, else by room ID
+// (always by ID once toComplete starts with "!"), described by the other.
+func (a *App) completeThreads(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	threads, err := a.completionThreads()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	names := map[string]int{}
+	for _, thread := range threads {
+		names[strings.ToLower(thread.DisplayName)]++
+	}
+
+	prefix := strings.ToLower(toComplete)
+	candidates := []string{}
+	for _, thread := range threads {
+		name := strings.Join(strings.Fields(thread.DisplayName), " ")
+		byName := name != "" && names[strings.ToLower(thread.DisplayName)] == 1 && !strings.HasPrefix(toComplete, "!")
+		switch {
+		case byName && strings.HasPrefix(strings.ToLower(name), prefix):
+			candidates = append(candidates, completion(name, fmt.Sprintf("%s, %s", thread.AccountID, thread.ID)))
+		case strings.HasPrefix(strings.ToLower(thread.ID), prefix):
+			candidates = append(candidates, completion(thread.ID, cmp.Or(name, thread.AccountID)))
+		}
+	}
+	return candidates, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeAccounts offers the account IDs that have threads, described by
+// their thread count.
+func (a *App) completeAccounts(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	threads, err := a.completionThreads()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	counts := map[string]int{}
+	for _, thread := range threads {
+		if thread.AccountID != "" {
+			counts[thread.AccountID]++
+		}
+	}
+	accounts := []string{}
+	for account := range counts {
+		if strings.HasPrefix(account, toComplete) {
+			accounts = append(accounts, account)
+		}
+	}
+	slices.Sort(accounts)
+	candidates := make([]string, 0, len(accounts))
+	for _, account := range accounts {
+		description := fmt.Sprintf("%d threads", counts[account])
+		if counts[account] == 1 {
+			description = "1 thread"
+		}
+		candidates = append(candidates, completion(account, description))
+	}
+	return candidates, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completionThreads lists every thread for completion. Completion runs
+// without the root command's setup, so the config is loaded here for its
+// dbPath, and a broken config is ignored rather than reported.
+func (a *App) completionThreads() ([]beeper.Thread, error) {
+	if path, err := config.ResolveConfigPath(a.ConfigPath); err == nil {
+		if cfg, err := config.LoadConfig(path); err == nil {
+			a.Config = cfg
+		}
+	}
+	store, _, err := a.openStore()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = store.Close()
+	}()
+	return store.ListThreads(context.Background(), beeper.ThreadListOptions{All: true, Label: beeper.LabelAll, IncludeLowPriority: true})
+}
+
+// completion formats a candidate with a description shells may show next
+// to it.
+func completion(value string, description string) string {
+	if description == "" {
+		return value
+	}
+	return value + "\t" + description
+}
@@ -0,0 +1,295 @@
+package cli
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+	"github.com/spf13/cobra"
+)
+
+func newContactsCmd(app *App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "contacts",
+		Aliases: []string{"person"},
+		Short:   "List people across threads and platforms",
+	}
+
+	cmd.AddCommand(newContactsListCmd(app))
+	cmd.AddCommand(newContactsShowCmd(app))
+	cmd.AddCommand(newContactsRankCmd(app))
+	cmd.AddCommand(newContactsTimelineCmd(app))
+
+	return cmd
+}
+
+func newContactsListCmd(app *App) *cobra.Command {
+	var query string
+	var platform string
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List contacts, most active first",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			ctx := context.Background()
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			contacts, err := store.ListContacts(ctx, beeper.ContactOptions{
+				Query:    query,
+				Platform: platform,
+				Limit:    limit,
+			})
+			if err != nil {
+				return err
+			}
+
+			if app.JSON {
+				return app.write(contacts)
+			}
+
+			w := newTabWriter()
+			if err := writeHeader(w, "NAME\tPLATFORMS\tTHREADS\tMESSAGES\tLAST MESSAGE"); err != nil {
+				return err
+			}
+			for _, c := range contacts {
+				if err := writef(w, "%s\t%s\t%d\t%d\t%s\n", c.Name, safe(strings.Join(c.Platforms, ", ")), c.ThreadCount, c.Messages, formatTime(c.LastMessage)); err != nil {
+					return err
+				}
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().StringVar(&query, "query", "", "only contacts whose name or ID contains this text")
+	cmd.Flags().StringVar(&platform, "platform", "", "only contacts on this platform (e.g. whatsapp)")
+	cmd.Flags().IntVar(&limit, "limit", 50, "max number of contacts (0 for all)")
+
+	return cmd
+}
+
+func newContactsShowCmd(app *App) *cobra.Command {
+	var id string
+
+	cmd := &cobra.Command{
+		Use:   "show [id|name]",
+		Short: "Show a contact's IDs, platforms, and threads",
+		RunE: func(_ *cobra.Command, args []string) error {
+			if id == "" && len(args) > 0 {
+				id = strings.Join(args, " ")
+			}
+			if id == "" {
+				return fmt.Errorf("contact ID or name is required")
+			}
+
+			ctx := context.Background()
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			contact, err := store.GetContact(ctx, id)
+			if err != nil {
+				return err
+			}
+
+			if app.JSON {
+				return app.write(contact)
+			}
+
+			fmt.Printf("Name: %s\n", contact.Name)
+			fmt.Printf("IDs: %s\n", strings.Join(contact.IDs, ", "))
+			fmt.Printf("Platforms: %s\n", safe(strings.Join(contact.Platforms, ", ")))
+			fmt.Printf("Messages: %d\n", contact.Messages)
+			fmt.Printf("Last message: %s\n", formatTime(contact.LastMessage))
+			if len(contact.Threads) == 0 {
+				return nil
+			}
+			fmt.Println()
+			w := newTabWriter()
+			if err := writeHeader(w, "THREAD\tACCOUNT\tMESSAGES\tLAST MESSAGE\tID"); err != nil {
+				return err
+			}
+			for _, t := range contact.Threads {
+				if err := writef(w, "%s\t%s\t%d\t%s\t%s\n", safe(t.ThreadName), safe(t.AccountID), t.Messages, formatTime(t.LastMessage), t.ThreadID); err != nil {
+					return err
+				}
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().StringVar(&id, "id", "", "contact ID or name")
+
+	return cmd
+}
+
+func newContactsRankCmd(app *App) *cobra.Command {
+	var platform string
+	var limit int
+	var halfLife int
+
+	cmd := &cobra.Command{
+		Use:   "rank",
+		Short: "Rank contacts by recency, frequency, reciprocity, and mentions",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if halfLife <= 0 {
+				return fmt.Errorf("--half-life must be positive")
+			}
+
+			ctx := context.Background()
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			ranked, err := store.RankContacts(ctx, beeper.ContactRankOptions{
+				Platform: platform,
+				Limit:    limit,
+				HalfLife: time.Duration(halfLife) * 24 * time.Hour,
+			})
+			if err != nil {
+				return err
+			}
+
+			if app.JSON {
+				return app.write(ranked)
+			}
+
+			w := newTabWriter()
+			if err := writeHeader(w, "RANK\tNAME\tPLATFORMS\tSCORE\tRECENCY\tFREQUENCY\tRECIPROCITY\tMENTIONS\tLAST CONTACT"); err != nil {
+				return err
+			}
+			for i, c := range ranked {
+				s := c.Signals
+				if err := writef(w, "%d\t%s\t%s\t%.1f\t%.2f\t%.2f\t%.2f\t%.2f\t%s\n", i+1, c.Name, safe(strings.Join(c.Platforms, ", ")), c.Score,
+					s.Recency, s.Frequency, s.Reciprocity, s.Mentions, formatTime(s.LastContact)); err != nil {
+					return err
+				}
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().StringVar(&platform, "platform", "", "only contacts on this platform (e.g. whatsapp)")
+	cmd.Flags().IntVar(&limit, "limit", 20, "max number of contacts (0 for all)")
+	cmd.Flags().IntVar(&halfLife, "half-life", 30, "days after which a message counts half as much")
+
+	return cmd
+}
+
+func newContactsTimelineCmd(app *App) *cobra.Command {
+	var id string
+	var days int
+	var after string
+	var since string
+	var before string
+	var limit int
+	var all bool
+	var dmsOnly bool
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "timeline [id|name]",
+		Short: "Everything exchanged with a person across platforms, in one chronological stream",
+		RunE: func(_ *cobra.Command, args []string) error {
+			if id == "" && len(args) > 0 {
+				id = strings.Join(args, " ")
+			}
+			if id == "" {
+				return fmt.Errorf("contact ID or name is required")
+			}
+			afterTime, err := parseTimeFlag(cmp.Or(after, since), days)
+			if err != nil {
+				return err
+			}
+			beforeTime, err := parseTimePtr(before)
+			if err != nil {
+				return err
+			}
+			formatValue, err := parseMessageFormat(format)
+			if err != nil {
+				return err
+			}
+
+			ctx := context.Background()
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			contact, err := store.GetContact(ctx, id)
+			if err != nil {
+				return err
+			}
+			messages, err := store.PersonTimeline(ctx, contact, beeper.TimelineOptions{
+				After:   afterTime,
+				Before:  beforeTime,
+				Limit:   limit,
+				All:     all,
+				DMsOnly: dmsOnly,
+				Format:  formatValue,
+			})
+			if err != nil {
+				return err
+			}
+
+			if app.JSON {
+				contact.Threads = nil
+				return app.write(map[string]any{
+					"contact":  contact,
+					"messages": messages,
+				})
+			}
+
+			w := newTextTable(4)
+			if err := writeHeader(w, "TIME\tACCOUNT\tTHREAD\tSENDER\tTEXT"); err != nil {
+				return err
+			}
+			for _, msg := range messages {
+				sender := msg.SenderName
+				if sender == "" {
+					sender = msg.SenderID
+				}
+				if msg.IsSentByMe {
+					sender = outputLocale.T("Me")
+				}
+				if err := writef(w, "%s\t%s\t%s\t%s\t%s\n", formatTime(msg.Timestamp), safe(msg.AccountID), safe(msg.ThreadName), sender, tableText(outputLocale.Text(msg.Text))); err != nil {
+					return err
+				}
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().StringVar(&id, "id", "", "contact ID or name")
+	cmd.Flags().IntVar(&days, "days", 0, "only include messages from the last N days")
+	cmd.Flags().StringVar(&after, "after", "", "only include messages after this time: RFC3339, YYYY-MM-DD, yesterday, last monday, 2w, 3d ago, ...")
+	cmd.Flags().StringVar(&since, "since", "", "same as --after, e.g. --since yesterday")
+	cmd.MarkFlagsMutuallyExclusive("after", "since")
+	cmd.Flags().StringVar(&before, "before", "", "only include messages before this time (same forms as --after)")
+	cmd.Flags().IntVar(&limit, "limit", 100, "keep the N most recent messages")
+	cmd.Flags().BoolVar(&all, "all", false, "the whole history instead of --limit messages")
+	cmd.Flags().BoolVar(&dmsOnly, "dms-only", false, "only your DMs with them, not their messages in groups")
+	cmd.Flags().StringVar(&format, "format", string(beeper.FormatRich), "message format: plain|rich")
+
+	return cmd
+}
@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+	"github.com/spf13/cobra"
+)
+
+// countResult is the structured output of the count commands and flags.
+type countResult struct {
+	Count int `json:"count"`
+}
+
+// writeCount prints n alone, or as {"count": n} for structured output.
+func writeCount(app *App, n int) error {
+	if app.JSON {
+		return app.write(countResult{Count: n})
+	}
+	_, err := fmt.Println(n)
+	return err
+}
+
+func newMessagesCountCmd(app *App) *cobra.Command {
+	var threadID string
+	var accountID string
+	var days int
+	var after string
+	var since string
+	var before string
+	var msgType string
+	var from string
+	var mine bool
+	var received bool
+	var includeDeleted bool
+	var includeExpired bool
+	var skipUndecrypted bool
+	var unreadOnly bool
+
+	cmd := &cobra.Command{
+		Use:               "count [thread]",
+		Short:             "Count messages, in one thread or all of them",
+		ValidArgsFunction: app.completeThreadArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if threadID == "" && len(args) > 0 {
+				threadID = args[0]
+			}
+			if unreadOnly && threadID == "" {
+				return fmt.Errorf("--unread-only requires a thread")
+			}
+
+			ctx := context.Background()
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			afterTime, err := parseTimeFlag(cmp.Or(after, since), days)
+			if err != nil {
+				return err
+			}
+			beforeTime, err := parseTimePtr(before)
+			if err != nil {
+				return err
+			}
+			if threadID != "" {
+				threadID, err = resolveThreadRef(ctx, store, threadID)
+				if err != nil {
+					return err
+				}
+			}
+			var senderIDs []string
+			if from != "" {
+				senderIDs, err = resolveSenderRef(ctx, store, from)
+				if err != nil {
+					return err
+				}
+			}
+
+			n, err := store.CountMessages(ctx, beeper.MessageCountOptions{
+				MessageListOptions: beeper.MessageListOptions{
+					ThreadID:        threadID,
+					After:           afterTime,
+					Before:          beforeTime,
+					IncludeDeleted:  includeDeleted,
+					SenderIDs:       senderIDs,
+					SkipUndecrypted: skipUndecrypted,
+					IncludeExpired:  includeExpired,
+					Type:            msgType,
+					UnreadOnly:      unreadOnly,
+				},
+				AccountID: accountID,
+				SentByMe:  mine,
+				Received:  received,
+			})
+			if err != nil {
+				return err
+			}
+			return writeCount(app, n)
+		},
+	}
+
+	cmd.Flags().StringVar(&threadID, "thread", "", "only count this thread (ID or name); default all threads")
+	cmd.Flags().StringVar(&accountID, "account", "", "only count threads of this account/platform ID")
+	cmd.Flags().IntVar(&days, "days", 0, "only count messages from the last N days")
+	cmd.Flags().StringVar(&after, "after", "", "only count messages after this time: RFC3339, YYYY-MM-DD, yesterday, last monday, 2w, 3d ago, ...")
+	cmd.Flags().StringVar(&since, "since", "", "same as --after, e.g. --since today")
+	cmd.Flags().StringVar(&before, "before", "", "only count messages before this time (same forms as --after)")
+	cmd.MarkFlagsMutuallyExclusive("after", "since")
+	cmd.Flags().StringVar(&msgType, "type", "", "only messages of this kind (image, video, audio, file, location, link) or type (TEXT, STICKER, ...)")
+	cmd.Flags().StringVar(&from, "from", "", "only messages sent by this participant (ID or contact name)")
+	cmd.Flags().BoolVar(&mine, "mine", false, "only messages you sent")
+	cmd.Flags().BoolVar(&received, "received", false, "only messages you received")
+	cmd.MarkFlagsMutuallyExclusive("mine", "received")
+	cmd.MarkFlagsMutuallyExclusive("mine", "from")
+	cmd.MarkFlagsMutuallyExclusive("received", "from")
+	cmd.Flags().BoolVar(&includeDeleted, "include-deleted", false, "also count deleted messages")
+	cmd.Flags().BoolVar(&includeExpired, "include-expired", false, "also count disappearing messages that expired")
+	cmd.Flags().BoolVar(&skipUndecrypted, "skip-undecrypted", false, "leave out messages Beeper has not decrypted yet")
+	cmd.Flags().BoolVar(&unreadOnly, "unread-only", false, "only messages after your read marker (requires a thread)")
+
+	return cmd
+}
+
+func newThreadsCountCmd(app *App) *cobra.Command {
+	var filter threadFilter
+
+	cmd := &cobra.Command{
+		Use:   "count",
+		Short: "Count threads matching the threads list filters",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx := context.Background()
+			store, dbPath, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+			opts, err := filter.options(ctx, cmd, app, store, dbPath)
+			if err != nil {
+				return err
+			}
+
+			n, err := store.CountThreads(ctx, opts)
+			if err != nil {
+				return err
+			}
+			return writeCount(app, n)
+		},
+	}
+
+	filter.register(cmd)
+
+	return cmd
+}
@@ -1,17 +1,33 @@
 package cli
 
 import (
+	"bytes"
 	"context"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"os"
+	"slices"
+	"strings"
 
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
 	"github.com/spf13/cobra"
 )
 
+type dbViews struct {
+	Path    string   `json:"path"`
+	Dialect string   `json:"dialect"`
+	Views   []string `json:"views"`
+}
+
 type dbInfo struct {
 	Path      string   `json:"path"`
 	HasFTS    bool     `json:"hasFts"`
 	ReadOnly  bool     `json:"readOnly"`
 	BridgeDBs []string `json:"bridgeDbs,omitempty"`
+	// Undecrypted counts messages Beeper has not decrypted yet, per thread.
+	Undecrypted []beeper.UndecryptedCount `json:"undecrypted"`
 }
 
 func newDBCmd(app *App) *cobra.Command {
@@ -21,6 +37,9 @@ func newDBCmd(app *App) *cobra.Command {
 	}
 
 	cmd.AddCommand(newDBInfoCmd(app))
+	cmd.AddCommand(newDBViewsCmd(app))
+	cmd.AddCommand(newDBQueryCmd(app))
+	cmd.AddCommand(newDBBackupCmd(app))
 	return cmd
 }
 
@@ -43,12 +62,17 @@ func newDBInfoCmd(app *App) *cobra.Command {
 				return err
 			}
 
-			info := dbInfo{Path: path, HasFTS: hasFTS, ReadOnly: true}
+			undecrypted, err := store.UndecryptedCounts(ctx)
+			if err != nil {
+				return err
+			}
+
+			info := dbInfo{Path: path, HasFTS: hasFTS, ReadOnly: true, Undecrypted: undecrypted}
 			if bridges := store.BridgeDBs(); len(bridges) > 0 {
 				info.BridgeDBs = bridges
 			}
 			if app.JSON {
-				return writeJSON(info)
+				return app.write(info)
 			}
 
 			fmt.Printf("Path: %s\n", info.Path)
@@ -57,9 +81,188 @@ func newDBInfoCmd(app *App) *cobra.Command {
 			if len(info.BridgeDBs) > 0 {
 				fmt.Printf("Bridge DBs: %d\n", len(info.BridgeDBs))
 			}
+			total := 0
+			for _, count := range info.Undecrypted {
+				total += count.Messages
+			}
+			fmt.Printf("Undecrypted messages: %d\n", total)
+			for _, count := range info.Undecrypted {
+				fmt.Printf("  %s: %d\n", safe(count.Name), count.Messages)
+			}
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func newDBViewsCmd(app *App) *cobra.Command {
+	var outPath string
+	var dialect string
+	var catalog string
+
+	cmd := &cobra.Command{
+		Use:   "views",
+		Short: "Generate SQL views that flatten threads and messages into typed columns",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if dialect != string(beeper.DialectDuckDB) && !cmd.Flags().Changed("catalog") {
+				catalog = ""
+			}
+			sql, err := beeper.GenerateViews(beeper.ViewOptions{
+				Dialect: beeper.ViewDialect(dialect),
+				Catalog: catalog,
+			})
+			if err != nil {
+				return err
+			}
+
+			if outPath == "" || outPath == "-" {
+				_, err := fmt.Print(sql)
+				return err
+			}
+			if err := os.WriteFile(outPath, []byte(sql), 0o644); err != nil {
+				return err
+			}
+			if app.JSON {
+				return app.write(dbViews{Path: outPath, Dialect: dialect, Views: beeper.ViewNames})
+			}
+			fmt.Printf("Wrote %d views to %s\n", len(beeper.ViewNames), outPath)
 			return nil
 		},
 	}
 
+	cmd.Flags().StringVar(&outPath, "out", "", "write SQL to this file (default: stdout)")
+	cmd.Flags().StringVar(&dialect, "dialect", string(beeper.DialectDuckDB), "SQL dialect: duckdb|sqlite")
+	cmd.Flags().StringVar(&catalog, "catalog", "beeper", "name the Beeper database is attached as (duckdb only by default)")
+
 	return cmd
 }
+
+func newDBQueryCmd(app *App) *cobra.Command {
+	var format string
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "query <sql>",
+		Short: "Run a read-only SELECT against the Beeper database",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if app.JSON && !cmd.Flags().Changed("format") {
+				format = app.Output
+			}
+			if !slices.Contains(outputFormats, format) {
+				return fmt.Errorf("invalid format %q (expected table, csv, json, or ndjson)", format)
+			}
+			if err := beeper.ValidateSelect(args[0]); err != nil {
+				return err
+			}
+
+			ctx := context.Background()
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			result, err := store.Query(ctx, args[0], limit)
+			if err != nil {
+				return err
+			}
+			if result.Truncated {
+				fmt.Fprintln(os.Stderr, outputLocale.Tf("showing the first %d rows; raise --limit (0 for all) to see more", limit))
+			}
+
+			switch format {
+			case outputJSON:
+				return writeOutput(os.Stdout, outputJSON, result)
+			case outputNDJSON:
+				for _, row := range result.Rows {
+					line, err := queryObject(result.Columns, row)
+					if err != nil {
+						return err
+					}
+					if _, err := fmt.Printf("%s\n", line); err != nil {
+						return err
+					}
+				}
+				return nil
+			case outputCSV:
+				w := csv.NewWriter(os.Stdout)
+				if err := w.Write(result.Columns); err != nil {
+					return err
+				}
+				for _, row := range result.Rows {
+					if err := w.Write(queryRecord(row, "")); err != nil {
+						return err
+					}
+				}
+				w.Flush()
+				return w.Error()
+			}
+
+			w := newTabWriter()
+			if err := writeLine(w, strings.Join(result.Columns, "\t")); err != nil {
+				return err
+			}
+			for _, row := range result.Rows {
+				record := queryRecord(row, "NULL")
+				for i, v := range record {
+					record[i] = strings.NewReplacer("\t", " ", "\n", " ").Replace(v)
+				}
+				if err := writeLine(w, strings.Join(record, "\t")); err != nil {
+					return err
+				}
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", outputTable, "output format: table|csv|json|ndjson (overrides --output)")
+	cmd.Flags().IntVar(&limit, "limit", 1000, "maximum rows to return (0 for all)")
+
+	return cmd
+}
+
+// queryObject encodes one result row as a JSON object with the columns in
+// query order.
+func queryObject(columns []string, row []any) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, col := range columns {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(col)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(row[i])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// queryRecord formats one result row as strings; binary blobs are shown as
+// SQL hex literals and NULL as null.
+func queryRecord(row []any, null string) []string {
+	record := make([]string, len(row))
+	for i, v := range row {
+		switch v := v.(type) {
+		case nil:
+			record[i] = null
+		case []byte:
+			record[i] = "x'" + hex.EncodeToString(v) + "'"
+		default:
+			record[i] = fmt.Sprint(v)
+		}
+	}
+	return record
+}
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/backup"
+	"github.com/spf13/cobra"
+)
+
+// dbBackup reports a db backup run.
+type dbBackup struct {
+	Path    string          `json:"path"`
+	Size    int64           `json:"size"`
+	Policy  string          `json:"policy,omitempty"`
+	Removed []backup.Backup `json:"removed,omitempty"`
+}
+
+func newDBBackupCmd(app *App) *cobra.Command {
+	var outDir string
+	var rotate string
+
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Copy index.db into a backup directory, optionally pruning old copies",
+		Long: "Write a consistent copy of index.db (WAL included) to --out as index-<UTC time>.db. " +
+			"With --rotate, copies outside the retention policy are deleted afterwards, so the command can run from cron.",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if outDir == "" {
+				return fmt.Errorf("--out is required")
+			}
+			var policy backup.Policy
+			if rotate != "" {
+				var err error
+				policy, err = backup.ParsePolicy(rotate)
+				if err != nil {
+					return err
+				}
+			}
+			if err := os.MkdirAll(outDir, 0o700); err != nil {
+				return err
+			}
+
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			name := backup.FileName(time.Now())
+			path := filepath.Join(outDir, name)
+			// Written under a hidden name first, so a pruning run never sees
+			// a partial copy.
+			tmpPath := filepath.Join(outDir, "."+name+".tmp")
+			if err := store.Backup(context.Background(), tmpPath); err != nil {
+				_ = os.Remove(tmpPath)
+				return err
+			}
+			if err := os.Rename(tmpPath, path); err != nil {
+				_ = os.Remove(tmpPath)
+				return err
+			}
+			info, err := os.Stat(path)
+			if err != nil {
+				return err
+			}
+
+			result := dbBackup{Path: path, Size: info.Size()}
+			if policy != nil {
+				result.Policy = policy.String()
+				result.Removed, err = backup.Prune(outDir, policy)
+				if err != nil {
+					return err
+				}
+			}
+
+			if app.JSON {
+				return app.write(result)
+			}
+			fmt.Printf("Wrote %s (%.1f MB)\n", result.Path, float64(result.Size)/(1<<20))
+			for _, b := range result.Removed {
+				fmt.Printf("Removed %s\n", b.Path)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&outDir, "out", "", "backup directory (created if missing)")
+	cmd.Flags().StringVar(&rotate, "rotate", "", "retention policy, e.g. keep=7d,4w,12m: newest copy of each of the last 7 days, 4 weeks, and 12 months (h and y work too)")
+
+	return cmd
+}
@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// mutuallyExclusiveAnnotation is where cobra records the groups of
+// MarkFlagsMutuallyExclusive on each flag.
+const mutuallyExclusiveAnnotation = "cobra_annotation_mutually_exclusive"
+
+// enrichmentFlags are the global flags that can also be configured per
+// command, so a scripted command can skip enrichment the others keep.
+var enrichmentFlags = []string{"no-names", "no-participants", "no-bridge"}
+
+// applyCommandDefaults sets the flags configured for cmd under "commands"
+// as if they had been passed, unless the user passed them (or a flag
+// exclusive with them) on the command line. Unknown flags in cmd's section
+// fail, so typos are not silently ignored; sections naming no command only
+// warn, so one typo does not break every other command.
+func (a *App) applyCommandDefaults(cmd *cobra.Command) error {
+	paths := make([]string, 0, len(a.Config.Commands))
+	for path := range a.Config.Commands {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	root := cmd.Root()
+	path := ""
+	for _, candidate := range paths {
+		target, _, err := root.Find(strings.Fields(candidate))
+		if err != nil || target == root || target.CommandPath() != root.Name()+" "+strings.Join(strings.Fields(candidate), " ") {
+			fmt.Fprintf(os.Stderr, "warning: config commands: unknown command %q, ignoring it\n", candidate)
+			continue
+		}
+		if target == cmd && path == "" {
+			path = candidate
+		}
+	}
+	if path == "" {
+		return nil
+	}
+
+	defaults := a.Config.Commands[path]
+	names := make([]string, 0, len(defaults))
+	for name := range defaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if cmd.LocalNonPersistentFlags().Lookup(name) == nil && !slices.Contains(enrichmentFlags, name) {
+			return fmt.Errorf("invalid config: commands.%s: unknown flag --%s", path, name)
+		}
+	}
+
+	// Flags set on the command line win, and so do their exclusive
+	// alternatives: a configured --label must not clash with --all.
+	explicit := map[string]bool{}
+	cmd.Flags().Visit(func(f *pflag.Flag) { explicit[f.Name] = true })
+	for _, name := range names {
+		flag := cmd.Flags().Lookup(name)
+		if explicit[name] || excludedBy(flag, explicit) {
+			continue
+		}
+		values, err := flagValues(defaults[name])
+		if err != nil {
+			return fmt.Errorf("invalid config: commands.%s.%s: %w", path, name, err)
+		}
+		for _, value := range values {
+			if err := cmd.Flags().Set(name, value); err != nil {
+				return fmt.Errorf("invalid config: commands.%s.%s: %w", path, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// excludedBy reports whether flag is mutually exclusive with one of the
+// explicitly set flags.
+func excludedBy(flag *pflag.Flag, explicit map[string]bool) bool {
+	for _, group := range flag.Annotations[mutuallyExclusiveAnnotation] {
+		for _, other := range strings.Fields(group) {
+			if other != flag.Name && explicit[other] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// flagValues turns a JSON config value into flag arguments; a list sets a
+// repeatable flag once per element.
+func flagValues(v any) ([]string, error) {
+	switch v := v.(type) {
+	case string:
+		return []string{v}, nil
+	case bool:
+		return []string{strconv.FormatBool(v)}, nil
+	case float64:
+		return []string{strconv.FormatFloat(v, 'f', -1, 64)}, nil
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			value, err := flagValues(item)
+			if err != nil || len(value) != 1 {
+				return nil, fmt.Errorf("list items must be strings, numbers, or booleans")
+			}
+			values = append(values, value[0])
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("expected a string, number, boolean, or list")
+	}
+}
@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/KrauseFx/beeper-cli/internal/config"
+	"github.com/spf13/cobra"
+)
+
+func TestApplyCommandDefaults(t *testing.T) {
+	app := &App{Config: config.Config{Commands: map[string]map[string]any{
+		"search":        {"limti": 5.0},
+		"messages list": {"limit": 7.0},
+		"no such":       {"limit": 1.0},
+	}}}
+	root := newRootCmd(app)
+	find := func(path string) *cobra.Command {
+		cmd, _, err := root.Find(strings.Fields(path))
+		if err != nil {
+			t.Fatalf("find %s: %v", path, err)
+		}
+		return cmd
+	}
+
+	// A typo in another command's section and an unknown section do not
+	// break this command.
+	list := find("messages list")
+	if err := app.applyCommandDefaults(list); err != nil {
+		t.Fatalf("messages list: %v", err)
+	}
+	if got := list.Flags().Lookup("limit").Value.String(); got != "7" {
+		t.Fatalf("expected the configured limit, got %s", got)
+	}
+	if err := app.applyCommandDefaults(find("threads list")); err != nil {
+		t.Fatalf("threads list: %v", err)
+	}
+
+	err := app.applyCommandDefaults(find("search"))
+	if err == nil || !strings.Contains(err.Error(), "unknown flag --limti") {
+		t.Fatalf("expected the search section's typo to fail search, got %v", err)
+	}
+}
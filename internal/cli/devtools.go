@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/synth"
+	"github.com/spf13/cobra"
+)
+
+func newDevtoolsCmd(app *App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "devtools",
+		Short: "Developer tools for testing and benchmarking",
+	}
+
+	cmd.AddCommand(newGenDBCmd(app))
+	return cmd
+}
+
+func newGenDBCmd(app *App) *cobra.Command {
+	var outDir string
+	var threads int
+	var messages string
+	var platforms string
+	var days int
+	var seed int64
+	var fts bool
+	var bridges bool
+	var schemaPath string
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "gen-db",
+		Short: "Generate a synthetic Beeper index.db and bridge DBs for tests and benchmarks",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			count, err := parseCount(messages)
+			if err != nil {
+				return fmt.Errorf("invalid --messages: %w", err)
+			}
+			path := filepath.Join(outDir, "index.db")
+			if _, err := os.Stat(path); err == nil && !force {
+				return fmt.Errorf("%s already exists (use --force to overwrite)", path)
+			}
+
+			opts := synth.Options{
+				Dir:      outDir,
+				Threads:  threads,
+				Messages: count,
+				Days:     days,
+				Seed:     seed,
+				FTS:      fts,
+				Bridges:  bridges,
+			}
+			if platforms != "" {
+				for _, p := range strings.Split(platforms, ",") {
+					if p = strings.TrimSpace(p); p != "" {
+						opts.Platforms = append(opts.Platforms, p)
+					}
+				}
+			}
+			if schemaPath != "" {
+				data, err := os.ReadFile(schemaPath)
+				if err != nil {
+					return err
+				}
+				opts.Schema = string(data)
+			}
+
+			start := time.Now()
+			result, err := synth.Generate(context.Background(), opts)
+			if err != nil {
+				return err
+			}
+			if app.JSON {
+				return app.write(result)
+			}
+
+			fmt.Printf("Wrote %s in %s\n", result.Path, time.Since(start).Round(time.Millisecond))
+			fmt.Printf("Threads: %d\n", result.Threads)
+			fmt.Printf("Participants: %d\n", result.Participants)
+			fmt.Printf("Messages: %d\n", result.Messages)
+			if fts {
+				fmt.Printf("FTS: %t\n", result.FTS)
+			}
+			if len(result.BridgeDBs) > 0 {
+				fmt.Printf("Bridge DBs: %d\n", len(result.BridgeDBs))
+			}
+			if len(result.Skipped) > 0 {
+				fmt.Printf("Skipped tables missing from schema: %s\n", strings.Join(result.Skipped, ", "))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&outDir, "out", "synthetic-beeper", "directory to write index.db (and local-*/megabridge.db) into")
+	cmd.Flags().IntVar(&threads, "threads", 100, "number of threads")
+	cmd.Flags().StringVar(&messages, "messages", "10000", "number of messages (accepts 1e6 notation)")
+	cmd.Flags().StringVar(&platforms, "platforms", "", "comma-separated account IDs (default: whatsapp,telegram,signal,instagram,discord)")
+	cmd.Flags().IntVar(&days, "days", 365, "history span in days, ending now")
+	cmd.Flags().Int64Var(&seed, "seed", 1, "random seed; the same seed and flags produce the same data")
+	cmd.Flags().BoolVar(&fts, "fts", false, "also build Beeper's FTS5 message index when sqlite supports it")
+	cmd.Flags().BoolVar(&bridges, "bridges", true, "write bridge DBs that name DM partners")
+	cmd.Flags().StringVar(&schemaPath, "schema", "", "SQL file with CREATE statements to use instead of the built-in schema")
+	cmd.Flags().BoolVar(&force, "force", false, "overwrite an existing index.db")
+
+	return cmd
+}
+
+// parseCount parses a non-negative whole number, allowing scientific
+// notation such as 1e6.
+func parseCount(value string) (int, error) {
+	if n, err := strconv.Atoi(value); err == nil {
+		if n < 0 {
+			return 0, errors.New("must not be negative")
+		}
+		return n, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number", value)
+	}
+	if f < 0 || f != math.Trunc(f) || f > math.MaxInt32 {
+		return 0, fmt.Errorf("%q is not a whole number of messages", value)
+	}
+	return int(f), nil
+}
@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+	"github.com/KrauseFx/beeper-cli/internal/report"
+	"github.com/spf13/cobra"
+)
+
+func newDigestCmd(app *App) *cobra.Command {
+	var since string
+	var until string
+	var accountID string
+	var limit int
+	var format string
+	var outPath string
+
+	cmd := &cobra.Command{
+		Use:   "digest",
+		Short: "Summarize the conversations active since a point in time",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			now := time.Now()
+			sinceTime, err := parseSinceFlag(since, now)
+			if err != nil {
+				return err
+			}
+			untilTime, err := parseDateFlag(until, true)
+			if err != nil {
+				return err
+			}
+			if untilTime == nil {
+				untilTime = &now
+			}
+			formatValue, err := parseMessageFormat(format)
+			if err != nil {
+				return err
+			}
+
+			ctx := context.Background()
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			digest, err := report.BuildDigest(ctx, store, report.DigestOptions{
+				Since:     sinceTime,
+				Until:     *untilTime,
+				AccountID: accountID,
+				Limit:     limit,
+				Format:    formatValue,
+			})
+			if err != nil {
+				return err
+			}
+
+			var w io.Writer = os.Stdout
+			if outPath != "" {
+				f, err := os.Create(outPath)
+				if err != nil {
+					return err
+				}
+				defer func() {
+					_ = f.Close()
+				}()
+				w = f
+			}
+			if app.JSON {
+				return writeOutput(w, app.Output, digest)
+			}
+			return report.WriteDigestMarkdown(w, digest)
+		},
+	}
+
+	cmd.Flags().StringVar(&since, "since", "24h", "start of the window: a time ago (24h, 7d, 2w), yesterday, a weekday, YYYY-MM-DD, or RFC3339")
+	cmd.Flags().StringVar(&until, "until", "", "end of the window, in the same forms as --since (default: now)")
+	cmd.Flags().StringVar(&accountID, "account", "", "filter by account/platform ID")
+	cmd.Flags().IntVar(&limit, "limit", 50, "max number of conversations, busiest first")
+	cmd.Flags().StringVar(&format, "format", string(beeper.FormatRich), "message format: plain|rich")
+	cmd.Flags().StringVar(&outPath, "out", "", "write the digest to a file instead of stdout")
+
+	return cmd
+}
@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"errors"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+)
+
+// Exit codes. Failures the beeper package reports with a sentinel error
+// get their own code so scripts can branch on them; everything else
+// exits with exitError.
+const (
+	exitError             = 1
+	exitUsage             = 2
+	exitDBNotFound        = 3
+	exitSchemaUnsupported = 4
+	exitNotFound          = 5
+	exitFTSUnavailable    = 6
+)
+
+// exitCode maps a command error to the process exit code.
+func exitCode(err error) int {
+	var usage usageError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &usage):
+		return exitUsage
+	case errors.Is(err, beeper.ErrDBNotFound):
+		return exitDBNotFound
+	case errors.Is(err, beeper.ErrSchemaUnsupported):
+		return exitSchemaUnsupported
+	case errors.Is(err, beeper.ErrThreadNotFound), errors.Is(err, beeper.ErrMessageNotFound):
+		return exitNotFound
+	case errors.Is(err, beeper.ErrFTSUnavailable):
+		return exitFTSUnavailable
+	default:
+		return exitError
+	}
+}
+
+// usageError marks invalid flags and arguments.
+type usageError struct{ err error }
+
+func (e usageError) Error() string { return e.err.Error() }
+func (e usageError) Unwrap() error { return e.err }
+
+// dbPathError marks a failure to locate the database as
+// beeper.ErrDBNotFound, keeping the message listing the paths tried.
+type dbPathError struct{ err error }
+
+func (e dbPathError) Error() string   { return e.err.Error() }
+func (e dbPathError) Unwrap() []error { return []error{e.err, beeper.ErrDBNotFound} }
@@ -0,0 +1,30 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+)
+
+func TestExitCode(t *testing.T) {
+	cases := []struct {
+		err  error
+		want int
+	}{
+		{nil, 0},
+		{errors.New("boom"), exitError},
+		{usageError{errors.New("unknown flag: --x")}, exitUsage},
+		{dbPathError{errors.New("could not find Beeper database")}, exitDBNotFound},
+		{fmt.Errorf("open: %w", beeper.ErrSchemaUnsupported), exitSchemaUnsupported},
+		{fmt.Errorf("%w: !x", beeper.ErrThreadNotFound), exitNotFound},
+		{fmt.Errorf("%w: $x", beeper.ErrMessageNotFound), exitNotFound},
+		{fmt.Errorf("%w: no index", beeper.ErrFTSUnavailable), exitFTSUnavailable},
+	}
+	for _, tc := range cases {
+		if got := exitCode(tc.err); got != tc.want {
+			t.Fatalf("exitCode(%v) = %d, want %d", tc.err, got, tc.want)
+		}
+	}
+}
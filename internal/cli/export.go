@@ -0,0 +1,394 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+	"github.com/KrauseFx/beeper-cli/internal/export"
+	"github.com/spf13/cobra"
+)
+
+type exportedFile struct {
+	ThreadID string `json:"threadId"`
+	Path     string `json:"path"`
+	Messages int    `json:"messages"`
+	// Changed is false when the file already held identical content and
+	// was left untouched.
+	Changed bool `json:"changed"`
+}
+
+type exportedTable struct {
+	Table string `json:"table"`
+	Path  string `json:"path"`
+	Rows  int64  `json:"rows"`
+}
+
+func newExportCmd(app *App) *cobra.Command {
+	var threadRefs []string
+	var threadsFile string
+	var outDir string
+	var fileFormat string
+	var format string
+	var withProvenance bool
+	var skipUndecrypted bool
+	var sessions bool
+	var sessionGap string
+	var from string
+	var include []string
+	var exclude []string
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export full thread histories to files",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			refs := slices.Clone(threadRefs)
+			if threadsFile != "" {
+				fileRefs, err := export.ReadThreadsFile(threadsFile)
+				if err != nil {
+					return err
+				}
+				refs = append(refs, fileRefs...)
+			}
+			if len(refs) == 0 {
+				return fmt.Errorf("at least one --thread or --threads-file is required")
+			}
+
+			if fileFormat != "json" && fileFormat != "parquet" && fileFormat != "text-canonical" {
+				return fmt.Errorf("invalid file format %q (expected json, parquet, or text-canonical)", fileFormat)
+			}
+			if sessions && fileFormat == "text-canonical" {
+				return fmt.Errorf("--sessions is not supported with --file-format text-canonical")
+			}
+			formatValue, err := parseMessageFormat(format)
+			if err != nil {
+				return err
+			}
+			gap := time.Duration(0)
+			if sessions {
+				gap, err = parseDuration(sessionGap)
+				if err != nil {
+					return err
+				}
+				if gap <= 0 {
+					return fmt.Errorf("--session-gap must be positive")
+				}
+			}
+			opts := exportOptions{format: formatValue, withProvenance: withProvenance, skipUndecrypted: skipUndecrypted, sessionGap: gap, include: include, exclude: exclude}
+
+			ctx := context.Background()
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			if from != "" {
+				opts.senderIDs, err = resolveSenderRef(ctx, store, from)
+				if err != nil {
+					return err
+				}
+			}
+
+			threadIDs := make([]string, 0, len(refs))
+			for _, ref := range refs {
+				threadID, err := resolveThreadRef(ctx, store, ref)
+				if err != nil {
+					return err
+				}
+				threadIDs = append(threadIDs, threadID)
+			}
+
+			if err := os.MkdirAll(outDir, 0o755); err != nil {
+				return err
+			}
+
+			if fileFormat == "parquet" {
+				tables, err := exportParquet(ctx, store, threadIDs, outDir, opts)
+				if err != nil {
+					return err
+				}
+				if app.JSON {
+					return app.write(tables)
+				}
+				w := newTabWriter()
+				if err := writeHeader(w, "TABLE\tROWS\tPATH"); err != nil {
+					return err
+				}
+				for _, table := range tables {
+					if err := writef(w, "%s\t%d\t%s\n", table.Table, table.Rows, table.Path); err != nil {
+						return err
+					}
+				}
+				return w.Flush()
+			}
+
+			written := []exportedFile{}
+			// Threads whose IDs sanitize to the same file name get a
+			// suffix instead of overwriting each other.
+			used := map[string]string{}
+			for _, threadID := range threadIDs {
+				exportThread, ext := exportThreadJSON, ".json"
+				if fileFormat == "text-canonical" {
+					exportThread, ext = exportThreadText, ".txt"
+				}
+				path := filepath.Join(outDir, export.UniqueFileName(threadID, ext, used))
+				file, err := exportThread(ctx, store, threadID, path, opts)
+				if err != nil {
+					return fmt.Errorf("export %s: %w", threadID, err)
+				}
+				written = append(written, file)
+			}
+
+			if app.JSON {
+				return app.write(written)
+			}
+
+			w := newTabWriter()
+			if err := writeHeader(w, "THREAD_ID\tMESSAGES\tSTATUS\tPATH"); err != nil {
+				return err
+			}
+			for _, file := range written {
+				status := "unchanged"
+				if file.Changed {
+					status = "written"
+				}
+				if err := writef(w, "%s\t%d\t%s\t%s\n", file.ThreadID, file.Messages, status, file.Path); err != nil {
+					return err
+				}
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.AddCommand(newExportLegalCmd(app))
+	cmd.AddCommand(newExportGitCmd(app))
+	cmd.AddCommand(newExportContextCmd(app))
+	cmd.AddCommand(newExportFeedCmd(app))
+	cmd.AddCommand(newExportCallsCmd(app))
+	cmd.AddCommand(newExportFlashcardsCmd(app))
+	cmd.AddCommand(newExportVerifyCmd(app))
+
+	cmd.Flags().StringArrayVar(&threadRefs, "thread", nil, "thread ID or name to export (repeatable)")
+	cmd.Flags().StringVar(&threadsFile, "threads-file", "", "file with one thread ID or name per line (# comments allowed)")
+	cmd.Flags().StringVar(&outDir, "out", ".", "output directory")
+	cmd.Flags().StringVar(&fileFormat, "file-format", "json", "file type: json (one file per thread), parquet (messages, threads, participants tables), or text-canonical (one diffable .txt per thread)")
+	cmd.Flags().StringVar(&format, "format", string(beeper.FormatRich), "message format: plain|rich")
+	cmd.Flags().BoolVar(&withProvenance, "with-provenance", false, "include platform, remote ID, and sent-via fields")
+	cmd.Flags().BoolVar(&skipUndecrypted, "skip-undecrypted", false, "leave out messages Beeper has not decrypted yet instead of showing them as [Not yet decrypted]")
+	cmd.Flags().BoolVar(&sessions, "sessions", false, "group messages into conversation sessions (adds session IDs and summaries)")
+	cmd.Flags().StringVar(&sessionGap, "session-gap", beeper.DefaultSessionGap.String(), "silence that starts a new session (with --sessions)")
+	cmd.Flags().StringVar(&from, "from", "", "only messages sent by this participant (ID or contact name)")
+	cmd.Flags().StringArrayVar(&include, "include", nil, "only messages containing this text (repeatable; any may match)")
+	cmd.Flags().StringArrayVar(&exclude, "exclude", nil, "leave out messages containing this text (repeatable)")
+
+	return cmd
+}
+
+// exportOptions controls how each exported thread is loaded.
+type exportOptions struct {
+	format         beeper.MessageFormat
+	withProvenance bool
+	// skipUndecrypted leaves out messages Beeper has not decrypted yet.
+	skipUndecrypted bool
+	// sessionGap enables session segmentation when positive.
+	sessionGap time.Duration
+	// senderIDs, include, and exclude filter the exported messages in the
+	// query (see beeper.MessageListOptions).
+	senderIDs []string
+	include   []string
+	exclude   []string
+}
+
+// listOptions returns the options listing every exported message of a
+// thread.
+func (o exportOptions) listOptions(threadID string) beeper.MessageListOptions {
+	return beeper.MessageListOptions{
+		ThreadID:        threadID,
+		All:             true,
+		Format:          o.format,
+		WithProvenance:  o.withProvenance,
+		SkipUndecrypted: o.skipUndecrypted,
+		IncludeExpired:  true,
+		SenderIDs:       o.senderIDs,
+		Include:         o.include,
+		Exclude:         o.exclude,
+	}
+}
+
+// loadThreadExport loads a thread and all of its messages, oldest first,
+// for the exports that need them all at once: markdown (messages export
+// and export git), export legal, and sessions. The others stream them with
+// Store.IterMessages.
+func loadThreadExport(ctx context.Context, store *beeper.Store, threadID string, opts exportOptions) (export.ThreadExport, error) {
+	thread, err := store.GetThread(ctx, threadID, true)
+	if err != nil {
+		return export.ThreadExport{}, err
+	}
+	messages, err := store.ListMessages(ctx, opts.listOptions(threadID))
+	if err != nil {
+		return export.ThreadExport{}, err
+	}
+	slices.Reverse(messages)
+
+	result := export.ThreadExport{Thread: thread, Messages: messages}
+	if opts.sessionGap > 0 {
+		for i := range messages {
+			messages[i].ThreadName = thread.DisplayName
+		}
+		result.Sessions = beeper.SegmentSessions(messages, opts.sessionGap)
+	}
+	return result, nil
+}
+
+// exportThreadJSON writes a thread as canonical JSON, streaming its
+// messages unless sessions need them all at once.
+func exportThreadJSON(ctx context.Context, store *beeper.Store, threadID string, path string, opts exportOptions) (exportedFile, error) {
+	if opts.sessionGap > 0 {
+		data, err := loadThreadExport(ctx, store, threadID, opts)
+		if err != nil {
+			return exportedFile{}, err
+		}
+		var buf bytes.Buffer
+		if err := export.WriteJSON(&buf, export.Canonical(data)); err != nil {
+			return exportedFile{}, err
+		}
+		changed, err := export.WriteFileIfChanged(path, buf.Bytes())
+		if err != nil {
+			return exportedFile{}, err
+		}
+		return exportedFile{ThreadID: threadID, Path: path, Messages: len(data.Messages), Changed: changed}, nil
+	}
+
+	thread, err := store.GetThread(ctx, threadID, true)
+	if err != nil {
+		return exportedFile{}, err
+	}
+	// lastActivity falls back to the newest exported message, which is
+	// needed before the messages are written.
+	var last time.Time
+	if thread.LastMessage.IsZero() {
+		newest := opts.listOptions(threadID)
+		newest.All, newest.Limit = false, 1
+		messages, err := store.ListMessages(ctx, newest)
+		if err != nil {
+			return exportedFile{}, err
+		}
+		if len(messages) > 0 {
+			last = messages[0].Timestamp
+		}
+	}
+	thread = export.CanonicalThread(thread, last)
+	count := 0
+	changed, err := export.WriteFileIfChangedFunc(path, func(w io.Writer) error {
+		messages := func(yield func(beeper.Message, error) bool) {
+			for msg, err := range store.IterMessages(ctx, opts.listOptions(threadID)) {
+				if !yield(export.CanonicalMessage(msg), err) {
+					return
+				}
+			}
+		}
+		var err error
+		count, err = export.StreamJSON(w, thread, messages)
+		return err
+	})
+	if err != nil {
+		return exportedFile{}, err
+	}
+	return exportedFile{ThreadID: threadID, Path: path, Messages: count, Changed: changed}, nil
+}
+
+// exportThreadText writes a thread as canonical text, one line per
+// message, streaming its messages.
+func exportThreadText(ctx context.Context, store *beeper.Store, threadID string, path string, opts exportOptions) (exportedFile, error) {
+	count := 0
+	changed, err := export.WriteFileIfChangedFunc(path, func(w io.Writer) error {
+		var err error
+		count, err = export.StreamCanonicalText(w, store.IterMessages(ctx, opts.listOptions(threadID)))
+		return err
+	})
+	if err != nil {
+		return exportedFile{}, err
+	}
+	return exportedFile{ThreadID: threadID, Path: path, Messages: count, Changed: changed}, nil
+}
+
+// addParquetThread writes one thread to the Parquet tables, streaming its
+// messages unless sessions need them all at once.
+func addParquetThread(ctx context.Context, store *beeper.Store, tables *export.ParquetTables, threadID string, opts exportOptions) error {
+	if opts.sessionGap > 0 {
+		data, err := loadThreadExport(ctx, store, threadID, opts)
+		if err != nil {
+			return err
+		}
+		return tables.Add(data)
+	}
+
+	thread, err := store.GetThread(ctx, threadID, true)
+	if err != nil {
+		return err
+	}
+	if err := tables.AddThread(thread); err != nil {
+		return err
+	}
+	for msg, err := range store.IterMessages(ctx, opts.listOptions(threadID)) {
+		if err != nil {
+			return err
+		}
+		if err := tables.AddMessage(thread, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func exportParquet(ctx context.Context, store *beeper.Store, threadIDs []string, outDir string, opts exportOptions) ([]exportedTable, error) {
+	names := []string{"messages", "threads", "participants"}
+	files := make([]*os.File, 0, len(names))
+	defer func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}()
+	for _, name := range names {
+		f, err := os.Create(filepath.Join(outDir, name+".parquet"))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+
+	tables := export.NewParquetTables(files[0], files[1], files[2])
+	for _, threadID := range threadIDs {
+		if err := addParquetThread(ctx, store, tables, threadID, opts); err != nil {
+			return nil, fmt.Errorf("export %s: %w", threadID, err)
+		}
+	}
+	if err := tables.Close(); err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		if err := f.Close(); err != nil {
+			return nil, err
+		}
+	}
+	files = nil
+
+	written := []exportedTable{}
+	for i, w := range []*export.ParquetWriter{tables.Messages, tables.Threads, tables.Participants} {
+		written = append(written, exportedTable{
+			Table: names[i],
+			Path:  filepath.Join(outDir, names[i]+".parquet"),
+			Rows:  w.Rows(),
+		})
+	}
+	return written, nil
+}
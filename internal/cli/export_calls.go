@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+	"github.com/KrauseFx/beeper-cli/internal/export"
+	"github.com/spf13/cobra"
+)
+
+type exportedCalls struct {
+	Path    string `json:"path"`
+	Calls   int    `json:"calls"`
+	Changed bool   `json:"changed"`
+}
+
+func newExportCallsCmd(app *App) *cobra.Command {
+	var filter statsFilter
+	var format string
+	var outPath string
+
+	cmd := &cobra.Command{
+		Use:   "calls",
+		Short: "Write voice and video calls as an iCalendar file (or JSON)",
+		Long: "Write every detected call as a calendar event, so calls show up next to meetings in a calendar app.\n" +
+			"Rerun it from cron to keep a subscribed calendar current; an unchanged file is left untouched.",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if format != "ics" && format != "json" {
+				return fmt.Errorf("invalid --format %q (use ics|json)", format)
+			}
+
+			ctx := context.Background()
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			opts, err := filter.options(ctx, store)
+			if err != nil {
+				return err
+			}
+			calls, err := store.ListCalls(ctx, beeper.CallOptions{ThreadID: opts.ThreadID, AccountID: opts.AccountID, After: opts.After, Before: opts.Before})
+			if err != nil {
+				return err
+			}
+
+			var buf bytes.Buffer
+			if format == "json" {
+				enc := json.NewEncoder(&buf)
+				enc.SetIndent("", "  ")
+				err = enc.Encode(calls)
+			} else {
+				err = export.WriteCallsICS(&buf, calls)
+			}
+			if err != nil {
+				return err
+			}
+			if outPath == "-" {
+				_, err := os.Stdout.Write(buf.Bytes())
+				return err
+			}
+			changed, err := export.WriteFileIfChanged(outPath, buf.Bytes())
+			if err != nil {
+				return err
+			}
+
+			result := exportedCalls{Path: outPath, Calls: len(calls), Changed: changed}
+			if app.JSON {
+				return app.write(result)
+			}
+			fmt.Printf("Wrote %d calls to %s\n", result.Calls, result.Path)
+			return nil
+		},
+	}
+
+	filter.register(cmd)
+	cmd.Flags().StringVar(&format, "format", "ics", "output format: ics|json")
+	cmd.Flags().StringVar(&outPath, "out", "calls.ics", "output file (- for stdout)")
+
+	return cmd
+}
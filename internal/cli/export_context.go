@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+	"github.com/KrauseFx/beeper-cli/internal/export"
+	"github.com/spf13/cobra"
+)
+
+// exportedDossier reports an export context run.
+type exportedDossier struct {
+	Path      string   `json:"path"`
+	Bookmarks int      `json:"bookmarks"`
+	Missing   []string `json:"missing,omitempty"`
+}
+
+func newExportContextCmd(app *App) *cobra.Command {
+	var bookmarks bool
+	var threadRef string
+	var contextSize int
+	var format string
+	var outPath string
+
+	cmd := &cobra.Command{
+		Use:   "context",
+		Short: "Write bookmarked messages with the messages around them to one Markdown dossier",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if !bookmarks {
+				return fmt.Errorf("nothing to export: use --bookmarks")
+			}
+			if contextSize < 0 {
+				return fmt.Errorf("--context must not be negative")
+			}
+			formatValue, err := parseMessageFormat(format)
+			if err != nil {
+				return err
+			}
+
+			ctx := context.Background()
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			threadID := ""
+			if threadRef != "" {
+				threadID, err = resolveThreadRef(ctx, store, threadRef)
+				if err != nil {
+					return err
+				}
+			}
+			entries, err := app.loadBookmarks(ctx, store, threadID, beeper.SearchOptions{Context: contextSize, Format: formatValue})
+			if err != nil {
+				return err
+			}
+
+			dossier := export.BookmarkExport{Ran: time.Now(), Notes: map[int64]string{}}
+			result := exportedDossier{Path: outPath}
+			for _, entry := range entries {
+				if entry.Message == nil {
+					result.Missing = append(result.Missing, entry.EventID)
+					fmt.Fprintf(os.Stderr, "warning: bookmarked message %s no longer exists\n", entry.EventID)
+					continue
+				}
+				dossier.Results = append(dossier.Results, beeper.SearchResult{Match: *entry.Message, Context: entry.context})
+				dossier.Notes[entry.Message.ID] = entry.Note
+			}
+			result.Bookmarks = len(dossier.Results)
+
+			if outPath == "-" {
+				return export.WriteBookmarksMarkdown(os.Stdout, dossier)
+			}
+			f, err := os.Create(outPath)
+			if err != nil {
+				return err
+			}
+			if err := export.WriteBookmarksMarkdown(f, dossier); err != nil {
+				_ = f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+
+			if app.JSON {
+				return app.write(result)
+			}
+			fmt.Printf("Wrote %d bookmarks to %s\n", result.Bookmarks, result.Path)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&bookmarks, "bookmarks", false, "export every bookmark (see bookmarks add)")
+	cmd.Flags().StringVar(&threadRef, "thread", "", "only bookmarks in this thread (ID or name)")
+	cmd.Flags().IntVar(&contextSize, "context", 5, "include N messages before and after each bookmark")
+	cmd.Flags().StringVar(&format, "format", string(beeper.FormatRich), "message format: plain|rich")
+	cmd.Flags().StringVar(&outPath, "out", "bookmarks.md", "output file (- for stdout)")
+
+	return cmd
+}
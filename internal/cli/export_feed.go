@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+	"github.com/KrauseFx/beeper-cli/internal/export"
+	"github.com/spf13/cobra"
+)
+
+// feedsOPML is the subscription list export feed writes next to the feeds.
+const feedsOPML = "feeds.opml"
+
+func newExportFeedCmd(app *App) *cobra.Command {
+	var threadRefs []string
+	var threadsFile string
+	var outDir string
+	var baseURL string
+	var limit int
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "feed",
+		Short: "Write an Atom feed per thread and an OPML list of them for feed readers",
+		Long: "Write an Atom feed of each thread's newest messages, plus feeds.opml listing them, to a directory\n" +
+			"a feed reader can poll (e.g. served by any static web server). Rerun it from cron to update the feeds;\n" +
+			"unchanged feeds are left untouched.",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			refs := slices.Clone(threadRefs)
+			if threadsFile != "" {
+				fileRefs, err := export.ReadThreadsFile(threadsFile)
+				if err != nil {
+					return err
+				}
+				refs = append(refs, fileRefs...)
+			}
+			if len(refs) == 0 {
+				return fmt.Errorf("at least one --thread or --threads-file is required")
+			}
+			if limit <= 0 {
+				return fmt.Errorf("--limit must be positive")
+			}
+			formatValue, err := parseMessageFormat(format)
+			if err != nil {
+				return err
+			}
+			if baseURL != "" && !strings.HasSuffix(baseURL, "/") {
+				baseURL += "/"
+			}
+
+			ctx := context.Background()
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			if err := os.MkdirAll(outDir, 0o755); err != nil {
+				return err
+			}
+
+			written := []exportedFile{}
+			links := []export.FeedLink{}
+			used := map[string]string{}
+			for _, ref := range refs {
+				threadID, err := resolveThreadRef(ctx, store, ref)
+				if err != nil {
+					return err
+				}
+				thread, err := store.GetThread(ctx, threadID, false)
+				if err != nil {
+					return fmt.Errorf("export %s: %w", threadID, err)
+				}
+				messages, err := store.ListMessages(ctx, beeper.MessageListOptions{ThreadID: threadID, Limit: limit, Format: formatValue})
+				if err != nil {
+					return fmt.Errorf("export %s: %w", threadID, err)
+				}
+
+				name := export.UniqueFileName(threadID, ".atom", used)
+				// Without --base-url, feeds.opml links the feeds relatively
+				// and the feeds have no self link.
+				feed := export.Feed{Thread: thread, Messages: messages}
+				feedURL := name
+				if baseURL != "" {
+					feedURL = baseURL + url.PathEscape(name)
+					feed.SelfURL = feedURL
+				}
+				var buf bytes.Buffer
+				if err := export.WriteAtom(&buf, feed); err != nil {
+					return err
+				}
+				path := filepath.Join(outDir, name)
+				changed, err := export.WriteFileIfChanged(path, buf.Bytes())
+				if err != nil {
+					return err
+				}
+				written = append(written, exportedFile{ThreadID: threadID, Path: path, Messages: len(messages), Changed: changed})
+				links = append(links, export.FeedLink{Title: thread.DisplayName, URL: feedURL})
+			}
+
+			var buf bytes.Buffer
+			if err := export.WriteOPML(&buf, "Beeper threads", links); err != nil {
+				return err
+			}
+			if _, err := export.WriteFileIfChanged(filepath.Join(outDir, feedsOPML), buf.Bytes()); err != nil {
+				return err
+			}
+
+			if app.JSON {
+				return app.write(written)
+			}
+			w := newTabWriter()
+			if err := writeHeader(w, "THREAD_ID\tMESSAGES\tSTATUS\tPATH"); err != nil {
+				return err
+			}
+			for _, file := range written {
+				status := "unchanged"
+				if file.Changed {
+					status = "written"
+				}
+				if err := writef(w, "%s\t%d\t%s\t%s\n", file.ThreadID, file.Messages, status, file.Path); err != nil {
+					return err
+				}
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().StringArrayVar(&threadRefs, "thread", nil, "thread ID or name to write a feed for (repeatable)")
+	cmd.Flags().StringVar(&threadsFile, "threads-file", "", "file with one thread ID or name per line (# comments allowed)")
+	cmd.Flags().StringVar(&outDir, "out", "feeds", "output directory")
+	cmd.Flags().StringVar(&baseURL, "base-url", "", "URL the output directory is served at, for absolute feed links in feeds.opml")
+	cmd.Flags().IntVar(&limit, "limit", 50, "newest messages per feed")
+	cmd.Flags().StringVar(&format, "format", string(beeper.FormatPlain), "message format: plain|rich")
+
+	return cmd
+}
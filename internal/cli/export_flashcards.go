@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+	"github.com/KrauseFx/beeper-cli/internal/export"
+	"github.com/spf13/cobra"
+)
+
+type exportedFlashcards struct {
+	ThreadID string `json:"threadId"`
+	Path     string `json:"path"`
+	Cards    int    `json:"cards"`
+	Changed  bool   `json:"changed"`
+}
+
+func newExportFlashcardsCmd(app *App) *cobra.Command {
+	var threadRef string
+	var bookmarks bool
+	var include []string
+	var tags []string
+	var from string
+	var to string
+	var format string
+	var outPath string
+
+	cmd := &cobra.Command{
+		Use:   "flashcards",
+		Short: "Turn a thread's questions and answers into an Anki-importable TSV",
+		Long: "Pair each question in a thread (a message ending in \"?\") with the reply that follows, or with --bookmarks\n" +
+			"turn bookmarked answers into cards, and write them as a TSV file Anki imports with File > Import.\n" +
+			"Importing the file again updates existing cards instead of duplicating them.",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if threadRef == "" {
+				return fmt.Errorf("--thread is required")
+			}
+			after, err := parseDateFlag(from, false)
+			if err != nil {
+				return err
+			}
+			before, err := parseDateFlag(to, true)
+			if err != nil {
+				return err
+			}
+			formatValue, err := parseMessageFormat(format)
+			if err != nil {
+				return err
+			}
+
+			ctx := context.Background()
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			threadID, err := resolveThreadRef(ctx, store, threadRef)
+			if err != nil {
+				return err
+			}
+			thread, err := store.GetThread(ctx, threadID, false)
+			if err != nil {
+				return err
+			}
+			messages, err := store.ListMessages(ctx, beeper.MessageListOptions{ThreadID: threadID, All: true, After: after, Before: before, Format: formatValue, SkipUndecrypted: true})
+			if err != nil {
+				return err
+			}
+			slices.Reverse(messages)
+
+			var cards []export.Flashcard
+			if bookmarks {
+				cards, err = app.bookmarkFlashcards(ctx, threadID, messages)
+				if err != nil {
+					return err
+				}
+			} else {
+				cards = export.QuestionFlashcards(messages)
+			}
+			cards = export.FilterFlashcards(cards, include)
+			cardTags := append([]string{"beeper", export.FlashcardTag(thread.DisplayName)}, tags...)
+			for i := range cards {
+				cards[i].Tags = cardTags
+			}
+
+			var buf bytes.Buffer
+			if err := export.WriteAnkiTSV(&buf, cards); err != nil {
+				return err
+			}
+			if outPath == "-" {
+				_, err := os.Stdout.Write(buf.Bytes())
+				return err
+			}
+			changed, err := export.WriteFileIfChanged(outPath, buf.Bytes())
+			if err != nil {
+				return err
+			}
+
+			result := exportedFlashcards{ThreadID: threadID, Path: outPath, Cards: len(cards), Changed: changed}
+			if app.JSON {
+				return app.write(result)
+			}
+			fmt.Printf("Wrote %d flashcards to %s\n", result.Cards, result.Path)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&threadRef, "thread", "", "thread ID or name (required)")
+	cmd.Flags().BoolVar(&bookmarks, "bookmarks", false, "make cards from the thread's bookmarks instead of its questions (see bookmarks add)")
+	cmd.Flags().StringArrayVar(&include, "include", nil, "only cards containing this text on either side (repeatable; any may match)")
+	cmd.Flags().StringArrayVar(&tags, "tag", nil, "extra Anki tag for every card (repeatable)")
+	cmd.Flags().StringVar(&from, "from", "", "start date (YYYY-MM-DD, RFC3339, yesterday, last monday, 2w, ...)")
+	cmd.Flags().StringVar(&to, "to", "", "end date, inclusive (same forms as --from)")
+	cmd.Flags().StringVar(&format, "format", string(beeper.FormatPlain), "message format: plain|rich")
+	cmd.Flags().StringVar(&outPath, "out", "flashcards.tsv", "output file (- for stdout)")
+
+	return cmd
+}
+
+// bookmarkFlashcards makes a card from every bookmark in a thread whose
+// message is among messages (oldest first).
+func (a *App) bookmarkFlashcards(ctx context.Context, threadID string, messages []beeper.Message) ([]export.Flashcard, error) {
+	side, err := a.openSidecar()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = side.Close()
+	}()
+	marks, err := side.Bookmarks(ctx, threadID)
+	if err != nil {
+		return nil, err
+	}
+
+	byEvent := make(map[string]beeper.Message, len(messages))
+	for _, msg := range messages {
+		byEvent[msg.EventID] = msg
+	}
+	cards := []export.Flashcard{}
+	for _, mark := range marks {
+		msg, ok := byEvent[mark.EventID]
+		if !ok {
+			continue
+		}
+		card, ok := export.BookmarkFlashcard(messages, msg, mark.Note)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "warning: no question found for bookmarked message %s; add one with bookmarks add --note\n", mark.EventID)
+			continue
+		}
+		cards = append(cards, card)
+	}
+	return cards, nil
+}
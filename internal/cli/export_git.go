@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+	"github.com/KrauseFx/beeper-cli/internal/export"
+	"github.com/spf13/cobra"
+)
+
+type gitArchiveResult struct {
+	Repo    string   `json:"repo"`
+	Threads int      `json:"threads"`
+	Changed []string `json:"changed"`
+	Commit  string   `json:"commit,omitempty"`
+	Message string   `json:"message,omitempty"`
+}
+
+func newExportGitCmd(app *App) *cobra.Command {
+	var repoDir string
+	var threadRefs []string
+	var threadsFile string
+	var accountID string
+
+	cmd := &cobra.Command{
+		Use:   "git",
+		Short: "Write per-thread Markdown into a git repository and commit the changes",
+		Long: "Writes one Markdown transcript per thread plus a threads.json index into --repo " +
+			"(initialized on first use) and commits whatever changed, with a message listing " +
+			"new messages per thread. Without --thread/--threads-file, every thread is archived.",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if repoDir == "" {
+				return fmt.Errorf("--repo is required")
+			}
+			refs := slices.Clone(threadRefs)
+			if threadsFile != "" {
+				fileRefs, err := export.ReadThreadsFile(threadsFile)
+				if err != nil {
+					return err
+				}
+				refs = append(refs, fileRefs...)
+			}
+
+			ctx := context.Background()
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			threadIDs := []string{}
+			if len(refs) == 0 {
+				threads, err := store.ListThreads(ctx, beeper.ThreadListOptions{All: true, Label: beeper.LabelAll, IncludeLowPriority: true, AccountID: accountID})
+				if err != nil {
+					return err
+				}
+				for _, thread := range threads {
+					threadIDs = append(threadIDs, thread.ID)
+				}
+			}
+			for _, ref := range refs {
+				threadID, err := resolveThreadRef(ctx, store, ref)
+				if err != nil {
+					return err
+				}
+				if !slices.Contains(threadIDs, threadID) {
+					threadIDs = append(threadIDs, threadID)
+				}
+			}
+
+			repo, err := export.OpenGitRepo(repoDir)
+			if err != nil {
+				return err
+			}
+			result, err := archiveToGit(ctx, store, repo, threadIDs)
+			if err != nil {
+				return err
+			}
+
+			if app.JSON {
+				return app.write(result)
+			}
+			if result.Commit == "" {
+				fmt.Printf("No changes in %d threads; nothing to commit\n", result.Threads)
+				return nil
+			}
+			fmt.Printf("Committed %s in %s\n\n%s", result.Commit[:min(12, len(result.Commit))], result.Repo, result.Message)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&repoDir, "repo", "", "archive repository directory (created and initialized if needed)")
+	cmd.Flags().StringArrayVar(&threadRefs, "thread", nil, "thread ID or name to archive (repeatable)")
+	cmd.Flags().StringVar(&threadsFile, "threads-file", "", "file with one thread ID or name per line (# comments allowed)")
+	cmd.Flags().StringVar(&accountID, "account", "", "only threads from this account/platform ID (when archiving all threads)")
+
+	return cmd
+}
+
+// archiveToGit rewrites the transcripts of the given threads, updates the
+// index, and commits. Threads archived earlier but not selected now are
+// left as they are.
+func archiveToGit(ctx context.Context, store *beeper.Store, repo *export.GitRepo, threadIDs []string) (gitArchiveResult, error) {
+	result := gitArchiveResult{Repo: repo.Dir, Threads: len(threadIDs), Changed: []string{}}
+	prev, err := repo.ReadIndex()
+	if err != nil {
+		return result, err
+	}
+	next := make(map[string]export.GitThread, len(prev)+len(threadIDs))
+	// Archived threads keep their files; new ones whose names collide get
+	// a suffix.
+	used := map[string]string{}
+	for id, t := range prev {
+		next[id] = t
+		used[strings.ToLower(t.File)] = id
+	}
+
+	// Only the transcripts written in this run and the index are committed.
+	files := []string{export.GitIndexFile}
+	for _, threadID := range threadIDs {
+		data, err := loadThreadExport(ctx, store, threadID, exportOptions{format: beeper.FormatRich})
+		if err != nil {
+			return result, fmt.Errorf("export %s: %w", threadID, err)
+		}
+		var buf bytes.Buffer
+		if err := export.WriteLocalizedMarkdown(&buf, data, outputLocale); err != nil {
+			return result, err
+		}
+		entry := export.GitThread{
+			ThreadID:  threadID,
+			Name:      data.Thread.DisplayName,
+			AccountID: data.Thread.AccountID,
+			File:      export.UniqueFileName(threadID, ".md", used),
+			Messages:  len(data.Messages),
+		}
+		if n := len(data.Messages); n > 0 {
+			entry.LastMessage = data.Messages[n-1].Timestamp
+		}
+		next[threadID] = entry
+		files = append(files, entry.File)
+
+		path := filepath.Join(repo.Dir, entry.File)
+		if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, buf.Bytes()) {
+			continue
+		}
+		if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+			return result, err
+		}
+		result.Changed = append(result.Changed, threadID)
+	}
+
+	if err := repo.WriteIndex(next); err != nil {
+		return result, err
+	}
+	message := export.GitCommitMessage(prev, next)
+	hash, err := repo.Commit(message, files)
+	if err != nil {
+		return result, err
+	}
+	if hash != "" {
+		result.Commit = hash
+		result.Message = message
+	}
+	return result, nil
+}
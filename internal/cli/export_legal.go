@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+	"github.com/KrauseFx/beeper-cli/internal/export"
+	"github.com/spf13/cobra"
+)
+
+func newExportLegalCmd(app *App) *cobra.Command {
+	var threadRef string
+	var from string
+	var to string
+	var outDir string
+	var keyPath string
+
+	cmd := &cobra.Command{
+		Use:   "legal",
+		Short: "Write a tamper-evident legal hold bundle for one thread",
+		Long: "Writes transcript.pdf, raw events.json, cached media, a SHA-256 manifest, " +
+			"and an Ed25519-signed summary.json/summary.sig into --out.",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if threadRef == "" {
+				return fmt.Errorf("thread ID is required")
+			}
+			fromTime, err := parseDateFlag(from, false)
+			if err != nil {
+				return err
+			}
+			toTime, err := parseDateFlag(to, true)
+			if err != nil {
+				return err
+			}
+
+			var key ed25519.PrivateKey
+			if keyPath != "" {
+				key, err = export.LoadSigningKey(keyPath)
+			} else {
+				key, err = export.GenerateSigningKey()
+				fmt.Fprintln(os.Stderr, "warning: no --signing-key given; signing with a one-off key")
+			}
+			if err != nil {
+				return err
+			}
+
+			ctx := context.Background()
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			threadID, err := resolveThreadRef(ctx, store, threadRef)
+			if err != nil {
+				return err
+			}
+			thread, err := store.GetThread(ctx, threadID, true)
+			if err != nil {
+				return err
+			}
+			rangeOpts := beeper.MessageListOptions{
+				ThreadID: threadID,
+				All:      true,
+				After:    fromTime,
+				Before:   toTime,
+				Format:   beeper.FormatRich,
+				// Expired disappearing messages stay in the record as
+				// tombstones.
+				IncludeExpired: true,
+			}
+			messages, err := store.ListMessages(ctx, rangeOpts)
+			if err != nil {
+				return err
+			}
+			slices.Reverse(messages)
+			events, err := store.ListRawEvents(ctx, rangeOpts)
+			if err != nil {
+				return err
+			}
+
+			media := []beeper.Attachment{}
+			for _, ev := range events {
+				if ev.IsDeleted {
+					continue
+				}
+				att, ok := beeper.ParseAttachment(string(ev.Payload), ev.Type)
+				if !ok {
+					continue
+				}
+				att.MessageID = ev.ID
+				att.EventID = ev.EventID
+				att.ThreadID = ev.ThreadID
+				att.SenderID = ev.SenderID
+				att.Timestamp = ev.Timestamp
+				if path, ok := store.ResolveMediaPath(att.URL); ok {
+					att.LocalPath = path
+				}
+				media = append(media, att)
+			}
+
+			if outDir == "" {
+				outDir = "legal-" + export.FileName(threadID, "")
+			}
+			summary, err := export.WriteLegalBundle(outDir, export.LegalBundle{
+				Thread:   thread,
+				Messages: messages,
+				Events:   events,
+				Media:    media,
+				From:     fromTime,
+				To:       toTime,
+			}, key, time.Now())
+			if err != nil {
+				return err
+			}
+			if summary.LossyLines > 0 {
+				fmt.Fprintf(os.Stderr, "warning: %d transcript lines have characters outside Latin-1, shown as ? in transcript.pdf; events.json has the exact text\n", summary.LossyLines)
+			}
+
+			if app.JSON {
+				return app.write(summary)
+			}
+			fmt.Printf("Bundle: %s\n", filepath.Clean(outDir))
+			fmt.Printf("Messages: %d\n", summary.Messages)
+			fmt.Printf("Events: %d\n", summary.Events)
+			fmt.Printf("Media: %d copied, %d not cached locally\n", summary.MediaFiles, len(summary.MissingMedia))
+			fmt.Printf("Manifest SHA-256: %s\n", summary.ManifestSHA256)
+			fmt.Printf("Public key: %s\n", summary.PublicKey)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&threadRef, "thread", "", "thread ID or name")
+	cmd.Flags().StringVar(&from, "from", "", "first day to include (YYYY-MM-DD, RFC3339, yesterday, last monday, 2w, ...)")
+	cmd.Flags().StringVar(&to, "to", "", "last day to include (same forms as --from)")
+	cmd.Flags().StringVar(&outDir, "out", "", "bundle directory (default: legal-<thread>)")
+	cmd.Flags().StringVar(&keyPath, "signing-key", "", "Ed25519 seed or private key (hex or base64) used to sign summary.json")
+
+	return cmd
+}
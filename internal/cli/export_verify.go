@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+	"github.com/KrauseFx/beeper-cli/internal/export"
+	"github.com/spf13/cobra"
+)
+
+type verifyReport struct {
+	Archive string `json:"archive"`
+	Kind    string `json:"kind"`
+	// Files counts the manifest entries checked; 0 for archives without a
+	// manifest.
+	Files    int `json:"files"`
+	Messages int `json:"messages"`
+	// Deleted counts archived messages deleted in Beeper since.
+	Deleted  int                    `json:"deleted"`
+	Problems []export.VerifyProblem `json:"problems"`
+}
+
+func newExportVerifyCmd(app *App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify <archive>",
+		Short: "Check an export or legal bundle against its manifest and the database",
+		Long: "Re-hash a legal bundle's files against its manifest and signature, and check that every archived\n" +
+			"message still resolves to a database row with the same content (or one deleted since).\n" +
+			"Exits non-zero when anything drifted.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			dir := filepath.Clean(args[0])
+			archive, err := export.ReadArchive(dir)
+			if err != nil {
+				return err
+			}
+			report := verifyReport{Archive: dir, Kind: archive.Kind, Messages: len(archive.Messages), Problems: []export.VerifyProblem{}}
+			if archive.Kind == export.ArchiveLegal {
+				files, problems, err := export.VerifyManifest(dir, archive.Summary)
+				if err != nil {
+					return err
+				}
+				report.Files = files
+				report.Problems = append(report.Problems, problems...)
+			}
+
+			ctx := context.Background()
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			rows := map[string]map[string]beeper.RawEvent{}
+			for _, archived := range archive.Messages {
+				byEvent, ok := rows[archived.ThreadID]
+				if !ok {
+					events, err := store.ListRawEvents(ctx, beeper.MessageListOptions{ThreadID: archived.ThreadID})
+					if err != nil {
+						return err
+					}
+					byEvent = make(map[string]beeper.RawEvent, len(events))
+					for _, ev := range events {
+						byEvent[ev.EventID] = ev
+					}
+					rows[archived.ThreadID] = byEvent
+				}
+				current, found := byEvent[archived.EventID]
+				deleted, problem := export.CompareMessage(archived, current, found)
+				if deleted {
+					report.Deleted++
+				}
+				if problem != nil {
+					report.Problems = append(report.Problems, *problem)
+				}
+			}
+
+			if app.JSON {
+				if err := app.write(report); err != nil {
+					return err
+				}
+			} else if err := writeVerifyReport(report); err != nil {
+				return err
+			}
+			if len(report.Problems) > 0 {
+				if len(report.Problems) == 1 {
+					return fmt.Errorf("%s failed verification: 1 problem", dir)
+				}
+				return fmt.Errorf("%s failed verification: %d problems", dir, len(report.Problems))
+			}
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func writeVerifyReport(report verifyReport) error {
+	if len(report.Problems) > 0 {
+		w := newTabWriter()
+		if err := writeHeader(w, "PROBLEM\tPATH\tEVENT\tDETAIL"); err != nil {
+			return err
+		}
+		for _, p := range report.Problems {
+			if err := writef(w, "%s\t%s\t%s\t%s\n", p.Kind, p.Path, p.EventID, p.Detail); err != nil {
+				return err
+			}
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		fmt.Println()
+	}
+	if report.Kind == export.ArchiveLegal {
+		fmt.Printf("Files: %d checked against the manifest\n", report.Files)
+	}
+	fmt.Printf("Messages: %d checked, %d deleted in Beeper since\n", report.Messages, report.Deleted)
+	if len(report.Problems) == 0 {
+		fmt.Printf("%s %s archive verified\n", report.Archive, report.Kind)
+	}
+	return nil
+}
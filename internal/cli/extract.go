@@ -0,0 +1,398 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+	"github.com/KrauseFx/beeper-cli/internal/embed"
+	"github.com/KrauseFx/beeper-cli/internal/extract"
+	"github.com/KrauseFx/beeper-cli/internal/sidecar"
+	"github.com/spf13/cobra"
+)
+
+const extractBatchSize = 1000
+
+// embedBatchSize is smaller than extractBatchSize because each batch is one
+// call to the (possibly external) embedding model.
+const embedBatchSize = 100
+
+type extractResult struct {
+	Kind     string `json:"kind"`
+	Sidecar  string `json:"sidecar"`
+	Messages int    `json:"messages"`
+	Stored   int    `json:"stored"`
+	// ByKind counts entities per entity kind, attachments per media type,
+	// or vectors per embedding model.
+	ByKind map[string]int        `json:"byKind"`
+	Top    []sidecar.EntityCount `json:"top,omitempty"`
+}
+
+func newExtractCmd(app *App) *cobra.Command {
+	var kind string
+	var threadRef string
+	var accountID string
+	var from string
+	var to string
+	var top int
+	var embedCommand string
+	var tokenizer string
+	var outPath string
+	var minConfidence float64
+
+	cmd := &cobra.Command{
+		Use:   "extract",
+		Short: "Derive structured data from messages into the sidecar DB",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if kind != "birthdays" && !slices.Contains(indexKinds, kind) {
+				return fmt.Errorf("invalid kind %q (expected entities, media, embeddings, fuzzy, or birthdays)", kind)
+			}
+			if outPath != "" && kind != "birthdays" {
+				return fmt.Errorf("--out is only supported with --kind birthdays")
+			}
+			after, err := parseDateFlag(from, false)
+			if err != nil {
+				return err
+			}
+			before, err := parseDateFlag(to, true)
+			if err != nil {
+				return err
+			}
+
+			ctx := context.Background()
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			threadID := ""
+			if threadRef != "" {
+				threadID, err = resolveThreadRef(ctx, store, threadRef)
+				if err != nil {
+					return err
+				}
+			}
+
+			scan := beeper.ScanOptions{
+				ThreadID:  threadID,
+				AccountID: accountID,
+				After:     after,
+				Before:    before,
+			}
+			// Birthdays are a report, not an index: nothing is stored.
+			if kind == "birthdays" {
+				birthdays, err := mineBirthdays(ctx, store, scan)
+				if err != nil {
+					return err
+				}
+				birthdays = slices.DeleteFunc(birthdays, func(b extract.Birthday) bool { return b.Confidence < minConfidence })
+				return app.writeBirthdays(birthdays, outPath)
+			}
+
+			side, err := app.openSidecar()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = side.Close()
+			}()
+
+			// Only a run over the whole history is a checkpoint that
+			// `index update` can resume from.
+			full := threadID == "" && accountID == "" && after == nil && before == nil
+			checkpoint := func(name string) *indexCheckpoint {
+				if !full {
+					return nil
+				}
+				return &indexCheckpoint{side: side, state: sidecar.IndexState{Name: name}}
+			}
+			if kind == "embeddings" {
+				embedder := app.embedder(embedCommand)
+				result, err := extractEmbeddings(ctx, store, side, embedder, scan, checkpoint(embeddingsIndexName(embedder.Model())))
+				if err != nil {
+					return err
+				}
+				if app.JSON {
+					return app.write(result)
+				}
+				fmt.Printf("Embedded %d of %d messages with %s in %s\n", result.Stored, result.Messages, embedder.Model(), result.Sidecar)
+				return nil
+			}
+			if kind == "fuzzy" {
+				result, err := extractFuzzy(ctx, store, side, scan, checkpoint("fuzzy"))
+				if err != nil {
+					return err
+				}
+				if app.JSON {
+					return app.write(result)
+				}
+				fmt.Printf("Indexed %d words of %d messages in %s\n", result.Stored, result.Messages, result.Sidecar)
+				return nil
+			}
+			if kind == "media" {
+				if err := app.applyTokenizer(ctx, side, tokenizer); err != nil {
+					return err
+				}
+				result, err := extractMedia(ctx, store, side, scan, checkpoint("media"))
+				if err != nil {
+					return err
+				}
+				if app.JSON {
+					return app.write(result)
+				}
+				fmt.Printf("Indexed %d attachments in %s\n", result.Stored, result.Sidecar)
+				types := make([]string, 0, len(result.ByKind))
+				for t := range result.ByKind {
+					types = append(types, t)
+				}
+				slices.Sort(types)
+				for _, t := range types {
+					fmt.Printf("  %s: %d\n", t, result.ByKind[t])
+				}
+				return nil
+			}
+
+			result, err := extractEntities(ctx, store, side, scan, checkpoint("entities"))
+			if err != nil {
+				return err
+			}
+			result.Top, err = side.TopEntities(ctx, "", top)
+			if err != nil {
+				return err
+			}
+
+			if app.JSON {
+				return app.write(result)
+			}
+
+			fmt.Printf("Scanned %d messages, stored %d entities in %s\n", result.Messages, result.Stored, result.Sidecar)
+			for _, k := range extract.Kinds {
+				fmt.Printf("  %s: %d\n", k, result.ByKind[string(k)])
+			}
+			if len(result.Top) == 0 {
+				return nil
+			}
+			fmt.Println()
+			w := newTabWriter()
+			if err := writeHeader(w, "KIND\tENTITY\tMESSAGES"); err != nil {
+				return err
+			}
+			for _, c := range result.Top {
+				if err := writef(w, "%s\t%s\t%d\n", c.Kind, c.Text, c.Messages); err != nil {
+					return err
+				}
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().StringVar(&kind, "kind", "entities", "what to extract: entities|media|embeddings|fuzzy|birthdays")
+	cmd.Flags().StringVar(&threadRef, "thread", "", "only this thread (ID or name)")
+	cmd.Flags().StringVar(&accountID, "account", "", "filter by account/platform ID")
+	cmd.Flags().StringVar(&from, "from", "", "start date (YYYY-MM-DD, RFC3339, yesterday, last monday, 2w, ...)")
+	cmd.Flags().StringVar(&to, "to", "", "end date, inclusive (same forms as --from)")
+	cmd.Flags().IntVar(&top, "top", 10, "number of most frequent entities to show")
+	cmd.Flags().StringVar(&embedCommand, "embed-command", "", "external embedding command for --kind embeddings (or set BEEPER_CLI_EMBED_COMMAND)")
+	cmd.Flags().StringVar(&tokenizer, "tokenizer", "", "search tokenizer for --kind media: unicode61|porter|simple|icu|icu:<locale> (default: config tokenizer, else unchanged)")
+	cmd.Flags().StringVar(&outPath, "out", "", "with --kind birthdays, write them as an iCalendar file instead of printing them")
+	cmd.Flags().Float64Var(&minConfidence, "min-confidence", 0, "with --kind birthdays, only birthdays at least this confident (0-1)")
+
+	return cmd
+}
+
+// extractEntities runs the entity extractor over every message matching
+// opts, replacing previously stored entities for those messages.
+func extractEntities(ctx context.Context, store *beeper.Store, side *sidecar.DB, opts beeper.ScanOptions, cp *indexCheckpoint) (extractResult, error) {
+	result := extractResult{Kind: "entities", Sidecar: side.Path(), ByKind: map[string]int{}}
+
+	people, err := store.ParticipantNames(ctx)
+	if err != nil {
+		return result, err
+	}
+	extractor := extract.NewEntityExtractor(people)
+
+	opts.Limit = extractBatchSize
+	opts.Format = beeper.FormatPlain
+	for {
+		messages, err := store.ScanMessages(ctx, opts)
+		if err != nil {
+			return result, err
+		}
+		if len(messages) == 0 {
+			return result, nil
+		}
+
+		ids := make([]int64, 0, len(messages))
+		entities := []sidecar.Entity{}
+		for _, msg := range messages {
+			ids = append(ids, msg.ID)
+			seen := map[string]struct{}{}
+			for _, ent := range extractor.Entities(msg.Text) {
+				key := string(ent.Kind) + "\x00" + sidecar.NormalizeEntity(ent.Text)
+				if _, ok := seen[key]; ok {
+					continue
+				}
+				seen[key] = struct{}{}
+				entities = append(entities, sidecar.Entity{
+					MessageID: msg.ID,
+					EventID:   msg.EventID,
+					ThreadID:  msg.ThreadID,
+					Kind:      string(ent.Kind),
+					Text:      ent.Text,
+					Start:     ent.Start,
+					End:       ent.End,
+				})
+				result.ByKind[string(ent.Kind)]++
+			}
+		}
+		if err := side.ReplaceEntities(ctx, ids, entities); err != nil {
+			return result, err
+		}
+		result.Messages += len(messages)
+		result.Stored += len(entities)
+		opts.AfterID = slices.Max(ids)
+		if err := cp.save(ctx, opts.AfterID, len(messages), len(entities)); err != nil {
+			return result, err
+		}
+	}
+}
+
+// extractMedia indexes the file names, captions, and alt text of every media
+// message matching opts so search can find attachments without text.
+func extractMedia(ctx context.Context, store *beeper.Store, side *sidecar.DB, opts beeper.ScanOptions, cp *indexCheckpoint) (extractResult, error) {
+	result := extractResult{Kind: "media", Sidecar: side.Path(), ByKind: map[string]int{}}
+
+	opts.Limit = extractBatchSize
+	for {
+		attachments, lastID, err := store.ScanAttachments(ctx, opts)
+		if err != nil {
+			return result, err
+		}
+		if lastID == opts.AfterID {
+			return result, nil
+		}
+
+		ids := make([]int64, 0, len(attachments))
+		media := make([]sidecar.Media, 0, len(attachments))
+		for _, att := range attachments {
+			ids = append(ids, att.MessageID)
+			media = append(media, sidecar.Media{
+				MessageID: att.MessageID,
+				EventID:   att.EventID,
+				ThreadID:  att.ThreadID,
+				Type:      att.Type,
+				FileName:  att.FileName,
+				Caption:   att.Caption,
+				AltText:   att.AltText,
+			})
+			result.ByKind[att.Type]++
+		}
+		if err := side.ReplaceMedia(ctx, ids, media); err != nil {
+			return result, err
+		}
+		result.Messages += len(attachments)
+		result.Stored += len(media)
+		opts.AfterID = lastID
+		if err := cp.save(ctx, opts.AfterID, len(attachments), len(media)); err != nil {
+			return result, err
+		}
+	}
+}
+
+// extractEmbeddings stores a vector for the text of every message matching
+// opts, replacing previously stored vectors of the same model. Messages
+// without text are skipped.
+func extractEmbeddings(ctx context.Context, store *beeper.Store, side *sidecar.DB, embedder embed.Embedder, opts beeper.ScanOptions, cp *indexCheckpoint) (extractResult, error) {
+	result := extractResult{Kind: "embeddings", Sidecar: side.Path(), ByKind: map[string]int{}}
+	model := embedder.Model()
+
+	opts.Limit = embedBatchSize
+	opts.Format = beeper.FormatPlain
+	for {
+		messages, err := store.ScanMessages(ctx, opts)
+		if err != nil {
+			return result, err
+		}
+		if len(messages) == 0 {
+			return result, nil
+		}
+
+		ids := make([]int64, 0, len(messages))
+		texts := []string{}
+		embedded := []beeper.Message{}
+		for _, msg := range messages {
+			ids = append(ids, msg.ID)
+			if strings.TrimSpace(msg.Text) == "" {
+				continue
+			}
+			texts = append(texts, msg.Text)
+			embedded = append(embedded, msg)
+		}
+		embeddings := make([]sidecar.Embedding, 0, len(embedded))
+		if len(texts) > 0 {
+			vectors, err := embedder.Embed(ctx, texts)
+			if err != nil {
+				return result, err
+			}
+			for i, msg := range embedded {
+				embeddings = append(embeddings, sidecar.Embedding{
+					MessageID: msg.ID,
+					EventID:   msg.EventID,
+					ThreadID:  msg.ThreadID,
+					Vector:    vectors[i],
+				})
+			}
+		}
+		if err := side.ReplaceEmbeddings(ctx, model, ids, embeddings); err != nil {
+			return result, err
+		}
+		result.Messages += len(messages)
+		result.Stored += len(embeddings)
+		result.ByKind[model] += len(embeddings)
+		opts.AfterID = slices.Max(ids)
+		if err := cp.save(ctx, opts.AfterID, len(messages), len(embeddings)); err != nil {
+			return result, err
+		}
+	}
+}
+
+// extractFuzzy indexes the words of every message matching opts by their
+// trigrams, so search --fuzzy can find them despite typos.
+func extractFuzzy(ctx context.Context, store *beeper.Store, side *sidecar.DB, opts beeper.ScanOptions, cp *indexCheckpoint) (extractResult, error) {
+	result := extractResult{Kind: "fuzzy", Sidecar: side.Path(), ByKind: map[string]int{}}
+
+	opts.Limit = extractBatchSize
+	opts.Format = beeper.FormatPlain
+	for {
+		messages, err := store.ScanMessages(ctx, opts)
+		if err != nil {
+			return result, err
+		}
+		if len(messages) == 0 {
+			return result, nil
+		}
+
+		ids := make([]int64, 0, len(messages))
+		terms := make(map[int64][]string, len(messages))
+		stored := 0
+		for _, msg := range messages {
+			ids = append(ids, msg.ID)
+			terms[msg.ID] = sidecar.Terms(msg.Text)
+			stored += len(terms[msg.ID])
+		}
+		if err := side.ReplaceFuzzyTerms(ctx, ids, terms); err != nil {
+			return result, err
+		}
+		result.Messages += len(messages)
+		result.Stored += stored
+		opts.AfterID = slices.Max(ids)
+		if err := cp.save(ctx, opts.AfterID, len(messages), stored); err != nil {
+			return result, err
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+	"github.com/KrauseFx/beeper-cli/internal/export"
+	"github.com/KrauseFx/beeper-cli/internal/extract"
+)
+
+type writtenBirthdays struct {
+	Path      string `json:"path"`
+	Birthdays int    `json:"birthdays"`
+	Changed   bool   `json:"changed"`
+}
+
+// mineBirthdays collects the birthday greetings you sent in DMs matching
+// scan and infers a birthday per contact.
+func mineBirthdays(ctx context.Context, store *beeper.Store, scan beeper.ScanOptions) ([]extract.Birthday, error) {
+	threads, err := store.ListThreads(ctx, beeper.ThreadListOptions{
+		All:                true,
+		Label:              beeper.LabelAll,
+		IncludeLowPriority: true,
+		Type:               beeper.ThreadTypeDM,
+		AccountID:          scan.AccountID,
+		WithParticipants:   true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	greetings := []extract.Greeting{}
+	for _, thread := range threads {
+		if scan.ThreadID != "" && thread.ID != scan.ThreadID {
+			continue
+		}
+		contactID := ""
+		for _, p := range thread.Participants {
+			if !p.IsSelf {
+				contactID = p.ID
+				break
+			}
+		}
+		messages, err := store.ListMessages(ctx, beeper.MessageListOptions{
+			ThreadID:        thread.ID,
+			All:             true,
+			After:           scan.After,
+			Before:          scan.Before,
+			Format:          beeper.FormatPlain,
+			SkipUndecrypted: true,
+			Include:         extract.BirthdayTerms,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, msg := range messages {
+			if !msg.IsSentByMe || !extract.IsBirthdayGreeting(msg.Text) {
+				continue
+			}
+			greetings = append(greetings, extract.Greeting{ContactID: contactID, ContactName: thread.DisplayName, ThreadID: thread.ID, Time: msg.Timestamp})
+		}
+	}
+	return extract.InferBirthdays(greetings), nil
+}
+
+// writeBirthdays prints birthdays as a table or in the --output format,
+// or writes them as an iCalendar file to outPath.
+func (a *App) writeBirthdays(birthdays []extract.Birthday, outPath string) error {
+	if outPath != "" {
+		var buf bytes.Buffer
+		if err := export.WriteBirthdaysICS(&buf, birthdays); err != nil {
+			return err
+		}
+		changed, err := export.WriteFileIfChanged(outPath, buf.Bytes())
+		if err != nil {
+			return err
+		}
+		if a.JSON {
+			return a.write(writtenBirthdays{Path: outPath, Birthdays: len(birthdays), Changed: changed})
+		}
+		fmt.Printf("Wrote %d birthdays to %s\n", len(birthdays), outPath)
+		return nil
+	}
+	if a.JSON {
+		return a.write(birthdays)
+	}
+	w := newTabWriter()
+	if err := writeHeader(w, "CONTACT\tBIRTHDAY\tCONFIDENCE\tYEARS\tGREETINGS\tLAST GREETED\tID"); err != nil {
+		return err
+	}
+	for _, b := range birthdays {
+		if err := writef(w, "%s\t%s %d\t%.2f\t%d\t%d\t%s\t%s\n",
+			safe(b.Name), b.Month.String()[:3], b.Day, b.Confidence, b.Years, b.Greetings, formatTime(b.LastGreeted), b.ContactID); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
@@ -18,3 +18,37 @@ func parseMessageFormat(value string) (beeper.MessageFormat, error) {
 		return "", fmt.Errorf("invalid format %q: use plain or rich", value)
 	}
 }
+
+func parseThreadLabel(value string) (beeper.ThreadLabel, error) {
+	normalized := beeper.ThreadLabel(strings.ToLower(strings.TrimSpace(value)))
+	switch normalized {
+	case "":
+		return beeper.LabelAll, nil
+	case beeper.LabelAll, beeper.LabelInbox, beeper.LabelArchive, beeper.LabelFavourite, beeper.LabelUnread:
+		return normalized, nil
+	default:
+		return "", fmt.Errorf("invalid label %q: use inbox, archive, favourite, unread, or all", value)
+	}
+}
+
+func parseThreadSort(value string) (beeper.ThreadSort, error) {
+	normalized := beeper.ThreadSort(strings.ToLower(strings.TrimSpace(value)))
+	switch normalized {
+	case "":
+		return beeper.SortActivity, nil
+	case beeper.SortActivity, beeper.SortImportance, beeper.SortUnread, beeper.SortName, beeper.SortMessages:
+		return normalized, nil
+	default:
+		return "", fmt.Errorf("invalid sort %q: use activity, unread, name, messages, or importance", value)
+	}
+}
+
+func parseThreadType(value string) (beeper.ThreadType, error) {
+	normalized := beeper.ThreadType(strings.ToLower(strings.TrimSpace(value)))
+	switch normalized {
+	case "", beeper.ThreadTypeDM, beeper.ThreadTypeGroup:
+		return normalized, nil
+	default:
+		return "", fmt.Errorf("invalid type %q: use dm or group", value)
+	}
+}
@@ -0,0 +1,390 @@
+package cli
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+	"github.com/KrauseFx/beeper-cli/internal/sidecar"
+	"github.com/spf13/cobra"
+)
+
+// indexKinds are the sidecar indexes `index update` can build.
+var indexKinds = []string{"entities", "media", "embeddings", "fuzzy", "threads"}
+
+// threadsIndexName is the checkpoint of the thread listing cache.
+const threadsIndexName = "threads"
+
+// indexStatus is one row of `index status`.
+type indexStatus struct {
+	sidecar.IndexState
+	// Pending counts messages newer than the checkpoint.
+	Pending int `json:"pending"`
+	// Tokenizer is set for the media index, the one with full-text search.
+	Tokenizer string `json:"tokenizer,omitempty"`
+}
+
+// indexCheckpoint persists an index's progress after every batch, so an
+// interrupted build resumes where it stopped. A nil checkpoint does
+// nothing.
+type indexCheckpoint struct {
+	side  *sidecar.DB
+	state sidecar.IndexState
+}
+
+func (c *indexCheckpoint) save(ctx context.Context, lastID int64, messages int, stored int) error {
+	if c == nil {
+		return nil
+	}
+	c.state.LastMessageID = lastID
+	c.state.Messages += messages
+	c.state.Stored += stored
+	return c.side.SaveIndexState(ctx, c.state)
+}
+
+// applyTokenizer rebuilds the sidecar's media search index when the
+// tokenizer from the flag or config differs from the one it was built with.
+func (a *App) applyTokenizer(ctx context.Context, side *sidecar.DB, spec string) error {
+	if spec == "" {
+		spec = a.Config.Tokenizer
+	}
+	if spec == "" {
+		return nil
+	}
+	changed, err := side.SetTokenizer(ctx, spec)
+	if err != nil {
+		return err
+	}
+	if changed {
+		fmt.Fprintf(os.Stderr, "Rebuilt the media search index with the %s tokenizer\n", spec)
+	}
+	return nil
+}
+
+// embeddingsIndexName keys embedding checkpoints by model, since each model
+// is indexed separately.
+func embeddingsIndexName(model string) string {
+	return "embeddings:" + model
+}
+
+func newIndexCmd(app *App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "index",
+		Short: "Incrementally maintain the sidecar indexes built by extract",
+	}
+
+	cmd.AddCommand(newIndexBuildCmd(app))
+	cmd.AddCommand(newIndexUpdateCmd(app))
+	cmd.AddCommand(newIndexStatusCmd(app))
+	return cmd
+}
+
+// threadCacheSource identifies the database a thread cache belongs to:
+// the absolute path openStore resolved.
+func threadCacheSource(dbPath string) string {
+	if abs, err := filepath.Abs(dbPath); err == nil {
+		return abs
+	}
+	return dbPath
+}
+
+// threadCacheMismatch explains why the cache checkpoint state cannot be
+// used with store, opened from source, or returns "" if it can: the cache
+// was built from another database, or covers rows the database no longer
+// has.
+func threadCacheMismatch(ctx context.Context, store *beeper.Store, state sidecar.IndexState, source string) (string, error) {
+	if state.Source != source {
+		return fmt.Sprintf("it was built from %s, not %s", cmp.Or(state.Source, "an unrecorded database"), source), nil
+	}
+	lastID, err := store.MaxMessageID(ctx)
+	if err != nil {
+		return "", err
+	}
+	if state.LastMessageID > lastID {
+		return fmt.Sprintf("it covers messages up to row %d, but %s ends at row %d", state.LastMessageID, source, lastID), nil
+	}
+	return "", nil
+}
+
+// updateThreadCache summarizes the messages after the cache's checkpoint
+// by thread and merges them into the sidecar, or with rebuild replaces the
+// cache with summaries of every message. A cache that does not match the
+// database at source is rebuilt.
+func updateThreadCache(ctx context.Context, store *beeper.Store, side *sidecar.DB, state sidecar.IndexState, source string, rebuild bool) (extractResult, error) {
+	result := extractResult{Kind: threadsIndexName, Sidecar: side.Path(), ByKind: map[string]int{}}
+	if !rebuild && state.LastMessageID > 0 {
+		reason, err := threadCacheMismatch(ctx, store, state, source)
+		if err != nil {
+			return result, err
+		}
+		if reason != "" {
+			fmt.Fprintf(os.Stderr, "Rebuilding the thread listing cache: %s\n", reason)
+			rebuild = true
+		}
+	}
+	if rebuild {
+		state = sidecar.IndexState{Name: threadsIndexName}
+	}
+	state.Source = source
+
+	summaries, lastID, err := store.SummarizeThreads(ctx, state.LastMessageID)
+	if err != nil {
+		return result, err
+	}
+	for _, summary := range summaries {
+		result.Messages += summary.TotalMessages
+		if summary.BridgeName != "" {
+			result.ByKind["bridgeNames"]++
+		}
+	}
+	result.Stored = len(summaries)
+	state.LastMessageID = lastID
+	state.Messages += result.Messages
+	state.Stored += result.Stored
+	if rebuild {
+		err = side.ReplaceThreadSummaries(ctx, summaries, state)
+	} else {
+		err = side.MergeThreadSummaries(ctx, summaries, state)
+	}
+	return result, err
+}
+
+// threadCache loads the thread listing cache for store, opened from
+// dbPath, or returns nil when it has not been built. A cache built from
+// another database is ignored with a warning.
+func (a *App) threadCache(ctx context.Context, store *beeper.Store, dbPath string) (*beeper.ThreadCache, error) {
+	side, err := a.openExistingSidecar()
+	if err != nil || side == nil {
+		return nil, err
+	}
+	defer func() {
+		_ = side.Close()
+	}()
+	state, ok, err := side.IndexState(ctx, threadsIndexName)
+	if err != nil || !ok {
+		return nil, err
+	}
+	reason, err := threadCacheMismatch(ctx, store, state, threadCacheSource(dbPath))
+	if err != nil {
+		return nil, err
+	}
+	if reason != "" {
+		fmt.Fprintf(os.Stderr, "warning: ignoring the thread listing cache: %s; run `index build` to rebuild it\n", reason)
+		return nil, nil
+	}
+	summaries, err := side.ThreadSummaries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &beeper.ThreadCache{LastMessageID: state.LastMessageID, Summaries: summaries}, nil
+}
+
+func newIndexBuildCmd(app *App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "build",
+		Short: "Build the thread listing cache from scratch",
+		Long: "Store every thread's last message time, message count, and bridge DM name in the sidecar, so threads list\n" +
+			"and threads count skip aggregating every message. index update keeps the cache current; build it again after\n" +
+			"messages are removed or redacted.",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			ctx := context.Background()
+			store, dbPath, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+			side, err := app.openSidecar()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = side.Close()
+			}()
+
+			result, err := updateThreadCache(ctx, store, side, sidecar.IndexState{}, threadCacheSource(dbPath), true)
+			if err != nil {
+				return err
+			}
+			if app.JSON {
+				return app.write(result)
+			}
+			fmt.Printf("%s: %d messages in %d threads, %d bridge names\n", result.Kind, result.Messages, result.Stored, result.ByKind["bridgeNames"])
+			return nil
+		},
+	}
+	return cmd
+}
+
+func newIndexUpdateCmd(app *App) *cobra.Command {
+	var kinds []string
+	var embedCommand string
+	var tokenizer string
+
+	cmd := &cobra.Command{
+		Use:   "update",
+		Short: "Index only the messages added since the last run",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			for _, kind := range kinds {
+				if !slices.Contains(indexKinds, kind) {
+					return fmt.Errorf("invalid kind %q (expected entities, media, embeddings, fuzzy, or threads)", kind)
+				}
+			}
+
+			ctx := context.Background()
+			store, dbPath, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+			side, err := app.openSidecar()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = side.Close()
+			}()
+
+			if err := app.applyTokenizer(ctx, side, tokenizer); err != nil {
+				return err
+			}
+
+			embedder := app.embedder(embedCommand)
+			names := map[string]string{
+				"entities":   "entities",
+				"media":      "media",
+				"embeddings": embeddingsIndexName(embedder.Model()),
+				"fuzzy":      "fuzzy",
+				"threads":    threadsIndexName,
+			}
+			// Without --kind, update every index that has been built before.
+			if !cmd.Flags().Changed("kind") {
+				kinds = nil
+				for _, kind := range indexKinds {
+					if _, ok, err := side.IndexState(ctx, names[kind]); err != nil {
+						return err
+					} else if ok {
+						kinds = append(kinds, kind)
+					}
+				}
+				if len(kinds) == 0 {
+					return fmt.Errorf("no index has been built yet: run `extract` or `index update --kind <kind>`")
+				}
+			}
+
+			results := []extractResult{}
+			for _, kind := range kinds {
+				state, _, err := side.IndexState(ctx, names[kind])
+				if err != nil {
+					return err
+				}
+				cp := &indexCheckpoint{side: side, state: state}
+				scan := beeper.ScanOptions{AfterID: state.LastMessageID}
+				var result extractResult
+				switch kind {
+				case "entities":
+					result, err = extractEntities(ctx, store, side, scan, cp)
+				case "media":
+					result, err = extractMedia(ctx, store, side, scan, cp)
+				case "embeddings":
+					result, err = extractEmbeddings(ctx, store, side, embedder, scan, cp)
+				case "fuzzy":
+					result, err = extractFuzzy(ctx, store, side, scan, cp)
+				case "threads":
+					result, err = updateThreadCache(ctx, store, side, state, threadCacheSource(dbPath), false)
+				}
+				if err != nil {
+					return err
+				}
+				results = append(results, result)
+			}
+
+			if app.JSON {
+				return app.write(results)
+			}
+			for _, result := range results {
+				fmt.Printf("%s: %d new messages, %d stored\n", result.Kind, result.Messages, result.Stored)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&kinds, "kind", nil, "indexes to update: entities|media|embeddings|fuzzy|threads (default: every index built before)")
+	cmd.Flags().StringVar(&embedCommand, "embed-command", "", "external embedding command for --kind embeddings (or set BEEPER_CLI_EMBED_COMMAND)")
+	cmd.Flags().StringVar(&tokenizer, "tokenizer", "", "media search tokenizer: unicode61|porter|simple|icu|icu:<locale> (default: config tokenizer, else unchanged)")
+
+	return cmd
+}
+
+func newIndexStatusCmd(app *App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show how far each sidecar index has been built",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			ctx := context.Background()
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			statuses := []indexStatus{}
+			side, err := app.openExistingSidecar()
+			if err != nil {
+				return err
+			}
+			if side != nil {
+				defer func() {
+					_ = side.Close()
+				}()
+				states, err := side.IndexStates(ctx)
+				if err != nil {
+					return err
+				}
+				tokenizer, err := side.Tokenizer(ctx)
+				if err != nil {
+					return err
+				}
+				for _, state := range states {
+					pending, err := store.CountMessagesAfter(ctx, state.LastMessageID)
+					if err != nil {
+						return err
+					}
+					status := indexStatus{IndexState: state, Pending: pending}
+					if state.Name == "media" {
+						status.Tokenizer = tokenizer
+					}
+					statuses = append(statuses, status)
+				}
+			}
+
+			if app.JSON {
+				return app.write(statuses)
+			}
+			if len(statuses) == 0 {
+				fmt.Println("No index has been built yet; run `extract` or `index update --kind <kind>`.")
+				return nil
+			}
+			w := newTabWriter()
+			if err := writeHeader(w, "INDEX\tLAST MESSAGE\tMESSAGES\tSTORED\tPENDING\tUPDATED\tTOKENIZER"); err != nil {
+				return err
+			}
+			for _, s := range statuses {
+				if err := writef(w, "%s\t%d\t%d\t%d\t%d\t%s\t%s\n", s.Name, s.LastMessageID, s.Messages, s.Stored, s.Pending, formatTime(s.UpdatedAt), safe(s.Tokenizer)); err != nil {
+					return err
+				}
+			}
+			return w.Flush()
+		},
+	}
+	return cmd
+}
@@ -0,0 +1,197 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+	"github.com/KrauseFx/beeper-cli/internal/config"
+	"github.com/spf13/cobra"
+)
+
+type initResult struct {
+	ConfigPath string        `json:"configPath"`
+	Config     config.Config `json:"config"`
+	HasFTS     bool          `json:"hasFts"`
+	BridgeDBs  []string      `json:"bridgeDbs,omitempty"`
+}
+
+func newInitCmd(app *App) *cobra.Command {
+	var yes bool
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Locate the database and write a config file",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			path, err := config.ResolveConfigPath(app.ConfigPath)
+			if err != nil {
+				return err
+			}
+			if _, err := os.Stat(path); err == nil && !force {
+				return fmt.Errorf("config already exists at %s (use --force to overwrite)", path)
+			}
+
+			p := &prompter{in: bufio.NewReader(os.Stdin), out: os.Stdout, defaults: yes}
+
+			dbPath, err := chooseDBPath(p, app.DBPath)
+			if err != nil {
+				return err
+			}
+
+			store, err := beeper.OpenWithOptions(dbPath, beeper.StoreOptions{BridgeLookup: !app.NoBridge})
+			if err != nil {
+				return fmt.Errorf("open %s: %w", dbPath, err)
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+			hasFTS, err := store.HasFTS(context.Background())
+			if err != nil {
+				return err
+			}
+			bridges := store.BridgeDBs()
+			if !app.JSON {
+				fmt.Printf("Database: %s\n", dbPath)
+				fmt.Printf("FTS: %t\n", hasFTS)
+				fmt.Printf("Bridge DBs: %d\n", len(bridges))
+			}
+
+			cfg := config.Config{DBPath: dbPath}
+			cfg.Format, err = p.ask("Default message format (plain|rich)", string(beeper.FormatRich), func(v string) error {
+				_, err := parseMessageFormat(v)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			cfg.Timezone, err = p.ask("Timezone (IANA name, empty for system)", "", func(v string) error {
+				_, err := time.LoadLocation(v)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			cfg.Threads.Label, err = p.ask("Default label for threads list (inbox|archive|favourite|unread|all)", string(beeper.LabelAll), func(v string) error {
+				_, err := parseThreadLabel(v)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			if cfg.Threads.Label == string(beeper.LabelAll) {
+				cfg.Threads.Label = ""
+			}
+			cfg.Color, err = p.ask("Colors (auto|always|never)", "auto", func(v string) error {
+				switch v {
+				case "auto", "always", "never":
+					return nil
+				}
+				return fmt.Errorf("invalid color mode %q", v)
+			})
+			if err != nil {
+				return err
+			}
+
+			if err := config.SaveConfig(path, cfg); err != nil {
+				return err
+			}
+
+			if app.JSON {
+				return app.write(initResult{ConfigPath: path, Config: cfg, HasFTS: hasFTS, BridgeDBs: bridges})
+			}
+			fmt.Printf("Wrote %s\n", path)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&yes, "yes", false, "accept defaults without prompting")
+	cmd.Flags().BoolVar(&force, "force", false, "overwrite an existing config file")
+
+	return cmd
+}
+
+func chooseDBPath(p *prompter, explicit string) (string, error) {
+	if explicit != "" {
+		return config.ResolveDBPath(explicit)
+	}
+	candidates := config.CandidateDBPaths()
+	if len(candidates) == 0 {
+		path, err := p.ask("Path to Beeper index.db", "", func(v string) error {
+			_, err := config.ResolveDBPath(v)
+			return err
+		})
+		if err != nil {
+			return "", err
+		}
+		if path == "" {
+			return "", errors.New("could not find Beeper database; pass --db")
+		}
+		return config.ResolveDBPath(path)
+	}
+	if len(candidates) == 1 || p.defaults {
+		return candidates[0], nil
+	}
+
+	for i, path := range candidates {
+		fmt.Fprintf(p.out, "  [%d] %s\n", i+1, path)
+	}
+	choice, err := p.ask("Database", "1", func(v string) error {
+		var n int
+		if _, err := fmt.Sscanf(v, "%d", &n); err != nil || n < 1 || n > len(candidates) {
+			return fmt.Errorf("choose 1-%d", len(candidates))
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	var n int
+	_, _ = fmt.Sscanf(choice, "%d", &n)
+	return candidates[n-1], nil
+}
+
+// prompter reads answers line by line, re-asking until validation passes.
+type prompter struct {
+	in       *bufio.Reader
+	out      io.Writer
+	defaults bool
+}
+
+func (p *prompter) ask(question string, def string, validate func(string) error) (string, error) {
+	if p.defaults {
+		return def, nil
+	}
+	for {
+		if def != "" {
+			fmt.Fprintf(p.out, "%s [%s]: ", question, def)
+		} else {
+			fmt.Fprintf(p.out, "%s: ", question)
+		}
+		line, err := p.in.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return "", err
+		}
+		answer := strings.TrimSpace(line)
+		if answer == "" {
+			answer = def
+		}
+		if answer == "" || validate == nil {
+			return answer, nil
+		}
+		verr := validate(answer)
+		if verr == nil {
+			return answer, nil
+		}
+		if errors.Is(err, io.EOF) {
+			return "", verr
+		}
+		fmt.Fprintln(p.out, verr)
+	}
+}
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+	"github.com/spf13/cobra"
+)
+
+func newLinksCmd(app *App) *cobra.Command {
+	var threadRef string
+	var accountID string
+	var days int
+	var after string
+	var since string
+	var before string
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "links",
+		Short: "List URLs shared in chats, newest first, each once",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if days < 0 {
+				return fmt.Errorf("--days must not be negative")
+			}
+			afterTime, err := parseTimeFlag(cmp.Or(after, since), days)
+			if err != nil {
+				return err
+			}
+			beforeTime, err := parseTimePtr(before)
+			if err != nil {
+				return err
+			}
+
+			ctx := context.Background()
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			threadID := ""
+			if threadRef != "" {
+				threadID, err = resolveThreadRef(ctx, store, threadRef)
+				if err != nil {
+					return err
+				}
+			}
+
+			links, err := store.ListLinks(ctx, beeper.LinkOptions{
+				ThreadID:  threadID,
+				AccountID: accountID,
+				After:     afterTime,
+				Before:    beforeTime,
+				Limit:     limit,
+			})
+			if err != nil {
+				return err
+			}
+
+			if app.JSON {
+				return app.write(links)
+			}
+
+			w := newTextTable(4)
+			if err := writeHeader(w, "TIME\tTHREAD\tSENDER\tSHARES\tTITLE\tURL"); err != nil {
+				return err
+			}
+			for _, link := range links {
+				msg := link.Message
+				sender := cmp.Or(msg.SenderName, msg.SenderID)
+				if msg.IsSentByMe {
+					sender = outputLocale.T("Me")
+				}
+				if err := writef(w, "%s\t%s\t%s\t%d\t%s\t%s\n", formatTime(msg.Timestamp), safe(msg.ThreadName), safe(sender), link.Shares, tableText(safe(link.Title)), link.URL); err != nil {
+					return err
+				}
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().StringVar(&threadRef, "thread", "", "thread ID (room ID) or name")
+	cmd.Flags().StringVar(&accountID, "account", "", "filter by account/platform ID")
+	cmd.Flags().IntVar(&days, "days", 30, "only links from the last N days (0 for all time)")
+	cmd.Flags().StringVar(&after, "after", "", "only include links shared after this time: RFC3339, YYYY-MM-DD, yesterday, last monday, 2w, 3d ago, ...")
+	cmd.Flags().StringVar(&since, "since", "", "same as --after, e.g. --since yesterday")
+	cmd.MarkFlagsMutuallyExclusive("after", "since")
+	cmd.Flags().StringVar(&before, "before", "", "only include links shared before this time (same forms as --after)")
+	cmd.Flags().IntVar(&limit, "limit", 100, "max number of links")
+
+	return cmd
+}
@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"io"
+	"os"
+
+	"github.com/KrauseFx/beeper-cli/internal/redact"
+	"github.com/spf13/cobra"
+)
+
+// startMasking routes stdout through a redact.Writer when --mask (or config
+// mask.enabled) is set. Commands print with fmt and os.Stdout directly, so
+// os.Stdout itself is swapped for a pipe; stopMasking drains it and
+// restores the terminal. JSON output and the MCP protocol stream are never
+// masked.
+func (a *App) startMasking(cmd *cobra.Command) error {
+	enabled := a.Config.Mask.Enabled
+	if cmd.Flags().Changed("mask") {
+		enabled = a.Mask
+	}
+	if !enabled || a.JSON || cmd.Name() == "mcp" {
+		return nil
+	}
+	masker, err := redact.New(a.Config.Mask.Rules, a.Config.Mask.Patterns)
+	if err != nil {
+		return err
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	done := make(chan error, 1)
+	go func() {
+		mw := redact.NewWriter(stdout, masker)
+		_, err := io.Copy(mw, r)
+		if flushErr := mw.Flush(); err == nil {
+			err = flushErr
+		}
+		done <- err
+	}()
+
+	a.stopMasking = func() error {
+		os.Stdout = stdout
+		_ = w.Close()
+		err := <-done
+		_ = r.Close()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/KrauseFx/beeper-cli/internal/mcp"
+	"github.com/spf13/cobra"
+)
+
+func newMCPCmd(app *App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mcp",
+		Short: "Serve threads, messages, and search as MCP tools over stdio",
+		Long:  "Run a Model Context Protocol server on stdin/stdout so LLM clients can list threads, read messages, and search local chat history. All tools are read-only.",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			return mcp.NewServer(store, Version).Serve(ctx, os.Stdin, os.Stdout)
+		},
+	}
+	return cmd
+}
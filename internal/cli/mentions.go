@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+	"github.com/spf13/cobra"
+)
+
+func newMentionsCmd(app *App) *cobra.Command {
+	var days int
+	var since string
+	var accountID string
+	var threadRef string
+	var unreadOnly bool
+	var limit int
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "mentions",
+		Short: "List messages that mention you across all threads, newest first",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if days < 0 {
+				return fmt.Errorf("--days must not be negative")
+			}
+			var after *time.Time
+			if since != "" {
+				sinceTime, err := parseSinceFlag(since, time.Now())
+				if err != nil {
+					return err
+				}
+				after = &sinceTime
+			} else if days > 0 {
+				start := time.Now().AddDate(0, 0, -days)
+				after = &start
+			}
+			formatValue, err := parseMessageFormat(format)
+			if err != nil {
+				return err
+			}
+
+			ctx := context.Background()
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			threadID := ""
+			if threadRef != "" {
+				threadID, err = resolveThreadRef(ctx, store, threadRef)
+				if err != nil {
+					return err
+				}
+			}
+
+			mentions, err := store.ListMentions(ctx, beeper.MentionOptions{
+				ThreadID:   threadID,
+				AccountID:  accountID,
+				After:      after,
+				Limit:      limit,
+				Format:     formatValue,
+				UnreadOnly: unreadOnly,
+			})
+			if err != nil {
+				return err
+			}
+
+			if app.JSON {
+				return app.write(mentions)
+			}
+
+			w := newTextTable(4)
+			if err := writeHeader(w, "TIME\tACCOUNT\tTHREAD\tSENDER\tTEXT"); err != nil {
+				return err
+			}
+			for _, msg := range mentions {
+				sender := msg.SenderName
+				if sender == "" {
+					sender = msg.SenderID
+				}
+				if err := writef(w, "%s\t%s\t%s\t%s\t%s\n", formatTime(msg.Timestamp), safe(msg.AccountID), safe(msg.ThreadName), sender, tableText(outputLocale.Text(msg.Text))); err != nil {
+					return err
+				}
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().IntVar(&days, "days", 7, "only mentions from the last N days (0 for all time)")
+	cmd.Flags().StringVar(&since, "since", "", "start of the window instead of --days: a time ago (24h, 2w), yesterday, a weekday, YYYY-MM-DD, or RFC3339")
+	cmd.Flags().StringVar(&accountID, "account", "", "filter by account/platform ID")
+	cmd.Flags().StringVar(&threadRef, "thread", "", "thread ID (room ID) or name")
+	cmd.Flags().BoolVar(&unreadOnly, "unread", false, "only mentions you have not read yet")
+	cmd.Flags().IntVar(&limit, "limit", 100, "max number of mentions")
+	cmd.Flags().StringVar(&format, "format", string(beeper.FormatRich), "message format: plain|rich")
+	cmd.MarkFlagsMutuallyExclusive("days", "since")
+
+	return cmd
+}
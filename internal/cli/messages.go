@@ -1,10 +1,18 @@
 package cli
 
 import (
+	"bufio"
+	"cmp"
 	"context"
 	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/KrauseFx/beeper-cli/internal/beeper"
+	"github.com/KrauseFx/beeper-cli/internal/export"
 	"github.com/spf13/cobra"
 )
 
@@ -15,6 +23,12 @@ func newMessagesCmd(app *App) *cobra.Command {
 	}
 
 	cmd.AddCommand(newMessagesListCmd(app))
+	cmd.AddCommand(newMessagesCountCmd(app))
+	cmd.AddCommand(newMessagesTreeCmd(app))
+	cmd.AddCommand(newMessagesGetCmd(app))
+	cmd.AddCommand(newMessagesRepliesCmd(app))
+	cmd.AddCommand(newMessagesSampleCmd(app))
+	cmd.AddCommand(newMessagesExportCmd(app))
 
 	return cmd
 }
@@ -24,19 +38,39 @@ func newMessagesListCmd(app *App) *cobra.Command {
 	var limit int
 	var days int
 	var after string
+	var since string
 	var before string
 	var format string
+	var withProvenance bool
+	var withEngagement bool
+	var topEngaged bool
+	var withReactions bool
+	var withReplies bool
+	var includeDeleted bool
+	var showEdits bool
+	var skipUndecrypted bool
+	var includeExpired bool
+	var groupBy string
+	var cursor string
+	var msgType string
+	var unreadOnly bool
+	var from string
+	var count bool
 
 	cmd := &cobra.Command{
-		Use:   "list",
-		Short: "List recent messages in a thread",
-		RunE: func(_ *cobra.Command, args []string) error {
+		Use:               "list [thread]",
+		Short:             "List recent messages in a thread",
+		ValidArgsFunction: app.completeThreadArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
 			if threadID == "" && len(args) > 0 {
 				threadID = args[0]
 			}
 			if threadID == "" {
 				return fmt.Errorf("thread ID is required")
 			}
+			if groupBy != "" && groupBy != "day" {
+				return fmt.Errorf("invalid --group-by %q: use day", groupBy)
+			}
 
 			ctx := context.Background()
 			store, _, err := app.openStore()
@@ -47,7 +81,7 @@ func newMessagesListCmd(app *App) *cobra.Command {
 				_ = store.Close()
 			}()
 
-			afterTime, err := parseTimeFlag(after, days)
+			afterTime, err := parseTimeFlag(cmp.Or(after, since), days)
 			if err != nil {
 				return err
 			}
@@ -60,9 +94,219 @@ func newMessagesListCmd(app *App) *cobra.Command {
 				return err
 			}
 
+			threadID, err = resolveThreadRef(ctx, store, threadID)
+			if err != nil {
+				return err
+			}
+			var senderIDs []string
+			if from != "" {
+				senderIDs, err = resolveSenderRef(ctx, store, from)
+				if err != nil {
+					return err
+				}
+			}
+			opts := beeper.MessageListOptions{
+				ThreadID:        threadID,
+				Limit:           limit,
+				After:           afterTime,
+				Before:          beforeTime,
+				Format:          formatValue,
+				WithProvenance:  withProvenance,
+				WithEngagement:  withEngagement,
+				TopEngaged:      topEngaged,
+				WithReactions:   withReactions,
+				Cursor:          cursor,
+				IncludeDeleted:  includeDeleted,
+				ShowEdits:       showEdits,
+				SenderIDs:       senderIDs,
+				SkipUndecrypted: skipUndecrypted,
+				IncludeExpired:  includeExpired,
+				Type:            msgType,
+				UnreadOnly:      unreadOnly,
+			}
+			if count {
+				n, err := store.CountMessages(ctx, beeper.MessageCountOptions{MessageListOptions: opts})
+				if err != nil {
+					return err
+				}
+				return writeCount(app, n)
+			}
+			if (app.Output == outputNDJSON || app.Output == outputCSV) && !withReplies && groupBy == "" && !topEngaged {
+				return writeMessageStream(ctx, store, app.Output, opts)
+			}
+			page, err := store.ListMessagesPage(ctx, opts)
+			if err != nil {
+				return err
+			}
+			messages := page.Messages
+
+			if withReplies {
+				// Replies nest below their parent; conversations stay
+				// newest first.
+				ordered := slices.Clone(messages)
+				slices.Reverse(ordered)
+				trees := beeper.BuildMessageTree(ordered)
+				slices.Reverse(trees)
+				if app.JSON {
+					return app.writePage(messageTreePage{Messages: trees, NextCursor: page.NextCursor}, trees, page.NextCursor, cmd.Flags().Changed("cursor"))
+				}
+				if err := writeMessageTree(os.Stdout, trees, 0); err != nil {
+					return err
+				}
+				return writeCursorTrailer(page.NextCursor)
+			}
+
+			groups := []beeper.MessageDay{{Messages: messages}}
+			if groupBy == "day" {
+				groups = beeper.GroupMessagesByDay(messages)
+				if app.JSON {
+					return app.writePage(messageDayPage{Days: groups, NextCursor: page.NextCursor}, groups, page.NextCursor, cmd.Flags().Changed("cursor"))
+				}
+			}
+			if app.JSON {
+				return app.writePage(page, messages, page.NextCursor, cmd.Flags().Changed("cursor"))
+			}
+
+			engagement := withEngagement || topEngaged
+			w := newTextTable(2)
+			header := "TIME\tSENDER\tTEXT"
+			if engagement {
+				header += "\tREACTIONS\tREPLIES"
+			}
+			if withReactions {
+				header += "\tREACTED"
+			}
+			if showEdits {
+				header += "\tPREVIOUS VERSIONS"
+			}
+			if err := writeHeader(w, header); err != nil {
+				return err
+			}
+			for _, group := range groups {
+				if group.Day != "" {
+					// Empty cells keep the separator inside the table's columns.
+					if err := writeLine(w, dayHeading(group.Day)+strings.Repeat("\t", strings.Count(header, "\t"))); err != nil {
+						return err
+					}
+				}
+				for _, msg := range group.Messages {
+					sender := msg.SenderName
+					if sender == "" {
+						sender = msg.SenderID
+					}
+					text := tableText(outputLocale.Text(msg.Text))
+					if msg.IsDeleted && !msg.Expired {
+						text = outputLocale.T("[deleted]") + " " + text
+					}
+					if msg.Edited {
+						text += " " + outputLocale.T("(edited)")
+					}
+					row := fmt.Sprintf("%s\t%s\t%s", formatTime(msg.Timestamp), sender, text)
+					if engagement {
+						row += fmt.Sprintf("\t%d\t%d", msg.ReactionCount, msg.ReplyCount)
+					}
+					if withReactions {
+						row += "\t" + formatReactors(msg.Reactions)
+					}
+					if showEdits {
+						row += "\t" + safe(strings.Join(msg.PreviousVersions, " | "))
+					}
+					if err := writeLine(w, row); err != nil {
+						return err
+					}
+				}
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			return writeCursorTrailer(page.NextCursor)
+		},
+	}
+
+	cmd.Flags().StringVar(&threadID, "thread", "", "thread ID or name")
+	cmd.Flags().IntVar(&limit, "limit", 50, "max number of messages to return")
+	cmd.Flags().IntVar(&days, "days", 0, "only include messages from the last N days")
+	cmd.Flags().StringVar(&after, "after", "", "only include messages after this time: RFC3339, YYYY-MM-DD, yesterday, last monday, 2w, 3d ago, ...")
+	cmd.Flags().StringVar(&since, "since", "", "same as --after, e.g. --since yesterday")
+	cmd.Flags().StringVar(&before, "before", "", "only include messages before this time (same forms as --after)")
+	cmd.MarkFlagsMutuallyExclusive("after", "since")
+	cmd.Flags().StringVar(&format, "format", string(beeper.FormatRich), "message format: plain|rich")
+	cmd.Flags().BoolVar(&withProvenance, "with-provenance", false, "include platform, remote ID, and sent-via fields in JSON output")
+	cmd.Flags().BoolVar(&withEngagement, "with-engagement", false, "include reaction and reply counts")
+	cmd.Flags().BoolVar(&topEngaged, "top-engaged", false, "only messages with reactions or replies, most engaged first")
+	cmd.Flags().BoolVar(&withReactions, "with-reactions", false, "include who reacted with what")
+	cmd.Flags().BoolVar(&withReplies, "with-replies", false, "nest replies below the message they reply to")
+	cmd.Flags().BoolVar(&includeDeleted, "include-deleted", false, "also list deleted messages, marked [deleted]")
+	cmd.Flags().BoolVar(&showEdits, "show-edits", false, "show the latest version of edited messages with their earlier versions, instead of listing edits as messages")
+	cmd.Flags().BoolVar(&skipUndecrypted, "skip-undecrypted", false, "leave out messages Beeper has not decrypted yet instead of showing them as [Not yet decrypted]")
+	cmd.Flags().BoolVar(&includeExpired, "include-expired", false, "also list disappearing messages that expired, as [Expired message] tombstones")
+	cmd.Flags().StringVar(&msgType, "type", "", "only messages of this kind (image, video, audio, file, location, link) or type (TEXT, STICKER, ...)")
+	cmd.Flags().BoolVar(&unreadOnly, "unread-only", false, "only messages after your read marker (see threads show)")
+	cmd.Flags().StringVar(&from, "from", "", "only messages sent by this participant (ID or contact name)")
+	cmd.Flags().StringVar(&groupBy, "group-by", "", "insert day separators (JSON: an array of {day, messages}): day")
+	cmd.MarkFlagsMutuallyExclusive("with-replies", "top-engaged")
+	cmd.MarkFlagsMutuallyExclusive("group-by", "with-replies")
+	cmd.MarkFlagsMutuallyExclusive("group-by", "top-engaged")
+	cmd.Flags().StringVar(&cursor, "cursor", "", "continue after a previous page (its next cursor); with --json, prints {messages, nextCursor}")
+	cmd.Flags().BoolVar(&count, "count", false, "print only how many messages match, ignoring --limit (see messages count)")
+	for _, flag := range []string{"top-engaged", "with-replies", "group-by", "cursor"} {
+		cmd.MarkFlagsMutuallyExclusive("count", flag)
+	}
+
+	return cmd
+}
+
+func newMessagesTreeCmd(app *App) *cobra.Command {
+	var threadRef string
+	var root string
+	var limit int
+	var days int
+	var after string
+	var since string
+	var before string
+	var format string
+
+	cmd := &cobra.Command{
+		Use:               "tree [thread]",
+		Short:             "Show a thread's messages as a tree of Matrix threads and replies",
+		ValidArgsFunction: app.completeThreadArgs,
+		RunE: func(_ *cobra.Command, args []string) error {
+			if threadRef == "" && len(args) > 0 {
+				threadRef = args[0]
+			}
+			if threadRef == "" {
+				return fmt.Errorf("thread ID is required")
+			}
+			afterTime, err := parseTimeFlag(cmp.Or(after, since), days)
+			if err != nil {
+				return err
+			}
+			beforeTime, err := parseTimePtr(before)
+			if err != nil {
+				return err
+			}
+			formatValue, err := parseMessageFormat(format)
+			if err != nil {
+				return err
+			}
+
+			ctx := context.Background()
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			threadID, err := resolveThreadRef(ctx, store, threadRef)
+			if err != nil {
+				return err
+			}
 			messages, err := store.ListMessages(ctx, beeper.MessageListOptions{
 				ThreadID: threadID,
 				Limit:    limit,
+				All:      limit <= 0,
 				After:    afterTime,
 				Before:   beforeTime,
 				Format:   formatValue,
@@ -70,13 +314,290 @@ func newMessagesListCmd(app *App) *cobra.Command {
 			if err != nil {
 				return err
 			}
+			slices.Reverse(messages)
+
+			trees := beeper.BuildMessageTree(messages)
+			if root != "" {
+				node := beeper.MessageSubtree(trees, root)
+				if node == nil {
+					return fmt.Errorf("event %s not found in the loaded messages", root)
+				}
+				trees = []*beeper.MessageNode{node}
+			}
 
 			if app.JSON {
-				return writeJSON(messages)
+				return app.write(trees)
+			}
+			return writeMessageTree(os.Stdout, trees, 0)
+		},
+	}
+
+	cmd.Flags().StringVar(&threadRef, "thread", "", "thread ID or name")
+	cmd.Flags().StringVar(&root, "root", "", "only show the replies below this event ID")
+	cmd.Flags().IntVar(&limit, "limit", 500, "number of most recent messages to arrange (0 for all)")
+	cmd.Flags().IntVar(&days, "days", 0, "only include messages from the last N days")
+	cmd.Flags().StringVar(&after, "after", "", "only include messages after this time: RFC3339, YYYY-MM-DD, yesterday, last monday, 2w, 3d ago, ...")
+	cmd.Flags().StringVar(&since, "since", "", "same as --after, e.g. --since yesterday")
+	cmd.Flags().StringVar(&before, "before", "", "only include messages before this time (same forms as --after)")
+	cmd.MarkFlagsMutuallyExclusive("after", "since")
+	cmd.Flags().StringVar(&format, "format", string(beeper.FormatRich), "message format: plain|rich")
+
+	return cmd
+}
+
+// messageDayPage is a page of messages grouped by day.
+type messageDayPage struct {
+	Days       []beeper.MessageDay `json:"days"`
+	NextCursor string              `json:"nextCursor,omitempty"`
+}
+
+// writeMessageStream writes the messages opts lists as NDJSON or CSV, one
+// at a time. With All they are read from the database in batches as they
+// are written, so a whole history is never held in memory.
+func writeMessageStream(ctx context.Context, store *beeper.Store, format string, opts beeper.MessageListOptions) error {
+	if !opts.All {
+		page, err := store.ListMessagesPage(ctx, opts)
+		if err != nil {
+			return err
+		}
+		return writeStreamPage(format, page.Messages, page.NextCursor)
+	}
+	out := bufio.NewWriter(os.Stdout)
+	stream := newTypedOutputStream[beeper.Message](out, format)
+	for msg, err := range store.IterMessagesNewestFirst(ctx, opts) {
+		if err != nil {
+			return err
+		}
+		if err := stream.write(msg); err != nil {
+			return err
+		}
+	}
+	return out.Flush()
+}
+
+// dayHeading formats a YYYY-MM-DD day as a separator no wider than a
+// formatted time.
+func dayHeading(day string) string {
+	t, err := time.ParseInLocation("2006-01-02", day, time.Local)
+	if err != nil {
+		return "── " + day
+	}
+	return "── " + outputLocale.Format(t, outputLocale.ShortDate)
+}
+
+// messageTreePage is a page of messages nested into reply trees.
+type messageTreePage struct {
+	Messages   []*beeper.MessageNode `json:"messages"`
+	NextCursor string                `json:"nextCursor,omitempty"`
+}
+
+func newMessagesGetCmd(app *App) *cobra.Command {
+	var eventID string
+	var contextSize int
+	var window string
+	var format string
+	var withProvenance bool
+
+	cmd := &cobra.Command{
+		Use:   "get [event]",
+		Short: "Show one message by event ID, with the messages around it",
+		RunE: func(_ *cobra.Command, args []string) error {
+			if eventID == "" && len(args) > 0 {
+				eventID = args[0]
+			}
+			if eventID == "" {
+				return fmt.Errorf("event ID is required")
+			}
+			if contextSize < 0 {
+				return fmt.Errorf("invalid --context %d: must not be negative", contextSize)
 			}
+			windowDuration, err := parseDuration(window)
+			if err != nil {
+				return err
+			}
+			formatValue, err := parseMessageFormat(format)
+			if err != nil {
+				return err
+			}
+
+			ctx := context.Background()
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
 
-			w := newTabWriter()
-			if err := writeLine(w, "TIME\tSENDER\tTEXT"); err != nil {
+			result, err := store.GetMessageByEventID(ctx, eventID, beeper.SearchOptions{
+				Context:        contextSize,
+				Window:         windowDuration,
+				Format:         formatValue,
+				WithProvenance: withProvenance,
+			})
+			if err != nil {
+				return err
+			}
+			if app.JSON {
+				return app.write(result)
+			}
+
+			match := result.Match
+			fmt.Printf("%s (%s)\n\n", safe(match.ThreadName), safe(match.AccountID))
+			w := newTextTable(2)
+			if err := writeHeader(w, "TIME\tSENDER\tTEXT"); err != nil {
+				return err
+			}
+			for _, block := range beeper.MergeSearchContext([]beeper.SearchResult{result}) {
+				for _, msg := range block.Messages {
+					sender := msg.SenderName
+					if sender == "" {
+						sender = msg.SenderID
+					}
+					marker := "  "
+					if msg.ID == match.ID {
+						marker = "> "
+					}
+					if err := writef(w, "%s%s\t%s\t%s\n", marker, formatTime(msg.Timestamp), sender, tableText(outputLocale.Text(msg.Text))); err != nil {
+						return err
+					}
+				}
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().StringVar(&eventID, "event", "", "Matrix event ID of the message (or the first argument)")
+	cmd.Flags().IntVar(&contextSize, "context", 5, "include N messages before and after it (0 for none)")
+	cmd.Flags().StringVar(&window, "window", "", "include every message within this time of it instead (e.g., 30m); with --context, trimmed to N per side")
+	cmd.Flags().StringVar(&format, "format", string(beeper.FormatRich), "message format: plain|rich")
+	cmd.Flags().BoolVar(&withProvenance, "with-provenance", false, "include platform, remote ID, and sent-via fields in JSON output")
+
+	return cmd
+}
+
+func newMessagesRepliesCmd(app *App) *cobra.Command {
+	var eventID string
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "replies [event]",
+		Short: "Show a message and every reply to it",
+		RunE: func(_ *cobra.Command, args []string) error {
+			if eventID == "" && len(args) > 0 {
+				eventID = args[0]
+			}
+			if eventID == "" {
+				return fmt.Errorf("event ID is required")
+			}
+			formatValue, err := parseMessageFormat(format)
+			if err != nil {
+				return err
+			}
+
+			ctx := context.Background()
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			node, err := store.ListReplies(ctx, eventID, formatValue)
+			if err != nil {
+				return err
+			}
+			if app.JSON {
+				return app.write(node)
+			}
+			return writeMessageTree(os.Stdout, []*beeper.MessageNode{node}, 0)
+		},
+	}
+
+	cmd.Flags().StringVar(&eventID, "event", "", "event ID of the message")
+	cmd.Flags().StringVar(&format, "format", string(beeper.FormatRich), "message format: plain|rich")
+
+	return cmd
+}
+
+// writeMessageTree prints one message per line, replies indented below
+// their parent.
+func writeMessageTree(w io.Writer, nodes []*beeper.MessageNode, depth int) error {
+	for _, node := range nodes {
+		sender := node.SenderName
+		if sender == "" {
+			sender = node.SenderID
+		}
+		prefix := ""
+		if depth > 0 {
+			prefix = strings.Repeat("  ", depth-1) + "└ "
+		}
+		text := strings.ReplaceAll(node.Text, "\n", " ")
+		if err := writef(w, "%s%s  %s: %s\n", prefix, formatTime(node.Timestamp), sender, text); err != nil {
+			return err
+		}
+		if err := writeMessageTree(w, node.Replies, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newMessagesSampleCmd(app *App) *cobra.Command {
+	var n int
+	var threadRef string
+	var accountID string
+	var seed int64
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "sample",
+		Short: "Return a reproducible random sample of messages",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			formatValue, err := parseMessageFormat(format)
+			if err != nil {
+				return err
+			}
+			if !cmd.Flags().Changed("seed") {
+				seed = time.Now().UnixNano()
+				fmt.Fprintf(os.Stderr, "seed: %d\n", seed)
+			}
+
+			ctx := context.Background()
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			threadID := ""
+			if threadRef != "" {
+				threadID, err = resolveThreadRef(ctx, store, threadRef)
+				if err != nil {
+					return err
+				}
+			}
+
+			messages, err := store.SampleMessages(ctx, beeper.SampleOptions{
+				N:         n,
+				Seed:      seed,
+				ThreadID:  threadID,
+				AccountID: accountID,
+				Format:    formatValue,
+			})
+			if err != nil {
+				return err
+			}
+
+			if app.JSON {
+				return app.write(messages)
+			}
+
+			w := newTextTable(3)
+			if err := writeHeader(w, "TIME\tTHREAD\tSENDER\tTEXT"); err != nil {
 				return err
 			}
 			for _, msg := range messages {
@@ -84,7 +605,7 @@ func newMessagesListCmd(app *App) *cobra.Command {
 				if sender == "" {
 					sender = msg.SenderID
 				}
-				if err := writef(w, "%s\t%s\t%s\n", formatTime(msg.Timestamp), sender, msg.Text); err != nil {
+				if err := writef(w, "%s\t%s\t%s\t%s\n", formatTime(msg.Timestamp), safe(msg.ThreadName), sender, tableText(msg.Text)); err != nil {
 					return err
 				}
 			}
@@ -92,12 +613,126 @@ func newMessagesListCmd(app *App) *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVar(&threadID, "thread", "", "thread ID (room ID)")
-	cmd.Flags().IntVar(&limit, "limit", 50, "max number of messages to return")
-	cmd.Flags().IntVar(&days, "days", 0, "only include messages from the last N days")
-	cmd.Flags().StringVar(&after, "after", "", "only include messages after this RFC3339 timestamp")
-	cmd.Flags().StringVar(&before, "before", "", "only include messages before this RFC3339 timestamp")
+	cmd.Flags().IntVar(&n, "n", 200, "sample size")
+	cmd.Flags().StringVar(&threadRef, "thread", "", "only sample from this thread (ID or name)")
+	cmd.Flags().StringVar(&accountID, "account", "", "filter by account/platform ID")
+	cmd.Flags().Int64Var(&seed, "seed", 0, "random seed for a reproducible sample (default: random, printed to stderr)")
 	cmd.Flags().StringVar(&format, "format", string(beeper.FormatRich), "message format: plain|rich")
 
 	return cmd
 }
+
+func newMessagesExportCmd(app *App) *cobra.Command {
+	var threadRef string
+	var format string
+	var outPath string
+	var skipUndecrypted bool
+	var from string
+	var include []string
+	var exclude []string
+
+	cmd := &cobra.Command{
+		Use:               "export",
+		Short:             "Write a thread's full transcript to a file",
+		ValidArgsFunction: app.completeThreadArgs,
+		RunE: func(_ *cobra.Command, args []string) error {
+			if threadRef == "" && len(args) > 0 {
+				threadRef = args[0]
+			}
+			if threadRef == "" {
+				return fmt.Errorf("thread ID is required")
+			}
+			ext := ""
+			switch format {
+			case "markdown", "md":
+				ext = ".md"
+			case "json":
+				ext = ".json"
+			case "ndjson":
+				ext = ".ndjson"
+			case "text-canonical":
+				ext = ".txt"
+			default:
+				return fmt.Errorf("invalid format %q (expected markdown, json, ndjson, or text-canonical)", format)
+			}
+
+			ctx := context.Background()
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			threadID, err := resolveThreadRef(ctx, store, threadRef)
+			if err != nil {
+				return err
+			}
+			opts := exportOptions{format: beeper.FormatRich, skipUndecrypted: skipUndecrypted, include: include, exclude: exclude}
+			if from != "" {
+				opts.senderIDs, err = resolveSenderRef(ctx, store, from)
+				if err != nil {
+					return err
+				}
+			}
+			// JSON, NDJSON, and canonical text are streamed message by
+			// message; markdown needs the whole thread for its summary line.
+			write := func(w io.Writer) (int, error) {
+				switch ext {
+				case ".ndjson":
+					return export.StreamNDJSON(w, store.IterMessages(ctx, opts.listOptions(threadID)))
+				case ".txt":
+					return export.StreamCanonicalText(w, store.IterMessages(ctx, opts.listOptions(threadID)))
+				case ".json":
+					thread, err := store.GetThread(ctx, threadID, true)
+					if err != nil {
+						return 0, err
+					}
+					return export.StreamJSON(w, thread, store.IterMessages(ctx, opts.listOptions(threadID)))
+				}
+				data, err := loadThreadExport(ctx, store, threadID, opts)
+				if err != nil {
+					return 0, err
+				}
+				return len(data.Messages), export.WriteLocalizedMarkdown(w, data, outputLocale)
+			}
+			if outPath == "-" {
+				_, err := write(os.Stdout)
+				return err
+			}
+			if outPath == "" {
+				outPath = export.FileName(threadID, ext)
+			}
+			f, err := os.Create(outPath)
+			if err != nil {
+				return err
+			}
+			count, err := write(f)
+			if err != nil {
+				_ = f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+
+			file := exportedFile{ThreadID: threadID, Path: outPath, Messages: count, Changed: true}
+			if app.JSON {
+				return app.write(file)
+			}
+			fmt.Printf("Wrote %d messages to %s\n", file.Messages, file.Path)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&threadRef, "thread", "", "thread to export (ID or name)")
+	cmd.Flags().StringVar(&format, "format", "markdown", "output format: markdown|json|ndjson (one message per line)|text-canonical (diffable, one escaped line per message)")
+	cmd.Flags().StringVar(&outPath, "out", "", "output file (default: <thread>.md in the current directory, - for stdout)")
+	cmd.Flags().BoolVar(&skipUndecrypted, "skip-undecrypted", false, "leave out messages Beeper has not decrypted yet instead of showing them as [Not yet decrypted]")
+	cmd.Flags().StringVar(&from, "from", "", "only messages sent by this participant (ID or contact name)")
+	cmd.Flags().StringArrayVar(&include, "include", nil, "only messages containing this text (repeatable; any may match)")
+	cmd.Flags().StringArrayVar(&exclude, "exclude", nil, "leave out messages containing this text (repeatable)")
+
+	return cmd
+}
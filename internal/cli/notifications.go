@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"context"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+	"github.com/spf13/cobra"
+)
+
+func newNotificationsCmd(app *App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "notifications",
+		Short: "Reconstruct notifications from the message history",
+	}
+
+	cmd.AddCommand(newNotificationsListCmd(app))
+	return cmd
+}
+
+func newNotificationsListCmd(app *App) *cobra.Command {
+	var since string
+	var until string
+	var accountID string
+	var limit int
+	var includeLowPriority bool
+	var mentionsOnly bool
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List messages and mentions that would have notified you",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			sinceTime, err := parseSinceFlag(since, time.Now())
+			if err != nil {
+				return err
+			}
+			untilTime, err := parseDateFlag(until, true)
+			if err != nil {
+				return err
+			}
+			formatValue, err := parseMessageFormat(format)
+			if err != nil {
+				return err
+			}
+
+			ctx := context.Background()
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			notifications, err := store.ListNotifications(ctx, beeper.NotificationOptions{
+				Since:              sinceTime,
+				Until:              untilTime,
+				AccountID:          accountID,
+				Limit:              limit,
+				IncludeLowPriority: includeLowPriority,
+				MentionsOnly:       mentionsOnly,
+				Format:             formatValue,
+			})
+			if err != nil {
+				return err
+			}
+
+			if app.JSON {
+				return app.write(notifications)
+			}
+
+			w := newTextTable(5)
+			if err := writeHeader(w, "TIME\tKIND\tACCOUNT\tTHREAD\tSENDER\tTEXT"); err != nil {
+				return err
+			}
+			for _, n := range notifications {
+				sender := n.SenderName
+				if sender == "" {
+					sender = n.SenderID
+				}
+				if err := writef(w, "%s\t%s\t%s\t%s\t%s\t%s\n", formatTime(n.Timestamp), n.Kind, safe(n.AccountID), safe(n.ThreadName), sender, tableText(outputLocale.Text(n.Text))); err != nil {
+					return err
+				}
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().StringVar(&since, "since", "24h", "start of the window: a time ago (24h, 7d, 2w), yesterday, a weekday, YYYY-MM-DD, or RFC3339")
+	cmd.Flags().StringVar(&until, "until", "", "end of the window, in the same forms as --since (default: now)")
+	cmd.Flags().StringVar(&accountID, "account", "", "filter by account/platform ID")
+	cmd.Flags().IntVar(&limit, "limit", 100, "max number of notifications")
+	cmd.Flags().BoolVar(&includeLowPriority, "include-low-priority", false, "also notify for every message in low-priority threads")
+	cmd.Flags().BoolVar(&mentionsOnly, "mentions", false, "only mentions of you")
+	cmd.Flags().StringVar(&format, "format", string(beeper.FormatRich), "message format: plain|rich")
+
+	return cmd
+}
@@ -1,31 +1,310 @@
 package cli
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
-	"text/tabwriter"
+	"reflect"
+	"slices"
+	"strings"
 	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/i18n"
+	"github.com/spf13/cobra"
 )
 
-const timeLayout = "2006-01-02 15:04:05"
+// Output formats selected with --output.
+const (
+	outputTable  = "table"
+	outputJSON   = "json"
+	outputNDJSON = "ndjson"
+	outputCSV    = "csv"
+)
 
-func formatTime(ts time.Time) string {
-	if ts.IsZero() {
-		return "-"
+var outputFormats = []string{outputTable, outputJSON, outputNDJSON, outputCSV}
+
+// outputLocale localizes table output and exports; set from --locale or the
+// config like time.Local is from the timezone.
+var outputLocale = i18n.English
+
+// resolveOutput validates --output and reconciles it with --json. Every
+// format but table sets App.JSON, so commands take their structured-output
+// path and print through App.write.
+func (a *App) resolveOutput(cmd *cobra.Command) error {
+	if !slices.Contains(outputFormats, a.Output) {
+		return fmt.Errorf("invalid output %q (expected table, json, ndjson, or csv)", a.Output)
+	}
+	if a.JSON {
+		if cmd.Flags().Changed("output") && a.Output != outputJSON {
+			return fmt.Errorf("--json conflicts with --output %s", a.Output)
+		}
+		a.Output = outputJSON
+	}
+	a.JSON = a.Output != outputTable
+	return nil
+}
+
+// write prints a command's structured result in the --output format.
+func (a *App) write(v any) error {
+	return writeOutput(os.Stdout, a.Output, v)
+}
+
+// writePage prints one page of a paginated listing. With --cursor given,
+// JSON output is the page object itself (items plus nextCursor); otherwise
+// items are printed as usual and a next cursor goes to stderr, keeping
+// stdout machine-readable.
+func (a *App) writePage(page any, items any, nextCursor string, paging bool) error {
+	if paging && a.Output == outputJSON {
+		return a.write(page)
+	}
+	if err := a.write(items); err != nil {
+		return err
+	}
+	if nextCursor != "" {
+		fmt.Fprintln(os.Stderr, outputLocale.Tf("next cursor: %s", nextCursor))
+	}
+	return nil
+}
+
+// writeCursorTrailer ends a table with the cursor of the next page, if any.
+func writeCursorTrailer(nextCursor string) error {
+	if nextCursor == "" {
+		return nil
+	}
+	_, err := fmt.Printf("\n%s\n", outputLocale.Tf("Next cursor: %s", nextCursor))
+	return err
+}
+
+// writeOutput encodes v as one indented JSON document (json, and table for
+// commands without a table view), as NDJSON, or as CSV. NDJSON and CSV turn
+// a top-level list into one line or row per element and anything else
+// into a single line or row.
+func writeOutput(w io.Writer, format string, v any) error {
+	switch format {
+	case outputNDJSON:
+		items, err := outputItems(v)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			if _, err := fmt.Fprintf(w, "%s\n", item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case outputCSV:
+		items, err := outputItems(v)
+		if err != nil {
+			return err
+		}
+		return writeCSV(w, items)
+	default:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
 	}
-	return ts.Local().Format(timeLayout)
 }
 
-func newTabWriter() *tabwriter.Writer {
-	return tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+// outputStream writes results one at a time for commands that produce
+// them incrementally, such as watch, or too many to hold at once, such as
+// messages list --all. JSON and NDJSON both write one compact value per
+// line; CSV takes its columns from the item type's JSON fields.
+type outputStream struct {
+	w       io.Writer
+	format  string
+	csv     *csv.Writer
+	columns []string
 }
 
-func writeJSON(v any) error {
-	enc := json.NewEncoder(os.Stdout)
-	enc.SetIndent("", "  ")
-	return enc.Encode(v)
+// newTypedOutputStream returns an outputStream whose CSV columns are every
+// JSON field of T, so items that omit empty fields keep a fixed set of
+// columns.
+func newTypedOutputStream[T any](w io.Writer, format string) *outputStream {
+	return &outputStream{w: w, format: format, columns: jsonFields(reflect.TypeFor[T]())}
+}
+
+// jsonFields returns the JSON names of a struct type's fields in order,
+// with embedded structs flattened as encoding/json does.
+func jsonFields(t reflect.Type) []string {
+	fields := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		switch {
+		case name == "-" || (!f.IsExported() && !f.Anonymous):
+			continue
+		case f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct:
+			fields = append(fields, jsonFields(f.Type)...)
+		case name == "":
+			fields = append(fields, f.Name)
+		default:
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
+
+func (s *outputStream) write(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if s.format != outputCSV {
+		_, err := fmt.Fprintf(s.w, "%s\n", data)
+		return err
+	}
+	row, _, err := csvRow(data)
+	if err != nil {
+		return err
+	}
+	if s.csv == nil {
+		s.csv = csv.NewWriter(s.w)
+		if err := s.csv.Write(s.columns); err != nil {
+			return err
+		}
+	}
+	record := make([]string, len(s.columns))
+	for i, col := range s.columns {
+		record[i] = row[col]
+	}
+	if err := s.csv.Write(record); err != nil {
+		return err
+	}
+	// Flush every row so it can be piped as soon as it arrives.
+	s.csv.Flush()
+	return s.csv.Error()
+}
+
+// writeStreamPage writes one page of items as NDJSON or CSV through an
+// outputStream, so they are not marshaled twice, and the next cursor to
+// stderr like writePage.
+func writeStreamPage[T any](format string, items []T, nextCursor string) error {
+	out := bufio.NewWriter(os.Stdout)
+	stream := newTypedOutputStream[T](out, format)
+	for _, item := range items {
+		if err := stream.write(item); err != nil {
+			return err
+		}
+	}
+	if err := out.Flush(); err != nil {
+		return err
+	}
+	if nextCursor != "" {
+		fmt.Fprintln(os.Stderr, outputLocale.Tf("next cursor: %s", nextCursor))
+	}
+	return nil
+}
+
+// outputItems marshals v and splits a top-level array into its elements.
+func outputItems(v any) ([]json.RawMessage, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil, nil
+	}
+	if len(data) == 0 || data[0] != '[' {
+		return []json.RawMessage{data}, nil
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// writeCSV writes one row per item. Object fields become columns in the
+// order they first appear; other items go into a "value" column. Nested
+// objects and arrays are written as compact JSON.
+func writeCSV(w io.Writer, items []json.RawMessage) error {
+	columns := []string{}
+	rows := make([]map[string]string, 0, len(items))
+	for _, item := range items {
+		row, keys, err := csvRow(item)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if !slices.Contains(columns, key) {
+				columns = append(columns, key)
+			}
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+	record := make([]string, len(columns))
+	for _, row := range rows {
+		for i, col := range columns {
+			record[i] = row[col]
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func csvRow(item json.RawMessage) (map[string]string, []string, error) {
+	if len(item) == 0 || item[0] != '{' {
+		cell, err := csvCell(item)
+		return map[string]string{"value": cell}, []string{"value"}, err
+	}
+	row := map[string]string{}
+	keys := []string{}
+	dec := json.NewDecoder(bytes.NewReader(item))
+	if _, err := dec.Token(); err != nil {
+		return nil, nil, err
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key, _ := tok.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, nil, err
+		}
+		if row[key], err = csvCell(value); err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, key)
+	}
+	return row, keys, nil
+}
+
+func csvCell(value json.RawMessage) (string, error) {
+	switch {
+	case len(value) == 0 || bytes.Equal(value, []byte("null")):
+		return "", nil
+	case value[0] == '"':
+		var s string
+		err := json.Unmarshal(value, &s)
+		return s, err
+	default:
+		return string(value), nil
+	}
+}
+
+func formatTime(ts time.Time) string {
+	if ts.IsZero() {
+		return "-"
+	}
+	return outputLocale.Format(ts.Local(), outputLocale.DateTime)
 }
 
 func writeLine(w io.Writer, args ...any) error {
@@ -33,6 +312,11 @@ func writeLine(w io.Writer, args ...any) error {
 	return err
 }
 
+// writeHeader writes a tab-separated table header in the output locale.
+func writeHeader(w io.Writer, header string) error {
+	return writeLine(w, outputLocale.Header(header))
+}
+
 func writef(w io.Writer, format string, args ...any) error {
 	_, err := fmt.Fprintf(w, format, args...)
 	return err
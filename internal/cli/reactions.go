@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+	"github.com/spf13/cobra"
+)
+
+func newReactionsCmd(app *App) *cobra.Command {
+	var threadRef string
+	var key string
+	var limit int
+	var days int
+	var after string
+	var since string
+	var before string
+	var format string
+
+	cmd := &cobra.Command{
+		Use:               "reactions [thread]",
+		Short:             "Show messages in a thread that got reactions, and who reacted with what",
+		ValidArgsFunction: app.completeThreadArgs,
+		RunE: func(_ *cobra.Command, args []string) error {
+			if threadRef == "" && len(args) > 0 {
+				threadRef = args[0]
+			}
+			if threadRef == "" {
+				return fmt.Errorf("thread ID is required")
+			}
+			afterTime, err := parseTimeFlag(cmp.Or(after, since), days)
+			if err != nil {
+				return err
+			}
+			beforeTime, err := parseTimePtr(before)
+			if err != nil {
+				return err
+			}
+			formatValue, err := parseMessageFormat(format)
+			if err != nil {
+				return err
+			}
+
+			ctx := context.Background()
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			threadID, err := resolveThreadRef(ctx, store, threadRef)
+			if err != nil {
+				return err
+			}
+			list, err := store.ListReactions(ctx, beeper.ReactionOptions{
+				ThreadID: threadID,
+				Key:      key,
+				After:    afterTime,
+				Before:   beforeTime,
+				Limit:    limit,
+				Format:   formatValue,
+			})
+			if err != nil {
+				return err
+			}
+
+			if app.JSON {
+				return app.write(list)
+			}
+
+			w := newTextTable(2)
+			if err := writeHeader(w, "TIME\tSENDER\tTEXT\tREACTIONS\tBY"); err != nil {
+				return err
+			}
+			for _, item := range list {
+				msg := item.Message
+				sender := msg.SenderName
+				if sender == "" {
+					sender = msg.SenderID
+				}
+				tallies := make([]string, 0, len(item.Tallies))
+				for _, t := range item.Tallies {
+					tallies = append(tallies, fmt.Sprintf("%s %d", t.Key, t.Count))
+				}
+				if err := writef(w, "%s\t%s\t%s\t%s\t%s\n", formatTime(msg.Timestamp), sender, tableText(outputLocale.Text(msg.Text)), strings.Join(tallies, "  "), formatReactors(item.Reactions)); err != nil {
+					return err
+				}
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().StringVar(&threadRef, "thread", "", "thread ID (room ID) or name")
+	cmd.Flags().StringVar(&key, "key", "", "only reactions with this emoji (👍 also matches skin-tone variants)")
+	cmd.Flags().IntVar(&limit, "limit", 50, "max number of messages to return")
+	cmd.Flags().IntVar(&days, "days", 0, "only messages from the last N days")
+	cmd.Flags().StringVar(&after, "after", "", "only messages after this time: RFC3339, YYYY-MM-DD, yesterday, last monday, 2w, 3d ago, ...")
+	cmd.Flags().StringVar(&since, "since", "", "same as --after, e.g. --since yesterday")
+	cmd.Flags().StringVar(&before, "before", "", "only messages before this time (same forms as --after)")
+	cmd.MarkFlagsMutuallyExclusive("after", "since")
+	cmd.Flags().StringVar(&format, "format", string(beeper.FormatRich), "message format: plain|rich")
+
+	return cmd
+}
+
+// formatReactors renders reactions as "👍 Alice, 🎉 Me".
+func formatReactors(reactions []beeper.Reaction) string {
+	parts := make([]string, 0, len(reactions))
+	for _, r := range reactions {
+		name := r.SenderName
+		if name == "" {
+			name = r.SenderID
+		}
+		if r.IsSentByMe {
+			name = outputLocale.T("Me")
+		}
+		parts = append(parts, r.Key+" "+name)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/KrauseFx/beeper-cli/internal/config"
+	"github.com/KrauseFx/beeper-cli/internal/recording"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// unrecordedFlags point at local files, control recording itself, or are
+// secrets, so they are left out of a session's arguments.
+var unrecordedFlags = []string{"db", "config", "sidecar", "record", "record-redact", "version", "token", "signing-key"}
+
+// safeFlags hold formats, enumerations, or times rather than chat content.
+// With --record-redact every other string flag is pseudonymized, like
+// positional arguments.
+var safeFlags = []string{
+	"output", "locale", "color", "format", "file-format", "sort", "label", "type", "kind",
+	"entity-kind", "group-by", "account", "platform", "export", "dialect", "tokenizer",
+	"days", "since", "after", "before", "to", "until", "window", "gap", "session-gap",
+	"stale-after", "interval", "cursor", "listen",
+}
+
+// redactFlag reports whether --record-redact pseudonymizes the values of f:
+// every string or string list flag not in safeFlags.
+func redactFlag(f *pflag.Flag) bool {
+	switch f.Value.Type() {
+	case "string", "stringArray", "stringSlice":
+		return !slices.Contains(safeFlags, f.Name)
+	}
+	return false
+}
+
+// recordingSession is an active --record.
+type recordingSession struct {
+	*recording.Recorder
+	path     string
+	args     []string
+	config   config.Config
+	redactor *recording.Redactor
+}
+
+// startRecording begins capturing the queries of cmd when --record is set.
+func (a *App) startRecording(cmd *cobra.Command, args []string) error {
+	if a.RecordPath == "" {
+		if a.RecordRedact {
+			return fmt.Errorf("--record-redact requires --record")
+		}
+		return nil
+	}
+	if cmd.Name() == "replay" {
+		return fmt.Errorf("--record cannot be combined with replay")
+	}
+
+	session := &recordingSession{path: a.RecordPath, config: a.Config}
+	if a.RecordRedact {
+		session.redactor = recording.NewRedactor()
+	}
+	session.Recorder = recording.NewRecorder(session.redactor)
+	session.config.DBPath = ""
+	session.config.SidecarPath = ""
+
+	session.args = strings.Fields(cmd.CommandPath())[1:]
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		if slices.Contains(unrecordedFlags, f.Name) {
+			return
+		}
+		values := []string{f.Value.String()}
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			values = sv.GetSlice()
+		}
+		for _, v := range values {
+			if redactFlag(f) {
+				v = session.redactArg(v)
+			}
+			session.args = append(session.args, "--"+f.Name+"="+v)
+		}
+	})
+	for _, arg := range args {
+		session.args = append(session.args, session.redactArg(arg))
+	}
+	a.recorder = session
+	return nil
+}
+
+// redactArg pseudonymizes a thread name, search query, or similar argument
+// the same way as recorded chat content, so name lookups still match on
+// replay. Matrix IDs and numbers are kept.
+func (s *recordingSession) redactArg(arg string) string {
+	if s.redactor == nil || strings.HasPrefix(arg, "!") || strings.HasPrefix(arg, "@") || strings.HasPrefix(arg, "$") {
+		return arg
+	}
+	if _, err := strconv.Atoi(arg); err == nil {
+		return arg
+	}
+	return s.redactor.Text(arg)
+}
+
+func (s *recordingSession) save(cmdErr error) error {
+	rec := s.Recording()
+	rec.CLIVersion = Version
+	rec.Args = s.args
+	rec.Config = s.config
+	if cmdErr != nil {
+		rec.Error = cmdErr.Error()
+	}
+	if err := rec.Save(s.path); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Recorded %d queries to %s\n", len(rec.Queries), s.path)
+	return nil
+}
+
+func newReplayCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "replay <session.json>",
+		Short: "Re-run a command recorded with --record against its recorded queries",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			rec, err := recording.Load(args[0])
+			if err != nil {
+				return err
+			}
+			if len(rec.Args) > 0 && rec.Args[0] == "replay" {
+				return fmt.Errorf("cannot replay a replay")
+			}
+			fmt.Fprintf(os.Stderr, "Replaying: beeper-cli %s (recorded with %s, %d queries)\n", strings.Join(rec.Args, " "), rec.CLIVersion, len(rec.Queries))
+
+			replayer := recording.NewReplayer(rec)
+			replayApp := &App{replayer: replayer, replayConf: &rec.Config}
+			root := newRootCmd(replayApp)
+			root.SilenceErrors = true
+			root.SilenceUsage = true
+			root.SetArgs(append(slices.Clone(rec.Args), "--no-bridge"))
+			err = replayApp.finish(root.Execute())
+
+			fmt.Fprintf(os.Stderr, "Replayed %d of %d recorded queries\n", replayer.Replayed(), len(rec.Queries))
+			if rec.Error != "" {
+				fmt.Fprintf(os.Stderr, "Recorded error: %s\n", rec.Error)
+			}
+			return err
+		},
+	}
+	return cmd
+}
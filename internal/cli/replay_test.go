@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestStartRecordingRedactsFlags(t *testing.T) {
+	app := &App{RecordPath: "session.json", RecordRedact: true}
+	cmd := &cobra.Command{Use: "list"}
+	cmd.Flags().String("include", "", "")
+	cmd.Flags().StringArray("participant", nil, "")
+	cmd.Flags().String("format", "", "")
+	cmd.Flags().String("token", "", "")
+	cmd.Flags().Int("limit", 0, "")
+	if err := cmd.ParseFlags([]string{"--include=invoice", "--participant=Alice", "--format=plain", "--token=s3cret", "--limit=5"}); err != nil {
+		t.Fatalf("parse flags: %v", err)
+	}
+	if err := app.startRecording(cmd, nil); err != nil {
+		t.Fatalf("start recording: %v", err)
+	}
+	args := app.recorder.args
+	redactor := app.recorder.redactor
+	want := []string{"--format=plain", "--include=" + redactor.Text("invoice"), "--limit=5", "--participant=" + redactor.Text("Alice")}
+	if !slices.Equal(args, want) {
+		t.Fatalf("expected %v, got %v", want, args)
+	}
+	if strings.Contains(strings.Join(args, " "), "s3cret") || redactor.Text("invoice") == "invoice" {
+		t.Fatalf("recorded arguments leak: %v", args)
+	}
+}
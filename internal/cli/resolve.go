@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"context"
+	"strings"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+)
+
+// resolveThreadRef maps a thread ID or a human name to a thread ID.
+func resolveThreadRef(ctx context.Context, store *beeper.Store, ref string) (string, error) {
+	ref = strings.TrimSpace(ref)
+	if strings.HasPrefix(ref, "!") {
+		return ref, nil
+	}
+	thread, err := store.ResolveThread(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	return thread.ID, nil
+}
+
+// resolveSenderRef maps a participant ID or contact name to the participant
+// IDs the contact sends messages as.
+func resolveSenderRef(ctx context.Context, store *beeper.Store, ref string) ([]string, error) {
+	ref = strings.TrimSpace(ref)
+	if strings.HasPrefix(ref, "@") {
+		return []string{ref}, nil
+	}
+	contact, err := store.GetContact(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	return contact.IDs, nil
+}
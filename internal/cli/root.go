@@ -1,29 +1,61 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/KrauseFx/beeper-cli/internal/beeper"
 	"github.com/KrauseFx/beeper-cli/internal/config"
+	"github.com/KrauseFx/beeper-cli/internal/embed"
+	"github.com/KrauseFx/beeper-cli/internal/i18n"
+	"github.com/KrauseFx/beeper-cli/internal/recording"
+	"github.com/KrauseFx/beeper-cli/internal/sidecar"
 	"github.com/spf13/cobra"
 )
 
 // App holds shared CLI configuration.
 type App struct {
-	DBPath      string
-	JSON        bool
-	NoBridge    bool
-	ShowVersion bool
+	DBPath         string
+	ConfigPath     string
+	SidecarPath    string
+	JSON           bool
+	Output         string
+	NoBridge       bool
+	NoNames        bool
+	NoParticipants bool
+	Snapshot       bool
+	Immutable      bool
+	ShowVersion    bool
+	Mask           bool
+	RecordPath     string
+	RecordRedact   bool
+	Profile        bool
+	Locale         string
+	Color          string
+	Full           bool
+	Config         config.Config
+
+	// stopMasking restores stdout after masked output; nil when unmasked.
+	stopMasking func() error
+	// recorder captures queries for --record; replayer answers them
+	// during replay.
+	recorder   *recordingSession
+	replayer   *recording.Replayer
+	replayConf *config.Config
+	// profiler times queries for --profile-queries.
+	profiler *recording.Profiler
 }
 
 // Execute runs the CLI entrypoint.
 func Execute() {
 	app := &App{}
 	rootCmd := newRootCmd(app)
-	if err := rootCmd.Execute(); err != nil {
+	err := app.finish(rootCmd.Execute())
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 }
 
@@ -32,12 +64,31 @@ func newRootCmd(app *App) *cobra.Command {
 		Use:   "beeper-cli",
 		Short: "Read-only CLI for local Beeper chats",
 		Long:  "Beeper CLI provides read-only access to local Beeper SQLite data, including threads, messages, and search.",
-		PersistentPreRunE: func(_ *cobra.Command, _ []string) error {
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			if app.ShowVersion {
 				fmt.Println(Version)
 				os.Exit(0)
 			}
-			return nil
+			if err := app.resolveOutput(cmd); err != nil {
+				return err
+			}
+			if cmd.Name() == "init" {
+				return nil
+			}
+			if err := app.loadConfig(cmd); err != nil {
+				return err
+			}
+			// Before masking, which replaces stdout with a pipe.
+			if err := app.resolveStyle(cmd); err != nil {
+				return err
+			}
+			if err := app.startMasking(cmd); err != nil {
+				return err
+			}
+			if app.Profile {
+				app.profiler = recording.NewProfiler()
+			}
+			return app.startRecording(cmd, args)
 		},
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			if app.ShowVersion {
@@ -49,29 +100,205 @@ func newRootCmd(app *App) *cobra.Command {
 	}
 
 	cmd.PersistentFlags().StringVar(&app.DBPath, "db", "", "path to Beeper index.db (or set BEEPER_DB)")
-	cmd.PersistentFlags().BoolVar(&app.JSON, "json", false, "output JSON")
+	cmd.PersistentFlags().StringVar(&app.ConfigPath, "config", "", "path to config file (or set BEEPER_CLI_CONFIG)")
+	cmd.PersistentFlags().StringVar(&app.SidecarPath, "sidecar", "", "path to the writable sidecar DB for derived data (or set BEEPER_CLI_SIDECAR)")
+	cmd.PersistentFlags().BoolVar(&app.JSON, "json", false, "output JSON (same as --output json)")
+	cmd.PersistentFlags().StringVar(&app.Output, "output", outputTable, "output format: table|json|ndjson|csv")
 	cmd.PersistentFlags().BoolVar(&app.NoBridge, "no-bridge", false, "disable megabridge name lookups")
+	cmd.PersistentFlags().BoolVar(&app.NoParticipants, "no-participants", false, "skip loading participants: no sender names or participant lists")
+	cmd.PersistentFlags().BoolVar(&app.NoNames, "no-names", false, "skip every name lookup (participants and bridges); output IDs, titles, and timestamps only")
+	cmd.PersistentFlags().BoolVar(&app.Snapshot, "snapshot", false, "query a temporary copy of the database (WAL included) so long commands never hold up Beeper")
+	cmd.PersistentFlags().BoolVar(&app.Immutable, "immutable", false, "open the database without locking; changes not yet checkpointed from the WAL are not visible")
+	cmd.PersistentFlags().BoolVar(&app.Mask, "mask", false, "mask phone numbers, one-time codes, and card numbers in terminal output (not --json or --output)")
+	cmd.PersistentFlags().StringVar(&app.RecordPath, "record", "", "record the queries this command issues to a session file for bug reports (see replay)")
+	cmd.PersistentFlags().BoolVar(&app.RecordRedact, "record-redact", false, "pseudonymize chat content in the --record session file")
+	cmd.PersistentFlags().BoolVar(&app.Profile, "profile-queries", false, "time the queries this command issues and print a summary to stderr")
+	cmd.PersistentFlags().StringVar(&app.Locale, "locale", "", "language of table headers, dates, and exports: en|de|fr|es (default: config locale, else en)")
+	cmd.PersistentFlags().StringVar(&app.Color, "color", "", "color table output: auto|always|never (default: config color, else auto)")
+	cmd.PersistentFlags().BoolVar(&app.Full, "full", false, "do not shorten message text to the terminal width in tables")
 	cmd.PersistentFlags().BoolVar(&app.ShowVersion, "version", false, "print version")
+	cmd.MarkFlagsMutuallyExclusive("snapshot", "immutable")
+	cmd.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return usageError{err}
+	})
 
 	cmd.AddCommand(newThreadsCmd(app))
 	cmd.AddCommand(newMessagesCmd(app))
 	cmd.AddCommand(newSearchCmd(app))
+	cmd.AddCommand(newExportCmd(app))
+	cmd.AddCommand(newWrappedCmd(app))
+	cmd.AddCommand(newDigestCmd(app))
+	cmd.AddCommand(newBoardCmd(app))
+	cmd.AddCommand(newStatsCmd(app))
+	cmd.AddCommand(newExtractCmd(app))
+	cmd.AddCommand(newIndexCmd(app))
+	cmd.AddCommand(newAnalyzeCmd(app))
+	cmd.AddCommand(newContactsCmd(app))
+	cmd.AddCommand(newBookmarksCmd(app))
+	cmd.AddCommand(newReactionsCmd(app))
+	cmd.AddCommand(newMentionsCmd(app))
+	cmd.AddCommand(newLinksCmd(app))
+	cmd.AddCommand(newNotificationsCmd(app))
+	cmd.AddCommand(newAccountsCmd(app))
+	cmd.AddCommand(newAttachmentsCmd(app))
+	cmd.AddCommand(newWatchCmd(app))
+	cmd.AddCommand(newMCPCmd(app))
+	cmd.AddCommand(newServeCmd(app))
 	cmd.AddCommand(newDBCmd(app))
+	cmd.AddCommand(newDevtoolsCmd(app))
+	cmd.AddCommand(newInitCmd(app))
+	cmd.AddCommand(newReplayCmd())
 	cmd.AddCommand(newVersionCmd())
+	app.registerCompletions(cmd)
 
 	return cmd
 }
 
+// loadConfig reads the config file and applies its defaults, global and
+// per command, to flags the user did not set.
+// During replay the recorded config is used instead.
+func (a *App) loadConfig(cmd *cobra.Command) error {
+	path := "recording"
+	cfg := config.Config{}
+	if a.replayConf != nil {
+		cfg = *a.replayConf
+	} else {
+		var err error
+		path, err = config.ResolveConfigPath(a.ConfigPath)
+		if err != nil {
+			return err
+		}
+		cfg, err = config.LoadConfig(path)
+		if err != nil {
+			return err
+		}
+	}
+	a.Config = cfg
+
+	if cfg.Timezone != "" {
+		loc, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			return fmt.Errorf("invalid timezone %q in config: %w", cfg.Timezone, err)
+		}
+		// Table output renders timestamps via time.Local.
+		time.Local = loc
+	}
+
+	tag := a.Locale
+	if tag == "" {
+		tag = cfg.Locale
+	}
+	loc, err := i18n.Parse(tag)
+	if err != nil {
+		return err
+	}
+	outputLocale = loc
+
+	if cfg.Format != "" {
+		if _, err := parseMessageFormat(cfg.Format); err != nil {
+			return fmt.Errorf("invalid config %s: %w", path, err)
+		}
+		// Only message-format flags (plain|rich) pick up the default.
+		flag := cmd.Flags().Lookup("format")
+		if flag != nil && !flag.Changed && flag.DefValue == string(beeper.FormatRich) {
+			if err := flag.Value.Set(cfg.Format); err != nil {
+				return err
+			}
+		}
+	}
+	return a.applyCommandDefaults(cmd)
+}
+
+// finish stops masking, writes the --record session, and prints the
+// --profile-queries report once the command has run, returning err or the
+// first error doing so.
+func (a *App) finish(err error) error {
+	if a.stopMasking != nil {
+		if stopErr := a.stopMasking(); err == nil {
+			err = stopErr
+		}
+		a.stopMasking = nil
+	}
+	if a.recorder != nil {
+		if saveErr := a.recorder.save(err); err == nil {
+			err = saveErr
+		}
+		a.recorder = nil
+	}
+	if a.profiler != nil {
+		if reportErr := a.profiler.WriteReport(os.Stderr, profileTop); err == nil {
+			err = reportErr
+		}
+		a.profiler = nil
+	}
+	return err
+}
+
+// profileTop is how many queries the --profile-queries report lists.
+const profileTop = 10
+
 func (a *App) openStore() (*beeper.Store, string, error) {
-	path, err := config.ResolveDBPath(a.DBPath)
+	if a.replayer != nil {
+		store, err := beeper.OpenWithOptions("replay", beeper.StoreOptions{Connector: a.replayer.Connector})
+		return store, "replay", err
+	}
+	dbPath := a.DBPath
+	if dbPath == "" && os.Getenv("BEEPER_DB") == "" {
+		dbPath = a.Config.DBPath
+	}
+	path, err := config.ResolveDBPath(dbPath)
 	if err != nil {
-		return nil, "", err
+		return nil, "", dbPathError{err}
 	}
-	store, err := beeper.OpenWithOptions(path, beeper.StoreOptions{
-		BridgeLookup: !a.NoBridge,
-	})
+	opts := beeper.StoreOptions{
+		BridgeLookup:   !a.NoBridge,
+		NoParticipants: a.NoParticipants,
+		NoNames:        a.NoNames,
+		Snapshot:       a.Snapshot,
+		Immutable:      a.Immutable,
+		PreviewLength:  a.Config.PreviewLength,
+	}
+	if a.recorder != nil {
+		opts.Connector = a.recorder.Connector
+	}
+	if a.profiler != nil {
+		opts.Connector = a.profiler.Wrap(opts.Connector)
+	}
+	store, err := beeper.OpenWithOptions(path, opts)
 	if err != nil {
 		return nil, "", err
 	}
 	return store, path, nil
 }
+
+// openSidecar opens the writable sidecar database for derived data.
+func (a *App) openSidecar() (*sidecar.DB, error) {
+	path, err := config.ResolveSidecarPath(a.SidecarPath, a.Config.SidecarPath)
+	if err != nil {
+		return nil, err
+	}
+	return sidecar.Open(path)
+}
+
+// embedder returns the embedder for semantic search: the external command
+// from the flag, BEEPER_CLI_EMBED_COMMAND, or config, else the built-in one.
+func (a *App) embedder(command string) embed.Embedder {
+	if command = config.ResolveEmbedCommand(command, a.Config.EmbedCommand); command != "" {
+		return embed.CommandEmbedder{Command: command}
+	}
+	return embed.HashEmbedder{}
+}
+
+// openExistingSidecar opens the sidecar DB only if it has been created, so
+// read-only commands do not leave an empty file behind. It returns nil
+// when there is none.
+func (a *App) openExistingSidecar() (*sidecar.DB, error) {
+	path, err := config.ResolveSidecarPath(a.SidecarPath, a.Config.SidecarPath)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return sidecar.Open(path)
+}
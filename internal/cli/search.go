@@ -1,32 +1,98 @@
 package cli
 
 import (
+	"bytes"
+	"cmp"
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/KrauseFx/beeper-cli/internal/beeper"
+	"github.com/KrauseFx/beeper-cli/internal/embed"
+	"github.com/KrauseFx/beeper-cli/internal/export"
+	"github.com/KrauseFx/beeper-cli/internal/extract"
+	"github.com/KrauseFx/beeper-cli/internal/sidecar"
 	"github.com/spf13/cobra"
 )
 
+// semanticOverfetch is how many nearest neighbors search --semantic
+// considers per requested result before filtering.
+const semanticOverfetch = 5
+
 func newSearchCmd(app *App) *cobra.Command {
 	var days int
+	var after string
+	var since string
+	var before string
 	var limit int
 	var threadID string
 	var accountID string
 	var contextSize int
 	var window string
 	var format string
+	var withProvenance bool
+	var entity string
+	var entityKind string
+	var msgType string
+	var mergeContext bool
+	var groupBy string
+	var semantic bool
+	var fuzzy bool
+	var fuzzyThreshold float64
+	var embedCommand string
+	var from string
+	var mine bool
+	var emoji []string
+	var skipUndecrypted bool
+	var requireFTS bool
+	var cursor string
+	var exportFormat string
+	var outDir string
+	var count bool
 
 	cmd := &cobra.Command{
-		Use:   "search <query>",
+		Use:   "search [query]",
 		Short: "Full-text search across messages",
-		RunE: func(_ *cobra.Command, args []string) error {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			query := strings.TrimSpace(strings.Join(args, " "))
-			if query == "" {
+			if query == "" && entity == "" && len(emoji) == 0 && msgType == "" {
 				return fmt.Errorf("search query is required")
 			}
+			for _, e := range emoji {
+				if found := beeper.ExtractEmoji(e); len(found) != 1 || found[0] != beeper.NormalizeEmoji(strings.TrimSpace(e)) {
+					return fmt.Errorf("invalid --emoji %q: expected a single emoji", e)
+				}
+			}
+			if semantic && query == "" {
+				return fmt.Errorf("--semantic requires a search query")
+			}
+			if fuzzy && query == "" {
+				return fmt.Errorf("--fuzzy requires a search query")
+			}
+			if fuzzyThreshold <= 0 || fuzzyThreshold > 1 {
+				return fmt.Errorf("invalid --fuzzy-threshold %v: must be in (0, 1]", fuzzyThreshold)
+			}
+			if groupBy != "" && groupBy != "thread" {
+				return fmt.Errorf("invalid --group-by %q: use thread", groupBy)
+			}
+			paging := cmd.Flags().Changed("cursor")
+			if exportFormat != "" && !slices.Contains(export.SearchFormats, exportFormat) {
+				return fmt.Errorf("invalid --export %q: use md, csv, or jsonl", exportFormat)
+			}
 
+			afterTime, err := parseTimePtr(cmp.Or(after, since))
+			if err != nil {
+				return err
+			}
+			beforeTime, err := parseTimePtr(before)
+			if err != nil {
+				return err
+			}
 			windowDuration, err := parseDuration(window)
 			if err != nil {
 				return err
@@ -45,60 +111,513 @@ func newSearchCmd(app *App) *cobra.Command {
 				_ = store.Close()
 			}()
 
-			results, err := store.SearchMessages(ctx, beeper.SearchOptions{
-				Query:     query,
-				ThreadID:  threadID,
-				Days:      days,
-				Limit:     limit,
-				AccountID: accountID,
-				Context:   contextSize,
-				Window:    windowDuration,
-				Format:    formatValue,
-			})
-			if err != nil {
-				return err
+			if threadID != "" {
+				threadID, err = resolveThreadRef(ctx, store, threadID)
+				if err != nil {
+					return err
+				}
 			}
 
-			if app.JSON {
-				return writeJSON(results)
+			var senderIDs []string
+			if from != "" {
+				senderIDs, err = resolveSenderRef(ctx, store, from)
+				if err != nil {
+					return err
+				}
 			}
 
-			w := newTabWriter()
-			if err := writeLine(w, "TIME\tACCOUNT\tTHREAD\tSENDER\tTEXT\tSCORE"); err != nil {
-				return err
+			var messageIDs []int64
+			if entity != "" {
+				messageIDs, err = app.entityMessageIDs(ctx, entity, entityKind)
+				if err != nil {
+					return err
+				}
 			}
-			for _, msg := range results {
-				match := msg.Match
-				sender := match.SenderName
-				if sender == "" {
-					sender = match.SenderID
+
+			opts := beeper.SearchOptions{
+				Query:           query,
+				ThreadID:        threadID,
+				Days:            days,
+				After:           afterTime,
+				Before:          beforeTime,
+				Limit:           limit,
+				AccountID:       accountID,
+				Context:         contextSize,
+				Window:          windowDuration,
+				Format:          formatValue,
+				WithProvenance:  withProvenance,
+				MessageIDs:      messageIDs,
+				Type:            msgType,
+				SenderIDs:       senderIDs,
+				SentByMe:        mine,
+				Emoji:           emoji,
+				SkipUndecrypted: skipUndecrypted,
+				Cursor:          cursor,
+				RequireFTS:      requireFTS,
+			}
+			if count {
+				n, err := app.countSearchMatches(ctx, store, opts)
+				if err != nil {
+					return err
 				}
-				if err := writef(w, "%s\t%s\t%s\t%s\t%s\t%.2f\n", formatTime(match.Timestamp), safe(match.AccountID), safe(match.ThreadName), sender, match.Text, match.Score); err != nil {
+				return writeCount(app, n)
+			}
+			if semantic || fuzzy {
+				var results []beeper.SearchResult
+				if semantic {
+					results, err = app.semanticSearch(ctx, store, opts, embedCommand)
+				} else {
+					results, err = app.fuzzySearch(ctx, store, opts, fuzzyThreshold)
+				}
+				if err != nil {
 					return err
 				}
-				if contextSize > 0 || windowDuration > 0 {
-					for _, ctxMsg := range msg.Context {
-						ctxSender := ctxMsg.SenderName
-						if ctxSender == "" {
-							ctxSender = ctxMsg.SenderID
-						}
-						if err := writef(w, "  %s\t%s\t%s\t%s\t%s\t\n", formatTime(ctxMsg.Timestamp), safe(ctxMsg.AccountID), safe(ctxMsg.ThreadName), ctxSender, ctxMsg.Text); err != nil {
-							return err
-						}
+				if exportFormat != "" {
+					return exportSearchResults(query, results, exportFormat, outDir)
+				}
+				return writeSearchResults(app, results, "", false, mergeContext, groupBy, contextSize > 0 || windowDuration > 0)
+			}
+
+			page, err := store.SearchMessagesPage(ctx, opts)
+			if err != nil {
+				return err
+			}
+			results := page.Results
+
+			// Media messages often have no text; match their file names,
+			// captions, and alt text via the sidecar index as well. Paging
+			// only walks the keyword matches.
+			if query != "" && !paging {
+				mediaIDs, err := app.searchMediaIDs(ctx, opts)
+				if err != nil {
+					return err
+				}
+				if len(mediaIDs) > 0 {
+					mediaOpts := opts
+					mediaOpts.Query = ""
+					mediaOpts.MessageIDs = mediaIDs
+					mediaResults, err := store.SearchMessages(ctx, mediaOpts)
+					if err != nil {
+						return err
+					}
+					if len(mediaResults) > 0 {
+						// The keyword cursor would skip matches the merge
+						// pushed off this page.
+						page.NextCursor = ""
+					}
+					results = beeper.MergeSearchResults(results, mediaResults)
+					if limit > 0 && len(results) > limit {
+						results = results[:limit]
 					}
 				}
 			}
-			return w.Flush()
+
+			if exportFormat != "" {
+				if err := exportSearchResults(query, results, exportFormat, outDir); err != nil {
+					return err
+				}
+				return writeCursorTrailer(page.NextCursor)
+			}
+			return writeSearchResults(app, results, page.NextCursor, paging, mergeContext, groupBy, contextSize > 0 || windowDuration > 0)
 		},
 	}
 
 	cmd.Flags().IntVar(&days, "days", 0, "only include messages from the last N days")
+	cmd.Flags().StringVar(&after, "after", "", "only include messages after this time: RFC3339, YYYY-MM-DD, yesterday, last monday, 2w, 3d ago, ...")
+	cmd.Flags().StringVar(&since, "since", "", "same as --after, e.g. --since yesterday")
+	cmd.Flags().StringVar(&before, "before", "", "only include messages before this time (same forms as --after)")
+	cmd.MarkFlagsMutuallyExclusive("after", "since")
+	cmd.MarkFlagsMutuallyExclusive("days", "after")
+	cmd.MarkFlagsMutuallyExclusive("days", "since")
 	cmd.Flags().IntVar(&limit, "limit", 50, "max number of results")
-	cmd.Flags().StringVar(&threadID, "thread", "", "only search within a thread (room ID)")
+	cmd.Flags().StringVar(&threadID, "thread", "", "only search within a thread (ID or name)")
 	cmd.Flags().StringVar(&accountID, "account", "", "filter by account/platform ID")
 	cmd.Flags().IntVar(&contextSize, "context", 0, "include N messages before/after the match")
 	cmd.Flags().StringVar(&window, "window", "", "context time window (e.g., 60m)")
 	cmd.Flags().StringVar(&format, "format", string(beeper.FormatRich), "message format: plain|rich")
+	cmd.Flags().BoolVar(&withProvenance, "with-provenance", false, "include platform, remote ID, and sent-via fields in JSON output")
+	cmd.Flags().StringVar(&entity, "entity", "", "only messages mentioning this entity (requires `extract --kind entities`)")
+	cmd.Flags().StringVar(&entityKind, "entity-kind", "", "restrict --entity to person|place|organization|date")
+	cmd.Flags().BoolVar(&mergeContext, "merge-context", false, "merge hits with overlapping context into one block per conversation stretch")
+	cmd.Flags().StringVar(&groupBy, "group-by", "", "cluster results under each conversation with its hit count (JSON: an array of {threadId, hits, results}): thread")
+	cmd.MarkFlagsMutuallyExclusive("group-by", "merge-context")
+	cmd.Flags().BoolVar(&semantic, "semantic", false, "rank by embedding similarity instead of keywords (requires extract --kind embeddings)")
+	cmd.Flags().BoolVar(&fuzzy, "fuzzy", false, "tolerate typos by matching similar words, scored by similarity (requires extract --kind fuzzy)")
+	cmd.Flags().Float64Var(&fuzzyThreshold, "fuzzy-threshold", sidecar.DefaultFuzzySimilarity, "least word similarity (0-1) for --fuzzy; lower tolerates more typos")
+	cmd.Flags().StringVar(&embedCommand, "embed-command", "", "external embedding command for --semantic (or set BEEPER_CLI_EMBED_COMMAND)")
+	cmd.Flags().StringVar(&msgType, "type", "", "only messages of this kind (image, video, audio, file, location, link) or type (TEXT, STICKER, ...)")
+	cmd.Flags().StringVar(&from, "from", "", "only messages sent by this participant (ID or contact name)")
+	cmd.Flags().BoolVar(&mine, "mine", false, "only messages you sent")
+	cmd.MarkFlagsMutuallyExclusive("from", "mine")
+	cmd.Flags().StringArrayVar(&emoji, "emoji", nil, "only messages containing this emoji (repeatable; 👍 also matches skin-tone variants)")
+	cmd.Flags().BoolVar(&requireFTS, "fts", false, "fail instead of falling back to substring matching when the full-text index is unavailable")
+	cmd.Flags().BoolVar(&skipUndecrypted, "skip-undecrypted", false, "leave out messages Beeper has not decrypted yet instead of showing them as [Not yet decrypted]")
+	cmd.Flags().StringVar(&cursor, "cursor", "", "continue after a previous page (its next cursor); with --json, prints {results, nextCursor}")
+	cmd.MarkFlagsMutuallyExclusive("cursor", "semantic", "fuzzy")
+	cmd.Flags().StringVar(&exportFormat, "export", "", "write the results, with context, to a file in --out instead of printing them: md|csv|jsonl")
+	cmd.Flags().StringVar(&outDir, "out", "", "output directory for --export")
+	cmd.Flags().BoolVar(&count, "count", false, "print only how many messages match, ignoring --limit")
+	for _, flag := range []string{"semantic", "fuzzy", "merge-context", "group-by", "export", "cursor"} {
+		cmd.MarkFlagsMutuallyExclusive("count", flag)
+	}
+	cmd.MarkFlagsRequiredTogether("export", "out")
 
 	return cmd
 }
+
+// exportSearchResults writes search results to a file in dir named after
+// the query.
+func exportSearchResults(query string, results []beeper.SearchResult, format string, dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := export.WriteSearchResults(&buf, format, export.SearchExport{Query: query, Ran: time.Now(), Results: results}); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, export.SearchFileName(query, format))
+	if _, err := export.WriteFileIfChanged(path, buf.Bytes()); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %d results to %s\n", len(results), path)
+	return nil
+}
+
+// searchThreadPage is a page of search results grouped by thread.
+type searchThreadPage struct {
+	Threads    []beeper.SearchThread `json:"threads"`
+	NextCursor string                `json:"nextCursor,omitempty"`
+}
+
+// searchBlockPage is a page of search results merged into context blocks.
+type searchBlockPage struct {
+	Blocks     []beeper.SearchBlock `json:"blocks"`
+	NextCursor string               `json:"nextCursor,omitempty"`
+}
+
+// searchCSVRow is one message of a search result as a CSV row: the columns
+// of messages --output csv, after whether it is the match or context and
+// which match it belongs to.
+type searchCSVRow struct {
+	Role         string `json:"role"`
+	MatchEventID string `json:"matchEventId"`
+	beeper.Message
+}
+
+// searchCSVRows flattens results into a row per match followed by a row per
+// context message, so CSV output has no nested JSON cells.
+func searchCSVRows(results []beeper.SearchResult) []searchCSVRow {
+	rows := []searchCSVRow{}
+	for _, result := range results {
+		rows = append(rows, searchCSVRow{Role: "match", MatchEventID: result.Match.EventID, Message: result.Match})
+		for _, msg := range result.Context {
+			rows = append(rows, searchCSVRow{Role: "context", MatchEventID: result.Match.EventID, Message: msg})
+		}
+	}
+	return rows
+}
+
+// writeSearchResults prints search results as JSON or a table, optionally
+// merged into context blocks or grouped by thread.
+func writeSearchResults(app *App, results []beeper.SearchResult, nextCursor string, paging bool, mergeContext bool, groupBy string, withContext bool) error {
+	if groupBy == "thread" {
+		threads := beeper.GroupSearchResultsByThread(results)
+		if app.JSON {
+			return app.writePage(searchThreadPage{Threads: threads, NextCursor: nextCursor}, threads, nextCursor, paging)
+		}
+		if err := writeSearchThreads(threads, withContext); err != nil {
+			return err
+		}
+		return writeCursorTrailer(nextCursor)
+	}
+	if mergeContext {
+		blocks := beeper.MergeSearchContext(results)
+		if app.JSON {
+			return app.writePage(searchBlockPage{Blocks: blocks, NextCursor: nextCursor}, blocks, nextCursor, paging)
+		}
+		if err := writeSearchBlocks(blocks); err != nil {
+			return err
+		}
+		return writeCursorTrailer(nextCursor)
+	}
+
+	switch app.Output {
+	case outputCSV:
+		return writeStreamPage(outputCSV, searchCSVRows(results), nextCursor)
+	case outputNDJSON:
+		return writeStreamPage(outputNDJSON, results, nextCursor)
+	}
+	if app.JSON {
+		return app.writePage(beeper.SearchPage{Results: results, NextCursor: nextCursor}, results, nextCursor, paging)
+	}
+
+	w := newTextTable(4)
+	if err := writeHeader(w, "TIME\tACCOUNT\tTHREAD\tSENDER\tTEXT\tSCORE"); err != nil {
+		return err
+	}
+	for _, msg := range results {
+		match := msg.Match
+		sender := match.SenderName
+		if sender == "" {
+			sender = match.SenderID
+		}
+		if err := writef(w, "%s\t%s\t%s\t%s\t%s\t%.2f\n", formatTime(match.Timestamp), safe(match.AccountID), safe(match.ThreadName), sender, tableText(outputLocale.Text(match.Text)), match.Score); err != nil {
+			return err
+		}
+		if withContext {
+			for _, ctxMsg := range msg.Context {
+				ctxSender := ctxMsg.SenderName
+				if ctxSender == "" {
+					ctxSender = ctxMsg.SenderID
+				}
+				if err := writef(w, "  %s\t%s\t%s\t%s\t%s\t\n", formatTime(ctxMsg.Timestamp), safe(ctxMsg.AccountID), safe(ctxMsg.ThreadName), ctxSender, tableText(outputLocale.Text(ctxMsg.Text))); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return writeCursorTrailer(nextCursor)
+}
+
+// writeSearchThreads prints search results under a heading per thread with
+// its hit count; the heading names the thread, so rows leave it out.
+func writeSearchThreads(threads []beeper.SearchThread, withContext bool) error {
+	w := newTextTable(3)
+	if err := writeHeader(w, "TIME\tACCOUNT\tSENDER\tTEXT\tSCORE"); err != nil {
+		return err
+	}
+	for _, thread := range threads {
+		hits := fmt.Sprintf("%d hits", thread.Hits)
+		if thread.Hits == 1 {
+			hits = "1 hit"
+		}
+		// Empty cells keep the heading inside the table's columns.
+		if err := writeLine(w, "── "+safe(cmp.Or(thread.ThreadName, thread.ThreadID))+" ("+hits+")\t\t\t\t"); err != nil {
+			return err
+		}
+		for _, result := range thread.Results {
+			match := result.Match
+			if err := writef(w, "%s\t%s\t%s\t%s\t%.2f\n", formatTime(match.Timestamp), safe(match.AccountID), cmp.Or(match.SenderName, match.SenderID), tableText(outputLocale.Text(match.Text)), match.Score); err != nil {
+				return err
+			}
+			if !withContext {
+				continue
+			}
+			for _, ctxMsg := range result.Context {
+				if err := writef(w, "  %s\t%s\t%s\t%s\t\n", formatTime(ctxMsg.Timestamp), safe(ctxMsg.AccountID), cmp.Or(ctxMsg.SenderName, ctxMsg.SenderID), tableText(outputLocale.Text(ctxMsg.Text))); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return w.Flush()
+}
+
+// writeSearchBlocks prints merged context blocks grep-style: hits are
+// flush left with their score, context is indented, and blocks are
+// separated by "--".
+func writeSearchBlocks(blocks []beeper.SearchBlock) error {
+	w := newTextTable(4)
+	if err := writeHeader(w, "TIME\tACCOUNT\tTHREAD\tSENDER\tTEXT\tSCORE"); err != nil {
+		return err
+	}
+	for i, block := range blocks {
+		if i > 0 {
+			if err := writeLine(w, "--"); err != nil {
+				return err
+			}
+		}
+		for _, msg := range block.Messages {
+			sender := msg.SenderName
+			if sender == "" {
+				sender = msg.SenderID
+			}
+			if slices.Contains(block.Matches, msg.ID) {
+				err := writef(w, "%s\t%s\t%s\t%s\t%s\t%.2f\n", formatTime(msg.Timestamp), safe(msg.AccountID), safe(msg.ThreadName), sender, tableText(outputLocale.Text(msg.Text)), msg.Score)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			if err := writef(w, "  %s\t%s\t%s\t%s\t%s\t\n", formatTime(msg.Timestamp), safe(msg.AccountID), safe(msg.ThreadName), sender, tableText(outputLocale.Text(msg.Text))); err != nil {
+				return err
+			}
+		}
+	}
+	return w.Flush()
+}
+
+// entityMessageIDs looks up messages mentioning an extracted entity.
+func (a *App) entityMessageIDs(ctx context.Context, entity string, kind string) ([]int64, error) {
+	if kind != "" && !slices.Contains(extract.Kinds, extract.EntityKind(kind)) {
+		return nil, fmt.Errorf("invalid entity kind %q (expected person, place, organization, or date)", kind)
+	}
+	side, err := a.openSidecar()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = side.Close()
+	}()
+	return side.MessageIDsForEntity(ctx, entity, kind)
+}
+
+// mediaMessageIDs matches query against indexed attachment file names,
+// captions, and alt text. It returns nothing until `extract --kind media`
+// has created the index. --type is applied when the store looks the IDs
+// up, since only the store knows message kinds.
+func (a *App) mediaMessageIDs(ctx context.Context, query string) ([]int64, error) {
+	side, err := a.openExistingSidecar()
+	if err != nil || side == nil {
+		return nil, err
+	}
+	defer func() {
+		_ = side.Close()
+	}()
+	return side.SearchMedia(ctx, query, "")
+}
+
+// searchMediaIDs returns the sidecar media matches of opts.Query within
+// opts.MessageIDs, if set.
+func (a *App) searchMediaIDs(ctx context.Context, opts beeper.SearchOptions) ([]int64, error) {
+	mediaIDs, err := a.mediaMessageIDs(ctx, opts.Query)
+	if err != nil {
+		return nil, err
+	}
+	if opts.MessageIDs != nil {
+		mediaIDs = slices.DeleteFunc(mediaIDs, func(id int64) bool { return !slices.Contains(opts.MessageIDs, id) })
+	}
+	return mediaIDs, nil
+}
+
+// countSearchMatches counts the keyword matches of opts plus the sidecar
+// media matches the listing would merge in. Messages matching both ways
+// are counted once: the media matches that also match the text are
+// subtracted.
+func (a *App) countSearchMatches(ctx context.Context, store *beeper.Store, opts beeper.SearchOptions) (int, error) {
+	n, err := store.CountSearchMatches(ctx, opts)
+	if err != nil || strings.TrimSpace(opts.Query) == "" {
+		return n, err
+	}
+	mediaIDs, err := a.searchMediaIDs(ctx, opts)
+	if err != nil || len(mediaIDs) == 0 {
+		return n, err
+	}
+	mediaOpts := opts
+	mediaOpts.Query = ""
+	mediaOpts.MessageIDs = mediaIDs
+	media, err := store.CountSearchMatches(ctx, mediaOpts)
+	if err != nil {
+		return 0, err
+	}
+	bothOpts := opts
+	bothOpts.MessageIDs = mediaIDs
+	both, err := store.CountSearchMatches(ctx, bothOpts)
+	if err != nil {
+		return 0, err
+	}
+	return n + media - both, nil
+}
+
+// semanticSearch ranks indexed messages by similarity to opts.Query and
+// applies the remaining filters through SearchMessages. Scores are cosine
+// similarities, higher is better.
+func (a *App) semanticSearch(ctx context.Context, store *beeper.Store, opts beeper.SearchOptions, embedCommand string) ([]beeper.SearchResult, error) {
+	side, err := a.openExistingSidecar()
+	if err != nil {
+		return nil, err
+	}
+	if side == nil {
+		return nil, fmt.Errorf("no semantic index: run `extract --kind embeddings` first")
+	}
+	defer func() {
+		_ = side.Close()
+	}()
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	// Over-fetch so account, type, and date filters still leave enough hits.
+	hits, err := embed.Nearest(ctx, side, a.embedder(embedCommand), opts.Query, opts.ThreadID, max(limit*semanticOverfetch, 200))
+	if err != nil {
+		return nil, err
+	}
+	scores := make(map[int64]float64, len(hits))
+	for _, hit := range hits {
+		scores[hit.MessageID] = float64(hit.Score)
+	}
+	return rankedSearch(ctx, store, opts, scores, limit)
+}
+
+// fuzzySearch matches opts.Query against the sidecar's fuzzy word index,
+// tolerating typos, and applies the remaining filters through
+// SearchMessages. Scores are the mean similarity of the query's words to
+// their closest words in the message, from minSimilarity to 1.
+func (a *App) fuzzySearch(ctx context.Context, store *beeper.Store, opts beeper.SearchOptions, minSimilarity float64) ([]beeper.SearchResult, error) {
+	side, err := a.openExistingSidecar()
+	if err != nil {
+		return nil, err
+	}
+	if side == nil {
+		return nil, fmt.Errorf("no fuzzy index: run `extract --kind fuzzy` first")
+	}
+	defer func() {
+		_ = side.Close()
+	}()
+	if ok, err := side.HasFuzzyIndex(ctx); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, fmt.Errorf("no fuzzy index: run `extract --kind fuzzy` first")
+	}
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	hits, err := side.SearchFuzzy(ctx, opts.Query, minSimilarity)
+	if err != nil {
+		return nil, err
+	}
+	scores := make(map[int64]float64, len(hits))
+	for _, hit := range hits {
+		scores[hit.MessageID] = hit.Score
+	}
+	return rankedSearch(ctx, store, opts, scores, limit)
+}
+
+// rankedSearch loads the messages in scores that pass the filters in opts,
+// best score first, and keeps the top limit.
+func rankedSearch(ctx context.Context, store *beeper.Store, opts beeper.SearchOptions, scores map[int64]float64, limit int) ([]beeper.SearchResult, error) {
+	ids := make([]int64, 0, len(scores))
+	for id := range scores {
+		if opts.MessageIDs != nil && !slices.Contains(opts.MessageIDs, id) {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return []beeper.SearchResult{}, nil
+	}
+
+	opts.Query = ""
+	opts.MessageIDs = ids
+	opts.Limit = len(ids)
+	results, err := store.SearchMessages(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	for i := range results {
+		results[i].Match.Score = scores[results[i].Match.ID]
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Match.Score > results[j].Match.Score
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
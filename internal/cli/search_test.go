@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+)
+
+func TestSearchCSV(t *testing.T) {
+	at := func(minute int) time.Time { return time.Date(2025, 3, 1, 10, minute, 0, 0, time.UTC) }
+	results := []beeper.SearchResult{
+		{
+			Match: beeper.Message{ID: 2, EventID: "$m", ThreadID: "!team:beeper.local", ThreadName: "Team Chat", AccountID: "whatsapp",
+				SenderID: "@alice:beeper.local", SenderName: "Alice", Timestamp: at(1), Type: "TEXT", Text: "lunch, anyone?", Score: 1.5},
+			Context: []beeper.Message{
+				{ID: 1, EventID: "$c", ThreadID: "!team:beeper.local", ThreadName: "Team Chat", AccountID: "whatsapp",
+					SenderID: "@me:beeper.local", Timestamp: at(0), IsSentByMe: true, Type: "TEXT", Text: "hungry"},
+			},
+		},
+	}
+
+	var out strings.Builder
+	stream := newTypedOutputStream[searchCSVRow](&out, outputCSV)
+	for _, row := range searchCSVRows(results) {
+		if err := stream.write(row); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	want := `role,matchEventId,id,eventId,threadId,threadName,accountId,senderId,senderName,timestamp,isSentByMe,type,text,preview,score,provenance,sessionId,threadRootEventId,replyToEventId,reactionCount,replyCount,reactions,isDeleted,edited,previousVersions,undecrypted,disappearing,expiresAt,expired
+match,$m,2,$m,!team:beeper.local,Team Chat,whatsapp,@alice:beeper.local,Alice,2025-03-01T10:01:00Z,false,TEXT,"lunch, anyone?",,1.5,,,,,,,,,,,,,,
+context,$m,1,$c,!team:beeper.local,Team Chat,whatsapp,@me:beeper.local,,2025-03-01T10:00:00Z,true,TEXT,hungry,,,,,,,,,,,,,,,,
+`
+	if out.String() != want {
+		t.Fatalf("unexpected CSV:\n%s", out.String())
+	}
+
+	// The message columns are the ones messages --output csv writes.
+	var messages strings.Builder
+	if err := newTypedOutputStream[beeper.Message](&messages, outputCSV).write(results[0].Match); err != nil {
+		t.Fatalf("write messages: %v", err)
+	}
+	header, _, _ := strings.Cut(messages.String(), "\n")
+	if !strings.HasPrefix(want, "role,matchEventId,"+header+"\n") {
+		t.Fatalf("search columns differ from messages columns %q", header)
+	}
+}
@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/api"
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+	"github.com/spf13/cobra"
+)
+
+func newServeCmd(app *App) *cobra.Command {
+	var listen string
+	var token string
+	var allowOrigin string
+	var nice bool
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve threads, messages, and search as a read-only JSON HTTP API",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if token == "" {
+				token = os.Getenv("BEEPER_CLI_TOKEN")
+			}
+			host, _, err := net.SplitHostPort(listen)
+			if err != nil {
+				return fmt.Errorf("invalid --listen %q: %w", listen, err)
+			}
+			if token == "" && !api.IsLoopback(host) {
+				return usageError{fmt.Errorf("--token is required to listen on %s, which is not a loopback address", listen)}
+			}
+			// A browser sends a page's requests to 127.0.0.1 with a loopback
+			// Host, so without a token CORS would let any allowed origin
+			// read everything.
+			switch {
+			case allowOrigin == "*":
+				return usageError{fmt.Errorf("--allow-origin * is not allowed; name the dashboard's origin, e.g. http://localhost:5173")}
+			case allowOrigin != "" && token == "":
+				return usageError{fmt.Errorf("--allow-origin requires --token (or BEEPER_CLI_TOKEN)")}
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			handler := api.NewHandler(store, api.Options{Token: token, AllowOrigin: allowOrigin})
+			if nice {
+				handler = niceHandler(store.NewThrottle(), handler)
+			}
+			server := &http.Server{
+				Addr:              listen,
+				Handler:           handler,
+				ReadHeaderTimeout: 10 * time.Second,
+			}
+			errCh := make(chan error, 1)
+			go func() {
+				errCh <- server.ListenAndServe()
+			}()
+			fmt.Fprintf(os.Stderr, "Serving on http://%s (Ctrl-C to stop)\n", listen)
+
+			select {
+			case err := <-errCh:
+				return err
+			case <-ctx.Done():
+			}
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := server.Shutdown(shutdownCtx); err != nil {
+				return err
+			}
+			if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&listen, "listen", "127.0.0.1:8787", "address to listen on")
+	cmd.Flags().StringVar(&token, "token", "", "require this bearer token (or set BEEPER_CLI_TOKEN)")
+	cmd.Flags().BoolVar(&nice, "nice", false, "answer one request at a time and hold requests back while Beeper is writing")
+	cmd.Flags().StringVar(&allowOrigin, "allow-origin", "", "Access-Control-Allow-Origin value for browser dashboards (e.g. http://localhost:5173; requires --token)")
+
+	return cmd
+}
+
+// niceHandler runs requests one at a time, each after Beeper has stopped
+// writing or waited long enough.
+func niceHandler(throttle *beeper.Throttle, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		release, err := throttle.Acquire(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		defer release()
+		next.ServeHTTP(w, r)
+	})
+}
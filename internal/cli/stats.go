@@ -0,0 +1,452 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+	"github.com/spf13/cobra"
+)
+
+type statsOverview struct {
+	beeper.Totals
+	SentRatio  float64               `json:"sentRatio"`
+	Accounts   []beeper.AccountCount `json:"accounts"`
+	Days       []beeper.DayCount     `json:"days,omitempty"`
+	Weeks      []beeper.WeekCount    `json:"weeks,omitempty"`
+	TopThreads []beeper.ThreadCount  `json:"topThreads"`
+	TopSenders []beeper.SenderCount  `json:"topSenders"`
+}
+
+// statsFilter holds the flags shared by the aggregate stats subcommands.
+type statsFilter struct {
+	threadRef string
+	accountID string
+	from      string
+	to        string
+}
+
+func (f *statsFilter) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.threadRef, "thread", "", "only this thread (ID or name)")
+	cmd.Flags().StringVar(&f.accountID, "account", "", "filter by account/platform ID")
+	cmd.Flags().StringVar(&f.from, "from", "", "start date (YYYY-MM-DD, RFC3339, yesterday, last monday, 2w, ...)")
+	cmd.Flags().StringVar(&f.to, "to", "", "end date, inclusive (same forms as --from)")
+}
+
+func (f *statsFilter) options(ctx context.Context, store *beeper.Store) (beeper.StatsOptions, error) {
+	after, err := parseDateFlag(f.from, false)
+	if err != nil {
+		return beeper.StatsOptions{}, err
+	}
+	before, err := parseDateFlag(f.to, true)
+	if err != nil {
+		return beeper.StatsOptions{}, err
+	}
+	opts := beeper.StatsOptions{After: after, Before: before, AccountID: f.accountID}
+	if f.threadRef != "" {
+		opts.ThreadID, err = resolveThreadRef(ctx, store, f.threadRef)
+		if err != nil {
+			return beeper.StatsOptions{}, err
+		}
+	}
+	return opts, nil
+}
+
+type sessionsReport struct {
+	Gap      string              `json:"gap"`
+	Stats    beeper.SessionStats `json:"stats"`
+	Sessions []beeper.Session    `json:"sessions"`
+}
+
+func newStatsCmd(app *App) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Aggregate statistics over messages",
+	}
+
+	cmd.AddCommand(newStatsOverviewCmd(app))
+	cmd.AddCommand(newStatsThreadsCmd(app))
+	cmd.AddCommand(newStatsSendersCmd(app))
+	cmd.AddCommand(newStatsSessionsCmd(app))
+	cmd.AddCommand(newStatsCallsCmd(app))
+	return cmd
+}
+
+func newStatsSessionsCmd(app *App) *cobra.Command {
+	var gapValue string
+	var threadRef string
+	var accountID string
+	var from string
+	var to string
+	var limit int
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "sessions",
+		Short: "Group messages into conversation sessions separated by silence",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			gap, err := parseDuration(gapValue)
+			if err != nil {
+				return err
+			}
+			if gap <= 0 {
+				return fmt.Errorf("--gap must be positive")
+			}
+			after, err := parseDateFlag(from, false)
+			if err != nil {
+				return err
+			}
+			before, err := parseDateFlag(to, true)
+			if err != nil {
+				return err
+			}
+			formatValue, err := parseMessageFormat(format)
+			if err != nil {
+				return err
+			}
+
+			ctx := context.Background()
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			threadID := ""
+			if threadRef != "" {
+				threadID, err = resolveThreadRef(ctx, store, threadRef)
+				if err != nil {
+					return err
+				}
+			}
+
+			stats, sessions, err := store.ListSessions(ctx, beeper.SessionOptions{
+				ThreadID:  threadID,
+				AccountID: accountID,
+				After:     after,
+				Before:    before,
+				Gap:       gap,
+				Limit:     limit,
+				Format:    formatValue,
+			})
+			if err != nil {
+				return err
+			}
+
+			if app.JSON {
+				return app.write(sessionsReport{Gap: gap.String(), Stats: stats, Sessions: sessions})
+			}
+
+			w := newTabWriter()
+			if err := writeHeader(w, "SESSION\tSTART\tDURATION\tMSGS\tTHREAD\tWITH\tSUMMARY"); err != nil {
+				return err
+			}
+			for _, s := range sessions {
+				if err := writef(w, "%s\t%s\t%s\t%d\t%s\t%s\t%s\n",
+					s.ID,
+					formatTime(s.Start),
+					s.Duration().Round(time.Minute),
+					s.Messages,
+					safe(s.ThreadName),
+					strings.Join(s.Participants, ", "),
+					s.Summary,
+				); err != nil {
+					return err
+				}
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+
+			fmt.Printf("\n%d sessions, %d messages (gap %s)\n", stats.Sessions, stats.Messages, gap)
+			fmt.Printf("Avg messages/session: %.1f\n", stats.AvgMessages)
+			fmt.Printf("Median duration: %.0fm, longest: %.0fm\n", stats.MedianMinutes, stats.LongestMinutes)
+			fmt.Printf("Started by you: %d\n", stats.StartedByMe)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&gapValue, "gap", beeper.DefaultSessionGap.String(), "silence that starts a new session")
+	cmd.Flags().StringVar(&threadRef, "thread", "", "only this thread (ID or name)")
+	cmd.Flags().StringVar(&accountID, "account", "", "filter by account/platform ID")
+	cmd.Flags().StringVar(&from, "from", "", "start date (YYYY-MM-DD, RFC3339, yesterday, last monday, 2w, ...)")
+	cmd.Flags().StringVar(&to, "to", "", "end date, inclusive (same forms as --from)")
+	cmd.Flags().IntVar(&limit, "limit", 20, "number of most recent sessions to list (0 for all)")
+	cmd.Flags().StringVar(&format, "format", string(beeper.FormatRich), "message format for summaries: plain|rich")
+
+	return cmd
+}
+
+func newStatsOverviewCmd(app *App) *cobra.Command {
+	var filter statsFilter
+	var by string
+	var top int
+
+	cmd := &cobra.Command{
+		Use:   "overview",
+		Short: "Message totals, per-platform volume, activity over time, and top threads and contacts",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if by != "day" && by != "week" {
+				return fmt.Errorf("invalid --by %q: use day or week", by)
+			}
+
+			ctx := context.Background()
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			opts, err := filter.options(ctx, store)
+			if err != nil {
+				return err
+			}
+			report := statsOverview{}
+			if report.Totals, err = store.MessageTotals(ctx, opts); err != nil {
+				return err
+			}
+			if report.Messages > 0 {
+				report.SentRatio = float64(report.Sent) / float64(report.Messages)
+			}
+			if report.Accounts, err = store.MessagesPerAccount(ctx, opts); err != nil {
+				return err
+			}
+			days, err := store.MessagesPerDay(ctx, opts)
+			if err != nil {
+				return err
+			}
+			if by == "day" {
+				report.Days = days
+			} else {
+				report.Weeks = beeper.WeeklyCounts(days)
+			}
+			if report.TopThreads, err = store.TopThreads(ctx, opts, top); err != nil {
+				return err
+			}
+			if report.TopSenders, err = store.TopSenders(ctx, opts, top); err != nil {
+				return err
+			}
+
+			if app.JSON {
+				return app.write(report)
+			}
+			return writeStatsOverview(report)
+		},
+	}
+
+	filter.register(cmd)
+	cmd.Flags().StringVar(&by, "by", "week", "activity bucket: day|week")
+	cmd.Flags().IntVar(&top, "top", 10, "number of top threads and contacts")
+
+	return cmd
+}
+
+func writeStatsOverview(report statsOverview) error {
+	fmt.Printf("Messages: %d (sent %d, received %d, %.0f%% sent)\n",
+		report.Messages, report.Sent, report.Received, report.SentRatio*100)
+	fmt.Printf("Threads: %d\n", report.Threads)
+	fmt.Printf("First: %s\nLast: %s\n", formatTime(report.First), formatTime(report.Last))
+
+	fmt.Println("\nPlatforms")
+	w := newTabWriter()
+	if err := writeHeader(w, "ACCOUNT\tTHREADS\tMSGS\tSENT\tRECEIVED"); err != nil {
+		return err
+	}
+	for _, a := range report.Accounts {
+		if err := writef(w, "%s\t%d\t%d\t%d\t%d\n", safe(a.AccountID), a.Threads, a.Messages, a.Sent, a.Received); err != nil {
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	w = newTabWriter()
+	if report.Days != nil {
+		fmt.Println("\nPer day")
+		for _, d := range report.Days {
+			if err := writef(w, "%s\t%d\n", d.Day, d.Messages); err != nil {
+				return err
+			}
+		}
+	} else {
+		fmt.Println("\nPer week")
+		for _, wk := range report.Weeks {
+			if err := writef(w, "%s\t%d\n", wk.Week, wk.Messages); err != nil {
+				return err
+			}
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Println("\nTop threads")
+	if err := writeThreadCounts(report.TopThreads); err != nil {
+		return err
+	}
+	fmt.Println("\nTop contacts")
+	return writeSenderCounts(report.TopSenders)
+}
+
+func newStatsThreadsCmd(app *App) *cobra.Command {
+	var filter statsFilter
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "threads",
+		Short: "Most active threads with sent and received counts",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			ctx := context.Background()
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			opts, err := filter.options(ctx, store)
+			if err != nil {
+				return err
+			}
+			counts, err := store.TopThreads(ctx, opts, limit)
+			if err != nil {
+				return err
+			}
+			if app.JSON {
+				return app.write(counts)
+			}
+			return writeThreadCounts(counts)
+		},
+	}
+
+	filter.register(cmd)
+	cmd.Flags().IntVar(&limit, "limit", 10, "number of threads")
+
+	return cmd
+}
+
+func newStatsSendersCmd(app *App) *cobra.Command {
+	var filter statsFilter
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "senders",
+		Short: "Contacts who sent the most messages",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			ctx := context.Background()
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			opts, err := filter.options(ctx, store)
+			if err != nil {
+				return err
+			}
+			counts, err := store.TopSenders(ctx, opts, limit)
+			if err != nil {
+				return err
+			}
+			if app.JSON {
+				return app.write(counts)
+			}
+			return writeSenderCounts(counts)
+		},
+	}
+
+	filter.register(cmd)
+	cmd.Flags().IntVar(&limit, "limit", 10, "number of contacts")
+
+	return cmd
+}
+
+func newStatsCallsCmd(app *App) *cobra.Command {
+	var filter statsFilter
+	var limit int
+
+	cmd := &cobra.Command{
+		Use:   "calls",
+		Short: "Voice and video calls per contact with count and total duration",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			ctx := context.Background()
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			opts, err := filter.options(ctx, store)
+			if err != nil {
+				return err
+			}
+			calls, err := store.ListCalls(ctx, beeper.CallOptions{ThreadID: opts.ThreadID, AccountID: opts.AccountID, After: opts.After, Before: opts.Before})
+			if err != nil {
+				return err
+			}
+			summaries := beeper.SummarizeCalls(calls)
+			if limit > 0 && len(summaries) > limit {
+				summaries = summaries[:limit]
+			}
+			if app.JSON {
+				return app.write(summaries)
+			}
+
+			w := newTabWriter()
+			if err := writeHeader(w, "THREAD\tACCOUNT\tCALLS\tMISSED\tVIDEO\tDURATION\tLAST\tID"); err != nil {
+				return err
+			}
+			for _, c := range summaries {
+				if err := writef(w, "%s\t%s\t%d\t%d\t%d\t%s\t%s\t%s\n",
+					safe(c.ThreadName), safe(c.AccountID), c.Calls, c.Missed, c.Video, c.Duration, formatTime(c.Last), c.ThreadID); err != nil {
+					return err
+				}
+			}
+			return w.Flush()
+		},
+	}
+
+	filter.register(cmd)
+	cmd.Flags().IntVar(&limit, "limit", 10, "number of contacts (0 = all)")
+
+	return cmd
+}
+
+func writeThreadCounts(counts []beeper.ThreadCount) error {
+	w := newTabWriter()
+	if err := writeHeader(w, "THREAD\tACCOUNT\tMSGS\tSENT\tRECEIVED\tID"); err != nil {
+		return err
+	}
+	for _, c := range counts {
+		if err := writef(w, "%s\t%s\t%d\t%d\t%d\t%s\n",
+			safe(c.Name), safe(c.AccountID), c.Messages, c.Sent, c.Received, c.ThreadID); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+func writeSenderCounts(counts []beeper.SenderCount) error {
+	w := newTabWriter()
+	if err := writeHeader(w, "CONTACT\tMSGS\tTHREADS\tID"); err != nil {
+		return err
+	}
+	for _, c := range counts {
+		if err := writef(w, "%s\t%d\t%d\t%s\n", safe(c.Name), c.Messages, c.Threads, c.SenderID); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,238 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+)
+
+// Color modes for --color and the config file's color setting.
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+// minTextWidth is the narrowest a truncated text column gets, however
+// wide the other columns are.
+const minTextWidth = 20
+
+const (
+	ansiBold  = "\x1b[1m"
+	ansiDim   = "\x1b[2m"
+	ansiReset = "\x1b[0m"
+)
+
+// tableStyle controls how tables are rendered in the terminal.
+type tableStyle struct {
+	// Color enables ANSI styling of headers and times.
+	Color bool
+	// Width is the terminal width text columns are shortened to fit; 0
+	// when stdout is not a terminal or --full is set.
+	Width int
+}
+
+// outputStyle is the table style of the current command, set from --color,
+// --full, and the terminal before it runs.
+var outputStyle tableStyle
+
+// tableWriter is what table output is written through: a tabwriter, or a
+// textTable when text needs shortening or color.
+type tableWriter interface {
+	io.Writer
+	Flush() error
+}
+
+// resolveStyle sets outputStyle from --color (or the config file's color
+// setting) and --full. Color and truncation only apply when stdout is a
+// terminal, so piped output stays plain and complete; NO_COLOR and
+// TERM=dumb turn auto color off.
+func (a *App) resolveStyle(cmd *cobra.Command) error {
+	mode := a.Color
+	if !cmd.Flags().Changed("color") && a.Config.Color != "" {
+		mode = a.Config.Color
+	}
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	if mode == "" {
+		mode = colorAuto
+	}
+	terminal := isTerminal(os.Stdout)
+
+	style := tableStyle{}
+	switch mode {
+	case colorAlways:
+		style.Color = true
+	case colorNever:
+	case colorAuto:
+		style.Color = terminal && os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb"
+	default:
+		return fmt.Errorf("invalid color %q (expected auto, always, or never)", mode)
+	}
+	if terminal && !a.Full {
+		style.Width = terminalWidth(os.Stdout)
+	}
+	outputStyle = style
+	return nil
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// terminalWidth returns $COLUMNS when set, otherwise the width the
+// terminal reports, or 0 when neither is known.
+func terminalWidth(f *os.File) int {
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+	return ttyWidth(f)
+}
+
+func newTabWriter() tableWriter {
+	return newTextTable(-1)
+}
+
+// newTextTable returns a table writer whose column textColumn (0-based,
+// -1 for none) holds message text: with a known terminal width, line
+// breaks in it are flattened and it is shortened with "…" so rows fit.
+// Without color or a width this is a plain tabwriter.
+func newTextTable(textColumn int) tableWriter {
+	if !outputStyle.Color && (outputStyle.Width <= 0 || textColumn < 0) {
+		return tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	}
+	return &textTable{out: os.Stdout, style: outputStyle, textColumn: textColumn}
+}
+
+// tableText prepares message text for a table cell. When text columns are
+// shortened to the terminal width, line breaks and tabs become spaces so a
+// message stays on one row.
+func tableText(text string) string {
+	if outputStyle.Width <= 0 {
+		return text
+	}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\t", " ")
+	return strings.ReplaceAll(text, "\n", " ⏎ ")
+}
+
+// textTable buffers tab-separated rows and renders them on Flush like a
+// tabwriter with two spaces of padding, measuring cells by their visible
+// width so color codes do not shift columns.
+type textTable struct {
+	out        io.Writer
+	style      tableStyle
+	textColumn int
+	buf        bytes.Buffer
+}
+
+func (t *textTable) Write(p []byte) (int, error) {
+	return t.buf.Write(p)
+}
+
+func (t *textTable) Flush() error {
+	data := strings.TrimSuffix(t.buf.String(), "\n")
+	t.buf.Reset()
+	if data == "" {
+		return nil
+	}
+	rows := [][]string{}
+	for _, line := range strings.Split(data, "\n") {
+		rows = append(rows, strings.Split(line, "\t"))
+	}
+
+	if full := columnWidths(rows, true); t.style.Width > 0 && t.textColumn >= 0 && t.textColumn < len(full) {
+		total := 2 * (len(full) - 1)
+		for _, w := range full {
+			total += w
+		}
+		budget := max(t.style.Width-(total-full[t.textColumn]), minTextWidth)
+		for i, row := range rows {
+			// The header keeps its label.
+			if i > 0 && len(row) > 1 && t.textColumn < len(row) {
+				row[t.textColumn] = truncateText(row[t.textColumn], budget)
+			}
+		}
+	}
+	widths := columnWidths(rows, false)
+
+	var out strings.Builder
+	for i, row := range rows {
+		var line strings.Builder
+		for j, cell := range row {
+			if j < len(row)-1 {
+				cell += strings.Repeat(" ", widths[j]-utf8.RuneCountInString(cell)+2)
+			}
+			line.WriteString(cell)
+		}
+		text := strings.TrimRight(line.String(), " ")
+		if t.style.Color {
+			text = t.colorize(i, row, text)
+		}
+		out.WriteString(text)
+		out.WriteByte('\n')
+	}
+	_, err := io.WriteString(t.out, out.String())
+	return err
+}
+
+// colorize styles a rendered row: the header is bold, separator rows and
+// the leading time column are dimmed.
+func (t *textTable) colorize(index int, row []string, line string) string {
+	switch {
+	case index == 0:
+		return ansiBold + line + ansiReset
+	case len(row) == 1:
+		return ansiDim + line + ansiReset
+	case t.textColumn < 0:
+		return line
+	}
+	// Text tables lead with the time; keep any indentation unstyled.
+	first := row[0]
+	trimmed := strings.TrimLeft(first, " ")
+	indent := len(first) - len(trimmed)
+	if trimmed == "" {
+		return line
+	}
+	return line[:indent] + ansiDim + trimmed + ansiReset + line[indent+len(trimmed):]
+}
+
+// columnWidths returns the widest cell per column. Like a tabwriter, it
+// only counts tab-terminated cells unless withLast is set, which also
+// counts the last cell of rows with more than one; rows without a tab
+// never count.
+func columnWidths(rows [][]string, withLast bool) []int {
+	widths := []int{}
+	for _, row := range rows {
+		if len(row) < 2 {
+			continue
+		}
+		for j, cell := range row {
+			if j == len(row)-1 && !withLast {
+				break
+			}
+			if j >= len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[j] = max(widths[j], utf8.RuneCountInString(cell))
+		}
+	}
+	return widths
+}
+
+// truncateText shortens text to at most width runes, ending in "…" when
+// cut.
+func truncateText(text string, width int) string {
+	if utf8.RuneCountInString(text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	return strings.TrimRight(string(runes[:width-1]), " ") + "…"
+}
@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTextTableTruncatesText(t *testing.T) {
+	var out strings.Builder
+	table := &textTable{out: &out, style: tableStyle{Width: 40}, textColumn: 1}
+	long := strings.Repeat("word ", 20)
+	for _, line := range []string{"TIME\tTEXT\tSCORE", "10:00\t" + long + "\t1.00", "--", "  10:01\tshort\t"} {
+		if err := writeLine(table, line); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	if err := table.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	want := []string{
+		"TIME     TEXT                      SCORE",
+		"10:00    word word word word wor…  1.00",
+		"--",
+		"  10:01  short",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected table:\n%s", out.String())
+	}
+	for _, line := range lines {
+		if utf8.RuneCountInString(line) > 40 {
+			t.Fatalf("line wider than the terminal: %q", line)
+		}
+	}
+}
+
+func TestTextTableColor(t *testing.T) {
+	var out strings.Builder
+	table := &textTable{out: &out, style: tableStyle{Color: true}, textColumn: 1}
+	_ = writeLine(table, "TIME\tTEXT")
+	_ = writeLine(table, "  10:00\thi")
+	if err := table.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	want := ansiBold + "TIME     TEXT" + ansiReset + "\n  " + ansiDim + "10:00" + ansiReset + "  hi\n"
+	if out.String() != want {
+		t.Fatalf("unexpected colored table: %q", out.String())
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package cli
+
+import "os"
+
+// ttyWidth is unknown on this platform; set $COLUMNS instead.
+func ttyWidth(*os.File) int {
+	return 0
+}
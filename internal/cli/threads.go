@@ -1,9 +1,15 @@
 package cli
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"os/exec"
+	"runtime"
 	"strings"
+	"time"
 
 	"github.com/KrauseFx/beeper-cli/internal/beeper"
 	"github.com/spf13/cobra"
@@ -16,56 +22,162 @@ func newThreadsCmd(app *App) *cobra.Command {
 	}
 
 	cmd.AddCommand(newThreadsListCmd(app))
+	cmd.AddCommand(newThreadsCountCmd(app))
 	cmd.AddCommand(newThreadsShowCmd(app))
+	cmd.AddCommand(newThreadsMentionsCmd(app))
+	cmd.AddCommand(newThreadsOpenCmd(app))
 
 	return cmd
 }
 
+// threadFilter holds the flags selecting threads, shared by threads list
+// and threads count.
+type threadFilter struct {
+	days               int
+	accountID          string
+	label              string
+	includeLowPriority bool
+	all                bool
+	threadType         string
+	participant        string
+	noCache            bool
+}
+
+func (f *threadFilter) register(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&f.days, "days", 0, "only include threads active in the last N days")
+	cmd.Flags().StringVar(&f.accountID, "account", "", "filter by account/platform ID")
+	cmd.Flags().StringVar(&f.label, "label", string(beeper.LabelAll), "filter by label: inbox|archive|favourite|unread|all (default from config, else all)")
+	cmd.Flags().BoolVar(&f.includeLowPriority, "include-low-priority", false, "include low-priority threads")
+	cmd.Flags().BoolVar(&f.all, "all", false, "list all threads, ignoring configured label/days defaults")
+	cmd.Flags().StringVar(&f.threadType, "type", "", "only dm or group threads")
+	cmd.Flags().StringVar(&f.participant, "participant", "", "only threads with this participant (user ID, or part of a name)")
+	cmd.Flags().BoolVar(&f.noCache, "no-cache", false, "aggregate every message even if index build has cached the thread stats")
+	cmd.MarkFlagsMutuallyExclusive("all", "label")
+	cmd.MarkFlagsMutuallyExclusive("all", "days")
+}
+
+// options applies the configured label and days defaults unless --all or
+// the flags themselves override them, and loads the thread listing cache
+// for store, opened from dbPath, if it has been built.
+func (f *threadFilter) options(ctx context.Context, cmd *cobra.Command, app *App, store *beeper.Store, dbPath string) (beeper.ThreadListOptions, error) {
+	label, days := f.label, f.days
+	if f.all {
+		label = string(beeper.LabelAll)
+		days = 0
+	} else {
+		if !cmd.Flags().Changed("label") && app.Config.Threads.Label != "" {
+			label = app.Config.Threads.Label
+		}
+		if !cmd.Flags().Changed("days") && app.Config.Threads.Days > 0 {
+			days = app.Config.Threads.Days
+		}
+	}
+	labelValue, err := parseThreadLabel(label)
+	if err != nil {
+		return beeper.ThreadListOptions{}, err
+	}
+	typeValue, err := parseThreadType(f.threadType)
+	if err != nil {
+		return beeper.ThreadListOptions{}, err
+	}
+	opts := beeper.ThreadListOptions{
+		Days:               days,
+		AccountID:          f.accountID,
+		Label:              labelValue,
+		IncludeLowPriority: f.includeLowPriority,
+		Type:               typeValue,
+		Participant:        f.participant,
+	}
+	if !f.noCache {
+		if opts.Cache, err = app.threadCache(ctx, store, dbPath); err != nil {
+			return beeper.ThreadListOptions{}, err
+		}
+	}
+	return opts, nil
+}
+
 func newThreadsListCmd(app *App) *cobra.Command {
-	var days int
+	var filter threadFilter
 	var limit int
-	var accountID string
-	var label string
-	var includeLowPriority bool
 	var withParticipants bool
 	var withStats bool
+	var sortBy string
+	var count bool
 
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List threads ordered by last activity",
-		RunE: func(_ *cobra.Command, _ []string) error {
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if !cmd.Flags().Changed("sort") && app.Config.Threads.Sort != "" {
+				sortBy = app.Config.Threads.Sort
+			}
+			sortValue, err := parseThreadSort(sortBy)
+			if err != nil {
+				return err
+			}
+
 			ctx := context.Background()
-			store, _, err := app.openStore()
+			store, dbPath, err := app.openStore()
 			if err != nil {
 				return err
 			}
 			defer func() {
 				_ = store.Close()
 			}()
+			opts, err := filter.options(ctx, cmd, app, store, dbPath)
+			if err != nil {
+				return err
+			}
 
-			threads, err := store.ListThreads(ctx, beeper.ThreadListOptions{
-				Days:               days,
-				Limit:              limit,
-				AccountID:          accountID,
-				Label:              beeper.ThreadLabel(label),
-				IncludeLowPriority: includeLowPriority,
-				WithParticipants:   withParticipants,
-				WithStats:          withStats,
-			})
+			if count {
+				n, err := store.CountThreads(ctx, opts)
+				if err != nil {
+					return err
+				}
+				return writeCount(app, n)
+			}
+			opts.Limit = limit
+			opts.WithParticipants = withParticipants
+			opts.WithStats = withStats
+			opts.Sort = sortValue
+			threads, err := store.ListThreads(ctx, opts)
 			if err != nil {
 				return err
 			}
 
 			if app.JSON {
-				return writeJSON(threads)
+				return app.write(threads)
+			}
+
+			// Sorting by a number adds it as a column.
+			column := ""
+			value := func(beeper.Thread) string { return "" }
+			switch sortValue {
+			case beeper.SortImportance:
+				column = "IMPORTANCE"
+				value = func(t beeper.Thread) string { return fmt.Sprintf("%.1f", t.Importance) }
+			case beeper.SortUnread:
+				column = "UNREAD"
+				value = func(t beeper.Thread) string { return fmt.Sprint(t.UnreadCount) }
+			case beeper.SortMessages:
+				column = "MESSAGES"
+				value = func(t beeper.Thread) string { return fmt.Sprint(t.TotalMessages) }
 			}
 
 			w := newTabWriter()
-			if err := writeLine(w, "TIME\tACCOUNT\tTHREAD\tTHREAD_ID"); err != nil {
+			header := "TIME\tACCOUNT\tTHREAD\tTHREAD_ID"
+			if column != "" {
+				header = "TIME\tACCOUNT\tTHREAD\t" + column + "\tTHREAD_ID"
+			}
+			if err := writeHeader(w, header); err != nil {
 				return err
 			}
 			for _, thread := range threads {
-				if err := writef(w, "%s\t%s\t%s\t%s\n", formatTime(thread.LastActivity), safe(thread.AccountID), safe(thread.DisplayName), thread.ID); err != nil {
+				row := fmt.Sprintf("%s\t%s\t%s\t", formatTime(thread.LastActivity), safe(thread.AccountID), safe(thread.DisplayName))
+				if column != "" {
+					row += value(thread) + "\t"
+				}
+				if err := writeLine(w, row+thread.ID); err != nil {
 					return err
 				}
 			}
@@ -73,13 +185,12 @@ func newThreadsListCmd(app *App) *cobra.Command {
 		},
 	}
 
-	cmd.Flags().IntVar(&days, "days", 0, "only include threads active in the last N days")
+	filter.register(cmd)
 	cmd.Flags().IntVar(&limit, "limit", 50, "max number of threads to return")
-	cmd.Flags().StringVar(&accountID, "account", "", "filter by account/platform ID")
-	cmd.Flags().StringVar(&label, "label", string(beeper.LabelAll), "filter by label: inbox|archive|favourite|unread|all")
-	cmd.Flags().BoolVar(&includeLowPriority, "include-low-priority", false, "include low-priority threads")
 	cmd.Flags().BoolVar(&withParticipants, "with-participants", false, "include participants in JSON output")
 	cmd.Flags().BoolVar(&withStats, "with-stats", false, "include message stats in JSON output")
+	cmd.Flags().StringVar(&sortBy, "sort", string(beeper.SortActivity), "order: activity|unread|name|messages|importance (importance ranks by contacts, see contacts rank; default from config)")
+	cmd.Flags().BoolVar(&count, "count", false, "print only how many threads match, ignoring --limit (same as threads count)")
 
 	return cmd
 }
@@ -89,17 +200,42 @@ func newThreadsShowCmd(app *App) *cobra.Command {
 	var withStats bool
 	var withLast int
 	var format string
+	var since string
+	var before string
+	var unreadOnly bool
+	var wpm int
+	var membership bool
 
 	cmd := &cobra.Command{
-		Use:   "show",
-		Short: "Show details for a single thread",
-		RunE: func(_ *cobra.Command, args []string) error {
+		Use:               "show [thread]",
+		Short:             "Show details for a single thread",
+		ValidArgsFunction: app.completeThreadArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
 			if threadID == "" && len(args) > 0 {
 				threadID = args[0]
 			}
 			if threadID == "" {
 				return fmt.Errorf("thread ID is required")
 			}
+			if !withStats {
+				for _, name := range []string{"since", "before", "unread", "wpm"} {
+					if cmd.Flags().Changed(name) {
+						return fmt.Errorf("--%s requires --with-stats", name)
+					}
+				}
+			}
+			var after *time.Time
+			if since != "" {
+				sinceTime, err := parseSinceFlag(since, time.Now())
+				if err != nil {
+					return err
+				}
+				after = &sinceTime
+			}
+			beforeTime, err := parseDateFlag(before, true)
+			if err != nil {
+				return err
+			}
 
 			ctx := context.Background()
 			store, _, err := app.openStore()
@@ -115,10 +251,36 @@ func newThreadsShowCmd(app *App) *cobra.Command {
 				return err
 			}
 
+			threadID, err = resolveThreadRef(ctx, store, threadID)
+			if err != nil {
+				return err
+			}
 			thread, err := store.GetThread(ctx, threadID, withStats)
 			if err != nil {
 				return err
 			}
+			if thread.ReadMarker, err = store.ReadMarker(ctx, threadID); err != nil && !errors.Is(err, beeper.ErrNoReadMarker) {
+				return err
+			}
+			if withStats {
+				if unreadOnly && !thread.LastOpen.IsZero() {
+					after = &thread.LastOpen
+				}
+				reading, err := store.ReadingStats(ctx, beeper.StatsOptions{
+					ThreadID: threadID,
+					After:    after,
+					Before:   beforeTime,
+				}, wpm)
+				if err != nil {
+					return err
+				}
+				thread.Reading = &reading
+			}
+			if membership {
+				if thread.Membership, err = store.ListMembership(ctx, threadID); err != nil {
+					return err
+				}
+			}
 
 			if app.JSON {
 				if withLast > 0 {
@@ -130,16 +292,16 @@ func newThreadsShowCmd(app *App) *cobra.Command {
 					if err != nil {
 						return err
 					}
-					return writeJSON(map[string]any{
+					return app.write(map[string]any{
 						"thread":   thread,
 						"messages": messages,
 					})
 				}
-				return writeJSON(thread)
+				return app.write(thread)
 			}
 
 			w := newTabWriter()
-			if err := writeLine(w, "FIELD\tVALUE"); err != nil {
+			if err := writeHeader(w, "FIELD\tVALUE"); err != nil {
 				return err
 			}
 			if err := writef(w, "ID\t%s\n", thread.ID); err != nil {
@@ -151,6 +313,24 @@ func newThreadsShowCmd(app *App) *cobra.Command {
 			if err := writef(w, "Name\t%s\n", safe(thread.DisplayName)); err != nil {
 				return err
 			}
+			if err := writef(w, "Name Source\t%s\n", safe(thread.NameSource)); err != nil {
+				return err
+			}
+			if thread.CanonicalAlias != "" {
+				if err := writef(w, "Alias\t%s\n", thread.CanonicalAlias); err != nil {
+					return err
+				}
+			}
+			if len(thread.Aliases) > 0 {
+				if err := writef(w, "Other Aliases\t%s\n", strings.Join(thread.Aliases, ", ")); err != nil {
+					return err
+				}
+			}
+			if thread.Bridge != nil {
+				if err := writef(w, "Bridge\t%s %s\n", thread.Bridge.Platform, thread.Bridge.RemoteID); err != nil {
+					return err
+				}
+			}
 			if err := writef(w, "Type\t%s\n", safe(thread.Type)); err != nil {
 				return err
 			}
@@ -172,11 +352,39 @@ func newThreadsShowCmd(app *App) *cobra.Command {
 			if err := writef(w, "Unread Mentions\t%d\n", thread.UnreadMentions); err != nil {
 				return err
 			}
+			if marker := thread.ReadMarker; marker != nil {
+				if err := writef(w, "Last Read\t%s (%s)\n", formatTime(marker.Timestamp), marker.EventID); err != nil {
+					return err
+				}
+			}
+			if err := writef(w, "Undecrypted\t%d\n", thread.Undecrypted); err != nil {
+				return err
+			}
 			if len(thread.Tags) > 0 {
 				if err := writef(w, "Tags\t%s\n", strings.Join(thread.Tags, ",")); err != nil {
 					return err
 				}
 			}
+			if reading := thread.Reading; reading != nil {
+				if err := writef(w, "Total Messages\t%d\n", thread.TotalMessages); err != nil {
+					return err
+				}
+				if err := writef(w, "Last Message\t%s\n", formatTime(thread.LastMessage)); err != nil {
+					return err
+				}
+				if err := writef(w, "Messages In Window\t%d\n", reading.Messages); err != nil {
+					return err
+				}
+				if err := writef(w, "Words\t%d\n", reading.Words); err != nil {
+					return err
+				}
+				if err := writef(w, "Average Length\t%.1f words, %.0f characters\n", reading.AverageWords, reading.AverageChars); err != nil {
+					return err
+				}
+				if err := writef(w, "Reading Time\t%s at %d wpm\n", formatReadingTime(reading.ReadingMinutes), reading.WordsPerMinute); err != nil {
+					return err
+				}
+			}
 			if err := w.Flush(); err != nil {
 				return err
 			}
@@ -193,6 +401,17 @@ func newThreadsShowCmd(app *App) *cobra.Command {
 				}
 			}
 
+			if membership {
+				fmt.Println()
+				fmt.Println("Membership:")
+				if len(thread.Membership) == 0 {
+					fmt.Println("- no membership events synced")
+				}
+				for _, change := range thread.Membership {
+					fmt.Printf("- %s %s\n", formatTime(change.Timestamp), membershipLine(change))
+				}
+			}
+
 			if withLast > 0 {
 				fmt.Println()
 				fmt.Println("Recent messages:")
@@ -209,7 +428,7 @@ func newThreadsShowCmd(app *App) *cobra.Command {
 					if sender == "" {
 						sender = msg.SenderID
 					}
-					fmt.Printf("- %s %s: %s\n", formatTime(msg.Timestamp), sender, msg.Text)
+					fmt.Printf("- %s %s: %s\n", formatTime(msg.Timestamp), sender, outputLocale.Text(msg.Text))
 				}
 			}
 
@@ -217,10 +436,131 @@ func newThreadsShowCmd(app *App) *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVar(&threadID, "id", "", "thread ID (room ID)")
-	cmd.Flags().BoolVar(&withStats, "with-stats", false, "include message stats")
+	cmd.Flags().StringVar(&threadID, "id", "", "thread ID or name")
+	cmd.Flags().BoolVar(&withStats, "with-stats", false, "include message stats, word counts, and reading time")
 	cmd.Flags().IntVar(&withLast, "with-last", 0, "include last N messages")
 	cmd.Flags().StringVar(&format, "format", string(beeper.FormatRich), "message format: plain|rich")
+	cmd.Flags().StringVar(&since, "since", "", "with --with-stats, count words from this time on (24h, 2w, yesterday, YYYY-MM-DD, RFC3339)")
+	cmd.Flags().StringVar(&before, "before", "", "with --with-stats, count words up to this time, in the same forms as --since")
+	cmd.Flags().BoolVar(&unreadOnly, "unread", false, "with --with-stats, count words since the thread was last opened")
+	cmd.Flags().IntVar(&wpm, "wpm", beeper.DefaultReadingWPM, "with --with-stats, reading speed in words per minute")
+	cmd.MarkFlagsMutuallyExclusive("since", "unread")
+	cmd.Flags().BoolVar(&membership, "membership", false, "include who joined, left, was invited, or was removed, and when")
+
+	return cmd
+}
+
+// membershipLine renders a membership change as "Alice joined" or "Bob
+// removed Carol (spam)".
+func membershipLine(change beeper.MembershipChange) string {
+	user := cmp.Or(change.UserName, change.UserID)
+	line := user + " " + change.Action
+	if change.ActorID != "" {
+		actor := cmp.Or(change.ActorName, change.ActorID)
+		switch change.Action {
+		case beeper.MembershipInvited, beeper.MembershipRemoved, beeper.MembershipBanned, beeper.MembershipUnbanned:
+			line = actor + " " + change.Action + " " + user
+		}
+	}
+	if change.Reason != "" {
+		line += " (" + change.Reason + ")"
+	}
+	return line
+}
+
+// formatReadingTime renders a reading-time estimate in minutes as "<1 min",
+// "12 min", or "2h 05m".
+func formatReadingTime(minutes float64) string {
+	rounded := int(math.Round(minutes))
+	switch {
+	case minutes > 0 && rounded < 1:
+		return "<1 min"
+	case rounded < 60:
+		return fmt.Sprintf("%d min", rounded)
+	default:
+		return fmt.Sprintf("%dh %02dm", rounded/60, rounded%60)
+	}
+}
+
+type threadMentions struct {
+	Thread   beeper.Thread    `json:"thread"`
+	Mentions []beeper.Message `json:"mentions"`
+}
+
+func newThreadsMentionsCmd(app *App) *cobra.Command {
+	var accountID string
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "mentions",
+		Short: "List threads with unread mentions and the mentioning messages",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			formatValue, err := parseMessageFormat(format)
+			if err != nil {
+				return err
+			}
+
+			ctx := context.Background()
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			threads, err := store.ListThreads(ctx, beeper.ThreadListOptions{
+				All:                true,
+				AccountID:          accountID,
+				Label:              beeper.LabelAll,
+				IncludeLowPriority: true,
+			})
+			if err != nil {
+				return err
+			}
+
+			results := []threadMentions{}
+			for _, thread := range threads {
+				if thread.UnreadMentions <= 0 {
+					continue
+				}
+				mentions, err := store.ListMentions(ctx, beeper.MentionOptions{
+					ThreadID: thread.ID,
+					Limit:    thread.UnreadMentions,
+					Format:   formatValue,
+				})
+				if err != nil {
+					return err
+				}
+				results = append(results, threadMentions{Thread: thread, Mentions: mentions})
+			}
+
+			if app.JSON {
+				return app.write(results)
+			}
+
+			for i, result := range results {
+				if i > 0 {
+					fmt.Println()
+				}
+				fmt.Printf("%s (%s) — %d unread mention(s)  %s\n", safe(result.Thread.DisplayName), safe(result.Thread.AccountID), result.Thread.UnreadMentions, result.Thread.ID)
+				if len(result.Mentions) == 0 {
+					fmt.Println("- (mentioning messages not found)")
+				}
+				for _, msg := range result.Mentions {
+					sender := msg.SenderName
+					if sender == "" {
+						sender = msg.SenderID
+					}
+					fmt.Printf("- %s %s: %s\n", formatTime(msg.Timestamp), sender, outputLocale.Text(msg.Text))
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&accountID, "account", "", "filter by account/platform ID")
+	cmd.Flags().StringVar(&format, "format", string(beeper.FormatRich), "message format: plain|rich")
 
 	return cmd
 }
@@ -231,3 +571,86 @@ func safe(value string) string {
 	}
 	return value
 }
+
+// threadLink is the output of `threads open`.
+type threadLink struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"displayName"`
+	URL         string `json:"url"`
+}
+
+func newThreadsOpenCmd(app *App) *cobra.Command {
+	var web bool
+	var printOnly bool
+
+	cmd := &cobra.Command{
+		Use:               "open <thread>",
+		Short:             "Open a thread in Beeper Desktop",
+		ValidArgsFunction: app.completeThreadArgs,
+		Args:              cobra.ExactArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			ctx := context.Background()
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			threadID, err := resolveThreadRef(ctx, store, args[0])
+			if err != nil {
+				return err
+			}
+			thread, err := store.GetThread(ctx, threadID, false)
+			if err != nil {
+				return err
+			}
+
+			link := threadLink{ID: thread.ID, DisplayName: thread.DisplayName, URL: beeper.MatrixURI(thread.ID)}
+			if web {
+				link.URL = beeper.MatrixToURL(thread.ID)
+			}
+			if !printOnly {
+				if err := openURL(link.URL); err != nil {
+					return fmt.Errorf("open %s: %w", link.URL, err)
+				}
+			}
+
+			if app.JSON {
+				return app.write(link)
+			}
+			if printOnly {
+				fmt.Println(link.URL)
+				return nil
+			}
+			fmt.Printf("Opened %s (%s)\n", safe(link.DisplayName), link.URL)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&web, "web", false, "use an https://matrix.to link instead of the matrix: URI")
+	cmd.Flags().BoolVar(&printOnly, "print", false, "only print the link")
+
+	return cmd
+}
+
+// openURL hands a URL to the operating system's default handler.
+func openURL(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if len(out) > 0 {
+			return fmt.Errorf("%w: %s", err, out)
+		}
+		return err
+	}
+	return nil
+}
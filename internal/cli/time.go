@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -17,14 +18,16 @@ func parseTimeFlag(value string, days int) (*time.Time, error) {
 	return nil, nil
 }
 
+// parseTimePtr parses an --after/--before style value with parseTime; day
+// values resolve to the start of the day.
 func parseTimePtr(value string) (*time.Time, error) {
 	value = strings.TrimSpace(value)
 	if value == "" {
 		return nil, nil
 	}
-	parsed, err := time.Parse(time.RFC3339, value)
+	parsed, err := parseTime(value, time.Now(), false)
 	if err != nil {
-		return nil, fmt.Errorf("invalid time %q: use RFC3339", value)
+		return nil, err
 	}
 	return &parsed, nil
 }
@@ -40,3 +43,151 @@ func parseDuration(value string) (time.Duration, error) {
 	}
 	return d, nil
 }
+
+// parseDateFlag parses a time flag with parseTime. With endOfDay, day
+// values resolve to the last millisecond of the day so ranges like
+// --to 2023-06-30 include the whole day.
+func parseDateFlag(value string, endOfDay bool) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	parsed, err := parseTime(value, time.Now(), endOfDay)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+// parseSinceFlag parses the start of a --since window with parseTime.
+func parseSinceFlag(value string, now time.Time) (time.Time, error) {
+	parsed, err := parseTime(value, now, false)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --since: %w", err)
+	}
+	return parsed, nil
+}
+
+// parseTime parses a time flag value relative to now:
+//   - RFC3339, or a YYYY-MM-DD date in local time
+//   - now, today, yesterday
+//   - a weekday, optionally after "last" (monday, last fri): its most
+//     recent occurrence before today
+//   - an amount of time ago, with or without "ago": 2w, 3d ago, 90m,
+//     1h30m, 2 weeks ago, last month
+//
+// Dates, today, yesterday, and weekdays resolve to the start of the day, or
+// with endOfDay to its last millisecond.
+func parseTime(value string, now time.Time, endOfDay bool) (time.Time, error) {
+	raw := strings.TrimSpace(value)
+	if parsed, err := time.Parse(time.RFC3339, raw); err == nil {
+		return parsed, nil
+	}
+	day := func(t time.Time) time.Time {
+		start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+		if endOfDay {
+			return start.AddDate(0, 0, 1).Add(-time.Millisecond)
+		}
+		return start
+	}
+	if parsed, err := time.ParseInLocation("2006-01-02", raw, now.Location()); err == nil {
+		return day(parsed), nil
+	}
+
+	words := strings.Fields(strings.ToLower(raw))
+	switch phrase := strings.Join(words, " "); phrase {
+	case "now":
+		return now, nil
+	case "today":
+		return day(now), nil
+	case "yesterday":
+		return day(now.AddDate(0, 0, -1)), nil
+	}
+	if len(words) > 0 && words[len(words)-1] == "ago" {
+		words = words[:len(words)-1]
+	}
+	if len(words) == 2 && words[0] == "last" {
+		if _, ok := weekdays[words[1]]; ok {
+			words = words[1:]
+		} else {
+			words = []string{"1", words[1]}
+		}
+	}
+	if len(words) == 1 {
+		if weekday, ok := weekdays[words[0]]; ok {
+			back := (int(now.Weekday())-int(weekday)+6)%7 + 1
+			return day(now.AddDate(0, 0, -back)), nil
+		}
+	}
+	if t, ok := timeAgo(words, now); ok {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: use RFC3339, YYYY-MM-DD, today, yesterday, a weekday like last monday, or a time ago like 2w or 3d ago", value)
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+}
+
+// timeUnits maps the unit words of relative times to a canonical unit.
+var timeUnits = map[string]string{
+	"s": "s", "sec": "s", "secs": "s", "second": "s", "seconds": "s",
+	"m": "m", "min": "m", "mins": "m", "minute": "m", "minutes": "m",
+	"h": "h", "hr": "h", "hrs": "h", "hour": "h", "hours": "h",
+	"d": "d", "day": "d", "days": "d",
+	"w": "w", "wk": "w", "wks": "w", "week": "w", "weeks": "w",
+	"mo": "mo", "month": "mo", "months": "mo",
+	"y": "y", "yr": "y", "yrs": "y", "year": "y", "years": "y",
+}
+
+// timeAgo resolves an amount of time ("2w", "3 days", "1h30m") to that
+// long before now. Days and longer move by calendar days, so "1d" is the
+// same clock time yesterday even across a DST change.
+func timeAgo(words []string, now time.Time) (time.Time, bool) {
+	amount := strings.Join(words, "")
+	if d, err := time.ParseDuration(amount); err == nil && d >= 0 {
+		return now.Add(-d), true
+	}
+	digits := len(amount) - len(strings.TrimLeft(amount, "0123456789"))
+	if len(words) == 2 && (words[0] == "a" || words[0] == "an") {
+		amount, digits = "1"+words[1], 1
+	}
+	n, err := strconv.Atoi(amount[:digits])
+	if err != nil {
+		return time.Time{}, false
+	}
+	switch timeUnits[amount[digits:]] {
+	case "s":
+		return now.Add(-time.Duration(n) * time.Second), true
+	case "m":
+		return now.Add(-time.Duration(n) * time.Minute), true
+	case "h":
+		return now.Add(-time.Duration(n) * time.Hour), true
+	case "d":
+		return now.AddDate(0, 0, -n), true
+	case "w":
+		return now.AddDate(0, 0, -7*n), true
+	case "mo":
+		return now.AddDate(0, -n, 0), true
+	case "y":
+		return now.AddDate(-n, 0, 0), true
+	}
+	return time.Time{}, false
+}
+
+// parseAgeFlag accepts a duration like parseDuration does, plus 7d for days.
+func parseAgeFlag(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	}
+	return parseDuration(value)
+}
@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	loc := time.FixedZone("CET", 3600)
+	// A Wednesday.
+	now := time.Date(2025, 3, 12, 15, 30, 0, 0, loc)
+	day := func(d int) time.Time { return time.Date(2025, 3, d, 0, 0, 0, 0, loc) }
+
+	cases := []struct {
+		value string
+		want  time.Time
+	}{
+		{"2025-03-01T10:00:00Z", time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)},
+		{"2025-03-01", day(1)},
+		{"now", now},
+		{"today", day(12)},
+		{" Yesterday ", day(11)},
+		{"monday", day(10)},
+		{"last monday", day(10)},
+		{"last Wed", day(5)},
+		{"thursday", day(6)},
+		{"2w", now.AddDate(0, 0, -14)},
+		{"3d ago", now.AddDate(0, 0, -3)},
+		{"3 days ago", now.AddDate(0, 0, -3)},
+		{"90m", now.Add(-90 * time.Minute)},
+		{"1h30m", now.Add(-90 * time.Minute)},
+		{"24h", now.Add(-24 * time.Hour)},
+		{"an hour ago", now.Add(-time.Hour)},
+		{"last week", now.AddDate(0, 0, -7)},
+		{"2mo", now.AddDate(0, -2, 0)},
+		{"1 year ago", now.AddDate(-1, 0, 0)},
+	}
+	for _, tc := range cases {
+		got, err := parseTime(tc.value, now, false)
+		if err != nil {
+			t.Fatalf("parse %q: %v", tc.value, err)
+		}
+		if !got.Equal(tc.want) {
+			t.Fatalf("parse %q: expected %s, got %s", tc.value, tc.want, got)
+		}
+	}
+
+	for _, value := range []string{"", "someday", "last", "3 fortnights", "-2d", "2025-13-01", "next monday"} {
+		if _, err := parseTime(value, now, false); err == nil {
+			t.Fatalf("expected an error for %q", value)
+		}
+	}
+}
+
+func TestParseTimeEndOfDay(t *testing.T) {
+	now := time.Date(2025, 3, 12, 15, 30, 0, 0, time.UTC)
+	endOf := func(d int) time.Time { return time.Date(2025, 3, d+1, 0, 0, 0, 0, time.UTC).Add(-time.Millisecond) }
+
+	for value, want := range map[string]time.Time{
+		"2025-03-01": endOf(1),
+		"yesterday":  endOf(11),
+		"last fri":   endOf(7),
+		// Points in time are not days and stay as they are.
+		"2d": now.AddDate(0, 0, -2),
+	} {
+		got, err := parseTime(value, now, true)
+		if err != nil {
+			t.Fatalf("parse %q: %v", value, err)
+		}
+		if !got.Equal(want) {
+			t.Fatalf("parse %q: expected %s, got %s", value, want, got)
+		}
+	}
+}
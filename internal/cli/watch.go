@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+	"github.com/spf13/cobra"
+)
+
+func newWatchCmd(app *App) *cobra.Command {
+	var threadRef string
+	var accountID string
+	var interval string
+	var format string
+	var nice bool
+
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Stream new messages as they arrive",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			if app.Snapshot || app.Immutable {
+				return fmt.Errorf("watch follows the live database and cannot use --snapshot or --immutable")
+			}
+			pollInterval, err := parseDuration(interval)
+			if err != nil {
+				return err
+			}
+			if pollInterval <= 0 {
+				return fmt.Errorf("--interval must be positive")
+			}
+			formatValue, err := parseMessageFormat(format)
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			threadID := ""
+			if threadRef != "" {
+				threadID, err = resolveThreadRef(ctx, store, threadRef)
+				if err != nil {
+					return err
+				}
+			}
+
+			// One JSON object (or CSV row) per line, so output can be piped
+			// message by message.
+			stream := newTypedOutputStream[beeper.Message](os.Stdout, app.Output)
+			fmt.Fprintln(os.Stderr, "Watching for new messages (Ctrl-C to stop)...")
+			return store.Watch(ctx, beeper.WatchOptions{
+				ThreadID:  threadID,
+				AccountID: accountID,
+				Interval:  pollInterval,
+				Format:    formatValue,
+				Nice:      nice,
+			}, func(msg beeper.Message) error {
+				if app.JSON {
+					return stream.write(msg)
+				}
+				sender := msg.SenderName
+				if sender == "" {
+					sender = msg.SenderID
+				}
+				if msg.IsSentByMe {
+					sender = outputLocale.T("Me")
+				}
+				_, err := fmt.Printf("%s  %s  %s: %s\n", formatTime(msg.Timestamp), safe(msg.ThreadName), sender, strings.ReplaceAll(msg.Text, "\n", " "))
+				return err
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&threadRef, "thread", "", "only this thread (ID or name)")
+	cmd.Flags().StringVar(&accountID, "account", "", "filter by account/platform ID")
+	cmd.Flags().StringVar(&interval, "interval", beeper.DefaultWatchInterval.String(), "how often to check for new messages")
+	cmd.Flags().StringVar(&format, "format", string(beeper.FormatRich), "message format: plain|rich")
+	cmd.Flags().BoolVar(&nice, "nice", false, "poll at most every 10s and back off while Beeper is writing")
+
+	return cmd
+}
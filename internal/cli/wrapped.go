@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/report"
+	"github.com/spf13/cobra"
+)
+
+func newWrappedCmd(app *App) *cobra.Command {
+	var accountID string
+	var format string
+	var outPath string
+
+	cmd := &cobra.Command{
+		Use:   "wrapped [year]",
+		Short: "Generate a \"year in review\" report",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			year := time.Now().Year()
+			if len(args) > 0 {
+				parsed, err := strconv.Atoi(args[0])
+				if err != nil || parsed < 1970 || parsed > 9999 {
+					return fmt.Errorf("invalid year %q", args[0])
+				}
+				year = parsed
+			}
+			if format != "markdown" && format != "html" {
+				return fmt.Errorf("invalid format %q: use markdown or html", format)
+			}
+
+			ctx := context.Background()
+			store, _, err := app.openStore()
+			if err != nil {
+				return err
+			}
+			defer func() {
+				_ = store.Close()
+			}()
+
+			wrapped, err := report.BuildWrapped(ctx, store, year, accountID)
+			if err != nil {
+				return err
+			}
+			if app.JSON {
+				return app.write(wrapped)
+			}
+
+			var w io.Writer = os.Stdout
+			if outPath != "" {
+				f, err := os.Create(outPath)
+				if err != nil {
+					return err
+				}
+				defer func() {
+					_ = f.Close()
+				}()
+				w = f
+			}
+			if format == "html" {
+				return report.WriteWrappedHTML(w, wrapped)
+			}
+			return report.WriteWrappedMarkdown(w, wrapped)
+		},
+	}
+
+	cmd.Flags().StringVar(&accountID, "account", "", "filter by account/platform ID")
+	cmd.Flags().StringVar(&format, "format", "markdown", "report format: markdown|html")
+	cmd.Flags().StringVar(&outPath, "out", "", "write the report to a file instead of stdout")
+
+	return cmd
+}
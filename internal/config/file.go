@@ -0,0 +1,139 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Config holds user preferences persisted between runs.
+type Config struct {
+	DBPath      string `json:"dbPath,omitempty"`
+	SidecarPath string `json:"sidecarPath,omitempty"`
+	Format      string `json:"format,omitempty"`
+	Timezone    string `json:"timezone,omitempty"`
+	Color       string `json:"color,omitempty"`
+	// Locale is the language of table headers, dates, and exports (en, de,
+	// fr, es).
+	Locale  string        `json:"locale,omitempty"`
+	Threads ThreadsConfig `json:"threads,omitempty"`
+	// Board picks the threads shown by `board`.
+	Board BoardConfig `json:"board,omitempty"`
+	// EmbedCommand is the external embedding command for semantic search.
+	EmbedCommand string `json:"embedCommand,omitempty"`
+	// Tokenizer is the tokenizer of the sidecar's media search index
+	// (unicode61, porter, simple, icu, or icu:<locale>).
+	Tokenizer string `json:"tokenizer,omitempty"`
+	// PreviewLength is the length of message previews ("preview" in JSON
+	// output) in characters; zero means 120.
+	PreviewLength int `json:"previewLength,omitempty"`
+	// Mask controls masking of sensitive patterns in terminal output.
+	Mask MaskConfig `json:"mask,omitempty"`
+	// Commands sets per-command flag defaults, keyed by command path
+	// ("search", "messages list") and then flag name, e.g.
+	// {"search": {"limit": 200, "format": "plain", "context": 2}}.
+	Commands map[string]map[string]any `json:"commands,omitempty"`
+}
+
+// ThreadsConfig holds defaults for `threads list`.
+type ThreadsConfig struct {
+	Label string `json:"label,omitempty"`
+	Days  int    `json:"days,omitempty"`
+	Sort  string `json:"sort,omitempty"`
+}
+
+// BoardConfig holds the threads shown by `board`.
+type BoardConfig struct {
+	// Threads are thread IDs or names, shown in this order; empty means
+	// the favourite threads.
+	Threads []string `json:"threads,omitempty"`
+	// Limit caps the number of favourites shown.
+	Limit int `json:"limit,omitempty"`
+}
+
+// MaskConfig holds the rules for `--mask`.
+type MaskConfig struct {
+	// Enabled masks terminal output without passing --mask.
+	Enabled bool `json:"enabled,omitempty"`
+	// Rules selects built-in rules (phone, otp, card); empty means all.
+	Rules []string `json:"rules,omitempty"`
+	// Patterns are extra regular expressions whose matches are masked.
+	Patterns []string `json:"patterns,omitempty"`
+}
+
+// ResolveConfigPath returns the config file path based on flags, env, or defaults.
+func ResolveConfigPath(explicit string) (string, error) {
+	if explicit != "" {
+		return expandPath(explicit), nil
+	}
+	if env := os.Getenv("BEEPER_CLI_CONFIG"); env != "" {
+		return expandPath(env), nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "beeper-cli", "config.json"), nil
+}
+
+// ResolveSidecarPath returns the sidecar database path based on flags, env,
+// config, or defaults (next to the config file).
+func ResolveSidecarPath(explicit string, configured string) (string, error) {
+	if explicit != "" {
+		return expandPath(explicit), nil
+	}
+	if env := os.Getenv("BEEPER_CLI_SIDECAR"); env != "" {
+		return expandPath(env), nil
+	}
+	if configured != "" {
+		return expandPath(configured), nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "beeper-cli", "sidecar.db"), nil
+}
+
+// ResolveEmbedCommand returns the external embedding command based on flags,
+// env, or config. Empty means the built-in hashing embedder.
+func ResolveEmbedCommand(explicit string, configured string) string {
+	if explicit != "" {
+		return explicit
+	}
+	if env := os.Getenv("BEEPER_CLI_EMBED_COMMAND"); env != "" {
+		return env
+	}
+	return configured
+}
+
+// LoadConfig reads the config file at path. A missing file yields an empty config.
+func LoadConfig(path string) (Config, error) {
+	var cfg Config
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return cfg, nil
+		}
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// SaveConfig writes cfg to path, creating parent directories as needed.
+func SaveConfig(path string, cfg Config) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	return os.WriteFile(path, data, 0o600)
+}
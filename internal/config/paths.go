@@ -47,6 +47,29 @@ func ResolveDBPath(explicit string) (string, error) {
 	return "", fmt.Errorf("could not find Beeper database; tried: %s", strings.Join(tried, ", "))
 }
 
+// CandidateDBPaths lists every existing index.db found in the default locations.
+func CandidateDBPaths() []string {
+	seen := map[string]struct{}{}
+	found := []string{}
+	candidates := []string{}
+	if env := os.Getenv("BEEPER_DB"); env != "" {
+		candidates = append(candidates, env)
+	}
+	candidates = append(candidates, defaultPaths()...)
+	candidates = append(candidates, globCandidates()...)
+	for _, path := range candidates {
+		path = expandPath(path)
+		if _, ok := seen[path]; ok {
+			continue
+		}
+		seen[path] = struct{}{}
+		if fileExists(path) {
+			found = append(found, path)
+		}
+	}
+	return found
+}
+
 func defaultPaths() []string {
 	var paths []string
 	paths = append(paths, []string{
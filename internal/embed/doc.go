@@ -0,0 +1,4 @@
+// Package embed turns message text into vectors for semantic search. An
+// Embedder either runs an external embedding command or, without one, uses
+// a built-in hashing embedder that needs no model files.
+package embed
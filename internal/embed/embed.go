@@ -0,0 +1,140 @@
+package embed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os/exec"
+	"strings"
+	"unicode"
+)
+
+// Embedder maps texts to vectors. Vectors from different models are not
+// comparable, so the index is keyed by Model.
+type Embedder interface {
+	Model() string
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// HashDim is the dimension of HashEmbedder vectors.
+const HashDim = 512
+
+// HashEmbedder is a model-free embedder: it hashes lowercased words and
+// their character trigrams into a fixed-size vector. It finds messages
+// that share words or word stems with the query, not true paraphrases;
+// use an external model via CommandEmbedder for that.
+type HashEmbedder struct{}
+
+// Model implements Embedder.
+func (HashEmbedder) Model() string {
+	return fmt.Sprintf("hash-v1/%d", HashDim)
+}
+
+// Embed implements Embedder.
+func (HashEmbedder) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		v := make([]float32, HashDim)
+		for _, word := range words(text) {
+			addFeature(v, "w:"+word, 1)
+			padded := []rune("#" + word + "#")
+			for j := 0; j+3 <= len(padded); j++ {
+				addFeature(v, "t:"+string(padded[j:j+3]), 0.5)
+			}
+		}
+		Normalize(v)
+		vectors[i] = v
+	}
+	return vectors, nil
+}
+
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// addFeature adds weight to the bucket of feature, with a hash-derived sign
+// so unrelated features colliding in one bucket tend to cancel out.
+func addFeature(v []float32, feature string, weight float32) {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(feature))
+	sum := h.Sum64()
+	if sum>>63 == 1 {
+		weight = -weight
+	}
+	v[sum%uint64(len(v))] += weight
+}
+
+// CommandEmbedder runs an external command through the shell. The command
+// reads a JSON array of strings on stdin and writes a JSON array with one
+// vector (array of numbers) per input string to stdout.
+type CommandEmbedder struct {
+	Command string
+}
+
+// Model implements Embedder. The command line identifies the model.
+func (c CommandEmbedder) Model() string {
+	return "command:" + c.Command
+}
+
+// Embed implements Embedder.
+func (c CommandEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	input, err := json.Marshal(texts)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, "sh", "-c", c.Command)
+	cmd.Stdin = bytes.NewReader(input)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("embed command: %w: %s", err, msg)
+		}
+		return nil, fmt.Errorf("embed command: %w", err)
+	}
+	var vectors [][]float32
+	if err := json.Unmarshal(output, &vectors); err != nil {
+		return nil, fmt.Errorf("embed command: invalid output: %w", err)
+	}
+	if len(vectors) != len(texts) {
+		return nil, fmt.Errorf("embed command: got %d vectors for %d texts", len(vectors), len(texts))
+	}
+	for _, v := range vectors {
+		Normalize(v)
+	}
+	return vectors, nil
+}
+
+// Normalize scales v to unit length in place, so the dot product of two
+// normalized vectors is their cosine similarity.
+func Normalize(v []float32) {
+	var sum float64
+	for _, f := range v {
+		sum += float64(f) * float64(f)
+	}
+	if sum == 0 {
+		return
+	}
+	scale := float32(1 / math.Sqrt(sum))
+	for i := range v {
+		v[i] *= scale
+	}
+}
+
+// Dot returns the dot product of a and b, or 0 if their lengths differ.
+func Dot(a, b []float32) float32 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var sum float32
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
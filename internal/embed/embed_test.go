@@ -0,0 +1,99 @@
+package embed
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/KrauseFx/beeper-cli/internal/sidecar"
+)
+
+func TestHashEmbedderSimilarity(t *testing.T) {
+	ctx := context.Background()
+	vectors, err := HashEmbedder{}.Embed(ctx, []string{
+		"which restaurant did Anna recommend?",
+		"Anna recommended a great restaurant in Kreuzberg",
+		"the build is broken again",
+		"",
+	})
+	if err != nil {
+		t.Fatalf("embed: %v", err)
+	}
+	related := Dot(vectors[0], vectors[1])
+	unrelated := Dot(vectors[0], vectors[2])
+	if related <= unrelated {
+		t.Fatalf("expected related text to score higher: %.3f <= %.3f", related, unrelated)
+	}
+	if self := Dot(vectors[1], vectors[1]); self < 0.999 || self > 1.001 {
+		t.Fatalf("expected unit vectors, got norm² %.3f", self)
+	}
+	if empty := Dot(vectors[3], vectors[3]); empty != 0 {
+		t.Fatalf("expected zero vector for empty text, got %.3f", empty)
+	}
+}
+
+func TestCommandEmbedder(t *testing.T) {
+	ctx := context.Background()
+	embedder := CommandEmbedder{Command: `cat >/dev/null; echo '[[3,4],[0,2]]'`}
+	vectors, err := embedder.Embed(ctx, []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("embed: %v", err)
+	}
+	if vectors[0][0] != 0.6 || vectors[0][1] != 0.8 || vectors[1][1] != 1 {
+		t.Fatalf("expected normalized vectors, got %v", vectors)
+	}
+
+	if _, err := embedder.Embed(ctx, []string{"a"}); err == nil || !strings.Contains(err.Error(), "2 vectors for 1 texts") {
+		t.Fatalf("expected count mismatch error, got %v", err)
+	}
+	failing := CommandEmbedder{Command: "echo no model >&2; exit 3"}
+	if _, err := failing.Embed(ctx, []string{"a"}); err == nil || !strings.Contains(err.Error(), "no model") {
+		t.Fatalf("expected stderr in error, got %v", err)
+	}
+}
+
+func TestNearest(t *testing.T) {
+	side, err := sidecar.Open(filepath.Join(t.TempDir(), "sidecar.db"))
+	if err != nil {
+		t.Fatalf("open sidecar: %v", err)
+	}
+	defer func() { _ = side.Close() }()
+	ctx := context.Background()
+	embedder := HashEmbedder{}
+
+	if _, err := Nearest(ctx, side, embedder, "restaurant", "", 5); err == nil || !strings.Contains(err.Error(), "extract --kind embeddings") {
+		t.Fatalf("expected missing index error, got %v", err)
+	}
+
+	texts := []string{"lunch at the new restaurant?", "deploy finished", "restaurants near the office", "see you tomorrow"}
+	vectors, err := embedder.Embed(ctx, texts)
+	if err != nil {
+		t.Fatalf("embed: %v", err)
+	}
+	ids := []int64{}
+	embeddings := []sidecar.Embedding{}
+	for i, v := range vectors {
+		ids = append(ids, int64(i+1))
+		embeddings = append(embeddings, sidecar.Embedding{MessageID: int64(i + 1), ThreadID: "!room", Vector: v})
+	}
+	if err := side.ReplaceEmbeddings(ctx, embedder.Model(), ids, embeddings); err != nil {
+		t.Fatalf("store: %v", err)
+	}
+
+	hits, err := Nearest(ctx, side, embedder, "restaurant", "", 2)
+	if err != nil {
+		t.Fatalf("nearest: %v", err)
+	}
+	if len(hits) != 2 || hits[0].Score < hits[1].Score {
+		t.Fatalf("unexpected hits: %+v", hits)
+	}
+	for _, hit := range hits {
+		if hit.MessageID != 1 && hit.MessageID != 3 {
+			t.Fatalf("expected restaurant messages, got %+v", hits)
+		}
+	}
+	if hits, _ := Nearest(ctx, side, embedder, "restaurant", "!other", 2); len(hits) != 0 {
+		t.Fatalf("expected no hits in other thread, got %+v", hits)
+	}
+}
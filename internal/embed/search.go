@@ -0,0 +1,85 @@
+package embed
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/KrauseFx/beeper-cli/internal/sidecar"
+)
+
+// Hit is a message ranked by cosine similarity to a query.
+type Hit struct {
+	MessageID int64   `json:"messageId"`
+	ThreadID  string  `json:"threadId"`
+	Score     float32 `json:"score"`
+}
+
+// Nearest embeds query and returns up to k indexed messages most similar to
+// it, best first, optionally restricted to one thread. Messages with no
+// positive similarity are not hits. It fails if nothing
+// has been indexed with the embedder's model.
+func Nearest(ctx context.Context, side *sidecar.DB, embedder Embedder, query string, threadID string, k int) ([]Hit, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("search query is required")
+	}
+	n, err := side.CountEmbeddings(ctx, embedder.Model())
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("no embeddings for model %q: run `extract --kind embeddings` with the same embedder first", embedder.Model())
+	}
+	vectors, err := embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, err
+	}
+	q := vectors[0]
+
+	top := &hitHeap{}
+	err = side.EachEmbedding(ctx, embedder.Model(), threadID, func(e sidecar.Embedding) error {
+		hit := Hit{MessageID: e.MessageID, ThreadID: e.ThreadID, Score: Dot(q, e.Vector)}
+		if hit.Score <= 0 {
+			return nil
+		}
+		if top.Len() < k {
+			heap.Push(top, hit)
+		} else if k > 0 && less((*top)[0], hit) {
+			(*top)[0] = hit
+			heap.Fix(top, 0)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	hits := []Hit(*top)
+	sort.Slice(hits, func(i, j int) bool { return less(hits[j], hits[i]) })
+	return hits, nil
+}
+
+// less orders hits by score, breaking ties by preferring newer (higher)
+// message IDs.
+func less(a, b Hit) bool {
+	if a.Score != b.Score {
+		return a.Score < b.Score
+	}
+	return a.MessageID < b.MessageID
+}
+
+// hitHeap is a min-heap keeping the k best hits seen so far.
+type hitHeap []Hit
+
+func (h hitHeap) Len() int           { return len(h) }
+func (h hitHeap) Less(i, j int) bool { return less(h[i], h[j]) }
+func (h hitHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *hitHeap) Push(x any)        { *h = append(*h, x.(Hit)) }
+func (h *hitHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
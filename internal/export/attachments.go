@@ -0,0 +1,115 @@
+package export
+
+import (
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+)
+
+// commonExtensions picks the usual extension where mime.ExtensionsByType
+// would return several in alphabetical order (".jfif" for JPEG).
+var commonExtensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/gif":       ".gif",
+	"image/webp":      ".webp",
+	"image/heic":      ".heic",
+	"video/mp4":       ".mp4",
+	"video/quicktime": ".mov",
+	"audio/ogg":       ".ogg",
+	"audio/mpeg":      ".mp3",
+	"audio/mp4":       ".m4a",
+	"application/pdf": ".pdf",
+}
+
+// AttachmentFileName names an exported attachment after when it was sent,
+// who sent it, and its original file name, e.g.
+// "2025-01-03 101500 Carol - boarding pass.pdf". Attachments without a file
+// name use the event ID, with an extension derived from the MIME type.
+func AttachmentFileName(att beeper.Attachment) string {
+	sender := att.SenderName
+	if sender == "" {
+		sender = att.SenderID
+	}
+	name := att.FileName
+	if name == "" {
+		name = strings.TrimPrefix(att.EventID, "$")
+	}
+	if filepath.Ext(name) == "" {
+		name += mimeExtension(att.MimeType)
+	}
+	base := fmt.Sprintf("%s %s - %s", att.Timestamp.Local().Format("2006-01-02 150405"), sender, name)
+	return SafeName(base)
+}
+
+func mimeExtension(mimeType string) string {
+	mimeType = strings.ToLower(strings.TrimSpace(strings.SplitN(mimeType, ";", 2)[0]))
+	if ext, ok := commonExtensions[mimeType]; ok {
+		return ext
+	}
+	if exts, err := mime.ExtensionsByType(mimeType); err == nil && len(exts) > 0 {
+		return exts[0]
+	}
+	return ""
+}
+
+// maxNameRunes keeps names well below the 255-byte limit of common
+// filesystems even for multi-byte characters.
+const maxNameRunes = 120
+
+// SafeName makes s usable as a single path component on macOS, Linux, and
+// Windows: separators, reserved punctuation, and control characters become
+// "_", and overlong names are shortened, keeping the extension.
+func SafeName(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case strings.ContainsRune(`/\:*?"<>|`, r), r < 0x20, r == 0x7f:
+			return '_'
+		default:
+			return r
+		}
+	}, strings.TrimSpace(s))
+	s = strings.TrimRight(s, ". ")
+	if strings.Trim(s, ".") == "" {
+		return "_"
+	}
+	if runes := []rune(s); len(runes) > maxNameRunes {
+		ext := []rune(filepath.Ext(s))
+		if len(ext) > 10 {
+			ext = nil
+		}
+		s = strings.TrimSpace(string(runes[:maxNameRunes-len(ext)])) + string(ext)
+	}
+	return s
+}
+
+// CopyAttachment copies a cached media file to dst and sets its
+// modification time to when it was sent. A dst of the same size is assumed
+// to be an earlier copy and left alone; the result reports whether the file
+// was copied.
+func CopyAttachment(src string, dst string, sent time.Time) (bool, error) {
+	in, err := os.Stat(src)
+	if err != nil {
+		return false, err
+	}
+	if out, err := os.Stat(dst); err == nil && out.Size() == in.Size() {
+		return false, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return false, err
+	}
+	if err := copyFile(src, dst); err != nil {
+		return false, err
+	}
+	if !sent.IsZero() {
+		if err := os.Chtimes(dst, sent, sent); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
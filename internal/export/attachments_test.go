@@ -0,0 +1,72 @@
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+)
+
+func TestAttachmentFileName(t *testing.T) {
+	sent := time.Date(2025, 1, 3, 10, 15, 0, 0, time.Local)
+	tests := []struct {
+		att  beeper.Attachment
+		want string
+	}{
+		{beeper.Attachment{Timestamp: sent, SenderName: "Carol", FileName: "boarding pass.pdf"}, "2025-01-03 101500 Carol - boarding pass.pdf"},
+		{beeper.Attachment{Timestamp: sent, SenderID: "@bob:beeper.local", EventID: "$ev1", MimeType: "image/jpeg"}, "2025-01-03 101500 @bob_beeper.local - ev1.jpg"},
+		{beeper.Attachment{Timestamp: sent, SenderName: "A/B", FileName: "notes", MimeType: "application/pdf; charset=binary"}, "2025-01-03 101500 A_B - notes.pdf"},
+	}
+	for _, tt := range tests {
+		if got := AttachmentFileName(tt.att); got != tt.want {
+			t.Fatalf("got %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestSafeName(t *testing.T) {
+	tests := map[string]string{
+		` what?: "a<b>" `: `what__ _a_b__`,
+		"..":              "_",
+		"trailing dots..": "trailing dots",
+	}
+	for in, want := range tests {
+		if got := SafeName(in); got != want {
+			t.Fatalf("SafeName(%q) = %q, want %q", in, got, want)
+		}
+	}
+	long := SafeName(strings.Repeat("ä", 200) + ".jpeg")
+	if n := len([]rune(long)); n != maxNameRunes || !strings.HasSuffix(long, ".jpeg") {
+		t.Fatalf("expected %d runes ending in .jpeg, got %d: %q", maxNameRunes, n, long)
+	}
+}
+
+func TestCopyAttachment(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "cache", "abc")
+	if err := os.MkdirAll(filepath.Dir(src), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(src, []byte("pdf bytes"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	sent := time.Date(2025, 1, 3, 10, 15, 0, 0, time.UTC)
+	dst := filepath.Join(dir, "out", "Thread", "file.pdf")
+
+	if copied, err := CopyAttachment(src, dst, sent); err != nil || !copied {
+		t.Fatalf("first copy: copied=%v err=%v", copied, err)
+	}
+	info, err := os.Stat(dst)
+	if err != nil || !info.ModTime().Equal(sent) {
+		t.Fatalf("expected mtime %v, got %v (%v)", sent, info, err)
+	}
+	if copied, err := CopyAttachment(src, dst, sent); err != nil || copied {
+		t.Fatalf("second copy: copied=%v err=%v", copied, err)
+	}
+	if _, err := CopyAttachment(filepath.Join(dir, "missing"), dst, sent); err == nil {
+		t.Fatal("expected error for missing source")
+	}
+}
@@ -0,0 +1,90 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+)
+
+// BookmarkExport is a dossier of bookmarked messages, each with the
+// messages around it.
+type BookmarkExport struct {
+	Ran time.Time
+	// Results holds one result per bookmark: the message and its context.
+	Results []beeper.SearchResult
+	// Notes are the bookmark notes by message ID.
+	Notes map[int64]string
+}
+
+// WriteBookmarksMarkdown renders bookmarks as one Markdown document with a
+// section per thread, threads by name and bookmarks oldest first within
+// each. Bookmarks whose context overlaps share one run of messages (see
+// beeper.MergeSearchContext); separate runs in a thread are divided by an
+// ellipsis. Bookmarked messages are set in bold, followed by their note.
+func WriteBookmarksMarkdown(w io.Writer, export BookmarkExport) error {
+	results := slices.Clone(export.Results)
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i].Match, results[j].Match
+		if an, bn := strings.ToLower(a.ThreadName), strings.ToLower(b.ThreadName); an != bn {
+			return an < bn
+		}
+		if a.ThreadID != b.ThreadID {
+			return a.ThreadID < b.ThreadID
+		}
+		return a.Timestamp.Before(b.Timestamp)
+	})
+	blocks := beeper.MergeSearchContext(results)
+
+	threads := 0
+	for i, block := range blocks {
+		if i == 0 || block.ThreadID != blocks[i-1].ThreadID {
+			threads++
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("# Bookmarks\n\n")
+	fmt.Fprintf(&b, "_%d bookmarks · %d threads · exported %s_\n", len(results), threads, export.Ran.Local().Format("2006-01-02 15:04"))
+
+	for i, block := range blocks {
+		if i > 0 && block.ThreadID == blocks[i-1].ThreadID {
+			b.WriteString("- …\n")
+		} else {
+			title := block.ThreadName
+			if title == "" {
+				title = block.ThreadID
+			}
+			if block.AccountID != "" {
+				title += " (" + block.AccountID + ")"
+			}
+			fmt.Fprintf(&b, "\n## %s\n\n", markdownEscape(title))
+		}
+		for _, msg := range block.Messages {
+			sender := msg.SenderName
+			if sender == "" {
+				sender = msg.SenderID
+			}
+			if msg.IsSentByMe {
+				sender = "Me"
+			}
+			line := fmt.Sprintf("%s %s: %s", msg.Timestamp.Local().Format("2006-01-02 15:04"), markdownEscape(sender), markdownText(msg.Text))
+			if !slices.Contains(block.Matches, msg.ID) {
+				fmt.Fprintf(&b, "- %s\n", line)
+				continue
+			}
+			fmt.Fprintf(&b, "- **%s**", line)
+			if note := strings.TrimSpace(export.Notes[msg.ID]); note != "" {
+				fmt.Fprintf(&b, " — _%s_", markdownEscape(note))
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,46 @@
+package export
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+)
+
+func TestWriteBookmarksMarkdown(t *testing.T) {
+	at := time.Date(2025, 12, 19, 16, 0, 0, 0, time.UTC)
+	msg := func(thread string, id int64, minute int, text string) beeper.Message {
+		return beeper.Message{ID: id, ThreadID: "!" + strings.ToLower(thread), ThreadName: thread, AccountID: "signal", SenderID: "@bob", SenderName: "Bob", Timestamp: at.Add(time.Duration(minute) * time.Minute), Text: text}
+	}
+	stamp := func(m beeper.Message) string { return m.Timestamp.Local().Format("2006-01-02 15:04") }
+	early, late, other := msg("Trip", 2, 1, "flight booked"), msg("Trip", 9, 90, "hotel booked"), msg("Admin", 5, 30, "invoice")
+	dossier := BookmarkExport{
+		Ran: at,
+		Results: []beeper.SearchResult{
+			{Match: late, Context: []beeper.Message{msg("Trip", 8, 89, "any news?")}},
+			{Match: early, Context: []beeper.Message{msg("Trip", 1, 0, "plans?"), msg("Trip", 3, 2, "great")}},
+			{Match: other},
+		},
+		Notes: map[int64]string{2: "flight_number"},
+	}
+
+	var md strings.Builder
+	if err := WriteBookmarksMarkdown(&md, dossier); err != nil {
+		t.Fatalf("markdown: %v", err)
+	}
+	out := md.String()
+	for _, want := range []string{
+		"_3 bookmarks · 2 threads",
+		"- **" + stamp(early) + " Bob: flight booked** — _flight\\_number_\n",
+		"- " + stamp(msg("Trip", 3, 2, "")) + " Bob: great\n- …\n- ",
+		"- **" + stamp(late) + " Bob: hotel booked**\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("markdown missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "## Admin (signal)") > strings.Index(out, "## Trip (signal)") || strings.Count(out, "## Trip") != 1 {
+		t.Fatalf("expected one section per thread, by name:\n%s", out)
+	}
+}
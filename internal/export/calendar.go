@@ -0,0 +1,132 @@
+package export
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+	"github.com/KrauseFx/beeper-cli/internal/extract"
+)
+
+// icsLineLength is the longest content line RFC 5545 allows, in octets.
+const icsLineLength = 75
+
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// WriteCallsICS writes calls as an iCalendar file with one event per call,
+// so a calendar app shows when (and how long) calls happened. Calls
+// without a duration become zero-length events.
+func WriteCallsICS(w io.Writer, calls []beeper.Call) error {
+	var b strings.Builder
+	line := func(format string, args ...any) {
+		writeICSLine(&b, fmt.Sprintf(format, args...))
+	}
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//beeper-cli//calls//EN")
+	line("CALSCALE:GREGORIAN")
+	for _, call := range calls {
+		msg := call.Message
+		start := msg.Timestamp.UTC()
+		line("BEGIN:VEVENT")
+		line("UID:%s@beeper-cli", icsEscaper.Replace(msg.EventID))
+		line("DTSTAMP:%s", icsTime(start))
+		line("DTSTART:%s", icsTime(start))
+		line("DTEND:%s", icsTime(start.Add(call.Duration)))
+		line("SUMMARY:%s", icsEscaper.Replace(callSummary(call)))
+		description := msg.AccountID
+		if call.Duration > 0 {
+			description = strings.TrimPrefix(description+", "+call.Duration.String(), ", ")
+		}
+		if description != "" {
+			line("DESCRIPTION:%s", icsEscaper.Replace(description))
+		}
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteBirthdaysICS writes inferred birthdays as an iCalendar file of
+// all-day events repeating every year from the first greeted year. The
+// events only depend on the greetings, so the file is stable between runs.
+func WriteBirthdaysICS(w io.Writer, birthdays []extract.Birthday) error {
+	var b strings.Builder
+	line := func(format string, args ...any) {
+		writeICSLine(&b, fmt.Sprintf(format, args...))
+	}
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//beeper-cli//birthdays//EN")
+	line("CALSCALE:GREGORIAN")
+	for _, birthday := range birthdays {
+		year := birthday.FirstGreeted.Local().Year()
+		start := time.Date(year, birthday.Month, birthday.Day, 0, 0, 0, 0, time.UTC)
+		// 29 February starts in a leap year.
+		for start.Day() != birthday.Day {
+			year++
+			start = time.Date(year, birthday.Month, birthday.Day, 0, 0, 0, 0, time.UTC)
+		}
+		line("BEGIN:VEVENT")
+		line("UID:%s@beeper-cli", icsEscaper.Replace("birthday-"+cmp.Or(birthday.ContactID, birthday.ThreadID)))
+		line("DTSTAMP:%s", icsTime(birthday.LastGreeted))
+		line("DTSTART;VALUE=DATE:%s", start.Format("20060102"))
+		line("RRULE:FREQ=YEARLY")
+		line("SUMMARY:%s", icsEscaper.Replace(birthday.Name+"'s birthday"))
+		line("DESCRIPTION:%s", icsEscaper.Replace(fmt.Sprintf("Inferred from %d greetings in %d years (confidence %.2f)", birthday.Greetings, birthday.Years, birthday.Confidence)))
+		line("TRANSP:TRANSPARENT")
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// callSummary is e.g. "Missed video call from Alice" or "Call with Team".
+func callSummary(call beeper.Call) string {
+	kind := "call"
+	if call.Video {
+		kind = "video call"
+	}
+	name := call.Message.ThreadName
+	if name == "" {
+		name = call.Message.ThreadID
+	}
+	switch {
+	case call.Missed && !call.Outgoing:
+		return "Missed " + kind + " from " + name
+	case call.Missed:
+		return "Unanswered " + kind + " to " + name
+	default:
+		return strings.ToUpper(kind[:1]) + kind[1:] + " with " + name
+	}
+}
+
+func icsTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// writeICSLine writes one content line, folded into lines of at most
+// icsLineLength octets without splitting UTF-8 sequences.
+func writeICSLine(b *strings.Builder, line string) {
+	limit := icsLineLength
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space.
+		limit = icsLineLength - 1
+	}
+	b.WriteString(line + "\r\n")
+}
+
+func utf8RuneStart(c byte) bool {
+	return c&0xC0 != 0x80
+}
@@ -0,0 +1,77 @@
+package export
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+	"github.com/KrauseFx/beeper-cli/internal/extract"
+)
+
+func TestWriteCallsICS(t *testing.T) {
+	at := time.Date(2025, 3, 4, 9, 30, 0, 0, time.FixedZone("CET", 3600))
+	calls := []beeper.Call{
+		{
+			Message:  beeper.Message{EventID: "$call1", ThreadName: "Alice, Bob; and " + strings.Repeat("Ü", 40), AccountID: "whatsapp", Timestamp: at},
+			Video:    true,
+			Duration: 12*time.Minute + 5*time.Second,
+		},
+		{Message: beeper.Message{EventID: "$call2", ThreadID: "!dm:beeper.local", Timestamp: at.Add(-time.Hour)}, Missed: true},
+	}
+
+	var b strings.Builder
+	if err := WriteCallsICS(&b, calls); err != nil {
+		t.Fatalf("write ics: %v", err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\nVERSION:2.0\r\n",
+		"UID:$call1@beeper-cli\r\n",
+		"DTSTART:20250304T083000Z\r\nDTEND:20250304T084205Z\r\n",
+		`SUMMARY:Video call with Alice\, Bob\; and Ü`,
+		"DESCRIPTION:whatsapp\\, 12m5s\r\n",
+		"DTSTART:20250304T073000Z\r\nDTEND:20250304T073000Z\r\n",
+		"SUMMARY:Missed call from !dm:beeper.local\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		if len(line) > icsLineLength {
+			t.Fatalf("line longer than %d octets: %q", icsLineLength, line)
+		}
+	}
+	if !strings.Contains(out, "\r\n Ü") {
+		t.Fatalf("expected folded summary:\n%s", out)
+	}
+}
+
+func TestWriteBirthdaysICS(t *testing.T) {
+	greeted := time.Date(2023, 3, 14, 9, 0, 0, 0, time.UTC)
+	birthdays := []extract.Birthday{
+		{ContactID: "@anna:beeper.local", Name: "Anna", Month: time.March, Day: 14, Confidence: 0.66, Years: 3, Greetings: 4, FirstGreeted: greeted.AddDate(-2, 0, 0), LastGreeted: greeted},
+		{ThreadID: "!leap:beeper.local", Name: "Leap", Month: time.February, Day: 29, Confidence: 0.5, Years: 1, Greetings: 1, FirstGreeted: greeted, LastGreeted: greeted},
+	}
+
+	var b strings.Builder
+	if err := WriteBirthdaysICS(&b, birthdays); err != nil {
+		t.Fatalf("write ics: %v", err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"UID:birthday-@anna:beeper.local@beeper-cli\r\n",
+		"DTSTAMP:20230314T090000Z\r\n",
+		"DTSTART;VALUE=DATE:20210314\r\nRRULE:FREQ=YEARLY\r\n",
+		"SUMMARY:Anna's birthday\r\n",
+		`DESCRIPTION:Inferred from 4 greetings in 3 years (confidence 0.66)`,
+		"UID:birthday-!leap:beeper.local@beeper-cli\r\n",
+		"DTSTART;VALUE=DATE:20240229\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,2 @@
+// Package export writes thread transcripts and archives to disk.
+package export
@@ -0,0 +1,364 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+)
+
+// ThreadExport bundles a thread with its messages in chronological order.
+type ThreadExport struct {
+	Thread   beeper.Thread    `json:"thread"`
+	Sessions []beeper.Session `json:"sessions,omitempty"`
+	Messages []beeper.Message `json:"messages"`
+}
+
+// FileName returns a filesystem-safe file name for a thread ID.
+func FileName(threadID string, ext string) string {
+	var b strings.Builder
+	for _, r := range strings.TrimPrefix(threadID, "!") {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	name := b.String()
+	if name == "" {
+		name = "thread"
+	}
+	return name + ext
+}
+
+// UniqueFileName returns FileName(threadID, ext) unless another thread
+// already took that name in used, in which case the name gets a short
+// suffix derived from the thread ID ("abc_beeper.local-1a2b3c4d.json").
+// used maps lowercased names, since macOS file systems ignore case, to the
+// thread that took them; the returned name is added to it.
+func UniqueFileName(threadID string, ext string, used map[string]string) string {
+	name := FileName(threadID, ext)
+	if owner, ok := used[strings.ToLower(name)]; ok && owner != threadID {
+		sum := sha256.Sum256([]byte(threadID))
+		name = FileName(threadID, "") + "-" + hex.EncodeToString(sum[:4]) + ext
+	}
+	used[strings.ToLower(name)] = threadID
+	return name
+}
+
+// Canonical returns a copy of export whose serialization only changes when
+// the thread's messages or metadata do: read state (last opened, unread
+// flags and counts) is dropped, lastActivity becomes the last message time,
+// times are in UTC, participants and tags are sorted, and message previews
+// (which repeat the text) are dropped. Messages are
+// expected oldest first with ties broken by ID, as loaded for export.
+func Canonical(export ThreadExport) ThreadExport {
+	var last time.Time
+	if n := len(export.Messages); n > 0 {
+		last = export.Messages[n-1].Timestamp
+	}
+	result := ThreadExport{Thread: CanonicalThread(export.Thread, last)}
+	if export.Sessions != nil {
+		result.Sessions = slices.Clone(export.Sessions)
+		for i := range result.Sessions {
+			result.Sessions[i].Start = utc(result.Sessions[i].Start)
+			result.Sessions[i].End = utc(result.Sessions[i].End)
+		}
+	}
+	result.Messages = slices.Clone(export.Messages)
+	for i := range result.Messages {
+		result.Messages[i] = CanonicalMessage(result.Messages[i])
+	}
+	return result
+}
+
+// CanonicalThread returns the thread as Canonical leaves it. last is the
+// time of the thread's last exported message, used as lastActivity when
+// the thread has no last message time of its own.
+func CanonicalThread(thread beeper.Thread, last time.Time) beeper.Thread {
+	thread.LastOpen = time.Time{}
+	thread.IsUnread = false
+	thread.IsMarkedUnread = false
+	thread.UnreadCount = 0
+	thread.UnreadMentions = 0
+	thread.LastActivity = thread.LastMessage
+	if thread.LastActivity.IsZero() {
+		thread.LastActivity = last
+	}
+	thread.LastActivity = utc(thread.LastActivity)
+	thread.LastMessage = utc(thread.LastMessage)
+	thread.Participants = slices.Clone(thread.Participants)
+	slices.SortStableFunc(thread.Participants, func(a, b beeper.Participant) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	thread.Tags = slices.Clone(thread.Tags)
+	slices.Sort(thread.Tags)
+	return thread
+}
+
+// CanonicalMessage returns the message as Canonical leaves it.
+func CanonicalMessage(msg beeper.Message) beeper.Message {
+	msg.Timestamp = utc(msg.Timestamp)
+	msg.Preview = ""
+	if msg.ExpiresAt != nil {
+		t := msg.ExpiresAt.UTC()
+		msg.ExpiresAt = &t
+	}
+	return msg
+}
+
+func utc(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	return t.UTC()
+}
+
+// WriteFileIfChanged replaces the file at path with data unless it already
+// holds exactly data, so unchanged files keep their modification time. The
+// new content is written to a temporary file and renamed into place, so
+// readers (and sync tools) never see a partial file.
+func WriteFileIfChanged(path string, data []byte) (bool, error) {
+	if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, data) {
+		return false, nil
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return false, err
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		_ = tmp.Close()
+		return false, err
+	}
+	if err := tmp.Close(); err != nil {
+		return false, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// WriteFileIfChangedFunc is WriteFileIfChanged for content too large to
+// hold in memory: write streams it into a temporary file, which replaces
+// the file at path unless both hold the same bytes.
+func WriteFileIfChangedFunc(path string, write func(w io.Writer) error) (bool, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	buf := bufio.NewWriter(tmp)
+	if err := write(buf); err != nil {
+		_ = tmp.Close()
+		return false, err
+	}
+	if err := buf.Flush(); err != nil {
+		_ = tmp.Close()
+		return false, err
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		_ = tmp.Close()
+		return false, err
+	}
+	if err := tmp.Close(); err != nil {
+		return false, err
+	}
+	same, err := sameFileContent(path, tmp.Name())
+	if err != nil || same {
+		return false, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// sameFileContent reports whether two files hold the same bytes. A missing
+// file at a differs from any b.
+func sameFileContent(a, b string) (bool, error) {
+	infoA, err := os.Stat(a)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	infoB, err := os.Stat(b)
+	if err != nil {
+		return false, err
+	}
+	if infoA.Size() != infoB.Size() {
+		return false, nil
+	}
+	fa, err := os.Open(a)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = fa.Close() }()
+	fb, err := os.Open(b)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = fb.Close() }()
+	ra, rb := bufio.NewReader(fa), bufio.NewReader(fb)
+	for {
+		ca, errA := ra.ReadByte()
+		cb, errB := rb.ReadByte()
+		if errA == io.EOF && errB == io.EOF {
+			return true, nil
+		}
+		if errA != nil && errA != io.EOF {
+			return false, errA
+		}
+		if errB != nil && errB != io.EOF {
+			return false, errB
+		}
+		if ca != cb || errA != errB {
+			return false, nil
+		}
+	}
+}
+
+// WriteJSON encodes a thread export as indented JSON.
+func WriteJSON(w io.Writer, export ThreadExport) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(export)
+}
+
+// WriteNDJSON writes the messages of a thread export as newline-delimited
+// JSON, one message per line, so large histories can be streamed into jq or
+// DuckDB. Thread metadata is not included; every message carries its
+// thread ID.
+func WriteNDJSON(w io.Writer, export ThreadExport) error {
+	enc := json.NewEncoder(w)
+	for _, msg := range export.Messages {
+		if err := enc.Encode(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StreamNDJSON writes messages as WriteNDJSON does while they are read,
+// so a history is never held in memory, and returns how many it wrote.
+func StreamNDJSON(w io.Writer, messages iter.Seq2[beeper.Message, error]) (int, error) {
+	enc := json.NewEncoder(w)
+	n := 0
+	for msg, err := range messages {
+		if err != nil {
+			return n, err
+		}
+		if err := enc.Encode(msg); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// StreamJSON writes a thread and its messages as WriteJSON does for a
+// ThreadExport without sessions, encoding each message as it is read so a
+// history is never held in memory. It returns how many messages it wrote.
+func StreamJSON(w io.Writer, thread beeper.Thread, messages iter.Seq2[beeper.Message, error]) (int, error) {
+	data, err := json.MarshalIndent(thread, "  ", "  ")
+	if err != nil {
+		return 0, err
+	}
+	if _, err := fmt.Fprintf(w, "{\n  \"thread\": %s,\n  \"messages\": [", data); err != nil {
+		return 0, err
+	}
+	n := 0
+	for msg, err := range messages {
+		if err != nil {
+			return n, err
+		}
+		data, err := json.MarshalIndent(msg, "    ", "  ")
+		if err != nil {
+			return n, err
+		}
+		sep := ",\n    "
+		if n == 0 {
+			sep = "\n    "
+		}
+		if _, err := fmt.Fprintf(w, "%s%s", sep, data); err != nil {
+			return n, err
+		}
+		n++
+	}
+	end := "]\n}\n"
+	if n > 0 {
+		end = "\n  ]\n}\n"
+	}
+	_, err = io.WriteString(w, end)
+	return n, err
+}
+
+// ReadThreadsFile reads thread references from a selection file.
+func ReadThreadsFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	return ParseThreadList(f)
+}
+
+// ParseThreadList parses one thread ID or alias per line.
+// Blank lines are skipped. "#" starts a comment when it begins a line or
+// follows whitespace and is itself followed by whitespace or the end of the
+// line, so Matrix aliases like "#team:example.org" are kept intact.
+func ParseThreadList(r io.Reader) ([]string, error) {
+	refs := []string{}
+	seen := map[string]struct{}{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := stripComment(scanner.Text())
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if _, ok := seen[line]; ok {
+			continue
+		}
+		seen[line] = struct{}{}
+		refs = append(refs, line)
+	}
+	return refs, scanner.Err()
+}
+
+func stripComment(line string) string {
+	for i := 0; i < len(line); i++ {
+		if line[i] != '#' {
+			continue
+		}
+		if i > 0 && line[i-1] != ' ' && line[i-1] != '\t' {
+			continue
+		}
+		if i+1 == len(line) || line[i+1] == ' ' || line[i+1] == '\t' {
+			return line[:i]
+		}
+	}
+	return line
+}
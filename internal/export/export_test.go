@@ -0,0 +1,295 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+	"github.com/KrauseFx/beeper-cli/internal/i18n"
+)
+
+func TestParseThreadList(t *testing.T) {
+	input := `# weekly backup
+!abc:beeper.local
+  !def:beeper.local   # family
+
+#team:example.org
+Team Chat
+!abc:beeper.local
+#
+`
+	refs, err := ParseThreadList(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	expected := []string{"!abc:beeper.local", "!def:beeper.local", "#team:example.org", "Team Chat"}
+	if strings.Join(refs, "|") != strings.Join(expected, "|") {
+		t.Fatalf("expected %v, got %v", expected, refs)
+	}
+}
+
+func TestFileName(t *testing.T) {
+	if name := FileName("!abc:beeper.local", ".json"); name != "abc_beeper.local.json" {
+		t.Fatalf("unexpected file name: %s", name)
+	}
+}
+
+func TestUniqueFileName(t *testing.T) {
+	used := map[string]string{}
+	names := []string{
+		UniqueFileName("!abc:beeper.local", ".json", used),
+		UniqueFileName("!abc_beeper.local", ".json", used),
+		UniqueFileName("!ABC:beeper.local", ".json", used),
+		UniqueFileName("!abc:beeper.local", ".json", used),
+		UniqueFileName("!def:beeper.local", ".json", used),
+	}
+	want := []string{
+		"abc_beeper.local.json",
+		"abc_beeper.local-f0602036.json",
+		"ABC_beeper.local-4f8b086b.json",
+		"abc_beeper.local.json",
+		"def_beeper.local.json",
+	}
+	if strings.Join(names, "|") != strings.Join(want, "|") {
+		t.Fatalf("expected %v, got %v", want, names)
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	day := func(d, h, m int) time.Time { return time.Date(2025, 1, d, h, m, 0, 0, time.Local) }
+	data := ThreadExport{
+		Thread: beeper.Thread{
+			ID:           "!abc:beeper.local",
+			AccountID:    "signal",
+			DisplayName:  "Berlin Trip",
+			Participants: []beeper.Participant{{Name: "Carol"}, {Name: "Me", IsSelf: true}},
+		},
+		Messages: []beeper.Message{
+			{SenderName: "Carol", Timestamp: day(3, 10, 0), Text: "Flights booked\nsee you there"},
+			{IsSentByMe: true, Timestamp: day(3, 10, 5), Text: "[Image] boarding_pass"},
+			{SenderID: "@dave_x:beeper.local", Timestamp: day(4, 9, 30), Text: "[File: plan.pdf]"},
+		},
+	}
+	var b strings.Builder
+	if err := WriteMarkdown(&b, data); err != nil {
+		t.Fatalf("write markdown: %v", err)
+	}
+	expected := `# Berlin Trip
+
+_signal · with Carol · 3 messages · 2025-01-03 to 2025-01-04_
+
+## Friday, 3 January 2025
+
+**10:00 Carol:** Flights booked  
+see you there
+
+**10:05 Me:** [Image] boarding_pass
+
+## Saturday, 4 January 2025
+
+**09:30 @dave\_x:beeper.local:** [File: plan.pdf]
+`
+	if b.String() != expected {
+		t.Fatalf("unexpected markdown:\n%s", b.String())
+	}
+
+	de, err := i18n.Parse("de")
+	if err != nil {
+		t.Fatalf("parse locale: %v", err)
+	}
+	b.Reset()
+	if err := WriteLocalizedMarkdown(&b, data, de); err != nil {
+		t.Fatalf("write localized markdown: %v", err)
+	}
+	for _, want := range []string{
+		"_signal · mit Carol · 3 Nachrichten · 03.01.2025 bis 04.01.2025_",
+		"## Freitag, 3. Januar 2025",
+		"**10:05 Ich:** [Bild] boarding_pass",
+		"[Datei: plan.pdf]",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Fatalf("localized markdown missing %q:\n%s", want, b.String())
+		}
+	}
+}
+
+func TestCanonicalIgnoresReadStateAndZone(t *testing.T) {
+	berlin := time.FixedZone("CET", 3600)
+	ts := time.Date(2025, 1, 3, 10, 0, 0, 0, time.UTC)
+	base := ThreadExport{
+		Thread: beeper.Thread{
+			ID:           "!abc:beeper.local",
+			DisplayName:  "Berlin Trip",
+			LastMessage:  ts,
+			LastActivity: ts,
+			Tags:         []string{"work", "favourite"},
+			Participants: []beeper.Participant{{ID: "@me:beeper.local", IsSelf: true}, {ID: "@carol:beeper.local"}},
+		},
+		Sessions: []beeper.Session{{ID: "s-1", Start: ts, End: ts}},
+		Messages: []beeper.Message{{ID: 1, Timestamp: ts, Text: "Flights booked"}},
+	}
+	read := base
+	read.Thread.LastOpen = ts.Add(time.Hour)
+	read.Thread.LastActivity = ts.Add(time.Hour)
+	read.Thread.UnreadCount = 3
+	read.Thread.IsUnread = true
+	read.Thread.Participants = []beeper.Participant{base.Thread.Participants[1], base.Thread.Participants[0]}
+	read.Thread.Tags = []string{"favourite", "work"}
+	read.Messages = []beeper.Message{{ID: 1, Timestamp: ts.In(berlin), Text: "Flights booked"}}
+	read.Sessions = []beeper.Session{{ID: "s-1", Start: ts.In(berlin), End: ts.In(berlin)}}
+
+	var a, b strings.Builder
+	if err := WriteJSON(&a, Canonical(base)); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := WriteJSON(&b, Canonical(read)); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if a.String() != b.String() {
+		t.Fatalf("expected identical output:\n%s\n---\n%s", a.String(), b.String())
+	}
+	if !strings.Contains(a.String(), `"timestamp": "2025-01-03T10:00:00Z"`) {
+		t.Fatalf("expected UTC timestamps:\n%s", a.String())
+	}
+	if read.Thread.Participants[0].ID != "@carol:beeper.local" || read.Messages[0].Timestamp.Location() != berlin {
+		t.Fatal("Canonical modified its input")
+	}
+}
+
+func TestWriteFileIfChanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "thread.json")
+	if changed, err := WriteFileIfChanged(path, []byte("one")); err != nil || !changed {
+		t.Fatalf("first write: changed=%v err=%v", changed, err)
+	}
+	old := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	if changed, err := WriteFileIfChanged(path, []byte("one")); err != nil || changed {
+		t.Fatalf("identical write: changed=%v err=%v", changed, err)
+	}
+	if info, _ := os.Stat(path); !info.ModTime().Equal(old) {
+		t.Fatalf("identical write touched the file: %v", info.ModTime())
+	}
+	if changed, err := WriteFileIfChanged(path, []byte("two")); err != nil || !changed {
+		t.Fatalf("changed write: changed=%v err=%v", changed, err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "two" {
+		t.Fatalf("unexpected content %q", data)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Fatalf("expected no temporary files, got %d entries", len(entries))
+	}
+}
+
+func TestWriteFileIfChangedFunc(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "thread.json")
+	write := func(content string) func(io.Writer) error {
+		return func(w io.Writer) error {
+			_, err := io.WriteString(w, content)
+			return err
+		}
+	}
+	if changed, err := WriteFileIfChangedFunc(path, write("one")); err != nil || !changed {
+		t.Fatalf("first write: changed=%v err=%v", changed, err)
+	}
+	old := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	if changed, err := WriteFileIfChangedFunc(path, write("one")); err != nil || changed {
+		t.Fatalf("identical write: changed=%v err=%v", changed, err)
+	}
+	if info, _ := os.Stat(path); !info.ModTime().Equal(old) {
+		t.Fatalf("identical write touched the file: %v", info.ModTime())
+	}
+	if changed, err := WriteFileIfChangedFunc(path, write("two")); err != nil || !changed {
+		t.Fatalf("changed write: changed=%v err=%v", changed, err)
+	}
+	if _, err := WriteFileIfChangedFunc(path, func(io.Writer) error { return errors.New("boom") }); err == nil {
+		t.Fatal("expected the write error")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "two" {
+		t.Fatalf("unexpected content %q", data)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Fatalf("expected no temporary files, got %d entries", len(entries))
+	}
+}
+
+func TestStreamJSONMatchesWriteJSON(t *testing.T) {
+	ts := time.Date(2025, 1, 3, 10, 0, 0, 0, time.UTC)
+	thread := beeper.Thread{ID: "!abc:beeper.local", DisplayName: "<Trip> & co", Tags: []string{"work"}}
+	for _, messages := range [][]beeper.Message{
+		{},
+		{{ID: 1, Timestamp: ts, Text: "a <b> & c"}, {ID: 2, Timestamp: ts.Add(time.Minute), Text: "two\nlines", Reactions: []beeper.Reaction{{Key: "👍", SenderID: "@me:beeper.local"}}}},
+	} {
+		var want, got bytes.Buffer
+		if err := WriteJSON(&want, ThreadExport{Thread: thread, Messages: messages}); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		n, err := StreamJSON(&got, thread, func(yield func(beeper.Message, error) bool) {
+			for _, msg := range messages {
+				if !yield(msg, nil) {
+					return
+				}
+			}
+		})
+		if err != nil || n != len(messages) {
+			t.Fatalf("stream: %d, %v", n, err)
+		}
+		if got.String() != want.String() {
+			t.Fatalf("streamed JSON differs:\n%s\nwant\n%s", got.String(), want.String())
+		}
+	}
+}
+
+func TestWriteNDJSON(t *testing.T) {
+	data := ThreadExport{
+		Thread: beeper.Thread{ID: "!room:beeper.local"},
+		Messages: []beeper.Message{
+			{ID: 1, ThreadID: "!room:beeper.local", Text: "first"},
+			{ID: 2, ThreadID: "!room:beeper.local", Text: "second\nline"},
+		},
+	}
+	var buf bytes.Buffer
+	if err := WriteNDJSON(&buf, data); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %q", len(lines), buf.String())
+	}
+	var msg beeper.Message
+	if err := json.Unmarshal([]byte(lines[1]), &msg); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if msg.ID != 2 || msg.Text != "second\nline" {
+		t.Fatalf("unexpected message: %+v", msg)
+	}
+}
+
+func TestStreamCanonicalText(t *testing.T) {
+	at := time.Date(2025, 1, 2, 3, 4, 5, 6_000_000, time.FixedZone("CET", 3600))
+	messages := func(yield func(beeper.Message, error) bool) {
+		_ = yield(beeper.Message{SenderID: "@alice:beeper.local", SenderName: "Alice", Timestamp: at, Text: "two\nlines\tand a \\ slash"}, nil) &&
+			yield(beeper.Message{SenderID: "@bob:beeper.local", Timestamp: at.Add(time.Second), Text: "bell\a"}, nil)
+	}
+	var buf bytes.Buffer
+	n, err := StreamCanonicalText(&buf, messages)
+	if err != nil || n != 2 {
+		t.Fatalf("write: %d, %v", n, err)
+	}
+	want := "2025-01-02T02:04:05.006Z\t@alice:beeper.local\ttwo\\nlines\\tand a \\\\ slash\n" +
+		"2025-01-02T02:04:06.006Z\t@bob:beeper.local\tbell\\u0007\n"
+	if buf.String() != want {
+		t.Fatalf("unexpected text:\n%q\nwant\n%q", buf.String(), want)
+	}
+}
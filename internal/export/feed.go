@@ -0,0 +1,145 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+)
+
+// feedTitleLength caps the message text used as an entry title.
+const feedTitleLength = 80
+
+// Feed is an Atom feed of one thread's newest messages.
+type Feed struct {
+	Thread beeper.Thread
+	// Messages are newest first.
+	Messages []beeper.Message
+	// SelfURL, when set, is the feed's own address.
+	SelfURL string
+}
+
+// FeedLink is one feed listed in an OPML file.
+type FeedLink struct {
+	Title string
+	URL   string
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Link    *atomLink   `xml:"link,omitempty"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	ID      string     `xml:"id"`
+	Title   string     `xml:"title"`
+	Updated string     `xml:"updated"`
+	Author  atomAuthor `xml:"author"`
+	Content atomText   `xml:"content"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// WriteAtom writes f as an Atom 1.0 feed with one entry per message. IDs
+// are tag URIs derived from room and event IDs, and the feed's updated time
+// is its newest message, so the output only changes when messages do.
+func WriteAtom(w io.Writer, f Feed) error {
+	updated := f.Thread.LastMessage
+	if len(f.Messages) > 0 {
+		updated = f.Messages[0].Timestamp
+	}
+	feed := atomFeed{
+		ID:      feedTag("thread", f.Thread.ID),
+		Title:   f.Thread.DisplayName,
+		Updated: atomTime(updated),
+	}
+	if f.SelfURL != "" {
+		feed.Link = &atomLink{Rel: "self", Href: f.SelfURL}
+	}
+	for _, msg := range f.Messages {
+		author := msg.SenderName
+		if msg.IsSentByMe {
+			author = "Me"
+		} else if author == "" {
+			author = msg.SenderID
+		}
+		feed.Entries = append(feed.Entries, atomEntry{
+			ID:      feedTag("event", msg.EventID),
+			Title:   feedTitle(author, msg.Text),
+			Updated: atomTime(msg.Timestamp),
+			Author:  atomAuthor{Name: author},
+			Content: atomText{Type: "text", Body: msg.Text},
+		})
+	}
+	return writeXML(w, feed)
+}
+
+type opml struct {
+	XMLName  xml.Name      `xml:"opml"`
+	Version  string        `xml:"version,attr"`
+	Title    string        `xml:"head>title"`
+	Outlines []opmlOutline `xml:"body>outline"`
+}
+
+type opmlOutline struct {
+	Type   string `xml:"type,attr"`
+	Text   string `xml:"text,attr"`
+	Title  string `xml:"title,attr"`
+	XMLURL string `xml:"xmlUrl,attr"`
+}
+
+// WriteOPML writes an OPML 2.0 subscription list of feeds, which feed
+// readers import to follow every thread at once.
+func WriteOPML(w io.Writer, title string, feeds []FeedLink) error {
+	doc := opml{Version: "2.0", Title: title}
+	for _, feed := range feeds {
+		doc.Outlines = append(doc.Outlines, opmlOutline{Type: "rss", Text: feed.Title, Title: feed.Title, XMLURL: feed.URL})
+	}
+	return writeXML(w, doc)
+}
+
+func writeXML(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// feedTag returns a tag URI (RFC 4151) for a Matrix room or event ID.
+func feedTag(kind string, id string) string {
+	return fmt.Sprintf("tag:beeper-cli,2024:%s/%s", kind, url.PathEscape(id))
+}
+
+func atomTime(t time.Time) string {
+	return utc(t).Format(time.RFC3339)
+}
+
+// feedTitle is "Sender: text" on one line, shortened for feed readers.
+func feedTitle(author string, text string) string {
+	return author + ": " + beeper.Preview(text, feedTitleLength)
+}
@@ -0,0 +1,50 @@
+package export
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+)
+
+func TestWriteAtomAndOPML(t *testing.T) {
+	at := time.Date(2025, 3, 4, 9, 30, 0, 0, time.FixedZone("CET", 3600))
+	feed := Feed{
+		Thread: beeper.Thread{ID: "!team:beeper.local", DisplayName: "Team <Chat>"},
+		Messages: []beeper.Message{
+			{EventID: "$b", IsSentByMe: true, Timestamp: at, Text: strings.Repeat("x", 100)},
+			{EventID: "$a", SenderName: "Alice", Timestamp: at.Add(-time.Hour), Text: "good\nmorning"},
+		},
+	}
+
+	var b strings.Builder
+	if err := WriteAtom(&b, feed); err != nil {
+		t.Fatalf("write atom: %v", err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		`<feed xmlns="http://www.w3.org/2005/Atom">`,
+		"<id>tag:beeper-cli,2024:thread/%21team:beeper.local</id>",
+		"<title>Team &lt;Chat&gt;</title>",
+		"<updated>2025-03-04T08:30:00Z</updated>",
+		"<title>Me: " + strings.Repeat("x", feedTitleLength-1) + "…</title>",
+		"<title>Alice: good morning</title>",
+		"<id>tag:beeper-cli,2024:event/$a</id>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, `rel="self"`) {
+		t.Fatalf("expected no self link without SelfURL:\n%s", out)
+	}
+
+	b.Reset()
+	if err := WriteOPML(&b, "Beeper threads", []FeedLink{{Title: "Team Chat", URL: "team_beeper.local.atom"}}); err != nil {
+		t.Fatalf("write opml: %v", err)
+	}
+	if !strings.Contains(b.String(), `<outline type="rss" text="Team Chat" title="Team Chat" xmlUrl="team_beeper.local.atom">`) {
+		t.Fatalf("unexpected opml:\n%s", b.String())
+	}
+}
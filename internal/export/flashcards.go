@@ -0,0 +1,140 @@
+package export
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"html"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+)
+
+const (
+	// flashcardAnswerMessages caps how many messages form one answer.
+	flashcardAnswerMessages = 3
+	// flashcardAnswerWindow is how long after a question an answer may
+	// start.
+	flashcardAnswerWindow = 12 * time.Hour
+)
+
+var flashcardTagCleaner = strings.NewReplacer(" ", "_", "\t", "_", "\n", "_")
+
+// Flashcard is one question and answer taken from a conversation.
+type Flashcard struct {
+	// EventID is the message the card was made from: the question, or the
+	// bookmarked answer.
+	EventID string   `json:"eventId"`
+	Front   string   `json:"front"`
+	Back    string   `json:"back"`
+	Tags    []string `json:"tags,omitempty"`
+}
+
+// QuestionFlashcards pairs every question in messages (oldest first) with
+// its answer. A question is a message ending in "?"; its answer is the run
+// of messages other senders write next, up to three and starting within
+// twelve hours, until the asker writes again.
+func QuestionFlashcards(messages []beeper.Message) []Flashcard {
+	cards := []Flashcard{}
+	for i, question := range messages {
+		front := strings.TrimSpace(question.Text)
+		if !strings.HasSuffix(front, "?") {
+			continue
+		}
+		answer := []string{}
+		for _, msg := range messages[i+1:] {
+			if msg.SenderID == question.SenderID || len(answer) == flashcardAnswerMessages {
+				break
+			}
+			if len(answer) == 0 && msg.Timestamp.Sub(question.Timestamp) > flashcardAnswerWindow {
+				break
+			}
+			if text := strings.TrimSpace(msg.Text); text != "" {
+				answer = append(answer, text)
+			}
+		}
+		if len(answer) == 0 {
+			continue
+		}
+		cards = append(cards, Flashcard{EventID: question.EventID, Front: front, Back: strings.Join(answer, "\n")})
+	}
+	return cards
+}
+
+// BookmarkFlashcard makes a card from a bookmarked answer: the front is the
+// bookmark's note, else the message the answer replies to, else the last
+// message before it from someone else. messages are the thread's messages,
+// oldest first; ok is false when no front is found.
+func BookmarkFlashcard(messages []beeper.Message, answer beeper.Message, note string) (Flashcard, bool) {
+	card := Flashcard{EventID: answer.EventID, Front: strings.TrimSpace(note), Back: strings.TrimSpace(answer.Text)}
+	if card.Front == "" {
+		var previous *beeper.Message
+		for i, msg := range messages {
+			if msg.EventID == answer.EventID {
+				break
+			}
+			if answer.ReplyToEventID != "" && msg.EventID == answer.ReplyToEventID {
+				previous = &messages[i]
+				break
+			}
+			if msg.SenderID != answer.SenderID {
+				previous = &messages[i]
+			}
+		}
+		if previous != nil {
+			card.Front = strings.TrimSpace(previous.Text)
+		}
+	}
+	return card, card.Front != "" && card.Back != ""
+}
+
+// FilterFlashcards keeps the cards whose front or back contains one of
+// terms (case-insensitive); no terms keep every card.
+func FilterFlashcards(cards []Flashcard, terms []string) []Flashcard {
+	if len(terms) == 0 {
+		return cards
+	}
+	kept := []Flashcard{}
+	for _, card := range cards {
+		text := strings.ToLower(card.Front + "\n" + card.Back)
+		for _, term := range terms {
+			if strings.Contains(text, strings.ToLower(term)) {
+				kept = append(kept, card)
+				break
+			}
+		}
+	}
+	return kept
+}
+
+// FlashcardTag turns a name into an Anki tag, which cannot contain spaces.
+func FlashcardTag(name string) string {
+	return flashcardTagCleaner.Replace(strings.TrimSpace(name))
+}
+
+// WriteAnkiTSV writes cards as a tab-separated file Anki imports as notes
+// with Front and Back fields (File > Import). Header lines tell Anki the
+// separator, that fields are HTML, which column holds tags, and a GUID
+// column derived from the event ID, so importing the file again updates
+// cards instead of duplicating them.
+func WriteAnkiTSV(w io.Writer, cards []Flashcard) error {
+	var b strings.Builder
+	b.WriteString("#separator:tab\n#html:true\n#guid column:1\n#tags column:4\n")
+	for _, card := range cards {
+		sum := sha256.Sum256([]byte(card.EventID))
+		b.WriteString("bcli-" + hex.EncodeToString(sum[:6]))
+		b.WriteString("\t" + ankiField(card.Front))
+		b.WriteString("\t" + ankiField(card.Back))
+		b.WriteString("\t" + strings.Join(card.Tags, " ") + "\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// ankiField escapes text for an HTML field on one line.
+func ankiField(text string) string {
+	text = html.EscapeString(strings.ReplaceAll(text, "\t", " "))
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.ReplaceAll(text, "\n", "<br>")
+}
@@ -0,0 +1,67 @@
+package export
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+)
+
+func TestFlashcards(t *testing.T) {
+	at := time.Date(2025, 3, 4, 9, 0, 0, 0, time.UTC)
+	msg := func(id string, sender string, minutes int, text string) beeper.Message {
+		return beeper.Message{EventID: id, SenderID: sender, Timestamp: at.Add(time.Duration(minutes) * time.Minute), Text: text}
+	}
+	messages := []beeper.Message{
+		msg("$q1", "@me", 0, "How do you say <cat> in German?"),
+		msg("$a1", "@tutor", 1, "die Katze"),
+		msg("$a2", "@tutor", 2, "plural:\tdie Katzen"),
+		msg("$m1", "@me", 3, "thanks"),
+		msg("$q2", "@me", 4, "And dog?"),
+		msg("$q3", "@tutor", 24*60, "Vocab quiz tomorrow?"),
+		msg("$a3", "@me", 24*60+1, "Sure, vocab at 10"),
+		msg("$q4", "@tutor", 3*24*60, "Anyone there?"),
+	}
+
+	cards := QuestionFlashcards(messages)
+	if len(cards) != 2 {
+		t.Fatalf("expected 2 cards (unanswered and late questions skipped), got %+v", cards)
+	}
+	if cards[0].EventID != "$q1" || cards[0].Back != "die Katze\nplural:\tdie Katzen" {
+		t.Fatalf("unexpected first card: %+v", cards[0])
+	}
+	if got := FilterFlashcards(cards, []string{"VOCAB"}); len(got) != 1 || got[0].EventID != "$q3" {
+		t.Fatalf("expected only the vocab card, got %+v", got)
+	}
+
+	card, ok := BookmarkFlashcard(messages, messages[2], "")
+	if !ok || card.Front != "How do you say <cat> in German?" || card.Back != "plural:\tdie Katzen" {
+		t.Fatalf("unexpected bookmark card: %+v, %v", card, ok)
+	}
+	reply := msg("$r", "@tutor", 5, "der Hund")
+	reply.ReplyToEventID = "$q2"
+	if card, ok := BookmarkFlashcard(messages, reply, "dog"); !ok || card.Front != "dog" {
+		t.Fatalf("expected the note as front, got %+v", card)
+	}
+	if _, ok := BookmarkFlashcard(messages, messages[0], ""); ok {
+		t.Fatal("expected no card without an earlier message from someone else")
+	}
+
+	cards[0].Tags = []string{"beeper", FlashcardTag("German Tutor")}
+	var b strings.Builder
+	if err := WriteAnkiTSV(&b, cards[:1]); err != nil {
+		t.Fatalf("write tsv: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	if len(lines) != 5 || lines[0] != "#separator:tab" || lines[3] != "#tags column:4" {
+		t.Fatalf("unexpected header:\n%s", b.String())
+	}
+	fields := strings.Split(lines[4], "\t")
+	if len(fields) != 4 || !strings.HasPrefix(fields[0], "bcli-") ||
+		fields[1] != "How do you say &lt;cat&gt; in German?" ||
+		fields[2] != "die Katze<br>plural: die Katzen" ||
+		fields[3] != "beeper German_Tutor" {
+		t.Fatalf("unexpected row: %q", fields)
+	}
+}
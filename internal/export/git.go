@@ -0,0 +1,203 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// GitIndexFile is the archive index committed next to the transcripts.
+const GitIndexFile = "threads.json"
+
+// GitThread is one thread's entry in the archive index.
+type GitThread struct {
+	ThreadID    string    `json:"threadId"`
+	Name        string    `json:"name"`
+	AccountID   string    `json:"accountId,omitempty"`
+	File        string    `json:"file"`
+	Messages    int       `json:"messages"`
+	LastMessage time.Time `json:"lastMessage,omitempty"`
+}
+
+// GitRepo runs git in an archive directory.
+type GitRepo struct {
+	Dir string
+}
+
+// OpenGitRepo creates dir if needed and initializes a repository there
+// unless one exists.
+func OpenGitRepo(dir string) (*GitRepo, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, errors.New("git not found in PATH")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	repo := &GitRepo{Dir: dir}
+	if _, err := os.Stat(filepath.Join(dir, ".git")); errors.Is(err, os.ErrNotExist) {
+		if _, err := repo.git("init", "--quiet"); err != nil {
+			return nil, err
+		}
+	}
+	return repo, nil
+}
+
+// ReadIndex returns the threads recorded by the previous run, keyed by
+// thread ID. A missing index yields an empty map.
+func (r *GitRepo) ReadIndex() (map[string]GitThread, error) {
+	data, err := os.ReadFile(filepath.Join(r.Dir, GitIndexFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]GitThread{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var threads []GitThread
+	if err := json.Unmarshal(data, &threads); err != nil {
+		return nil, fmt.Errorf("read %s: %w", GitIndexFile, err)
+	}
+	index := make(map[string]GitThread, len(threads))
+	for _, t := range threads {
+		index[t.ThreadID] = t
+	}
+	return index, nil
+}
+
+// WriteIndex writes the index sorted by file name.
+func (r *GitRepo) WriteIndex(index map[string]GitThread) error {
+	threads := make([]GitThread, 0, len(index))
+	for _, t := range index {
+		threads = append(threads, t)
+	}
+	sort.Slice(threads, func(i, j int) bool { return threads[i].File < threads[j].File })
+	data, err := json.MarshalIndent(threads, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(r.Dir, GitIndexFile), append(data, '\n'), 0o644)
+}
+
+// Commit stages the given files, relative to the repository, and commits
+// them with message. Other changes in the repository, staged or not, are
+// left out of the commit. It returns the new commit hash, or "" when none
+// of the files changed.
+func (r *GitRepo) Commit(message string, files []string) (string, error) {
+	if len(files) == 0 {
+		return "", nil
+	}
+	if _, err := r.git(append([]string{"add", "--"}, files...)...); err != nil {
+		return "", err
+	}
+	status, err := r.git(append([]string{"status", "--porcelain", "--"}, files...)...)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(status) == "" {
+		return "", nil
+	}
+	args := []string{}
+	// Commit even where git has no identity configured (e.g. cron jobs).
+	if email, _ := r.git("config", "user.email"); strings.TrimSpace(email) == "" {
+		args = append(args, "-c", "user.name=beeper-cli", "-c", "user.email=beeper-cli@localhost")
+	}
+	args = append(args, "commit", "--quiet", "--file", "-", "--")
+	args = append(args, files...)
+	if _, err := r.gitInput(message, args...); err != nil {
+		return "", err
+	}
+	hash, err := r.git("rev-parse", "HEAD")
+	return strings.TrimSpace(hash), err
+}
+
+func (r *GitRepo) git(args ...string) (string, error) {
+	return r.gitInput("", args...)
+}
+
+func (r *GitRepo) gitInput(input string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.Dir
+	cmd.Stdin = strings.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		// git config exits 1 for unset keys; callers treat that as empty.
+		if len(args) > 0 && args[0] == "config" {
+			return "", nil
+		}
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// GitCommitMessage summarizes how the archive changed between two indexes:
+// a subject with the number of new messages and threads, and one line per
+// changed thread.
+func GitCommitMessage(prev, next map[string]GitThread) string {
+	type change struct {
+		name  string
+		line  string
+		added int
+	}
+	changes := []change{}
+	added, newThreads := 0, 0
+	for id, t := range next {
+		old, ok := prev[id]
+		switch {
+		case !ok:
+			newThreads++
+			added += t.Messages
+			changes = append(changes, change{t.Name, fmt.Sprintf("- %s: new thread, %d messages", t.Name, t.Messages), t.Messages})
+		case t.Messages != old.Messages:
+			delta := t.Messages - old.Messages
+			added += max(delta, 0)
+			changes = append(changes, change{t.Name, fmt.Sprintf("- %s: %+d messages (%d total)", t.Name, delta, t.Messages), delta})
+		case t.Name != old.Name:
+			changes = append(changes, change{t.Name, fmt.Sprintf("- %s: renamed from %s", t.Name, old.Name), 0})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].added != changes[j].added {
+			return changes[i].added > changes[j].added
+		}
+		return changes[i].name < changes[j].name
+	})
+
+	parts := []string{}
+	if added > 0 {
+		parts = append(parts, fmt.Sprintf("%d new %s", added, plural(added, "message", "messages")))
+	}
+	updated := len(changes) - newThreads
+	if updated > 0 {
+		parts = append(parts, fmt.Sprintf("%d %s updated", updated, plural(updated, "thread", "threads")))
+	}
+	if newThreads > 0 {
+		parts = append(parts, fmt.Sprintf("%d new %s", newThreads, plural(newThreads, "thread", "threads")))
+	}
+	subject := "Archive chats"
+	if len(parts) > 0 {
+		subject += ": " + strings.Join(parts, ", ")
+	}
+	if len(changes) == 0 {
+		return subject + "\n"
+	}
+	lines := make([]string, 0, len(changes))
+	for _, c := range changes {
+		lines = append(lines, c.line)
+	}
+	return subject + "\n\n" + strings.Join(lines, "\n") + "\n"
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
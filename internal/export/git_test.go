@@ -0,0 +1,75 @@
+package export
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGitCommitMessage(t *testing.T) {
+	prev := map[string]GitThread{
+		"!team": {ThreadID: "!team", Name: "Team Chat", Messages: 10},
+		"!trip": {ThreadID: "!trip", Name: "Trip", Messages: 4},
+	}
+	next := map[string]GitThread{
+		"!team":  {ThreadID: "!team", Name: "Team Chat", Messages: 15},
+		"!trip":  {ThreadID: "!trip", Name: "Trip", Messages: 4},
+		"!alice": {ThreadID: "!alice", Name: "Alice", Messages: 2},
+	}
+	expected := "Archive chats: 7 new messages, 1 thread updated, 1 new thread\n\n" +
+		"- Team Chat: +5 messages (15 total)\n" +
+		"- Alice: new thread, 2 messages\n"
+	if msg := GitCommitMessage(prev, next); msg != expected {
+		t.Fatalf("unexpected message:\n%s", msg)
+	}
+}
+
+func TestGitRepoCommit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := filepath.Join(t.TempDir(), "archive")
+	repo, err := OpenGitRepo(dir)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	index := map[string]GitThread{"!team": {ThreadID: "!team", Name: "Team Chat", File: "team.md", Messages: 1}}
+	if err := os.WriteFile(filepath.Join(dir, "team.md"), []byte("# Team Chat\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.WriteIndex(index); err != nil {
+		t.Fatalf("write index: %v", err)
+	}
+	// Unrelated files, staged or not, stay out of the archive commit.
+	for _, name := range []string{"notes.txt", "staged.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("mine\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := repo.git("add", "staged.txt"); err != nil {
+		t.Fatal(err)
+	}
+	files := []string{"team.md", GitIndexFile}
+	hash, err := repo.Commit(GitCommitMessage(map[string]GitThread{}, index), files)
+	if err != nil || hash == "" {
+		t.Fatalf("commit: %q %v", hash, err)
+	}
+	committed, err := repo.git("show", "--name-only", "--format=", "HEAD")
+	if err != nil || strings.Fields(committed)[0] != "team.md" || len(strings.Fields(committed)) != 2 {
+		t.Fatalf("unexpected committed files %q %v", committed, err)
+	}
+	if hash, err := repo.Commit("nothing", files); err != nil || hash != "" {
+		t.Fatalf("expected no commit for unchanged tree, got %q %v", hash, err)
+	}
+
+	read, err := repo.ReadIndex()
+	if err != nil || read["!team"].Messages != 1 {
+		t.Fatalf("read index: %+v %v", read, err)
+	}
+	subject, err := repo.git("log", "-1", "--format=%s")
+	if err != nil || !strings.HasPrefix(subject, "Archive chats: 1 new message") {
+		t.Fatalf("unexpected subject %q %v", subject, err)
+	}
+}
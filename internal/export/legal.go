@@ -0,0 +1,312 @@
+package export
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+)
+
+const legalTimeLayout = "2006-01-02 15:04:05 MST"
+
+// LegalBundle is the content of a legal hold export.
+type LegalBundle struct {
+	Thread   beeper.Thread
+	Messages []beeper.Message
+	Events   []beeper.RawEvent
+	Media    []beeper.Attachment
+	From     *time.Time
+	To       *time.Time
+}
+
+// ManifestEntry records the SHA-256 digest of one bundle file.
+type ManifestEntry struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// LegalSummary describes a legal hold bundle and is signed with Ed25519.
+type LegalSummary struct {
+	ThreadID     string     `json:"threadId"`
+	ThreadName   string     `json:"threadName"`
+	AccountID    string     `json:"accountId"`
+	From         *time.Time `json:"from,omitempty"`
+	To           *time.Time `json:"to,omitempty"`
+	GeneratedAt  time.Time  `json:"generatedAt"`
+	Messages     int        `json:"messages"`
+	Events       int        `json:"events"`
+	MediaFiles   int        `json:"mediaFiles"`
+	MissingMedia []string   `json:"missingMedia,omitempty"`
+	// LossyLines counts transcript lines with characters outside Latin-1,
+	// which the PDF shows as "?"; events.json has their exact text.
+	LossyLines     int    `json:"lossyLines,omitempty"`
+	ManifestSHA256 string `json:"manifestSha256"`
+	PublicKey      string `json:"publicKey"`
+}
+
+// WriteLegalBundle writes transcript.pdf, events.json, media/, manifest.json,
+// summary.json, and summary.sig into dir. The manifest lists a SHA-256 digest
+// for every content file; the summary embeds the manifest digest and is
+// signed with key. dir must not exist or be empty, so the manifest never
+// vouches for files this run did not write.
+func WriteLegalBundle(dir string, bundle LegalBundle, key ed25519.PrivateKey, now time.Time) (LegalSummary, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return LegalSummary{}, err
+	}
+	if len(entries) > 0 {
+		return LegalSummary{}, fmt.Errorf("bundle directory %s is not empty; choose a new --out", dir)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "media"), 0o755); err != nil {
+		return LegalSummary{}, err
+	}
+
+	summary := LegalSummary{
+		ThreadID:    bundle.Thread.ID,
+		ThreadName:  bundle.Thread.DisplayName,
+		AccountID:   bundle.Thread.AccountID,
+		From:        bundle.From,
+		To:          bundle.To,
+		GeneratedAt: now.UTC(),
+		Messages:    len(bundle.Messages),
+		Events:      len(bundle.Events),
+		PublicKey:   hex.EncodeToString(key.Public().(ed25519.PublicKey)),
+	}
+
+	pdf, err := os.Create(filepath.Join(dir, "transcript.pdf"))
+	if err != nil {
+		return LegalSummary{}, err
+	}
+	transcript := legalTranscript(bundle, now)
+	for _, line := range transcript {
+		if pdfLossy(line) {
+			summary.LossyLines++
+		}
+	}
+	if summary.LossyLines > 0 {
+		note := fmt.Sprintf("Note: %d lines have characters shown as \"?\"; events.json holds the exact text.", summary.LossyLines)
+		transcript = slices.Insert(transcript, 5, note)
+	}
+	if err := WritePDF(pdf, "Transcript: "+bundle.Thread.DisplayName, transcript); err != nil {
+		_ = pdf.Close()
+		return LegalSummary{}, err
+	}
+	if err := pdf.Close(); err != nil {
+		return LegalSummary{}, err
+	}
+
+	if err := writeJSONFile(filepath.Join(dir, "events.json"), bundle.Events); err != nil {
+		return LegalSummary{}, err
+	}
+
+	for i, att := range bundle.Media {
+		if att.LocalPath == "" {
+			summary.MissingMedia = append(summary.MissingMedia, att.EventID)
+			continue
+		}
+		name := fmt.Sprintf("%04d-%s", i+1, mediaFileName(att))
+		if err := copyFile(att.LocalPath, filepath.Join(dir, "media", name)); err != nil {
+			return LegalSummary{}, fmt.Errorf("copy media %s: %w", att.EventID, err)
+		}
+		summary.MediaFiles++
+	}
+
+	manifest, err := BuildManifest(dir, "manifest.json", "summary.json", "summary.sig")
+	if err != nil {
+		return LegalSummary{}, err
+	}
+	if err := writeJSONFile(filepath.Join(dir, "manifest.json"), manifest); err != nil {
+		return LegalSummary{}, err
+	}
+	manifestSum, err := fileSHA256(filepath.Join(dir, "manifest.json"))
+	if err != nil {
+		return LegalSummary{}, err
+	}
+	summary.ManifestSHA256 = manifestSum
+
+	summaryBytes, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return LegalSummary{}, err
+	}
+	summaryBytes = append(summaryBytes, '\n')
+	if err := os.WriteFile(filepath.Join(dir, "summary.json"), summaryBytes, 0o644); err != nil {
+		return LegalSummary{}, err
+	}
+	signature := ed25519.Sign(key, summaryBytes)
+	if err := os.WriteFile(filepath.Join(dir, "summary.sig"), []byte(hex.EncodeToString(signature)+"\n"), 0o644); err != nil {
+		return LegalSummary{}, err
+	}
+
+	return summary, nil
+}
+
+// BuildManifest hashes every regular file under dir, skipping the named
+// top-level files, and returns entries sorted by slash-separated path.
+func BuildManifest(dir string, skip ...string) ([]ManifestEntry, error) {
+	skipped := map[string]bool{}
+	for _, name := range skip {
+		skipped[name] = true
+	}
+	entries := []ManifestEntry{}
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if skipped[rel] {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		sum, err := fileSHA256(path)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, ManifestEntry{Path: rel, SHA256: sum, Size: info.Size()})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	return entries, nil
+}
+
+// LoadSigningKey reads an Ed25519 key stored as a hex or base64 encoded
+// 32-byte seed or 64-byte private key.
+func LoadSigningKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	raw := strings.TrimSpace(string(data))
+	decoded, err := hex.DecodeString(raw)
+	if err != nil {
+		decoded, err = base64.StdEncoding.DecodeString(raw)
+		if err != nil {
+			return nil, errors.New("signing key must be hex or base64 encoded")
+		}
+	}
+	switch len(decoded) {
+	case ed25519.SeedSize:
+		return ed25519.NewKeyFromSeed(decoded), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(decoded), nil
+	default:
+		return nil, fmt.Errorf("signing key has %d bytes; expected %d or %d", len(decoded), ed25519.SeedSize, ed25519.PrivateKeySize)
+	}
+}
+
+// GenerateSigningKey creates a fresh Ed25519 key.
+func GenerateSigningKey() (ed25519.PrivateKey, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	return key, err
+}
+
+func legalTranscript(bundle LegalBundle, now time.Time) []string {
+	rangeText := "all messages"
+	if bundle.From != nil || bundle.To != nil {
+		from, to := "beginning", "now"
+		if bundle.From != nil {
+			from = bundle.From.UTC().Format(legalTimeLayout)
+		}
+		if bundle.To != nil {
+			to = bundle.To.UTC().Format(legalTimeLayout)
+		}
+		rangeText = from + " to " + to
+	}
+
+	lines := []string{
+		"Thread ID: " + bundle.Thread.ID,
+		"Account: " + bundle.Thread.AccountID,
+		"Range: " + rangeText,
+		"Generated: " + now.UTC().Format(legalTimeLayout),
+		fmt.Sprintf("Messages: %d", len(bundle.Messages)),
+		"",
+	}
+	for _, msg := range bundle.Messages {
+		sender := msg.SenderName
+		if sender == "" {
+			sender = msg.SenderID
+		}
+		textLines := strings.Split(msg.Text, "\n")
+		lines = append(lines, fmt.Sprintf("%s | %s | %s", msg.Timestamp.UTC().Format(legalTimeLayout), sender, textLines[0]))
+		for _, extra := range textLines[1:] {
+			lines = append(lines, "    "+extra)
+		}
+	}
+	return lines
+}
+
+func mediaFileName(att beeper.Attachment) string {
+	name := att.FileName
+	if name == "" {
+		name = att.EventID
+	}
+	return FileName(name, "")
+}
+
+func writeJSONFile(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+func copyFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = in.Close()
+	}()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,100 @@
+package export
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+)
+
+func TestWriteLegalBundleSignsSummary(t *testing.T) {
+	dir := t.TempDir()
+	mediaSrc := filepath.Join(t.TempDir(), "blob")
+	if err := os.WriteFile(mediaSrc, []byte("contract"), 0o644); err != nil {
+		t.Fatalf("write media: %v", err)
+	}
+	key, err := GenerateSigningKey()
+	if err != nil {
+		t.Fatalf("key: %v", err)
+	}
+
+	ts := time.UnixMilli(1700000000000)
+	summary, err := WriteLegalBundle(dir, LegalBundle{
+		Thread: beeper.Thread{ID: "!room1:beeper.local", DisplayName: "Team Chat"},
+		Messages: []beeper.Message{
+			{EventID: "$1", SenderName: "Alice", Timestamp: ts, Text: "hello (world)"},
+			{EventID: "$4", SenderName: "Zoë", Timestamp: ts, Text: "Привет 🎉"},
+		},
+		Events: []beeper.RawEvent{{EventID: "$1", Timestamp: ts, Type: "TEXT"}},
+		Media: []beeper.Attachment{
+			{EventID: "$2", FileName: "contract.pdf", LocalPath: mediaSrc},
+			{EventID: "$3", FileName: "missing.jpg"},
+		},
+	}, key, ts)
+	if err != nil {
+		t.Fatalf("write bundle: %v", err)
+	}
+	if summary.MediaFiles != 1 || len(summary.MissingMedia) != 1 || summary.LossyLines != 1 {
+		t.Fatalf("unexpected media counts: %+v", summary)
+	}
+
+	summaryBytes, err := os.ReadFile(filepath.Join(dir, "summary.json"))
+	if err != nil {
+		t.Fatalf("read summary: %v", err)
+	}
+	sigHex, err := os.ReadFile(filepath.Join(dir, "summary.sig"))
+	if err != nil {
+		t.Fatalf("read signature: %v", err)
+	}
+	sig, err := hex.DecodeString(strings.TrimSpace(string(sigHex)))
+	if err != nil {
+		t.Fatalf("decode signature: %v", err)
+	}
+	if !ed25519.Verify(key.Public().(ed25519.PublicKey), summaryBytes, sig) {
+		t.Fatalf("signature does not verify")
+	}
+
+	manifest, err := BuildManifest(dir, "manifest.json", "summary.json", "summary.sig")
+	if err != nil {
+		t.Fatalf("manifest: %v", err)
+	}
+	paths := []string{}
+	for _, entry := range manifest {
+		paths = append(paths, entry.Path)
+	}
+	if strings.Join(paths, ",") != "events.json,media/0001-contract.pdf,transcript.pdf" {
+		t.Fatalf("unexpected manifest paths: %v", paths)
+	}
+
+	pdf, err := os.ReadFile(filepath.Join(dir, "transcript.pdf"))
+	if err != nil {
+		t.Fatalf("read pdf: %v", err)
+	}
+	if !bytes.HasPrefix(pdf, []byte("%PDF-1.4")) || !bytes.Contains(pdf, []byte(`hello \(world\)`)) || !bytes.Contains(pdf, []byte("events.json holds the exact text")) {
+		t.Fatalf("unexpected pdf content")
+	}
+}
+
+func TestWriteLegalBundleRefusesNonEmptyDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("unrelated"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	key, err := GenerateSigningKey()
+	if err != nil {
+		t.Fatalf("key: %v", err)
+	}
+	_, err = WriteLegalBundle(dir, LegalBundle{Thread: beeper.Thread{ID: "!room1:beeper.local"}}, key, time.Now())
+	if err == nil || !strings.Contains(err.Error(), "not empty") {
+		t.Fatalf("expected a non-empty directory error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "manifest.json")); !os.IsNotExist(err) {
+		t.Fatalf("expected no bundle to be written, got %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/KrauseFx/beeper-cli/internal/i18n"
+)
+
+// WriteMarkdown renders a thread export as a chronological Markdown
+// transcript with one heading per day. Timestamps use the local time zone.
+// Messages are expected oldest first and rendered in rich format so
+// attachments appear as placeholders such as "[Image]".
+func WriteMarkdown(w io.Writer, export ThreadExport) error {
+	return WriteLocalizedMarkdown(w, export, i18n.English)
+}
+
+// WriteLocalizedMarkdown is WriteMarkdown with day headings, dates,
+// attachment placeholders, and the summary line in loc's language.
+func WriteLocalizedMarkdown(w io.Writer, export ThreadExport, loc *i18n.Locale) error {
+	var b strings.Builder
+	title := export.Thread.DisplayName
+	if title == "" {
+		title = export.Thread.ID
+	}
+	fmt.Fprintf(&b, "# %s\n\n", title)
+
+	meta := []string{}
+	if export.Thread.AccountID != "" {
+		meta = append(meta, export.Thread.AccountID)
+	}
+	names := []string{}
+	for _, p := range export.Thread.Participants {
+		if !p.IsSelf && p.Name != "" {
+			names = append(names, p.Name)
+		}
+	}
+	if len(names) > 0 {
+		meta = append(meta, loc.Tf("with %s", strings.Join(names, ", ")))
+	}
+	meta = append(meta, loc.Tf("%d messages", len(export.Messages)))
+	if n := len(export.Messages); n > 0 {
+		first := loc.Format(export.Messages[0].Timestamp.Local(), loc.Date)
+		last := loc.Format(export.Messages[n-1].Timestamp.Local(), loc.Date)
+		meta = append(meta, loc.Tf("%s to %s", first, last))
+	}
+	fmt.Fprintf(&b, "_%s_\n", strings.Join(meta, " · "))
+
+	day := ""
+	for _, msg := range export.Messages {
+		ts := msg.Timestamp.Local()
+		if d := ts.Format("2006-01-02"); d != day {
+			day = d
+			fmt.Fprintf(&b, "\n## %s\n", loc.Format(ts, loc.LongDate))
+		}
+		sender := msg.SenderName
+		if sender == "" {
+			sender = msg.SenderID
+		}
+		if msg.IsSentByMe {
+			sender = loc.T("Me")
+		}
+		fmt.Fprintf(&b, "\n**%s %s:** %s\n", ts.Format("15:04"), markdownEscape(sender), markdownText(loc.Text(msg.Text)))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// markdownText keeps line breaks inside a message as hard breaks so a
+// multi-line message stays one paragraph.
+func markdownText(text string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.Join(lines, "  \n")
+}
+
+func markdownEscape(s string) string {
+	return strings.NewReplacer("*", `\*`, "_", `\_`, "`", "\\`").Replace(s)
+}
@@ -0,0 +1,485 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// ParquetType is the logical type of a Parquet column.
+type ParquetType int
+
+const (
+	ParquetString ParquetType = iota
+	ParquetInt64
+	ParquetBool
+	ParquetDouble
+	// ParquetTimestamp is stored as INT64 milliseconds since the Unix epoch (UTC).
+	ParquetTimestamp
+)
+
+// ParquetColumn describes one column of a flat Parquet schema.
+type ParquetColumn struct {
+	Name     string
+	Type     ParquetType
+	Optional bool
+}
+
+// parquetRowGroupSize bounds how many rows are buffered before a row group is flushed.
+const parquetRowGroupSize = 64 * 1024
+
+// Physical types, converted types, and enums from parquet.thrift.
+const (
+	parquetPhysBoolean   = 0
+	parquetPhysInt64     = 2
+	parquetPhysDouble    = 5
+	parquetPhysByteArray = 6
+
+	parquetConvertedUTF8            = 0
+	parquetConvertedTimestampMillis = 9
+
+	parquetRequired = 0
+	parquetOptional = 1
+
+	parquetEncodingPlain = 0
+	parquetEncodingRLE   = 3
+
+	parquetPageData = 0
+)
+
+// ParquetWriter writes a flat table as an uncompressed, PLAIN-encoded Parquet
+// file. Rows are buffered and flushed as row groups; Close writes the footer.
+type ParquetWriter struct {
+	w         *countingWriter
+	columns   []ParquetColumn
+	chunks    []parquetChunk
+	rows      int64
+	totalRows int64
+	groups    []parquetRowGroup
+	started   bool
+	closed    bool
+}
+
+type parquetChunk struct {
+	values  bytes.Buffer
+	bools   []bool
+	defined []bool
+}
+
+type parquetRowGroup struct {
+	rows    int64
+	size    int64
+	columns []parquetColumnMeta
+}
+
+type parquetColumnMeta struct {
+	offset int64
+	size   int64
+	values int64
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// NewParquetWriter returns a writer for the given columns.
+func NewParquetWriter(w io.Writer, columns []ParquetColumn) *ParquetWriter {
+	return &ParquetWriter{
+		w:       &countingWriter{w: w},
+		columns: columns,
+		chunks:  make([]parquetChunk, len(columns)),
+	}
+}
+
+// WriteRow appends one row. Values must match the column types: string,
+// int/int64, bool, float64, or time.Time. nil (or a zero time.Time) is
+// written as null and is only allowed in optional columns.
+func (p *ParquetWriter) WriteRow(values ...any) error {
+	if p.closed {
+		return fmt.Errorf("parquet writer is closed")
+	}
+	if len(values) != len(p.columns) {
+		return fmt.Errorf("parquet row has %d values, want %d", len(values), len(p.columns))
+	}
+	for i, col := range p.columns {
+		if err := p.chunks[i].append(col, values[i]); err != nil {
+			return err
+		}
+	}
+	p.rows++
+	if p.rows >= parquetRowGroupSize {
+		return p.flush()
+	}
+	return nil
+}
+
+// Rows returns the number of rows written so far.
+func (p *ParquetWriter) Rows() int64 {
+	return p.totalRows + p.rows
+}
+
+// Close flushes buffered rows and writes the file footer.
+func (p *ParquetWriter) Close() error {
+	if p.closed {
+		return nil
+	}
+	if p.rows > 0 || !p.started {
+		if err := p.flush(); err != nil {
+			return err
+		}
+	}
+	p.closed = true
+
+	footer := p.fileMetadata()
+	if _, err := p.w.Write(footer); err != nil {
+		return err
+	}
+	var length [4]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(len(footer)))
+	if _, err := p.w.Write(length[:]); err != nil {
+		return err
+	}
+	_, err := p.w.Write([]byte("PAR1"))
+	return err
+}
+
+func (c *parquetChunk) append(col ParquetColumn, value any) error {
+	if t, ok := value.(time.Time); ok && t.IsZero() && col.Optional {
+		value = nil
+	}
+	if value == nil {
+		if !col.Optional {
+			return fmt.Errorf("parquet column %s is required", col.Name)
+		}
+		c.defined = append(c.defined, false)
+		return nil
+	}
+	if col.Optional {
+		c.defined = append(c.defined, true)
+	}
+
+	switch col.Type {
+	case ParquetString:
+		s, ok := value.(string)
+		if !ok {
+			return columnTypeError(col, value)
+		}
+		var length [4]byte
+		binary.LittleEndian.PutUint32(length[:], uint32(len(s)))
+		c.values.Write(length[:])
+		c.values.WriteString(s)
+	case ParquetInt64:
+		var n int64
+		switch v := value.(type) {
+		case int:
+			n = int64(v)
+		case int64:
+			n = v
+		default:
+			return columnTypeError(col, value)
+		}
+		_ = binary.Write(&c.values, binary.LittleEndian, n)
+	case ParquetBool:
+		b, ok := value.(bool)
+		if !ok {
+			return columnTypeError(col, value)
+		}
+		c.bools = append(c.bools, b)
+	case ParquetDouble:
+		f, ok := value.(float64)
+		if !ok {
+			return columnTypeError(col, value)
+		}
+		_ = binary.Write(&c.values, binary.LittleEndian, math.Float64bits(f))
+	case ParquetTimestamp:
+		t, ok := value.(time.Time)
+		if !ok {
+			return columnTypeError(col, value)
+		}
+		_ = binary.Write(&c.values, binary.LittleEndian, t.UnixMilli())
+	default:
+		return fmt.Errorf("parquet column %s has unknown type %d", col.Name, col.Type)
+	}
+	return nil
+}
+
+func columnTypeError(col ParquetColumn, value any) error {
+	return fmt.Errorf("parquet column %s: unexpected value type %T", col.Name, value)
+}
+
+// body returns the page payload: definition levels (optional columns only)
+// followed by PLAIN-encoded values.
+func (c *parquetChunk) body(col ParquetColumn) []byte {
+	var buf bytes.Buffer
+	if col.Optional {
+		levels := encodeLevels(c.defined)
+		var length [4]byte
+		binary.LittleEndian.PutUint32(length[:], uint32(len(levels)))
+		buf.Write(length[:])
+		buf.Write(levels)
+	}
+	if col.Type == ParquetBool {
+		packed := make([]byte, (len(c.bools)+7)/8)
+		for i, b := range c.bools {
+			if b {
+				packed[i/8] |= 1 << (i % 8)
+			}
+		}
+		buf.Write(packed)
+	} else {
+		buf.Write(c.values.Bytes())
+	}
+	return buf.Bytes()
+}
+
+func (c *parquetChunk) reset() {
+	c.values.Reset()
+	c.bools = c.bools[:0]
+	c.defined = c.defined[:0]
+}
+
+// encodeLevels writes definition levels (bit width 1) as RLE runs of the
+// RLE/bit-packed hybrid encoding.
+func encodeLevels(defined []bool) []byte {
+	var buf bytes.Buffer
+	for start := 0; start < len(defined); {
+		end := start
+		for end < len(defined) && defined[end] == defined[start] {
+			end++
+		}
+		writeUvarint(&buf, uint64(end-start)<<1)
+		if defined[start] {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+		start = end
+	}
+	return buf.Bytes()
+}
+
+func (p *ParquetWriter) flush() error {
+	if !p.started {
+		if _, err := p.w.Write([]byte("PAR1")); err != nil {
+			return err
+		}
+		p.started = true
+	}
+	if p.rows == 0 {
+		return nil
+	}
+
+	group := parquetRowGroup{rows: p.rows}
+	for i, col := range p.columns {
+		body := p.chunks[i].body(col)
+		header := pageHeader(len(body), p.rows)
+		meta := parquetColumnMeta{offset: p.w.n, size: int64(len(header) + len(body)), values: p.rows}
+		if _, err := p.w.Write(header); err != nil {
+			return err
+		}
+		if _, err := p.w.Write(body); err != nil {
+			return err
+		}
+		group.size += meta.size
+		group.columns = append(group.columns, meta)
+		p.chunks[i].reset()
+	}
+	p.groups = append(p.groups, group)
+	p.totalRows += p.rows
+	p.rows = 0
+	return nil
+}
+
+func pageHeader(size int, values int64) []byte {
+	c := &compactWriter{}
+	c.beginStruct()
+	c.i32Field(1, parquetPageData)
+	c.i32Field(2, int32(size))
+	c.i32Field(3, int32(size))
+	c.structField(5)
+	c.i32Field(1, int32(values))
+	c.i32Field(2, parquetEncodingPlain)
+	c.i32Field(3, parquetEncodingRLE)
+	c.i32Field(4, parquetEncodingRLE)
+	c.endStruct()
+	c.endStruct()
+	return c.buf.Bytes()
+}
+
+func (p *ParquetWriter) fileMetadata() []byte {
+	c := &compactWriter{}
+	c.beginStruct()
+	c.i32Field(1, 1)
+
+	c.listField(2, compactStruct, len(p.columns)+1)
+	c.beginStruct()
+	c.stringField(4, "schema")
+	c.i32Field(5, int32(len(p.columns)))
+	c.endStruct()
+	for _, col := range p.columns {
+		physical, converted := parquetTypes(col.Type)
+		c.beginStruct()
+		c.i32Field(1, physical)
+		repetition := int32(parquetRequired)
+		if col.Optional {
+			repetition = parquetOptional
+		}
+		c.i32Field(3, repetition)
+		c.stringField(4, col.Name)
+		if converted >= 0 {
+			c.i32Field(6, converted)
+		}
+		c.endStruct()
+	}
+
+	c.i64Field(3, p.totalRows)
+
+	c.listField(4, compactStruct, len(p.groups))
+	for _, group := range p.groups {
+		c.beginStruct()
+		c.listField(1, compactStruct, len(group.columns))
+		for i, meta := range group.columns {
+			col := p.columns[i]
+			physical, _ := parquetTypes(col.Type)
+			c.beginStruct()
+			c.i64Field(2, meta.offset)
+			c.structField(3)
+			c.i32Field(1, physical)
+			encodings := []int32{parquetEncodingPlain}
+			if col.Optional {
+				encodings = append(encodings, parquetEncodingRLE)
+			}
+			c.listField(2, compactI32, len(encodings))
+			for _, e := range encodings {
+				c.i32(e)
+			}
+			c.listField(3, compactBinary, 1)
+			c.binary(col.Name)
+			c.i32Field(4, 0) // UNCOMPRESSED
+			c.i64Field(5, meta.values)
+			c.i64Field(6, meta.size)
+			c.i64Field(7, meta.size)
+			c.i64Field(9, meta.offset)
+			c.endStruct()
+			c.endStruct()
+		}
+		c.i64Field(2, group.size)
+		c.i64Field(3, group.rows)
+		c.endStruct()
+	}
+
+	c.stringField(6, "beeper-cli")
+	c.endStruct()
+	return c.buf.Bytes()
+}
+
+// parquetTypes maps a column type to its physical type and converted type
+// (-1 when none applies).
+func parquetTypes(t ParquetType) (int32, int32) {
+	switch t {
+	case ParquetInt64:
+		return parquetPhysInt64, -1
+	case ParquetBool:
+		return parquetPhysBoolean, -1
+	case ParquetDouble:
+		return parquetPhysDouble, -1
+	case ParquetTimestamp:
+		return parquetPhysInt64, parquetConvertedTimestampMillis
+	default:
+		return parquetPhysByteArray, parquetConvertedUTF8
+	}
+}
+
+// Thrift compact protocol type IDs used by the Parquet metadata.
+const (
+	compactI32    = 5
+	compactI64    = 6
+	compactBinary = 8
+	compactList   = 9
+	compactStruct = 12
+)
+
+// compactWriter encodes the subset of the Thrift compact protocol needed for
+// Parquet page headers and file metadata.
+type compactWriter struct {
+	buf   bytes.Buffer
+	last  int16
+	stack []int16
+}
+
+func (c *compactWriter) fieldHeader(id int16, typ byte) {
+	delta := id - c.last
+	if delta > 0 && delta <= 15 {
+		c.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		c.buf.WriteByte(typ)
+		writeUvarint(&c.buf, uint64(uint16((id<<1)^(id>>15))))
+	}
+	c.last = id
+}
+
+func (c *compactWriter) beginStruct() {
+	c.stack = append(c.stack, c.last)
+	c.last = 0
+}
+
+func (c *compactWriter) endStruct() {
+	c.buf.WriteByte(0)
+	c.last = c.stack[len(c.stack)-1]
+	c.stack = c.stack[:len(c.stack)-1]
+}
+
+func (c *compactWriter) structField(id int16) {
+	c.fieldHeader(id, compactStruct)
+	c.beginStruct()
+}
+
+func (c *compactWriter) i32Field(id int16, v int32) {
+	c.fieldHeader(id, compactI32)
+	c.i32(v)
+}
+
+func (c *compactWriter) i64Field(id int16, v int64) {
+	c.fieldHeader(id, compactI64)
+	writeUvarint(&c.buf, uint64((v<<1)^(v>>63)))
+}
+
+func (c *compactWriter) stringField(id int16, v string) {
+	c.fieldHeader(id, compactBinary)
+	c.binary(v)
+}
+
+func (c *compactWriter) listField(id int16, elem byte, n int) {
+	c.fieldHeader(id, compactList)
+	if n < 15 {
+		c.buf.WriteByte(byte(n)<<4 | elem)
+		return
+	}
+	c.buf.WriteByte(0xF0 | elem)
+	writeUvarint(&c.buf, uint64(n))
+}
+
+func (c *compactWriter) i32(v int32) {
+	writeUvarint(&c.buf, uint64(uint32((v<<1)^(v>>31))))
+}
+
+func (c *compactWriter) binary(v string) {
+	writeUvarint(&c.buf, uint64(len(v)))
+	c.buf.WriteString(v)
+}
+
+func writeUvarint(buf *bytes.Buffer, v uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	buf.Write(tmp[:n])
+}
@@ -0,0 +1,168 @@
+package export
+
+import (
+	"io"
+	"strings"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+)
+
+// MessageColumns is the schema of messages.parquet.
+var MessageColumns = []ParquetColumn{
+	{Name: "id", Type: ParquetInt64},
+	{Name: "event_id", Type: ParquetString},
+	{Name: "thread_id", Type: ParquetString},
+	{Name: "account_id", Type: ParquetString},
+	{Name: "sender_id", Type: ParquetString},
+	{Name: "sender_name", Type: ParquetString, Optional: true},
+	{Name: "timestamp", Type: ParquetTimestamp},
+	{Name: "is_sent_by_me", Type: ParquetBool},
+	{Name: "type", Type: ParquetString},
+	{Name: "text", Type: ParquetString},
+	{Name: "platform", Type: ParquetString, Optional: true},
+	{Name: "remote_id", Type: ParquetString, Optional: true},
+	{Name: "sent_via", Type: ParquetString, Optional: true},
+	{Name: "session_id", Type: ParquetString, Optional: true},
+	{Name: "disappearing", Type: ParquetBool},
+	{Name: "expires_at", Type: ParquetTimestamp, Optional: true},
+	{Name: "expired", Type: ParquetBool},
+}
+
+// ThreadColumns is the schema of threads.parquet.
+var ThreadColumns = []ParquetColumn{
+	{Name: "id", Type: ParquetString},
+	{Name: "account_id", Type: ParquetString},
+	{Name: "display_name", Type: ParquetString},
+	{Name: "type", Type: ParquetString, Optional: true},
+	{Name: "last_activity", Type: ParquetTimestamp, Optional: true},
+	{Name: "last_open", Type: ParquetTimestamp, Optional: true},
+	{Name: "is_unread", Type: ParquetBool},
+	{Name: "is_archived", Type: ParquetBool},
+	{Name: "is_low_priority", Type: ParquetBool},
+	{Name: "unread_count", Type: ParquetInt64},
+	{Name: "unread_mentions", Type: ParquetInt64},
+	{Name: "total_messages", Type: ParquetInt64},
+	{Name: "tags", Type: ParquetString, Optional: true},
+}
+
+// ParticipantColumns is the schema of participants.parquet.
+var ParticipantColumns = []ParquetColumn{
+	{Name: "thread_id", Type: ParquetString},
+	{Name: "participant_id", Type: ParquetString},
+	{Name: "name", Type: ParquetString, Optional: true},
+	{Name: "is_self", Type: ParquetBool},
+}
+
+// ParquetTables writes threads, their participants, and their messages into
+// three Parquet files that can be joined on thread_id.
+type ParquetTables struct {
+	Messages     *ParquetWriter
+	Threads      *ParquetWriter
+	Participants *ParquetWriter
+}
+
+// NewParquetTables returns table writers backed by the given outputs.
+func NewParquetTables(messages, threads, participants io.Writer) *ParquetTables {
+	return &ParquetTables{
+		Messages:     NewParquetWriter(messages, MessageColumns),
+		Threads:      NewParquetWriter(threads, ThreadColumns),
+		Participants: NewParquetWriter(participants, ParticipantColumns),
+	}
+}
+
+// Add writes one thread export to all three tables.
+func (t *ParquetTables) Add(export ThreadExport) error {
+	if err := t.AddThread(export.Thread); err != nil {
+		return err
+	}
+	for _, msg := range export.Messages {
+		if err := t.AddMessage(export.Thread, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddThread writes a thread and its participants; its messages follow
+// with AddMessage, so they can be streamed.
+func (t *ParquetTables) AddThread(thread beeper.Thread) error {
+	err := t.Threads.WriteRow(
+		thread.ID,
+		thread.AccountID,
+		thread.DisplayName,
+		nullString(thread.Type),
+		thread.LastActivity,
+		thread.LastOpen,
+		thread.IsUnread,
+		thread.IsArchived,
+		thread.IsLowPriority,
+		thread.UnreadCount,
+		thread.UnreadMentions,
+		thread.TotalMessages,
+		nullString(strings.Join(thread.Tags, ",")),
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range thread.Participants {
+		if err := t.Participants.WriteRow(thread.ID, p.ID, nullString(p.Name), p.IsSelf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddMessage writes one message of thread.
+func (t *ParquetTables) AddMessage(thread beeper.Thread, msg beeper.Message) error {
+	accountID := msg.AccountID
+	if accountID == "" {
+		accountID = thread.AccountID
+	}
+	var platform, remoteID, sentVia, expiresAt any
+	if msg.ExpiresAt != nil {
+		expiresAt = *msg.ExpiresAt
+	}
+	if msg.Provenance != nil {
+		platform = nullString(msg.Provenance.Platform)
+		remoteID = nullString(msg.Provenance.RemoteID)
+		sentVia = nullString(msg.Provenance.SentVia)
+	}
+	return t.Messages.WriteRow(
+		msg.ID,
+		msg.EventID,
+		msg.ThreadID,
+		accountID,
+		msg.SenderID,
+		nullString(msg.SenderName),
+		msg.Timestamp,
+		msg.IsSentByMe,
+		msg.Type,
+		msg.Text,
+		platform,
+		remoteID,
+		sentVia,
+		nullString(msg.SessionID),
+		msg.Disappearing,
+		expiresAt,
+		msg.Expired,
+	)
+}
+
+// Close writes the footers of all three files.
+func (t *ParquetTables) Close() error {
+	for _, w := range []*ParquetWriter{t.Messages, t.Threads, t.Participants} {
+		if err := w.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nullString maps empty strings to null.
+func nullString(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
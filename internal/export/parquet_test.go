@@ -0,0 +1,162 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+func TestParquetWriterRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewParquetWriter(&buf, []ParquetColumn{
+		{Name: "id", Type: ParquetInt64},
+		{Name: "name", Type: ParquetString, Optional: true},
+		{Name: "flag", Type: ParquetBool},
+		{Name: "at", Type: ParquetTimestamp},
+	})
+	ts := time.UnixMilli(1700000000123)
+	rows := [][]any{
+		{int64(1), "alice", true, ts},
+		{2, nil, false, ts.Add(time.Second)},
+		{int64(3), "carol", true, ts.Add(2 * time.Second)},
+	}
+	for _, row := range rows {
+		if err := w.WriteRow(row...); err != nil {
+			t.Fatalf("write row: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	data := buf.Bytes()
+	if string(data[:4]) != "PAR1" || string(data[len(data)-4:]) != "PAR1" {
+		t.Fatalf("missing magic bytes")
+	}
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	footer := data[len(data)-8-footerLen : len(data)-8]
+	meta := decodeCompactStruct(t, bytes.NewReader(footer))
+
+	if got := meta[3].(int64); got != 3 {
+		t.Fatalf("num_rows = %d, want 3", got)
+	}
+	schema := meta[2].([]any)
+	if len(schema) != 5 {
+		t.Fatalf("schema has %d elements, want 5", len(schema))
+	}
+	if name := string(schema[2].(map[int16]any)[4].([]byte)); name != "name" {
+		t.Fatalf("schema[2] name = %q", name)
+	}
+
+	group := meta[4].([]any)[0].(map[int16]any)
+	chunks := group[1].([]any)
+	offset := func(i int) int64 {
+		return chunks[i].(map[int16]any)[3].(map[int16]any)[9].(int64)
+	}
+
+	ids := readPage(t, data, offset(0))
+	for i, want := range []int64{1, 2, 3} {
+		if got := int64(binary.LittleEndian.Uint64(ids[i*8:])); got != want {
+			t.Fatalf("id[%d] = %d, want %d", i, got, want)
+		}
+	}
+
+	names := readPage(t, data, offset(1))
+	levelsLen := int(binary.LittleEndian.Uint32(names))
+	// Runs: 1x defined, 1x null, 1x defined.
+	if !bytes.Equal(names[4:4+levelsLen], []byte{2, 1, 2, 0, 2, 1}) {
+		t.Fatalf("unexpected definition levels % x", names[4:4+levelsLen])
+	}
+	values := names[4+levelsLen:]
+	if !bytes.Equal(values, []byte("\x05\x00\x00\x00alice\x05\x00\x00\x00carol")) {
+		t.Fatalf("unexpected string values %q", values)
+	}
+
+	flags := readPage(t, data, offset(2))
+	if !bytes.Equal(flags, []byte{0b101}) {
+		t.Fatalf("unexpected bool values % x", flags)
+	}
+
+	times := readPage(t, data, offset(3))
+	if got := int64(binary.LittleEndian.Uint64(times)); got != ts.UnixMilli() {
+		t.Fatalf("timestamp = %d, want %d", got, ts.UnixMilli())
+	}
+}
+
+func TestParquetWriterRejectsNullInRequiredColumn(t *testing.T) {
+	w := NewParquetWriter(&bytes.Buffer{}, []ParquetColumn{{Name: "id", Type: ParquetInt64}})
+	if err := w.WriteRow(nil); err == nil {
+		t.Fatalf("expected error for null in required column")
+	}
+	if err := w.WriteRow("1"); err == nil {
+		t.Fatalf("expected error for mismatched type")
+	}
+}
+
+// readPage decodes the page header at offset and returns the page body.
+func readPage(t *testing.T, data []byte, offset int64) []byte {
+	t.Helper()
+	r := bytes.NewReader(data[offset:])
+	header := decodeCompactStruct(t, r)
+	size := int(header[3].(int32))
+	start := len(data[offset:]) - r.Len()
+	return data[offset+int64(start) : offset+int64(start+size)]
+}
+
+// decodeCompactStruct parses a Thrift compact struct into field ID -> value.
+func decodeCompactStruct(t *testing.T, r *bytes.Reader) map[int16]any {
+	t.Helper()
+	fields := map[int16]any{}
+	var last int16
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			t.Fatalf("read field header: %v", err)
+		}
+		if b == 0 {
+			return fields
+		}
+		typ := b & 0x0F
+		id := last + int16(b>>4)
+		if b>>4 == 0 {
+			v, _ := binary.ReadUvarint(r)
+			id = int16(v>>1) ^ -int16(v&1)
+		}
+		last = id
+		fields[id] = decodeCompactValue(t, r, typ)
+	}
+}
+
+func decodeCompactValue(t *testing.T, r *bytes.Reader, typ byte) any {
+	t.Helper()
+	switch typ {
+	case compactI32:
+		v, _ := binary.ReadVarint(r)
+		return int32(v)
+	case compactI64:
+		v, _ := binary.ReadVarint(r)
+		return v
+	case compactBinary:
+		n, _ := binary.ReadUvarint(r)
+		b := make([]byte, n)
+		_, _ = r.Read(b)
+		return b
+	case compactList:
+		header, _ := r.ReadByte()
+		n := int(header >> 4)
+		if n == 15 {
+			v, _ := binary.ReadUvarint(r)
+			n = int(v)
+		}
+		items := make([]any, n)
+		for i := range items {
+			items[i] = decodeCompactValue(t, r, header&0x0F)
+		}
+		return items
+	case compactStruct:
+		return decodeCompactStruct(t, r)
+	}
+	t.Fatalf("unsupported compact type %d", typ)
+	return nil
+}
@@ -0,0 +1,144 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	pdfPageWidth    = 612
+	pdfPageHeight   = 792
+	pdfMargin       = 50
+	pdfFontSize     = 9
+	pdfLeading      = 11
+	pdfCharsPerLine = 94
+	pdfLinesPerPage = (pdfPageHeight - 2*pdfMargin) / pdfLeading
+)
+
+// WritePDF renders lines of text as a minimal, dependency-free PDF document
+// using the built-in Courier font. Long lines are wrapped and characters
+// outside Latin-1 are replaced with "?".
+func WritePDF(w io.Writer, title string, lines []string) error {
+	wrapped := []string{}
+	if title != "" {
+		wrapped = append(wrapped, wrapLine(title, pdfCharsPerLine)...)
+		wrapped = append(wrapped, "")
+	}
+	for _, line := range lines {
+		wrapped = append(wrapped, wrapLine(line, pdfCharsPerLine)...)
+	}
+
+	pages := [][]string{}
+	for start := 0; start < len(wrapped); start += pdfLinesPerPage {
+		end := start + pdfLinesPerPage
+		if end > len(wrapped) {
+			end = len(wrapped)
+		}
+		pages = append(pages, wrapped[start:end])
+	}
+	if len(pages) == 0 {
+		pages = append(pages, nil)
+	}
+
+	var buf bytes.Buffer
+	offsets := []int{}
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	kids := make([]string, 0, len(pages))
+	for i := range pages {
+		// Objects 1-3 are catalog, page tree, and font; each page adds two.
+		kids = append(kids, fmt.Sprintf("%d 0 R", 4+i*2))
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+
+	for i, pageLines := range pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 5+i*2))
+
+		var content bytes.Buffer
+		fmt.Fprintf(&content, "BT\n/F1 %d Tf\n%d TL\n%d %d Td\n", pdfFontSize, pdfLeading, pdfMargin, pdfPageHeight-pdfMargin)
+		for _, line := range pageLines {
+			content.WriteString("(")
+			content.WriteString(pdfEscape(line))
+			content.WriteString(") Tj T*\n")
+		}
+		fmt.Fprintf(&content, "ET\n")
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func wrapLine(line string, width int) []string {
+	line = strings.ReplaceAll(line, "\t", "    ")
+	if utf8.RuneCountInString(line) <= width {
+		return []string{line}
+	}
+	out := []string{}
+	runes := []rune(line)
+	for len(runes) > width {
+		cut := width
+		for i := width; i > width/2; i-- {
+			if runes[i] == ' ' {
+				cut = i
+				break
+			}
+		}
+		out = append(out, string(runes[:cut]))
+		runes = runes[cut:]
+		for len(runes) > 0 && runes[0] == ' ' {
+			runes = runes[1:]
+		}
+	}
+	if len(runes) > 0 {
+		out = append(out, string(runes))
+	}
+	return out
+}
+
+// pdfLossy reports whether line has characters WritePDF cannot show and
+// replaces with "?".
+func pdfLossy(line string) bool {
+	for _, r := range strings.ReplaceAll(line, "\t", " ") {
+		if (r < 0x20 || r >= 0x7f) && (r < 0xa0 || r > 0xff) {
+			return true
+		}
+	}
+	return false
+}
+
+func pdfEscape(line string) string {
+	var b strings.Builder
+	for _, r := range line {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
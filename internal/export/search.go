@@ -0,0 +1,138 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+)
+
+// SearchFormats are the formats WriteSearchResults supports; each is also
+// the file extension.
+var SearchFormats = []string{"md", "csv", "jsonl"}
+
+// searchSlugLength caps the query part of a search export's file name.
+const searchSlugLength = 60
+
+// SearchFileName returns a file name for a search export, derived from the
+// query so several searches can share an output directory.
+func SearchFileName(query string, format string) string {
+	words := strings.Join(strings.Fields(strings.ToLower(query)), "-")
+	if words == "" {
+		return "search." + format
+	}
+	slug := FileName(words, "")
+	if len(slug) > searchSlugLength {
+		slug = slug[:searchSlugLength]
+	}
+	return "search-" + slug + "." + format
+}
+
+// SearchExport is a search and its results, ranked best first.
+type SearchExport struct {
+	Query   string                `json:"query"`
+	Ran     time.Time             `json:"ran"`
+	Results []beeper.SearchResult `json:"results"`
+}
+
+// WriteSearchResults writes a search export in one of SearchFormats.
+func WriteSearchResults(w io.Writer, format string, export SearchExport) error {
+	switch format {
+	case "md":
+		return WriteSearchMarkdown(w, export)
+	case "csv":
+		return WriteSearchCSV(w, export)
+	case "jsonl":
+		return WriteSearchJSONL(w, export)
+	default:
+		return fmt.Errorf("invalid export format %q: use md, csv, or jsonl", format)
+	}
+}
+
+// WriteSearchMarkdown renders search results as Markdown, one section per
+// conversation stretch: hits whose context overlaps share a section (see
+// beeper.MergeSearchContext) and are set in bold.
+func WriteSearchMarkdown(w io.Writer, export SearchExport) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Search: %s\n\n", export.Query)
+	fmt.Fprintf(&b, "_%d results · exported %s_\n", len(export.Results), export.Ran.Local().Format("2006-01-02 15:04"))
+
+	for _, block := range beeper.MergeSearchContext(export.Results) {
+		title := block.ThreadName
+		if title == "" {
+			title = block.ThreadID
+		}
+		if block.AccountID != "" {
+			title += " (" + block.AccountID + ")"
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n", markdownEscape(title))
+		for _, msg := range block.Messages {
+			sender := msg.SenderName
+			if sender == "" {
+				sender = msg.SenderID
+			}
+			if msg.IsSentByMe {
+				sender = "Me"
+			}
+			line := fmt.Sprintf("%s %s: %s", msg.Timestamp.Local().Format("2006-01-02 15:04"), markdownEscape(sender), markdownText(msg.Text))
+			if slices.Contains(block.Matches, msg.ID) {
+				fmt.Fprintf(&b, "- **%s**\n", line)
+				continue
+			}
+			fmt.Fprintf(&b, "- %s\n", line)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteSearchCSV writes one row per message: every hit followed by its
+// context. rank numbers the results from 1 and role is "match" or
+// "context".
+func WriteSearchCSV(w io.Writer, export SearchExport) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"rank", "role", "id", "event_id", "timestamp", "account", "thread_id", "thread", "sender_id", "sender", "is_sent_by_me", "text", "score"}); err != nil {
+		return err
+	}
+	row := func(rank int, role string, msg beeper.Message) error {
+		score := ""
+		if role == "match" {
+			score = strconv.FormatFloat(msg.Score, 'f', -1, 64)
+		}
+		return cw.Write([]string{
+			strconv.Itoa(rank), role, strconv.FormatInt(msg.ID, 10), msg.EventID,
+			msg.Timestamp.UTC().Format(time.RFC3339Nano), msg.AccountID, msg.ThreadID, msg.ThreadName,
+			msg.SenderID, msg.SenderName, strconv.FormatBool(msg.IsSentByMe), msg.Text, score,
+		})
+	}
+	for i, result := range export.Results {
+		if err := row(i+1, "match", result.Match); err != nil {
+			return err
+		}
+		for _, msg := range result.Context {
+			if err := row(i+1, "context", msg); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteSearchJSONL writes one search result ({match, context}) per line.
+func WriteSearchJSONL(w io.Writer, export SearchExport) error {
+	enc := json.NewEncoder(w)
+	for _, result := range export.Results {
+		if err := enc.Encode(result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
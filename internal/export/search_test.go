@@ -0,0 +1,74 @@
+package export
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+)
+
+func TestWriteSearchResults(t *testing.T) {
+	at := time.Date(2025, 12, 19, 16, 0, 0, 0, time.UTC)
+	msg := func(id int64, minute int, sender string, text string) beeper.Message {
+		return beeper.Message{ID: id, ThreadID: "!room1:beeper.local", ThreadName: "Team Chat", AccountID: "whatsapp", SenderID: "@" + strings.ToLower(sender), SenderName: sender, Timestamp: at.Add(time.Duration(minute) * time.Minute), Text: text}
+	}
+	hit := msg(2, 1, "Alice", "christmas party")
+	hit.Score = 1.5
+	search := SearchExport{
+		Query: "party",
+		Ran:   at,
+		Results: []beeper.SearchResult{{
+			Match:   hit,
+			Context: []beeper.Message{msg(1, 0, "Bob", "hello"), msg(3, 2, "Bob", "see you")},
+		}},
+	}
+
+	var md strings.Builder
+	if err := WriteSearchResults(&md, "md", search); err != nil {
+		t.Fatalf("markdown: %v", err)
+	}
+	for _, want := range []string{"# Search: party", "## Team Chat (whatsapp)", ": hello\n", "- **" + hit.Timestamp.Local().Format("2006-01-02 15:04") + " Alice: christmas party**\n"} {
+		if !strings.Contains(md.String(), want) {
+			t.Fatalf("markdown missing %q:\n%s", want, md.String())
+		}
+	}
+
+	var out strings.Builder
+	if err := WriteSearchResults(&out, "csv", search); err != nil {
+		t.Fatalf("csv: %v", err)
+	}
+	records, err := csv.NewReader(strings.NewReader(out.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	if len(records) != 4 || records[1][1] != "match" || records[1][11] != "christmas party" || records[1][12] != "1.5" || records[2][1] != "context" || records[2][12] != "" {
+		t.Fatalf("unexpected csv: %v", records)
+	}
+
+	out.Reset()
+	if err := WriteSearchResults(&out, "jsonl", search); err != nil {
+		t.Fatalf("jsonl: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != 1 || !strings.Contains(lines[0], `"context":[`) {
+		t.Fatalf("unexpected jsonl: %s", out.String())
+	}
+
+	if err := WriteSearchResults(&out, "xml", search); err == nil {
+		t.Fatalf("expected an error for an unknown format")
+	}
+}
+
+func TestSearchFileName(t *testing.T) {
+	cases := map[string]string{
+		"Christmas Party": "search-christmas-party.md",
+		"":                "search.md",
+		"café/€":          "search-caf___.md",
+	}
+	for query, want := range cases {
+		if got := SearchFileName(query, "md"); got != want {
+			t.Fatalf("SearchFileName(%q) = %q, want %q", query, got, want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"iter"
+	"strings"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+)
+
+// canonicalTimeLayout is the fixed-width UTC timestamp of canonical text
+// lines.
+const canonicalTimeLayout = "2006-01-02T15:04:05.000Z"
+
+// CanonicalTextLine renders a message as one tab-separated line: UTC
+// timestamp, sender ID, and text with backslashes, newlines, tabs, and
+// other control characters escaped. Sender names are left out, since they
+// depend on lookups that change between snapshots; the line ends in "\n".
+func CanonicalTextLine(msg beeper.Message) string {
+	return msg.Timestamp.UTC().Format(canonicalTimeLayout) + "\t" + escapeCanonical(msg.SenderID) + "\t" + escapeCanonical(msg.Text) + "\n"
+}
+
+// StreamCanonicalText writes messages as canonical text lines, oldest
+// first as given, and returns how many it wrote. Two exports of the same
+// messages are byte-identical, and a changed message changes one line, so
+// snapshots can be compared with diff.
+func StreamCanonicalText(w io.Writer, messages iter.Seq2[beeper.Message, error]) (int, error) {
+	n := 0
+	for msg, err := range messages {
+		if err != nil {
+			return n, err
+		}
+		if _, err := io.WriteString(w, CanonicalTextLine(msg)); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// escapeCanonical escapes text so it fits on one line of one column,
+// including the Unicode line separators some diff tools break lines at.
+func escapeCanonical(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f || r == '\u2028' || r == '\u2029':
+			fmt.Fprintf(&b, `\u%04x`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
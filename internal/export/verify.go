@@ -0,0 +1,256 @@
+package export
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+)
+
+// Archive kinds ReadArchive recognizes.
+const (
+	ArchiveLegal  = "legal"
+	ArchiveExport = "export"
+)
+
+// Problem kinds reported by archive verification.
+const (
+	ProblemMissingFile    = "missing-file"
+	ProblemModifiedFile   = "modified-file"
+	ProblemExtraFile      = "extra-file"
+	ProblemManifest       = "manifest"
+	ProblemSignature      = "signature"
+	ProblemMissingMessage = "missing-message"
+	ProblemChangedMessage = "changed-message"
+)
+
+// VerifyProblem is one discrepancy between an archive and its manifest or
+// the database.
+type VerifyProblem struct {
+	Kind    string `json:"kind"`
+	Path    string `json:"path,omitempty"`
+	EventID string `json:"eventId,omitempty"`
+	Detail  string `json:"detail"`
+}
+
+// ArchivedMessage is one message as an archive recorded it. Legal bundles
+// keep the full row in Raw; export JSON files keep the rendered Text.
+type ArchivedMessage struct {
+	Path     string
+	ThreadID string
+	EventID  string
+	Raw      *beeper.RawEvent
+	Text     string
+	Deleted  bool
+}
+
+// Archive is an export directory read back for verification.
+type Archive struct {
+	Kind string
+	// Summary is the signed summary of a legal bundle.
+	Summary  *LegalSummary
+	Messages []ArchivedMessage
+}
+
+// ReadArchive reads an `export legal` bundle (a directory with
+// manifest.json and events.json) or an `export` JSON directory (one
+// <room>.json file per thread).
+func ReadArchive(dir string) (Archive, error) {
+	if _, err := os.Stat(filepath.Join(dir, "manifest.json")); err == nil {
+		return readLegalArchive(dir)
+	}
+	archive := Archive{Kind: ArchiveExport}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return Archive{}, err
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return Archive{}, err
+		}
+		var thread ThreadExport
+		if err := json.Unmarshal(data, &thread); err != nil || thread.Thread.ID == "" {
+			continue
+		}
+		for _, msg := range thread.Messages {
+			archive.Messages = append(archive.Messages, ArchivedMessage{
+				Path:     filepath.Base(path),
+				ThreadID: thread.Thread.ID,
+				EventID:  msg.EventID,
+				Text:     msg.Text,
+				Deleted:  msg.IsDeleted,
+			})
+		}
+	}
+	if len(archive.Messages) == 0 {
+		return Archive{}, fmt.Errorf("%s is neither a legal bundle nor an export directory with thread JSON files", dir)
+	}
+	return archive, nil
+}
+
+func readLegalArchive(dir string) (Archive, error) {
+	archive := Archive{Kind: ArchiveLegal}
+	if data, err := os.ReadFile(filepath.Join(dir, "summary.json")); err == nil {
+		var summary LegalSummary
+		if err := json.Unmarshal(data, &summary); err != nil {
+			return Archive{}, fmt.Errorf("read summary.json: %w", err)
+		}
+		archive.Summary = &summary
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "events.json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return archive, nil
+	}
+	if err != nil {
+		return Archive{}, err
+	}
+	var events []beeper.RawEvent
+	if err := json.Unmarshal(data, &events); err != nil {
+		return Archive{}, fmt.Errorf("read events.json: %w", err)
+	}
+	for i := range events {
+		archive.Messages = append(archive.Messages, ArchivedMessage{
+			Path:     "events.json",
+			ThreadID: events[i].ThreadID,
+			EventID:  events[i].EventID,
+			Raw:      &events[i],
+			Deleted:  events[i].IsDeleted,
+		})
+	}
+	return archive, nil
+}
+
+// VerifyManifest re-hashes a legal bundle's files against manifest.json,
+// reports files added since, and checks the manifest digest and Ed25519
+// signature recorded in summary.json and summary.sig. It returns the
+// number of files checked.
+func VerifyManifest(dir string, summary *LegalSummary) (int, []VerifyProblem, error) {
+	problems := []VerifyProblem{}
+	data, err := os.ReadFile(filepath.Join(dir, "manifest.json"))
+	if err != nil {
+		return 0, nil, err
+	}
+	var manifest []ManifestEntry
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return 0, nil, fmt.Errorf("read manifest.json: %w", err)
+	}
+
+	current, err := BuildManifest(dir, "manifest.json", "summary.json", "summary.sig")
+	if err != nil {
+		return 0, nil, err
+	}
+	onDisk := make(map[string]ManifestEntry, len(current))
+	for _, entry := range current {
+		onDisk[entry.Path] = entry
+	}
+	for _, want := range manifest {
+		got, ok := onDisk[want.Path]
+		delete(onDisk, want.Path)
+		switch {
+		case !ok:
+			problems = append(problems, VerifyProblem{Kind: ProblemMissingFile, Path: want.Path, Detail: "listed in the manifest but missing"})
+		case got.SHA256 != want.SHA256:
+			problems = append(problems, VerifyProblem{Kind: ProblemModifiedFile, Path: want.Path, Detail: fmt.Sprintf("sha256 %s, manifest has %s", got.SHA256, want.SHA256)})
+		}
+	}
+	for _, entry := range current {
+		if _, ok := onDisk[entry.Path]; ok {
+			problems = append(problems, VerifyProblem{Kind: ProblemExtraFile, Path: entry.Path, Detail: "not listed in the manifest"})
+		}
+	}
+
+	if summary == nil {
+		problems = append(problems, VerifyProblem{Kind: ProblemSignature, Path: "summary.json", Detail: "missing; the manifest itself is unsigned"})
+		return len(manifest), problems, nil
+	}
+	if sum := sha256Hex(data); sum != summary.ManifestSHA256 {
+		problems = append(problems, VerifyProblem{Kind: ProblemManifest, Path: "manifest.json", Detail: fmt.Sprintf("sha256 %s, summary has %s", sum, summary.ManifestSHA256)})
+	}
+	if problem := verifySummarySignature(dir, summary); problem != "" {
+		problems = append(problems, VerifyProblem{Kind: ProblemSignature, Path: "summary.sig", Detail: problem})
+	}
+	return len(manifest), problems, nil
+}
+
+// verifySummarySignature checks summary.sig against summary.json with the
+// public key the summary names, returning what is wrong, if anything. The
+// key proves integrity, not authorship: compare it with the one you expect.
+func verifySummarySignature(dir string, summary *LegalSummary) string {
+	summaryBytes, err := os.ReadFile(filepath.Join(dir, "summary.json"))
+	if err != nil {
+		return err.Error()
+	}
+	sigHex, err := os.ReadFile(filepath.Join(dir, "summary.sig"))
+	if err != nil {
+		return "missing signature"
+	}
+	signature, err := hex.DecodeString(strings.TrimSpace(string(sigHex)))
+	if err != nil {
+		return "signature is not hex encoded"
+	}
+	publicKey, err := hex.DecodeString(summary.PublicKey)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		return "summary has no valid public key"
+	}
+	if !ed25519.Verify(publicKey, summaryBytes, signature) {
+		return "signature does not match summary.json"
+	}
+	return ""
+}
+
+// CompareMessage checks an archived message against its current database
+// row, when there is one. A message deleted in Beeper since it was
+// archived is not a problem (deleted reports it); a missing row or changed
+// content is.
+func CompareMessage(archived ArchivedMessage, current beeper.RawEvent, found bool) (deleted bool, problem *VerifyProblem) {
+	if !found {
+		return false, &VerifyProblem{Kind: ProblemMissingMessage, Path: archived.Path, EventID: archived.EventID, Detail: "no longer in the database"}
+	}
+	if current.IsDeleted && !archived.Deleted {
+		return true, nil
+	}
+	if current.IsDeleted != archived.Deleted {
+		return false, &VerifyProblem{Kind: ProblemChangedMessage, Path: archived.Path, EventID: archived.EventID, Detail: "deleted when archived, but no longer deleted"}
+	}
+
+	if archived.Raw != nil {
+		if archived.Raw.TextContent != current.TextContent || !sameJSON(archived.Raw.Payload, current.Payload) {
+			return false, &VerifyProblem{Kind: ProblemChangedMessage, Path: archived.Path, EventID: archived.EventID, Detail: "payload differs from the database"}
+		}
+		return false, nil
+	}
+	if archived.Deleted || archived.Text == beeper.UndecryptedText {
+		return false, nil
+	}
+	for _, format := range []beeper.MessageFormat{beeper.FormatPlain, beeper.FormatRich} {
+		if beeper.ResolveMessageText(string(current.Payload), current.Type, current.TextContent, format) == archived.Text {
+			return false, nil
+		}
+	}
+	return false, &VerifyProblem{Kind: ProblemChangedMessage, Path: archived.Path, EventID: archived.EventID, Detail: "text differs from the database"}
+}
+
+// sameJSON compares two JSON documents ignoring insignificant whitespace,
+// since events.json is written indented.
+func sameJSON(a []byte, b []byte) bool {
+	var ca, cb bytes.Buffer
+	if json.Compact(&ca, a) != nil || json.Compact(&cb, b) != nil {
+		return bytes.Equal(a, b)
+	}
+	return bytes.Equal(ca.Bytes(), cb.Bytes())
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,126 @@
+package export
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+)
+
+func TestVerifyManifestReportsDrift(t *testing.T) {
+	dir := t.TempDir()
+	key, err := GenerateSigningKey()
+	if err != nil {
+		t.Fatalf("key: %v", err)
+	}
+	ts := time.UnixMilli(1700000000000)
+	if _, err := WriteLegalBundle(dir, LegalBundle{
+		Thread:   beeper.Thread{ID: "!room1:beeper.local", DisplayName: "Team Chat"},
+		Messages: []beeper.Message{{EventID: "$1", SenderName: "Alice", Timestamp: ts, Text: "hello"}},
+		Events:   []beeper.RawEvent{{EventID: "$1", ThreadID: "!room1:beeper.local", Timestamp: ts, Type: "TEXT", Payload: json.RawMessage(`{"text":"hello"}`)}},
+	}, key, ts); err != nil {
+		t.Fatalf("write bundle: %v", err)
+	}
+
+	archive, err := ReadArchive(dir)
+	if err != nil {
+		t.Fatalf("read archive: %v", err)
+	}
+	if archive.Kind != ArchiveLegal || archive.Summary == nil || len(archive.Messages) != 1 || archive.Messages[0].Raw == nil {
+		t.Fatalf("unexpected archive: %+v", archive)
+	}
+	files, problems, err := VerifyManifest(dir, archive.Summary)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if files != 2 || len(problems) != 0 {
+		t.Fatalf("expected a clean bundle of 2 files, got %d files, %+v", files, problems)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "transcript.pdf"), []byte("%PDF-1.4 edited"), 0o644); err != nil {
+		t.Fatalf("tamper: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "media", "extra.txt"), []byte("new"), 0o644); err != nil {
+		t.Fatalf("add file: %v", err)
+	}
+	summary := *archive.Summary
+	summary.Messages = 99
+	data, err := json.Marshal(summary)
+	if err != nil {
+		t.Fatalf("marshal summary: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "summary.json"), data, 0o644); err != nil {
+		t.Fatalf("edit summary: %v", err)
+	}
+
+	_, problems, err = VerifyManifest(dir, &summary)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	kinds := map[string]string{}
+	for _, p := range problems {
+		kinds[p.Kind] = p.Path
+	}
+	want := map[string]string{
+		ProblemModifiedFile: "transcript.pdf",
+		ProblemExtraFile:    "media/extra.txt",
+		ProblemSignature:    "summary.sig",
+	}
+	if len(kinds) != len(want) {
+		t.Fatalf("unexpected problems: %+v", problems)
+	}
+	for kind, path := range want {
+		if kinds[kind] != path {
+			t.Fatalf("expected %s for %s, got %+v", kind, path, problems)
+		}
+	}
+}
+
+func TestCompareMessage(t *testing.T) {
+	current := beeper.RawEvent{EventID: "$1", Type: "TEXT", TextContent: "hi there", Payload: json.RawMessage(`{"text":"hi there"}`)}
+
+	cases := []struct {
+		name        string
+		archived    ArchivedMessage
+		current     beeper.RawEvent
+		found       bool
+		wantDeleted bool
+		wantProblem string
+	}{
+		{name: "missing", archived: ArchivedMessage{EventID: "$1", Text: "hi there"}, wantProblem: ProblemMissingMessage},
+		{name: "same text", archived: ArchivedMessage{EventID: "$1", Text: "hi there"}, current: current, found: true},
+		{name: "changed text", archived: ArchivedMessage{EventID: "$1", Text: "bye"}, current: current, found: true, wantProblem: ProblemChangedMessage},
+		{name: "deleted since", archived: ArchivedMessage{EventID: "$1", Text: "bye"}, current: beeper.RawEvent{EventID: "$1", IsDeleted: true}, found: true, wantDeleted: true},
+		{name: "undeleted", archived: ArchivedMessage{EventID: "$1", Deleted: true}, current: current, found: true, wantProblem: ProblemChangedMessage},
+		{name: "undecrypted", archived: ArchivedMessage{EventID: "$1", Text: beeper.UndecryptedText}, current: current, found: true},
+		{
+			name:     "indented payload",
+			archived: ArchivedMessage{EventID: "$1", Raw: &beeper.RawEvent{TextContent: "hi there", Payload: json.RawMessage("{\n  \"text\": \"hi there\"\n}")}},
+			current:  current,
+			found:    true,
+		},
+		{
+			name:        "changed payload",
+			archived:    ArchivedMessage{EventID: "$1", Raw: &beeper.RawEvent{TextContent: "hi there", Payload: json.RawMessage(`{"text":"hi"}`)}},
+			current:     current,
+			found:       true,
+			wantProblem: ProblemChangedMessage,
+		},
+	}
+	for _, tc := range cases {
+		deleted, problem := CompareMessage(tc.archived, tc.current, tc.found)
+		if deleted != tc.wantDeleted {
+			t.Fatalf("%s: deleted = %v", tc.name, deleted)
+		}
+		got := ""
+		if problem != nil {
+			got = problem.Kind
+		}
+		if got != tc.wantProblem {
+			t.Fatalf("%s: problem = %q, want %q", tc.name, got, tc.wantProblem)
+		}
+	}
+}
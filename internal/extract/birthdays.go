@@ -0,0 +1,174 @@
+package extract
+
+import (
+	"cmp"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"time"
+)
+
+// BirthdayTerms narrow a message query to texts that may hold a birthday
+// greeting; IsBirthdayGreeting decides.
+var BirthdayTerms = []string{
+	"birthday", "bday", "b-day", "hbd", "happy returns",
+	"cumplea", "aniversário", "aniversario", "geburtstag", "anniversaire",
+	"compleanno", "verjaardag", "рождения",
+}
+
+var (
+	birthdayGreetingPattern = regexp.MustCompile(`(?i)happy\s+b-?(?:irth)?day|\bhbd\b|many\s+happy\s+returns` +
+		`|feliz\s+(?:cumplea[ñn]os|anivers[áa]rio)` +
+		`|(?:alles\s+gute|herzlichen\s+gl[üu]ckwunsch|happy)\s+zum\s+geburtstag` +
+		`|(?:joyeux|bon)\s+anniversaire|buon\s+compleanno` +
+		`|gefeliciteerd\s+met\s+je\s+verjaardag|fijne\s+verjaardag` +
+		`|с\s+дн[её]м\s+рождения`)
+	// belatedPattern marks greetings sent after the day, which would skew
+	// the date.
+	belatedPattern = regexp.MustCompile(`(?i)belated|nachträglich|en\s+retard|atrasad[oa]|in\s+ritardo`)
+)
+
+// IsBirthdayGreeting reports whether text wishes someone a happy birthday
+// on the day, in English, Spanish, Portuguese, German, French, Italian,
+// Dutch, or Russian. Belated wishes do not count.
+func IsBirthdayGreeting(text string) bool {
+	return birthdayGreetingPattern.MatchString(text) && !belatedPattern.MatchString(text)
+}
+
+// Greeting is a birthday greeting sent to a contact.
+type Greeting struct {
+	ContactID   string
+	ContactName string
+	ThreadID    string
+	Time        time.Time
+}
+
+// Birthday is a contact's birthday inferred from greetings.
+type Birthday struct {
+	ContactID string     `json:"contactId"`
+	Name      string     `json:"name"`
+	ThreadID  string     `json:"threadId"`
+	Month     time.Month `json:"month"`
+	Day       int        `json:"day"`
+	// Confidence is between 0 and 1: the share of greeted years that agree
+	// on the date, discounted when few years do.
+	Confidence float64 `json:"confidence"`
+	// Years counts the years with a greeting on or next to the date;
+	// Greetings counts those greetings.
+	Years        int       `json:"years"`
+	Greetings    int       `json:"greetings"`
+	FirstGreeted time.Time `json:"firstGreeted"`
+	LastGreeted  time.Time `json:"lastGreeted"`
+}
+
+// InferBirthdays infers one birthday per contact from greetings, most
+// confident first. Greetings are placed on their local calendar day; the
+// date is the day whose neighbourhood (a day either side, for time zones
+// and late wishes) has greetings in the most years, and the confidence is
+// that share of all greeted years times 1 - 0.5^years, so one year gives
+// at most 0.5 and three agreeing years 0.88.
+func InferBirthdays(greetings []Greeting) []Birthday {
+	byContact := map[string][]Greeting{}
+	for _, g := range greetings {
+		key := cmp.Or(g.ContactID, g.ThreadID)
+		byContact[key] = append(byContact[key], g)
+	}
+
+	birthdays := make([]Birthday, 0, len(byContact))
+	for key, contact := range byContact {
+		// years[day] holds the years with a greeting on that day of a leap
+		// year, so 29 February has a place.
+		years := map[int]map[int]struct{}{}
+		allYears := map[int]struct{}{}
+		for _, g := range contact {
+			t := g.Time.Local()
+			day := leapYearDay(t.Month(), t.Day())
+			if years[day] == nil {
+				years[day] = map[int]struct{}{}
+			}
+			years[day][t.Year()] = struct{}{}
+			allYears[t.Year()] = struct{}{}
+		}
+
+		days := make([]int, 0, len(years))
+		for day := range years {
+			days = append(days, day)
+		}
+		slices.Sort(days)
+		best, bestSupport := 0, -1
+		for _, day := range days {
+			support := len(nearbyYears(years, day))
+			if support > bestSupport || (support == bestSupport && len(years[day]) > len(years[best])) {
+				best, bestSupport = day, support
+			}
+		}
+
+		date := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, best-1)
+		birthday := Birthday{
+			ContactID: contact[0].ContactID,
+			Name:      cmp.Or(contact[0].ContactName, key),
+			ThreadID:  contact[0].ThreadID,
+			Month:     date.Month(),
+			Day:       date.Day(),
+			Years:     bestSupport,
+		}
+		share := float64(bestSupport) / float64(len(allYears))
+		birthday.Confidence = math.Round(share*(1-math.Pow(0.5, float64(bestSupport)))*100) / 100
+		for _, g := range contact {
+			t := g.Time.Local()
+			if circularDistance(leapYearDay(t.Month(), t.Day()), best) > 1 {
+				continue
+			}
+			birthday.Greetings++
+			if birthday.FirstGreeted.IsZero() || g.Time.Before(birthday.FirstGreeted) {
+				birthday.FirstGreeted = g.Time
+			}
+			if g.Time.After(birthday.LastGreeted) {
+				birthday.LastGreeted = g.Time
+			}
+		}
+		birthdays = append(birthdays, birthday)
+	}
+
+	sort.Slice(birthdays, func(i, j int) bool {
+		a, b := birthdays[i], birthdays[j]
+		if a.Confidence != b.Confidence {
+			return a.Confidence > b.Confidence
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.ContactID < b.ContactID
+	})
+	return birthdays
+}
+
+// nearbyYears returns the years with a greeting within a day of day.
+func nearbyYears(years map[int]map[int]struct{}, day int) map[int]struct{} {
+	found := map[int]struct{}{}
+	for other, set := range years {
+		if circularDistance(other, day) > 1 {
+			continue
+		}
+		for year := range set {
+			found[year] = struct{}{}
+		}
+	}
+	return found
+}
+
+// leapYearDay is the day of the year of a date in a leap year (1-366).
+func leapYearDay(month time.Month, day int) int {
+	return time.Date(2000, month, day, 0, 0, 0, 0, time.UTC).YearDay()
+}
+
+// circularDistance is the number of days between two days of the year,
+// across New Year if shorter.
+func circularDistance(a int, b int) int {
+	d := a - b
+	if d < 0 {
+		d = -d
+	}
+	return min(d, 366-d)
+}
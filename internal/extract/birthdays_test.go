@@ -0,0 +1,72 @@
+package extract
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsBirthdayGreeting(t *testing.T) {
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"Happy birthday!! 🎂", true},
+		{"happy bday mate", true},
+		{"HBD", true},
+		{"Alles Gute zum Geburtstag, Anna!", true},
+		{"Joyeux anniversaire 🎉", true},
+		{"Feliz cumpleaños", true},
+		{"С днём рождения!", true},
+		{"Happy belated birthday!", false},
+		{"Nachträglich alles Gute zum Geburtstag", false},
+		{"What do you want for your birthday?", false},
+		{"Birthday party is at 8", false},
+	}
+	for _, tt := range tests {
+		if got := IsBirthdayGreeting(tt.text); got != tt.want {
+			t.Fatalf("IsBirthdayGreeting(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestInferBirthdays(t *testing.T) {
+	prev := time.Local
+	time.Local = time.UTC
+	t.Cleanup(func() { time.Local = prev })
+
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 9, 0, 0, 0, time.UTC)
+	}
+	greetings := []Greeting{
+		{ContactID: "@anna", ContactName: "Anna", ThreadID: "!anna", Time: at(2021, time.March, 14)},
+		{ContactID: "@anna", ContactName: "Anna", ThreadID: "!anna", Time: at(2022, time.March, 15)},
+		{ContactID: "@anna", ContactName: "Anna", ThreadID: "!anna", Time: at(2023, time.March, 14)},
+		{ContactID: "@anna", ContactName: "Anna", ThreadID: "!anna", Time: at(2023, time.March, 14).Add(time.Hour)},
+		// A greeting to Anna's mother, not Anna.
+		{ContactID: "@anna", ContactName: "Anna", ThreadID: "!anna", Time: at(2024, time.July, 2)},
+		{ContactID: "@ben", ContactName: "Ben", ThreadID: "!ben", Time: at(2024, time.December, 31)},
+		{ThreadID: "!leap", Time: at(2020, time.February, 29)},
+	}
+
+	got := InferBirthdays(greetings)
+	if len(got) != 3 {
+		t.Fatalf("expected 3 birthdays, got %+v", got)
+	}
+	anna := got[0]
+	if anna.Name != "Anna" || anna.Month != time.March || anna.Day != 14 || anna.Years != 3 || anna.Greetings != 4 {
+		t.Fatalf("unexpected birthday for Anna: %+v", anna)
+	}
+	// 3 of 4 greeted years agree: 0.75 * (1 - 0.5^3).
+	if anna.Confidence != 0.66 {
+		t.Fatalf("expected confidence 0.66, got %v", anna.Confidence)
+	}
+	if !anna.FirstGreeted.Equal(at(2021, time.March, 14)) || !anna.LastGreeted.Equal(at(2023, time.March, 14).Add(time.Hour)) {
+		t.Fatalf("unexpected greeting range: %+v", anna)
+	}
+	if got[1].Name != "!leap" || got[1].Month != time.February || got[1].Day != 29 || got[1].Confidence != 0.5 {
+		t.Fatalf("expected the leap day birthday keyed by thread, got %+v", got[1])
+	}
+	if got[2].Name != "Ben" || got[2].Month != time.December || got[2].Day != 31 {
+		t.Fatalf("unexpected birthday for Ben: %+v", got[2])
+	}
+}
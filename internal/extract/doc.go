@@ -0,0 +1,3 @@
+// Package extract derives structured information, such as named entities
+// and topic clusters, from message text.
+package extract
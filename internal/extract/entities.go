@@ -0,0 +1,301 @@
+package extract
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// EntityKind classifies a named entity.
+type EntityKind string
+
+const (
+	KindPerson       EntityKind = "person"
+	KindPlace        EntityKind = "place"
+	KindOrganization EntityKind = "organization"
+	KindDate         EntityKind = "date"
+)
+
+// Kinds lists all entity kinds.
+var Kinds = []EntityKind{KindPerson, KindPlace, KindOrganization, KindDate}
+
+// Entity is a span of text recognized as a named entity. Start and End are
+// byte offsets into the input.
+type Entity struct {
+	Kind  EntityKind `json:"kind"`
+	Text  string     `json:"text"`
+	Start int        `json:"start"`
+	End   int        `json:"end"`
+}
+
+// EntityExtractor is a lightweight, rule-based named-entity recognizer.
+// It combines date patterns, small built-in gazetteers of places and
+// organizations, the names of known chat participants, and contextual cues
+// (honorifics, "in/at/to <Name>", company suffixes). Capitalized words
+// without any cue are ignored, favouring precision over recall.
+type EntityExtractor struct {
+	people map[string]struct{}
+}
+
+// NewEntityExtractor returns an extractor that recognizes the given people
+// (typically participant display names) as persons. Both full names and
+// their individual name parts are matched.
+func NewEntityExtractor(people []string) *EntityExtractor {
+	e := &EntityExtractor{people: map[string]struct{}{}}
+	for _, name := range people {
+		name = strings.TrimSpace(name)
+		if name == "" || strings.ContainsAny(name, "@:+") {
+			continue
+		}
+		e.people[strings.ToLower(name)] = struct{}{}
+		for _, part := range strings.Fields(name) {
+			if utf8.RuneCountInString(part) >= 3 && !isStopword(strings.ToLower(part)) {
+				e.people[strings.ToLower(part)] = struct{}{}
+			}
+		}
+	}
+	return e
+}
+
+var (
+	monthPattern = `(?:Jan(?:uary)?|Feb(?:ruary)?|Mar(?:ch)?|Apr(?:il)?|May|June?|July?|Aug(?:ust)?|Sep(?:t(?:ember)?)?|Oct(?:ober)?|Nov(?:ember)?|Dec(?:ember)?)`
+	datePatterns = []*regexp.Regexp{
+		regexp.MustCompile(`\b\d{4}-\d{2}-\d{2}\b`),
+		regexp.MustCompile(`\b\d{1,2}[./]\d{1,2}[./](?:\d{4}|\d{2})\b`),
+		regexp.MustCompile(`\b` + monthPattern + `\.? \d{1,2}(?:st|nd|rd|th)?(?:,? \d{4})?\b`),
+		regexp.MustCompile(`\b\d{1,2}(?:st|nd|rd|th)?(?: of)? ` + monthPattern + `(?:,? \d{4})?\b`),
+		regexp.MustCompile(`\b(?:Monday|Tuesday|Wednesday|Thursday|Friday|Saturday|Sunday)\b`),
+	}
+)
+
+// Entities returns the entities found in text, ordered by position.
+func (e *EntityExtractor) Entities(text string) []Entity {
+	found := []Entity{}
+	covered := make([]bool, len(text))
+	mark := func(start, end int) {
+		for i := start; i < end; i++ {
+			covered[i] = true
+		}
+	}
+
+	for _, re := range datePatterns {
+		for _, loc := range re.FindAllStringIndex(text, -1) {
+			if covered[loc[0]] {
+				continue
+			}
+			found = append(found, Entity{Kind: KindDate, Text: text[loc[0]:loc[1]], Start: loc[0], End: loc[1]})
+			mark(loc[0], loc[1])
+		}
+	}
+
+	words := tokenize(text)
+	for i := 0; i < len(words); {
+		if !words[i].capitalized || covered[words[i].start] {
+			i++
+			continue
+		}
+		// Extend over a run of capitalized words, allowing lowercase
+		// connectors ("Bank of America", "Ludwig van Beethoven").
+		j := i + 1
+		for j < len(words) {
+			if words[j].capitalized && !covered[words[j].start] && !words[j-1].endsSentence {
+				j++
+				continue
+			}
+			if j+1 < len(words) && isConnector(words[j].text) && words[j+1].capitalized && !words[j-1].endsSentence {
+				j += 2
+				continue
+			}
+			break
+		}
+		prev := ""
+		if i > 0 {
+			// "Dr. Smith": the period after an honorific does not end a sentence.
+			p := strings.ToLower(words[i-1].text)
+			if _, ok := honorifics[p]; ok || !words[i-1].endsSentence {
+				prev = p
+			}
+		}
+		found = append(found, e.classifySpan(text, words[i:j], prev)...)
+		i = j
+	}
+
+	sort.Slice(found, func(a, b int) bool { return found[a].Start < found[b].Start })
+	return found
+}
+
+// classifySpan classifies a run of capitalized words, falling back to
+// classifying its words one by one.
+func (e *EntityExtractor) classifySpan(text string, span []word, prev string) []Entity {
+	start, end := span[0].start, span[len(span)-1].end
+	if kind, ok := e.classify(span, prev); ok {
+		return []Entity{{Kind: kind, Text: text[start:end], Start: start, End: end}}
+	}
+	if len(span) == 1 {
+		return nil
+	}
+	entities := []Entity{}
+	for k := range span {
+		p := prev
+		if k > 0 {
+			p = strings.ToLower(span[k-1].text)
+		}
+		if kind, ok := e.classify(span[k:k+1], p); ok {
+			entities = append(entities, Entity{Kind: kind, Text: span[k].text, Start: span[k].start, End: span[k].end})
+		}
+	}
+	return entities
+}
+
+func (e *EntityExtractor) classify(span []word, prev string) (EntityKind, bool) {
+	parts := make([]string, len(span))
+	for i, w := range span {
+		parts[i] = strings.ToLower(w.text)
+	}
+	key := strings.Join(parts, " ")
+
+	if len(span) == 1 {
+		if _, ok := honorifics[key]; ok || isStopword(key) || utf8.RuneCountInString(key) < 2 {
+			return "", false
+		}
+	}
+	if _, ok := organizations[key]; ok {
+		return KindOrganization, true
+	}
+	if len(span) > 1 {
+		// "Acme GmbH", "Bank of America", "University of Oxford".
+		for _, part := range parts {
+			if _, ok := orgSuffixes[part]; ok {
+				return KindOrganization, true
+			}
+		}
+	}
+	if _, ok := e.people[key]; ok {
+		return KindPerson, true
+	}
+	if _, ok := honorifics[prev]; ok {
+		return KindPerson, true
+	}
+	if _, ok := places[key]; ok {
+		return KindPlace, true
+	}
+	if _, ok := placePrepositions[prev]; ok && !isMonthOrDay(key) {
+		return KindPlace, true
+	}
+	return "", false
+}
+
+type word struct {
+	text         string
+	start        int
+	end          int
+	capitalized  bool
+	endsSentence bool
+}
+
+// tokenize splits text into words, keeping apostrophes, hyphens, and
+// ampersands inside words, and records whether punctuation after a word
+// ends a sentence or clause.
+func tokenize(text string) []word {
+	words := []word{}
+	i := 0
+	for i < len(text) {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(words) > 0 && strings.ContainsRune(".!?,;:()\"\n", r) {
+				words[len(words)-1].endsSentence = true
+			}
+			i += size
+			continue
+		}
+		start := i
+		for i < len(text) {
+			r, size = utf8.DecodeRuneInString(text[i:])
+			if unicode.IsLetter(r) || unicode.IsDigit(r) || ((r == '\'' || r == '’' || r == '-' || r == '&') && i+size < len(text) && isWordStart(text[i+size:])) {
+				i += size
+				continue
+			}
+			break
+		}
+		first, _ := utf8.DecodeRuneInString(text[start:])
+		words = append(words, word{text: text[start:i], start: start, end: i, capitalized: unicode.IsUpper(first)})
+	}
+	return words
+}
+
+func isWordStart(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isConnector(s string) bool {
+	switch s {
+	case "of", "de", "da", "del", "van", "von", "der", "la", "le", "am", "&":
+		return true
+	}
+	return false
+}
+
+func isMonthOrDay(s string) bool {
+	_, ok := monthsAndDays[s]
+	return ok
+}
+
+func isStopword(s string) bool {
+	_, ok := stopwords[s]
+	return ok
+}
+
+func set(values ...string) map[string]struct{} {
+	m := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		m[v] = struct{}{}
+	}
+	return m
+}
+
+var honorifics = set("mr", "mrs", "ms", "miss", "dr", "prof", "sir", "herr", "frau", "mme", "m")
+
+var placePrepositions = set("in", "at", "to", "from", "near", "via", "nach", "aus")
+
+// orgSuffixes mark a multi-word name as an organization.
+var orgSuffixes = set("inc", "ltd", "llc", "gmbh", "ag", "corp", "co", "plc", "sa", "bv", "university", "bank", "airlines", "airways", "group", "foundation", "institute", "labs", "studios", "hospital")
+
+var monthsAndDays = set(
+	"january", "february", "march", "april", "may", "june", "july", "august", "september", "october", "november", "december",
+	"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday",
+	"christmas", "easter",
+)
+
+// stopwords are capitalized words that commonly start sentences or
+// greetings and should never be entities on their own.
+var stopwords = set(
+	"i", "i'm", "i'll", "i've", "i'd", "a", "an", "the", "this", "that", "these", "those", "it", "it's", "we", "you", "he", "she", "they",
+	"my", "our", "your", "his", "her", "their", "me", "us", "them",
+	"hi", "hey", "hello", "thanks", "thank", "ok", "okay", "yes", "no", "yeah", "sure", "please", "sorry", "good", "great", "lol",
+	"what", "when", "where", "who", "why", "how", "and", "but", "or", "so", "if", "then", "also", "just", "not",
+	"today", "tomorrow", "yesterday", "tonight", "morning", "evening", "home", "work", "office",
+)
+
+var places = set(
+	// Countries and regions.
+	"germany", "france", "spain", "italy", "portugal", "austria", "switzerland", "netherlands", "belgium", "poland", "sweden", "norway", "denmark",
+	"finland", "ireland", "uk", "united kingdom", "england", "scotland", "wales", "usa", "united states", "america", "canada", "mexico", "brazil",
+	"argentina", "japan", "china", "india", "australia", "new zealand", "greece", "turkey", "egypt", "israel", "south africa", "korea", "europe", "asia", "africa",
+	// Cities.
+	"berlin", "munich", "hamburg", "cologne", "frankfurt", "vienna", "zurich", "geneva", "paris", "lyon", "london", "manchester", "edinburgh", "dublin",
+	"amsterdam", "rotterdam", "brussels", "madrid", "barcelona", "lisbon", "rome", "milan", "venice", "florence", "athens", "istanbul", "prague", "warsaw",
+	"budapest", "copenhagen", "stockholm", "oslo", "helsinki", "new york", "nyc", "san francisco", "sf", "los angeles", "la", "seattle", "chicago", "boston",
+	"austin", "miami", "washington", "toronto", "vancouver", "montreal", "tokyo", "kyoto", "osaka", "beijing", "shanghai", "hong kong", "singapore",
+	"bangkok", "sydney", "melbourne", "dubai", "tel aviv", "cairo", "cape town", "mumbai", "delhi", "bangalore", "seoul", "mexico city", "sao paulo",
+)
+
+var organizations = set(
+	"google", "apple", "microsoft", "amazon", "meta", "facebook", "instagram", "whatsapp", "telegram", "signal", "twitter", "netflix", "spotify", "uber",
+	"airbnb", "tesla", "ibm", "intel", "nvidia", "samsung", "sony", "openai", "anthropic", "github", "gitlab", "slack", "discord", "linkedin", "paypal",
+	"stripe", "shopify", "oracle", "sap", "siemens", "bosch", "bmw", "mercedes", "volkswagen", "vw", "audi", "porsche", "lufthansa", "ryanair", "easyjet",
+	"deutsche bahn", "emirates", "ikea", "zara", "h&m", "nike", "adidas", "starbucks", "mcdonald's", "nasa", "eu",
+	"nato", "unicef", "harvard", "stanford", "mit", "oxford", "cambridge", "beeper", "matrix",
+)
@@ -0,0 +1,38 @@
+package extract
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEntities(t *testing.T) {
+	e := NewEntityExtractor([]string{"Alice Smith", "@bob:beeper.local"})
+
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Meeting with Google in Berlin on Monday?", []string{"organization:Google", "place:Berlin", "date:Monday"}},
+		{"Thanks Alice!", []string{"person:Alice"}},
+		{"Dinner on March 3rd at Paris Bistro?", []string{"date:March 3rd", "place:Paris Bistro"}},
+		{"Ask Dr. Müller about the 2024-05-01 invoice", []string{"person:Müller", "date:2024-05-01"}},
+		{"Flights booked with Acme Widgets GmbH, hotel near Alexanderplatz", []string{"organization:Acme Widgets GmbH", "place:Alexanderplatz"}},
+		{"I'll call you tomorrow. Sure thing", nil},
+		{"Alice Smith joined Bank of America on 12 June 2021", []string{"person:Alice Smith", "organization:Bank of America", "date:12 June 2021"}},
+	}
+	for _, tt := range tests {
+		got := []string{}
+		for _, ent := range e.Entities(tt.text) {
+			got = append(got, string(ent.Kind)+":"+ent.Text)
+			if tt.text[ent.Start:ent.End] != ent.Text {
+				t.Fatalf("%q: offsets %d-%d do not match %q", tt.text, ent.Start, ent.End, ent.Text)
+			}
+		}
+		if len(tt.want) == 0 && len(got) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("%q: got %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
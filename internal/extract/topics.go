@@ -0,0 +1,366 @@
+package extract
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+)
+
+// TopicOptions controls topic clustering.
+type TopicOptions struct {
+	// Topics is the number of clusters; zero picks one from the history size.
+	Topics int
+	// Excerpts is the number of representative messages per topic.
+	Excerpts int
+}
+
+// Topic is a cluster of conversation sessions about similar things.
+type Topic struct {
+	Label    string    `json:"label"`
+	Keywords []string  `json:"keywords"`
+	Sessions int       `json:"sessions"`
+	Messages int       `json:"messages"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Excerpts []Excerpt `json:"excerpts"`
+}
+
+// Excerpt is a message that represents a topic well.
+type Excerpt struct {
+	EventID   string    `json:"eventId"`
+	Timestamp time.Time `json:"timestamp"`
+	Sender    string    `json:"sender"`
+	Text      string    `json:"text"`
+}
+
+const (
+	maxTopics      = 12
+	topicKeywords  = 5
+	excerptRunes   = 120
+	kmeansRounds   = 25
+	minExcerptSize = 20
+)
+
+// Topics clusters messages into topics. Messages must be in chronological
+// order and carry session IDs (see beeper.SegmentSessions); each session is
+// one document. Documents are weighted with TF-IDF and grouped with
+// spherical k-means using a deterministic farthest-first seeding, so the
+// same history always yields the same topics. Topics are ordered by the
+// date they first came up.
+func Topics(messages []beeper.Message, opts TopicOptions) []Topic {
+	docs := buildTopicDocs(messages)
+	if len(docs) == 0 {
+		return []Topic{}
+	}
+	weighTopicDocs(docs)
+
+	k := opts.Topics
+	if k <= 0 {
+		k = int(math.Round(math.Sqrt(float64(len(docs)) / 2)))
+	}
+	k = max(1, min(k, maxTopics, len(docs)))
+	assign, centroids := kmeans(docs, k)
+
+	topics := []Topic{}
+	for c, centroid := range centroids {
+		members := []*topicDoc{}
+		for i, doc := range docs {
+			if assign[i] == c {
+				members = append(members, doc)
+			}
+		}
+		if len(members) == 0 {
+			continue
+		}
+		topic := Topic{Keywords: topTerms(centroid, topicKeywords), Sessions: len(members)}
+		for _, doc := range members {
+			topic.Messages += len(doc.messages)
+			if topic.Start.IsZero() || doc.messages[0].Timestamp.Before(topic.Start) {
+				topic.Start = doc.messages[0].Timestamp
+			}
+			if last := doc.messages[len(doc.messages)-1].Timestamp; last.After(topic.End) {
+				topic.End = last
+			}
+		}
+		topic.Label = strings.Join(topic.Keywords, ", ")
+		topic.Excerpts = excerpts(members, centroid, opts.Excerpts)
+		topics = append(topics, topic)
+	}
+	sort.SliceStable(topics, func(i, j int) bool { return topics[i].Start.Before(topics[j].Start) })
+	return topics
+}
+
+type topicDoc struct {
+	messages []beeper.Message
+	terms    map[string]float64
+	vector   map[string]float64
+}
+
+func buildTopicDocs(messages []beeper.Message) []*topicDoc {
+	docs := []*topicDoc{}
+	byID := map[string]*topicDoc{}
+	for _, msg := range messages {
+		key := msg.SessionID
+		if key == "" {
+			key = msg.ThreadID
+		}
+		doc, ok := byID[key]
+		if !ok {
+			doc = &topicDoc{terms: map[string]float64{}}
+			byID[key] = doc
+			docs = append(docs, doc)
+		}
+		doc.messages = append(doc.messages, msg)
+		for _, term := range topicTerms(msg.Text) {
+			doc.terms[term]++
+		}
+	}
+	kept := docs[:0]
+	for _, doc := range docs {
+		if len(doc.terms) > 0 {
+			kept = append(kept, doc)
+		}
+	}
+	return kept
+}
+
+// weighTopicDocs sets each document's L2-normalized TF-IDF vector. Terms in
+// only one document or in more than half of them carry no topic signal and
+// are dropped once there are enough documents to tell.
+func weighTopicDocs(docs []*topicDoc) {
+	df := map[string]int{}
+	for _, doc := range docs {
+		for term := range doc.terms {
+			df[term]++
+		}
+	}
+	n := float64(len(docs))
+	for _, doc := range docs {
+		doc.vector = map[string]float64{}
+		for term, tf := range doc.terms {
+			if len(docs) >= 6 && (df[term] < 2 || float64(df[term]) > n/2) {
+				continue
+			}
+			doc.vector[term] = (1 + math.Log(tf)) * math.Log(1+n/float64(df[term]))
+		}
+		normalize(doc.vector)
+	}
+}
+
+// kmeans runs spherical k-means and returns each document's cluster and the
+// cluster centroids.
+func kmeans(docs []*topicDoc, k int) ([]int, []map[string]float64) {
+	centroids := seedCentroids(docs, k)
+	assign := make([]int, len(docs))
+	for round := 0; round < kmeansRounds; round++ {
+		changed := false
+		for i, doc := range docs {
+			best, bestSim := 0, -1.0
+			for c, centroid := range centroids {
+				if sim := dot(doc.vector, centroid); sim > bestSim {
+					best, bestSim = c, sim
+				}
+			}
+			if round == 0 || assign[i] != best {
+				changed = true
+			}
+			assign[i] = best
+		}
+		if !changed {
+			break
+		}
+		for c := range centroids {
+			sum := map[string]float64{}
+			for i, doc := range docs {
+				if assign[i] != c {
+					continue
+				}
+				for term, w := range doc.vector {
+					sum[term] += w
+				}
+			}
+			if len(sum) > 0 {
+				normalize(sum)
+				centroids[c] = sum
+			}
+		}
+	}
+	return assign, centroids
+}
+
+// seedCentroids starts from the longest document and repeatedly adds the
+// document least similar to every chosen seed.
+func seedCentroids(docs []*topicDoc, k int) []map[string]float64 {
+	first := 0
+	for i, doc := range docs {
+		if len(doc.vector) > len(docs[first].vector) {
+			first = i
+		}
+	}
+	chosen := []int{first}
+	closest := make([]float64, len(docs))
+	for i, doc := range docs {
+		closest[i] = dot(doc.vector, docs[first].vector)
+	}
+	for len(chosen) < k {
+		next := -1
+		for i := range docs {
+			if slices.Contains(chosen, i) {
+				continue
+			}
+			if next < 0 || closest[i] < closest[next] {
+				next = i
+			}
+		}
+		chosen = append(chosen, next)
+		for i, doc := range docs {
+			closest[i] = math.Max(closest[i], dot(doc.vector, docs[next].vector))
+		}
+	}
+	centroids := make([]map[string]float64, len(chosen))
+	for c, i := range chosen {
+		centroids[c] = map[string]float64{}
+		for term, w := range docs[i].vector {
+			centroids[c][term] = w
+		}
+	}
+	return centroids
+}
+
+// excerpts picks the messages whose words weigh most in the centroid,
+// preferring messages long enough to be informative.
+func excerpts(docs []*topicDoc, centroid map[string]float64, n int) []Excerpt {
+	if n <= 0 {
+		return []Excerpt{}
+	}
+	type scored struct {
+		msg   beeper.Message
+		score float64
+	}
+	candidates := []scored{}
+	for _, doc := range docs {
+		for _, msg := range doc.messages {
+			terms := topicTerms(msg.Text)
+			if len(terms) == 0 {
+				continue
+			}
+			score := 0.0
+			for _, term := range terms {
+				score += centroid[term]
+			}
+			score /= math.Sqrt(float64(len(terms)))
+			if utf8.RuneCountInString(msg.Text) < minExcerptSize {
+				score /= 2
+			}
+			candidates = append(candidates, scored{msg: msg, score: score})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+
+	result := []Excerpt{}
+	for _, c := range candidates[:min(n, len(candidates))] {
+		sender := c.msg.SenderName
+		if sender == "" {
+			sender = c.msg.SenderID
+		}
+		if c.msg.IsSentByMe {
+			sender = "Me"
+		}
+		result = append(result, Excerpt{
+			EventID:   c.msg.EventID,
+			Timestamp: c.msg.Timestamp,
+			Sender:    sender,
+			Text:      beeper.Preview(c.msg.Text, excerptRunes),
+		})
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Timestamp.Before(result[j].Timestamp) })
+	return result
+}
+
+func topTerms(vector map[string]float64, n int) []string {
+	terms := make([]string, 0, len(vector))
+	for term := range vector {
+		terms = append(terms, term)
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if vector[terms[i]] != vector[terms[j]] {
+			return vector[terms[i]] > vector[terms[j]]
+		}
+		return terms[i] < terms[j]
+	})
+	return terms[:min(n, len(terms))]
+}
+
+// topicTerms lowercases text and returns its content words: at least three
+// letters, not a stopword, not a URL.
+func topicTerms(text string) []string {
+	terms := []string{}
+	for _, field := range strings.Fields(text) {
+		if strings.Contains(field, "://") || strings.HasPrefix(field, "[") {
+			continue
+		}
+		for _, term := range strings.FieldsFunc(strings.ToLower(field), func(r rune) bool {
+			return !unicode.IsLetter(r) && r != '\''
+		}) {
+			term = strings.Trim(term, "'")
+			if utf8.RuneCountInString(term) < 3 || isStopword(term) || isTopicStopword(term) {
+				continue
+			}
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+func normalize(v map[string]float64) {
+	sum := 0.0
+	for _, w := range v {
+		sum += w * w
+	}
+	if sum == 0 {
+		return
+	}
+	norm := math.Sqrt(sum)
+	for term := range v {
+		v[term] /= norm
+	}
+}
+
+func dot(a, b map[string]float64) float64 {
+	if len(b) < len(a) {
+		a, b = b, a
+	}
+	sum := 0.0
+	for term, w := range a {
+		sum += w * b[term]
+	}
+	return sum
+}
+
+func isTopicStopword(s string) bool {
+	_, ok := topicStopwords[s]
+	return ok
+}
+
+// topicStopwords are frequent function words and chat filler that would
+// otherwise dominate every topic.
+var topicStopwords = set(
+	"about", "above", "after", "again", "all", "am", "any", "are", "aren't", "because", "been", "before", "being", "both", "can", "can't",
+	"could", "did", "didn't", "does", "doesn't", "doing", "don't", "down", "each", "few", "for", "from", "further", "had", "has", "have",
+	"having", "here", "how's", "into", "isn't", "its", "let's", "more", "most", "much", "must", "myself", "nor", "now", "off", "once", "only",
+	"other", "ought", "ours", "out", "over", "own", "same", "should", "some", "such", "than", "that's", "there", "there's", "they're",
+	"through", "too", "under", "until", "very", "was", "wasn't", "were", "weren't", "what's", "when's", "where's", "which", "while",
+	"who's", "whom", "will", "with", "won't", "would", "wouldn't", "you're", "you've", "you'll", "yours", "get", "got", "going", "gonna",
+	"like", "know", "think", "want", "really", "one", "see", "yes", "yeah", "yep", "nope", "haha", "hahaha", "lol", "omg", "btw", "thx",
+	"thanks", "okay", "cool", "nice", "well", "still", "back", "time", "way", "make", "need", "let", "say", "said", "tell", "come", "take",
+	"day", "even", "new", "right", "next", "last", "i'm", "i'll", "it's", "we're", "we'll", "he's", "she's", "mean", "guess",
+	// German filler, common in mixed-language chats.
+	"und", "der", "die", "das", "ich", "du", "ist", "nicht", "ein", "eine", "mit", "auf", "für", "aber", "auch", "noch", "schon", "dann",
+	"wie", "was", "wir", "ihr", "sie", "mal", "bin", "bist", "sind", "hab", "habe", "haben", "kann", "oder", "wenn", "jetzt", "gut", "ganz",
+)
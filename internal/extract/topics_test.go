@@ -0,0 +1,56 @@
+package extract
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+)
+
+func TestTopics(t *testing.T) {
+	base := time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+	texts := []string{
+		"Booked the flight to Lisbon, hotel is near the beach",
+		"Vacation plans: flight leaves Friday, hotel check-in at noon",
+		"Did anyone pack sunscreen for the beach vacation?",
+		"The apartment rent is going up again next month",
+		"Landlord wants the rent transfer by the first, apartment inspection too",
+		"Found a cheaper apartment, rent includes heating",
+		"Hotel pool and beach were amazing, best vacation flight ever",
+		"Signed the lease for the new apartment, rent is fine",
+	}
+	messages := []beeper.Message{}
+	for i, text := range texts {
+		messages = append(messages, beeper.Message{
+			ThreadID:   "!a",
+			EventID:    "$" + string(rune('a'+i)),
+			SenderName: "Bob",
+			Timestamp:  base.Add(time.Duration(i) * 24 * time.Hour),
+			Text:       text,
+		})
+	}
+	beeper.SegmentSessions(messages, time.Hour)
+
+	topics := Topics(messages, TopicOptions{Topics: 2, Excerpts: 2})
+	if len(topics) != 2 {
+		t.Fatalf("expected 2 topics, got %+v", topics)
+	}
+	travel, housing := topics[0], topics[1]
+	if !slices.Contains(travel.Keywords, "vacation") || !slices.Contains(housing.Keywords, "rent") {
+		t.Fatalf("unexpected keywords: %v / %v", travel.Keywords, housing.Keywords)
+	}
+	if travel.Sessions != 4 || housing.Sessions != 4 || travel.Messages != 4 {
+		t.Fatalf("unexpected sizes: %+v / %+v", travel, housing)
+	}
+	if !travel.Start.Equal(base) || !travel.End.Equal(base.Add(6*24*time.Hour)) {
+		t.Fatalf("unexpected travel range: %s - %s", travel.Start, travel.End)
+	}
+	if len(housing.Excerpts) != 2 || housing.Excerpts[0].Sender != "Bob" || housing.Excerpts[0].Timestamp.After(housing.Excerpts[1].Timestamp) {
+		t.Fatalf("unexpected excerpts: %+v", housing.Excerpts)
+	}
+
+	if got := Topics(nil, TopicOptions{}); len(got) != 0 {
+		t.Fatalf("expected no topics for empty history, got %+v", got)
+	}
+}
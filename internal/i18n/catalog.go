@@ -0,0 +1,263 @@
+package i18n
+
+var german = &Locale{
+	Tag:       "de",
+	DateTime:  "02.01.2006 15:04:05",
+	Date:      "02.01.2006",
+	LongDate:  "Monday, 2. January 2006",
+	ShortDate: "Mon, 2. Jan 2006",
+
+	months:        [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+	shortMonths:   [12]string{"Jan.", "Feb.", "März", "Apr.", "Mai", "Juni", "Juli", "Aug.", "Sep.", "Okt.", "Nov.", "Dez."},
+	weekdays:      [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+	shortWeekdays: [7]string{"So", "Mo", "Di", "Mi", "Do", "Fr", "Sa"},
+	labels: map[string]string{
+		"ACCOUNT":             "KONTO",
+		"BRIDGE LAST MESSAGE": "LETZTE BRIDGE-NACHRICHT",
+		"BRIDGE UPDATED":      "BRIDGE AKTUALISIERT",
+		"BY":                  "VON",
+		"CACHED":              "IM CACHE",
+		"CONTACT":             "KONTAKT",
+		"DURATION":            "DAUER",
+		"ENTITY":              "ENTITÄT",
+		"FIELD":               "FELD",
+		"FILE":                "DATEI",
+		"FREQUENCY":           "HÄUFIGKEIT",
+		"KIND":                "ART",
+		"LAST CONTACT":        "LETZTER KONTAKT",
+		"LAST MESSAGE":        "LETZTE NACHRICHT",
+		"LAST RECEIVED":       "ZULETZT EMPFANGEN",
+		"LAST SENT":           "ZULETZT GESENDET",
+		"MENTIONS":            "ERWÄHNUNGEN",
+		"MESSAGES":            "NACHRICHTEN",
+		"MSGS":                "NACHR.",
+		"PATH":                "PFAD",
+		"PENDING":             "AUSSTEHEND",
+		"PLATFORMS":           "PLATTFORMEN",
+		"PREVIOUS VERSIONS":   "FRÜHERE VERSIONEN",
+		"RANK":                "RANG",
+		"REACTED":             "REAKTION",
+		"REACTIONS":           "REAKTIONEN",
+		"RECEIVED":            "EMPFANGEN",
+		"RECENCY":             "AKTUALITÄT",
+		"RECIPROCITY":         "GEGENSEITIGKEIT",
+		"REPLIES":             "ANTWORTEN",
+		"ROWS":                "ZEILEN",
+		"SCORE":               "WERTUNG",
+		"SENDER":              "ABSENDER",
+		"SENT":                "GESENDET",
+		"SESSION":             "SITZUNG",
+		"SIZE":                "GRÖSSE",
+		"START":               "BEGINN",
+		"STORED":              "GESPEICHERT",
+		"SUMMARY":             "ZUSAMMENFASSUNG",
+		"TABLE":               "TABELLE",
+		"THREAD":              "CHAT",
+		"THREAD_ID":           "CHAT_ID",
+		"THREADS":             "CHATS",
+		"TIME":                "ZEIT",
+		"TYPE":                "TYP",
+		"UNREAD":              "UNGELESEN",
+		"UPDATED":             "AKTUALISIERT",
+		"VALUE":               "WERT",
+		"WITH":                "MIT",
+
+		"Image":             "Bild",
+		"Video":             "Video",
+		"Audio":             "Audio",
+		"Audio message":     "Sprachnachricht",
+		"File":              "Datei",
+		"Location":          "Standort",
+		"Contact":           "Kontakt",
+		"Sticker":           "Sticker",
+		"Not yet decrypted": "Noch nicht entschlüsselt",
+		"Expired message":   "Abgelaufene Nachricht",
+
+		"Me":          "Ich",
+		"[deleted]":   "[gelöscht]",
+		"(edited)":    "(bearbeitet)",
+		"with %s":     "mit %s",
+		"%d messages": "%d Nachrichten",
+		"%s to %s":    "%s bis %s",
+
+		"Next cursor: %s": "Nächster Cursor: %s",
+		"next cursor: %s": "nächster Cursor: %s",
+		"showing the first %d rows; raise --limit (0 for all) to see more": "die ersten %d Zeilen; mehr mit höherem --limit (0 für alle)",
+	},
+}
+
+var french = &Locale{
+	Tag:       "fr",
+	DateTime:  "02/01/2006 15:04:05",
+	Date:      "02/01/2006",
+	LongDate:  "Monday 2 January 2006",
+	ShortDate: "Mon 2 Jan 2006",
+
+	months:        [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+	shortMonths:   [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+	weekdays:      [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+	shortWeekdays: [7]string{"dim.", "lun.", "mar.", "mer.", "jeu.", "ven.", "sam."},
+	labels: map[string]string{
+		"ACCOUNT":             "COMPTE",
+		"BRIDGE LAST MESSAGE": "DERNIER MESSAGE PASSERELLE",
+		"BRIDGE UPDATED":      "PASSERELLE MISE À JOUR",
+		"BY":                  "PAR",
+		"CACHED":              "EN CACHE",
+		"CONTACT":             "CONTACT",
+		"DURATION":            "DURÉE",
+		"ENTITY":              "ENTITÉ",
+		"FIELD":               "CHAMP",
+		"FILE":                "FICHIER",
+		"FREQUENCY":           "FRÉQUENCE",
+		"KIND":                "GENRE",
+		"LAST CONTACT":        "DERNIER CONTACT",
+		"LAST MESSAGE":        "DERNIER MESSAGE",
+		"LAST RECEIVED":       "DERNIER REÇU",
+		"LAST SENT":           "DERNIER ENVOYÉ",
+		"MENTIONS":            "MENTIONS",
+		"MESSAGES":            "MESSAGES",
+		"MSGS":                "MSGS",
+		"NAME":                "NOM",
+		"PATH":                "CHEMIN",
+		"PENDING":             "EN ATTENTE",
+		"PLATFORMS":           "PLATEFORMES",
+		"PREVIOUS VERSIONS":   "VERSIONS PRÉCÉDENTES",
+		"RANK":                "RANG",
+		"REACTED":             "RÉACTION",
+		"REACTIONS":           "RÉACTIONS",
+		"RECEIVED":            "REÇUS",
+		"RECENCY":             "RÉCENCE",
+		"RECIPROCITY":         "RÉCIPROCITÉ",
+		"REPLIES":             "RÉPONSES",
+		"ROWS":                "LIGNES",
+		"SCORE":               "SCORE",
+		"SENDER":              "EXPÉDITEUR",
+		"SENT":                "ENVOYÉS",
+		"SESSION":             "SESSION",
+		"SIZE":                "TAILLE",
+		"START":               "DÉBUT",
+		"STATUS":              "STATUT",
+		"STORED":              "STOCKÉS",
+		"SUMMARY":             "RÉSUMÉ",
+		"TABLE":               "TABLE",
+		"THREAD":              "DISCUSSION",
+		"THREAD_ID":           "ID_DISCUSSION",
+		"THREADS":             "DISCUSSIONS",
+		"TIME":                "HEURE",
+		"TYPE":                "TYPE",
+		"UNREAD":              "NON LUS",
+		"UPDATED":             "MIS À JOUR",
+		"VALUE":               "VALEUR",
+		"WITH":                "AVEC",
+
+		"Image":             "Image",
+		"Video":             "Vidéo",
+		"Audio":             "Audio",
+		"Audio message":     "Message vocal",
+		"File":              "Fichier",
+		"Location":          "Position",
+		"Contact":           "Contact",
+		"Sticker":           "Autocollant",
+		"Not yet decrypted": "Pas encore déchiffré",
+		"Expired message":   "Message expiré",
+
+		"Me":          "Moi",
+		"[deleted]":   "[supprimé]",
+		"(edited)":    "(modifié)",
+		"with %s":     "avec %s",
+		"%d messages": "%d messages",
+		"%s to %s":    "du %s au %s",
+
+		"Next cursor: %s": "Curseur suivant : %s",
+		"next cursor: %s": "curseur suivant : %s",
+		"showing the first %d rows; raise --limit (0 for all) to see more": "affichage des %d premières lignes ; augmentez --limit (0 pour tout) pour en voir plus",
+	},
+}
+
+var spanish = &Locale{
+	Tag:       "es",
+	DateTime:  "02/01/2006 15:04:05",
+	Date:      "02/01/2006",
+	LongDate:  "Monday, 2 de January de 2006",
+	ShortDate: "Mon, 2 Jan 2006",
+
+	months:        [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+	shortMonths:   [12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sept", "oct", "nov", "dic"},
+	weekdays:      [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+	shortWeekdays: [7]string{"dom", "lun", "mar", "mié", "jue", "vie", "sáb"},
+	labels: map[string]string{
+		"ACCOUNT":             "CUENTA",
+		"BRIDGE LAST MESSAGE": "ÚLTIMO MENSAJE DEL PUENTE",
+		"BRIDGE UPDATED":      "PUENTE ACTUALIZADO",
+		"BY":                  "POR",
+		"CACHED":              "EN CACHÉ",
+		"CONTACT":             "CONTACTO",
+		"DURATION":            "DURACIÓN",
+		"ENTITY":              "ENTIDAD",
+		"FIELD":               "CAMPO",
+		"FILE":                "ARCHIVO",
+		"FREQUENCY":           "FRECUENCIA",
+		"KIND":                "CLASE",
+		"LAST CONTACT":        "ÚLTIMO CONTACTO",
+		"LAST MESSAGE":        "ÚLTIMO MENSAJE",
+		"LAST RECEIVED":       "ÚLTIMO RECIBIDO",
+		"LAST SENT":           "ÚLTIMO ENVIADO",
+		"MENTIONS":            "MENCIONES",
+		"MESSAGES":            "MENSAJES",
+		"MSGS":                "MSJS",
+		"NAME":                "NOMBRE",
+		"PATH":                "RUTA",
+		"PENDING":             "PENDIENTES",
+		"PLATFORMS":           "PLATAFORMAS",
+		"PREVIOUS VERSIONS":   "VERSIONES ANTERIORES",
+		"RANK":                "PUESTO",
+		"REACTED":             "REACCIÓN",
+		"REACTIONS":           "REACCIONES",
+		"RECEIVED":            "RECIBIDOS",
+		"RECENCY":             "RECENCIA",
+		"RECIPROCITY":         "RECIPROCIDAD",
+		"REPLIES":             "RESPUESTAS",
+		"ROWS":                "FILAS",
+		"SCORE":               "PUNTUACIÓN",
+		"SENDER":              "REMITENTE",
+		"SENT":                "ENVIADOS",
+		"SESSION":             "SESIÓN",
+		"SIZE":                "TAMAÑO",
+		"START":               "INICIO",
+		"STATUS":              "ESTADO",
+		"STORED":              "GUARDADOS",
+		"SUMMARY":             "RESUMEN",
+		"TABLE":               "TABLA",
+		"THREAD":              "CHAT",
+		"THREAD_ID":           "ID_CHAT",
+		"THREADS":             "CHATS",
+		"TIME":                "HORA",
+		"TYPE":                "TIPO",
+		"UNREAD":              "NO LEÍDOS",
+		"UPDATED":             "ACTUALIZADO",
+		"VALUE":               "VALOR",
+		"WITH":                "CON",
+
+		"Image":             "Imagen",
+		"Video":             "Vídeo",
+		"Audio":             "Audio",
+		"Audio message":     "Mensaje de voz",
+		"File":              "Archivo",
+		"Location":          "Ubicación",
+		"Contact":           "Contacto",
+		"Sticker":           "Sticker",
+		"Not yet decrypted": "Aún no descifrado",
+		"Expired message":   "Mensaje caducado",
+
+		"Me":          "Yo",
+		"[deleted]":   "[eliminado]",
+		"(edited)":    "(editado)",
+		"with %s":     "con %s",
+		"%d messages": "%d mensajes",
+		"%s to %s":    "del %s al %s",
+
+		"Next cursor: %s": "Siguiente cursor: %s",
+		"next cursor: %s": "siguiente cursor: %s",
+		"showing the first %d rows; raise --limit (0 for all) to see more": "se muestran las primeras %d filas; aumenta --limit (0 para todas) para ver más",
+	},
+}
@@ -0,0 +1,5 @@
+// Package i18n localizes human-readable output: date layouts with
+// translated month and weekday names, table column headers, and the
+// placeholders that stand in for attachments. Machine-readable output
+// (JSON, NDJSON, CSV) is never localized.
+package i18n
@@ -0,0 +1,151 @@
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Locale is a language for human-readable output.
+type Locale struct {
+	// Tag is the language code, such as "de".
+	Tag string
+	// DateTime, Date, LongDate, and ShortDate are Go time layouts for table
+	// timestamps, dates, day headings in exports, and day separators.
+	DateTime  string
+	Date      string
+	LongDate  string
+	ShortDate string
+
+	months        [12]string
+	shortMonths   [12]string
+	weekdays      [7]string
+	shortWeekdays [7]string
+	// labels translates English headers, placeholders, and phrases.
+	labels map[string]string
+}
+
+// English is the default locale and leaves output as it always was.
+var English = &Locale{
+	Tag:       "en",
+	DateTime:  "2006-01-02 15:04:05",
+	Date:      "2006-01-02",
+	LongDate:  "Monday, 2 January 2006",
+	ShortDate: "Mon, 2 Jan 2006",
+}
+
+var locales = map[string]*Locale{
+	"en": English,
+	"de": german,
+	"fr": french,
+	"es": spanish,
+}
+
+// Supported returns the supported language codes.
+func Supported() []string {
+	tags := make([]string, 0, len(locales))
+	for tag := range locales {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// Parse returns the locale for a language code; region suffixes such as
+// "de-AT" or "de_DE.UTF-8" are ignored. An empty tag is English.
+func Parse(tag string) (*Locale, error) {
+	base := strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(base, "-_."); i >= 0 {
+		base = base[:i]
+	}
+	if base == "" {
+		return English, nil
+	}
+	if l, ok := locales[base]; ok {
+		return l, nil
+	}
+	return nil, fmt.Errorf("unsupported locale %q (supported: %s)", tag, strings.Join(Supported(), ", "))
+}
+
+// T translates an English label, or returns it unchanged.
+func (l *Locale) T(label string) string {
+	if translated, ok := l.labels[label]; ok {
+		return translated
+	}
+	return label
+}
+
+// Tf translates an English format string and formats it.
+func (l *Locale) Tf(format string, args ...any) string {
+	return fmt.Sprintf(l.T(format), args...)
+}
+
+// Header translates each tab-separated column of a table header.
+func (l *Locale) Header(header string) string {
+	if l.labels == nil {
+		return header
+	}
+	columns := strings.Split(header, "\t")
+	for i, column := range columns {
+		columns[i] = l.T(column)
+	}
+	return strings.Join(columns, "\t")
+}
+
+// placeholders are the attachment, decryption, and expiry placeholders
+// of rich message text, as in "[Image]" or "[File: report.pdf]".
+var placeholders = []string{"Not yet decrypted", "Expired message", "Audio message", "Image", "Video", "Audio", "File", "Location", "Contact", "Sticker"}
+
+// Text translates the attachment placeholder a rich message text starts
+// with, if any; the rest of the text is left alone.
+func (l *Locale) Text(text string) string {
+	if l.labels == nil || !strings.HasPrefix(text, "[") {
+		return text
+	}
+	for _, p := range placeholders {
+		rest, ok := strings.CutPrefix(text[1:], p)
+		if ok && (strings.HasPrefix(rest, "]") || strings.HasPrefix(rest, ":")) {
+			return "[" + l.T(p) + rest
+		}
+	}
+	return text
+}
+
+// Format is time.Format with the locale's month and weekday names.
+func (l *Locale) Format(t time.Time, layout string) string {
+	if l.labels == nil {
+		return t.Format(layout)
+	}
+	var b strings.Builder
+	start := 0
+	for i := 0; i < len(layout); {
+		name, n := l.name(t, layout[i:])
+		if n == 0 {
+			i++
+			continue
+		}
+		b.WriteString(t.Format(layout[start:i]))
+		b.WriteString(name)
+		i += n
+		start = i
+	}
+	b.WriteString(t.Format(layout[start:]))
+	return b.String()
+}
+
+// name returns the localized name for a month or weekday element at the
+// start of layout and the element's length, or 0 if there is none.
+func (l *Locale) name(t time.Time, layout string) (string, int) {
+	switch {
+	case strings.HasPrefix(layout, "January"):
+		return l.months[t.Month()-1], len("January")
+	case strings.HasPrefix(layout, "Jan"):
+		return l.shortMonths[t.Month()-1], len("Jan")
+	case strings.HasPrefix(layout, "Monday"):
+		return l.weekdays[t.Weekday()], len("Monday")
+	case strings.HasPrefix(layout, "Mon"):
+		return l.shortWeekdays[t.Weekday()], len("Mon")
+	}
+	return "", 0
+}
@@ -0,0 +1,78 @@
+package i18n
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	for _, tag := range []string{"de", "DE", "de-AT", "de_DE.UTF-8"} {
+		l, err := Parse(tag)
+		if err != nil || l.Tag != "de" {
+			t.Fatalf("Parse(%q) = %v, %v", tag, l, err)
+		}
+	}
+	if l, err := Parse(""); err != nil || l != English {
+		t.Fatalf("expected English for an empty tag, got %v, %v", l, err)
+	}
+	if _, err := Parse("xx"); err == nil {
+		t.Fatalf("expected an error for an unsupported locale")
+	}
+}
+
+func TestFormat(t *testing.T) {
+	ts := time.Date(2025, time.March, 5, 9, 7, 0, 0, time.UTC)
+	tests := []struct {
+		tag    string
+		layout func(*Locale) string
+		want   string
+	}{
+		{"en", func(l *Locale) string { return l.LongDate }, "Wednesday, 5 March 2025"},
+		{"en", func(l *Locale) string { return l.DateTime }, "2025-03-05 09:07:00"},
+		{"de", func(l *Locale) string { return l.LongDate }, "Mittwoch, 5. März 2025"},
+		{"de", func(l *Locale) string { return l.ShortDate }, "Mi, 5. März 2025"},
+		{"de", func(l *Locale) string { return l.DateTime }, "05.03.2025 09:07:00"},
+		{"fr", func(l *Locale) string { return l.LongDate }, "mercredi 5 mars 2025"},
+		{"es", func(l *Locale) string { return l.LongDate }, "miércoles, 5 de marzo de 2025"},
+	}
+	for _, tt := range tests {
+		l, err := Parse(tt.tag)
+		if err != nil {
+			t.Fatalf("parse %q: %v", tt.tag, err)
+		}
+		if got := l.Format(ts, tt.layout(l)); got != tt.want {
+			t.Fatalf("%s: got %q, want %q", tt.tag, got, tt.want)
+		}
+	}
+}
+
+func TestLabels(t *testing.T) {
+	de, err := Parse("de")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if got := de.Header("TIME\tSENDER\tTEXT\tNEW COLUMN"); got != "ZEIT\tABSENDER\tTEXT\tNEW COLUMN" {
+		t.Fatalf("unexpected header %q", got)
+	}
+	if got := English.Header("TIME\tSENDER"); got != "TIME\tSENDER" {
+		t.Fatalf("English header changed: %q", got)
+	}
+	tests := map[string]string{
+		"[Image] sunset":          "[Bild] sunset",
+		"[File: report.pdf]":      "[Datei: report.pdf]",
+		"[Audio message]":         "[Sprachnachricht]",
+		"[Imagine] that":          "[Imagine] that",
+		"see [Image] in the text": "see [Image] in the text",
+	}
+	for in, want := range tests {
+		if got := de.Text(in); got != want {
+			t.Fatalf("Text(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if got := de.Tf("%d messages", 3); got != "3 Nachrichten" {
+		t.Fatalf("unexpected phrase %q", got)
+	}
+	if got := de.Tf("Next cursor: %s", "abc"); got != "Nächster Cursor: abc" {
+		t.Fatalf("unexpected paging hint %q", got)
+	}
+}
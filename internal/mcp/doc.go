@@ -0,0 +1,3 @@
+// Package mcp serves the read-only Store API as Model Context Protocol
+// tools over stdio, so desktop LLM clients can query local chat history.
+package mcp
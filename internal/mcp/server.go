@@ -0,0 +1,151 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+)
+
+// Store is the part of *beeper.Store exposed as tools.
+type Store interface {
+	ListThreads(ctx context.Context, opts beeper.ThreadListOptions) ([]beeper.Thread, error)
+	GetThread(ctx context.Context, threadID string, withStats bool) (beeper.Thread, error)
+	FindThreadsByName(ctx context.Context, name string) ([]beeper.Thread, error)
+	ListMessages(ctx context.Context, opts beeper.MessageListOptions) ([]beeper.Message, error)
+	SearchMessages(ctx context.Context, opts beeper.SearchOptions) ([]beeper.SearchResult, error)
+	ListContacts(ctx context.Context, opts beeper.ContactOptions) ([]beeper.Contact, error)
+}
+
+// Server answers MCP requests against a Store.
+type Server struct {
+	store   Store
+	version string
+	tools   []tool
+}
+
+// NewServer returns a server exposing store's read-only queries as tools.
+// version is reported to clients as the server version.
+func NewServer(store Store, version string) *Server {
+	s := &Server{store: store, version: version}
+	s.tools = s.defineTools()
+	return s
+}
+
+// Tools returns the tool definitions advertised to clients.
+func (s *Server) Tools() []Tool {
+	tools := make([]Tool, 0, len(s.tools))
+	for _, t := range s.tools {
+		tools = append(tools, t.Tool)
+	}
+	return tools
+}
+
+// Serve reads newline-delimited JSON-RPC messages from r and writes
+// responses to w until r is exhausted or ctx is cancelled.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	enc := json.NewEncoder(w)
+	for scanner.Scan() {
+		if ctx.Err() != nil {
+			return nil
+		}
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		resp, ok := s.handle(ctx, line)
+		if !ok {
+			continue
+		}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// handle processes one message; ok is false for notifications, which get
+// no response.
+func (s *Server) handle(ctx context.Context, line []byte) (response, bool) {
+	var req request
+	if err := json.Unmarshal(line, &req); err != nil {
+		return errorResponse(json.RawMessage("null"), codeParseError, "parse error: "+err.Error()), true
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		if req.isNotification() {
+			return response{}, false
+		}
+		return errorResponse(req.ID, codeInvalidRequest, "invalid request"), true
+	}
+	if req.isNotification() {
+		// notifications/initialized, notifications/cancelled, ...
+		return response{}, false
+	}
+
+	switch req.Method {
+	case "initialize":
+		var params initializeParams
+		_ = json.Unmarshal(req.Params, &params)
+		version := protocolVersions[0]
+		if slices.Contains(protocolVersions, params.ProtocolVersion) {
+			version = params.ProtocolVersion
+		}
+		return resultResponse(req.ID, initializeResult{
+			ProtocolVersion: version,
+			Capabilities:    map[string]any{"tools": map[string]any{}},
+			ServerInfo:      serverInfo{Name: "beeper-cli", Version: s.version},
+			Instructions:    "Read-only access to the user's local Beeper chat history. Use search_messages to find messages, list_threads to browse conversations, and list_messages to read a thread.",
+		}), true
+	case "ping":
+		return resultResponse(req.ID, map[string]any{}), true
+	case "tools/list":
+		return resultResponse(req.ID, toolsListResult{Tools: s.Tools()}), true
+	case "tools/call":
+		var params toolCallParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return errorResponse(req.ID, codeInvalidParams, "invalid params: "+err.Error()), true
+		}
+		idx := slices.IndexFunc(s.tools, func(t tool) bool { return t.Name == params.Name })
+		if idx < 0 {
+			return errorResponse(req.ID, codeInvalidParams, fmt.Sprintf("unknown tool %q", params.Name)), true
+		}
+		return resultResponse(req.ID, s.callTool(ctx, s.tools[idx], params.Arguments)), true
+	default:
+		return errorResponse(req.ID, codeMethodNotFound, fmt.Sprintf("method %q not found", req.Method)), true
+	}
+}
+
+// callTool runs a tool. Tool failures are reported in the result with
+// isError set, so the model can see and react to them.
+func (s *Server) callTool(ctx context.Context, t tool, args json.RawMessage) toolCallResult {
+	if len(args) == 0 || string(args) == "null" {
+		args = json.RawMessage("{}")
+	}
+	value, err := t.call(ctx, args)
+	if err != nil {
+		return toolCallResult{Content: []content{{Type: "text", Text: err.Error()}}, IsError: true}
+	}
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return toolCallResult{Content: []content{{Type: "text", Text: err.Error()}}, IsError: true}
+	}
+	return toolCallResult{Content: []content{{Type: "text", Text: string(data)}}}
+}
+
+func resultResponse(id json.RawMessage, result any) response {
+	return response{JSONRPC: "2.0", ID: id, Result: result}
+}
+
+func errorResponse(id json.RawMessage, code int, message string) response {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	return response{JSONRPC: "2.0", ID: id, Error: &rpcError{Code: code, Message: message}}
+}
@@ -0,0 +1,121 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+)
+
+type fakeStore struct {
+	search beeper.SearchOptions
+}
+
+func (f *fakeStore) ListThreads(_ context.Context, _ beeper.ThreadListOptions) ([]beeper.Thread, error) {
+	return []beeper.Thread{{ID: "!team", DisplayName: "Team Chat"}}, nil
+}
+
+func (f *fakeStore) GetThread(_ context.Context, threadID string, _ bool) (beeper.Thread, error) {
+	return beeper.Thread{ID: threadID, DisplayName: "Team Chat"}, nil
+}
+
+func (f *fakeStore) FindThreadsByName(_ context.Context, name string) ([]beeper.Thread, error) {
+	if strings.Contains("Team Chat", name) {
+		return []beeper.Thread{{ID: "!team", DisplayName: "Team Chat"}}, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeStore) ListMessages(_ context.Context, opts beeper.MessageListOptions) ([]beeper.Message, error) {
+	return []beeper.Message{{ThreadID: opts.ThreadID, Text: "hello"}}, nil
+}
+
+func (f *fakeStore) SearchMessages(_ context.Context, opts beeper.SearchOptions) ([]beeper.SearchResult, error) {
+	f.search = opts
+	return []beeper.SearchResult{}, nil
+}
+
+func (f *fakeStore) ListContacts(_ context.Context, _ beeper.ContactOptions) ([]beeper.Contact, error) {
+	return []beeper.Contact{}, nil
+}
+
+func serve(t *testing.T, store Store, lines ...string) []map[string]any {
+	t.Helper()
+	var out bytes.Buffer
+	server := NewServer(store, "test")
+	if err := server.Serve(context.Background(), strings.NewReader(strings.Join(lines, "\n")+"\n"), &out); err != nil {
+		t.Fatalf("serve: %v", err)
+	}
+	responses := []map[string]any{}
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var resp map[string]any
+		if err := dec.Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+func TestServeHandshake(t *testing.T) {
+	responses := serve(t, &fakeStore{},
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"resources/list"}`,
+		`not json`,
+	)
+	if len(responses) != 4 {
+		t.Fatalf("expected 4 responses (notification gets none), got %d: %v", len(responses), responses)
+	}
+	result := responses[0]["result"].(map[string]any)
+	if result["protocolVersion"] != "2024-11-05" {
+		t.Fatalf("expected negotiated version, got %v", result["protocolVersion"])
+	}
+	tools := responses[1]["result"].(map[string]any)["tools"].([]any)
+	names := []string{}
+	for _, tool := range tools {
+		names = append(names, tool.(map[string]any)["name"].(string))
+	}
+	if strings.Join(names, ",") != "list_threads,get_thread,list_messages,search_messages,list_contacts" {
+		t.Fatalf("unexpected tools: %v", names)
+	}
+	if code := responses[2]["error"].(map[string]any)["code"].(float64); code != codeMethodNotFound {
+		t.Fatalf("expected method not found, got %v", code)
+	}
+	if code := responses[3]["error"].(map[string]any)["code"].(float64); code != codeParseError {
+		t.Fatalf("expected parse error, got %v", code)
+	}
+}
+
+func TestServeToolCalls(t *testing.T) {
+	store := &fakeStore{}
+	responses := serve(t, store,
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"search_messages","arguments":{"query":"lunch","thread":"Team","context":2}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"list_messages","arguments":{"thread":"Nobody"}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"list_threads","arguments":{"label":"spam"}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"drop_tables"}}`,
+	)
+	if len(responses) != 4 {
+		t.Fatalf("expected 4 responses, got %d", len(responses))
+	}
+	if store.search.ThreadID != "!team" || store.search.Context != 2 || store.search.Limit != 20 {
+		t.Fatalf("unexpected search options: %+v", store.search)
+	}
+	if isError, _ := responses[0]["result"].(map[string]any)["isError"].(bool); isError {
+		t.Fatalf("search should succeed: %v", responses[0])
+	}
+	for _, resp := range responses[1:3] {
+		result := resp["result"].(map[string]any)
+		if result["isError"] != true {
+			t.Fatalf("expected tool error, got %v", resp)
+		}
+	}
+	if code := responses[3]["error"].(map[string]any)["code"].(float64); code != codeInvalidParams {
+		t.Fatalf("expected invalid params for unknown tool, got %v", code)
+	}
+}
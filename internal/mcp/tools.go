@@ -0,0 +1,283 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+)
+
+// tool pairs a definition with its handler. Handlers return a value that
+// is sent to the client as indented JSON text.
+type tool struct {
+	Tool
+	call func(ctx context.Context, args json.RawMessage) (any, error)
+}
+
+func (s *Server) defineTools() []tool {
+	return []tool{
+		{
+			Tool: Tool{
+				Name:        "list_threads",
+				Description: "List chat threads (conversations), most recently active first.",
+				InputSchema: objectSchema(nil, map[string]any{
+					"days":    property("integer", "only threads active in the last N days"),
+					"limit":   property("integer", "max number of threads (default 20)"),
+					"account": property("string", "filter by account/platform ID, e.g. whatsapp"),
+					"label":   enumProperty("which threads to list (default all)", "all", "inbox", "archive", "favourite", "unread"),
+				}),
+			},
+			call: s.listThreads,
+		},
+		{
+			Tool: Tool{
+				Name:        "get_thread",
+				Description: "Get one thread with its participants and message statistics.",
+				InputSchema: objectSchema([]string{"thread"}, map[string]any{
+					"thread": property("string", "thread room ID (starting with !) or name"),
+				}),
+			},
+			call: s.getThread,
+		},
+		{
+			Tool: Tool{
+				Name:        "list_messages",
+				Description: "List messages in a thread, newest first.",
+				InputSchema: objectSchema([]string{"thread"}, map[string]any{
+					"thread": property("string", "thread room ID (starting with !) or name"),
+					"limit":  property("integer", "max number of messages (default 50)"),
+					"after":  property("string", "only messages after this time (RFC 3339 or YYYY-MM-DD)"),
+					"before": property("string", "only messages before this time (RFC 3339 or YYYY-MM-DD)"),
+				}),
+			},
+			call: s.listMessages,
+		},
+		{
+			Tool: Tool{
+				Name:        "search_messages",
+				Description: "Full-text search across all messages, best matches first.",
+				InputSchema: objectSchema([]string{"query"}, map[string]any{
+					"query":   property("string", "search terms"),
+					"limit":   property("integer", "max number of results (default 20)"),
+					"thread":  property("string", "only search within this thread (room ID or name)"),
+					"account": property("string", "filter by account/platform ID"),
+					"days":    property("integer", "only messages from the last N days"),
+					"context": property("integer", "include N messages before and after each match"),
+				}),
+			},
+			call: s.searchMessages,
+		},
+		{
+			Tool: Tool{
+				Name:        "list_contacts",
+				Description: "List people the user chats with, most messages first.",
+				InputSchema: objectSchema(nil, map[string]any{
+					"query":    property("string", "filter by name or ID substring"),
+					"platform": property("string", "filter by platform, e.g. signal"),
+					"limit":    property("integer", "max number of contacts (default 50)"),
+				}),
+			},
+			call: s.listContacts,
+		},
+	}
+}
+
+func (s *Server) listThreads(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args struct {
+		Days    int    `json:"days"`
+		Limit   int    `json:"limit"`
+		Account string `json:"account"`
+		Label   string `json:"label"`
+	}
+	if err := decodeArgs(raw, &args); err != nil {
+		return nil, err
+	}
+	label := beeper.ThreadLabel(strings.ToLower(strings.TrimSpace(args.Label)))
+	switch label {
+	case "":
+		label = beeper.LabelAll
+	case beeper.LabelAll, beeper.LabelInbox, beeper.LabelArchive, beeper.LabelFavourite, beeper.LabelUnread:
+	default:
+		return nil, fmt.Errorf("invalid label %q: use inbox, archive, favourite, unread, or all", args.Label)
+	}
+	return s.store.ListThreads(ctx, beeper.ThreadListOptions{
+		Days:      args.Days,
+		Limit:     defaultLimit(args.Limit, 20),
+		AccountID: args.Account,
+		Label:     label,
+	})
+}
+
+func (s *Server) getThread(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args struct {
+		Thread string `json:"thread"`
+	}
+	if err := decodeArgs(raw, &args); err != nil {
+		return nil, err
+	}
+	threadID, err := s.resolveThread(ctx, args.Thread)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.GetThread(ctx, threadID, true)
+}
+
+func (s *Server) listMessages(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args struct {
+		Thread string `json:"thread"`
+		Limit  int    `json:"limit"`
+		After  string `json:"after"`
+		Before string `json:"before"`
+	}
+	if err := decodeArgs(raw, &args); err != nil {
+		return nil, err
+	}
+	threadID, err := s.resolveThread(ctx, args.Thread)
+	if err != nil {
+		return nil, err
+	}
+	after, err := parseTimeArg("after", args.After, false)
+	if err != nil {
+		return nil, err
+	}
+	before, err := parseTimeArg("before", args.Before, true)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.ListMessages(ctx, beeper.MessageListOptions{
+		ThreadID: threadID,
+		Limit:    defaultLimit(args.Limit, 50),
+		After:    after,
+		Before:   before,
+		Format:   beeper.FormatRich,
+	})
+}
+
+func (s *Server) searchMessages(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args struct {
+		Query   string `json:"query"`
+		Limit   int    `json:"limit"`
+		Thread  string `json:"thread"`
+		Account string `json:"account"`
+		Days    int    `json:"days"`
+		Context int    `json:"context"`
+	}
+	if err := decodeArgs(raw, &args); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(args.Query) == "" {
+		return nil, fmt.Errorf("query is required")
+	}
+	threadID := ""
+	if args.Thread != "" {
+		var err error
+		if threadID, err = s.resolveThread(ctx, args.Thread); err != nil {
+			return nil, err
+		}
+	}
+	return s.store.SearchMessages(ctx, beeper.SearchOptions{
+		Query:     args.Query,
+		ThreadID:  threadID,
+		Days:      args.Days,
+		Limit:     defaultLimit(args.Limit, 20),
+		AccountID: args.Account,
+		Context:   args.Context,
+		Format:    beeper.FormatRich,
+	})
+}
+
+func (s *Server) listContacts(ctx context.Context, raw json.RawMessage) (any, error) {
+	var args struct {
+		Query    string `json:"query"`
+		Platform string `json:"platform"`
+		Limit    int    `json:"limit"`
+	}
+	if err := decodeArgs(raw, &args); err != nil {
+		return nil, err
+	}
+	return s.store.ListContacts(ctx, beeper.ContactOptions{
+		Query:    args.Query,
+		Platform: args.Platform,
+		Limit:    defaultLimit(args.Limit, 50),
+	})
+}
+
+// resolveThread accepts a room ID or a name matching exactly one thread.
+func (s *Server) resolveThread(ctx context.Context, ref string) (string, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return "", fmt.Errorf("thread is required")
+	}
+	if strings.HasPrefix(ref, "!") {
+		return ref, nil
+	}
+	matches, err := s.store.FindThreadsByName(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no thread matches %q", ref)
+	case 1:
+		return matches[0].ID, nil
+	default:
+		ids := make([]string, 0, len(matches))
+		for _, thread := range matches {
+			ids = append(ids, thread.ID)
+		}
+		return "", fmt.Errorf("%q matches %d threads: %s", ref, len(matches), strings.Join(ids, ", "))
+	}
+}
+
+func decodeArgs(raw json.RawMessage, v any) error {
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
+
+// parseTimeArg accepts RFC 3339 or a plain date; with endOfDay a plain date
+// means the end of that day.
+func parseTimeArg(name string, value string, endOfDay bool) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q: use RFC 3339 or YYYY-MM-DD", name, value)
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return &t, nil
+}
+
+func defaultLimit(limit int, fallback int) int {
+	if limit <= 0 {
+		return fallback
+	}
+	return limit
+}
+
+func objectSchema(required []string, properties map[string]any) map[string]any {
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func property(typ string, description string) map[string]any {
+	return map[string]any{"type": typ, "description": description}
+}
+
+func enumProperty(description string, values ...string) map[string]any {
+	return map[string]any{"type": "string", "description": description, "enum": values}
+}
@@ -0,0 +1,6 @@
+// Package recording captures the SQL queries a command issues against the
+// Beeper database, together with the rows they returned, and replays them
+// from memory. A recording (optionally with chat content pseudonymized)
+// lets users attach a reproducible bug report without sharing their
+// database. A Profiler wraps the same connectors to time the queries.
+package recording
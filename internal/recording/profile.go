@@ -0,0 +1,270 @@
+package recording
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+)
+
+// Profiler times the queries issued through its connectors. Queries with
+// the same SQL (ignoring whitespace and the length of placeholder lists)
+// are summed up into one QueryStat.
+type Profiler struct {
+	mu    sync.Mutex
+	stats map[string]*QueryStat
+}
+
+// QueryStat sums up the runs of one query. Duration counts the time spent
+// in SQLite, preparing the query and stepping through its rows, not the
+// time the caller spends between rows.
+type QueryStat struct {
+	SQL      string        `json:"sql"`
+	Label    string        `json:"label"`
+	Calls    int           `json:"calls"`
+	Rows     int           `json:"rows"`
+	Duration time.Duration `json:"duration"`
+	Max      time.Duration `json:"max"`
+}
+
+// NewProfiler returns an empty Profiler.
+func NewProfiler() *Profiler {
+	return &Profiler{stats: map[string]*QueryStat{}}
+}
+
+// Wrap returns a connector function timing the queries of the connectors
+// open returns, e.g. a Recorder's. With a nil open, connections come from
+// the store's sqlite3 driver.
+func (p *Profiler) Wrap(open func(dsn string) driver.Connector) func(dsn string) driver.Connector {
+	return func(dsn string) driver.Connector {
+		var inner driver.Connector = dsnConnector(dsn)
+		if open != nil {
+			inner = open(dsn)
+		}
+		return &profilingConnector{Connector: inner, p: p}
+	}
+}
+
+// Stats returns the queries seen so far, most total time first.
+func (p *Profiler) Stats() []QueryStat {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	stats := make([]QueryStat, 0, len(p.stats))
+	for _, s := range p.stats {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Duration != stats[j].Duration {
+			return stats[i].Duration > stats[j].Duration
+		}
+		return stats[i].SQL < stats[j].SQL
+	})
+	return stats
+}
+
+// WriteReport writes a summary line (query count, total time, and the
+// slowest single run) and the top queries by total time to w.
+func (p *Profiler) WriteReport(w io.Writer, top int) error {
+	stats := p.Stats()
+	calls := 0
+	var total time.Duration
+	var slowest *QueryStat
+	for i := range stats {
+		calls += stats[i].Calls
+		total += stats[i].Duration
+		if slowest == nil || stats[i].Max > slowest.Max {
+			slowest = &stats[i]
+		}
+	}
+	if slowest == nil {
+		_, err := fmt.Fprintln(w, "queries: 0")
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "queries: %d, distinct: %d, total: %s, slowest: %s %s\n", calls, len(stats), roundDuration(total), slowest.Label, roundDuration(slowest.Max)); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "CALLS\tTOTAL\tMAX\tROWS\tQUERY"); err != nil {
+		return err
+	}
+	if top > 0 && len(stats) > top {
+		stats = stats[:top]
+	}
+	for _, s := range stats {
+		if _, err := fmt.Fprintf(tw, "%d\t%s\t%s\t%d\t%s\n", s.Calls, roundDuration(s.Duration), roundDuration(s.Max), s.Rows, s.Label); err != nil {
+			return err
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "slowest SQL: %s\n", abbreviate(slowest.SQL, 240))
+	return err
+}
+
+func (p *Profiler) add(query string, d time.Duration, rows int) {
+	key := profileKey(query)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s, ok := p.stats[key]
+	if !ok {
+		s = &QueryStat{SQL: key, Label: queryLabel(key)}
+		p.stats[key] = s
+	}
+	s.Calls++
+	s.Rows += rows
+	s.Duration += d
+	if d > s.Max {
+		s.Max = d
+	}
+}
+
+// placeholderList matches the "(?, ?, ?)" of an IN list built for a slice.
+var placeholderList = regexp.MustCompile(`\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+
+// profileKey normalizes query so IN lists of any length count as one query.
+func profileKey(query string) string {
+	return placeholderList.ReplaceAllString(normalizeSQL(query), "(?...)")
+}
+
+var (
+	queryTables = regexp.MustCompile(`(?i)\b(?:FROM|JOIN)\s+([A-Za-z_][A-Za-z0-9_]*)`)
+	queryIn     = regexp.MustCompile(`(?i)\bIN\s*\(\s*\?`)
+)
+
+// queryLabel names a query by the tables it reads, e.g.
+// "participants IN(...)", falling back to its abbreviated SQL.
+func queryLabel(query string) string {
+	tables := []string{}
+	for _, m := range queryTables.FindAllStringSubmatch(query, -1) {
+		name := m[1]
+		if !containsFold(tables, name) {
+			tables = append(tables, name)
+		}
+	}
+	if len(tables) == 0 {
+		return abbreviate(query, 60)
+	}
+	label := strings.Join(tables, ",")
+	if queryIn.MatchString(query) {
+		label += " IN(...)"
+	}
+	if strings.Contains(strings.ToUpper(query), " MATCH ") {
+		label += " MATCH"
+	}
+	return label
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// roundDuration keeps report durations readable: whole milliseconds from
+// one millisecond up, microseconds below.
+func roundDuration(d time.Duration) time.Duration {
+	if d >= time.Millisecond {
+		return d.Round(time.Millisecond)
+	}
+	return d.Round(time.Microsecond)
+}
+
+// dsnConnector opens dsn with the store's sqlite3 driver.
+type dsnConnector string
+
+func (c dsnConnector) Connect(context.Context) (driver.Conn, error) {
+	return c.Driver().Open(string(c))
+}
+
+func (c dsnConnector) Driver() driver.Driver {
+	return beeper.NewSQLiteDriver()
+}
+
+type profilingConnector struct {
+	driver.Connector
+	p *Profiler
+}
+
+func (c *profilingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &profilingConn{Conn: conn, p: c.p}, nil
+}
+
+// profilingConn wraps a connection. database/sql runs queries through
+// QueryContext and statements through ExecContext; both are timed.
+type profilingConn struct {
+	driver.Conn
+	p *Profiler
+}
+
+func (c *profilingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	rows, err := queryer.QueryContext(ctx, query, args)
+	if err != nil {
+		if err != driver.ErrSkip {
+			c.p.add(query, time.Since(start), 0)
+		}
+		return nil, err
+	}
+	return &profilingRows{Rows: rows, p: c.p, query: query, elapsed: time.Since(start)}, nil
+}
+
+func (c *profilingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	start := time.Now()
+	result, err := execer.ExecContext(ctx, query, args)
+	if err != driver.ErrSkip {
+		c.p.add(query, time.Since(start), 0)
+	}
+	return result, err
+}
+
+// profilingRows counts a query once its rows are closed, with the time
+// spent preparing it and stepping through its rows.
+type profilingRows struct {
+	driver.Rows
+	p       *Profiler
+	query   string
+	elapsed time.Duration
+	rows    int
+}
+
+func (r *profilingRows) Next(dest []driver.Value) error {
+	start := time.Now()
+	err := r.Rows.Next(dest)
+	r.elapsed += time.Since(start)
+	if err == nil {
+		r.rows++
+	}
+	return err
+}
+
+func (r *profilingRows) Close() error {
+	start := time.Now()
+	err := r.Rows.Close()
+	r.p.add(r.query, r.elapsed+time.Since(start), r.rows)
+	return err
+}
@@ -0,0 +1,54 @@
+package recording
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+func TestProfiler(t *testing.T) {
+	path := createDB(t)
+	profiler := NewProfiler()
+	recorder := NewRecorder(nil)
+	db := sql.OpenDB(profiler.Wrap(recorder.Connector)("file:" + path + "?mode=ro"))
+	defer func() { _ = db.Close() }()
+
+	queryRows(t, db, "!room:beeper.local")
+	queryRows(t, db, "!other:beeper.local")
+	for _, ids := range [][]any{{1}, {1, 2}} {
+		rows, err := db.Query("SELECT id FROM messages WHERE id IN ("+strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")+")", ids...)
+		if err != nil {
+			t.Fatalf("query: %v", err)
+		}
+		_ = rows.Close()
+	}
+	if _, err := db.Query("SELECT 1 FROM nowhere"); err == nil {
+		t.Fatalf("expected an error")
+	}
+
+	stats := profiler.Stats()
+	if len(stats) != 3 {
+		t.Fatalf("expected 3 distinct queries, got %+v", stats)
+	}
+	byLabel := map[string]QueryStat{}
+	for _, s := range stats {
+		byLabel[s.Label] = s
+	}
+	if s := byLabel["messages"]; s.Calls != 2 || s.Rows != 2 {
+		t.Fatalf("expected the room query twice with 2 rows, got %+v", s)
+	}
+	if s := byLabel["messages IN(...)"]; s.Calls != 2 || !strings.Contains(s.SQL, "IN (?...)") {
+		t.Fatalf("expected IN lists of any length to group, got %+v", s)
+	}
+	if len(recorder.Recording().Queries) != 5 {
+		t.Fatalf("expected the wrapped recorder to see the queries, got %d", len(recorder.Recording().Queries))
+	}
+
+	var report strings.Builder
+	if err := profiler.WriteReport(&report, 10); err != nil {
+		t.Fatalf("report: %v", err)
+	}
+	if !strings.HasPrefix(report.String(), "queries: 5, distinct: 3, total: ") || !strings.Contains(report.String(), "CALLS  TOTAL") {
+		t.Fatalf("unexpected report:\n%s", report.String())
+	}
+}
@@ -0,0 +1,163 @@
+package recording
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+)
+
+// Recorder captures the queries issued through its connectors.
+type Recorder struct {
+	mu       sync.Mutex
+	redactor *Redactor
+	schema   []string
+	queries  []Query
+}
+
+// NewRecorder returns a Recorder. When redactor is non-nil, chat content
+// in arguments and rows is pseudonymized as it is recorded.
+func NewRecorder(redactor *Redactor) *Recorder {
+	return &Recorder{redactor: redactor}
+}
+
+// Connector returns a driver.Connector opening dsn with the store's
+// sqlite3 driver and recording every query made on its connections.
+func (r *Recorder) Connector(dsn string) driver.Connector {
+	return &recordingConnector{rec: r, dsn: dsn}
+}
+
+// Recording returns what has been recorded so far. The caller fills in the
+// command-line arguments, config, and error.
+func (r *Recorder) Recording() *Recording {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &Recording{
+		Version:  FormatVersion,
+		Redacted: r.redactor != nil,
+		Schema:   append([]string(nil), r.schema...),
+		Queries:  append([]Query(nil), r.queries...),
+	}
+}
+
+// add appends a query and returns its index.
+func (r *Recorder) add(q Query) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.queries = append(r.queries, q)
+	return len(r.queries) - 1
+}
+
+func (r *Recorder) addRow(i int, row []Value) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.queries[i].Rows = append(r.queries[i].Rows, row)
+}
+
+// captureSchema reads the CREATE statements once, directly on conn so the
+// lookup itself is not recorded.
+func (r *Recorder) captureSchema(ctx context.Context, conn driver.QueryerContext) {
+	r.mu.Lock()
+	done := r.schema != nil
+	r.mu.Unlock()
+	if done {
+		return
+	}
+	rows, err := conn.QueryContext(ctx, "SELECT sql FROM sqlite_master WHERE sql IS NOT NULL ORDER BY type, name", nil)
+	if err != nil {
+		return
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	schema := []string{}
+	dest := make([]driver.Value, 1)
+	for rows.Next(dest) == nil {
+		switch v := dest[0].(type) {
+		case string:
+			schema = append(schema, v)
+		case []byte:
+			schema = append(schema, string(v))
+		}
+	}
+	r.mu.Lock()
+	r.schema = schema
+	r.mu.Unlock()
+}
+
+type recordingConnector struct {
+	rec *Recorder
+	dsn string
+}
+
+func (c *recordingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Driver().Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+	if q, ok := conn.(driver.QueryerContext); ok {
+		c.rec.captureSchema(ctx, q)
+	}
+	return &recordingConn{Conn: conn, rec: c.rec}, nil
+}
+
+func (c *recordingConnector) Driver() driver.Driver {
+	return beeper.NewSQLiteDriver()
+}
+
+// recordingConn wraps a sqlite3 connection. database/sql runs queries
+// through QueryContext, so only that path is recorded.
+type recordingConn struct {
+	driver.Conn
+	rec *Recorder
+}
+
+func (c *recordingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	rows, err := queryer.QueryContext(ctx, query, args)
+
+	entry := Query{SQL: query}
+	for _, arg := range args {
+		entry.Args = append(entry.Args, Value{V: c.rec.redactor.Arg(arg.Value)})
+	}
+	if err != nil {
+		if errors.Is(err, driver.ErrSkip) {
+			return nil, err
+		}
+		entry.Error = err.Error()
+		c.rec.add(entry)
+		return nil, err
+	}
+	entry.Columns = rows.Columns()
+	return &recordingRows{Rows: rows, rec: c.rec, index: c.rec.add(entry), columns: entry.Columns}, nil
+}
+
+type recordingRows struct {
+	driver.Rows
+	rec     *Recorder
+	index   int
+	columns []string
+}
+
+func (r *recordingRows) Next(dest []driver.Value) error {
+	if err := r.Rows.Next(dest); err != nil {
+		if err != io.EOF {
+			r.rec.mu.Lock()
+			r.rec.queries[r.index].Error = err.Error()
+			r.rec.mu.Unlock()
+		}
+		return err
+	}
+	row := make([]Value, len(dest))
+	for i, v := range dest {
+		row[i] = Value{V: r.rec.redactor.Column(r.columns[i], v)}
+	}
+	r.rec.addRow(r.index, row)
+	return nil
+}
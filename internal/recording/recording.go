@@ -0,0 +1,139 @@
+package recording
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/config"
+)
+
+// FormatVersion is the version of the recording file layout.
+const FormatVersion = 1
+
+// Recording is one recorded CLI invocation.
+type Recording struct {
+	Version    int    `json:"version"`
+	CLIVersion string `json:"cliVersion"`
+	// Args are the command-line arguments to replay, without the database,
+	// config, and recording flags.
+	Args []string `json:"args"`
+	// Redacted is true when chat content was pseudonymized.
+	Redacted bool `json:"redacted"`
+	// Config is the effective config, without local paths.
+	Config config.Config `json:"config"`
+	// Schema holds the CREATE statements of the recorded database.
+	Schema  []string `json:"schema,omitempty"`
+	Queries []Query  `json:"queries"`
+	// Error is the error the command failed with, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// Query is one recorded query and its result.
+type Query struct {
+	SQL     string    `json:"sql"`
+	Args    []Value   `json:"args,omitempty"`
+	Columns []string  `json:"columns,omitempty"`
+	Rows    [][]Value `json:"rows,omitempty"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// Value is a SQL value that keeps its driver type through JSON: integers,
+// floats, strings, booleans, and NULL are stored natively, blobs as
+// {"blob": base64} and times as {"time": RFC 3339}.
+type Value struct {
+	V any
+}
+
+// MarshalJSON implements json.Marshaler.
+func (v Value) MarshalJSON() ([]byte, error) {
+	switch x := v.V.(type) {
+	case []byte:
+		return json.Marshal(map[string]string{"blob": base64.StdEncoding.EncodeToString(x)})
+	case time.Time:
+		return json.Marshal(map[string]string{"time": x.Format(time.RFC3339Nano)})
+	case float64:
+		data, err := json.Marshal(x)
+		if err != nil {
+			return nil, err
+		}
+		// Keep a decimal point so the value is replayed as a float.
+		if !bytes.ContainsAny(data, ".eE") {
+			data = append(data, ".0"...)
+		}
+		return data, nil
+	default:
+		return json.Marshal(x)
+	}
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (v *Value) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var raw any
+	if err := dec.Decode(&raw); err != nil {
+		return err
+	}
+	switch x := raw.(type) {
+	case json.Number:
+		if n, err := x.Int64(); err == nil {
+			v.V = n
+			return nil
+		}
+		f, err := x.Float64()
+		if err != nil {
+			return err
+		}
+		v.V = f
+	case map[string]any:
+		if s, ok := x["blob"].(string); ok {
+			b, err := base64.StdEncoding.DecodeString(s)
+			if err != nil {
+				return err
+			}
+			v.V = b
+			return nil
+		}
+		if s, ok := x["time"].(string); ok {
+			t, err := time.Parse(time.RFC3339Nano, s)
+			if err != nil {
+				return err
+			}
+			v.V = t
+			return nil
+		}
+		return fmt.Errorf("unknown value %s", data)
+	default:
+		v.V = x
+	}
+	return nil
+}
+
+// Load reads a recording file.
+func Load(path string) (*Recording, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rec Recording
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("invalid recording %s: %w", path, err)
+	}
+	if rec.Version != FormatVersion {
+		return nil, fmt.Errorf("unsupported recording version %d in %s", rec.Version, path)
+	}
+	return &rec, nil
+}
+
+// Save writes the recording as indented JSON.
+func (r *Recording) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
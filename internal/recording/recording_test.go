@@ -0,0 +1,158 @@
+package recording
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func createDB(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "index.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	if _, err := db.Exec(`CREATE TABLE messages (id INTEGER PRIMARY KEY, roomID TEXT, text_content TEXT, message JSON, score REAL, raw BLOB);
+		INSERT INTO messages VALUES
+			(1, '!room:beeper.local', 'Call me at +49 170 1234567', '{"text":"Call me at +49 170 1234567","msgtype":"m.text","sender":"@whatsapp_4917012345:beeper.local"}', 1.0, x'00ff'),
+			(2, '!room:beeper.local', NULL, '{"m.relates_to":{"rel_type":"m.thread"}}', 0.5, NULL);`); err != nil {
+		t.Fatalf("schema: %v", err)
+	}
+	return path
+}
+
+type row struct {
+	ID      int64
+	Room    string
+	Text    sql.NullString
+	Message string
+	Score   float64
+	Raw     []byte
+}
+
+func queryRows(t *testing.T, db *sql.DB, roomID string) []row {
+	t.Helper()
+	rows, err := db.Query("SELECT id, roomID, text_content, message, score, raw FROM messages WHERE roomID = ? ORDER BY id", roomID)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	defer func() { _ = rows.Close() }()
+	out := []row{}
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.ID, &r.Room, &r.Text, &r.Message, &r.Score, &r.Raw); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		out = append(out, r)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("rows: %v", err)
+	}
+	return out
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	path := createDB(t)
+	rec := NewRecorder(nil)
+	db := sql.OpenDB(rec.Connector(fmt.Sprintf("file:%s?mode=ro", path)))
+	want := queryRows(t, db, "!room:beeper.local")
+	_ = db.Close()
+
+	recording := rec.Recording()
+	recording.Args = []string{"messages", "list"}
+	file := filepath.Join(t.TempDir(), "session.json")
+	if err := recording.Save(file); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	loaded, err := Load(file)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(loaded.Schema) == 0 || !strings.Contains(loaded.Schema[0], "CREATE TABLE messages") {
+		t.Fatalf("schema not recorded: %v", loaded.Schema)
+	}
+	if len(loaded.Queries) != 1 || len(loaded.Queries[0].Rows) != 2 {
+		t.Fatalf("unexpected queries: %+v", loaded.Queries)
+	}
+
+	replayer := NewReplayer(loaded)
+	replayDB := sql.OpenDB(replayer.Connector(""))
+	defer func() { _ = replayDB.Close() }()
+	// Arguments are not compared on replay.
+	got := queryRows(t, replayDB, "ignored")
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("replay mismatch:\ngot  %+v\nwant %+v", got, want)
+	}
+	if replayer.Replayed() != 1 {
+		t.Fatalf("expected 1 replayed query, got %d", replayer.Replayed())
+	}
+	if _, err := replayDB.Query("SELECT id, roomID, text_content, message, score, raw FROM messages WHERE roomID = ? ORDER BY id", "x"); err == nil {
+		t.Fatal("expected error once the recorded query is used up")
+	}
+}
+
+func TestRedactedRecording(t *testing.T) {
+	path := createDB(t)
+	redactor := NewRedactor()
+	rec := NewRecorder(redactor)
+	db := sql.OpenDB(rec.Connector(fmt.Sprintf("file:%s?mode=ro", path)))
+	got := queryRows(t, db, "!room:beeper.local")
+	_ = db.Close()
+	if got[0].Text.String != "Call me at +49 170 1234567" {
+		t.Fatalf("recording changed the live result: %+v", got[0])
+	}
+
+	q := rec.Recording().Queries[0]
+	if arg := q.Args[0].V.(string); arg == "!room:beeper.local" || !strings.HasPrefix(arg, "!") {
+		t.Fatalf("argument not pseudonymized: %q", arg)
+	}
+	first := q.Rows[0]
+	if first[1].V != "!room:beeper.local" {
+		t.Fatalf("room ID should be kept: %v", first[1].V)
+	}
+	text := first[2].V.(string)
+	if text == "Call me at +49 170 1234567" || len(text) != len("Call me at +49 170 1234567") || text[0] < 'A' || text[0] > 'Z' {
+		t.Fatalf("text not pseudonymized with the same shape: %q", text)
+	}
+	var message map[string]string
+	if err := json.Unmarshal([]byte(first[3].V.(string)), &message); err != nil {
+		t.Fatalf("message is no longer JSON: %v", err)
+	}
+	if message["text"] != text {
+		t.Fatalf("equal strings should get equal pseudonyms: %q vs %q", message["text"], text)
+	}
+	if message["msgtype"] != "m.text" {
+		t.Fatalf("non-sensitive field changed: %q", message["msgtype"])
+	}
+	if strings.Contains(message["sender"], "4917012345") || !strings.HasPrefix(message["sender"], "@whatsapp_") {
+		t.Fatalf("digit run in ID not pseudonymized: %q", message["sender"])
+	}
+	if redactor.Text("Team Chat") != redactor.Text("Team Chat") || !strings.EqualFold(redactor.Text("team chat"), redactor.Text("Team Chat")) {
+		t.Fatal("pseudonyms must be stable and case-insensitive")
+	}
+}
+
+func TestValueJSON(t *testing.T) {
+	when := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	values := []Value{{V: nil}, {V: int64(42)}, {V: 1.0}, {V: 2.5}, {V: "text"}, {V: true}, {V: []byte{0, 1}}, {V: when}}
+	data, err := json.Marshal(values)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var decoded []Value
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	for i := range values {
+		if !reflect.DeepEqual(decoded[i].V, values[i].V) {
+			t.Fatalf("value %d: got %#v, want %#v", i, decoded[i].V, values[i].V)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package recording
+
+import (
+	"bytes"
+	"crypto/rand"
+	"database/sql/driver"
+	"encoding/binary"
+	"encoding/json"
+	"hash/fnv"
+	mrand "math/rand"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// sensitiveNames are column names and JSON keys (lower case, without "_"
+// and "-") whose values are chat content or personal data.
+var sensitiveNames = map[string]struct{}{
+	"alt": {}, "alttext": {}, "body": {}, "caption": {}, "content": {},
+	"description": {}, "displayname": {}, "email": {}, "emails": {},
+	"filename": {}, "firstname": {}, "formattedbody": {}, "fullname": {},
+	"lastname": {}, "name": {}, "nickname": {}, "phone": {},
+	"phonenumber": {}, "phonenumbers": {}, "sendername": {}, "snippet": {},
+	"text": {}, "textcontent": {}, "threadname": {}, "title": {},
+	"topic": {}, "username": {},
+}
+
+// minDigitRun is the shortest digit run pseudonymized outside sensitive
+// fields, so phone numbers embedded in IDs are hidden but short numbers
+// are kept.
+const minDigitRun = 7
+
+// Redactor pseudonymizes recorded values. Each letter and digit is replaced
+// by one derived from a per-recording secret and the case-folded input, so
+// equal strings (ignoring case) stay equal, length, case, and punctuation
+// are kept, and the original cannot be looked up without the secret. A nil
+// Redactor leaves values unchanged.
+type Redactor struct {
+	salt [8]byte
+}
+
+// NewRedactor returns a Redactor with a random secret.
+func NewRedactor() *Redactor {
+	r := &Redactor{}
+	_, _ = rand.Read(r.salt[:])
+	return r
+}
+
+// Text pseudonymizes every letter and digit of s.
+func (r *Redactor) Text(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+	h := fnv.New64a()
+	_, _ = h.Write(r.salt[:])
+	_, _ = h.Write([]byte(strings.ToLower(s)))
+	rng := mrand.New(mrand.NewSource(int64(binary.BigEndian.Uint64(h.Sum(nil)))))
+
+	var b strings.Builder
+	for _, c := range s {
+		switch {
+		case unicode.IsLetter(c):
+			letter := rune('a' + rng.Intn(26))
+			if unicode.IsUpper(c) {
+				letter = unicode.ToUpper(letter)
+			}
+			b.WriteRune(letter)
+		case unicode.IsDigit(c):
+			b.WriteRune(rune('0' + rng.Intn(10)))
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// Arg pseudonymizes a query argument. Arguments are never used on replay,
+// so every string is replaced.
+func (r *Redactor) Arg(v driver.Value) driver.Value {
+	if r == nil {
+		return v
+	}
+	switch x := v.(type) {
+	case string:
+		return r.Text(x)
+	case []byte:
+		return []byte(r.Text(string(x)))
+	}
+	return v
+}
+
+// Column pseudonymizes a value of the named result column: sensitive
+// columns completely, JSON documents field by field, and other strings
+// only in long digit runs.
+func (r *Redactor) Column(name string, v driver.Value) driver.Value {
+	if r == nil {
+		return v
+	}
+	switch x := v.(type) {
+	case string:
+		return r.value(x, isSensitive(name))
+	case []byte:
+		if utf8.Valid(x) {
+			return []byte(r.value(string(x), isSensitive(name)))
+		}
+	}
+	return v
+}
+
+func (r *Redactor) value(s string, sensitive bool) string {
+	trimmed := strings.TrimSpace(s)
+	if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		if out, ok := r.json(s, sensitive); ok {
+			return out
+		}
+	}
+	if sensitive {
+		return r.Text(s)
+	}
+	return r.digitRuns(s)
+}
+
+func (r *Redactor) json(s string, sensitive bool) (string, bool) {
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil || dec.More() {
+		return "", false
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(r.walk(doc, sensitive)); err != nil {
+		return "", false
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), true
+}
+
+func (r *Redactor) walk(v any, sensitive bool) any {
+	switch x := v.(type) {
+	case map[string]any:
+		for k, val := range x {
+			x[k] = r.walk(val, sensitive || isSensitive(k))
+		}
+	case []any:
+		for i, val := range x {
+			x[i] = r.walk(val, sensitive)
+		}
+	case string:
+		if sensitive {
+			return r.Text(x)
+		}
+		return r.digitRuns(x)
+	}
+	return v
+}
+
+// digitRuns pseudonymizes runs of at least minDigitRun digits, unless s is
+// a plain number.
+func (r *Redactor) digitRuns(s string) string {
+	var b strings.Builder
+	start := -1
+	flush := func(end int) {
+		if start < 0 {
+			return
+		}
+		run := s[start:end]
+		if len(run) >= minDigitRun && len(run) < len(s) {
+			run = r.Text(run)
+		}
+		b.WriteString(run)
+		start = -1
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] >= '0' && s[i] <= '9' {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		flush(i)
+		b.WriteByte(s[i])
+	}
+	flush(len(s))
+	return b.String()
+}
+
+func isSensitive(name string) bool {
+	name = strings.ToLower(name)
+	name = strings.NewReplacer("_", "", "-", "").Replace(name)
+	_, ok := sensitiveNames[name]
+	return ok
+}
@@ -0,0 +1,136 @@
+package recording
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Replayer answers queries from a recording instead of a database: each
+// query gets the rows recorded for the first unused query with the same
+// SQL. Arguments are not compared, so pseudonymized recordings replay the
+// same way as plain ones.
+type Replayer struct {
+	mu   sync.Mutex
+	rec  *Recording
+	used []bool
+}
+
+// NewReplayer returns a Replayer for rec.
+func NewReplayer(rec *Recording) *Replayer {
+	return &Replayer{rec: rec, used: make([]bool, len(rec.Queries))}
+}
+
+// Connector returns a driver.Connector whose connections answer from the
+// recording. The DSN is ignored.
+func (r *Replayer) Connector(string) driver.Connector {
+	return replayConnector{r: r}
+}
+
+// Replayed returns how many recorded queries have been answered.
+func (r *Replayer) Replayed() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := 0
+	for _, used := range r.used {
+		if used {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *Replayer) next(query string) (Query, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	want := normalizeSQL(query)
+	for i, q := range r.rec.Queries {
+		if r.used[i] || normalizeSQL(q.SQL) != want {
+			continue
+		}
+		r.used[i] = true
+		return q, nil
+	}
+	return Query{}, fmt.Errorf("replay: query not in recording: %s", abbreviate(want, 120))
+}
+
+func normalizeSQL(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+func abbreviate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
+
+type replayConnector struct {
+	r *Replayer
+}
+
+func (c replayConnector) Connect(context.Context) (driver.Conn, error) {
+	return replayConn(c), nil
+}
+
+func (c replayConnector) Driver() driver.Driver {
+	return replayDriver(c)
+}
+
+type replayDriver struct {
+	r *Replayer
+}
+
+func (d replayDriver) Open(string) (driver.Conn, error) {
+	return replayConn(d), nil
+}
+
+type replayConn struct {
+	r *Replayer
+}
+
+var errReplayOnly = errors.New("replay: only recorded queries are supported")
+
+func (c replayConn) Prepare(string) (driver.Stmt, error) { return nil, errReplayOnly }
+func (c replayConn) Close() error                        { return nil }
+func (c replayConn) Begin() (driver.Tx, error)           { return nil, errReplayOnly }
+
+func (c replayConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	q, err := c.r.next(query)
+	if err != nil {
+		return nil, err
+	}
+	if q.Error != "" && q.Rows == nil {
+		return nil, errors.New(q.Error)
+	}
+	return &replayRows{query: q}, nil
+}
+
+type replayRows struct {
+	query Query
+	pos   int
+}
+
+func (r *replayRows) Columns() []string { return r.query.Columns }
+func (r *replayRows) Close() error      { return nil }
+
+func (r *replayRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.query.Rows) {
+		if r.query.Error != "" {
+			return errors.New(r.query.Error)
+		}
+		return io.EOF
+	}
+	row := r.query.Rows[r.pos]
+	r.pos++
+	for i := range dest {
+		if i < len(row) {
+			dest[i] = row[i].V
+		}
+	}
+	return nil
+}
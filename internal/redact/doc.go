@@ -0,0 +1,4 @@
+// Package redact masks sensitive patterns (phone numbers, one-time codes,
+// and card numbers) in text printed to the terminal, so the CLI can be
+// demoed or screen-shared without exposing them.
+package redact
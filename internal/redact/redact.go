@@ -0,0 +1,219 @@
+package redact
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Built-in rule names.
+const (
+	RulePhone = "phone"
+	RuleOTP   = "otp"
+	RuleCard  = "card"
+)
+
+// Rules lists the built-in rules in the order they are applied.
+var Rules = []string{RuleCard, RulePhone, RuleOTP}
+
+// maskRune replaces each masked digit. It is a single ASCII character so
+// masked text keeps its width and table columns stay aligned.
+const maskRune = '*'
+
+var (
+	cardPattern = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+	// phonePatterns match international numbers (+ or 00 prefix), North
+	// American (415) 555-1234 style numbers, and national numbers with a
+	// leading 0. Dates and times do not match.
+	phonePatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?:\+|\b00)\d[\d ()./-]{5,}\d`),
+		regexp.MustCompile(`(?:\(\d{3}\)\s?|\b\d{3}[ .-])\d{3}[ .-]\d{4}\b`),
+		regexp.MustCompile(`\b0\d{2,4}[ /-]?\d{3,}(?:[ -]\d{2,})?\b`),
+	}
+	// otpPattern matches 4-8 digit codes that follow a keyword such as
+	// "code" or "PIN", plus Google-style G-123456 codes.
+	otpPattern = regexp.MustCompile(`(?i)(?:\b(?:code|codes|otp|pin|passcode|password|verification|token|tan)\b[^\d\n]{0,24}\d{3}[ -]?\d{1,5}\b|\bG-\d{6}\b)`)
+)
+
+// Masker masks sensitive substrings. The zero value masks nothing.
+type Masker struct {
+	rules []rule
+}
+
+type rule struct {
+	pattern *regexp.Regexp
+	// keep is the number of trailing digits left visible.
+	keep int
+	// valid optionally rejects matches, e.g. numbers failing the Luhn check.
+	valid func(string) bool
+}
+
+// New returns a Masker applying the named built-in rules (all of them when
+// names is empty) plus patterns, extra regular expressions whose matches
+// are masked completely.
+func New(names []string, patterns []string) (*Masker, error) {
+	if len(names) == 0 {
+		names = Rules
+	}
+	m := &Masker{}
+	for _, name := range Rules {
+		if !contains(names, name) {
+			continue
+		}
+		switch name {
+		case RuleCard:
+			m.rules = append(m.rules, rule{pattern: cardPattern, keep: 4, valid: luhn})
+		case RulePhone:
+			for _, p := range phonePatterns {
+				m.rules = append(m.rules, rule{pattern: p, keep: 2, valid: phoneDigits})
+			}
+		case RuleOTP:
+			m.rules = append(m.rules, rule{pattern: otpPattern})
+		}
+	}
+	for _, name := range names {
+		if !contains(Rules, name) {
+			return nil, fmt.Errorf("unknown mask rule %q (expected %s)", name, strings.Join(Rules, ", "))
+		}
+	}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mask pattern %q: %w", p, err)
+		}
+		m.rules = append(m.rules, rule{pattern: re, keep: -1})
+	}
+	return m, nil
+}
+
+// Mask returns s with every match masked. Built-in rules replace digits
+// only, keeping separators and a few trailing digits; custom patterns
+// replace every non-space character.
+func (m *Masker) Mask(s string) string {
+	if m == nil {
+		return s
+	}
+	for _, r := range m.rules {
+		s = r.pattern.ReplaceAllStringFunc(s, func(match string) string {
+			if r.valid != nil && !r.valid(match) {
+				return match
+			}
+			return maskMatch(match, r.keep)
+		})
+	}
+	return s
+}
+
+// maskMatch masks the digits of match except the last keep ones. A
+// negative keep masks every non-space rune instead.
+func maskMatch(match string, keep int) string {
+	runes := []rune(match)
+	if keep < 0 {
+		for i, r := range runes {
+			if !unicode.IsSpace(r) {
+				runes[i] = maskRune
+			}
+		}
+		return string(runes)
+	}
+	for i := len(runes) - 1; i >= 0; i-- {
+		if !unicode.IsDigit(runes[i]) {
+			continue
+		}
+		if keep > 0 {
+			keep--
+			continue
+		}
+		runes[i] = maskRune
+	}
+	return string(runes)
+}
+
+func digits(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func phoneDigits(s string) bool {
+	n := len(digits(s))
+	return n >= 7 && n <= 15
+}
+
+// luhn reports whether the digits of s pass the Luhn checksum used by
+// payment cards.
+func luhn(s string) bool {
+	d := digits(s)
+	if len(d) < 13 || len(d) > 19 {
+		return false
+	}
+	sum := 0
+	for i := len(d) - 1; i >= 0; i-- {
+		n := int(d[i] - '0')
+		if (len(d)-1-i)%2 == 1 {
+			n *= 2
+			if n > 9 {
+				n -= 9
+			}
+		}
+		sum += n
+	}
+	return sum%10 == 0
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Writer masks text written to an underlying writer line by line, so
+// patterns split across Write calls are still caught. Call Flush to write a
+// trailing partial line.
+type Writer struct {
+	w      io.Writer
+	masker *Masker
+	buf    []byte
+}
+
+// NewWriter returns a Writer masking everything written to w.
+func NewWriter(w io.Writer, masker *Masker) *Writer {
+	return &Writer{w: w, masker: masker}
+}
+
+// Write buffers p and writes out every completed line, masked.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		line := w.masker.Mask(string(w.buf[:i+1]))
+		w.buf = w.buf[i+1:]
+		if _, err := io.WriteString(w.w, line); err != nil {
+			return len(p), err
+		}
+	}
+}
+
+// Flush writes any buffered partial line.
+func (w *Writer) Flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	line := w.masker.Mask(string(w.buf))
+	w.buf = nil
+	_, err := io.WriteString(w.w, line)
+	return err
+}
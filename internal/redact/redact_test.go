@@ -0,0 +1,73 @@
+package redact
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMask(t *testing.T) {
+	m, err := New(nil, nil)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	tests := []struct{ in, want string }{
+		{"call me at +49 170 1234567", "call me at +** *** *****67"},
+		{"office (415) 555-1234 ok", "office (***) ***-**34 ok"},
+		{"mobil 0170 1234567", "mobil **** *****67"},
+		{"card 4111 1111 1111 1111 thanks", "card **** **** **** 1111 thanks"},
+		{"Your verification code is 482913.", "Your verification code is ******."},
+		{"G-123456 is your Google code", "G-****** is your Google code"},
+		{"2025-12-19 16:37:05  Alice: see you at 7", "2025-12-19 16:37:05  Alice: see you at 7"},
+		{"order 4111 1111 1111 1112", "order 4111 1111 1111 1112"},
+		{"we were 12 people in 2024", "we were 12 people in 2024"},
+	}
+	for _, tt := range tests {
+		if got := m.Mask(tt.in); got != tt.want {
+			t.Errorf("Mask(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNewRulesAndPatterns(t *testing.T) {
+	m, err := New([]string{RuleOTP}, []string{`(?i)secret-\w+`})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	got := m.Mask("PIN 1234, +49 170 1234567, secret-plan")
+	if want := "PIN ****, +49 170 1234567, ***********"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if _, err := New([]string{"email"}, nil); err == nil {
+		t.Fatal("expected error for unknown rule")
+	}
+	if _, err := New(nil, []string{"("}); err == nil {
+		t.Fatal("expected error for invalid pattern")
+	}
+	if got := (*Masker)(nil).Mask("+49 170 1234567"); got != "+49 170 1234567" {
+		t.Fatalf("nil masker changed text: %q", got)
+	}
+}
+
+func TestWriterMasksAcrossWrites(t *testing.T) {
+	m, err := New(nil, nil)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	var out bytes.Buffer
+	w := NewWriter(&out, m)
+	for _, part := range []string{"call +49 170", " 1234567\nno", "thing\ncode 9", "876"} {
+		if _, err := w.Write([]byte(part)); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	if strings.Contains(out.String(), "code") {
+		t.Fatalf("partial line written before flush: %q", out.String())
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if want := "call +** *** *****67\nnothing\ncode ****"; out.String() != want {
+		t.Fatalf("got %q, want %q", out.String(), want)
+	}
+}
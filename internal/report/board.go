@@ -0,0 +1,149 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+)
+
+// boardDefaultLimit caps the favourites shown when no limit is set.
+const boardDefaultLimit = 10
+
+// sparkBars are the levels of a sparkline, lowest first.
+var sparkBars = []rune("▁▂▃▄▅▆▇█")
+
+// BoardOptions controls BuildBoard.
+type BoardOptions struct {
+	// ThreadIDs lists the threads to show, in order; empty means the
+	// favourite threads by last activity.
+	ThreadIDs []string
+	// Limit caps the number of favourites when ThreadIDs is empty.
+	Limit  int
+	Now    time.Time
+	Format beeper.MessageFormat
+}
+
+// Board is a one-screen overview of a set of threads.
+type Board struct {
+	GeneratedAt time.Time `json:"generatedAt"`
+	// Since is the start of today in local time.
+	Since time.Time `json:"since"`
+	// Today counts the board threads' messages since Since.
+	Today   int           `json:"today"`
+	Unread  int           `json:"unread"`
+	Hourly  []int         `json:"hourly"`
+	Threads []BoardThread `json:"threads"`
+}
+
+// BoardThread is one thread on a Board.
+type BoardThread struct {
+	ThreadID       string `json:"threadId"`
+	Name           string `json:"name"`
+	AccountID      string `json:"accountId,omitempty"`
+	Unread         int    `json:"unread"`
+	UnreadMentions int    `json:"unreadMentions,omitempty"`
+	Today          int    `json:"today"`
+	// Hourly counts today's messages per local hour, from midnight up to
+	// the current hour.
+	Hourly []int           `json:"hourly"`
+	Last   *beeper.Message `json:"last,omitempty"`
+}
+
+// BuildBoard gathers, for every board thread, its unread counts, last
+// message, and today's messages per hour.
+func BuildBoard(ctx context.Context, store *beeper.Store, opts BoardOptions) (Board, error) {
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	now = now.Local()
+	since := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	board := Board{GeneratedAt: now, Since: since, Hourly: make([]int, now.Hour()+1), Threads: []BoardThread{}}
+
+	threads, err := boardThreads(ctx, store, opts)
+	if err != nil {
+		return Board{}, err
+	}
+	for _, thread := range threads {
+		entry := BoardThread{
+			ThreadID:       thread.ID,
+			Name:           thread.DisplayName,
+			AccountID:      thread.AccountID,
+			Unread:         thread.UnreadCount,
+			UnreadMentions: thread.UnreadMentions,
+			Hourly:         make([]int, len(board.Hourly)),
+		}
+
+		last, err := store.ListMessages(ctx, beeper.MessageListOptions{ThreadID: thread.ID, Limit: 1, Format: opts.Format})
+		if err != nil {
+			return Board{}, err
+		}
+		if len(last) > 0 {
+			entry.Last = &last[0]
+		}
+		for msg, err := range store.IterMessages(ctx, beeper.MessageListOptions{ThreadID: thread.ID, After: &since, Before: &now, Format: opts.Format}) {
+			if err != nil {
+				return Board{}, err
+			}
+			hour := msg.Timestamp.Local().Hour()
+			if hour >= len(entry.Hourly) {
+				continue
+			}
+			entry.Hourly[hour]++
+			board.Hourly[hour]++
+			entry.Today++
+		}
+
+		board.Today += entry.Today
+		board.Unread += entry.Unread
+		board.Threads = append(board.Threads, entry)
+	}
+	return board, nil
+}
+
+// boardThreads returns the configured threads in order, or the favourites.
+func boardThreads(ctx context.Context, store *beeper.Store, opts BoardOptions) ([]beeper.Thread, error) {
+	if len(opts.ThreadIDs) == 0 {
+		limit := opts.Limit
+		if limit <= 0 {
+			limit = boardDefaultLimit
+		}
+		return store.ListThreads(ctx, beeper.ThreadListOptions{Label: beeper.LabelFavourite, IncludeLowPriority: true, Limit: limit})
+	}
+	threads := make([]beeper.Thread, 0, len(opts.ThreadIDs))
+	for _, id := range opts.ThreadIDs {
+		thread, err := store.GetThread(ctx, id, false)
+		if err != nil {
+			return nil, err
+		}
+		threads = append(threads, thread)
+	}
+	return threads, nil
+}
+
+// Sparkline renders counts as a row of block characters scaled to the
+// largest count; zero counts use the lowest bar.
+func Sparkline(counts []int) string {
+	peak := 0
+	for _, n := range counts {
+		peak = max(peak, n)
+	}
+	var b strings.Builder
+	for _, n := range counts {
+		level := 0
+		if peak > 0 && n > 0 {
+			level = max(1, n*(len(sparkBars)-1)/peak)
+		}
+		b.WriteRune(sparkBars[level])
+	}
+	return b.String()
+}
+
+// BoardSummary renders the board's headline, e.g.
+// "Mon Jan 2 15:04 · 12 unread · 40 messages today ▁▁▃█▂".
+func BoardSummary(b Board) string {
+	return fmt.Sprintf("%s · %d unread · %d messages today %s", b.GeneratedAt.Format("Mon Jan 2 15:04"), b.Unread, b.Today, Sparkline(b.Hourly))
+}
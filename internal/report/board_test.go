@@ -0,0 +1,25 @@
+package report
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSparklineAndBoardSummary(t *testing.T) {
+	if got := Sparkline([]int{0, 1, 4, 8, 0}); got != "▁▂▄█▁" {
+		t.Fatalf("unexpected sparkline %q", got)
+	}
+	if got := Sparkline([]int{0, 0}); got != "▁▁" {
+		t.Fatalf("unexpected empty sparkline %q", got)
+	}
+
+	board := Board{
+		GeneratedAt: time.Date(2025, 3, 4, 2, 15, 0, 0, time.Local),
+		Today:       5,
+		Unread:      3,
+		Hourly:      []int{1, 0, 4},
+	}
+	if got, want := BoardSummary(board), "Tue Mar 4 02:15 · 3 unread · 5 messages today ▂▁█"; got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,150 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+)
+
+// digestLineLength caps the first and last lines quoted per conversation.
+const digestLineLength = 160
+
+// DigestOptions controls BuildDigest.
+type DigestOptions struct {
+	Since     time.Time
+	Until     time.Time
+	AccountID string
+	// Limit caps the number of conversations (busiest first).
+	Limit  int
+	Format beeper.MessageFormat
+}
+
+// Digest summarizes the conversations that were active in a time window.
+type Digest struct {
+	Since          time.Time      `json:"since"`
+	Until          time.Time      `json:"until"`
+	AccountID      string         `json:"accountId,omitempty"`
+	Messages       int            `json:"messages"`
+	UnreadMentions int            `json:"unreadMentions"`
+	Conversations  []DigestThread `json:"conversations"`
+}
+
+// DigestThread is one active conversation in a Digest.
+type DigestThread struct {
+	ThreadID       string          `json:"threadId"`
+	Name           string          `json:"name"`
+	AccountID      string          `json:"accountId,omitempty"`
+	NewMessages    int             `json:"newMessages"`
+	Received       int             `json:"received"`
+	UnreadMentions int             `json:"unreadMentions,omitempty"`
+	First          *beeper.Message `json:"first,omitempty"`
+	Last           *beeper.Message `json:"last,omitempty"`
+}
+
+// BuildDigest gathers, for every conversation with messages between Since
+// and Until, the number of new messages, its unread mentions, and the
+// first and last message in the window.
+func BuildDigest(ctx context.Context, store *beeper.Store, opts DigestOptions) (Digest, error) {
+	since, until := opts.Since, opts.Until
+	digest := Digest{Since: since, Until: until, AccountID: opts.AccountID, Conversations: []DigestThread{}}
+
+	counts, err := store.TopThreads(ctx, beeper.StatsOptions{After: &since, Before: &until, AccountID: opts.AccountID}, opts.Limit)
+	if err != nil {
+		return Digest{}, err
+	}
+	for _, c := range counts {
+		thread, err := store.GetThread(ctx, c.ThreadID, false)
+		if err != nil {
+			return Digest{}, err
+		}
+		conv := DigestThread{
+			ThreadID:       c.ThreadID,
+			Name:           c.Name,
+			AccountID:      c.AccountID,
+			NewMessages:    c.Messages,
+			Received:       c.Received,
+			UnreadMentions: thread.UnreadMentions,
+		}
+
+		last, err := store.ListMessages(ctx, beeper.MessageListOptions{ThreadID: c.ThreadID, After: &since, Before: &until, Limit: 1, Format: opts.Format})
+		if err != nil {
+			return Digest{}, err
+		}
+		if len(last) > 0 {
+			conv.Last = &last[0]
+		}
+		first, err := store.ScanMessages(ctx, beeper.ScanOptions{ThreadID: c.ThreadID, After: &since, Before: &until, Limit: 1, Format: opts.Format})
+		if err != nil {
+			return Digest{}, err
+		}
+		if len(first) > 0 && (conv.Last == nil || first[0].ID != conv.Last.ID) {
+			conv.First = &first[0]
+			for _, p := range thread.Participants {
+				if p.ID == conv.First.SenderID {
+					conv.First.SenderName = p.Name
+				}
+			}
+		}
+
+		digest.Messages += conv.NewMessages
+		digest.UnreadMentions += conv.UnreadMentions
+		digest.Conversations = append(digest.Conversations, conv)
+	}
+	return digest, nil
+}
+
+// WriteDigestMarkdown renders the digest as Markdown.
+func WriteDigestMarkdown(w io.Writer, d Digest) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Digest: %s – %s\n\n", d.Since.Local().Format("2006-01-02 15:04"), d.Until.Local().Format("2006-01-02 15:04"))
+	if d.AccountID != "" {
+		fmt.Fprintf(&b, "_Account: %s_\n\n", d.AccountID)
+	}
+	if len(d.Conversations) == 0 {
+		b.WriteString("No new messages.\n")
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+	fmt.Fprintf(&b, "**%d** new messages in **%d** conversations", d.Messages, len(d.Conversations))
+	if d.UnreadMentions > 0 {
+		fmt.Fprintf(&b, ", **%d** unread mentions", d.UnreadMentions)
+	}
+	b.WriteString("\n")
+
+	for _, c := range d.Conversations {
+		fmt.Fprintf(&b, "\n## %s", c.Name)
+		if c.AccountID != "" {
+			fmt.Fprintf(&b, " (%s)", c.AccountID)
+		}
+		b.WriteString("\n\n")
+		fmt.Fprintf(&b, "- %d new messages, %d received", c.NewMessages, c.Received)
+		if c.UnreadMentions > 0 {
+			fmt.Fprintf(&b, ", **%d unread mentions**", c.UnreadMentions)
+		}
+		b.WriteString("\n")
+		if c.First != nil {
+			fmt.Fprintf(&b, "- First: %s\n", digestLine(*c.First))
+		}
+		if c.Last != nil {
+			fmt.Fprintf(&b, "- Last: %s\n", digestLine(*c.Last))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// digestLine renders a message as "Jan 2 15:04 Sender: text" on one line.
+func digestLine(msg beeper.Message) string {
+	sender := msg.SenderName
+	if msg.IsSentByMe {
+		sender = "You"
+	} else if sender == "" {
+		sender = msg.SenderID
+	}
+	return fmt.Sprintf("%s %s: %s", msg.Timestamp.Local().Format("Jan 2 15:04"), sender, beeper.Preview(msg.Text, digestLineLength))
+}
@@ -0,0 +1,54 @@
+package report
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+)
+
+func TestWriteDigestMarkdown(t *testing.T) {
+	at := time.Date(2025, 3, 4, 9, 30, 0, 0, time.Local)
+	digest := Digest{
+		Since:          at.Add(-24 * time.Hour),
+		Until:          at,
+		Messages:       3,
+		UnreadMentions: 1,
+		Conversations: []DigestThread{{
+			ThreadID:       "!room1:beeper.local",
+			Name:           "Team Chat",
+			AccountID:      "whatsapp",
+			NewMessages:    3,
+			Received:       2,
+			UnreadMentions: 1,
+			First:          &beeper.Message{SenderName: "Alice", Timestamp: at.Add(-time.Hour), Text: "good\nmorning"},
+			Last:           &beeper.Message{IsSentByMe: true, Timestamp: at, Text: strings.Repeat("x", 200)},
+		}},
+	}
+
+	var b strings.Builder
+	if err := WriteDigestMarkdown(&b, digest); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"**3** new messages in **1** conversations, **1** unread mentions",
+		"## Team Chat (whatsapp)",
+		"- 3 new messages, 2 received, **1 unread mentions**",
+		"- First: Mar 4 08:30 Alice: good morning\n",
+		"- Last: Mar 4 09:30 You: " + strings.Repeat("x", digestLineLength-1) + "…\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	b.Reset()
+	if err := WriteDigestMarkdown(&b, Digest{Since: digest.Since, Until: digest.Until}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if !strings.Contains(b.String(), "No new messages.") {
+		t.Fatalf("expected empty digest, got:\n%s", b.String())
+	}
+}
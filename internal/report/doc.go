@@ -0,0 +1,2 @@
+// Package report composes Store analytics into human-readable reports.
+package report
@@ -0,0 +1,150 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+)
+
+const wrappedTopN = 5
+
+// Wrapped is an annual "year in review" report.
+type Wrapped struct {
+	Year          int                  `json:"year"`
+	AccountID     string               `json:"accountId,omitempty"`
+	Totals        beeper.TextStats     `json:"totals"`
+	ActiveDays    int                  `json:"activeDays"`
+	BusiestDay    beeper.DayCount      `json:"busiestDay"`
+	LongestStreak beeper.Streak        `json:"longestStreak"`
+	TopContacts   []beeper.SenderCount `json:"topContacts"`
+	TopThreads    []beeper.ThreadCount `json:"topThreads"`
+	ThreadStreaks []beeper.Streak      `json:"threadStreaks"`
+}
+
+// BuildWrapped gathers the analytics for one calendar year in local time.
+func BuildWrapped(ctx context.Context, store *beeper.Store, year int, accountID string) (Wrapped, error) {
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.Local)
+	end := start.AddDate(1, 0, 0).Add(-time.Millisecond)
+	opts := beeper.StatsOptions{After: &start, Before: &end, AccountID: accountID}
+
+	report := Wrapped{Year: year, AccountID: accountID}
+	var err error
+	if report.Totals, err = store.TextStats(ctx, opts, wrappedTopN); err != nil {
+		return Wrapped{}, err
+	}
+	if report.TopContacts, err = store.TopSenders(ctx, opts, wrappedTopN); err != nil {
+		return Wrapped{}, err
+	}
+	if report.TopThreads, err = store.TopThreads(ctx, opts, wrappedTopN); err != nil {
+		return Wrapped{}, err
+	}
+	if report.ThreadStreaks, err = store.ThreadStreaks(ctx, opts, wrappedTopN); err != nil {
+		return Wrapped{}, err
+	}
+
+	perDay, err := store.MessagesPerDay(ctx, opts)
+	if err != nil {
+		return Wrapped{}, err
+	}
+	days := make([]string, 0, len(perDay))
+	for _, day := range perDay {
+		days = append(days, day.Day)
+		if day.Messages > report.BusiestDay.Messages {
+			report.BusiestDay = day
+		}
+	}
+	report.ActiveDays = len(days)
+	report.LongestStreak = beeper.LongestStreak(days)
+	return report, nil
+}
+
+// WriteWrappedMarkdown renders the report as Markdown.
+func WriteWrappedMarkdown(w io.Writer, r Wrapped) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %d Wrapped\n\n", r.Year)
+	if r.AccountID != "" {
+		fmt.Fprintf(&b, "_Account: %s_\n\n", r.AccountID)
+	}
+	b.WriteString("## The numbers\n\n")
+	fmt.Fprintf(&b, "- **%d** messages (%d sent, %d received)\n", r.Totals.Messages, r.Totals.Sent, r.Totals.Received)
+	fmt.Fprintf(&b, "- **%d** words in total, **%d** of them yours\n", r.Totals.Words, r.Totals.SentWords)
+	fmt.Fprintf(&b, "- Active on **%d** days\n", r.ActiveDays)
+	if r.BusiestDay.Day != "" {
+		fmt.Fprintf(&b, "- Busiest day: **%s** with %d messages\n", r.BusiestDay.Day, r.BusiestDay.Messages)
+	}
+	if r.LongestStreak.Days > 0 {
+		fmt.Fprintf(&b, "- Longest streak: **%d days** (%s to %s)\n", r.LongestStreak.Days, r.LongestStreak.Start, r.LongestStreak.End)
+	}
+
+	if len(r.TopContacts) > 0 {
+		b.WriteString("\n## Top contacts\n\n")
+		for i, c := range r.TopContacts {
+			fmt.Fprintf(&b, "%d. %s — %d messages\n", i+1, c.Name, c.Messages)
+		}
+	}
+	if len(r.TopThreads) > 0 {
+		b.WriteString("\n## Busiest conversations\n\n")
+		for i, t := range r.TopThreads {
+			fmt.Fprintf(&b, "%d. %s — %d messages\n", i+1, t.Name, t.Messages)
+		}
+	}
+	if len(r.ThreadStreaks) > 0 {
+		b.WriteString("\n## Longest streaks\n\n")
+		for i, s := range r.ThreadStreaks {
+			fmt.Fprintf(&b, "%d. %s — %d days in a row (%s to %s)\n", i+1, s.ThreadName, s.Days, s.Start, s.End)
+		}
+	}
+	if len(r.Totals.TopEmoji) > 0 {
+		b.WriteString("\n## Your most-used emoji\n\n")
+		for i, e := range r.Totals.TopEmoji {
+			fmt.Fprintf(&b, "%d. %s × %d\n", i+1, e.Emoji, e.Count)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+var wrappedHTML = template.Must(template.New("wrapped").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Year}} Wrapped</title>
+<style>
+body { font-family: -apple-system, system-ui, sans-serif; max-width: 40rem; margin: 2rem auto; padding: 0 1rem; background: #111; color: #eee; }
+h1 { font-size: 2.5rem; }
+h2 { color: #9af; border-bottom: 1px solid #333; }
+.big { font-size: 1.4rem; }
+.emoji { font-size: 2rem; }
+</style>
+</head>
+<body>
+<h1>{{.Year}} Wrapped</h1>
+{{if .AccountID}}<p><em>Account: {{.AccountID}}</em></p>{{end}}
+<h2>The numbers</h2>
+<p class="big"><strong>{{.Totals.Messages}}</strong> messages ({{.Totals.Sent}} sent, {{.Totals.Received}} received)</p>
+<p class="big"><strong>{{.Totals.Words}}</strong> words in total, <strong>{{.Totals.SentWords}}</strong> of them yours</p>
+<p class="big">Active on <strong>{{.ActiveDays}}</strong> days</p>
+{{if .BusiestDay.Day}}<p class="big">Busiest day: <strong>{{.BusiestDay.Day}}</strong> with {{.BusiestDay.Messages}} messages</p>{{end}}
+{{if .LongestStreak.Days}}<p class="big">Longest streak: <strong>{{.LongestStreak.Days}} days</strong> ({{.LongestStreak.Start}} to {{.LongestStreak.End}})</p>{{end}}
+{{if .TopContacts}}<h2>Top contacts</h2>
+<ol>{{range .TopContacts}}<li>{{.Name}} — {{.Messages}} messages</li>{{end}}</ol>{{end}}
+{{if .TopThreads}}<h2>Busiest conversations</h2>
+<ol>{{range .TopThreads}}<li>{{.Name}} — {{.Messages}} messages</li>{{end}}</ol>{{end}}
+{{if .ThreadStreaks}}<h2>Longest streaks</h2>
+<ol>{{range .ThreadStreaks}}<li>{{.ThreadName}} — {{.Days}} days in a row ({{.Start}} to {{.End}})</li>{{end}}</ol>{{end}}
+{{if .Totals.TopEmoji}}<h2>Your most-used emoji</h2>
+<p class="emoji">{{range $i, $e := .Totals.TopEmoji}}{{if $i}} · {{end}}{{$e.Emoji}} <small>×{{$e.Count}}</small>{{end}}</p>{{end}}
+</body>
+</html>
+`))
+
+// WriteWrappedHTML renders the report as a standalone HTML page.
+func WriteWrappedHTML(w io.Writer, r Wrapped) error {
+	return wrappedHTML.Execute(w, r)
+}
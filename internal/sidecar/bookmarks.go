@@ -0,0 +1,70 @@
+package sidecar
+
+import (
+	"context"
+	"time"
+)
+
+// Bookmark marks a message, by event ID, for later reference. Bookmarks
+// live only in the sidecar; Beeper never sees them.
+type Bookmark struct {
+	EventID   string    `json:"eventId"`
+	ThreadID  string    `json:"threadId"`
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// AddBookmark bookmarks a message. Bookmarking it again replaces the note
+// and keeps the original creation time.
+func (d *DB) AddBookmark(ctx context.Context, b Bookmark) error {
+	if b.CreatedAt.IsZero() {
+		b.CreatedAt = time.Now()
+	}
+	_, err := d.db.ExecContext(ctx, `INSERT INTO bookmarks (event_id, thread_id, note, created_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (event_id) DO UPDATE SET
+			thread_id = excluded.thread_id,
+			note = excluded.note`,
+		b.EventID, b.ThreadID, b.Note, b.CreatedAt.UnixMilli())
+	return err
+}
+
+// RemoveBookmark deletes a bookmark; ok is false if there was none.
+func (d *DB) RemoveBookmark(ctx context.Context, eventID string) (bool, error) {
+	res, err := d.db.ExecContext(ctx, "DELETE FROM bookmarks WHERE event_id = ?", eventID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// Bookmarks returns every bookmark, oldest first, optionally only those in
+// one thread.
+func (d *DB) Bookmarks(ctx context.Context, threadID string) ([]Bookmark, error) {
+	query := "SELECT event_id, thread_id, note, created_at FROM bookmarks"
+	args := []any{}
+	if threadID != "" {
+		query += " WHERE thread_id = ?"
+		args = append(args, threadID)
+	}
+	query += " ORDER BY created_at, event_id"
+
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	bookmarks := []Bookmark{}
+	for rows.Next() {
+		var b Bookmark
+		var created int64
+		if err := rows.Scan(&b.EventID, &b.ThreadID, &b.Note, &created); err != nil {
+			return nil, err
+		}
+		b.CreatedAt = time.UnixMilli(created)
+		bookmarks = append(bookmarks, b)
+	}
+	return bookmarks, rows.Err()
+}
@@ -0,0 +1,48 @@
+package sidecar
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBookmarks(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "sidecar.db"))
+	if err != nil {
+		t.Fatalf("open sidecar: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	ctx := context.Background()
+
+	first := time.UnixMilli(1700000000000)
+	if err := db.AddBookmark(ctx, Bookmark{EventID: "$b", ThreadID: "!room1", Note: "flight", CreatedAt: first.Add(time.Minute)}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if err := db.AddBookmark(ctx, Bookmark{EventID: "$a", ThreadID: "!room2", CreatedAt: first}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	// Re-bookmarking replaces the note but keeps the creation time.
+	if err := db.AddBookmark(ctx, Bookmark{EventID: "$b", ThreadID: "!room1", Note: "hotel"}); err != nil {
+		t.Fatalf("re-add: %v", err)
+	}
+
+	all, err := db.Bookmarks(ctx, "")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(all) != 2 || all[0].EventID != "$a" || all[1].Note != "hotel" || !all[1].CreatedAt.Equal(first.Add(time.Minute)) {
+		t.Fatalf("unexpected bookmarks: %+v", all)
+	}
+	inThread, err := db.Bookmarks(ctx, "!room1")
+	if err != nil || len(inThread) != 1 || inThread[0].EventID != "$b" {
+		t.Fatalf("unexpected thread bookmarks: %+v, %v", inThread, err)
+	}
+
+	if ok, err := db.RemoveBookmark(ctx, "$a"); err != nil || !ok {
+		t.Fatalf("remove: ok=%v err=%v", ok, err)
+	}
+	if ok, err := db.RemoveBookmark(ctx, "$a"); err != nil || ok {
+		t.Fatalf("remove again: ok=%v err=%v", ok, err)
+	}
+}
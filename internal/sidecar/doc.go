@@ -0,0 +1,5 @@
+// Package sidecar stores data derived from the Beeper database (entity
+// annotations, search indexes, the thread listing cache) and local
+// annotations such as bookmarks in a separate, writable SQLite file, so
+// Beeper's own index.db is only ever opened read-only.
+package sidecar
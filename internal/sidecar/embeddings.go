@@ -0,0 +1,105 @@
+package sidecar
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// Embedding is the vector of one message under one embedding model.
+type Embedding struct {
+	MessageID int64     `json:"messageId"`
+	EventID   string    `json:"eventId"`
+	ThreadID  string    `json:"threadId"`
+	Model     string    `json:"model"`
+	Vector    []float32 `json:"vector"`
+}
+
+// ReplaceEmbeddings deletes stored vectors of model for messageIDs and
+// stores embeddings in their place, in a single transaction.
+func (d *DB) ReplaceEmbeddings(ctx context.Context, model string, messageIDs []int64, embeddings []Embedding) error {
+	ids, err := json.Marshal(messageIDs)
+	if err != nil {
+		return err
+	}
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM embeddings WHERE model = ? AND message_id IN (SELECT value FROM json_each(?))", model, string(ids)); err != nil {
+		return err
+	}
+	stmt, err := tx.PrepareContext(ctx, "INSERT INTO embeddings (message_id, model, event_id, thread_id, vector) VALUES (?, ?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = stmt.Close() }()
+	for _, e := range embeddings {
+		if _, err := stmt.ExecContext(ctx, e.MessageID, model, e.EventID, e.ThreadID, encodeVector(e.Vector)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// CountEmbeddings returns how many messages have a vector under model.
+func (d *DB) CountEmbeddings(ctx context.Context, model string) (int, error) {
+	var n int
+	err := d.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM embeddings WHERE model = ?", model).Scan(&n)
+	return n, err
+}
+
+// EachEmbedding calls fn for every vector stored under model, optionally
+// restricted to one thread. Iteration stops at the first error.
+func (d *DB) EachEmbedding(ctx context.Context, model string, threadID string, fn func(Embedding) error) error {
+	query := "SELECT message_id, event_id, thread_id, vector FROM embeddings WHERE model = ?"
+	args := []any{model}
+	if threadID != "" {
+		query += " AND thread_id = ?"
+		args = append(args, threadID)
+	}
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		e := Embedding{Model: model}
+		var blob []byte
+		if err := rows.Scan(&e.MessageID, &e.EventID, &e.ThreadID, &blob); err != nil {
+			return err
+		}
+		if e.Vector, err = decodeVector(blob); err != nil {
+			return fmt.Errorf("message %d: %w", e.MessageID, err)
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// encodeVector stores a vector as little-endian float32s.
+func encodeVector(v []float32) []byte {
+	buf := make([]byte, 4*len(v))
+	for i, f := range v {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(f))
+	}
+	return buf
+}
+
+func decodeVector(buf []byte) ([]float32, error) {
+	if len(buf)%4 != 0 {
+		return nil, fmt.Errorf("invalid vector length %d", len(buf))
+	}
+	v := make([]float32, len(buf)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4*i:]))
+	}
+	return v, nil
+}
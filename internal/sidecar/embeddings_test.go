@@ -0,0 +1,59 @@
+package sidecar
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestEmbeddings(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "sidecar.db"))
+	if err != nil {
+		t.Fatalf("open sidecar: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	ctx := context.Background()
+
+	err = db.ReplaceEmbeddings(ctx, "m1", []int64{1, 2}, []Embedding{
+		{MessageID: 1, ThreadID: "!a", Vector: []float32{1, 0, -0.5}},
+		{MessageID: 2, ThreadID: "!b", Vector: []float32{0, 1, 0}},
+	})
+	if err != nil {
+		t.Fatalf("replace embeddings: %v", err)
+	}
+	if err := db.ReplaceEmbeddings(ctx, "m2", []int64{1}, []Embedding{{MessageID: 1, ThreadID: "!a", Vector: []float32{1}}}); err != nil {
+		t.Fatalf("replace other model: %v", err)
+	}
+
+	got := map[int64][]float32{}
+	collect := func(e Embedding) error {
+		got[e.MessageID] = e.Vector
+		return nil
+	}
+	if err := db.EachEmbedding(ctx, "m1", "", collect); err != nil {
+		t.Fatalf("each: %v", err)
+	}
+	want := map[int64][]float32{1: {1, 0, -0.5}, 2: {0, 1, 0}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	got = map[int64][]float32{}
+	if err := db.EachEmbedding(ctx, "m1", "!b", collect); err != nil {
+		t.Fatalf("each thread: %v", err)
+	}
+	if len(got) != 1 || got[2] == nil {
+		t.Fatalf("expected only message 2, got %v", got)
+	}
+
+	if err := db.ReplaceEmbeddings(ctx, "m1", []int64{1}, nil); err != nil {
+		t.Fatalf("replace again: %v", err)
+	}
+	if n, _ := db.CountEmbeddings(ctx, "m1"); n != 1 {
+		t.Fatalf("expected 1 vector for m1, got %d", n)
+	}
+	if n, _ := db.CountEmbeddings(ctx, "m2"); n != 1 {
+		t.Fatalf("expected 1 vector for m2, got %d", n)
+	}
+}
@@ -0,0 +1,120 @@
+package sidecar
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+)
+
+// Entity is a named entity found in a message.
+type Entity struct {
+	MessageID int64  `json:"messageId"`
+	EventID   string `json:"eventId"`
+	ThreadID  string `json:"threadId"`
+	Kind      string `json:"kind"`
+	Text      string `json:"text"`
+	Start     int    `json:"start"`
+	End       int    `json:"end"`
+}
+
+// EntityCount is how many messages mention an entity.
+type EntityCount struct {
+	Kind     string `json:"kind"`
+	Text     string `json:"text"`
+	Messages int    `json:"messages"`
+}
+
+// NormalizeEntity returns the lookup key for an entity text.
+func NormalizeEntity(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
+
+// ReplaceEntities deletes stored entities for messageIDs and inserts
+// entities in their place, in a single transaction.
+func (d *DB) ReplaceEntities(ctx context.Context, messageIDs []int64, entities []Entity) error {
+	ids, err := json.Marshal(messageIDs)
+	if err != nil {
+		return err
+	}
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM entities WHERE message_id IN (SELECT value FROM json_each(?))", string(ids)); err != nil {
+		return err
+	}
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO entities (message_id, event_id, thread_id, kind, text, norm, start_offset, end_offset)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = stmt.Close() }()
+	for _, e := range entities {
+		if _, err := stmt.ExecContext(ctx, e.MessageID, e.EventID, e.ThreadID, e.Kind, e.Text, NormalizeEntity(e.Text), e.Start, e.End); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// MessageIDsForEntity returns IDs of messages mentioning text, optionally
+// restricted to one kind.
+func (d *DB) MessageIDsForEntity(ctx context.Context, text string, kind string) ([]int64, error) {
+	query := "SELECT DISTINCT message_id FROM entities WHERE norm = ?"
+	args := []any{NormalizeEntity(text)}
+	if kind != "" {
+		query += " AND kind = ?"
+		args = append(args, kind)
+	}
+	query += " ORDER BY message_id"
+
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// TopEntities returns the most frequently mentioned entities, optionally of
+// one kind.
+func (d *DB) TopEntities(ctx context.Context, kind string, limit int) ([]EntityCount, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+	query := "SELECT kind, MIN(text), COUNT(DISTINCT message_id) AS c FROM entities"
+	args := []any{}
+	if kind != "" {
+		query += " WHERE kind = ?"
+		args = append(args, kind)
+	}
+	query += " GROUP BY kind, norm ORDER BY c DESC, norm ASC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	counts := []EntityCount{}
+	for rows.Next() {
+		var c EntityCount
+		if err := rows.Scan(&c.Kind, &c.Text, &c.Messages); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
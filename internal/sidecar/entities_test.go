@@ -0,0 +1,54 @@
+package sidecar
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestEntities(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "nested", "sidecar.db"))
+	if err != nil {
+		t.Fatalf("open sidecar: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	ctx := context.Background()
+
+	err = db.ReplaceEntities(ctx, []int64{1, 2, 3}, []Entity{
+		{MessageID: 1, Kind: "place", Text: "Berlin"},
+		{MessageID: 2, Kind: "place", Text: "berlin"},
+		{MessageID: 2, Kind: "organization", Text: "Google"},
+		{MessageID: 3, Kind: "person", Text: "Berlin"},
+	})
+	if err != nil {
+		t.Fatalf("replace entities: %v", err)
+	}
+
+	ids, err := db.MessageIDsForEntity(ctx, " BERLIN ", "")
+	if err != nil {
+		t.Fatalf("lookup: %v", err)
+	}
+	if !reflect.DeepEqual(ids, []int64{1, 2, 3}) {
+		t.Fatalf("unexpected ids: %v", ids)
+	}
+	ids, err = db.MessageIDsForEntity(ctx, "berlin", "place")
+	if err != nil {
+		t.Fatalf("lookup by kind: %v", err)
+	}
+	if !reflect.DeepEqual(ids, []int64{1, 2}) {
+		t.Fatalf("unexpected ids by kind: %v", ids)
+	}
+
+	// Re-extracting message 2 replaces its entities.
+	if err := db.ReplaceEntities(ctx, []int64{2}, nil); err != nil {
+		t.Fatalf("replace again: %v", err)
+	}
+	top, err := db.TopEntities(ctx, "", 0)
+	if err != nil {
+		t.Fatalf("top: %v", err)
+	}
+	if len(top) != 2 || top[0].Messages != 1 {
+		t.Fatalf("unexpected top entities: %+v", top)
+	}
+}
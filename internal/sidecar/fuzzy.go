@@ -0,0 +1,305 @@
+package sidecar
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// DefaultFuzzySimilarity is the least similarity (see Similarity) a word
+// must have to a query word to count as a fuzzy match.
+const DefaultFuzzySimilarity = 0.7
+
+// fuzzyTrigramShare is the share of a query word's trigrams a word must
+// have to be compared with it at all.
+const fuzzyTrigramShare = 0.3
+
+// FuzzyHit is a message matching a fuzzy query. Score is the mean, over the
+// query's words, of the best similarity of a word in the message.
+type FuzzyHit struct {
+	MessageID int64
+	Score     float64
+}
+
+// Terms splits text into the distinct lowercase words the fuzzy index
+// stores. Words are runs of letters and digits of at least two runes.
+func Terms(text string) []string {
+	terms := []string{}
+	seen := map[string]struct{}{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(word)) < 2 {
+			continue
+		}
+		if _, ok := seen[word]; ok {
+			continue
+		}
+		seen[word] = struct{}{}
+		terms = append(terms, word)
+	}
+	return terms
+}
+
+// Trigrams returns the distinct trigrams of a word padded with two leading
+// spaces and one trailing space, as in PostgreSQL's pg_trgm, so short words
+// and word starts get trigrams of their own.
+func Trigrams(term string) []string {
+	runes := []rune("  " + term + " ")
+	trigrams := []string{}
+	seen := map[string]struct{}{}
+	for i := 0; i+3 <= len(runes); i++ {
+		t := string(runes[i : i+3])
+		if _, ok := seen[t]; ok {
+			continue
+		}
+		seen[t] = struct{}{}
+		trigrams = append(trigrams, t)
+	}
+	return trigrams
+}
+
+// Similarity is 1 minus the edit distance between a and b, relative to the
+// longer word; swapping two adjacent letters counts as one edit, so
+// "recieve" and "receive" are 6/7 similar.
+func Similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(editDistance(ra, rb))/float64(longest)
+}
+
+// editDistance is the optimal string alignment distance: insertions,
+// deletions, substitutions, and transpositions of adjacent runes.
+func editDistance(a, b []rune) int {
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(b)]
+}
+
+// ReplaceFuzzyTerms deletes the indexed words of messageIDs and indexes
+// terms (message ID to its words, see Terms) in their place, in a single
+// transaction.
+func (d *DB) ReplaceFuzzyTerms(ctx context.Context, messageIDs []int64, terms map[int64][]string) error {
+	ids, err := json.Marshal(messageIDs)
+	if err != nil {
+		return err
+	}
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM fuzzy_postings WHERE message_id IN (SELECT value FROM json_each(?))", string(ids)); err != nil {
+		return err
+	}
+	insertTerm, err := tx.PrepareContext(ctx, "INSERT INTO fuzzy_terms (term) VALUES (?) ON CONFLICT (term) DO NOTHING")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = insertTerm.Close() }()
+	lookupTerm, err := tx.PrepareContext(ctx, "SELECT id FROM fuzzy_terms WHERE term = ?")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = lookupTerm.Close() }()
+	insertTrigram, err := tx.PrepareContext(ctx, "INSERT OR IGNORE INTO fuzzy_trigrams (trigram, term_id) VALUES (?, ?)")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = insertTrigram.Close() }()
+	insertPosting, err := tx.PrepareContext(ctx, "INSERT OR IGNORE INTO fuzzy_postings (term_id, message_id) VALUES (?, ?)")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = insertPosting.Close() }()
+
+	termIDs := map[string]int64{}
+	for _, messageID := range messageIDs {
+		for _, term := range terms[messageID] {
+			id, ok := termIDs[term]
+			if !ok {
+				res, err := insertTerm.ExecContext(ctx, term)
+				if err != nil {
+					return err
+				}
+				if err := lookupTerm.QueryRowContext(ctx, term).Scan(&id); err != nil {
+					return err
+				}
+				if added, _ := res.RowsAffected(); added > 0 {
+					for _, trigram := range Trigrams(term) {
+						if _, err := insertTrigram.ExecContext(ctx, trigram, id); err != nil {
+							return err
+						}
+					}
+				}
+				termIDs[term] = id
+			}
+			if _, err := insertPosting.ExecContext(ctx, id, messageID); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// HasFuzzyIndex reports whether `extract --kind fuzzy` has run: the fuzzy
+// index has a checkpoint, or a filtered run, which keeps none, stored words.
+func (d *DB) HasFuzzyIndex(ctx context.Context) (bool, error) {
+	if _, ok, err := d.IndexState(ctx, "fuzzy"); err != nil || ok {
+		return ok, err
+	}
+	var one int
+	err := d.db.QueryRowContext(ctx, "SELECT 1 FROM fuzzy_terms LIMIT 1").Scan(&one)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// SearchFuzzy returns the messages containing, for every word of query, a
+// word at least minSimilarity similar to it, best first (ties: newest
+// message first). Candidate words are found through shared trigrams and
+// then compared by edit distance.
+func (d *DB) SearchFuzzy(ctx context.Context, query string, minSimilarity float64) ([]FuzzyHit, error) {
+	if minSimilarity <= 0 {
+		minSimilarity = DefaultFuzzySimilarity
+	}
+	words := Terms(query)
+	if len(words) == 0 {
+		return []FuzzyHit{}, nil
+	}
+
+	// best[message][word] is the best similarity of a message's words to
+	// query word i.
+	best := map[int64][]float64{}
+	for i, word := range words {
+		similar, err := d.similarTerms(ctx, word, minSimilarity)
+		if err != nil {
+			return nil, err
+		}
+		if len(similar) == 0 {
+			return []FuzzyHit{}, nil
+		}
+		postings, err := d.postings(ctx, similar)
+		if err != nil {
+			return nil, err
+		}
+		for messageID, score := range postings {
+			if i > 0 && best[messageID] == nil {
+				continue
+			}
+			if best[messageID] == nil {
+				best[messageID] = make([]float64, len(words))
+			}
+			best[messageID][i] = score
+		}
+		// Drop messages that missed this word.
+		for messageID, scores := range best {
+			if scores[i] == 0 {
+				delete(best, messageID)
+			}
+		}
+	}
+
+	hits := make([]FuzzyHit, 0, len(best))
+	for messageID, scores := range best {
+		total := 0.0
+		for _, s := range scores {
+			total += s
+		}
+		hits = append(hits, FuzzyHit{MessageID: messageID, Score: total / float64(len(scores))})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].MessageID > hits[j].MessageID
+	})
+	return hits, nil
+}
+
+// similarTerms returns the IDs of indexed words similar to word, with their
+// similarity.
+func (d *DB) similarTerms(ctx context.Context, word string, minSimilarity float64) (map[int64]float64, error) {
+	trigrams := Trigrams(word)
+	list, err := json.Marshal(trigrams)
+	if err != nil {
+		return nil, err
+	}
+	shared := max(1, int(float64(len(trigrams))*fuzzyTrigramShare+0.5))
+	rows, err := d.db.QueryContext(ctx, `SELECT t.id, t.term FROM fuzzy_terms t
+		JOIN (SELECT term_id FROM fuzzy_trigrams
+			WHERE trigram IN (SELECT value FROM json_each(?))
+			GROUP BY term_id HAVING COUNT(*) >= ?) c ON c.term_id = t.id`, string(list), shared)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	similar := map[int64]float64{}
+	for rows.Next() {
+		var id int64
+		var term string
+		if err := rows.Scan(&id, &term); err != nil {
+			return nil, err
+		}
+		if s := Similarity(word, term); s >= minSimilarity {
+			similar[id] = s
+		}
+	}
+	return similar, rows.Err()
+}
+
+// postings maps every message containing one of terms to the best
+// similarity among the terms it contains.
+func (d *DB) postings(ctx context.Context, terms map[int64]float64) (map[int64]float64, error) {
+	ids := make([]int64, 0, len(terms))
+	for id := range terms {
+		ids = append(ids, id)
+	}
+	list, err := json.Marshal(ids)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := d.db.QueryContext(ctx, "SELECT term_id, message_id FROM fuzzy_postings WHERE term_id IN (SELECT value FROM json_each(?))", string(list))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	best := map[int64]float64{}
+	for rows.Next() {
+		var termID, messageID int64
+		if err := rows.Scan(&termID, &messageID); err != nil {
+			return nil, err
+		}
+		best[messageID] = max(best[messageID], terms[termID])
+	}
+	return best, rows.Err()
+}
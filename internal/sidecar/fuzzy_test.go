@@ -0,0 +1,102 @@
+package sidecar
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"receive", "receive", 1},
+		{"recieve", "receive", 6.0 / 7},
+		{"recive", "receive", 6.0 / 7},
+		{"party", "partly", 5.0 / 6},
+		{"cat", "dog", 0},
+	}
+	for _, tt := range tests {
+		if got := Similarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Fatalf("Similarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSearchFuzzy(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "sidecar.db"))
+	if err != nil {
+		t.Fatalf("open sidecar: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	ctx := context.Background()
+
+	texts := map[int64]string{
+		1: "Did you receive the invoice?",
+		2: "I never recieved the package",
+		3: "Dinner at eight",
+		4: "Invoice attached, please recieve",
+	}
+	if ok, err := db.HasFuzzyIndex(ctx); err != nil || ok {
+		t.Fatalf("expected no fuzzy index in a new sidecar, got %v %v", ok, err)
+	}
+	terms := map[int64][]string{}
+	for id, text := range texts {
+		terms[id] = Terms(text)
+	}
+	if err := db.ReplaceFuzzyTerms(ctx, []int64{1, 2, 3, 4}, terms); err != nil {
+		t.Fatalf("replace terms: %v", err)
+	}
+	if ok, err := db.HasFuzzyIndex(ctx); err != nil || !ok {
+		t.Fatalf("expected stored words to count as a fuzzy index, got %v %v", ok, err)
+	}
+
+	ids := func(hits []FuzzyHit) []int64 {
+		out := []int64{}
+		for _, hit := range hits {
+			out = append(out, hit.MessageID)
+		}
+		return out
+	}
+	tests := []struct {
+		query string
+		want  []int64
+	}{
+		{"recieve", []int64{4, 2, 1}},
+		{"receive invoce", []int64{1, 4}},
+		{"diner", []int64{3}},
+		{"package invoice", []int64{}},
+		{"!!", []int64{}},
+	}
+	for _, tt := range tests {
+		hits, err := db.SearchFuzzy(ctx, tt.query, 0)
+		if err != nil {
+			t.Fatalf("search %q: %v", tt.query, err)
+		}
+		if got := ids(hits); !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("search %q: got %v, want %v", tt.query, got, tt.want)
+		}
+	}
+
+	hits, err := db.SearchFuzzy(ctx, "recieve", 0)
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if hits[0].Score != 1 || hits[1].Score >= 1 || hits[1].Score < DefaultFuzzySimilarity {
+		t.Fatalf("scores = %+v", hits)
+	}
+
+	if err := db.ReplaceFuzzyTerms(ctx, []int64{4}, nil); err != nil {
+		t.Fatalf("replace again: %v", err)
+	}
+	hits, err = db.SearchFuzzy(ctx, "recieve", 0)
+	if err != nil {
+		t.Fatalf("search after replace: %v", err)
+	}
+	if got := ids(hits); !reflect.DeepEqual(got, []int64{2, 1}) {
+		t.Fatalf("after replace: got %v", got)
+	}
+}
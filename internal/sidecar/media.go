@@ -0,0 +1,100 @@
+package sidecar
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+)
+
+// Media is the searchable text of a media message: its file name, caption,
+// and alt text.
+type Media struct {
+	MessageID int64  `json:"messageId"`
+	EventID   string `json:"eventId"`
+	ThreadID  string `json:"threadId"`
+	Type      string `json:"type"`
+	FileName  string `json:"fileName,omitempty"`
+	Caption   string `json:"caption,omitempty"`
+	AltText   string `json:"altText,omitempty"`
+}
+
+// ReplaceMedia deletes indexed media for messageIDs and indexes media in
+// their place, in a single transaction.
+func (d *DB) ReplaceMedia(ctx context.Context, messageIDs []int64, media []Media) error {
+	ids, err := json.Marshal(messageIDs)
+	if err != nil {
+		return err
+	}
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, table := range []string{"DELETE FROM media WHERE message_id", "DELETE FROM media_fts WHERE rowid"} {
+		if _, err := tx.ExecContext(ctx, table+" IN (SELECT value FROM json_each(?))", string(ids)); err != nil {
+			return err
+		}
+	}
+	insert, err := tx.PrepareContext(ctx, `INSERT INTO media (message_id, event_id, thread_id, type, file_name, caption, alt_text)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = insert.Close() }()
+	index, err := tx.PrepareContext(ctx, "INSERT INTO media_fts (rowid, file_name, caption, alt_text) VALUES (?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = index.Close() }()
+	for _, m := range media {
+		if _, err := insert.ExecContext(ctx, m.MessageID, m.EventID, m.ThreadID, m.Type, m.FileName, m.Caption, m.AltText); err != nil {
+			return err
+		}
+		if _, err := index.ExecContext(ctx, m.MessageID, m.FileName, m.Caption, m.AltText); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// SearchMedia returns IDs of media messages whose file name, caption, or
+// alt text match query (FTS4 syntax, or FTS5 with the porter tokenizer),
+// optionally restricted to one message type. A query that is not valid
+// syntax is retried as a plain phrase.
+func (d *DB) SearchMedia(ctx context.Context, query string, msgType string) ([]int64, error) {
+	ids, err := d.searchMedia(ctx, query, msgType)
+	if err != nil && (strings.Contains(err.Error(), "malformed MATCH") || strings.Contains(err.Error(), "fts5: syntax error")) {
+		phrase := `"` + strings.ReplaceAll(query, `"`, " ") + `"`
+		return d.searchMedia(ctx, phrase, msgType)
+	}
+	return ids, err
+}
+
+func (d *DB) searchMedia(ctx context.Context, query string, msgType string) ([]int64, error) {
+	sqlQuery := `SELECT m.message_id FROM media_fts f
+		JOIN media m ON m.message_id = f.rowid
+		WHERE media_fts MATCH ?`
+	args := []any{query}
+	if msgType != "" {
+		sqlQuery += " AND m.type = ?"
+		args = append(args, strings.ToUpper(msgType))
+	}
+	sqlQuery += " ORDER BY m.message_id"
+
+	rows, err := d.db.QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,54 @@
+package sidecar
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSearchMedia(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "sidecar.db"))
+	if err != nil {
+		t.Fatalf("open sidecar: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	ctx := context.Background()
+
+	err = db.ReplaceMedia(ctx, []int64{1, 2, 3}, []Media{
+		{MessageID: 1, Type: "FILE", FileName: "contract.pdf", Caption: "signed rent agreement"},
+		{MessageID: 2, Type: "IMAGE", FileName: "IMG_0001.jpg", AltText: "Rent receipt from März"},
+		{MessageID: 3, Type: "FILE", FileName: "notes.txt"},
+	})
+	if err != nil {
+		t.Fatalf("replace media: %v", err)
+	}
+
+	tests := []struct {
+		query   string
+		msgType string
+		want    []int64
+	}{
+		{"contract.pdf", "", []int64{1}},
+		{"rent", "", []int64{1, 2}},
+		{"rent", "file", []int64{1}},
+		{"marz", "", []int64{2}},
+		{`"unbalanced`, "", []int64{}},
+	}
+	for _, tt := range tests {
+		ids, err := db.SearchMedia(ctx, tt.query, tt.msgType)
+		if err != nil {
+			t.Fatalf("search %q: %v", tt.query, err)
+		}
+		if !reflect.DeepEqual(ids, tt.want) {
+			t.Fatalf("search %q/%q: got %v, want %v", tt.query, tt.msgType, ids, tt.want)
+		}
+	}
+
+	if err := db.ReplaceMedia(ctx, []int64{1}, nil); err != nil {
+		t.Fatalf("replace again: %v", err)
+	}
+	if ids, _ := db.SearchMedia(ctx, "contract", ""); len(ids) != 0 {
+		t.Fatalf("expected re-indexed message to be gone, got %v", ids)
+	}
+}
@@ -0,0 +1,82 @@
+package sidecar
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// IndexState is the checkpoint of one derived index: everything up to
+// LastMessageID has been processed. Messages and Stored are running totals
+// since the index was first built. Source names the Beeper database an
+// index was built from, for indexes that are only valid against it.
+type IndexState struct {
+	Name          string    `json:"name"`
+	LastMessageID int64     `json:"lastMessageId"`
+	Messages      int       `json:"messages"`
+	Stored        int       `json:"stored"`
+	Source        string    `json:"source,omitempty"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// IndexState returns the checkpoint of the named index; ok is false if it
+// has never been built.
+func (d *DB) IndexState(ctx context.Context, name string) (IndexState, bool, error) {
+	state := IndexState{Name: name}
+	var updated int64
+	err := d.db.QueryRowContext(ctx, "SELECT last_message_id, messages, stored, source, updated_at FROM index_state WHERE name = ?", name).
+		Scan(&state.LastMessageID, &state.Messages, &state.Stored, &state.Source, &updated)
+	if errors.Is(err, sql.ErrNoRows) {
+		return state, false, nil
+	}
+	if err != nil {
+		return state, false, err
+	}
+	state.UpdatedAt = time.UnixMilli(updated)
+	return state, true, nil
+}
+
+// IndexStates returns the checkpoints of every index built so far, by name.
+func (d *DB) IndexStates(ctx context.Context) ([]IndexState, error) {
+	rows, err := d.db.QueryContext(ctx, "SELECT name, last_message_id, messages, stored, source, updated_at FROM index_state ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	states := []IndexState{}
+	for rows.Next() {
+		var state IndexState
+		var updated int64
+		if err := rows.Scan(&state.Name, &state.LastMessageID, &state.Messages, &state.Stored, &state.Source, &updated); err != nil {
+			return nil, err
+		}
+		state.UpdatedAt = time.UnixMilli(updated)
+		states = append(states, state)
+	}
+	return states, rows.Err()
+}
+
+// SaveIndexState records a checkpoint, stamping it with the current time.
+func (d *DB) SaveIndexState(ctx context.Context, state IndexState) error {
+	return saveIndexState(ctx, d.db, state)
+}
+
+// execer is implemented by *sql.DB and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+func saveIndexState(ctx context.Context, db execer, state IndexState) error {
+	_, err := db.ExecContext(ctx, `INSERT INTO index_state (name, last_message_id, messages, stored, source, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET
+			last_message_id = excluded.last_message_id,
+			messages = excluded.messages,
+			stored = excluded.stored,
+			source = excluded.source,
+			updated_at = excluded.updated_at`,
+		state.Name, state.LastMessageID, state.Messages, state.Stored, state.Source, time.Now().UnixMilli())
+	return err
+}
@@ -0,0 +1,45 @@
+package sidecar
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestIndexState(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "sidecar.db"))
+	if err != nil {
+		t.Fatalf("open sidecar: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	ctx := context.Background()
+
+	if _, ok, err := db.IndexState(ctx, "entities"); err != nil || ok {
+		t.Fatalf("expected no state yet, got ok=%v err=%v", ok, err)
+	}
+	if err := db.SaveIndexState(ctx, IndexState{Name: "entities", LastMessageID: 10, Messages: 10, Stored: 4}); err != nil {
+		t.Fatalf("save state: %v", err)
+	}
+	if err := db.SaveIndexState(ctx, IndexState{Name: "entities", LastMessageID: 25, Messages: 25, Stored: 9}); err != nil {
+		t.Fatalf("save state: %v", err)
+	}
+	if err := db.SaveIndexState(ctx, IndexState{Name: "media", LastMessageID: 3, Messages: 1, Stored: 1}); err != nil {
+		t.Fatalf("save state: %v", err)
+	}
+
+	state, ok, err := db.IndexState(ctx, "entities")
+	if err != nil || !ok {
+		t.Fatalf("load state: ok=%v err=%v", ok, err)
+	}
+	if state.LastMessageID != 25 || state.Messages != 25 || state.Stored != 9 || state.UpdatedAt.IsZero() {
+		t.Fatalf("unexpected state: %+v", state)
+	}
+
+	states, err := db.IndexStates(ctx)
+	if err != nil {
+		t.Fatalf("list states: %v", err)
+	}
+	if len(states) != 2 || states[0].Name != "entities" || states[1].Name != "media" {
+		t.Fatalf("unexpected states: %+v", states)
+	}
+}
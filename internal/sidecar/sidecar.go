@@ -0,0 +1,156 @@
+package sidecar
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	_ "github.com/mattn/go-sqlite3" // sqlite3 driver
+)
+
+// DB is a handle to the sidecar database.
+type DB struct {
+	db   *sql.DB
+	path string
+}
+
+// migrations are applied in order; PRAGMA user_version records how many ran.
+var migrations = []string{
+	`CREATE TABLE entities (
+		message_id INTEGER NOT NULL,
+		event_id TEXT NOT NULL,
+		thread_id TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		text TEXT NOT NULL,
+		norm TEXT NOT NULL,
+		start_offset INTEGER NOT NULL,
+		end_offset INTEGER NOT NULL
+	);
+	CREATE INDEX entities_norm ON entities (norm, kind);
+	CREATE INDEX entities_message ON entities (message_id);`,
+	`CREATE TABLE media (
+		message_id INTEGER PRIMARY KEY,
+		event_id TEXT NOT NULL,
+		thread_id TEXT NOT NULL,
+		type TEXT NOT NULL,
+		file_name TEXT NOT NULL,
+		caption TEXT NOT NULL,
+		alt_text TEXT NOT NULL
+	);
+	CREATE VIRTUAL TABLE media_fts USING fts4(file_name, caption, alt_text, tokenize=unicode61 "remove_diacritics=1");`,
+	`CREATE TABLE embeddings (
+		message_id INTEGER NOT NULL,
+		model TEXT NOT NULL,
+		event_id TEXT NOT NULL,
+		thread_id TEXT NOT NULL,
+		vector BLOB NOT NULL,
+		PRIMARY KEY (model, message_id)
+	);`,
+	`CREATE TABLE index_state (
+		name TEXT PRIMARY KEY,
+		last_message_id INTEGER NOT NULL,
+		messages INTEGER NOT NULL,
+		stored INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL
+	);`,
+	`CREATE TABLE settings (
+		key TEXT PRIMARY KEY,
+		value TEXT NOT NULL
+	);`,
+	`CREATE TABLE fuzzy_terms (
+		id INTEGER PRIMARY KEY,
+		term TEXT NOT NULL UNIQUE
+	);
+	CREATE TABLE fuzzy_trigrams (
+		trigram TEXT NOT NULL,
+		term_id INTEGER NOT NULL,
+		PRIMARY KEY (trigram, term_id)
+	) WITHOUT ROWID;
+	CREATE TABLE fuzzy_postings (
+		term_id INTEGER NOT NULL,
+		message_id INTEGER NOT NULL,
+		PRIMARY KEY (term_id, message_id)
+	) WITHOUT ROWID;
+	CREATE INDEX fuzzy_postings_message ON fuzzy_postings (message_id);`,
+	`CREATE TABLE bookmarks (
+		event_id TEXT PRIMARY KEY,
+		thread_id TEXT NOT NULL,
+		note TEXT NOT NULL,
+		created_at INTEGER NOT NULL
+	);`,
+	`CREATE TABLE thread_summaries (
+		thread_id TEXT PRIMARY KEY,
+		last_message_time INTEGER,
+		latest_hs_order INTEGER,
+		total_messages INTEGER NOT NULL,
+		bridge_name TEXT NOT NULL
+	);`,
+	`ALTER TABLE index_state ADD COLUMN source TEXT NOT NULL DEFAULT '';`,
+}
+
+// Open opens (creating if needed) the sidecar database at path and applies
+// pending migrations.
+func Open(path string) (*DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+	dsn := fmt.Sprintf("file:%s?_busy_timeout=5000&_journal_mode=WAL", path)
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	d := &DB{db: db, path: path}
+	if err := d.migrate(context.Background()); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("migrate sidecar %s: %w", path, err)
+	}
+	return d, nil
+}
+
+// Close closes the database.
+func (d *DB) Close() error {
+	if d == nil || d.db == nil {
+		return nil
+	}
+	return d.db.Close()
+}
+
+// Path returns the sidecar file path.
+func (d *DB) Path() string {
+	return d.path
+}
+
+func (d *DB) migrate(ctx context.Context) error {
+	var version int
+	if err := d.db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("schema version %d is newer than this build supports (%d)", version, len(migrations))
+	}
+	for i := version; i < len(migrations); i++ {
+		tx, err := d.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, migrations[i]); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package sidecar
+
+import (
+	"context"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+)
+
+// ReplaceThreadSummaries deletes every cached summary and stores summaries
+// in their place, recording state as the cache's checkpoint in the same
+// transaction.
+func (d *DB) ReplaceThreadSummaries(ctx context.Context, summaries []beeper.ThreadSummary, state IndexState) error {
+	return d.saveThreadSummaries(ctx, summaries, state, true)
+}
+
+// MergeThreadSummaries folds summaries of newer messages into the cache:
+// times take the maximum, counts are added, and a bridge name replaces the
+// cached one. Merging is not idempotent, so the checkpoint state is saved
+// in the same transaction.
+func (d *DB) MergeThreadSummaries(ctx context.Context, summaries []beeper.ThreadSummary, state IndexState) error {
+	return d.saveThreadSummaries(ctx, summaries, state, false)
+}
+
+func (d *DB) saveThreadSummaries(ctx context.Context, summaries []beeper.ThreadSummary, state IndexState, replace bool) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if replace {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM thread_summaries"); err != nil {
+			return err
+		}
+	}
+	insert, err := tx.PrepareContext(ctx, `INSERT INTO thread_summaries (thread_id, last_message_time, latest_hs_order, total_messages, bridge_name)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (thread_id) DO UPDATE SET
+			last_message_time = COALESCE(MAX(last_message_time, excluded.last_message_time), last_message_time, excluded.last_message_time),
+			latest_hs_order = COALESCE(MAX(latest_hs_order, excluded.latest_hs_order), latest_hs_order, excluded.latest_hs_order),
+			total_messages = total_messages + excluded.total_messages,
+			bridge_name = COALESCE(NULLIF(excluded.bridge_name, ''), bridge_name)`)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = insert.Close() }()
+	for _, s := range summaries {
+		if _, err := insert.ExecContext(ctx, s.ThreadID, s.LastMessageTime, s.LatestHsOrder, s.TotalMessages, s.BridgeName); err != nil {
+			return err
+		}
+	}
+	if err := saveIndexState(ctx, tx, state); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ThreadSummaries returns every cached summary, by thread ID.
+func (d *DB) ThreadSummaries(ctx context.Context) ([]beeper.ThreadSummary, error) {
+	rows, err := d.db.QueryContext(ctx, `SELECT thread_id, last_message_time, latest_hs_order, total_messages, bridge_name
+		FROM thread_summaries ORDER BY thread_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	summaries := []beeper.ThreadSummary{}
+	for rows.Next() {
+		var s beeper.ThreadSummary
+		if err := rows.Scan(&s.ThreadID, &s.LastMessageTime, &s.LatestHsOrder, &s.TotalMessages, &s.BridgeName); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries, rows.Err()
+}
@@ -0,0 +1,62 @@
+package sidecar
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+)
+
+func TestThreadSummaries(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "sidecar.db"))
+	if err != nil {
+		t.Fatalf("open sidecar: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	ctx := context.Background()
+
+	ms := func(v int64) sql.NullInt64 { return sql.NullInt64{Int64: v, Valid: true} }
+	if err := db.ReplaceThreadSummaries(ctx, []beeper.ThreadSummary{
+		{ThreadID: "!a", LastMessageTime: ms(100), LatestHsOrder: ms(5), TotalMessages: 3, BridgeName: "Alice"},
+		{ThreadID: "!b", TotalMessages: 0},
+	}, IndexState{Name: "threads", LastMessageID: 10}); err != nil {
+		t.Fatalf("replace: %v", err)
+	}
+	if err := db.MergeThreadSummaries(ctx, []beeper.ThreadSummary{
+		{ThreadID: "!a", LastMessageTime: ms(200), LatestHsOrder: ms(4), TotalMessages: 2},
+		{ThreadID: "!b", LastMessageTime: ms(50), TotalMessages: 1},
+		{ThreadID: "!c", LatestHsOrder: ms(1), TotalMessages: 0, BridgeName: "Carol"},
+	}, IndexState{Name: "threads", LastMessageID: 12, Source: "/data/index.db"}); err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+
+	summaries, err := db.ThreadSummaries(ctx)
+	if err != nil {
+		t.Fatalf("summaries: %v", err)
+	}
+	want := []beeper.ThreadSummary{
+		{ThreadID: "!a", LastMessageTime: ms(200), LatestHsOrder: ms(5), TotalMessages: 5, BridgeName: "Alice"},
+		{ThreadID: "!b", LastMessageTime: ms(50), TotalMessages: 1},
+		{ThreadID: "!c", LatestHsOrder: ms(1), BridgeName: "Carol"},
+	}
+	if len(summaries) != len(want) {
+		t.Fatalf("expected %d summaries, got %+v", len(want), summaries)
+	}
+	for i := range want {
+		if summaries[i] != want[i] {
+			t.Fatalf("summary %d: got %+v, want %+v", i, summaries[i], want[i])
+		}
+	}
+	if state, ok, err := db.IndexState(ctx, "threads"); err != nil || !ok || state.LastMessageID != 12 || state.Source != "/data/index.db" {
+		t.Fatalf("expected the checkpoint saved with the merge, got %+v %v %v", state, ok, err)
+	}
+
+	if err := db.ReplaceThreadSummaries(ctx, nil, IndexState{Name: "threads", LastMessageID: 12}); err != nil {
+		t.Fatalf("replace: %v", err)
+	}
+	if summaries, err := db.ThreadSummaries(ctx); err != nil || len(summaries) != 0 {
+		t.Fatalf("expected replace to clear the cache, got %+v %v", summaries, err)
+	}
+}
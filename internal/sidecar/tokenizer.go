@@ -0,0 +1,114 @@
+package sidecar
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultTokenizer is the tokenizer the media search index (file names,
+// captions, and alt text of attachments) is created with: Unicode word
+// boundaries, case folding, and diacritics removal, no stemming. Message
+// text is searched in Beeper's own index, which no tokenizer setting
+// touches.
+const DefaultTokenizer = "unicode61"
+
+// Tokenizers lists the accepted tokenizer specs. porter stems English words
+// (runs, running → run) on top of unicode61 and needs an SQLite built with
+// FTS5; icu segments and folds case by locale (icu:tr_TR handles the dotted
+// and dotless i) but needs an SQLite built with ICU.
+var Tokenizers = []string{"unicode61", "porter", "simple", "icu", "icu:<locale>"}
+
+const tokenizerKey = "fts_tokenizer"
+
+var localePattern = regexp.MustCompile(`^[A-Za-z]{2,3}(_[A-Za-z0-9]+)*$`)
+
+// tokenizerSQL returns the full-text module and tokenize clause for a
+// tokenizer spec. porter uses FTS5, whose porter stemmer can wrap
+// unicode61; FTS4's only wraps ASCII folding. The rest stay on FTS4, which
+// has the icu tokenizer.
+func tokenizerSQL(spec string) (module, tokenize string, err error) {
+	switch spec {
+	case "unicode61":
+		return "fts4", `unicode61 "remove_diacritics=1"`, nil
+	case "porter":
+		return "fts5", `'porter unicode61 remove_diacritics 2'`, nil
+	case "simple", "icu":
+		return "fts4", spec, nil
+	}
+	if locale, ok := strings.CutPrefix(spec, "icu:"); ok && localePattern.MatchString(locale) {
+		return "fts4", "icu " + locale, nil
+	}
+	return "", "", fmt.Errorf("invalid tokenizer %q (expected %s)", spec, strings.Join(Tokenizers, ", "))
+}
+
+// mediaIndexModule returns the full-text module media_fts was created with.
+func (d *DB) mediaIndexModule(ctx context.Context) (string, error) {
+	var create string
+	if err := d.db.QueryRowContext(ctx, "SELECT sql FROM sqlite_master WHERE name = 'media_fts'").Scan(&create); err != nil {
+		return "", err
+	}
+	if strings.Contains(strings.ToLower(create), "using fts5") {
+		return "fts5", nil
+	}
+	return "fts4", nil
+}
+
+// Tokenizer returns the tokenizer spec of the media search index.
+func (d *DB) Tokenizer(ctx context.Context) (string, error) {
+	var spec string
+	err := d.db.QueryRowContext(ctx, "SELECT value FROM settings WHERE key = ?", tokenizerKey).Scan(&spec)
+	if errors.Is(err, sql.ErrNoRows) {
+		return DefaultTokenizer, nil
+	}
+	return spec, err
+}
+
+// SetTokenizer recreates the media search index with the given tokenizer
+// and re-indexes the stored media into it, in a single transaction. It
+// reports whether anything changed; setting the current tokenizer is a
+// no-op unless the index still uses another full-text module, as porter
+// indexes built with FTS4 do.
+func (d *DB) SetTokenizer(ctx context.Context, spec string) (bool, error) {
+	module, tokenize, err := tokenizerSQL(spec)
+	if err != nil {
+		return false, err
+	}
+	current, err := d.Tokenizer(ctx)
+	if err != nil {
+		return false, err
+	}
+	if current == spec {
+		currentModule, err := d.mediaIndexModule(ctx)
+		if err != nil || currentModule == module {
+			return false, err
+		}
+	}
+
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, "DROP TABLE media_fts"); err != nil {
+		return false, err
+	}
+	create := fmt.Sprintf("CREATE VIRTUAL TABLE media_fts USING %s(file_name, caption, alt_text, tokenize=%s)", module, tokenize)
+	if _, err := tx.ExecContext(ctx, create); err != nil {
+		if strings.Contains(err.Error(), "unknown tokenizer") || strings.Contains(err.Error(), "no such module") {
+			return false, fmt.Errorf("tokenizer %q is not available in this SQLite build: %w", spec, err)
+		}
+		return false, err
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO media_fts (rowid, file_name, caption, alt_text) SELECT message_id, file_name, caption, alt_text FROM media"); err != nil {
+		return false, err
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO settings (key, value) VALUES (?, ?) ON CONFLICT (key) DO UPDATE SET value = excluded.value", tokenizerKey, spec); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
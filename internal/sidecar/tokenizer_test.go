@@ -0,0 +1,80 @@
+package sidecar
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSetTokenizer(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "sidecar.db"))
+	if err != nil {
+		t.Fatalf("open sidecar: %v", err)
+	}
+	defer func() { _ = db.Close() }()
+	ctx := context.Background()
+
+	if err := db.ReplaceMedia(ctx, []int64{1, 2}, []Media{
+		{MessageID: 1, Type: "VIDEO", FileName: "running.mp4"},
+		{MessageID: 2, Type: "IMAGE", FileName: "beach.jpg", Caption: "she runs"},
+	}); err != nil {
+		t.Fatalf("replace media: %v", err)
+	}
+
+	spec, err := db.Tokenizer(ctx)
+	if err != nil || spec != DefaultTokenizer {
+		t.Fatalf("expected default tokenizer, got %q (%v)", spec, err)
+	}
+	ids, err := db.SearchMedia(ctx, "run", "")
+	if err != nil {
+		t.Fatalf("search media: %v", err)
+	}
+	if len(ids) != 0 {
+		t.Fatalf("expected no stemmed matches with %s, got %v", DefaultTokenizer, ids)
+	}
+
+	for _, bad := range []string{"snowball", "icu:../x", ""} {
+		if _, err := db.SetTokenizer(ctx, bad); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+
+	changed, err := db.SetTokenizer(ctx, "porter")
+	if err != nil && strings.Contains(err.Error(), "no such module: fts5") {
+		t.Skipf("fts5 not available: %v", err)
+	}
+	if err != nil || !changed {
+		t.Fatalf("set tokenizer: changed=%v err=%v", changed, err)
+	}
+	ids, err = db.SearchMedia(ctx, "run", "")
+	if err != nil {
+		t.Fatalf("search media: %v", err)
+	}
+	if !reflect.DeepEqual(ids, []int64{1, 2}) {
+		t.Fatalf("expected stemmed matches [1 2], got %v", ids)
+	}
+	if changed, err := db.SetTokenizer(ctx, "porter"); err != nil || changed {
+		t.Fatalf("expected setting the same tokenizer to be a no-op, got changed=%v err=%v", changed, err)
+	}
+
+	// New media goes into the rebuilt index.
+	if err := db.ReplaceMedia(ctx, []int64{3}, []Media{{MessageID: 3, Type: "FILE", FileName: "notes.txt", Caption: "runner"}}); err != nil {
+		t.Fatalf("replace media: %v", err)
+	}
+	if ids, err := db.SearchMedia(ctx, "runner", ""); err != nil || !reflect.DeepEqual(ids, []int64{3}) {
+		t.Fatalf("expected [3], got %v (%v)", ids, err)
+	}
+
+	// porter folds case and diacritics beyond ASCII before stemming.
+	if err := db.ReplaceMedia(ctx, []int64{4}, []Media{{MessageID: 4, Type: "IMAGE", FileName: "ÜBERGÄNGE.jpg"}}); err != nil {
+		t.Fatalf("replace media: %v", err)
+	}
+	if ids, err := db.SearchMedia(ctx, "ubergange", ""); err != nil || !reflect.DeepEqual(ids, []int64{4}) {
+		t.Fatalf("expected [4], got %v (%v)", ids, err)
+	}
+	if spec, _ := db.Tokenizer(ctx); spec != "porter" {
+		t.Fatalf("expected porter to remain, got %q", spec)
+	}
+}
@@ -0,0 +1,7 @@
+// Package synth generates synthetic Beeper databases: an index.db with
+// threads, participants, read state, and messages (text, media, replies,
+// Matrix threads, reactions, deletions), plus per-platform megabridge.db
+// files naming DM partners. The data is fake but shaped like a real
+// account, for tests and for benchmarking the CLI before pointing it at
+// real chats.
+package synth
@@ -0,0 +1,675 @@
+package synth
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3" // sqlite3 driver
+)
+
+// Schema is the built-in subset of Beeper's index.db schema that the CLI
+// reads.
+const Schema = `
+CREATE TABLE threads (threadID TEXT PRIMARY KEY, accountID TEXT, thread JSON NOT NULL, timestamp INTEGER DEFAULT 0);
+CREATE TABLE breadcrumbs (id TEXT PRIMARY KEY, lastOpenTime INTEGER);
+CREATE TABLE participants (account_id TEXT NOT NULL, room_id TEXT NOT NULL, id TEXT NOT NULL, full_name TEXT, nickname TEXT, is_self INTEGER);
+CREATE TABLE mx_room_messages (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	roomID TEXT NOT NULL,
+	eventID TEXT NOT NULL,
+	senderContactID TEXT NOT NULL,
+	timestamp INTEGER NOT NULL,
+	isDeleted INTEGER NOT NULL DEFAULT 0,
+	type TEXT NOT NULL,
+	hsOrder INTEGER NOT NULL,
+	isSentByMe INTEGER NOT NULL,
+	message JSON,
+	text_content TEXT
+);
+CREATE INDEX mx_room_messages_room_ts ON mx_room_messages (roomID, timestamp);
+`
+
+// BridgeSchema is the subset of a megabridge.db the CLI reads.
+const BridgeSchema = `
+CREATE TABLE portal (mxid TEXT, other_user_id TEXT);
+CREATE TABLE ghost (id TEXT, name TEXT);
+`
+
+// SelfID and SelfName identify the account owner in generated databases.
+// The name must not be a common word, or mention detection would match it
+// in ordinary text.
+const (
+	SelfID   = "@me:beeper.local"
+	SelfName = "Robin Vale"
+)
+
+// DefaultPlatforms are the accounts threads are spread across.
+var DefaultPlatforms = []string{"whatsapp", "telegram", "signal", "instagram", "discord"}
+
+// Options controls Generate.
+type Options struct {
+	// Dir receives index.db and, with Bridges, local-<platform>/megabridge.db.
+	Dir      string
+	Threads  int
+	Messages int
+	// Platforms are account IDs; threads are assigned round-robin.
+	Platforms []string
+	// Days is the history span, ending at End (default: now).
+	Days int
+	End  time.Time
+	// Seed makes the output reproducible.
+	Seed int64
+	// FTS creates and fills Beeper's mx_room_messages_fts (FTS5) table. It
+	// is skipped when the sqlite3 build lacks FTS5.
+	FTS bool
+	// Bridges writes megabridge.db files naming DM partners.
+	Bridges bool
+	// Schema replaces the built-in CREATE statements, e.g. to mimic another
+	// Beeper version. Only columns that exist are filled; tables that do
+	// not exist are skipped.
+	Schema string
+}
+
+// Result describes a generated database.
+type Result struct {
+	Path         string   `json:"path"`
+	BridgeDBs    []string `json:"bridgeDbs,omitempty"`
+	Threads      int      `json:"threads"`
+	Participants int      `json:"participants"`
+	Messages     int      `json:"messages"`
+	FTS          bool     `json:"fts"`
+	// Skipped lists tables missing from a custom schema.
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+type thread struct {
+	id       string
+	platform string
+	group    bool
+	title    string
+	members  []int // contact indexes, without the owner
+	messages int
+	// named DMs have a participants row with the partner's name; others
+	// are only named by the bridge.
+	named bool
+}
+
+type message struct {
+	thread int32
+	sender int32 // -1 is the owner
+	ts     int64
+}
+
+// Generate writes a synthetic index.db into opts.Dir, replacing any
+// existing one.
+func Generate(ctx context.Context, opts Options) (Result, error) {
+	if opts.Threads <= 0 {
+		return Result{}, errors.New("at least one thread is required")
+	}
+	if opts.Messages < 0 {
+		return Result{}, errors.New("message count must not be negative")
+	}
+	if len(opts.Platforms) == 0 {
+		opts.Platforms = DefaultPlatforms
+	}
+	if opts.Days <= 0 {
+		opts.Days = 365
+	}
+	if opts.End.IsZero() {
+		opts.End = time.Now()
+	}
+	if opts.Schema == "" {
+		opts.Schema = Schema
+	}
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return Result{}, err
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	threads, contacts := planThreads(rng, opts)
+	messages := planMessages(rng, opts, threads)
+
+	result := Result{Path: filepath.Join(opts.Dir, "index.db"), Threads: len(threads), Messages: len(messages)}
+	for _, suffix := range []string{"", "-wal", "-shm", "-journal"} {
+		if err := os.Remove(result.Path + suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return result, err
+		}
+	}
+	db, err := sql.Open("sqlite3", "file:"+result.Path+"?_journal_mode=OFF&_synchronous=OFF")
+	if err != nil {
+		return result, err
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	db.SetMaxOpenConns(1)
+	if _, err := db.ExecContext(ctx, opts.Schema); err != nil {
+		return result, fmt.Errorf("create schema: %w", err)
+	}
+
+	w, err := newWriter(ctx, db)
+	if err != nil {
+		return result, err
+	}
+	result.Skipped = w.skipped
+	if result.Participants, err = w.writeParticipants(ctx, threads, contacts); err != nil {
+		return result, err
+	}
+	lastOrder, lastTS, err := w.writeMessages(ctx, rng, threads, messages)
+	if err != nil {
+		return result, err
+	}
+	if err := w.writeThreads(ctx, rng, threads, lastOrder, lastTS); err != nil {
+		return result, err
+	}
+	if err := w.tx.Commit(); err != nil {
+		return result, err
+	}
+
+	if opts.FTS {
+		result.FTS, err = writeFTS(ctx, db)
+		if err != nil {
+			return result, err
+		}
+	}
+	if opts.Bridges {
+		result.BridgeDBs, err = writeBridges(ctx, opts, threads, contacts)
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// planThreads creates threads and the contacts that take part in them.
+// About 60% are DMs; groups have 3-12 members drawn from a shared pool, so
+// contacts appear in several threads.
+func planThreads(rng *rand.Rand, opts Options) ([]thread, []string) {
+	pool := max(opts.Threads, 20)
+	contacts := make([]string, pool)
+	for i := range contacts {
+		contacts[i] = personName(i)
+	}
+
+	threads := make([]thread, opts.Threads)
+	for i := range threads {
+		t := &threads[i]
+		t.platform = opts.Platforms[i%len(opts.Platforms)]
+		t.id = fmt.Sprintf("!syn%05d%s:beeper.local", i, t.platform)
+		t.group = rng.Intn(10) < 4
+		if t.group {
+			t.title = groupTitle(rng)
+			size := 3 + rng.Intn(10)
+			for _, c := range rng.Perm(pool)[:min(size, pool)] {
+				t.members = append(t.members, c)
+			}
+			slices.Sort(t.members)
+			t.named = true
+		} else {
+			t.members = []int{i % pool}
+			t.named = rng.Intn(2) == 0
+		}
+	}
+	return threads, contacts
+}
+
+func contactID(platform string, contact int) string {
+	return fmt.Sprintf("@%s_%d:beeper.local", platform, 4915100000000+int64(contact)*7919)
+}
+
+// planMessages distributes the messages over threads with a Zipf-like
+// skew (a few busy threads, a long tail) and over time in bursts of
+// conversation, then sorts them chronologically.
+func planMessages(rng *rand.Rand, opts Options, threads []thread) []message {
+	weights := make([]float64, len(threads))
+	total := 0.0
+	for i := range weights {
+		weights[i] = 1 / math.Pow(float64(i+1), 0.8)
+		total += weights[i]
+	}
+	remaining := opts.Messages
+	for i := range threads {
+		share := int(math.Round(float64(opts.Messages) * weights[i] / total))
+		if i == len(threads)-1 || share > remaining {
+			share = remaining
+		}
+		threads[i].messages = share
+		remaining -= share
+	}
+
+	end := opts.End.UnixMilli()
+	span := int64(opts.Days) * 24 * int64(time.Hour/time.Millisecond)
+	messages := make([]message, 0, opts.Messages)
+	for i, t := range threads {
+		for n := 0; n < t.messages; {
+			ts := end - rng.Int63n(span)
+			burst := 1 + int(rng.ExpFloat64()*8)
+			for b := 0; b < burst && n < t.messages; b++ {
+				sender := int32(-1)
+				if rng.Intn(100) >= 35 {
+					sender = int32(t.members[rng.Intn(len(t.members))])
+				}
+				messages = append(messages, message{thread: int32(i), sender: sender, ts: min(ts, end)})
+				ts += 5_000 + int64(rng.ExpFloat64()*60_000)
+				n++
+			}
+		}
+	}
+	sort.SliceStable(messages, func(a, b int) bool { return messages[a].ts < messages[b].ts })
+	return messages
+}
+
+// writer inserts rows through prepared statements restricted to the
+// columns the schema has.
+type writer struct {
+	tx      *sql.Tx
+	columns map[string][]string
+	skipped []string
+}
+
+func newWriter(ctx context.Context, db *sql.DB) (*writer, error) {
+	w := &writer{columns: map[string][]string{}}
+	for _, table := range []string{"threads", "breadcrumbs", "participants", "mx_room_messages"} {
+		cols, err := tableColumns(ctx, db, table)
+		if err != nil {
+			return nil, err
+		}
+		if len(cols) == 0 {
+			w.skipped = append(w.skipped, table)
+		}
+		w.columns[table] = cols
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	w.tx = tx
+	return w, nil
+}
+
+func tableColumns(ctx context.Context, db *sql.DB, table string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, "SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+	cols := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		cols = append(cols, name)
+	}
+	return cols, rows.Err()
+}
+
+// inserter is a prepared INSERT for the columns of table that the schema
+// has; insert takes values for all known columns by name.
+type inserter struct {
+	stmt *sql.Stmt
+	cols []string
+	args []any
+}
+
+func (w *writer) prepare(ctx context.Context, table string, known []string) (*inserter, error) {
+	cols := []string{}
+	for _, c := range known {
+		if slices.Contains(w.columns[table], c) {
+			cols = append(cols, c)
+		}
+	}
+	if len(cols) == 0 {
+		return nil, nil
+	}
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(cols, ", "), strings.TrimSuffix(strings.Repeat("?, ", len(cols)), ", "))
+	stmt, err := w.tx.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return &inserter{stmt: stmt, cols: cols, args: make([]any, len(cols))}, nil
+}
+
+func (ins *inserter) insert(ctx context.Context, values map[string]any) error {
+	if ins == nil {
+		return nil
+	}
+	for i, c := range ins.cols {
+		ins.args[i] = values[c]
+	}
+	_, err := ins.stmt.ExecContext(ctx, ins.args...)
+	return err
+}
+
+func (ins *inserter) close() {
+	if ins != nil {
+		_ = ins.stmt.Close()
+	}
+}
+
+func (w *writer) writeParticipants(ctx context.Context, threads []thread, contacts []string) (int, error) {
+	ins, err := w.prepare(ctx, "participants", []string{"account_id", "room_id", "id", "full_name", "nickname", "is_self"})
+	if err != nil {
+		return 0, err
+	}
+	defer ins.close()
+	count := 0
+	for _, t := range threads {
+		rows := []map[string]any{{"id": SelfID, "full_name": SelfName, "is_self": 1}}
+		if t.named {
+			for _, c := range t.members {
+				rows = append(rows, map[string]any{"id": contactID(t.platform, c), "full_name": contacts[c], "is_self": 0})
+			}
+		}
+		for _, row := range rows {
+			row["account_id"] = t.platform
+			row["room_id"] = t.id
+			row["nickname"] = ""
+			if err := ins.insert(ctx, row); err != nil {
+				return count, err
+			}
+			count++
+		}
+	}
+	return count, nil
+}
+
+// recentEvents remembers the last few message event IDs of a thread, as
+// targets for replies and reactions.
+type recentEvents struct {
+	ids  []string
+	root string
+}
+
+func (r *recentEvents) add(id string) {
+	if len(r.ids) == 16 {
+		r.ids = r.ids[1:]
+	}
+	r.ids = append(r.ids, id)
+}
+
+func (r *recentEvents) pick(rng *rand.Rand) string {
+	if len(r.ids) == 0 {
+		return ""
+	}
+	return r.ids[rng.Intn(len(r.ids))]
+}
+
+func (w *writer) writeMessages(ctx context.Context, rng *rand.Rand, threads []thread, messages []message) ([]int64, []int64, error) {
+	ins, err := w.prepare(ctx, "mx_room_messages", []string{"roomID", "eventID", "senderContactID", "timestamp", "isDeleted", "type", "hsOrder", "isSentByMe", "message", "text_content"})
+	if err != nil {
+		return nil, nil, err
+	}
+	defer ins.close()
+
+	order := make([]int64, len(threads))
+	last := make([]int64, len(threads))
+	recent := make([]recentEvents, len(threads))
+	row := map[string]any{}
+	for i, m := range messages {
+		if i%10000 == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, nil, err
+			}
+		}
+		t := threads[m.thread]
+		order[m.thread]++
+		last[m.thread] = m.ts
+		sender := SelfID
+		if m.sender >= 0 {
+			sender = contactID(t.platform, int(m.sender))
+		}
+		eventID := fmt.Sprintf("$syn%08d", i+1)
+		msgType, payload, text := messageContent(rng, &recent[m.thread])
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		row["roomID"] = t.id
+		row["eventID"] = eventID
+		row["senderContactID"] = sender
+		row["timestamp"] = m.ts
+		row["isDeleted"] = boolInt(rng.Intn(100) == 0)
+		row["type"] = msgType
+		row["hsOrder"] = order[m.thread]
+		row["isSentByMe"] = boolInt(m.sender < 0)
+		row["message"] = string(data)
+		row["text_content"] = text
+		if err := ins.insert(ctx, row); err != nil {
+			return nil, nil, err
+		}
+		if msgType != "REACTION" && msgType != "HIDDEN" {
+			recent[m.thread].add(eventID)
+		}
+	}
+	return order, last, nil
+}
+
+// messageContent picks a message type and builds its payload: mostly text
+// (some replying to a recent message or posted in a Matrix thread), plus
+// media, locations, reactions, and hidden events.
+func messageContent(rng *rand.Rand, recent *recentEvents) (string, map[string]any, string) {
+	roll := rng.Intn(1000)
+	switch {
+	case roll < 50 && len(recent.ids) > 0:
+		key := reactionKeys[rng.Intn(len(reactionKeys))]
+		return "REACTION", map[string]any{"m.relates_to": map[string]any{"rel_type": "m.annotation", "event_id": recent.pick(rng), "key": key}}, ""
+	case roll < 55:
+		return "HIDDEN", map[string]any{"type": "m.room.member"}, ""
+	case roll < 105:
+		caption := ""
+		if rng.Intn(3) == 0 {
+			caption = sentence(rng)
+		}
+		return "IMAGE", map[string]any{
+			"body": caption, "fileName": fmt.Sprintf("IMG_%04d.jpg", rng.Intn(10000)),
+			"info": map[string]any{"mimetype": "image/jpeg", "size": 50_000 + rng.Intn(4_000_000), "w": 1600, "h": 1200},
+			"url":  fmt.Sprintf("mxc://beeper.local/syn%x", rng.Int63()),
+		}, caption
+	case roll < 125:
+		name := fileNames[rng.Intn(len(fileNames))]
+		return "FILE", map[string]any{
+			"body": name, "filename": name,
+			"info": map[string]any{"mimetype": mimeType(name), "size": 10_000 + rng.Intn(900_000)},
+			"url":  fmt.Sprintf("mxc://beeper.local/syn%x", rng.Int63()),
+		}, ""
+	case roll < 135:
+		return "VIDEO", map[string]any{
+			"body": fmt.Sprintf("VID_%04d.mp4", rng.Intn(10000)),
+			"info": map[string]any{"mimetype": "video/mp4", "size": 1_000_000 + rng.Intn(30_000_000)},
+			"url":  fmt.Sprintf("mxc://beeper.local/syn%x", rng.Int63()),
+		}, ""
+	case roll < 145:
+		return "AUDIO", map[string]any{
+			"body": "Voice message",
+			"info": map[string]any{"mimetype": "audio/ogg", "size": 20_000 + rng.Intn(500_000), "duration": 1000 + rng.Intn(120_000)},
+			"url":  fmt.Sprintf("mxc://beeper.local/syn%x", rng.Int63()),
+		}, ""
+	case roll < 150:
+		return "STICKER", map[string]any{"body": "sticker", "url": fmt.Sprintf("mxc://beeper.local/syn%x", rng.Int63())}, ""
+	case roll < 153:
+		lat, lon := 40+rng.Float64()*15, rng.Float64()*20
+		return "LOCATION", map[string]any{"body": "Location", "geo_uri": fmt.Sprintf("geo:%.5f,%.5f", lat, lon)}, ""
+	}
+
+	text := sentence(rng)
+	payload := map[string]any{"text": text}
+	switch roll2 := rng.Intn(100); {
+	case roll2 < 8 && len(recent.ids) > 0:
+		payload["m.relates_to"] = map[string]any{"m.in_reply_to": map[string]any{"event_id": recent.pick(rng)}}
+	case roll2 < 10 && len(recent.ids) > 0:
+		if recent.root == "" || rng.Intn(4) == 0 {
+			recent.root = recent.pick(rng)
+		}
+		payload["m.relates_to"] = map[string]any{"rel_type": "m.thread", "event_id": recent.root, "is_falling_back": true,
+			"m.in_reply_to": map[string]any{"event_id": recent.ids[len(recent.ids)-1]}}
+	}
+	return "TEXT", payload, text
+}
+
+func mimeType(name string) string {
+	switch filepath.Ext(name) {
+	case ".pdf":
+		return "application/pdf"
+	case ".txt":
+		return "text/plain"
+	case ".xlsx":
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case ".docx":
+		return "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// writeThreads inserts the thread rows last, once their latest message
+// order is known: about 20% are archived up to it, 10% unread, 5% low
+// priority, and 5% favourites; 70% have been opened recently.
+func (w *writer) writeThreads(ctx context.Context, rng *rand.Rand, threads []thread, lastOrder []int64, lastTS []int64) error {
+	ins, err := w.prepare(ctx, "threads", []string{"threadID", "accountID", "thread", "timestamp"})
+	if err != nil {
+		return err
+	}
+	defer ins.close()
+	crumbs, err := w.prepare(ctx, "breadcrumbs", []string{"id", "lastOpenTime"})
+	if err != nil {
+		return err
+	}
+	defer crumbs.close()
+
+	for i, t := range threads {
+		state := map[string]any{"type": "single"}
+		if t.group {
+			state["type"] = "group"
+			state["title"] = t.title
+		}
+		extra := map[string]any{}
+		unread := rng.Intn(10) == 0
+		state["isUnread"] = boolInt(unread)
+		state["isMarkedUnread"] = boolInt(!unread && rng.Intn(50) == 0)
+		state["isLowPriority"] = boolInt(rng.Intn(20) == 0)
+		if unread {
+			state["unreadCount"] = 1 + rng.Intn(min(int(lastOrder[i])+1, 30))
+			if t.group && rng.Intn(3) == 0 {
+				state["unreadMentionsCount"] = 1
+			}
+		}
+		if !unread && lastOrder[i] > 0 && rng.Intn(5) == 0 {
+			extra["isArchivedUpToOrder"] = lastOrder[i]
+		}
+		if rng.Intn(20) == 0 {
+			extra["tags"] = []string{"favourite"}
+		}
+		if len(extra) > 0 {
+			state["extra"] = extra
+		}
+		data, err := json.Marshal(state)
+		if err != nil {
+			return err
+		}
+		if err := ins.insert(ctx, map[string]any{"threadID": t.id, "accountID": t.platform, "thread": string(data), "timestamp": lastTS[i]}); err != nil {
+			return err
+		}
+		if lastTS[i] > 0 && rng.Intn(10) < 7 {
+			if err := crumbs.insert(ctx, map[string]any{"id": t.id, "lastOpenTime": lastTS[i] + rng.Int63n(3_600_000)}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeFTS creates and fills Beeper's FTS5 message index. It reports false
+// when the sqlite3 build has no FTS5.
+func writeFTS(ctx context.Context, db *sql.DB) (bool, error) {
+	if _, err := db.ExecContext(ctx, `CREATE VIRTUAL TABLE mx_room_messages_fts USING fts5(text_content)`); err != nil {
+		if strings.Contains(err.Error(), "no such module") {
+			return false, nil
+		}
+		return false, err
+	}
+	_, err := db.ExecContext(ctx, `INSERT INTO mx_room_messages_fts (rowid, text_content)
+		SELECT id, text_content FROM mx_room_messages WHERE COALESCE(text_content, '') != ''`)
+	return err == nil, err
+}
+
+// writeBridges writes one megabridge.db per platform with a portal and a
+// ghost for every DM, so DMs without a named participant are still named.
+func writeBridges(ctx context.Context, opts Options, threads []thread, contacts []string) ([]string, error) {
+	paths := []string{}
+	for _, platform := range opts.Platforms {
+		dir := filepath.Join(opts.Dir, "local-"+platform)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return paths, err
+		}
+		path := filepath.Join(dir, "megabridge.db")
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return paths, err
+		}
+		if err := writeBridge(ctx, path, platform, threads, contacts); err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+func writeBridge(ctx context.Context, path string, platform string, threads []thread, contacts []string) error {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+	if _, err := db.ExecContext(ctx, BridgeSchema); err != nil {
+		return err
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	seen := map[int]bool{}
+	for _, t := range threads {
+		if t.platform != platform || t.group {
+			continue
+		}
+		c := t.members[0]
+		remoteID := fmt.Sprintf("%d", 4915100000000+int64(c)*7919)
+		if _, err := tx.ExecContext(ctx, "INSERT INTO portal (mxid, other_user_id) VALUES (?, ?)", t.id, remoteID); err != nil {
+			return err
+		}
+		if seen[c] {
+			continue
+		}
+		seen[c] = true
+		if _, err := tx.ExecContext(ctx, "INSERT INTO ghost (id, name) VALUES (?, ?)", remoteID, contacts[c]); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,120 @@
+package synth
+
+import (
+	"context"
+	"crypto/sha256"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/KrauseFx/beeper-cli/internal/beeper"
+)
+
+var testEnd = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func TestGenerate(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	result, err := Generate(ctx, Options{Dir: dir, Threads: 12, Messages: 2000, Days: 30, End: testEnd, Seed: 7, Bridges: true})
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if result.Threads != 12 || result.Messages != 2000 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if len(result.BridgeDBs) != len(DefaultPlatforms) {
+		t.Fatalf("expected %d bridge DBs, got %v", len(DefaultPlatforms), result.BridgeDBs)
+	}
+
+	store, err := beeper.OpenWithOptions(result.Path, beeper.StoreOptions{BridgeLookup: true})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer func() {
+		_ = store.Close()
+	}()
+
+	threads, err := store.ListThreads(ctx, beeper.ThreadListOptions{All: true, IncludeLowPriority: true})
+	if err != nil {
+		t.Fatalf("list threads: %v", err)
+	}
+	if len(threads) != 12 {
+		t.Fatalf("expected 12 threads, got %d", len(threads))
+	}
+	for _, thread := range threads {
+		if thread.DisplayName == "" || thread.DisplayName == "(unknown)" {
+			t.Fatalf("thread %s has no display name", thread.ID)
+		}
+	}
+
+	messages, err := store.ListMessages(ctx, beeper.MessageListOptions{ThreadID: threads[0].ID, Limit: 50})
+	if err != nil {
+		t.Fatalf("list messages: %v", err)
+	}
+	if len(messages) == 0 {
+		t.Fatal("expected messages in the busiest thread")
+	}
+}
+
+func TestGenerateDeterministic(t *testing.T) {
+	ctx := context.Background()
+	sums := [2][32]byte{}
+	for i := range sums {
+		dir := t.TempDir()
+		result, err := Generate(ctx, Options{Dir: dir, Threads: 5, Messages: 300, End: testEnd, Seed: 42})
+		if err != nil {
+			t.Fatalf("generate: %v", err)
+		}
+		f, err := os.Open(result.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h := sha256.New()
+		_, err = io.Copy(h, f)
+		_ = f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		copy(sums[i][:], h.Sum(nil))
+	}
+	if sums[0] != sums[1] {
+		t.Fatal("expected identical databases for the same seed")
+	}
+}
+
+func TestGenerateCustomSchema(t *testing.T) {
+	schema := `
+CREATE TABLE threads (threadID TEXT PRIMARY KEY, accountID TEXT, thread JSON NOT NULL, timestamp INTEGER DEFAULT 0);
+CREATE TABLE mx_room_messages (id INTEGER PRIMARY KEY AUTOINCREMENT, roomID TEXT, eventID TEXT, senderContactID TEXT,
+	timestamp INTEGER, isDeleted INTEGER, type TEXT, hsOrder INTEGER, isSentByMe INTEGER, message JSON, text_content TEXT, extra TEXT);
+CREATE TABLE participants (account_id TEXT, room_id TEXT, id TEXT, full_name TEXT, nickname TEXT, is_self INTEGER);
+`
+	dir := t.TempDir()
+	result, err := Generate(context.Background(), Options{Dir: dir, Threads: 3, Messages: 50, End: testEnd, Schema: schema})
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0] != "breadcrumbs" {
+		t.Fatalf("expected breadcrumbs to be skipped, got %v", result.Skipped)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "local-whatsapp")); !os.IsNotExist(err) {
+		t.Fatalf("expected no bridge DBs without Bridges, got %v", err)
+	}
+
+	store, err := beeper.Open(result.Path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer func() {
+		_ = store.Close()
+	}()
+	rows, err := store.Query(context.Background(), "SELECT count(*) FROM mx_room_messages WHERE extra IS NULL", 0)
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if got := rows.Rows[0][0]; got != int64(50) {
+		t.Fatalf("expected 50 messages, got %v", got)
+	}
+}
@@ -0,0 +1,100 @@
+package synth
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+var firstNames = []string{
+	"Alice", "Ben", "Carla", "David", "Elena", "Felix", "Greta", "Hannah", "Ivan", "Julia",
+	"Karim", "Lena", "Marco", "Nina", "Oscar", "Paula", "Quentin", "Rosa", "Sam", "Tara",
+	"Umar", "Vera", "Will", "Ximena", "Yusuf", "Zoe", "Anton", "Bea", "Chris", "Dana",
+}
+
+var lastNames = []string{
+	"Smith", "Jones", "Müller", "Rossi", "Garcia", "Nguyen", "Kowalski", "Schmidt", "Dubois", "Silva",
+	"Novak", "Kim", "Larsen", "Okafor", "Ivanova", "Cohen", "Tanaka", "Weber", "Moreau", "Costa",
+}
+
+var places = []string{
+	"Berlin", "Paris", "London", "Lisbon", "Vienna", "Barcelona", "New York", "Tokyo", "Zurich", "Amsterdam",
+}
+
+var organizations = []string{
+	"Google", "Lufthansa", "Deutsche Bahn", "Spotify", "IKEA", "Airbnb", "Apple", "Beeper",
+}
+
+var groupTopics = []string{
+	"Family", "Book Club", "Climbing", "Flat Share", "Project Atlas", "Design Team", "Running Crew",
+	"Wedding Planning", "Board Games", "Parents", "Road Trip", "Dev Chat", "Neighbours", "Football",
+}
+
+var words = strings.Fields(`the a to and of I you it for on is we that this in be have with are at
+just can will so do not what but my get was like go if about me your all up out time know think
+really good yeah ok sure maybe tomorrow today tonight later now meeting dinner lunch coffee train
+flight hotel tickets weekend office call send check plan idea great thanks sorry see soon back home
+work project deadline draft review slides budget invoice party birthday gift photos video link
+doc update quick question late early running minutes booked done sounds perfect nice love haha`)
+
+var emoji = []string{"😂", "👍", "❤️", "🎉", "🙏", "😀", "🔥", "😅", "🤔", "👀"}
+
+var reactionKeys = []string{"👍", "❤️", "😂", "😮", "😢", "🙏"}
+
+var fileNames = []string{
+	"Q3 Roadmap.pdf", "invoice-2024-11.pdf", "notes.txt", "budget.xlsx", "contract_v2.docx", "slides.key",
+}
+
+// personName is unique for the first len(firstNames)*len(lastNames) contacts
+// and spreads last names across neighbouring indexes.
+func personName(i int) string {
+	return firstNames[i%len(firstNames)] + " " + lastNames[(i+i/len(firstNames))%len(lastNames)]
+}
+
+func groupTitle(rng *rand.Rand) string {
+	switch rng.Intn(3) {
+	case 0:
+		return places[rng.Intn(len(places))] + " Trip"
+	case 1:
+		return groupTopics[rng.Intn(len(groupTopics))] + " " + fmt.Sprint(2020+rng.Intn(6))
+	default:
+		return groupTopics[rng.Intn(len(groupTopics))]
+	}
+}
+
+// sentence returns a message of 1-20 words with occasional names, places,
+// organizations, times, questions, and emoji, so search, entity
+// extraction, and word statistics have something to find.
+func sentence(rng *rand.Rand) string {
+	n := 1 + rng.Intn(12)
+	if rng.Intn(5) == 0 {
+		n += rng.Intn(8)
+	}
+	parts := make([]string, 0, n+2)
+	for i := 0; i < n; i++ {
+		parts = append(parts, words[rng.Intn(len(words))])
+	}
+	switch rng.Intn(12) {
+	case 0:
+		parts = append(parts, "in", places[rng.Intn(len(places))])
+	case 1:
+		parts = append(parts, "with", organizations[rng.Intn(len(organizations))])
+	case 2:
+		parts = append(parts, "ask", firstNames[rng.Intn(len(firstNames))])
+	case 3:
+		parts = append(parts, "at", fmt.Sprintf("%d:%02d", 7+rng.Intn(15), 15*rng.Intn(4)))
+	case 4:
+		parts = append(parts, fmt.Sprintf("https://example.com/%s", words[rng.Intn(len(words))]))
+	}
+	text := strings.Join(parts, " ")
+	text = strings.ToUpper(text[:1]) + text[1:]
+	switch rng.Intn(6) {
+	case 0:
+		text += "?"
+	case 1:
+		text += "!"
+	case 2:
+		text += " " + emoji[rng.Intn(len(emoji))]
+	}
+	return text
+}